@@ -0,0 +1,99 @@
+package mcpgrafana
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const grafanaAPIKeyFileEnvVar = "GRAFANA_API_KEY_FILE"
+
+// apiKeyFile reads a service account token from a file, re-reading it only
+// when the file's modification time or size changes. This lets tokens be
+// rotated, e.g. by a Kubernetes secret mount, without restarting the server.
+type apiKeyFile struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	token   string
+}
+
+// apiKeyFiles holds one apiKeyFile per path, so that the cached token is
+// shared by all clients.
+var apiKeyFiles sync.Map
+
+func apiKeyFileFor(path string) *apiKeyFile {
+	f, _ := apiKeyFiles.LoadOrStore(path, &apiKeyFile{path: path})
+	return f.(*apiKeyFile)
+}
+
+// Token returns the token held in the file, re-reading it if it changed since
+// the last read. If the file can't be read, the last token read is returned
+// along with the error, so that callers can keep using it.
+func (f *apiKeyFile) Token() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return f.token, fmt.Errorf("stat %s: %w", grafanaAPIKeyFileEnvVar, err)
+	}
+	if f.token != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.token, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return f.token, fmt.Errorf("read %s: %w", grafanaAPIKeyFileEnvVar, err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return f.token, fmt.Errorf("%s %s is empty", grafanaAPIKeyFileEnvVar, f.path)
+	}
+	if f.token != "" && token != f.token {
+		slog.Info("Grafana API key file changed, using the new token", "path", f.path)
+	}
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	f.token = token
+	return token, nil
+}
+
+// APIKeyFileRoundTripper sets the Authorization header of each request to the
+// token currently held in an API key file, so that rotated tokens are picked
+// up by long-lived clients.
+type APIKeyFileRoundTripper struct {
+	underlying http.RoundTripper
+	file       *apiKeyFile
+}
+
+// NewAPIKeyFileRoundTripper returns a round tripper which authenticates
+// requests with the token read from the file at path.
+func NewAPIKeyFileRoundTripper(rt http.RoundTripper, path string) *APIKeyFileRoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &APIKeyFileRoundTripper{
+		underlying: rt,
+		file:       apiKeyFileFor(path),
+	}
+}
+
+func (t *APIKeyFileRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.file.Token()
+	if err != nil {
+		if token == "" {
+			return nil, err
+		}
+		slog.Warn("Failed to re-read Grafana API key file, using the previous token", "error", err)
+	}
+	clonedReq := req.Clone(req.Context())
+	clonedReq.Header.Set("Authorization", "Bearer "+token)
+	return t.underlying.RoundTrip(clonedReq)
+}
@@ -0,0 +1,115 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTokenFile writes token to path and sets its modification time, so that
+// tests don't depend on the filesystem's timestamp resolution.
+func writeTokenFile(t *testing.T, path, token string, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(token+"\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestAPIKeyFileToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	start := time.Now().Add(-time.Hour)
+	writeTokenFile(t, path, "token-1", start)
+	f := &apiKeyFile{path: path}
+
+	token, err := f.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	t.Run("unchanged mtime uses cached token", func(t *testing.T) {
+		writeTokenFile(t, path, "token-x", start)
+		token, err := f.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+	})
+
+	t.Run("changed mtime re-reads the file", func(t *testing.T) {
+		writeTokenFile(t, path, "token-2", start.Add(time.Minute))
+		token, err := f.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token)
+	})
+
+	t.Run("missing file keeps the previous token", func(t *testing.T) {
+		require.NoError(t, os.Remove(path))
+		token, err := f.Token()
+		require.Error(t, err)
+		assert.Equal(t, "token-2", token)
+	})
+}
+
+func TestAPIKeyFileRotation(t *testing.T) {
+	var authHeaders []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"database": "ok", "version": "11.0.0"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "token")
+	start := time.Now().Add(-time.Hour)
+	writeTokenFile(t, path, "old-token", start)
+	t.Setenv("GRAFANA_URL", server.URL)
+	t.Setenv(grafanaAPIKeyFileEnvVar, path)
+	t.Setenv(grafanaServiceAccountTokenEnvVar, "env-token")
+
+	ctx := ComposedStdioContextFunc(GrafanaConfig{})(context.Background())
+	assert.Equal(t, "old-token", GrafanaConfigFromContext(ctx).APIKey)
+
+	t.Run("grafana client", func(t *testing.T) {
+		authHeaders = nil
+		c := GrafanaClientFromContext(ctx)
+		_, err := c.Health.GetHealth()
+		require.NoError(t, err)
+
+		writeTokenFile(t, path, "new-token", start.Add(time.Minute))
+		_, err = c.Health.GetHealth()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bearer old-token", "Bearer new-token"}, authHeaders)
+	})
+
+	t.Run("datasource transport", func(t *testing.T) {
+		authHeaders = nil
+		cfg := GrafanaConfigFromContext(ctx)
+		rt, err := BuildTransport(&cfg, nil)
+		require.NoError(t, err)
+		client := &http.Client{Transport: rt}
+
+		writeTokenFile(t, path, "newer-token", start.Add(2*time.Minute))
+		resp, err := client.Get(server.URL + "/api/health")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, []string{"Bearer newer-token"}, authHeaders)
+	})
+}
+
+func TestURLAndAPIKeyFromEnvWithoutFile(t *testing.T) {
+	t.Setenv(grafanaAPIKeyFileEnvVar, "")
+	t.Setenv(grafanaServiceAccountTokenEnvVar, "")
+	t.Setenv(grafanaAPIEnvVar, "legacy-key")
+	_, apiKey := urlAndAPIKeyFromEnv()
+	assert.Equal(t, "legacy-key", apiKey)
+
+	t.Setenv(grafanaAPIKeyFileEnvVar, filepath.Join(t.TempDir(), "missing"))
+	_, apiKey = urlAndAPIKeyFromEnv()
+	assert.Equal(t, "legacy-key", apiKey)
+}
@@ -0,0 +1,163 @@
+package mcpgrafana
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	grafanaAuditLogEnvVar     = "GRAFANA_AUDIT_LOG"
+	grafanaAuditLogFileEnvVar = "GRAFANA_AUDIT_LOG_FILE"
+
+	// auditUserHeader is the forwarded header identifying the user on whose
+	// behalf a tool is called.
+	auditUserHeader = "X-Grafana-User-Email"
+)
+
+// sensitiveArgumentNames are substrings of argument names whose values are
+// redacted from audit records.
+var sensitiveArgumentNames = []string{"password", "secret", "token", "apikey", "api_key", "authorization", "credential", "cookie"}
+
+// AuditRecord is a single tool call, as written to the audit log.
+type AuditRecord struct {
+	Time       time.Time      `json:"time"`
+	Tool       string         `json:"tool"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	User       string         `json:"user,omitempty"`
+	DurationMS int64          `json:"durationMs"`
+	Success    bool           `json:"success"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// AuditLogMiddlewareFromEnv returns a middleware writing an audit record for
+// every tool call if GRAFANA_AUDIT_LOG is true. Records are appended to the
+// file named by GRAFANA_AUDIT_LOG_FILE, or written to stderr by default, since
+// stdout carries the stdio transport. If audit logging is disabled the
+// middleware does nothing.
+func AuditLogMiddlewareFromEnv() server.ToolHandlerMiddleware {
+	enabled, _ := strconv.ParseBool(os.Getenv(grafanaAuditLogEnvVar))
+	if !enabled {
+		return func(next server.ToolHandlerFunc) server.ToolHandlerFunc { return next }
+	}
+	var w io.Writer = os.Stderr
+	if path := os.Getenv(grafanaAuditLogFileEnvVar); path != "" {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			slog.Error("failed to open audit log file, writing audit records to stderr", "path", path, "error", err)
+		} else {
+			w = f
+		}
+	}
+	return AuditLogMiddleware(w)
+}
+
+// AuditLogMiddleware writes an AuditRecord for every tool call to w, as one
+// JSON object per line. Sensitive arguments such as passwords and tokens are
+// redacted.
+func AuditLogMiddleware(w io.Writer) server.ToolHandlerMiddleware {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+
+			record := AuditRecord{
+				Time:       start.UTC(),
+				Tool:       request.Params.Name,
+				Arguments:  redactArguments(request.GetArguments()),
+				User:       auditUser(ctx),
+				DurationMS: time.Since(start).Milliseconds(),
+				Success:    err == nil && (result == nil || !result.IsError),
+			}
+			switch {
+			case err != nil:
+				record.Error = err.Error()
+			case result != nil && result.IsError:
+				record.Error = toolResultText(result)
+			}
+
+			mu.Lock()
+			if encErr := enc.Encode(record); encErr != nil {
+				slog.Warn("Failed to write audit record", "tool", record.Tool, "error", encErr)
+			}
+			mu.Unlock()
+
+			return result, err
+		}
+	}
+}
+
+// auditUser returns the user a tool call is made on behalf of, as forwarded
+// in the X-Grafana-User-Email header.
+func auditUser(ctx context.Context) string {
+	for name, value := range GrafanaConfigFromContext(ctx).ExtraHeaders {
+		if strings.EqualFold(name, auditUserHeader) {
+			return value
+		}
+	}
+	return ""
+}
+
+// redactArguments returns a copy of a tool call's arguments with the values
+// of sensitive arguments, at any depth, replaced with "***".
+func redactArguments(args map[string]any) map[string]any {
+	if args == nil {
+		return nil
+	}
+	redacted := make(map[string]any, len(args))
+	for name, value := range args {
+		if isSensitiveArgument(name) {
+			redacted[name] = redactedHeaderValue
+			continue
+		}
+		redacted[name] = redactArgumentValue(value)
+	}
+	return redacted
+}
+
+func redactArgumentValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return redactArguments(v)
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = redactArgumentValue(item)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
+
+func isSensitiveArgument(name string) bool {
+	name = strings.ToLower(name)
+	for _, sensitive := range sensitiveArgumentNames {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// toolResultText returns the text of a tool result, for error results.
+func toolResultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,124 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditRecords decodes the JSON lines written by the audit middleware.
+func auditRecords(t *testing.T, buf *bytes.Buffer) []AuditRecord {
+	t.Helper()
+	var records []AuditRecord
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record AuditRecord
+		require.NoError(t, dec.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func auditToolRequest(name string, args map[string]any) mcp.CallToolRequest {
+	var request mcp.CallToolRequest
+	request.Params.Name = name
+	request.Params.Arguments = args
+	return request
+}
+
+func TestAuditLogMiddleware(t *testing.T) {
+	ctx := WithGrafanaConfig(context.Background(), GrafanaConfig{
+		ExtraHeaders: map[string]string{"x-grafana-user-email": "jane@example.com"},
+	})
+
+	t.Run("successful call", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := AuditLogMiddleware(&buf)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("ok"), nil
+		})
+
+		result, err := handler(ctx, auditToolRequest("query_prometheus", map[string]any{
+			"datasourceUid": "prometheus",
+			"expr":          "up",
+		}))
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+
+		records := auditRecords(t, &buf)
+		require.Len(t, records, 1)
+		record := records[0]
+		assert.Equal(t, "query_prometheus", record.Tool)
+		assert.Equal(t, map[string]any{"datasourceUid": "prometheus", "expr": "up"}, record.Arguments)
+		assert.Equal(t, "jane@example.com", record.User)
+		assert.True(t, record.Success)
+		assert.Empty(t, record.Error)
+		assert.False(t, record.Time.IsZero())
+		assert.GreaterOrEqual(t, record.DurationMS, int64(0))
+	})
+
+	t.Run("failed call", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := AuditLogMiddleware(&buf)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return nil, errors.New("datasource not found")
+		})
+
+		_, err := handler(ctx, auditToolRequest("query_loki_logs", map[string]any{"datasourceUid": "missing"}))
+		require.Error(t, err)
+
+		records := auditRecords(t, &buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, "query_loki_logs", records[0].Tool)
+		assert.False(t, records[0].Success)
+		assert.Equal(t, "datasource not found", records[0].Error)
+	})
+
+	t.Run("error result", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := AuditLogMiddleware(&buf)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultError("server busy"), nil
+		})
+
+		_, err := handler(context.Background(), auditToolRequest("list_teams", nil))
+		require.NoError(t, err)
+
+		records := auditRecords(t, &buf)
+		require.Len(t, records, 1)
+		assert.False(t, records[0].Success)
+		assert.Equal(t, "server busy", records[0].Error)
+		assert.Empty(t, records[0].User)
+	})
+
+	t.Run("sensitive arguments are redacted", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := AuditLogMiddleware(&buf)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("ok"), nil
+		})
+
+		_, err := handler(ctx, auditToolRequest("create_contact_point", map[string]any{
+			"name":     "ops",
+			"password": "hunter2",
+			"settings": map[string]any{"url": "https://example.com", "apiToken": "abc"},
+			"headers":  []any{map[string]any{"Authorization": "Bearer xyz"}},
+		}))
+		require.NoError(t, err)
+
+		records := auditRecords(t, &buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, map[string]any{
+			"name":     "ops",
+			"password": "***",
+			"settings": map[string]any{"url": "https://example.com", "apiToken": "***"},
+			"headers":  []any{map[string]any{"Authorization": "***"}},
+		}, records[0].Arguments)
+		assert.NotContains(t, buf.String(), "hunter2")
+	})
+}
@@ -0,0 +1,166 @@
+package mcpgrafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// BatchToolName is the name of the tool which makes several tool calls
+	// in one request.
+	BatchToolName = "batch"
+
+	// MaxBatchCalls is the largest number of calls a single batch may make.
+	MaxBatchCalls = 20
+)
+
+// BatchCall is a single tool call made by the batch tool.
+type BatchCall struct {
+	Tool string         `json:"tool" jsonschema:"required,description=The name of the tool to call"`
+	Args map[string]any `json:"args,omitempty" jsonschema:"description=The arguments to call the tool with"`
+}
+
+type BatchParams struct {
+	Calls []BatchCall `json:"calls" jsonschema:"required,description=The tool calls to make. They run concurrently\\, so they must not depend on each other's results"`
+}
+
+// BatchCallResult is the outcome of one call of a batch. Result holds the
+// text the tool returned, as JSON if it is valid JSON. If the call failed,
+// IsError is set and Error describes why.
+type BatchCallResult struct {
+	Tool    string `json:"tool"`
+	Result  any    `json:"result,omitempty"`
+	IsError bool   `json:"isError,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewBatchTool returns the batch tool, which calls several tools registered
+// on s at once and returns their results in the order they were requested.
+//
+// Each call is made through s, as if the client had made it, so it goes
+// through the same middleware as any other tool call, including the limit on
+// concurrent tool calls. The batch call itself doesn't count towards that
+// limit, and runs at most maxConcurrentTools of its calls at once, so that
+// the rest queue behind them rather than failing as the server being busy.
+// A maxConcurrentTools of zero or less runs every call at once. A call which
+// fails doesn't affect the others.
+func NewBatchTool(s *server.MCPServer, maxConcurrentTools int) Tool {
+	return MustTool(
+		BatchToolName,
+		fmt.Sprintf("Makes several independent tool calls at once, to save round trips, for example to list datasources and folders and run a couple of queries together. The calls run concurrently, and their results are returned in the order the calls were given, each with its tool name and either its result or, if it failed, isError and the error. One call failing doesn't affect the others. At most %d calls may be made in a batch, and batches can't be nested.", MaxBatchCalls),
+		func(ctx context.Context, args BatchParams) ([]BatchCallResult, error) {
+			return batch(ctx, s, maxConcurrentTools, args)
+		},
+		mcp.WithTitleAnnotation("Batch tool calls"),
+		mcp.WithIdempotentHintAnnotation(false),
+	)
+}
+
+func batch(ctx context.Context, s *server.MCPServer, maxConcurrentTools int, args BatchParams) ([]BatchCallResult, error) {
+	if len(args.Calls) == 0 {
+		return nil, NewError(ErrKindValidation, "calls is required", nil)
+	}
+	if len(args.Calls) > MaxBatchCalls {
+		return nil, NewError(ErrKindValidation, fmt.Sprintf("too many calls: %d, at most %d may be made in a batch", len(args.Calls), MaxBatchCalls), nil)
+	}
+
+	concurrency := len(args.Calls)
+	if maxConcurrentTools > 0 {
+		concurrency = min(concurrency, maxConcurrentTools)
+	}
+	slots := make(chan struct{}, concurrency)
+	results := make([]BatchCallResult, len(args.Calls))
+	var wg sync.WaitGroup
+	for i, call := range args.Calls {
+		results[i].Tool = call.Tool
+		if call.Tool == BatchToolName {
+			results[i].IsError = true
+			results[i].Error = "batches can't be nested"
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				results[i].IsError = true
+				results[i].Error = ctx.Err().Error()
+				return
+			}
+			defer func() { <-slots }()
+			results[i] = batchCall(ctx, s, i, call)
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// batchCall makes one call of a batch through the server, as a JSON-RPC
+// tools/call request.
+func batchCall(ctx context.Context, s *server.MCPServer, i int, call BatchCall) BatchCallResult {
+	result := BatchCallResult{Tool: call.Tool}
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(fmt.Sprintf("%s-%d", BatchToolName, i)),
+		Request: mcp.Request{Method: string(mcp.MethodToolsCall)},
+		Params:  map[string]any{"name": call.Tool, "arguments": call.Args},
+	})
+	if err != nil {
+		result.IsError = true
+		result.Error = fmt.Sprintf("marshal call: %s", err)
+		return result
+	}
+
+	switch response := s.HandleMessage(ctx, message).(type) {
+	case mcp.JSONRPCResponse:
+		callResult, ok := response.Result.(mcp.CallToolResult)
+		if !ok {
+			result.IsError = true
+			result.Error = fmt.Sprintf("unexpected result type %T", response.Result)
+			return result
+		}
+		text := batchResultText(callResult)
+		if callResult.IsError {
+			result.IsError = true
+			result.Error = text
+			return result
+		}
+		if json.Valid([]byte(text)) {
+			result.Result = json.RawMessage(text)
+		} else {
+			result.Result = text
+		}
+	case mcp.JSONRPCError:
+		result.IsError = true
+		result.Error = response.Error.Message
+	default:
+		result.IsError = true
+		result.Error = fmt.Sprintf("unexpected response type %T", response)
+	}
+	return result
+}
+
+// batchResultText joins the text content of a tool call result. Other
+// content, such as images, can't be returned in a batch, and is noted
+// instead.
+func batchResultText(result mcp.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			parts = append(parts, c.Text)
+		case *mcp.TextContent:
+			parts = append(parts, c.Text)
+		default:
+			parts = append(parts, fmt.Sprintf("[%T content omitted; call the tool directly to get it]", content))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,155 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoParams struct {
+	Message string `json:"message"`
+	DelayMs int    `json:"delayMs,omitempty"`
+}
+
+// newBatchTestServer returns a server with the batch tool and tools which
+// echo their message, fail, or return plain text, limited to the given number
+// of concurrent tool calls.
+func newBatchTestServer(t *testing.T, maxConcurrentTools int) *server.MCPServer {
+	t.Helper()
+	s := server.NewMCPServer("test", "1.0.0",
+		server.WithToolCapabilities(true),
+		server.WithToolHandlerMiddleware(toolConcurrencyMiddleware(maxConcurrentTools, 100*time.Millisecond)),
+	)
+	echo := MustTool("echo", "Echoes a message", func(ctx context.Context, args echoParams) (map[string]string, error) {
+		time.Sleep(time.Duration(args.DelayMs) * time.Millisecond)
+		return map[string]string{"message": args.Message}, nil
+	}, mcp.WithReadOnlyHintAnnotation(true))
+	echo.Register(s)
+	fail := MustTool("fail", "Always fails", func(ctx context.Context, args echoParams) (string, error) {
+		return "", errors.New("datasource not found")
+	}, mcp.WithReadOnlyHintAnnotation(true))
+	fail.Register(s)
+	text := MustTool("text", "Returns plain text", func(ctx context.Context, args echoParams) (string, error) {
+		return "hello " + args.Message, nil
+	}, mcp.WithReadOnlyHintAnnotation(true))
+	text.Register(s)
+	batchTool := NewBatchTool(s, maxConcurrentTools)
+	batchTool.Register(s)
+	return s
+}
+
+// callBatch calls the batch tool through the server, as a client would.
+func callBatch(t *testing.T, s *server.MCPServer, calls ...BatchCall) *mcp.CallToolResult {
+	t.Helper()
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": BatchToolName, "arguments": map[string]any{"calls": calls}},
+	})
+	require.NoError(t, err)
+	response, ok := s.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+	require.True(t, ok, "expected a successful JSON-RPC response")
+	result, ok := response.Result.(mcp.CallToolResult)
+	require.True(t, ok)
+	return &result
+}
+
+func batchResults(t *testing.T, result *mcp.CallToolResult) []map[string]any {
+	t.Helper()
+	require.False(t, result.IsError, "batch failed: %v", result.Content)
+	require.Len(t, result.Content, 1)
+	var results []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &results))
+	return results
+}
+
+func TestBatchTool(t *testing.T) {
+	t.Run("mixed success and failure in order", func(t *testing.T) {
+		s := newBatchTestServer(t, DefaultMaxConcurrentTools)
+		results := batchResults(t, callBatch(t, s,
+			// The first call finishes last, so results must be put back in
+			// the order the calls were made.
+			BatchCall{Tool: "echo", Args: map[string]any{"message": "first", "delayMs": 50}},
+			BatchCall{Tool: "fail"},
+			BatchCall{Tool: "text", Args: map[string]any{"message": "world"}},
+			BatchCall{Tool: "missing"},
+			BatchCall{Tool: "echo", Args: map[string]any{"message": "last"}},
+		))
+
+		require.Len(t, results, 5)
+		assert.Equal(t, map[string]any{"tool": "echo", "result": map[string]any{"message": "first"}}, results[0])
+		assert.Equal(t, map[string]any{"tool": "fail", "isError": true, "error": "datasource not found"}, results[1])
+		assert.Equal(t, map[string]any{"tool": "text", "result": "hello world"}, results[2])
+		assert.Equal(t, "missing", results[3]["tool"])
+		assert.Equal(t, true, results[3]["isError"])
+		assert.Contains(t, results[3]["error"], "tool 'missing' not found")
+		assert.Equal(t, map[string]any{"tool": "echo", "result": map[string]any{"message": "last"}}, results[4])
+	})
+
+	t.Run("calls run concurrently", func(t *testing.T) {
+		s := newBatchTestServer(t, DefaultMaxConcurrentTools)
+		calls := make([]BatchCall, 5)
+		for i := range calls {
+			calls[i] = BatchCall{Tool: "echo", Args: map[string]any{"message": fmt.Sprint(i), "delayMs": 100}}
+		}
+		start := time.Now()
+		results := batchResults(t, callBatch(t, s, calls...))
+		assert.Less(t, time.Since(start), 400*time.Millisecond)
+		for i, result := range results {
+			assert.Equal(t, map[string]any{"message": fmt.Sprint(i)}, result["result"])
+		}
+	})
+
+	t.Run("calls over the concurrency limit queue rather than fail", func(t *testing.T) {
+		// Each call takes longer than calls wait for a free slot, so calls
+		// started beyond the limit would be rejected as the server being
+		// busy.
+		s := newBatchTestServer(t, 2)
+		calls := make([]BatchCall, 5)
+		for i := range calls {
+			calls[i] = BatchCall{Tool: "echo", Args: map[string]any{"message": fmt.Sprint(i), "delayMs": 250}}
+		}
+		start := time.Now()
+		results := batchResults(t, callBatch(t, s, calls...))
+		require.Len(t, results, 5)
+		for i, result := range results {
+			assert.Nil(t, result["isError"], "call %d: %v", i, result["error"])
+			assert.Equal(t, map[string]any{"message": fmt.Sprint(i)}, result["result"])
+		}
+		// Two at a time, so in three rounds.
+		assert.GreaterOrEqual(t, time.Since(start), 750*time.Millisecond)
+	})
+
+	t.Run("nested batches are rejected", func(t *testing.T) {
+		s := newBatchTestServer(t, DefaultMaxConcurrentTools)
+		results := batchResults(t, callBatch(t, s,
+			BatchCall{Tool: BatchToolName, Args: map[string]any{"calls": []any{}}},
+			BatchCall{Tool: "text", Args: map[string]any{"message": "world"}},
+		))
+		assert.Equal(t, map[string]any{"tool": BatchToolName, "isError": true, "error": "batches can't be nested"}, results[0])
+		assert.Equal(t, "hello world", results[1]["result"])
+	})
+
+	t.Run("empty and oversized batches are invalid", func(t *testing.T) {
+		s := newBatchTestServer(t, DefaultMaxConcurrentTools)
+		result := callBatch(t, s)
+		require.True(t, result.IsError)
+		assert.Equal(t, string(ErrKindValidation), result.Meta.AdditionalFields[ErrorKindMetaKey])
+
+		result = callBatch(t, s, make([]BatchCall, MaxBatchCalls+1)...)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "too many calls")
+	})
+}
@@ -0,0 +1,122 @@
+package mcpgrafana
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// grafanaCloudAccessPolicyTokenEnvVar holds a Grafana Cloud access policy
+// token, e.g. "glc_...", with read scopes for the metrics, logs and traces of
+// the stack that GRAFANA_URL points at. When it is set and GRAFANA_URL is a
+// Grafana Cloud stack, e.g. https://mystack.grafana.net, the Prometheus, Loki
+// and Tempo datasources hosted by the stack are queried directly at their
+// hosted endpoints instead of through Grafana's datasource proxy.
+const grafanaCloudAccessPolicyTokenEnvVar = "GRAFANA_CLOUD_ACCESS_POLICY_TOKEN"
+
+// grafanaCloudDomains are the domains Grafana Cloud stacks and their hosted
+// datasources are served from.
+var grafanaCloudDomains = []string{"grafana.net", "grafana-dev.net", "grafana-ops.net"}
+
+// cloudAccessPolicyTokenFromEnv returns GRAFANA_CLOUD_ACCESS_POLICY_TOKEN with
+// surrounding whitespace removed.
+func cloudAccessPolicyTokenFromEnv() string {
+	return strings.TrimSpace(os.Getenv(grafanaCloudAccessPolicyTokenEnvVar))
+}
+
+// cloudAccessPolicyTokenForRequest returns GRAFANA_CLOUD_ACCESS_POLICY_TOKEN
+// for a request to the HTTP transports, unless the request brings its own
+// Grafana URL or credentials. The token belongs to the server, so it must
+// only be combined with the server's own Grafana and credentials; callers
+// supplying theirs go through Grafana's datasource proxy, which applies
+// their own permissions.
+func cloudAccessPolicyTokenForRequest(req *http.Request, forwardedHeaders map[string]string) string {
+	grafanaURL, apiKey := urlAndAPIKeyFromHeaders(req)
+	_, _, basicAuth := req.BasicAuth()
+	if grafanaURL != "" || apiKey != "" || basicAuth || len(forwardedHeaders) > 0 {
+		return ""
+	}
+	return cloudAccessPolicyTokenFromEnv()
+}
+
+// IsGrafanaCloudURL reports whether rawURL is served by Grafana Cloud, such
+// as a stack URL like https://mystack.grafana.net or a hosted datasource URL
+// like https://logs-prod-006.grafana.net.
+func IsGrafanaCloudURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range grafanaCloudDomains {
+		if strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// GrafanaCloudEndpoint is the hosted endpoint of a Prometheus, Loki or Tempo
+// datasource of a Grafana Cloud stack.
+type GrafanaCloudEndpoint struct {
+	// URL is the base URL of the datasource API, as configured on the
+	// datasource, e.g. https://prometheus-prod-01.grafana.net/api/prom.
+	URL string
+	// InstanceID is the ID of the hosted metrics, logs or traces instance,
+	// which Grafana Cloud datasources use as their basic auth user.
+	InstanceID string
+
+	token string
+}
+
+// GrafanaCloudEndpointFor returns the hosted endpoint of a datasource with
+// the given URL and basic auth user. ok is false, and requests should go
+// through Grafana's datasource proxy, unless cfg.URL is a Grafana Cloud
+// stack, a Cloud access policy token is configured, and the datasource is
+// hosted by Grafana Cloud and has an instance ID as its basic auth user.
+// Requests made on behalf of a user always go through the proxy, so that
+// the user's permissions apply.
+func GrafanaCloudEndpointFor(cfg GrafanaConfig, datasourceURL, basicAuthUser string) (endpoint *GrafanaCloudEndpoint, ok bool) {
+	if cfg.CloudAccessPolicyToken == "" || basicAuthUser == "" {
+		return nil, false
+	}
+	if cfg.AccessToken != "" || cfg.IDToken != "" {
+		return nil, false
+	}
+	if !IsGrafanaCloudURL(cfg.URL) || !IsGrafanaCloudURL(datasourceURL) {
+		return nil, false
+	}
+	return &GrafanaCloudEndpoint{
+		URL:        strings.TrimRight(datasourceURL, "/"),
+		InstanceID: basicAuthUser,
+		token:      cfg.CloudAccessPolicyToken,
+	}, true
+}
+
+// Authorization returns the Authorization header value for requests to the
+// endpoint: the access policy token, qualified by the instance ID, in the
+// Bearer form Grafana Cloud accepts.
+func (e *GrafanaCloudEndpoint) Authorization() string {
+	return "Bearer " + e.InstanceID + ":" + e.token
+}
+
+// RoundTripper returns rt wrapped to authenticate requests to the endpoint.
+func (e *GrafanaCloudEndpoint) RoundTripper(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &grafanaCloudRoundTripper{underlying: rt, authorization: e.Authorization()}
+}
+
+type grafanaCloudRoundTripper struct {
+	underlying    http.RoundTripper
+	authorization string
+}
+
+func (rt *grafanaCloudRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// clone the request to avoid modifying the original
+	clonedReq := req.Clone(req.Context())
+	clonedReq.Header.Set("Authorization", rt.authorization)
+	return rt.underlying.RoundTrip(clonedReq)
+}
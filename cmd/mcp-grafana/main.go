@@ -1,6 +1,7 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"flag"
@@ -9,8 +10,6 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"slices"
-	"strings"
 	"syscall"
 	"time"
 
@@ -21,19 +20,6 @@ import (
 	"github.com/grafana/mcp-grafana/tools"
 )
 
-func maybeAddTools(s *server.MCPServer, tf func(*server.MCPServer), enabledTools []string, disable bool, category string) {
-	if !slices.Contains(enabledTools, category) {
-		slog.Debug("Not enabling tools", "category", category)
-		return
-	}
-	if disable {
-		slog.Info("Disabling tools", "category", category)
-		return
-	}
-	slog.Debug("Enabling tools", "category", category)
-	tf(s)
-}
-
 // disabledTools indicates whether each category of tools should be disabled.
 type disabledTools struct {
 	enabledTools string
@@ -41,7 +27,7 @@ type disabledTools struct {
 	search, datasource, incident,
 	prometheus, loki, alerting,
 	dashboard, folder, oncall, asserts, sift, admin,
-	pyroscope, navigation, proxied, annotations, rendering, write bool
+	pyroscope, navigation, proxied, annotations, rendering, info, tempo, write bool
 }
 
 // Configuration for the Grafana client.
@@ -57,7 +43,7 @@ type grafanaConfig struct {
 }
 
 func (dt *disabledTools) addFlags() {
-	flag.StringVar(&dt.enabledTools, "enabled-tools", "search,datasource,incident,prometheus,loki,alerting,dashboard,folder,oncall,asserts,sift,pyroscope,navigation,proxied,annotations,rendering", "A comma separated list of tools enabled for this server. Can be overwritten entirely or by disabling specific components, e.g. --disable-search.")
+	flag.StringVar(&dt.enabledTools, "enabled-tools", "search,datasource,incident,prometheus,loki,alerting,dashboard,folder,oncall,asserts,sift,pyroscope,navigation,proxied,annotations,rendering,info,tempo", "A comma separated list of tools enabled for this server. Can be overwritten entirely or by disabling specific components, e.g. --disable-search, or by listing categories in the GRAFANA_DISABLED_TOOL_CATEGORIES environment variable.")
 	flag.BoolVar(&dt.search, "disable-search", false, "Disable search tools")
 	flag.BoolVar(&dt.datasource, "disable-datasource", false, "Disable datasource tools")
 	flag.BoolVar(&dt.incident, "disable-incident", false, "Disable incident tools")
@@ -76,37 +62,52 @@ func (dt *disabledTools) addFlags() {
 	flag.BoolVar(&dt.write, "disable-write", false, "Disable write tools (create/update operations)")
 	flag.BoolVar(&dt.annotations, "disable-annotations", false, "Disable annotation tools")
 	flag.BoolVar(&dt.rendering, "disable-rendering", false, "Disable rendering tools (panel/dashboard image export)")
+	flag.BoolVar(&dt.info, "disable-info", false, "Disable Grafana instance info tools")
+	flag.BoolVar(&dt.tempo, "disable-tempo", false, "Disable tempo tools")
 }
 
 func (gc *grafanaConfig) addFlags() {
 	flag.BoolVar(&gc.debug, "debug", false, "Enable debug mode for the Grafana transport")
 
 	// TLS configuration flags
-	flag.StringVar(&gc.tlsCertFile, "tls-cert-file", "", "Path to TLS certificate file for client authentication")
-	flag.StringVar(&gc.tlsKeyFile, "tls-key-file", "", "Path to TLS private key file for client authentication")
-	flag.StringVar(&gc.tlsCAFile, "tls-ca-file", "", "Path to TLS CA certificate file for server verification")
+	flag.StringVar(&gc.tlsCertFile, "tls-cert-file", "", "Path to TLS certificate file for client authentication. Defaults to GRAFANA_TLS_CLIENT_CERT_FILE")
+	flag.StringVar(&gc.tlsKeyFile, "tls-key-file", "", "Path to TLS private key file for client authentication. Defaults to GRAFANA_TLS_CLIENT_KEY_FILE")
+	flag.StringVar(&gc.tlsCAFile, "tls-ca-file", "", "Path to TLS CA certificate file for server verification. Defaults to GRAFANA_TLS_CA_FILE")
 	flag.BoolVar(&gc.tlsSkipVerify, "tls-skip-verify", false, "Skip TLS certificate verification (insecure)")
 }
 
 func (dt *disabledTools) addTools(s *server.MCPServer) {
-	enabledTools := strings.Split(dt.enabledTools, ",")
-	enableWriteTools := !dt.write
-	maybeAddTools(s, tools.AddSearchTools, enabledTools, dt.search, "search")
-	maybeAddTools(s, tools.AddDatasourceTools, enabledTools, dt.datasource, "datasource")
-	maybeAddTools(s, func(mcp *server.MCPServer) { tools.AddIncidentTools(mcp, enableWriteTools) }, enabledTools, dt.incident, "incident")
-	maybeAddTools(s, tools.AddPrometheusTools, enabledTools, dt.prometheus, "prometheus")
-	maybeAddTools(s, tools.AddLokiTools, enabledTools, dt.loki, "loki")
-	maybeAddTools(s, func(mcp *server.MCPServer) { tools.AddAlertingTools(mcp, enableWriteTools) }, enabledTools, dt.alerting, "alerting")
-	maybeAddTools(s, func(mcp *server.MCPServer) { tools.AddDashboardTools(mcp, enableWriteTools) }, enabledTools, dt.dashboard, "dashboard")
-	maybeAddTools(s, func(mcp *server.MCPServer) { tools.AddFolderTools(mcp, enableWriteTools) }, enabledTools, dt.folder, "folder")
-	maybeAddTools(s, tools.AddOnCallTools, enabledTools, dt.oncall, "oncall")
-	maybeAddTools(s, tools.AddAssertsTools, enabledTools, dt.asserts, "asserts")
-	maybeAddTools(s, func(mcp *server.MCPServer) { tools.AddSiftTools(mcp, enableWriteTools) }, enabledTools, dt.sift, "sift")
-	maybeAddTools(s, tools.AddAdminTools, enabledTools, dt.admin, "admin")
-	maybeAddTools(s, tools.AddPyroscopeTools, enabledTools, dt.pyroscope, "pyroscope")
-	maybeAddTools(s, tools.AddNavigationTools, enabledTools, dt.navigation, "navigation")
-	maybeAddTools(s, func(mcp *server.MCPServer) { tools.AddAnnotationTools(mcp, enableWriteTools) }, enabledTools, dt.annotations, "annotations")
-	maybeAddTools(s, tools.AddRenderingTools, enabledTools, dt.rendering, "rendering")
+	disabled := tools.DisabledToolCategoriesFromEnv()
+	for category, disable := range map[tools.ToolCategory]bool{
+		tools.CategorySearch:      dt.search,
+		tools.CategoryDatasource:  dt.datasource,
+		tools.CategoryIncident:    dt.incident,
+		tools.CategoryPrometheus:  dt.prometheus,
+		tools.CategoryLoki:        dt.loki,
+		tools.CategoryAlerting:    dt.alerting,
+		tools.CategoryDashboard:   dt.dashboard,
+		tools.CategoryFolder:      dt.folder,
+		tools.CategoryOnCall:      dt.oncall,
+		tools.CategoryAsserts:     dt.asserts,
+		tools.CategorySift:        dt.sift,
+		tools.CategoryAdmin:       dt.admin,
+		tools.CategoryPyroscope:   dt.pyroscope,
+		tools.CategoryNavigation:  dt.navigation,
+		tools.CategoryAnnotations: dt.annotations,
+		tools.CategoryRendering:   dt.rendering,
+		tools.CategoryInfo:        dt.info,
+		tools.CategoryTempo:       dt.tempo,
+	} {
+		if disable {
+			disabled = append(disabled, category)
+		}
+	}
+	tools.AddTools(s,
+		tools.WithEnabledCategories(tools.ParseToolCategories(dt.enabledTools)...),
+		tools.WithDisabledCategories(disabled...),
+		tools.WithWriteTools(!dt.write),
+		tools.WithReadOnly(tools.ReadOnlyFromEnv()),
+	)
 }
 
 func newServer(transport string, dt disabledTools) (*server.MCPServer, *mcpgrafana.ToolManager) {
@@ -147,6 +148,7 @@ func newServer(transport string, dt disabledTools) (*server.MCPServer, *mcpgrafa
 			},
 		}
 	}
+	maxConcurrentTools := mcpgrafana.MaxConcurrentToolsFromEnv()
 	s := server.NewMCPServer("mcp-grafana", mcpgrafana.Version(),
 		server.WithInstructions(`
 This server provides access to your Grafana instance and the surrounding ecosystem.
@@ -161,19 +163,26 @@ Available Capabilities:
 - OnCall: View and manage on-call schedules, shifts, teams, and users.
 - Admin: List teams and perform administrative tasks.
 - Pyroscope: Profile applications and fetch profiling data.
-- Navigation: Generate deeplink URLs for Grafana resources like dashboards, panels, and Explore queries.
+- Tempo: Search for traces and fetch the spans of a trace.
+- Navigation: Generate deeplink URLs for Grafana resources like dashboards, panels, and Explore queries, and create and resolve short URLs.
 - Rendering: Export dashboard panels or full dashboards as PNG images (requires Grafana Image Renderer plugin).
 - Proxied Tools: Access tools from external MCP servers (like Tempo) through dynamic discovery.
+- Batch: Make several independent tool calls in one request with the batch tool.
 
 Note that some of these capabilities may be disabled. Do not try to use features that are not available via tools.
 `),
 		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(mcpgrafana.AuditLogMiddlewareFromEnv()),
+		server.WithToolHandlerMiddleware(mcpgrafana.ToolConcurrencyMiddleware(maxConcurrentTools)),
+		server.WithToolHandlerMiddleware(mcpgrafana.ResponseSizeMiddleware(mcpgrafana.MaxResponseBytesFromEnv())),
 	)
 
 	// Initialize ToolManager now that server is created
 	stm = mcpgrafana.NewToolManager(sm, s, mcpgrafana.WithProxiedTools(!dt.proxied))
 
 	dt.addTools(s)
+	batchTool := mcpgrafana.NewBatchTool(s, maxConcurrentTools)
+	batchTool.Register(s)
 	return s, stm
 }
 
@@ -293,6 +302,7 @@ func run(transport, addr, basePath, endpointPath string, logLevel slog.Level, dt
 			server.WithSSEContextFunc(mcpgrafana.ComposedSSEContextFunc(gc)),
 			server.WithStaticBasePath(basePath),
 			server.WithHTTPServer(httpSrv),
+			mcpgrafana.SSEKeepAliveOptionFromEnv(),
 		)
 		mux := http.NewServeMux()
 		if basePath == "" {
@@ -357,13 +367,16 @@ func main() {
 
 	// Convert local grafanaConfig to mcpgrafana.GrafanaConfig
 	grafanaConfig := mcpgrafana.GrafanaConfig{Debug: gc.debug}
-	if gc.tlsCertFile != "" || gc.tlsKeyFile != "" || gc.tlsCAFile != "" || gc.tlsSkipVerify {
-		grafanaConfig.TLSConfig = &mcpgrafana.TLSConfig{
-			CertFile:   gc.tlsCertFile,
-			KeyFile:    gc.tlsKeyFile,
-			CAFile:     gc.tlsCAFile,
-			SkipVerify: gc.tlsSkipVerify,
-		}
+	// TLS flags take precedence over the GRAFANA_TLS_* environment variables.
+	tlsFromEnv := mcpgrafana.TLSConfigFromEnv()
+	grafanaTLS := mcpgrafana.TLSConfig{
+		CertFile:   cmp.Or(gc.tlsCertFile, tlsFromEnv.CertFile),
+		KeyFile:    cmp.Or(gc.tlsKeyFile, tlsFromEnv.KeyFile),
+		CAFile:     cmp.Or(gc.tlsCAFile, tlsFromEnv.CAFile),
+		SkipVerify: gc.tlsSkipVerify,
+	}
+	if grafanaTLS != (mcpgrafana.TLSConfig{}) {
+		grafanaConfig.TLSConfig = &grafanaTLS
 	}
 
 	if err := run(transport, *addr, *basePath, *endpointPath, parseLevel(*logLevel), dt, grafanaConfig, tls); err != nil {
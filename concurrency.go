@@ -0,0 +1,86 @@
+package mcpgrafana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	grafanaMaxConcurrentToolsEnvVar = "GRAFANA_MAX_CONCURRENT_TOOLS"
+
+	// DefaultMaxConcurrentTools is the default number of tool calls which may
+	// run at once across all clients.
+	DefaultMaxConcurrentTools = 32
+
+	// defaultToolConcurrencyMaxWait is how long a tool call waits for a free
+	// slot before failing with ErrServerBusy.
+	defaultToolConcurrencyMaxWait = 2 * time.Second
+)
+
+// ErrServerBusy is returned for tool calls made while the maximum number of
+// tool calls are already running, and none finished in time.
+var ErrServerBusy = errors.New("server busy")
+
+// MaxConcurrentToolsFromEnv parses GRAFANA_MAX_CONCURRENT_TOOLS, defaulting
+// to DefaultMaxConcurrentTools. Zero means unlimited.
+func MaxConcurrentToolsFromEnv() int {
+	limitStr := os.Getenv(grafanaMaxConcurrentToolsEnvVar)
+	if limitStr == "" {
+		return DefaultMaxConcurrentTools
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 0 {
+		slog.Warn("invalid GRAFANA_MAX_CONCURRENT_TOOLS value, using default", "value", limitStr, "default", DefaultMaxConcurrentTools)
+		return DefaultMaxConcurrentTools
+	}
+	return limit
+}
+
+// ToolConcurrencyMiddleware limits the number of tool calls running at once
+// across the whole server, so that many agents connecting at the same time
+// can't open an unbounded number of requests to Grafana. Calls over the limit
+// wait briefly for a running call to finish, then fail with a "server busy"
+// error rather than piling up. The calls made by the batch tool each count
+// towards the limit, but the batch itself doesn't; it queues its calls so
+// that no more than the limit run at once. A limit of zero or less
+// disables the limit.
+func ToolConcurrencyMiddleware(limit int) server.ToolHandlerMiddleware {
+	return toolConcurrencyMiddleware(limit, defaultToolConcurrencyMaxWait)
+}
+
+func toolConcurrencyMiddleware(limit int, maxWait time.Duration) server.ToolHandlerMiddleware {
+	if limit <= 0 {
+		return func(next server.ToolHandlerFunc) server.ToolHandlerFunc { return next }
+	}
+	slots := make(chan struct{}, limit)
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if request.Params.Name == BatchToolName {
+				// A batch only waits for its calls, which each take a slot
+				// of their own. Taking one for the batch too could leave its
+				// calls waiting on it.
+				return next(ctx, request)
+			}
+			timer := time.NewTimer(maxWait)
+			defer timer.Stop()
+			select {
+			case slots <- struct{}{}:
+			case <-timer.C:
+				slog.Warn("Rejecting tool call, too many tool calls in progress", "tool", request.Params.Name, "limit", limit)
+				return mcp.NewToolResultError(fmt.Sprintf("%s: %d tool calls are already in progress, try again later", ErrServerBusy, limit)), nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			defer func() { <-slots }()
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingToolHandler returns a tool handler which signals on started when
+// called and then blocks until release is closed.
+func blockingToolHandler(started chan<- struct{}, release <-chan struct{}) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		started <- struct{}{}
+		<-release
+		return mcp.NewToolResultText("ok"), nil
+	}
+}
+
+func TestToolConcurrencyMiddleware(t *testing.T) {
+	const limit = 3
+
+	t.Run("the call over the limit is rejected once the wait is over", func(t *testing.T) {
+		started := make(chan struct{}, limit)
+		release := make(chan struct{})
+		handler := toolConcurrencyMiddleware(limit, 20*time.Millisecond)(blockingToolHandler(started, release))
+
+		var wg sync.WaitGroup
+		for range limit {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := handler(context.Background(), mcp.CallToolRequest{})
+				assert.NoError(t, err)
+				assert.False(t, result.IsError)
+			}()
+		}
+		for range limit {
+			<-started
+		}
+
+		result, err := handler(context.Background(), mcp.CallToolRequest{})
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "server busy: 3 tool calls are already in progress")
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("the call over the limit runs when a slot frees up in time", func(t *testing.T) {
+		started := make(chan struct{}, limit+1)
+		release := make(chan struct{})
+		handler := toolConcurrencyMiddleware(limit, time.Second)(blockingToolHandler(started, release))
+
+		var wg sync.WaitGroup
+		for range limit {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = handler(context.Background(), mcp.CallToolRequest{})
+			}()
+		}
+		for range limit {
+			<-started
+		}
+
+		done := make(chan *mcp.CallToolResult)
+		go func() {
+			result, _ := handler(context.Background(), mcp.CallToolRequest{})
+			done <- result
+		}()
+		select {
+		case <-started:
+			t.Fatal("call over the limit started before a slot was free")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(release)
+		result := <-done
+		require.NotNil(t, result)
+		assert.False(t, result.IsError)
+		wg.Wait()
+	})
+
+	t.Run("waiting respects context cancellation", func(t *testing.T) {
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		handler := toolConcurrencyMiddleware(1, time.Second)(blockingToolHandler(started, release))
+
+		go func() { _, _ = handler(context.Background(), mcp.CallToolRequest{}) }()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := handler(ctx, mcp.CallToolRequest{})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		close(release)
+	})
+
+	t.Run("zero disables the limit", func(t *testing.T) {
+		started := make(chan struct{}, 10)
+		release := make(chan struct{})
+		handler := ToolConcurrencyMiddleware(0)(blockingToolHandler(started, release))
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = handler(context.Background(), mcp.CallToolRequest{})
+			}()
+		}
+		for range 10 {
+			<-started
+		}
+		close(release)
+		wg.Wait()
+	})
+}
+
+func TestMaxConcurrentToolsFromEnv(t *testing.T) {
+	t.Setenv(grafanaMaxConcurrentToolsEnvVar, "")
+	assert.Equal(t, DefaultMaxConcurrentTools, MaxConcurrentToolsFromEnv())
+
+	t.Setenv(grafanaMaxConcurrentToolsEnvVar, "8")
+	assert.Equal(t, 8, MaxConcurrentToolsFromEnv())
+
+	t.Setenv(grafanaMaxConcurrentToolsEnvVar, "0")
+	assert.Equal(t, 0, MaxConcurrentToolsFromEnv())
+
+	t.Setenv(grafanaMaxConcurrentToolsEnvVar, "-1")
+	assert.Equal(t, DefaultMaxConcurrentTools, MaxConcurrentToolsFromEnv())
+
+	t.Setenv(grafanaMaxConcurrentToolsEnvVar, "many")
+	assert.Equal(t, DefaultMaxConcurrentTools, MaxConcurrentToolsFromEnv())
+}
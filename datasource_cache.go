@@ -0,0 +1,76 @@
+package mcpgrafana
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/grafana-openapi-client-go/models"
+)
+
+// maxDatasourceCacheEntries caps the number of datasources held by a
+// DatasourceCache. Once full, the oldest entry is evicted.
+const maxDatasourceCacheEntries = 200
+
+// DatasourceCache caches datasource lookups by UID, so that a tool doesn't
+// repeatedly fetch the same datasource to determine its type. It is never
+// invalidated, so tools created with ConvertTool replace the cache in the
+// context with a fresh one for every call, and the cache in the context of a
+// server or session only marks that caching is enabled.
+type DatasourceCache struct {
+	mu      sync.Mutex
+	entries map[string]*models.DataSource
+	order   []string
+}
+
+func NewDatasourceCache() *DatasourceCache {
+	return &DatasourceCache{
+		entries: make(map[string]*models.DataSource),
+	}
+}
+
+// Get returns the cached datasource with the given UID, if any.
+func (c *DatasourceCache) Get(uid string) (*models.DataSource, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ds, ok := c.entries[uid]
+	return ds, ok
+}
+
+// Set caches the datasource with the given UID, evicting the oldest entry if
+// the cache is full.
+func (c *DatasourceCache) Set(uid string, ds *models.DataSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[uid]; !ok {
+		if len(c.order) >= maxDatasourceCacheEntries {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, uid)
+	}
+	c.entries[uid] = ds
+}
+
+// Len returns the number of cached datasources.
+func (c *DatasourceCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+type datasourceCacheKey struct{}
+
+// WithDatasourceCache sets the datasource cache in the context.
+func WithDatasourceCache(ctx context.Context, cache *DatasourceCache) context.Context {
+	return context.WithValue(ctx, datasourceCacheKey{}, cache)
+}
+
+// DatasourceCacheFromContext retrieves the datasource cache from the context.
+// Returns nil if no cache has been set, in which case lookups are not cached.
+func DatasourceCacheFromContext(ctx context.Context) *DatasourceCache {
+	c, ok := ctx.Value(datasourceCacheKey{}).(*DatasourceCache)
+	if !ok {
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,82 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatasourceCache(t *testing.T) {
+	t.Run("get and set", func(t *testing.T) {
+		cache := NewDatasourceCache()
+		_, ok := cache.Get("missing")
+		assert.False(t, ok)
+
+		ds := &models.DataSource{UID: "loki", Type: "loki"}
+		cache.Set("loki", ds)
+		got, ok := cache.Get("loki")
+		require.True(t, ok)
+		assert.Same(t, ds, got)
+	})
+
+	t.Run("evicts oldest entry when full", func(t *testing.T) {
+		cache := NewDatasourceCache()
+		for i := 0; i < maxDatasourceCacheEntries+1; i++ {
+			uid := fmt.Sprintf("ds-%d", i)
+			cache.Set(uid, &models.DataSource{UID: uid})
+		}
+		assert.Equal(t, maxDatasourceCacheEntries, cache.Len())
+		_, ok := cache.Get("ds-0")
+		assert.False(t, ok)
+		_, ok = cache.Get(fmt.Sprintf("ds-%d", maxDatasourceCacheEntries))
+		assert.True(t, ok)
+	})
+
+	t.Run("overwriting does not grow the cache", func(t *testing.T) {
+		cache := NewDatasourceCache()
+		cache.Set("a", &models.DataSource{UID: "a"})
+		cache.Set("a", &models.DataSource{UID: "a", Name: "updated"})
+		assert.Equal(t, 1, cache.Len())
+		got, _ := cache.Get("a")
+		assert.Equal(t, "updated", got.Name)
+	})
+
+	t.Run("context round trip", func(t *testing.T) {
+		assert.Nil(t, DatasourceCacheFromContext(context.Background()))
+		cache := NewDatasourceCache()
+		ctx := WithDatasourceCache(context.Background(), cache)
+		assert.Same(t, cache, DatasourceCacheFromContext(ctx))
+	})
+}
+
+func TestDatasourceCachePerToolCall(t *testing.T) {
+	type params struct{}
+	var caches []*DatasourceCache
+	_, handler, err := ConvertTool("cache_test", "Records the datasource cache", func(ctx context.Context, _ params) (string, error) {
+		cache := DatasourceCacheFromContext(ctx)
+		cache.Set("prometheus", &models.DataSource{UID: "prometheus"})
+		caches = append(caches, cache)
+		return "ok", nil
+	})
+	require.NoError(t, err)
+
+	// As for a stdio server, whose context lives as long as the process.
+	serverCache := NewDatasourceCache()
+	ctx := WithDatasourceCache(context.Background(), serverCache)
+	for range 2 {
+		_, err := handler(ctx, mcp.CallToolRequest{})
+		require.NoError(t, err)
+	}
+
+	require.Len(t, caches, 2)
+	assert.NotSame(t, caches[0], caches[1], "each tool call must get a fresh cache")
+	assert.Zero(t, serverCache.Len(), "lookups must not outlive the tool call")
+}
@@ -0,0 +1,116 @@
+package mcpgrafana
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/go-openapi/runtime"
+)
+
+// ErrorKind classifies why a tool call failed, so that clients can tell
+// mistakes in the call itself from problems with Grafana or the network.
+type ErrorKind string
+
+const (
+	// ErrKindValidation means the tool was called with invalid arguments.
+	ErrKindValidation ErrorKind = "validation"
+	// ErrKindAuth means the credentials used were missing, invalid or
+	// lacked permission for the request.
+	ErrKindAuth ErrorKind = "auth"
+	// ErrKindNotFound means the requested resource doesn't exist.
+	ErrKindNotFound ErrorKind = "not_found"
+	// ErrKindUpstream means Grafana, or a datasource queried through it,
+	// failed to handle a valid request.
+	ErrKindUpstream ErrorKind = "upstream"
+	// ErrKindNetwork means Grafana couldn't be reached.
+	ErrKindNetwork ErrorKind = "network"
+)
+
+// ErrorKindMetaKey is the key of the error kind in the _meta field of
+// failed tool call results.
+const ErrorKindMetaKey = "errorKind"
+
+// Error is an error with a kind classifying its cause and a message suitable
+// for showing to users.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// NewError returns an error of the given kind with a user-facing message,
+// optionally wrapping an underlying error.
+func NewError(kind ErrorKind, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// NewHTTPError returns an error for a non-2xx response with the given status
+// code, classified by ErrorKindForStatus.
+func NewHTTPError(statusCode int, message string) *Error {
+	return &Error{Kind: ErrorKindForStatus(statusCode), Message: message}
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Err.Error()
+	default:
+		return e.Message + ": " + e.Err.Error()
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKindForStatus classifies an HTTP response status code. It returns an
+// empty kind for successful responses.
+func ErrorKindForStatus(statusCode int) ErrorKind {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrKindAuth
+	case statusCode == http.StatusNotFound:
+		return ErrKindNotFound
+	case statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity:
+		return ErrKindValidation
+	case statusCode >= 400:
+		return ErrKindUpstream
+	}
+	return ""
+}
+
+// ErrorKindOf classifies an error returned by a tool. Errors of type *Error
+// keep their kind; errors carrying an HTTP status code, such as those returned
+// by the Grafana OpenAPI client, are classified by ErrorKindForStatus; and
+// failures to reach Grafana are network errors. It returns an empty kind for
+// errors it can't classify, including cancelled requests.
+func ErrorKindOf(err error) ErrorKind {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return ""
+	}
+	var kindErr *Error
+	if errors.As(err, &kindErr) && kindErr.Kind != "" {
+		return kindErr.Kind
+	}
+	// The response errors generated for each Grafana API operation.
+	var codeErr interface{ Code() int }
+	if errors.As(err, &codeErr) {
+		return ErrorKindForStatus(codeErr.Code())
+	}
+	var apiErr *runtime.APIError
+	if errors.As(err, &apiErr) {
+		return ErrorKindForStatus(apiErr.Code)
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return ErrKindNetwork
+	}
+	return ""
+}
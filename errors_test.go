@@ -0,0 +1,117 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/runtime"
+	"github.com/grafana/grafana-openapi-client-go/client/dashboards"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorKindOf(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		kind ErrorKind
+	}{
+		{name: "nil", err: nil, kind: ""},
+		{name: "plain error", err: errors.New("boom"), kind: ""},
+		{name: "kind error", err: NewError(ErrKindValidation, "uid is required", nil), kind: ErrKindValidation},
+		{name: "wrapped kind error", err: fmt.Errorf("get dashboard: %w", NewHTTPError(http.StatusNotFound, "not found")), kind: ErrKindNotFound},
+		{name: "openapi response", err: fmt.Errorf("get dashboard: %w", dashboards.NewGetDashboardByUIDForbidden()), kind: ErrKindAuth},
+		{name: "openapi API error", err: runtime.NewAPIError("getDashboardByUID", nil, http.StatusBadGateway), kind: ErrKindUpstream},
+		{name: "unauthorized", err: NewHTTPError(http.StatusUnauthorized, "invalid API key"), kind: ErrKindAuth},
+		{name: "bad request", err: NewHTTPError(http.StatusBadRequest, "parse error"), kind: ErrKindValidation},
+		{name: "server error", err: NewHTTPError(http.StatusInternalServerError, "internal error"), kind: ErrKindUpstream},
+		{name: "connection refused", err: &url.Error{Op: "Get", URL: "http://grafana", Err: errors.New("connection refused")}, kind: ErrKindNetwork},
+		{name: "timeout", err: fmt.Errorf("query: %w", context.DeadlineExceeded), kind: ErrKindNetwork},
+		{name: "cancelled", err: &url.Error{Op: "Get", URL: "http://grafana", Err: context.Canceled}, kind: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.kind, ErrorKindOf(tc.err))
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	assert.Equal(t, "uid is required", NewError(ErrKindValidation, "uid is required", nil).Error())
+	assert.Equal(t, "invalid arguments: bad json", NewError(ErrKindValidation, "invalid arguments", errors.New("bad json")).Error())
+	assert.Equal(t, "bad json", NewError(ErrKindValidation, "", errors.New("bad json")).Error())
+}
+
+func TestToolErrorKinds(t *testing.T) {
+	errorKind := func(t *testing.T, result *mcp.CallToolResult) any {
+		t.Helper()
+		require.NotNil(t, result)
+		require.True(t, result.IsError)
+		require.NotNil(t, result.Meta)
+		return result.Meta.AdditionalFields[ErrorKindMetaKey]
+	}
+
+	t.Run("404 from Grafana maps to not found", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Dashboard not found"}`))
+		}))
+		defer server.Close()
+
+		type getDashboardParams struct {
+			UID string `json:"uid"`
+		}
+		_, handler, err := ConvertTool("get_dashboard", "Gets a dashboard", func(ctx context.Context, args getDashboardParams) (any, error) {
+			resp, err := GrafanaClientFromContext(ctx).Dashboards.GetDashboardByUIDWithParams(dashboards.NewGetDashboardByUIDParamsWithContext(ctx).WithUID(args.UID))
+			if err != nil {
+				return nil, fmt.Errorf("get dashboard %s: %w", args.UID, err)
+			}
+			return resp.Payload, nil
+		})
+		require.NoError(t, err)
+
+		ctx := WithGrafanaClient(context.Background(), NewGrafanaClient(context.Background(), server.URL, "", nil, 0))
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "get_dashboard"
+		request.Params.Arguments = map[string]any{"uid": "missing"}
+		result, err := handler(ctx, request)
+		require.NoError(t, err)
+		assert.Equal(t, string(ErrKindNotFound), errorKind(t, result))
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "get dashboard missing")
+	})
+
+	t.Run("malformed arguments map to validation", func(t *testing.T) {
+		_, handler, err := ConvertTool("test_tool", "A test tool", testToolHandler)
+		require.NoError(t, err)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "test_tool"
+		request.Params.Arguments = map[string]any{"name": "test", "value": "not a number"}
+		result, err := handler(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, string(ErrKindValidation), errorKind(t, result))
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "invalid arguments")
+	})
+
+	t.Run("unclassified errors have no kind", func(t *testing.T) {
+		_, handler, err := ConvertTool("test_tool", "A test tool", testToolHandler)
+		require.NoError(t, err)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "test_tool"
+		request.Params.Arguments = map[string]any{"name": "error", "value": 65}
+		result, err := handler(context.Background(), request)
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+		assert.Nil(t, result.Meta)
+	})
+}
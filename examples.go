@@ -0,0 +1,91 @@
+package mcpgrafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// examplesSchemaKey is the JSON Schema keyword under which a tool's example
+// arguments are advertised in its input schema.
+const examplesSchemaKey = "examples"
+
+// WithExamples returns a tool option adding the given example arguments to
+// the tool's input schema, under the standard JSON Schema examples keyword,
+// so that clients show the model what valid arguments look like. Each
+// example is a complete set of arguments, as a client would send them.
+//
+// ConvertTool and MustTool check every example against the tool's parameter
+// struct, so an example using an unknown or mistyped argument, or missing a
+// required one, fails tool creation rather than misleading the model.
+func WithExamples(examples ...map[string]any) mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		if len(examples) == 0 || t.RawInputSchema == nil {
+			return
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(t.RawInputSchema, &schema); err != nil {
+			return
+		}
+		existing, _ := schema[examplesSchemaKey].([]any)
+		for _, example := range examples {
+			existing = append(existing, example)
+		}
+		schema[examplesSchemaKey] = existing
+		raw, err := json.Marshal(schema)
+		if err != nil {
+			return
+		}
+		t.RawInputSchema = raw
+	}
+}
+
+// ToolExamples returns the example arguments advertised in the tool's input
+// schema, if any.
+func ToolExamples(tool mcp.Tool) []map[string]any {
+	if tool.RawInputSchema == nil {
+		return nil
+	}
+	var schema struct {
+		Examples []map[string]any `json:"examples"`
+	}
+	if err := json.Unmarshal(tool.RawInputSchema, &schema); err != nil {
+		return nil
+	}
+	return schema.Examples
+}
+
+// checkToolExamples checks that every example advertised by the tool sets
+// its required arguments and decodes into argType without unknown fields.
+func checkToolExamples(tool mcp.Tool, argType reflect.Type) error {
+	examples := ToolExamples(tool)
+	if len(examples) == 0 {
+		return nil
+	}
+	var schema struct {
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(tool.RawInputSchema, &schema); err != nil {
+		return fmt.Errorf("unmarshal input schema: %w", err)
+	}
+	for i, example := range examples {
+		for _, name := range schema.Required {
+			if _, ok := example[name]; !ok {
+				return fmt.Errorf("example %d is missing required argument %q", i, name)
+			}
+		}
+		raw, err := json.Marshal(example)
+		if err != nil {
+			return fmt.Errorf("marshal example %d: %w", i, err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(reflect.New(argType).Interface()); err != nil {
+			return fmt.Errorf("example %d doesn't match the tool's arguments: %w", i, err)
+		}
+	}
+	return nil
+}
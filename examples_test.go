@@ -0,0 +1,84 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithExamples(t *testing.T) {
+	examples := []map[string]any{
+		{"name": "cpu", "value": 1},
+		{"name": "memory", "value": 2, "optional": true},
+	}
+
+	t.Run("advertised in the input schema", func(t *testing.T) {
+		tool := MustTool("test_tool", "A test tool", testToolHandler, mcp.WithReadOnlyHintAnnotation(true), WithExamples(examples...))
+		s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+		tool.Register(s)
+
+		message, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/list"})
+		require.NoError(t, err)
+		response, ok := s.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+		require.True(t, ok, "expected a successful JSON-RPC response")
+		result, ok := response.Result.(mcp.ListToolsResult)
+		require.True(t, ok)
+		require.Len(t, result.Tools, 1)
+
+		// The schema as clients see it, after the orgId parameter is added.
+		raw, err := json.Marshal(result.Tools[0])
+		require.NoError(t, err)
+		var advertised struct {
+			InputSchema struct {
+				Properties map[string]any   `json:"properties"`
+				Examples   []map[string]any `json:"examples"`
+			} `json:"inputSchema"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &advertised))
+		assert.Contains(t, advertised.InputSchema.Properties, OrgIDParameter)
+		assert.Equal(t, []map[string]any{
+			{"name": "cpu", "value": float64(1)},
+			{"name": "memory", "value": float64(2), "optional": true},
+		}, advertised.InputSchema.Examples)
+		assert.Equal(t, advertised.InputSchema.Examples, ToolExamples(result.Tools[0]))
+	})
+
+	t.Run("options can be repeated", func(t *testing.T) {
+		tool, _, err := ConvertTool("test_tool", "A test tool", testToolHandler, WithExamples(examples[0]), WithExamples(examples[1]))
+		require.NoError(t, err)
+		assert.Len(t, ToolExamples(tool), 2)
+	})
+
+	t.Run("no examples", func(t *testing.T) {
+		tool, _, err := ConvertTool("test_tool", "A test tool", testToolHandler)
+		require.NoError(t, err)
+		assert.Nil(t, ToolExamples(tool))
+		assert.NotContains(t, string(tool.RawInputSchema), examplesSchemaKey)
+	})
+
+	for _, tc := range []struct {
+		name    string
+		example map[string]any
+		wantErr string
+	}{
+		{name: "unknown argument", example: map[string]any{"name": "cpu", "value": 1, "nmae": "typo"}, wantErr: `tool test_tool: example 0 doesn't match the tool's arguments: json: unknown field "nmae"`},
+		{name: "missing required argument", example: map[string]any{"name": "cpu"}, wantErr: `tool test_tool: example 0 is missing required argument "value"`},
+		{name: "wrong type", example: map[string]any{"name": "cpu", "value": "one"}, wantErr: "tool test_tool: example 0 doesn't match the tool's arguments"},
+	} {
+		t.Run("invalid example: "+tc.name, func(t *testing.T) {
+			_, _, err := ConvertTool("test_tool", "A test tool", testToolHandler, WithExamples(tc.example))
+			require.ErrorContains(t, err, tc.wantErr)
+			assert.Panics(t, func() {
+				MustTool("test_tool", "A test tool", testToolHandler, WithExamples(tc.example))
+			})
+		})
+	}
+}
@@ -43,11 +43,19 @@ func TestForwardRequestHeadersFromEnv(t *testing.T) {
 	})
 }
 
+func TestForwardHeadersDenyFromEnv(t *testing.T) {
+	t.Setenv("GRAFANA_FORWARD_HEADERS_DENY", "")
+	assert.Nil(t, forwardHeadersDenyFromEnv())
+
+	t.Setenv("GRAFANA_FORWARD_HEADERS_DENY", " Cookie , X-Internal,,")
+	assert.Equal(t, []string{"Cookie", "X-Internal"}, forwardHeadersDenyFromEnv())
+}
+
 func TestExtractForwardedHeaders(t *testing.T) {
 	t.Run("empty allowed headers returns nil", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "http://example.com", nil)
 		req.Header.Set("Authorization", "Bearer token123")
-		headers := extractForwardedHeaders(req, nil)
+		headers := extractForwardedHeaders(req, nil, nil)
 		assert.Nil(t, headers)
 	})
 
@@ -55,7 +63,7 @@ func TestExtractForwardedHeaders(t *testing.T) {
 		req, _ := http.NewRequest("GET", "http://example.com", nil)
 		req.Header.Set("Authorization", "Bearer token123")
 		req.Header.Set("X-Other", "should-not-be-forwarded")
-		headers := extractForwardedHeaders(req, []string{"Authorization"})
+		headers := extractForwardedHeaders(req, []string{"Authorization"}, nil)
 		assert.Equal(t, map[string]string{"Authorization": "Bearer token123"}, headers)
 	})
 
@@ -64,7 +72,7 @@ func TestExtractForwardedHeaders(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer token123")
 		req.Header.Set("X-Grafana-User-Email", "user@example.com")
 		req.Header.Set("X-Other", "should-not-be-forwarded")
-		headers := extractForwardedHeaders(req, []string{"Authorization", "X-Grafana-User-Email"})
+		headers := extractForwardedHeaders(req, []string{"Authorization", "X-Grafana-User-Email"}, nil)
 		assert.Equal(t, map[string]string{
 			"Authorization":        "Bearer token123",
 			"X-Grafana-User-Email": "user@example.com",
@@ -76,7 +84,7 @@ func TestExtractForwardedHeaders(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer token123")
 		req.Header.Set("X-Other", "should-not-be-forwarded")
 		req.Header.Set("X-Another", "also-should-not-be-forwarded")
-		headers := extractForwardedHeaders(req, []string{"Authorization"})
+		headers := extractForwardedHeaders(req, []string{"Authorization"}, nil)
 		assert.Equal(t, map[string]string{"Authorization": "Bearer token123"}, headers)
 		assert.NotContains(t, headers, "X-Other")
 		assert.NotContains(t, headers, "X-Another")
@@ -88,7 +96,7 @@ func TestExtractForwardedHeaders(t *testing.T) {
 		req.Header.Set("X-Grafana-User-Email", "user@example.com")
 		req.Header.Set("X-Custom", "custom-value")
 		req.Header.Set("User-Agent", "test-agent")
-		headers := extractForwardedHeaders(req, []string{"*"})
+		headers := extractForwardedHeaders(req, []string{"*"}, nil)
 		assert.Contains(t, headers, "Authorization")
 		assert.Contains(t, headers, "X-Grafana-User-Email")
 		assert.Contains(t, headers, "X-Custom")
@@ -101,10 +109,65 @@ func TestExtractForwardedHeaders(t *testing.T) {
 		req, _ := http.NewRequest("GET", "http://example.com", nil)
 		req.Header.Set("Authorization", "Bearer token123")
 		// Don't set X-Grafana-User-Email, so it will be empty
-		headers := extractForwardedHeaders(req, []string{"Authorization", "X-Grafana-User-Email"})
+		headers := extractForwardedHeaders(req, []string{"Authorization", "X-Grafana-User-Email"}, nil)
 		assert.Equal(t, map[string]string{"Authorization": "Bearer token123"}, headers)
 		assert.NotContains(t, headers, "X-Grafana-User-Email")
 	})
+
+	t.Run("allowed header names are matched case-insensitively", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		req.Header.Set("X-Grafana-User-Email", "user@example.com")
+		headers := extractForwardedHeaders(req, []string{"x-grafana-user-email", "X-GRAFANA-USER-EMAIL"}, nil)
+		assert.Equal(t, map[string]string{"X-Grafana-User-Email": "user@example.com"}, headers)
+	})
+
+	t.Run("mixed-case duplicates become a single canonical entry", func(t *testing.T) {
+		for _, allowed := range [][]string{{"Authorization"}, {"authorization"}, {"*"}} {
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			// Set non-canonical names directly, as some proxies and HTTP/2
+			// frontends leave them.
+			req.Header["Authorization"] = []string{"Bearer first"}
+			req.Header["authorization"] = []string{"Bearer second"}
+			req.Header["AUTHORIZATION"] = []string{"Bearer third", "Bearer last"}
+			for range 10 {
+				headers := extractForwardedHeaders(req, allowed, nil)
+				assert.Equal(t, map[string]string{"Authorization": "Bearer second"}, headers, "allowed %v", allowed)
+			}
+		}
+	})
+
+	t.Run("wildcard skips hop-by-hop and denied headers", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		req.Header.Set("Authorization", "Bearer token123")
+		req.Header.Set("X-Custom", "custom-value")
+		req.Header.Set("Connection", "keep-alive")
+		req.Header.Set("Keep-Alive", "timeout=5")
+		req.Header.Set("Transfer-Encoding", "chunked")
+		req.Header.Set("Content-Length", "42")
+		req.Header.Set("Cookie", "session=very-large-cookie")
+		headers := extractForwardedHeaders(req, []string{"*"}, []string{"cookie"})
+		assert.Equal(t, map[string]string{
+			"Authorization": "Bearer token123",
+			"X-Custom":      "custom-value",
+		}, headers)
+	})
+
+	t.Run("deny list wins over allow list", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		req.Header.Set("Authorization", "Bearer token123")
+		req.Header.Set("Cookie", "session=abc")
+		req.Header.Set("Connection", "close")
+		headers := extractForwardedHeaders(req, []string{"Authorization", "Cookie", "Connection"}, []string{"Cookie"})
+		assert.Equal(t, map[string]string{"Authorization": "Bearer token123"}, headers)
+	})
+
+	t.Run("last value of a repeated header wins", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		req.Header.Add("X-Custom", "first")
+		req.Header.Add("X-Custom", "second")
+		headers := extractForwardedHeaders(req, []string{"X-Custom"}, nil)
+		assert.Equal(t, map[string]string{"X-Custom": "second"}, headers)
+	})
 }
 
 func TestExtractGrafanaInfoWithForwardedHeaders(t *testing.T) {
@@ -132,6 +195,30 @@ func TestExtractGrafanaInfoWithForwardedHeaders(t *testing.T) {
 		assert.Equal(t, "static-value", config.ExtraHeaders["X-Static"])
 	})
 
+	t.Run("forwarded headers replace env headers with differently cased names", func(t *testing.T) {
+		t.Setenv("GRAFANA_EXTRA_HEADERS", `{"authorization": "env-token"}`)
+		t.Setenv("GRAFANA_FORWARD_REQUEST_HEADERS", "AUTHORIZATION")
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		req.Header.Set("Authorization", "forwarded-token")
+		ctx := ExtractGrafanaInfoFromHeaders(context.Background(), req)
+		config := GrafanaConfigFromContext(ctx)
+		assert.Equal(t, map[string]string{"Authorization": "forwarded-token"}, config.ExtraHeaders)
+	})
+
+	t.Run("denied headers are not forwarded with wildcard", func(t *testing.T) {
+		t.Setenv("GRAFANA_FORWARD_REQUEST_HEADERS", "*")
+		t.Setenv("GRAFANA_FORWARD_HEADERS_DENY", "Cookie")
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		req.Header.Set("X-Grafana-User-Email", "user@example.com")
+		req.Header.Set("Cookie", "session=abc")
+		req.Header.Set("Connection", "keep-alive")
+		ctx := ExtractGrafanaInfoFromHeaders(context.Background(), req)
+		config := GrafanaConfigFromContext(ctx)
+		assert.Equal(t, "user@example.com", config.ExtraHeaders["X-Grafana-User-Email"])
+		assert.NotContains(t, config.ExtraHeaders, "Cookie")
+		assert.NotContains(t, config.ExtraHeaders, "Connection")
+	})
+
 	t.Run("env headers and forwarded headers both present", func(t *testing.T) {
 		t.Setenv("GRAFANA_EXTRA_HEADERS", `{"X-Static": "static-value"}`)
 		t.Setenv("GRAFANA_FORWARD_REQUEST_HEADERS", "Authorization")
@@ -0,0 +1,68 @@
+package mcpgrafana
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GzipRoundTripper wraps an http.RoundTripper to request gzip-compressed
+// responses and transparently decompress them, so that callers (including
+// the ones capping how much of an error body they read) always see the
+// decompressed content.
+//
+// net/http only does this itself when it sets the Accept-Encoding header, so
+// it is bypassed as soon as a header is forwarded or a custom transport is
+// used. Responses sent with any other encoding are returned unchanged.
+type GzipRoundTripper struct {
+	underlying http.RoundTripper
+}
+
+func NewGzipRoundTripper(rt http.RoundTripper) *GzipRoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &GzipRoundTripper{underlying: rt}
+}
+
+func (t *GzipRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" && req.Method != http.MethodHead {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	resp, err := t.underlying.RoundTrip(req)
+	if err != nil || resp.Uncompressed || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp, err
+	}
+
+	resp.Body = &gzipReadCloser{body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// gzipReadCloser decompresses a response body, lazily so that bodies which
+// are never read don't fail or block on reading the gzip header.
+type gzipReadCloser struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (r *gzipReadCloser) Read(p []byte) (int, error) {
+	if r.zr == nil && r.err == nil {
+		r.zr, r.err = gzip.NewReader(r.body)
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.zr.Read(p)
+}
+
+func (r *gzipReadCloser) Close() error {
+	return r.body.Close()
+}
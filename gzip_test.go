@@ -0,0 +1,118 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// gzipServer gzips its responses when the client accepts gzip, and records
+// the Accept-Encoding header of the last request.
+func gzipServer(t *testing.T, status int, body string, acceptEncoding *string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*acceptEncoding = r.Header.Get("Accept-Encoding")
+		if strings.Contains(*acceptEncoding, "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.WriteHeader(status)
+			_, _ = w.Write(gzipBytes(t, body))
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func TestGzipRoundTripper(t *testing.T) {
+	body := `{"dashboard": {"title": "` + strings.Repeat("large ", 1000) + `"}}`
+
+	t.Run("requests and decompresses gzip responses", func(t *testing.T) {
+		var acceptEncoding string
+		server := gzipServer(t, http.StatusOK, body, &acceptEncoding)
+		defer server.Close()
+
+		client := &http.Client{Transport: NewGzipRoundTripper(nil)}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, "gzip", acceptEncoding)
+		assert.Empty(t, resp.Header.Get("Content-Encoding"))
+		assert.True(t, resp.Uncompressed)
+		got, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(got))
+	})
+
+	t.Run("decompresses when the header is already set", func(t *testing.T) {
+		var acceptEncoding string
+		server := gzipServer(t, http.StatusOK, body, &acceptEncoding)
+		defer server.Close()
+
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		resp, err := NewGzipRoundTripper(nil).RoundTrip(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, "gzip, deflate", acceptEncoding)
+		got, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(got))
+	})
+
+	t.Run("identity encoding is returned unchanged", func(t *testing.T) {
+		var acceptEncoding string
+		server := gzipServer(t, http.StatusOK, body, &acceptEncoding)
+		defer server.Close()
+
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", "identity")
+		resp, err := NewGzipRoundTripper(nil).RoundTrip(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.False(t, resp.Uncompressed)
+		got, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(got))
+	})
+
+	t.Run("error body caps apply to decompressed content", func(t *testing.T) {
+		var acceptEncoding string
+		server := gzipServer(t, http.StatusInternalServerError, `{"message": "`+strings.Repeat("x", 4096)+`"}`, &acceptEncoding)
+		defer server.Close()
+
+		transport, err := BuildTransport(&GrafanaConfig{}, nil)
+		require.NoError(t, err)
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		capped, err := io.ReadAll(io.LimitReader(resp.Body, 14))
+		require.NoError(t, err)
+		assert.Equal(t, `{"message": "x`, string(capped))
+	})
+}
@@ -0,0 +1,163 @@
+package mcpgrafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/grafana/incident-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// grafanaInstancesEnvVar holds a JSON object of named Grafana instances,
+	// e.g. {"staging": {"url": "https://staging.grafana.example", "apiKey": "..."}}.
+	// Tools can target one of them with the instance parameter instead of
+	// the default instance configured by GRAFANA_URL.
+	grafanaInstancesEnvVar = "GRAFANA_INSTANCES"
+
+	// grafanaInstanceHeader selects one of the instances in GRAFANA_INSTANCES
+	// for every tool call of an SSE or streamable HTTP request.
+	grafanaInstanceHeader = "X-Grafana-Instance"
+
+	// InstanceParameter is the name of the tool parameter which selects one of
+	// the instances in GRAFANA_INSTANCES. It is added to every tool's input
+	// schema when any instances are configured.
+	InstanceParameter = "instance"
+)
+
+// GrafanaInstance is a named Grafana instance configured in GRAFANA_INSTANCES.
+type GrafanaInstance struct {
+	URL    string `json:"url"`
+	APIKey string `json:"apiKey"`
+}
+
+func grafanaInstancesFromEnv() map[string]GrafanaInstance {
+	value := strings.TrimSpace(os.Getenv(grafanaInstancesEnvVar))
+	if value == "" {
+		return nil
+	}
+	var instances map[string]GrafanaInstance
+	if err := json.Unmarshal([]byte(value), &instances); err != nil {
+		slog.Error("Failed to parse GRAFANA_INSTANCES, ignoring", "error", err)
+		return nil
+	}
+	for name, instance := range instances {
+		if instance.URL == "" {
+			slog.Error("Grafana instance in GRAFANA_INSTANCES has no url, ignoring", "instance", name)
+			delete(instances, name)
+		}
+	}
+	return instances
+}
+
+// WithGrafanaInstance replaces the Grafana configuration and clients in the
+// context with ones for the named instance from GrafanaConfig.Instances. It
+// returns an error naming the configured instances if there is no instance
+// with that name.
+func WithGrafanaInstance(ctx context.Context, name string) (context.Context, error) {
+	config := GrafanaConfigFromContext(ctx)
+	instance, ok := config.Instances[name]
+	if !ok {
+		if len(config.Instances) == 0 {
+			return nil, fmt.Errorf("unknown Grafana instance %q: no instances are configured in %s", name, grafanaInstancesEnvVar)
+		}
+		return nil, fmt.Errorf("unknown Grafana instance %q, expected one of: %s", name, strings.Join(slices.Sorted(maps.Keys(config.Instances)), ", "))
+	}
+
+	// Credentials of the default instance must not leak to other instances.
+	config.URL = instance.URL
+	config.APIKey = instance.APIKey
+	config.APIKeyFile = ""
+	config.BasicAuth = nil
+	config.AccessToken = ""
+	config.IDToken = ""
+	config.CloudAccessPolicyToken = ""
+	// Nor must the static and forwarded headers, which may carry credentials
+	// or tenant IDs meant for the default instance.
+	config.ExtraHeaders = nil
+	ctx = WithGrafanaConfig(ctx, config)
+	ctx = WithGrafanaClient(ctx, NewGrafanaClient(ctx, instance.URL, instance.APIKey, nil, config.OrgID))
+	ctx = WithIncidentClient(ctx, newInstanceIncidentClient(config, instance))
+	// Datasource UIDs are only unique within an instance.
+	if DatasourceCacheFromContext(ctx) != nil {
+		ctx = WithDatasourceCache(ctx, NewDatasourceCache())
+	}
+	return ctx, nil
+}
+
+func newInstanceIncidentClient(config GrafanaConfig, instance GrafanaInstance) *incident.Client {
+	incidentURL := fmt.Sprintf("%s/api/plugins/grafana-irm-app/resources/api/v1/", strings.TrimRight(instance.URL, "/"))
+	client := incident.NewClient(incidentURL, instance.APIKey)
+	transport, err := BuildTransport(&config, nil)
+	if err != nil {
+		slog.Error("Failed to create custom transport for incident client, using default", "error", err)
+		return client
+	}
+	client.HTTPClient.Transport = wrapWithUserAgent(NewOrgIDRoundTripper(transport, config.OrgID))
+	return client
+}
+
+// withGrafanaInstanceFromRequest applies the instance named by the instance
+// argument of a tool call, if any.
+func withGrafanaInstanceFromRequest(ctx context.Context, request mcp.CallToolRequest) (context.Context, error) {
+	name, _ := request.GetArguments()[InstanceParameter].(string)
+	if name == "" {
+		return ctx, nil
+	}
+	return WithGrafanaInstance(ctx, name)
+}
+
+// extractGrafanaInstanceFromHeaders applies the instance named by the
+// X-Grafana-Instance header, if any. It must run after the clients have been
+// added to the context. Unknown instances are logged and ignored, so the
+// request uses the default instance.
+var extractGrafanaInstanceFromHeaders httpContextFunc = func(ctx context.Context, req *http.Request) context.Context {
+	name := req.Header.Get(grafanaInstanceHeader)
+	if name == "" {
+		return ctx
+	}
+	instanceCtx, err := WithGrafanaInstance(ctx, name)
+	if err != nil {
+		slog.Warn("Ignoring X-Grafana-Instance header", "error", err)
+		return ctx
+	}
+	return instanceCtx
+}
+
+// withInstanceParameter adds the instance parameter to a tool's input schema,
+// listing the configured instance names.
+func withInstanceParameter(tool mcp.Tool, instances map[string]GrafanaInstance) mcp.Tool {
+	if len(instances) == 0 || tool.RawInputSchema == nil {
+		return tool
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(tool.RawInputSchema, &schema); err != nil {
+		return tool
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	if _, ok := properties[InstanceParameter]; ok {
+		return tool
+	}
+	properties[InstanceParameter] = map[string]any{
+		"type":        "string",
+		"enum":        slices.Sorted(maps.Keys(instances)),
+		"description": "The name of the Grafana instance to use, from GRAFANA_INSTANCES. Defaults to the default instance when omitted.",
+	}
+	schema["properties"] = properties
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return tool
+	}
+	tool.RawInputSchema = raw
+	return tool
+}
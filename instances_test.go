@@ -0,0 +1,141 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newInstanceTestServer returns a server answering the health endpoint which
+// records the Authorization header of each request.
+func newInstanceTestServer(t *testing.T, auth *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*auth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"database": "ok", "version": "11.0.0"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type instanceTestParams struct{}
+
+// instanceTestTool reports the URL of the Grafana instance it runs against
+// after making a request with its Grafana client.
+var instanceTestTool = MustTool(
+	"instance_test",
+	"Report the Grafana URL in use",
+	func(ctx context.Context, _ instanceTestParams) (string, error) {
+		if _, err := GrafanaClientFromContext(ctx).Health.GetHealth(); err != nil {
+			return "", err
+		}
+		return GrafanaConfigFromContext(ctx).URL, nil
+	},
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+func TestGrafanaInstances(t *testing.T) {
+	var prodAuth, stagingAuth string
+	prod := newInstanceTestServer(t, &prodAuth)
+	staging := newInstanceTestServer(t, &stagingAuth)
+
+	t.Setenv("GRAFANA_URL", prod.URL)
+	t.Setenv(grafanaServiceAccountTokenEnvVar, "prod-token")
+	t.Setenv(grafanaInstancesEnvVar, fmt.Sprintf(`{"staging": {"url": %q, "apiKey": "staging-token"}}`, staging.URL))
+	ctx := ComposedStdioContextFunc(GrafanaConfig{})(context.Background())
+
+	call := func(t *testing.T, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := instanceTestTool.Handler(ctx, request)
+		require.NoError(t, err)
+		return result
+	}
+	text := func(result *mcp.CallToolResult) string {
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("instance parameter selects staging", func(t *testing.T) {
+		result := call(t, map[string]any{"instance": "staging"})
+		require.False(t, result.IsError, text(result))
+		assert.Equal(t, staging.URL, text(result))
+		assert.Equal(t, "Bearer staging-token", stagingAuth)
+	})
+
+	t.Run("default instance is used when omitted", func(t *testing.T) {
+		prodAuth = ""
+		result := call(t, map[string]any{})
+		require.False(t, result.IsError, text(result))
+		assert.Equal(t, prod.URL, text(result))
+		assert.Equal(t, "Bearer prod-token", prodAuth)
+		assert.Equal(t, prod.URL, GrafanaConfigFromContext(ctx).URL)
+	})
+
+	t.Run("unknown instance", func(t *testing.T) {
+		result := call(t, map[string]any{"instance": "dev"})
+		require.True(t, result.IsError)
+		assert.Contains(t, text(result), `unknown Grafana instance "dev", expected one of: staging`)
+	})
+
+	t.Run("header selects staging", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		req.Header.Set(grafanaInstanceHeader, "staging")
+		headerCtx := ComposedHTTPContextFunc(GrafanaConfig{})(context.Background(), req)
+		assert.Equal(t, staging.URL, GrafanaConfigFromContext(headerCtx).URL)
+		assert.Equal(t, "staging-token", GrafanaConfigFromContext(headerCtx).APIKey)
+	})
+
+	t.Run("headers for the default instance don't reach staging", func(t *testing.T) {
+		t.Setenv(grafanaExtraHeadersEnvVar, `{"X-Scope-OrgID": "prod-tenant"}`)
+		t.Setenv(grafanaForwardRequestHeadersEnvVar, "Authorization")
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		req.Header.Set("Authorization", "Bearer prod-user-token")
+		headerCtx := ComposedHTTPContextFunc(GrafanaConfig{})(context.Background(), req)
+		require.Equal(t, "Bearer prod-user-token", GrafanaConfigFromContext(headerCtx).ExtraHeaders["Authorization"])
+
+		stagingAuth = ""
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"instance": "staging"}
+		result, err := instanceTestTool.Handler(headerCtx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, text(result))
+		assert.Equal(t, "Bearer staging-token", stagingAuth)
+
+		instanceCtx, err := WithGrafanaInstance(headerCtx, "staging")
+		require.NoError(t, err)
+		assert.Empty(t, GrafanaConfigFromContext(instanceCtx).ExtraHeaders)
+	})
+}
+
+func TestWithInstanceParameter(t *testing.T) {
+	instances := map[string]GrafanaInstance{"staging": {URL: "http://staging"}, "prod": {URL: "http://prod"}}
+
+	t.Run("adds the parameter with instance names", func(t *testing.T) {
+		tool := withInstanceParameter(instanceTestTool.Tool, instances)
+		var schema struct {
+			Properties map[string]struct {
+				Enum []string `json:"enum"`
+			} `json:"properties"`
+		}
+		require.NoError(t, json.Unmarshal(tool.RawInputSchema, &schema))
+		require.Contains(t, schema.Properties, InstanceParameter)
+		assert.Equal(t, []string{"prod", "staging"}, schema.Properties[InstanceParameter].Enum)
+	})
+
+	t.Run("unchanged without instances", func(t *testing.T) {
+		tool := withInstanceParameter(instanceTestTool.Tool, nil)
+		assert.Equal(t, instanceTestTool.Tool.RawInputSchema, tool.RawInputSchema)
+	})
+}
@@ -7,17 +7,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"reflect"
 	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/go-openapi/runtime/logger"
 	"github.com/go-openapi/strfmt"
 	"github.com/grafana/grafana-openapi-client-go/client"
 	"github.com/grafana/incident-go"
@@ -39,6 +42,13 @@ const (
 
 	grafanaExtraHeadersEnvVar          = "GRAFANA_EXTRA_HEADERS"
 	grafanaForwardRequestHeadersEnvVar = "GRAFANA_FORWARD_REQUEST_HEADERS"
+	grafanaForwardHeadersDenyEnvVar    = "GRAFANA_FORWARD_HEADERS_DENY"
+
+	grafanaAPIVersionEnvVar = "GRAFANA_API_VERSION"
+
+	grafanaTLSCAFileEnvVar         = "GRAFANA_TLS_CA_FILE"
+	grafanaTLSClientCertFileEnvVar = "GRAFANA_TLS_CLIENT_CERT_FILE"
+	grafanaTLSClientKeyFileEnvVar  = "GRAFANA_TLS_CLIENT_KEY_FILE"
 
 	grafanaURLHeader    = "X-Grafana-URL"
 	grafanaAPIKeyHeader = "X-Grafana-API-Key"
@@ -47,6 +57,15 @@ const (
 func urlAndAPIKeyFromEnv() (string, string) {
 	u := strings.TrimRight(os.Getenv(grafanaURLEnvVar), "/")
 
+	// A token file takes precedence, since it supports rotation
+	if path := os.Getenv(grafanaAPIKeyFileEnvVar); path != "" {
+		apiKey, err := apiKeyFileFor(path).Token()
+		if err == nil {
+			return u, apiKey
+		}
+		slog.Error("Failed to read Grafana API key file, falling back to environment variables", "error", err)
+	}
+
 	// Check for the new service account token environment variable first
 	apiKey := os.Getenv(grafanaServiceAccountTokenEnvVar)
 	if apiKey != "" {
@@ -118,38 +137,77 @@ func forwardRequestHeadersFromEnv() []string {
 	return headers
 }
 
+// forwardHeadersDenyFromEnv parses the comma separated list of headers in
+// GRAFANA_FORWARD_HEADERS_DENY.
+func forwardHeadersDenyFromEnv() []string {
+	var headers []string
+	for _, name := range strings.Split(os.Getenv(grafanaForwardHeadersDenyEnvVar), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			headers = append(headers, name)
+		}
+	}
+	return headers
+}
+
+// hopByHopHeaders are never forwarded to Grafana: they describe the incoming
+// connection rather than the request.
+var hopByHopHeaders = []string{"Connection", "Keep-Alive", "Transfer-Encoding", "Content-Length", "Te", "Trailer", "Upgrade"}
+
 // extractForwardedHeaders extracts headers from the incoming request based on the allowed headers list.
 // If allowedHeaders contains "*", all headers from the request are forwarded.
 // Otherwise, only headers matching the names in allowedHeaders are forwarded.
-func extractForwardedHeaders(req *http.Request, allowedHeaders []string) map[string]string {
+// Headers in deniedHeaders and hop-by-hop headers are never forwarded, even
+// if allowed.
+// Header names are matched case-insensitively and returned in canonical form.
+// If a header has several values, possibly under differently cased names,
+// the last one wins.
+func extractForwardedHeaders(req *http.Request, allowedHeaders, deniedHeaders []string) map[string]string {
 	if len(allowedHeaders) == 0 {
 		return nil
 	}
 
-	forwardedHeaders := make(map[string]string)
-
-	// If "*" is specified, forward all headers
-	if len(allowedHeaders) == 1 && allowedHeaders[0] == "*" {
-		for name, values := range req.Header {
-			if len(values) > 0 {
-				forwardedHeaders[name] = values[0]
-				slog.Debug("Forwarding header to Grafana", "header", name, "value", len(values[0]))
-			}
-		}
-		return forwardedHeaders
+	wildcard := len(allowedHeaders) == 1 && allowedHeaders[0] == "*"
+	allowed := make(map[string]bool, len(allowedHeaders))
+	for _, name := range allowedHeaders {
+		allowed[http.CanonicalHeaderKey(name)] = true
+	}
+	denied := make(map[string]bool, len(hopByHopHeaders)+len(deniedHeaders))
+	for _, name := range slices.Concat(hopByHopHeaders, deniedHeaders) {
+		denied[http.CanonicalHeaderKey(name)] = true
 	}
 
-	// Otherwise, forward only whitelisted headers
-	for _, headerName := range allowedHeaders {
-		if headerValue := req.Header.Get(headerName); headerValue != "" {
-			forwardedHeaders[headerName] = headerValue
-			slog.Debug("Forwarding header to Grafana", "header", headerName, "value", len(headerValue))
+	forwardedHeaders := make(map[string]string)
+	// Visit the names in order so that when a header is set under several
+	// cases, which value wins doesn't depend on map iteration order.
+	for _, name := range slices.Sorted(maps.Keys(req.Header)) {
+		canonical := http.CanonicalHeaderKey(name)
+		if denied[canonical] || (!wildcard && !allowed[canonical]) {
+			continue
+		}
+		values := req.Header[name]
+		if len(values) == 0 || values[len(values)-1] == "" {
+			continue
 		}
+		forwardedHeaders[canonical] = values[len(values)-1]
+	}
+	for name, value := range forwardedHeaders {
+		slog.Debug("Forwarding header to Grafana", "header", name, "value", len(value))
 	}
 
 	return forwardedHeaders
 }
 
+// setHeader sets a header in headers, replacing any existing value stored
+// under a differently cased name.
+func setHeader(headers map[string]string, name, value string) {
+	for existing := range headers {
+		if strings.EqualFold(existing, name) {
+			delete(headers, existing)
+		}
+	}
+	headers[name] = value
+}
+
 func orgIdFromHeaders(req *http.Request) int64 {
 	orgIDStr := req.Header.Get(client.OrgIDHeader)
 	if orgIDStr == "" {
@@ -181,6 +239,17 @@ type TLSConfig struct {
 	SkipVerify bool
 }
 
+// TLSConfigFromEnv returns the TLS configuration set by the
+// GRAFANA_TLS_CA_FILE, GRAFANA_TLS_CLIENT_CERT_FILE and
+// GRAFANA_TLS_CLIENT_KEY_FILE environment variables.
+func TLSConfigFromEnv() TLSConfig {
+	return TLSConfig{
+		CertFile: os.Getenv(grafanaTLSClientCertFileEnvVar),
+		KeyFile:  os.Getenv(grafanaTLSClientKeyFileEnvVar),
+		CAFile:   os.Getenv(grafanaTLSCAFileEnvVar),
+	}
+}
+
 // GrafanaConfig represents the full configuration for Grafana clients.
 // It includes connection details, authentication credentials, debug settings, and TLS options used throughout the MCP server's lifecycle.
 type GrafanaConfig struct {
@@ -201,6 +270,11 @@ type GrafanaConfig struct {
 	// It may be empty if we are using on-behalf-of auth.
 	APIKey string
 
+	// APIKeyFile is the path of a file holding the service account token, read
+	// from GRAFANA_API_KEY_FILE. When set, the file is re-read whenever it
+	// changes and its token is used for every request, overriding APIKey.
+	APIKeyFile string
+
 	// Credentials if user is using basic auth
 	BasicAuth *url.Userinfo
 
@@ -227,6 +301,51 @@ type GrafanaConfig struct {
 	// ExtraHeaders contains additional HTTP headers to send with all Grafana API requests.
 	// Parsed from GRAFANA_EXTRA_HEADERS environment variable as JSON object.
 	ExtraHeaders map[string]string
+
+	// MaxRetries is the number of times requests which fail with a transient
+	// error (429, 502, 503 or 504 responses, or connection resets) are retried.
+	// Parsed from GRAFANA_HTTP_MAX_RETRIES, defaulting to DefaultMaxRetries.
+	// Zero disables retries.
+	MaxRetries int
+
+	// RateLimitRPS limits outbound requests to Grafana to this many per
+	// second, shared across all clients in the process. Parsed from
+	// GRAFANA_RATE_LIMIT_RPS. Zero means unlimited.
+	RateLimitRPS float64
+
+	// MetadataCacheTTL is how long responses to metadata requests, such as
+	// listing datasources, folders or label names, are cached. Parsed from
+	// GRAFANA_METADATA_CACHE_TTL. Zero disables the cache.
+	MetadataCacheTTL time.Duration
+
+	// DefaultTenantID is the tenant sent in the X-Scope-OrgID header of Loki
+	// and Prometheus queries which don't name one, for multi-tenant Loki and
+	// Mimir datasources. Parsed from GRAFANA_DEFAULT_TENANT_ID.
+	DefaultTenantID string
+
+	// DefaultQueryRange is how far back query tools look when called without
+	// a start time. Parsed from GRAFANA_DEFAULT_QUERY_RANGE, defaulting to
+	// DefaultQueryRange. Zero means DefaultQueryRange.
+	DefaultQueryRange time.Duration
+
+	// CloudAccessPolicyToken is a Grafana Cloud access policy token used to
+	// query the Prometheus, Loki and Tempo datasources hosted by a Grafana
+	// Cloud stack directly, rather than through Grafana's datasource proxy.
+	// It is only used when URL is a Grafana Cloud stack. Parsed from
+	// GRAFANA_CLOUD_ACCESS_POLICY_TOKEN, and only set for HTTP requests which
+	// use the server's own Grafana URL and credentials.
+	CloudAccessPolicyToken string
+
+	// APIVersion is the version of the Grafana instance, e.g. "8.5.2", as
+	// given by GRAFANA_API_VERSION. Tools whose endpoints differ between
+	// Grafana versions use it to pick the right one up front. When empty they
+	// try the current endpoint and fall back to older ones on a 404.
+	APIVersion string
+
+	// Instances are the named Grafana instances parsed from GRAFANA_INSTANCES,
+	// which can be selected per tool call with the instance parameter or per
+	// request with the X-Grafana-Instance header.
+	Instances map[string]GrafanaInstance
 }
 
 const (
@@ -261,6 +380,9 @@ func (tc *TLSConfig) CreateTLSConfig() (*tls.Config, error) {
 	}
 
 	// Load client certificate if both cert and key files are provided
+	if (tc.CertFile == "") != (tc.KeyFile == "") {
+		return nil, fmt.Errorf("both a client certificate and key file are required for mutual TLS")
+	}
 	if tc.CertFile != "" && tc.KeyFile != "" {
 		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
 		if err != nil {
@@ -432,10 +554,28 @@ func BuildTransport(cfg *GrafanaConfig, base http.RoundTripper) (http.RoundTripp
 		}
 	}
 
+	transport = NewGzipRoundTripper(transport)
+
+	if cfg.APIKeyFile != "" {
+		transport = NewAPIKeyFileRoundTripper(transport, cfg.APIKeyFile)
+	}
+
 	if len(cfg.ExtraHeaders) > 0 {
 		transport = NewExtraHeadersRoundTripper(transport, cfg.ExtraHeaders)
 	}
 
+	if cfg.RateLimitRPS > 0 {
+		transport = NewRateLimitRoundTripper(transport, cfg.RateLimitRPS)
+	}
+
+	if cfg.MaxRetries > 0 {
+		transport = NewRetryRoundTripper(transport, cfg.MaxRetries)
+	}
+
+	if cfg.MetadataCacheTTL > 0 {
+		transport = NewMetadataCacheRoundTripper(transport, cfg.MetadataCacheTTL, metadataCacheIdentity(cfg))
+	}
+
 	return transport, nil
 }
 
@@ -502,6 +642,15 @@ var ExtractGrafanaInfoFromEnv server.StdioContextFunc = func(ctx context.Context
 	config.BasicAuth = basicAuth
 	config.OrgID = orgID
 	config.ExtraHeaders = extraHeaders
+	config.MaxRetries = maxRetriesFromEnv()
+	config.RateLimitRPS = rateLimitRPSFromEnv()
+	config.MetadataCacheTTL = metadataCacheTTLFromEnv()
+	config.DefaultTenantID = defaultTenantIDFromEnv()
+	config.DefaultQueryRange = defaultQueryRangeFromEnv()
+	config.CloudAccessPolicyToken = cloudAccessPolicyTokenFromEnv()
+	config.APIVersion = os.Getenv(grafanaAPIVersionEnvVar)
+	config.APIKeyFile = os.Getenv(grafanaAPIKeyFileEnvVar)
+	config.Instances = grafanaInstancesFromEnv()
 	return WithGrafanaConfig(ctx, config)
 }
 
@@ -531,13 +680,21 @@ var ExtractGrafanaInfoFromHeaders httpContextFunc = func(ctx context.Context, re
 
 	// Extract and merge forwarded headers from incoming request
 	allowedHeaders := forwardRequestHeadersFromEnv()
-	forwardedHeaders := extractForwardedHeaders(req, allowedHeaders)
+	forwardedHeaders := extractForwardedHeaders(req, allowedHeaders, forwardHeadersDenyFromEnv())
 	// Merge forwarded headers into extra headers (forwarded takes precedence)
 	for k, v := range forwardedHeaders {
-		extraHeaders[k] = v
+		setHeader(extraHeaders, k, v)
 	}
 
 	config.ExtraHeaders = extraHeaders
+	config.MaxRetries = maxRetriesFromEnv()
+	config.RateLimitRPS = rateLimitRPSFromEnv()
+	config.MetadataCacheTTL = metadataCacheTTLFromEnv()
+	config.DefaultTenantID = defaultTenantIDFromEnv()
+	config.DefaultQueryRange = defaultQueryRangeFromEnv()
+	config.CloudAccessPolicyToken = cloudAccessPolicyTokenForRequest(req, forwardedHeaders)
+	config.APIVersion = os.Getenv(grafanaAPIVersionEnvVar)
+	config.Instances = grafanaInstancesFromEnv()
 	return WithGrafanaConfig(ctx, config)
 }
 
@@ -628,9 +785,16 @@ func NewGrafanaClient(ctx context.Context, grafanaURL, apiKey string, auth *url.
 		timeout = DefaultGrafanaClientTimeout
 	}
 
-	slog.Debug("Creating Grafana client", "url", parsedURL.Redacted(), "api_key_set", apiKey != "", "basic_auth_set", config.BasicAuth != nil, "org_id", cfg.OrgID, "timeout", timeout, "extra_headers_count", len(config.ExtraHeaders))
+	slog.Debug("Creating Grafana client", "url", parsedURL.Redacted(), "api_key_set", apiKey != "", "basic_auth_set", config.BasicAuth != nil, "org_id", cfg.OrgID, "timeout", timeout, "extra_headers_count", len(config.ExtraHeaders), "max_retries", config.MaxRetries)
 	grafanaClient := client.NewHTTPClientWithConfig(strfmt.Default, cfg)
 
+	// In debug mode (enabled by the debug flag or the DEBUG environment
+	// variable) the client dumps every request and response, so make sure
+	// credentials don't end up in the logs.
+	if rt, ok := grafanaClient.Transport.(interface{ SetLogger(logger.Logger) }); ok {
+		rt.SetLogger(newRedactingLogger(debugLogger))
+	}
+
 	// Always enable HTTP tracing for context propagation (no-op when no exporter configured)
 	// Use reflection to wrap the transport without importing the runtime client package
 	v := reflect.ValueOf(grafanaClient.Transport)
@@ -657,10 +821,22 @@ func NewGrafanaClient(ctx context.Context, grafanaURL, apiKey string, auth *url.
 					if cfg.TLSConfig != nil {
 						timeoutTransport.TLSClientConfig = cfg.TLSConfig
 					}
-					var rt http.RoundTripper = timeoutTransport
+					var rt http.RoundTripper = NewGzipRoundTripper(timeoutTransport)
+					if config.APIKeyFile != "" {
+						rt = NewAPIKeyFileRoundTripper(rt, config.APIKeyFile)
+					}
 					if len(config.ExtraHeaders) > 0 {
 						rt = NewExtraHeadersRoundTripper(rt, config.ExtraHeaders)
 					}
+					if config.RateLimitRPS > 0 {
+						rt = NewRateLimitRoundTripper(rt, config.RateLimitRPS)
+					}
+					if config.MaxRetries > 0 {
+						rt = NewRetryRoundTripper(rt, config.MaxRetries)
+					}
+					if config.MetadataCacheTTL > 0 {
+						rt = NewMetadataCacheRoundTripper(rt, config.MetadataCacheTTL, metadataCacheIdentity(&config))
+					}
 					userAgentWrapped := wrapWithUserAgent(rt)
 					wrapped := otelhttp.NewTransport(userAgentWrapped)
 					transportField.Set(reflect.ValueOf(wrapped))
@@ -831,6 +1007,9 @@ func ComposedStdioContextFunc(config GrafanaConfig) server.StdioContextFunc {
 		func(ctx context.Context) context.Context {
 			return WithGrafanaConfig(ctx, config)
 		},
+		func(ctx context.Context) context.Context {
+			return WithDatasourceCache(ctx, NewDatasourceCache())
+		},
 		ExtractGrafanaInfoFromEnv,
 		ExtractGrafanaClientFromEnv,
 		ExtractIncidentClientFromEnv,
@@ -844,9 +1023,13 @@ func ComposedSSEContextFunc(config GrafanaConfig) server.SSEContextFunc {
 		func(ctx context.Context, req *http.Request) context.Context {
 			return WithGrafanaConfig(ctx, config)
 		},
+		func(ctx context.Context, req *http.Request) context.Context {
+			return WithDatasourceCache(ctx, NewDatasourceCache())
+		},
 		ExtractGrafanaInfoFromHeaders,
 		ExtractGrafanaClientFromHeaders,
 		ExtractIncidentClientFromHeaders,
+		extractGrafanaInstanceFromHeaders,
 	)
 }
 
@@ -857,8 +1040,12 @@ func ComposedHTTPContextFunc(config GrafanaConfig) server.HTTPContextFunc {
 		func(ctx context.Context, req *http.Request) context.Context {
 			return WithGrafanaConfig(ctx, config)
 		},
+		func(ctx context.Context, req *http.Request) context.Context {
+			return WithDatasourceCache(ctx, NewDatasourceCache())
+		},
 		ExtractGrafanaInfoFromHeaders,
 		ExtractGrafanaClientFromHeaders,
 		ExtractIncidentClientFromHeaders,
+		extractGrafanaInstanceFromHeaders,
 	)
 }
@@ -0,0 +1,276 @@
+package mcpgrafana
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	grafanaMetadataCacheTTLEnvVar = "GRAFANA_METADATA_CACHE_TTL"
+
+	// maxMetadataCacheEntries caps the number of responses held by a metadata
+	// cache. Once full, the oldest entry is evicted.
+	maxMetadataCacheEntries = 1000
+
+	// maxMetadataCacheBodyBytes is the largest response body cached.
+	maxMetadataCacheBodyBytes = 1 << 20
+)
+
+// metadataCacheTTLFromEnv parses GRAFANA_METADATA_CACHE_TTL, which accepts a
+// number of seconds or a duration such as "30s". Zero, the default, disables
+// the cache.
+func metadataCacheTTLFromEnv() time.Duration {
+	value := os.Getenv(grafanaMetadataCacheTTLEnvVar)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d
+	}
+	slog.Warn("invalid GRAFANA_METADATA_CACHE_TTL value, not caching metadata", "value", value)
+	return 0
+}
+
+// forwardedUserHeaders are the headers commonly used to tell Grafana which
+// end user a request is made on behalf of, e.g. by an auth proxy.
+var forwardedUserHeaders = []string{"X-WEBAUTH-USER", "X-Grafana-User", "X-Grafana-User-Email"}
+
+// ForwardedUser returns the user forwarded to Grafana in the configured extra
+// headers, if any.
+func ForwardedUser(cfg GrafanaConfig) string {
+	for _, name := range forwardedUserHeaders {
+		for k, v := range cfg.ExtraHeaders {
+			if strings.EqualFold(k, name) && v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// metadataPathPattern matches the paths of the metadata requests which may be
+// cached: the datasource and folder lists, and the label names, label values
+// and metric metadata of datasources queried through the datasource proxy.
+var metadataPathPattern = regexp.MustCompile(`^(?:/api/datasources|/api/folders|/api/datasources/proxy/uid/[^/]+(?:/loki)?/api/v1/(?:labels|label/[^/]+/values|metadata))/?$`)
+
+// metadataPostPathPattern matches the metadata requests which the Prometheus
+// client makes as POSTs with form encoded parameters. They don't change
+// anything, so they are cached keyed on their body too.
+var metadataPostPathPattern = regexp.MustCompile(`^/api/datasources/proxy/uid/[^/]+/api/v1/(?:labels|label/[^/]+/values)/?$`)
+
+// identityHeaders are the request headers identifying who a request is made
+// as, or which tenant it is for. They are part of the cache key, so that
+// clients with different credentials never share cached responses.
+var identityHeaders = []string{"Authorization", "Cookie", "X-Grafana-Org-Id", "X-Grafana-Api-Key", TenantIDHeader}
+
+type metadataCacheEntry struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	expires    time.Time
+}
+
+// metadataCache holds cached metadata responses by key.
+type metadataCache struct {
+	mu      sync.Mutex
+	entries map[string]*metadataCacheEntry
+	order   []string
+	now     func() time.Time
+}
+
+func newMetadataCache() *metadataCache {
+	return &metadataCache{
+		entries: make(map[string]*metadataCacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *metadataCache) get(key string) (*metadataCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (c *metadataCache) set(key string, entry *metadataCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		if len(c.order) >= maxMetadataCacheEntries {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = entry
+}
+
+var (
+	metadataCachesMu sync.Mutex
+	// metadataCaches holds one cache per configured TTL. Transports are built
+	// per request, so the cache has to outlive them to be of any use.
+	metadataCaches = map[time.Duration]*metadataCache{}
+)
+
+func sharedMetadataCache(ttl time.Duration) *metadataCache {
+	metadataCachesMu.Lock()
+	defer metadataCachesMu.Unlock()
+	cache, ok := metadataCaches[ttl]
+	if !ok {
+		cache = newMetadataCache()
+		metadataCaches[ttl] = cache
+	}
+	return cache
+}
+
+// MetadataCacheRoundTripper wraps an http.RoundTripper to cache successful
+// responses to metadata requests, such as listing datasources and folders or
+// label names, for a fixed TTL. Agents tend to make the same metadata calls
+// repeatedly within a session, and their results rarely change.
+//
+// Only the requests matched by metadataPathPattern are cached; query results
+// and mutating requests always go to Grafana. Responses are cached per
+// identity, such as the forwarded user, and per credentials sent with the
+// request.
+//
+// All MetadataCacheRoundTrippers created with the same TTL share a cache.
+type MetadataCacheRoundTripper struct {
+	underlying http.RoundTripper
+	cache      *metadataCache
+	ttl        time.Duration
+	identity   string
+}
+
+func NewMetadataCacheRoundTripper(rt http.RoundTripper, ttl time.Duration, identity string) *MetadataCacheRoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &MetadataCacheRoundTripper{
+		underlying: rt,
+		cache:      sharedMetadataCache(ttl),
+		ttl:        ttl,
+		identity:   identity,
+	}
+}
+
+// metadataCacheIdentity identifies who requests made with cfg are made as:
+// the forwarded user and the configured credentials. Credentials are hashed
+// so they aren't held in memory longer than needed.
+func metadataCacheIdentity(cfg *GrafanaConfig) string {
+	h := sha256.New()
+	parts := []string{cfg.URL, ForwardedUser(*cfg), cfg.APIKey, cfg.APIKeyFile, cfg.AccessToken, cfg.IDToken, strconv.FormatInt(cfg.OrgID, 10)}
+	if cfg.BasicAuth != nil {
+		parts = append(parts, cfg.BasicAuth.String())
+	}
+	for _, part := range parts {
+		_, _ = io.WriteString(h, part)
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *MetadataCacheRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, ok, err := t.cacheKey(req)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return t.underlying.RoundTrip(req)
+	}
+
+	if entry, ok := t.cache.get(key); ok {
+		slog.Debug("Serving metadata request from cache", "method", req.Method, "url", req.URL.Redacted())
+		return &http.Response{
+			Status:        strconv.Itoa(entry.statusCode) + " " + http.StatusText(entry.statusCode),
+			StatusCode:    entry.statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        entry.header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(entry.body)),
+			ContentLength: int64(len(entry.body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.underlying.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataCacheBodyBytes+1))
+	if err != nil {
+		_ = resp.Body.Close() //nolint:errcheck
+		return nil, err
+	}
+	if len(body) > maxMetadataCacheBodyBytes {
+		// Too large to cache; hand back the response with its body intact.
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	_ = resp.Body.Close() //nolint:errcheck
+
+	t.cache.set(key, &metadataCacheEntry{
+		statusCode: resp.StatusCode,
+		header:     resp.Header.Clone(),
+		body:       body,
+		expires:    t.cache.now().Add(t.ttl),
+	})
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp, nil
+}
+
+// cacheKey returns the key a metadata request is cached under, or false if
+// the request isn't cacheable.
+func (t *MetadataCacheRoundTripper) cacheKey(req *http.Request) (string, bool, error) {
+	var body []byte
+	switch {
+	case req.Method == http.MethodGet && metadataPathPattern.MatchString(req.URL.Path):
+	case req.Method == http.MethodPost && metadataPostPathPattern.MatchString(req.URL.Path):
+		if req.Body != nil {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			_ = req.Body.Close() //nolint:errcheck
+			if err != nil {
+				return "", false, err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+	default:
+		return "", false, nil
+	}
+
+	h := sha256.New()
+	for _, part := range []string{req.Method, req.URL.String(), t.identity} {
+		_, _ = io.WriteString(h, part)
+		_, _ = h.Write([]byte{0})
+	}
+	for _, name := range identityHeaders {
+		_, _ = io.WriteString(h, strings.Join(req.Header.Values(name), "\x00"))
+		_, _ = h.Write([]byte{0})
+	}
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), true, nil
+}
+
+// readCloser reads from one reader and closes another.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,173 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMetadataCacheRoundTripper returns a MetadataCacheRoundTripper with
+// its own cache and clock, so tests don't share state.
+func newTestMetadataCacheRoundTripper(ttl time.Duration, identity string, now *time.Time) *MetadataCacheRoundTripper {
+	cache := newMetadataCache()
+	cache.now = func() time.Time { return *now }
+	return &MetadataCacheRoundTripper{
+		underlying: http.DefaultTransport,
+		cache:      cache,
+		ttl:        ttl,
+		identity:   identity,
+	}
+}
+
+func TestMetadataCacheRoundTripper(t *testing.T) {
+	get := func(t *testing.T, client *http.Client, u string) string {
+		t.Helper()
+		resp, err := client.Get(u)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("identical metadata calls within the TTL are served from the cache", func(t *testing.T) {
+		server, calls := countingServer(t)
+		now := time.Now()
+		client := &http.Client{Transport: newTestMetadataCacheRoundTripper(time.Minute, "user", &now)}
+
+		assert.Equal(t, "ok", get(t, client, server.URL+"/api/datasources"))
+		assert.Equal(t, "ok", get(t, client, server.URL+"/api/datasources"))
+		assert.Equal(t, int32(1), calls.Load())
+
+		// A different URL is a different entry.
+		get(t, client, server.URL+"/api/folders?page=1")
+		get(t, client, server.URL+"/api/folders?page=2")
+		get(t, client, server.URL+"/api/folders?page=2")
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("entries expire after the TTL", func(t *testing.T) {
+		server, calls := countingServer(t)
+		now := time.Now()
+		client := &http.Client{Transport: newTestMetadataCacheRoundTripper(time.Minute, "user", &now)}
+
+		labels := server.URL + "/api/datasources/proxy/uid/loki/loki/api/v1/labels"
+		get(t, client, labels)
+		now = now.Add(59 * time.Second)
+		get(t, client, labels)
+		assert.Equal(t, int32(1), calls.Load())
+
+		now = now.Add(time.Second)
+		get(t, client, labels)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("queries and mutating calls are never cached", func(t *testing.T) {
+		server, calls := countingServer(t)
+		now := time.Now()
+		client := &http.Client{Transport: newTestMetadataCacheRoundTripper(time.Minute, "user", &now)}
+
+		for range 2 {
+			get(t, client, server.URL+"/api/datasources/proxy/uid/prom/api/v1/query_range")
+			get(t, client, server.URL+"/api/datasources/uid/prom")
+			resp, err := client.Post(server.URL+"/api/folders", "application/json", strings.NewReader(`{"title": "x"}`))
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+		}
+		assert.Equal(t, int32(6), calls.Load())
+	})
+
+	t.Run("Prometheus label requests made as POSTs are cached by body", func(t *testing.T) {
+		server, calls := countingServer(t)
+		now := time.Now()
+		client := &http.Client{Transport: newTestMetadataCacheRoundTripper(time.Minute, "user", &now)}
+
+		labels := server.URL + "/api/datasources/proxy/uid/prom/api/v1/labels"
+		for _, match := range []string{"up", "up", "down"} {
+			resp, err := client.PostForm(labels, url.Values{"match[]": {match}})
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+		}
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("different users and credentials don't share entries", func(t *testing.T) {
+		server, calls := countingServer(t)
+		now := time.Now()
+		rt := newTestMetadataCacheRoundTripper(time.Minute, "alice", &now)
+		other := *rt
+		other.identity = "bob"
+
+		get(t, &http.Client{Transport: rt}, server.URL+"/api/datasources")
+		get(t, &http.Client{Transport: &other}, server.URL+"/api/datasources")
+		assert.Equal(t, int32(2), calls.Load())
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/api/datasources", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer other-token")
+		resp, err := (&http.Client{Transport: rt}).Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("error responses are not cached", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+		now := time.Now()
+		client := &http.Client{Transport: newTestMetadataCacheRoundTripper(time.Minute, "user", &now)}
+
+		for range 2 {
+			resp, err := client.Get(server.URL + "/api/datasources")
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		}
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
+
+func TestMetadataCacheIdentity(t *testing.T) {
+	alice := GrafanaConfig{URL: "http://grafana", ExtraHeaders: map[string]string{"X-Grafana-User-Email": "alice@example.com"}}
+	bob := GrafanaConfig{URL: "http://grafana", ExtraHeaders: map[string]string{"x-grafana-user-email": "bob@example.com"}}
+	assert.Equal(t, "alice@example.com", ForwardedUser(alice))
+	assert.NotEqual(t, metadataCacheIdentity(&alice), metadataCacheIdentity(&bob))
+
+	other := alice
+	other.APIKey = "token"
+	assert.NotEqual(t, metadataCacheIdentity(&alice), metadataCacheIdentity(&other))
+	assert.Equal(t, metadataCacheIdentity(&alice), metadataCacheIdentity(&GrafanaConfig{URL: "http://grafana", ExtraHeaders: map[string]string{"X-Grafana-User-Email": "alice@example.com"}}))
+}
+
+func TestMetadataCacheTTLFromEnv(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"2m", 2 * time.Minute},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+	} {
+		t.Setenv(grafanaMetadataCacheTTLEnvVar, tc.value)
+		assert.Equal(t, tc.expected, metadataCacheTTLFromEnv(), "value %q", tc.value)
+	}
+}
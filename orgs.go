@@ -0,0 +1,151 @@
+package mcpgrafana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/grafana/grafana-openapi-client-go/client/orgs"
+	"github.com/grafana/grafana-openapi-client-go/client/signed_in_user"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// OrgIDParameter is the name of the tool parameter which selects the Grafana
+// organization a tool call is made in. It is added to the input schema of
+// every tool which doesn't already have an org ID parameter of its own.
+const OrgIDParameter = "orgId"
+
+// WithOrgID replaces the Grafana configuration, clients and datasource cache
+// in the context with ones sending requests to the organization with the
+// given ID, in the X-Grafana-Org-Id header. It returns an error if the
+// organization is known not to exist or not to be available to the signed in
+// user, listing the available organizations where possible.
+func WithOrgID(ctx context.Context, orgID int64) (context.Context, error) {
+	config := GrafanaConfigFromContext(ctx)
+	if orgID == config.OrgID {
+		return ctx, nil
+	}
+	if err := validateOrgID(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	config.OrgID = orgID
+	ctx = WithGrafanaConfig(ctx, config)
+	ctx = WithGrafanaClient(ctx, NewGrafanaClient(ctx, config.URL, config.APIKey, config.BasicAuth, orgID))
+	if ic := IncidentClientFromContext(ctx); ic != nil {
+		transport, err := BuildTransport(&config, nil)
+		if err != nil {
+			slog.Error("Failed to create custom transport for incident client, keeping its organization", "error", err)
+		} else {
+			orgClient := *ic
+			orgClient.HTTPClient = &http.Client{Transport: wrapWithUserAgent(NewOrgIDRoundTripper(transport, orgID))}
+			ctx = WithIncidentClient(ctx, &orgClient)
+		}
+	}
+	// Datasource UIDs are only unique within an organization.
+	if DatasourceCacheFromContext(ctx) != nil {
+		ctx = WithDatasourceCache(ctx, NewDatasourceCache())
+	}
+	return ctx, nil
+}
+
+// validateOrgID checks that the signed in user is a member of the
+// organization. Service accounts belong to a single organization and can't
+// list the organizations of a user, so for them the organization is looked
+// up directly, which needs server admin permissions. Validation is skipped if
+// neither is possible, leaving Grafana to reject requests to an organization
+// that isn't available.
+func validateOrgID(ctx context.Context, orgID int64) error {
+	client := GrafanaClientFromContext(ctx)
+	resp, err := client.SignedInUser.GetSignedInUserOrgListWithParams(signed_in_user.NewGetSignedInUserOrgListParamsWithContext(ctx))
+	if err == nil {
+		var available []string
+		for _, org := range resp.Payload {
+			if org.OrgID == orgID {
+				return nil
+			}
+			available = append(available, fmt.Sprintf("%d (%s)", org.OrgID, org.Name))
+		}
+		return fmt.Errorf("unknown organization ID %d, expected one of: %s", orgID, strings.Join(available, ", "))
+	}
+	slog.Debug("Failed to list the organizations of the signed in user, looking the organization up instead", "org_id", orgID, "error", err)
+
+	_, err = client.Orgs.GetOrgByIDWithParams(orgs.NewGetOrgByIDParamsWithContext(ctx).WithOrgID(orgID))
+	var apiErr *runtime.APIError
+	if errors.As(err, &apiErr) && apiErr.IsCode(http.StatusNotFound) {
+		return fmt.Errorf("unknown organization ID %d", orgID)
+	}
+	if err != nil {
+		slog.Debug("Failed to look up the organization, using it without validation", "org_id", orgID, "error", err)
+	}
+	return nil
+}
+
+// withOrgIDFromRequest applies the organization given by the orgId argument
+// of a tool call, if any. The argument's name is matched in any case, so the
+// orgID parameter of tools which have their own applies too.
+func withOrgIDFromRequest(ctx context.Context, request mcp.CallToolRequest) (context.Context, error) {
+	var value any
+	for name, v := range request.GetArguments() {
+		if strings.EqualFold(name, OrgIDParameter) {
+			value = v
+			break
+		}
+	}
+	if value == nil {
+		return ctx, nil
+	}
+	var orgID int64
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) {
+			orgID = int64(v)
+		}
+	case string:
+		orgID, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if orgID <= 0 {
+		return nil, fmt.Errorf("invalid %s %v, must be a positive integer", OrgIDParameter, value)
+	}
+	return WithOrgID(ctx, orgID)
+}
+
+// withOrgIDParameter adds the orgId parameter to a tool's input schema,
+// unless the tool already has a parameter of that name in any case, such as
+// the orgID of an alert rule.
+func withOrgIDParameter(tool mcp.Tool) mcp.Tool {
+	if tool.RawInputSchema == nil {
+		return tool
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(tool.RawInputSchema, &schema); err != nil {
+		return tool
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	for name := range properties {
+		if strings.EqualFold(name, OrgIDParameter) {
+			return tool
+		}
+	}
+	properties[OrgIDParameter] = map[string]any{
+		"type":        "integer",
+		"description": "Optionally, the ID of the Grafana organization to use, as listed by list_organizations. Defaults to the organization configured for the server.",
+	}
+	schema["properties"] = properties
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return tool
+	}
+	tool.RawInputSchema = raw
+	return tool
+}
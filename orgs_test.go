@@ -0,0 +1,210 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orgTestParams struct{}
+
+// orgTestTool reports the org ID configured for the call after making a
+// request with its Grafana client.
+var orgTestTool = MustTool(
+	"org_test",
+	"Report the organization in use",
+	func(ctx context.Context, _ orgTestParams) (int64, error) {
+		if _, err := GrafanaClientFromContext(ctx).Health.GetHealth(); err != nil {
+			return 0, err
+		}
+		return GrafanaConfigFromContext(ctx).OrgID, nil
+	},
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+func TestOrgIDParameter(t *testing.T) {
+	var orgHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/user/orgs":
+			_, _ = w.Write([]byte(`[{"orgId": 1, "name": "Main Org.", "role": "Admin"}, {"orgId": 3, "name": "Ops", "role": "Viewer"}]`))
+		case "/api/health":
+			orgHeader = r.Header.Get("X-Grafana-Org-Id")
+			_, _ = w.Write([]byte(`{"database": "ok", "version": "11.0.0"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("GRAFANA_URL", server.URL)
+	t.Setenv(grafanaServiceAccountTokenEnvVar, "token")
+	ctx := ComposedStdioContextFunc(GrafanaConfig{})(context.Background())
+
+	call := func(t *testing.T, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := orgTestTool.Handler(ctx, request)
+		require.NoError(t, err)
+		return result
+	}
+	text := func(result *mcp.CallToolResult) string {
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("orgId sets the org header", func(t *testing.T) {
+		result := call(t, map[string]any{OrgIDParameter: float64(3)})
+		require.False(t, result.IsError, text(result))
+		assert.Equal(t, "3", text(result))
+		assert.Equal(t, "3", orgHeader)
+	})
+
+	t.Run("configured org is used when omitted", func(t *testing.T) {
+		orgHeader = ""
+		result := call(t, map[string]any{})
+		require.False(t, result.IsError, text(result))
+		assert.Equal(t, "0", text(result))
+		assert.Empty(t, orgHeader)
+	})
+
+	t.Run("unknown org", func(t *testing.T) {
+		result := call(t, map[string]any{OrgIDParameter: float64(7)})
+		require.True(t, result.IsError)
+		assert.Equal(t, "unknown organization ID 7, expected one of: 1 (Main Org.), 3 (Ops)", text(result))
+	})
+
+	t.Run("invalid org", func(t *testing.T) {
+		result := call(t, map[string]any{OrgIDParameter: 1.5})
+		require.True(t, result.IsError)
+		assert.Contains(t, text(result), "invalid orgId 1.5, must be a positive integer")
+	})
+
+	t.Run("orgID spelling", func(t *testing.T) {
+		orgHeader = ""
+		result := call(t, map[string]any{"orgID": float64(3)})
+		require.False(t, result.IsError, text(result))
+		assert.Equal(t, "3", orgHeader)
+	})
+}
+
+func TestOrgIDParameterServiceAccount(t *testing.T) {
+	// Service accounts can't list the organizations of a user; this one can
+	// look up organization 3 but isn't a server admin for others.
+	var orgHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/user/orgs":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "user not found"}`))
+		case "/api/orgs/3":
+			_, _ = w.Write([]byte(`{"id": 3, "name": "Ops"}`))
+		case "/api/orgs/7":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "organization not found"}`))
+		case "/api/health":
+			orgHeader = r.Header.Get("X-Grafana-Org-Id")
+			_, _ = w.Write([]byte(`{"database": "ok", "version": "11.0.0"}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "permission denied"}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("GRAFANA_URL", server.URL)
+	t.Setenv(grafanaServiceAccountTokenEnvVar, "token")
+	ctx := ComposedStdioContextFunc(GrafanaConfig{})(context.Background())
+
+	call := func(t *testing.T, orgID int64) *mcp.CallToolResult {
+		t.Helper()
+		orgHeader = ""
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{OrgIDParameter: float64(orgID)}
+		result, err := orgTestTool.Handler(ctx, request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("org found by ID", func(t *testing.T) {
+		result := call(t, 3)
+		require.False(t, result.IsError)
+		assert.Equal(t, "3", orgHeader)
+	})
+
+	t.Run("unknown org", func(t *testing.T) {
+		result := call(t, 7)
+		require.True(t, result.IsError)
+		assert.Equal(t, "unknown organization ID 7", result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("org can't be looked up", func(t *testing.T) {
+		result := call(t, 5)
+		require.False(t, result.IsError)
+		assert.Equal(t, "5", orgHeader)
+	})
+}
+
+func TestWithOrgIDDatasourceCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"orgId": 1, "name": "Main Org."}, {"orgId": 2, "name": "Ops"}]`))
+	}))
+	defer server.Close()
+
+	ctx := WithGrafanaConfig(context.Background(), GrafanaConfig{URL: server.URL, OrgID: 1})
+	ctx = WithGrafanaClient(ctx, NewGrafanaClient(ctx, server.URL, "token", nil, 1))
+	ctx = WithDatasourceCache(ctx, NewDatasourceCache())
+	org1Cache := DatasourceCacheFromContext(ctx)
+	org1Cache.Set("prometheus", &models.DataSource{UID: "prometheus", OrgID: 1})
+
+	org2, err := WithOrgID(ctx, 2)
+	require.NoError(t, err)
+	org2Cache := DatasourceCacheFromContext(org2)
+	require.NotNil(t, org2Cache)
+	_, ok := org2Cache.Get("prometheus")
+	assert.False(t, ok, "datasources of org 1 must not be visible in org 2")
+
+	org2Cache.Set("prometheus", &models.DataSource{UID: "prometheus", OrgID: 2})
+	ds, ok := org1Cache.Get("prometheus")
+	require.True(t, ok)
+	assert.Equal(t, int64(1), ds.OrgID, "datasources of org 2 must not replace those of org 1")
+
+	same, err := WithOrgID(ctx, 1)
+	require.NoError(t, err)
+	assert.Same(t, org1Cache, DatasourceCacheFromContext(same))
+}
+
+func TestWithOrgIDParameter(t *testing.T) {
+	t.Run("adds the parameter", func(t *testing.T) {
+		tool := withOrgIDParameter(orgTestTool.Tool)
+		var schema struct {
+			Properties map[string]struct {
+				Type string `json:"type"`
+			} `json:"properties"`
+		}
+		require.NoError(t, json.Unmarshal(tool.RawInputSchema, &schema))
+		require.Contains(t, schema.Properties, OrgIDParameter)
+		assert.Equal(t, "integer", schema.Properties[OrgIDParameter].Type)
+	})
+
+	t.Run("tools with their own org ID are left alone", func(t *testing.T) {
+		type params struct {
+			OrgID int64 `json:"orgID"`
+		}
+		tool := MustTool("own_org", "Has an org ID", func(context.Context, params) (string, error) { return "", nil })
+		assert.Equal(t, tool.Tool.RawInputSchema, withOrgIDParameter(tool.Tool).RawInputSchema)
+	})
+}
@@ -0,0 +1,33 @@
+package mcpgrafana
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+const (
+	grafanaDefaultQueryRangeEnvVar = "GRAFANA_DEFAULT_QUERY_RANGE"
+
+	// DefaultQueryRange is how far back query tools look when called without
+	// a start time, unless GRAFANA_DEFAULT_QUERY_RANGE is set.
+	DefaultQueryRange = time.Hour
+)
+
+// defaultQueryRangeFromEnv parses GRAFANA_DEFAULT_QUERY_RANGE, a duration
+// such as "30m", "6h" or "7d", falling back to DefaultQueryRange if it is
+// unset or invalid.
+func defaultQueryRangeFromEnv() time.Duration {
+	value := strings.TrimSpace(os.Getenv(grafanaDefaultQueryRangeEnvVar))
+	if value == "" {
+		return DefaultQueryRange
+	}
+	if d, err := gtime.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	slog.Warn("invalid GRAFANA_DEFAULT_QUERY_RANGE value, using the default", "value", value, "default", DefaultQueryRange)
+	return DefaultQueryRange
+}
@@ -0,0 +1,36 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultQueryRangeFromEnv(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"":        DefaultQueryRange,
+		"30m":     30 * time.Minute,
+		" 6h ":    6 * time.Hour,
+		"7d":      7 * 24 * time.Hour,
+		"0s":      DefaultQueryRange,
+		"-1h":     DefaultQueryRange,
+		"forever": DefaultQueryRange,
+	} {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv(grafanaDefaultQueryRangeEnvVar, value)
+			assert.Equal(t, expected, defaultQueryRangeFromEnv())
+		})
+	}
+
+	t.Run("set in config", func(t *testing.T) {
+		t.Setenv("GRAFANA_URL", "http://localhost:3000")
+		t.Setenv(grafanaDefaultQueryRangeEnvVar, "3h")
+		ctx := ExtractGrafanaInfoFromEnv(context.Background())
+		assert.Equal(t, 3*time.Hour, GrafanaConfigFromContext(ctx).DefaultQueryRange)
+	})
+}
@@ -0,0 +1,156 @@
+package mcpgrafana
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	grafanaRateLimitRPSEnvVar = "GRAFANA_RATE_LIMIT_RPS"
+
+	// defaultRateLimitMaxWait is how long a request waits for the local rate
+	// limiter before failing with ErrRateLimitedLocally.
+	defaultRateLimitMaxWait = 2 * time.Second
+)
+
+// ErrRateLimitedLocally is returned by RateLimitRoundTripper when a request
+// would have to wait longer than allowed for the local rate limit.
+var ErrRateLimitedLocally = errors.New("rate limited locally")
+
+// rateLimitRPSFromEnv parses GRAFANA_RATE_LIMIT_RPS. Zero, the default,
+// means unlimited.
+func rateLimitRPSFromEnv() float64 {
+	rpsStr := os.Getenv(grafanaRateLimitRPSEnvVar)
+	if rpsStr == "" {
+		return 0
+	}
+	rps, err := strconv.ParseFloat(rpsStr, 64)
+	if err != nil || rps < 0 || math.IsNaN(rps) || math.IsInf(rps, 0) {
+		slog.Warn("invalid GRAFANA_RATE_LIMIT_RPS value, not rate limiting", "value", rpsStr)
+		return 0
+	}
+	return rps
+}
+
+// tokenBucket is a token bucket refilled at rate tokens per second, holding
+// at most burst tokens.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	// Allow bursts of up to one second's worth of requests, and at least one.
+	burst := max(1, math.Ceil(rate))
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		now:    time.Now,
+	}
+}
+
+// reserve takes a token, returning how long the caller must wait before
+// using it. If that would be longer than maxWait no token is taken and ok is
+// false.
+func (b *tokenBucket) reserve(maxWait time.Duration) (wait time.Duration, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if wait > maxWait {
+		return 0, false
+	}
+	b.tokens--
+	return wait, true
+}
+
+// cancel returns a token taken by reserve which ended up not being used.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+var (
+	rateLimitersMu sync.Mutex
+	// rateLimiters holds one limiter per configured rate. Transports are
+	// built per request, so the limiter has to outlive them to limit the
+	// process as a whole.
+	rateLimiters = map[float64]*tokenBucket{}
+)
+
+func sharedRateLimiter(rps float64) *tokenBucket {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+	limiter, ok := rateLimiters[rps]
+	if !ok {
+		limiter = newTokenBucket(rps)
+		rateLimiters[rps] = limiter
+	}
+	return limiter
+}
+
+// RateLimitRoundTripper wraps an http.RoundTripper to limit the rate of
+// outbound requests, protecting a shared Grafana from agents which call the
+// same tool in a tight loop. Requests over the limit wait for a token, up to
+// a short deadline, then fail with ErrRateLimitedLocally rather than queueing
+// indefinitely.
+//
+// All RateLimitRoundTrippers created with the same rate share a limiter.
+type RateLimitRoundTripper struct {
+	underlying http.RoundTripper
+	limiter    *tokenBucket
+	maxWait    time.Duration
+}
+
+func NewRateLimitRoundTripper(rt http.RoundTripper, rps float64) *RateLimitRoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &RateLimitRoundTripper{
+		underlying: rt,
+		limiter:    sharedRateLimiter(rps),
+		maxWait:    defaultRateLimitMaxWait,
+	}
+}
+
+func (t *RateLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	wait, ok := t.limiter.reserve(t.maxWait)
+	if !ok {
+		return nil, fmt.Errorf("%w: more than %s requests per second to Grafana, try again later", ErrRateLimitedLocally, strconv.FormatFloat(t.limiter.rate, 'f', -1, 64))
+	}
+	if wait > 0 {
+		slog.Debug("Waiting for local rate limit", "method", req.Method, "url", req.URL.Redacted(), "delay", wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			t.limiter.cancel()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	return t.underlying.RoundTrip(req)
+}
@@ -0,0 +1,152 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingServer responds 200 OK to every request, counting them.
+func countingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// newTestRateLimitRoundTripper returns a RateLimitRoundTripper with its own
+// limiter, so tests don't share state.
+func newTestRateLimitRoundTripper(rps float64, maxWait time.Duration) *RateLimitRoundTripper {
+	return &RateLimitRoundTripper{
+		underlying: http.DefaultTransport,
+		limiter:    newTokenBucket(rps),
+		maxWait:    maxWait,
+	}
+}
+
+func TestRateLimitRoundTripper(t *testing.T) {
+	t.Run("requests beyond the burst fail once the wait is too long", func(t *testing.T) {
+		server, calls := countingServer(t)
+		client := &http.Client{Transport: newTestRateLimitRoundTripper(2, 0)}
+
+		for range 2 {
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+		}
+		_, err := client.Get(server.URL)
+		require.ErrorIs(t, err, ErrRateLimitedLocally)
+		assert.ErrorContains(t, err, "rate limited locally")
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("requests beyond the burst wait for a token", func(t *testing.T) {
+		server, calls := countingServer(t)
+		client := &http.Client{Transport: newTestRateLimitRoundTripper(20, time.Second)}
+
+		start := time.Now()
+		for range 21 {
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+		}
+		// The burst of 20 is used immediately; the 21st waits about 50ms.
+		assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+		assert.Equal(t, int32(21), calls.Load())
+	})
+
+	t.Run("waiting respects context cancellation", func(t *testing.T) {
+		server, calls := countingServer(t)
+		rt := newTestRateLimitRoundTripper(1, time.Second)
+		client := &http.Client{Transport: rt}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		_, err = client.Do(req)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newTokenBucket(2)
+	b.now = func() time.Time { return now }
+
+	for range 2 {
+		wait, ok := b.reserve(0)
+		require.True(t, ok)
+		assert.Zero(t, wait)
+	}
+	_, ok := b.reserve(0)
+	assert.False(t, ok, "bucket should be empty")
+
+	wait, ok := b.reserve(time.Second)
+	require.True(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// After a second the reserved token is paid back and one more is
+	// available, but never more than the burst.
+	now = now.Add(time.Second)
+	wait, ok = b.reserve(0)
+	require.True(t, ok)
+	assert.Zero(t, wait)
+	_, ok = b.reserve(0)
+	assert.False(t, ok)
+
+	now = now.Add(time.Hour)
+	for range 2 {
+		_, ok = b.reserve(0)
+		require.True(t, ok)
+	}
+	_, ok = b.reserve(0)
+	assert.False(t, ok)
+}
+
+func TestRateLimitRPSFromEnv(t *testing.T) {
+	t.Setenv(grafanaRateLimitRPSEnvVar, "")
+	assert.Equal(t, 0.0, rateLimitRPSFromEnv())
+
+	t.Setenv(grafanaRateLimitRPSEnvVar, "10")
+	assert.Equal(t, 10.0, rateLimitRPSFromEnv())
+
+	t.Setenv(grafanaRateLimitRPSEnvVar, "0.5")
+	assert.Equal(t, 0.5, rateLimitRPSFromEnv())
+
+	t.Setenv(grafanaRateLimitRPSEnvVar, "-1")
+	assert.Equal(t, 0.0, rateLimitRPSFromEnv())
+
+	t.Setenv(grafanaRateLimitRPSEnvVar, "fast")
+	assert.Equal(t, 0.0, rateLimitRPSFromEnv())
+}
+
+func TestBuildTransportRateLimit(t *testing.T) {
+	transport, err := BuildTransport(&GrafanaConfig{RateLimitRPS: 1234}, nil)
+	require.NoError(t, err)
+	limited, ok := transport.(*RateLimitRoundTripper)
+	require.True(t, ok)
+
+	// Transports built with the same rate share a limiter.
+	other, err := BuildTransport(&GrafanaConfig{RateLimitRPS: 1234}, nil)
+	require.NoError(t, err)
+	assert.Same(t, limited.limiter, other.(*RateLimitRoundTripper).limiter)
+}
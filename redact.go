@@ -0,0 +1,78 @@
+package mcpgrafana
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-openapi/runtime/logger"
+)
+
+const (
+	grafanaRedactHeadersEnvVar = "GRAFANA_REDACT_HEADERS"
+
+	redactedHeaderValue = "***"
+)
+
+// defaultSensitiveHeaders are the headers whose values are always redacted
+// from logs.
+var defaultSensitiveHeaders = []string{"Authorization", "Cookie", "X-Grafana-Api-Key"}
+
+// sensitiveHeadersFromEnv returns the canonical names of the headers to
+// redact: the defaults plus any listed, comma separated, in
+// GRAFANA_REDACT_HEADERS.
+func sensitiveHeadersFromEnv() map[string]bool {
+	sensitive := make(map[string]bool, len(defaultSensitiveHeaders))
+	for _, name := range defaultSensitiveHeaders {
+		sensitive[http.CanonicalHeaderKey(name)] = true
+	}
+	for _, name := range strings.Split(os.Getenv(grafanaRedactHeadersEnvVar), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			sensitive[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+	return sensitive
+}
+
+// redactHTTPDump replaces the values of sensitive headers in a request or
+// response dumped by net/http/httputil with "***". The body is left as is.
+func redactHTTPDump(dump string, sensitive map[string]bool) string {
+	head, body, found := strings.Cut(dump, "\r\n\r\n")
+	lines := strings.Split(head, "\r\n")
+	// The first line is the request or status line.
+	for i := 1; i < len(lines); i++ {
+		name, _, ok := strings.Cut(lines[i], ":")
+		if ok && sensitive[http.CanonicalHeaderKey(strings.TrimSpace(name))] {
+			lines[i] = name + ": " + redactedHeaderValue
+		}
+	}
+	head = strings.Join(lines, "\r\n")
+	if !found {
+		return head
+	}
+	return head + "\r\n\r\n" + body
+}
+
+// debugLogger is where the Grafana client writes requests and responses in
+// debug mode.
+var debugLogger logger.Logger = logger.StandardLogger{}
+
+// redactingLogger is a go-openapi logger which redacts sensitive headers from
+// the requests and responses the Grafana client dumps in debug mode.
+type redactingLogger struct {
+	underlying logger.Logger
+	sensitive  map[string]bool
+}
+
+func newRedactingLogger(underlying logger.Logger) *redactingLogger {
+	return &redactingLogger{underlying: underlying, sensitive: sensitiveHeadersFromEnv()}
+}
+
+func (l *redactingLogger) Printf(format string, args ...any) {
+	l.underlying.Printf("%s", redactHTTPDump(fmt.Sprintf(format, args...), l.sensitive))
+}
+
+func (l *redactingLogger) Debugf(format string, args ...any) {
+	l.underlying.Debugf("%s", redactHTTPDump(fmt.Sprintf(format, args...), l.sensitive))
+}
@@ -0,0 +1,106 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogger is a go-openapi logger which records what is logged.
+type captureLogger struct {
+	mu  sync.Mutex
+	out strings.Builder
+}
+
+func (l *captureLogger) Printf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.out, format, args...)
+}
+
+func (l *captureLogger) Debugf(format string, args ...any) {
+	l.Printf(format, args...)
+}
+
+func (l *captureLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.out.String()
+}
+
+func TestSensitiveHeadersFromEnv(t *testing.T) {
+	t.Setenv(grafanaRedactHeadersEnvVar, "")
+	assert.Equal(t, map[string]bool{"Authorization": true, "Cookie": true, "X-Grafana-Api-Key": true}, sensitiveHeadersFromEnv())
+
+	t.Setenv(grafanaRedactHeadersEnvVar, "x-custom-token, X-Other ,")
+	assert.Equal(t, map[string]bool{
+		"Authorization":     true,
+		"Cookie":            true,
+		"X-Grafana-Api-Key": true,
+		"X-Custom-Token":    true,
+		"X-Other":           true,
+	}, sensitiveHeadersFromEnv())
+}
+
+func TestRedactingLogger(t *testing.T) {
+	t.Setenv(grafanaRedactHeadersEnvVar, "X-Custom-Token")
+
+	req, err := http.NewRequest(http.MethodPost, "http://grafana:3000/api/search?query=a:b", strings.NewReader(`{"Authorization": "in the body"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Cookie", "grafana_session=secret-session")
+	req.Header.Set("X-Grafana-Api-Key", "secret-api-key")
+	req.Header.Set("X-Custom-Token", "secret-custom")
+	req.Header.Set("X-Request-Id", "abc")
+	dump, err := httputil.DumpRequestOut(req, true)
+	require.NoError(t, err)
+
+	capture := &captureLogger{}
+	newRedactingLogger(capture).Debugf("%s\n", string(dump))
+	out := capture.String()
+
+	for _, secret := range []string{"secret-token", "secret-session", "secret-api-key", "secret-custom"} {
+		assert.NotContains(t, out, secret)
+	}
+	assert.Contains(t, out, "Authorization: ***\r\n")
+	assert.Contains(t, out, "Cookie: ***\r\n")
+	assert.Contains(t, out, "X-Grafana-Api-Key: ***\r\n")
+	assert.Contains(t, out, "X-Custom-Token: ***\r\n")
+	assert.Contains(t, out, "X-Request-Id: abc\r\n")
+	assert.Contains(t, out, "POST /api/search?query=a:b HTTP/1.1\r\n")
+	assert.True(t, strings.HasSuffix(out, `{"Authorization": "in the body"}`+"\n"), "body should be left as is")
+}
+
+func TestGrafanaClientDebugRedactsCredentials(t *testing.T) {
+	capture := &captureLogger{}
+	original := debugLogger
+	debugLogger = capture
+	t.Cleanup(func() { debugLogger = original })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"database": "ok", "version": "11.0.0"}`))
+	}))
+	defer server.Close()
+
+	ctx := WithGrafanaConfig(context.Background(), GrafanaConfig{URL: server.URL, Debug: true})
+	c := NewGrafanaClient(ctx, server.URL, "secret-token", nil, 0)
+	_, err := c.Health.GetHealth()
+	require.NoError(t, err)
+
+	out := capture.String()
+	assert.Contains(t, out, "GET /api/health")
+	assert.Contains(t, out, "Authorization: ***")
+	assert.NotContains(t, out, "secret-token")
+}
@@ -0,0 +1,111 @@
+package mcpgrafana
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	grafanaMaxResponseBytesEnvVar = "GRAFANA_MAX_RESPONSE_BYTES"
+
+	// DefaultMaxResponseBytes is the default limit on the size of the text
+	// returned by a tool call. It is above the size at which query_prometheus
+	// summarizes range query results, so that summarizing them takes
+	// precedence over truncating them.
+	DefaultMaxResponseBytes = 256 * 1024
+)
+
+// MaxResponseBytesFromEnv parses GRAFANA_MAX_RESPONSE_BYTES, defaulting to
+// DefaultMaxResponseBytes. Zero means unlimited.
+func MaxResponseBytesFromEnv() int {
+	limitStr := os.Getenv(grafanaMaxResponseBytesEnvVar)
+	if limitStr == "" {
+		return DefaultMaxResponseBytes
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 0 {
+		slog.Warn("invalid GRAFANA_MAX_RESPONSE_BYTES value, using default", "value", limitStr, "default", DefaultMaxResponseBytes)
+		return DefaultMaxResponseBytes
+	}
+	return limit
+}
+
+// ResponseSizeMiddleware truncates the text returned by tool calls to at
+// most maxBytes, so that a runaway query can't return megabytes which
+// overwhelm the model. Truncated results end with a note saying so and
+// suggesting the query be narrowed. Non-text content, such as rendered
+// images, is left as is. A limit of zero or less disables truncation.
+func ResponseSizeMiddleware(maxBytes int) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		if maxBytes <= 0 {
+			return next
+		}
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil {
+				return result, err
+			}
+			return truncateToolResult(request.Params.Name, result, maxBytes), nil
+		}
+	}
+}
+
+// truncateToolResult returns result with its text content cut down to at
+// most maxBytes in total, including the truncation note, or result itself if
+// it is within the limit.
+func truncateToolResult(tool string, result *mcp.CallToolResult, maxBytes int) *mcp.CallToolResult {
+	size := 0
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			size += len(text.Text)
+		}
+	}
+	if size <= maxBytes {
+		return result
+	}
+
+	slog.Warn("Truncating tool result", "tool", tool, "size", size, "limit", maxBytes)
+	note := fmt.Sprintf("\n\n[truncated: the result was %d bytes, more than the %d byte limit. Narrow the query, for example with a shorter time range, more selective filters or a lower limit, to get a complete result.]", size, maxBytes)
+	budget := max(0, maxBytes-len(note))
+
+	truncated := *result
+	truncated.Content = make([]mcp.Content, 0, len(result.Content))
+	noteAdded := false
+	for _, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			truncated.Content = append(truncated.Content, content)
+			continue
+		}
+		if noteAdded {
+			// Text past the limit is dropped.
+			continue
+		}
+		if len(text.Text) > budget {
+			text.Text = truncateUTF8(text.Text, budget) + note
+			noteAdded = true
+		}
+		budget -= len(text.Text)
+		truncated.Content = append(truncated.Content, text)
+	}
+	return &truncated
+}
+
+// truncateUTF8 returns the longest prefix of s of at most n bytes which
+// doesn't end part way through a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,99 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resultHandler(result *mcp.CallToolResult, err error) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return result, err
+	}
+}
+
+func TestResponseSizeMiddleware(t *testing.T) {
+	t.Run("results under the limit are returned as is", func(t *testing.T) {
+		result := mcp.NewToolResultText(strings.Repeat("a", 100))
+		handler := ResponseSizeMiddleware(100)(resultHandler(result, nil))
+
+		got, err := handler(context.Background(), mcp.CallToolRequest{})
+		require.NoError(t, err)
+		assert.Same(t, result, got)
+	})
+
+	t.Run("results over the limit are truncated with a note", func(t *testing.T) {
+		text := strings.Repeat("a", 2000)
+		handler := ResponseSizeMiddleware(1000)(resultHandler(mcp.NewToolResultText(text), nil))
+
+		got, err := handler(context.Background(), mcp.CallToolRequest{})
+		require.NoError(t, err)
+		require.Len(t, got.Content, 1)
+		truncated := got.Content[0].(mcp.TextContent).Text
+		assert.LessOrEqual(t, len(truncated), 1000)
+		assert.True(t, strings.HasPrefix(truncated, "aaaa"))
+		assert.Contains(t, truncated, "[truncated: the result was 2000 bytes, more than the 1000 byte limit. Narrow the query")
+	})
+
+	t.Run("the limit applies across text content, keeping images", func(t *testing.T) {
+		result := &mcp.CallToolResult{Content: []mcp.Content{
+			mcp.NewTextContent(strings.Repeat("a", 200)),
+			mcp.NewImageContent(strings.Repeat("i", 5000), "image/png"),
+			mcp.NewTextContent(strings.Repeat("b", 800)),
+			mcp.NewTextContent(strings.Repeat("c", 800)),
+		}}
+		handler := ResponseSizeMiddleware(1000)(resultHandler(result, nil))
+
+		got, err := handler(context.Background(), mcp.CallToolRequest{})
+		require.NoError(t, err)
+		require.Len(t, got.Content, 3)
+		assert.Equal(t, strings.Repeat("a", 200), got.Content[0].(mcp.TextContent).Text)
+		assert.IsType(t, mcp.ImageContent{}, got.Content[1])
+		last := got.Content[2].(mcp.TextContent).Text
+		assert.True(t, strings.HasPrefix(last, "bbbb"))
+		assert.Contains(t, last, "[truncated: the result was 1800 bytes")
+		assert.LessOrEqual(t, 200+len(last), 1000)
+
+		// The original result is left alone.
+		assert.Len(t, result.Content, 4)
+	})
+
+	t.Run("multi-byte characters aren't split", func(t *testing.T) {
+		assert.Equal(t, "héllo", truncateUTF8("héllo wörld", 6))
+		assert.Equal(t, "h", truncateUTF8("héllo", 2))
+		assert.Equal(t, "hé", truncateUTF8("héllo", 3))
+	})
+
+	t.Run("errors are passed through", func(t *testing.T) {
+		handler := ResponseSizeMiddleware(10)(resultHandler(nil, errors.New("boom")))
+		_, err := handler(context.Background(), mcp.CallToolRequest{})
+		require.EqualError(t, err, "boom")
+	})
+
+	t.Run("zero disables the limit", func(t *testing.T) {
+		result := mcp.NewToolResultText(strings.Repeat("a", 2000))
+		handler := ResponseSizeMiddleware(0)(resultHandler(result, nil))
+		got, err := handler(context.Background(), mcp.CallToolRequest{})
+		require.NoError(t, err)
+		assert.Same(t, result, got)
+	})
+}
+
+func TestMaxResponseBytesFromEnv(t *testing.T) {
+	t.Setenv(grafanaMaxResponseBytesEnvVar, "")
+	assert.Equal(t, DefaultMaxResponseBytes, MaxResponseBytesFromEnv())
+	t.Setenv(grafanaMaxResponseBytesEnvVar, "1024")
+	assert.Equal(t, 1024, MaxResponseBytesFromEnv())
+	t.Setenv(grafanaMaxResponseBytesEnvVar, "0")
+	assert.Equal(t, 0, MaxResponseBytesFromEnv())
+	t.Setenv(grafanaMaxResponseBytesEnvVar, "lots")
+	assert.Equal(t, DefaultMaxResponseBytes, MaxResponseBytesFromEnv())
+}
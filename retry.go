@@ -0,0 +1,167 @@
+package mcpgrafana
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	grafanaHTTPMaxRetriesEnvVar = "GRAFANA_HTTP_MAX_RETRIES"
+
+	// DefaultMaxRetries is the number of times a request which failed with a
+	// transient error is retried when GRAFANA_HTTP_MAX_RETRIES is not set.
+	DefaultMaxRetries = 3
+
+	defaultRetryBaseDelay = 200 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+	// maxRetryAfter caps how long a Retry-After header can make us wait.
+	maxRetryAfter = 30 * time.Second
+)
+
+func maxRetriesFromEnv() int {
+	maxRetriesStr := os.Getenv(grafanaHTTPMaxRetriesEnvVar)
+	if maxRetriesStr == "" {
+		return DefaultMaxRetries
+	}
+	maxRetries, err := strconv.Atoi(maxRetriesStr)
+	if err != nil || maxRetries < 0 {
+		slog.Warn("invalid GRAFANA_HTTP_MAX_RETRIES value, using default", "value", maxRetriesStr, "default", DefaultMaxRetries)
+		return DefaultMaxRetries
+	}
+	return maxRetries
+}
+
+// RetryRoundTripper wraps an http.RoundTripper to retry requests which fail
+// with a transient error: a 429, 502, 503 or 504 response, or a connection
+// reset. Retries use exponential backoff with jitter, honoring the
+// Retry-After header of 429 responses.
+//
+// Requests with non-idempotent methods are only retried on 429 responses,
+// since other failures may have happened after the request was processed.
+// Requests whose body can't be replayed are never retried.
+type RetryRoundTripper struct {
+	underlying http.RoundTripper
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+func NewRetryRoundTripper(rt http.RoundTripper, maxRetries int) *RetryRoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &RetryRoundTripper{
+		underlying: rt,
+		maxRetries: maxRetries,
+		baseDelay:  defaultRetryBaseDelay,
+		maxDelay:   defaultRetryMaxDelay,
+	}
+}
+
+func (t *RetryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.underlying.RoundTrip(attemptReq)
+		if attempt >= t.maxRetries || !replayable || !t.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if resp.StatusCode == http.StatusTooManyRequests {
+				if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+					delay = retryAfter
+				}
+			}
+			// Drain the body so the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+		}
+		slog.Debug("Retrying Grafana request", "method", req.Method, "url", req.URL.Redacted(), "attempt", attempt+1, "delay", delay, "status", statusCode(resp), "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry reports whether the outcome of a request is a transient failure
+// worth retrying.
+func (t *RetryRoundTripper) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return isIdempotent(req.Method) && req.Context().Err() == nil && errors.Is(err, syscall.ECONNRESET)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(req.Method)
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry attempt: exponential in
+// the attempt number, capped at maxDelay, with up to 50% jitter.
+func (t *RetryRoundTripper) backoff(attempt int) time.Duration {
+	delay := t.maxDelay
+	if attempt < 30 {
+		delay = min(t.baseDelay<<attempt, t.maxDelay)
+	}
+	if half := int64(delay / 2); half > 0 {
+		delay = time.Duration(half + rand.Int64N(half+1))
+	}
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = time.Until(date)
+	} else {
+		return 0, false
+	}
+	return max(0, min(delay, maxRetryAfter)), true
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func statusCode(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
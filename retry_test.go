@@ -0,0 +1,207 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRetryRoundTripper returns a RetryRoundTripper with delays short
+// enough for tests.
+func newTestRetryRoundTripper(maxRetries int) *RetryRoundTripper {
+	rt := NewRetryRoundTripper(nil, maxRetries)
+	rt.baseDelay = time.Millisecond
+	rt.maxDelay = 5 * time.Millisecond
+	return rt
+}
+
+// failingServer responds with the given status for the first failures
+// requests, then with 200 OK.
+func failingServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("transient error"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetryRoundTripper(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		t.Run(http.StatusText(status)+" fails twice then succeeds", func(t *testing.T) {
+			server, calls := failingServer(t, 2, status, nil)
+			client := &http.Client{Transport: newTestRetryRoundTripper(3)}
+
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close() //nolint:errcheck
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, int32(3), calls.Load())
+		})
+	}
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		server, calls := failingServer(t, 10, http.StatusBadGateway, nil)
+		client := &http.Client{Transport: newTestRetryRoundTripper(2)}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		server, calls := failingServer(t, 2, http.StatusInternalServerError, nil)
+		client := &http.Client{Transport: newTestRetryRoundTripper(3)}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("zero retries disables retrying", func(t *testing.T) {
+		server, calls := failingServer(t, 2, http.StatusServiceUnavailable, nil)
+		client := &http.Client{Transport: newTestRetryRoundTripper(0)}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("non-idempotent requests are only retried on 429", func(t *testing.T) {
+		server, calls := failingServer(t, 2, http.StatusBadGateway, nil)
+		client := &http.Client{Transport: newTestRetryRoundTripper(3)}
+
+		resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("replays request body on retry", func(t *testing.T) {
+		var bodies []string
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b := make([]byte, r.ContentLength)
+			_, _ = r.Body.Read(b)
+			bodies = append(bodies, string(b))
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+		client := &http.Client{Transport: newTestRetryRoundTripper(3)}
+
+		resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"a":1}`))
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{`{"a":1}`, `{"a":1}`}, bodies)
+	})
+
+	t.Run("honors Retry-After on 429", func(t *testing.T) {
+		server, calls := failingServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+		client := &http.Client{Transport: newTestRetryRoundTripper(3)}
+
+		start := time.Now()
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("stops waiting when the context is cancelled", func(t *testing.T) {
+		server, _ := failingServer(t, 10, http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}})
+		client := &http.Client{Transport: newTestRetryRoundTripper(3)}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		_, err = client.Do(req)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	d, ok := parseRetryAfter("2")
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, d)
+
+	d, ok = parseRetryAfter("3600")
+	assert.True(t, ok)
+	assert.Equal(t, maxRetryAfter, d)
+
+	d, ok = parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), d)
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("")
+	assert.False(t, ok)
+}
+
+func TestMaxRetriesFromEnv(t *testing.T) {
+	t.Setenv(grafanaHTTPMaxRetriesEnvVar, "")
+	assert.Equal(t, DefaultMaxRetries, maxRetriesFromEnv())
+
+	t.Setenv(grafanaHTTPMaxRetriesEnvVar, "5")
+	assert.Equal(t, 5, maxRetriesFromEnv())
+
+	t.Setenv(grafanaHTTPMaxRetriesEnvVar, "0")
+	assert.Equal(t, 0, maxRetriesFromEnv())
+
+	t.Setenv(grafanaHTTPMaxRetriesEnvVar, "-1")
+	assert.Equal(t, DefaultMaxRetries, maxRetriesFromEnv())
+
+	t.Setenv(grafanaHTTPMaxRetriesEnvVar, "many")
+	assert.Equal(t, DefaultMaxRetries, maxRetriesFromEnv())
+}
+
+func TestBuildTransportRetries(t *testing.T) {
+	server, calls := failingServer(t, 2, http.StatusServiceUnavailable, nil)
+
+	transport, err := BuildTransport(&GrafanaConfig{MaxRetries: 3}, nil)
+	require.NoError(t, err)
+	retry, ok := transport.(*RetryRoundTripper)
+	require.True(t, ok)
+	retry.baseDelay = time.Millisecond
+	retry.maxDelay = 5 * time.Millisecond
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
@@ -0,0 +1,52 @@
+package mcpgrafana
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	sseKeepAliveIntervalEnvVar = "GRAFANA_SSE_KEEPALIVE_INTERVAL"
+
+	// DefaultSSEKeepAliveInterval is how often a ping is sent on idle SSE
+	// connections when GRAFANA_SSE_KEEPALIVE_INTERVAL is not set.
+	DefaultSSEKeepAliveInterval = 30 * time.Second
+)
+
+// sseKeepAliveIntervalFromEnv returns the keepalive interval configured by
+// GRAFANA_SSE_KEEPALIVE_INTERVAL, which accepts a number of seconds or a
+// duration such as "45s". Zero disables keepalive pings.
+func sseKeepAliveIntervalFromEnv() time.Duration {
+	value := os.Getenv(sseKeepAliveIntervalEnvVar)
+	if value == "" {
+		return DefaultSSEKeepAliveInterval
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d
+	}
+	slog.Warn("invalid GRAFANA_SSE_KEEPALIVE_INTERVAL value, using default", "value", value, "default", DefaultSSEKeepAliveInterval)
+	return DefaultSSEKeepAliveInterval
+}
+
+// SSEKeepAliveOption returns an SSE server option which sends a ping on each
+// SSE connection at the given interval, so that proxies don't drop idle
+// connections. An interval of zero disables pings.
+func SSEKeepAliveOption(interval time.Duration) server.SSEOption {
+	if interval <= 0 {
+		return server.WithKeepAlive(false)
+	}
+	return server.WithKeepAliveInterval(interval)
+}
+
+// SSEKeepAliveOptionFromEnv returns an SSEKeepAliveOption using the interval
+// configured by GRAFANA_SSE_KEEPALIVE_INTERVAL.
+func SSEKeepAliveOptionFromEnv() server.SSEOption {
+	return SSEKeepAliveOption(sseKeepAliveIntervalFromEnv())
+}
@@ -0,0 +1,83 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEKeepAliveIntervalFromEnv(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", DefaultSSEKeepAliveInterval},
+		{"10", 10 * time.Second},
+		{"1m", time.Minute},
+		{"0", 0},
+		{"-5", DefaultSSEKeepAliveInterval},
+		{"often", DefaultSSEKeepAliveInterval},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			t.Setenv(sseKeepAliveIntervalEnvVar, tc.value)
+			assert.Equal(t, tc.expected, sseKeepAliveIntervalFromEnv())
+		})
+	}
+}
+
+// readSSEEvents starts an SSE server with the given option, connects to its
+// SSE endpoint and returns the data lines received before the timeout elapses.
+func readSSEEvents(t *testing.T, opt server.SSEOption, timeout time.Duration) []string {
+	t.Helper()
+	srv := server.NewSSEServer(server.NewMCPServer("test", "1.0.0"), opt)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/sse", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data:"); ok {
+			lines = append(lines, data)
+		}
+	}
+	return lines
+}
+
+func TestSSEKeepAliveOption(t *testing.T) {
+	t.Run("pings are sent at the interval", func(t *testing.T) {
+		lines := readSSEEvents(t, SSEKeepAliveOption(20*time.Millisecond), 200*time.Millisecond)
+		pings := 0
+		for _, line := range lines {
+			if strings.Contains(line, `"method":"ping"`) {
+				pings++
+			}
+		}
+		assert.GreaterOrEqual(t, pings, 2, "expected several ping frames, got %v", lines)
+	})
+
+	t.Run("zero disables pings", func(t *testing.T) {
+		lines := readSSEEvents(t, SSEKeepAliveOption(0), 200*time.Millisecond)
+		for _, line := range lines {
+			assert.NotContains(t, line, `"method":"ping"`)
+		}
+	})
+}
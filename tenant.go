@@ -0,0 +1,68 @@
+package mcpgrafana
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	grafanaDefaultTenantIDEnvVar = "GRAFANA_DEFAULT_TENANT_ID"
+
+	// TenantIDHeader is the header multi-tenant Loki and Mimir datasources
+	// read the tenant of a query from.
+	TenantIDHeader = "X-Scope-OrgID"
+)
+
+// defaultTenantIDFromEnv returns GRAFANA_DEFAULT_TENANT_ID with surrounding
+// whitespace removed.
+func defaultTenantIDFromEnv() string {
+	return strings.TrimSpace(os.Getenv(grafanaDefaultTenantIDEnvVar))
+}
+
+// TenantIDRoundTripper wraps an http.RoundTripper to add the X-Scope-OrgID
+// header, selecting the tenant queried on multi-tenant Loki and Mimir
+// datasources.
+type TenantIDRoundTripper struct {
+	underlying http.RoundTripper
+	tenantID   string
+}
+
+func (t *TenantIDRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// clone the request to avoid modifying the original
+	clonedReq := req.Clone(req.Context())
+	clonedReq.Header.Set(TenantIDHeader, t.tenantID)
+	return t.underlying.RoundTrip(clonedReq)
+}
+
+// NewTenantIDRoundTripper returns rt wrapped to send tenantID in the
+// X-Scope-OrgID header. If tenantID is empty it falls back to the
+// DefaultTenantID of cfg, and if that is empty too rt is returned unchanged.
+// A tenant forwarded from the client in cfg.ExtraHeaders always takes
+// precedence over the default; an explicit tenantID which differs from it is
+// a validation error rather than being silently replaced.
+func NewTenantIDRoundTripper(rt http.RoundTripper, cfg GrafanaConfig, tenantID string) (http.RoundTripper, error) {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for name, forwarded := range cfg.ExtraHeaders {
+		if !strings.EqualFold(name, TenantIDHeader) {
+			continue
+		}
+		if tenantID != "" && tenantID != forwarded {
+			return nil, NewError(ErrKindValidation, fmt.Sprintf("tenantId %q conflicts with the tenant %q set in the %s header of the request", tenantID, forwarded, TenantIDHeader), nil)
+		}
+		return rt, nil
+	}
+	if tenantID == "" {
+		tenantID = cfg.DefaultTenantID
+	}
+	if tenantID == "" {
+		return rt, nil
+	}
+	return &TenantIDRoundTripper{
+		underlying: rt,
+		tenantID:   tenantID,
+	}, nil
+}
@@ -0,0 +1,71 @@
+//go:build unit
+// +build unit
+
+package mcpgrafana
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantIDRoundTripper(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(TenantIDHeader)
+	}))
+	defer server.Close()
+
+	do := func(t *testing.T, cfg GrafanaConfig, tenantID string) string {
+		t.Helper()
+		got = ""
+		transport, err := BuildTransport(&cfg, nil)
+		require.NoError(t, err)
+		transport, err = NewTenantIDRoundTripper(transport, cfg, tenantID)
+		require.NoError(t, err)
+		client := &http.Client{Transport: transport}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return got
+	}
+
+	t.Run("explicit tenant", func(t *testing.T) {
+		assert.Equal(t, "team-a", do(t, GrafanaConfig{DefaultTenantID: "default"}, "team-a"))
+	})
+
+	t.Run("default tenant", func(t *testing.T) {
+		assert.Equal(t, "default", do(t, GrafanaConfig{DefaultTenantID: "default"}, ""))
+	})
+
+	t.Run("no tenant", func(t *testing.T) {
+		assert.Empty(t, do(t, GrafanaConfig{}, ""))
+	})
+
+	forwarded := GrafanaConfig{DefaultTenantID: "default", ExtraHeaders: map[string]string{"x-scope-orgid": "forwarded"}}
+
+	t.Run("forwarded header takes precedence over the default", func(t *testing.T) {
+		assert.Equal(t, "forwarded", do(t, forwarded, ""))
+	})
+
+	t.Run("explicit tenant matching the forwarded header", func(t *testing.T) {
+		assert.Equal(t, "forwarded", do(t, forwarded, "forwarded"))
+	})
+
+	t.Run("explicit tenant conflicting with the forwarded header", func(t *testing.T) {
+		_, err := NewTenantIDRoundTripper(nil, forwarded, "team-a")
+		require.EqualError(t, err, `tenantId "team-a" conflicts with the tenant "forwarded" set in the X-Scope-OrgID header of the request`)
+		assert.Equal(t, ErrKindValidation, ErrorKindOf(err))
+	})
+}
+
+func TestDefaultTenantIDFromEnv(t *testing.T) {
+	t.Setenv("GRAFANA_URL", "http://localhost:3000")
+	t.Setenv(grafanaDefaultTenantIDEnvVar, " tenant-1 ")
+	ctx := ExtractGrafanaInfoFromEnv(context.Background())
+	assert.Equal(t, "tenant-1", GrafanaConfigFromContext(ctx).DefaultTenantID)
+}
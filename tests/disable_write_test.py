@@ -37,6 +37,7 @@ async def test_disable_write_flag_disables_write_tools(grafana_env):
                 "update_dashboard",
                 "create_folder",
                 "create_incident",
+                "add_incident_activity",
                 "add_activity_to_incident",
                 "create_alert_rule",
                 "update_alert_rule",
@@ -46,6 +47,7 @@ async def test_disable_write_flag_disables_write_tools(grafana_env):
                 "update_annotation",
                 "patch_annotation",
                 "find_error_pattern_logs",
+                "run_sift_error_pattern_logs",
                 "find_slow_requests",
             ]
             
@@ -57,6 +59,7 @@ async def test_disable_write_flag_disables_write_tools(grafana_env):
                 "get_dashboard_by_uid",
                 "list_alert_rules",
                 "get_alert_rule_by_uid",
+                "get_alert_rule",
                 "list_contact_points",
                 "list_incidents",
                 "get_incident",
@@ -89,6 +92,7 @@ async def test_without_disable_write_flag_enables_write_tools(grafana_env):
                 "update_dashboard",
                 "create_folder",
                 "create_incident",
+                "add_incident_activity",
                 "add_activity_to_incident",
                 "create_alert_rule",
                 "update_alert_rule",
@@ -98,6 +102,7 @@ async def test_without_disable_write_flag_enables_write_tools(grafana_env):
                 "update_annotation",
                 "patch_annotation",
                 "find_error_pattern_logs",
+                "run_sift_error_pattern_logs",
                 "find_slow_requests",
             ]
             
@@ -109,6 +114,7 @@ async def test_without_disable_write_flag_enables_write_tools(grafana_env):
                 "get_dashboard_by_uid",
                 "list_alert_rules",
                 "get_alert_rule_by_uid",
+                "get_alert_rule",
                 "list_contact_points",
                 "list_incidents",
                 "get_incident",
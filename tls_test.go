@@ -1,9 +1,22 @@
 package mcpgrafana
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,6 +50,12 @@ func TestTLSConfig_CreateTLSConfig(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to load client certificate")
 	})
 
+	t.Run("client certificate without key", func(t *testing.T) {
+		config := &TLSConfig{CertFile: "client.pem"}
+		_, err := config.CreateTLSConfig()
+		assert.EqualError(t, err, "both a client certificate and key file are required for mutual TLS")
+	})
+
 	t.Run("invalid CA file", func(t *testing.T) {
 		config := &TLSConfig{
 			CAFile: "nonexistent-ca.pem",
@@ -47,6 +66,138 @@ func TestTLSConfig_CreateTLSConfig(t *testing.T) {
 	})
 }
 
+func TestTLSConfigFromEnv(t *testing.T) {
+	t.Setenv(grafanaTLSCAFileEnvVar, "/certs/ca.pem")
+	t.Setenv(grafanaTLSClientCertFileEnvVar, "/certs/client.pem")
+	t.Setenv(grafanaTLSClientKeyFileEnvVar, "/certs/client.key")
+	assert.Equal(t, TLSConfig{
+		CertFile: "/certs/client.pem",
+		KeyFile:  "/certs/client.key",
+		CAFile:   "/certs/ca.pem",
+	}, TLSConfigFromEnv())
+}
+
+// testCA is a certificate authority for issuing test certificates.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate for localhost signed by the CA, along with its
+// PEM encoded certificate and key.
+func (ca *testCA) issue(t *testing.T, usage x509.ExtKeyUsage) (tls.Certificate, []byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return cert, certPEM, keyPEM
+}
+
+// newTLSServer starts a server presenting a certificate signed by ca.
+func newTLSServer(t *testing.T, ca *testCA, clientCAs *x509.CertPool) *httptest.Server {
+	t.Helper()
+	cert, _, _ := ca.issue(t, x509.ExtKeyUsageServerAuth)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	if clientCAs != nil {
+		server.TLS.ClientCAs = clientCAs
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeTempFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func TestTLSConfigCustomCA(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := writeTempFile(t, "ca.pem", ca.pem)
+
+	get := func(t *testing.T, cfg *TLSConfig, url string) error {
+		t.Helper()
+		transport, err := BuildTransport(&GrafanaConfig{TLSConfig: cfg}, nil)
+		require.NoError(t, err)
+		resp, err := (&http.Client{Transport: transport}).Get(url)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		return nil
+	}
+
+	t.Run("server signed by the custom CA", func(t *testing.T) {
+		server := newTLSServer(t, ca, nil)
+		assert.NoError(t, get(t, &TLSConfig{CAFile: caFile}, server.URL))
+	})
+
+	t.Run("server signed by an unknown CA", func(t *testing.T) {
+		server := newTLSServer(t, newTestCA(t), nil)
+		err := get(t, &TLSConfig{CAFile: caFile}, server.URL)
+		var unknownAuthority x509.UnknownAuthorityError
+		assert.ErrorAs(t, err, &unknownAuthority)
+	})
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		clientCAs := x509.NewCertPool()
+		clientCAs.AddCert(ca.cert)
+		server := newTLSServer(t, ca, clientCAs)
+
+		_, certPEM, keyPEM := ca.issue(t, x509.ExtKeyUsageClientAuth)
+		certFile := writeTempFile(t, "client.pem", certPEM)
+		keyFile := writeTempFile(t, "client.key", keyPEM)
+		assert.NoError(t, get(t, &TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}, server.URL))
+
+		// Without a client certificate the server rejects the handshake.
+		assert.Error(t, get(t, &TLSConfig{CAFile: caFile}, server.URL))
+	})
+}
+
 func TestHTTPTransport(t *testing.T) {
 	t.Run("nil TLS config", func(t *testing.T) {
 		var tlsConfig *TLSConfig
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
+	"sync"
 
 	"github.com/invopop/jsonschema"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -22,6 +24,38 @@ import (
 type Tool struct {
 	Tool    mcp.Tool
 	Handler server.ToolHandlerFunc
+	// Mutating indicates that the tool modifies state, and so must not be
+	// registered when the server runs in read-only mode. MustMutatingTool
+	// sets it.
+	Mutating bool
+}
+
+// IsMutating reports whether the given tool may modify state, based on its
+// read-only hint annotation. Tools without a read-only hint are assumed to
+// be mutating. Read-only mode is decided by Tool.Mutating; this is only a
+// cross-check of the annotations clients see.
+func IsMutating(tool mcp.Tool) bool {
+	return tool.Annotations.ReadOnlyHint == nil || !*tool.Annotations.ReadOnlyHint
+}
+
+// readOnlyServers holds the servers in read-only mode, to which Register
+// doesn't add mutating tools.
+var readOnlyServers sync.Map
+
+// SetReadOnly sets whether the given server is in read-only mode. Mutating
+// tools registered on a server in read-only mode are skipped.
+func SetReadOnly(s *server.MCPServer, readOnly bool) {
+	if readOnly {
+		readOnlyServers.Store(s, struct{}{})
+	} else {
+		readOnlyServers.Delete(s)
+	}
+}
+
+// IsReadOnly reports whether the given server is in read-only mode.
+func IsReadOnly(s *server.MCPServer) bool {
+	_, ok := readOnlyServers.Load(s)
+	return ok
 }
 
 // HardError wraps an error to indicate it should propagate as a JSON-RPC protocol
@@ -44,8 +78,18 @@ func (e *HardError) Unwrap() error {
 // allowing fluent tool registration in a single statement:
 //
 //	mcpgrafana.MustTool(name, description, toolHandler).Register(server)
+//
+// The tool is registered with an additional orgId parameter selecting the
+// Grafana organization to use and, when GRAFANA_INSTANCES is set, an
+// instance parameter selecting which Grafana instance to use.
+//
+// Mutating tools are skipped if the server is in read-only mode.
 func (t *Tool) Register(mcp *server.MCPServer) {
-	mcp.AddTool(t.Tool, t.Handler)
+	if t.Mutating && IsReadOnly(mcp) {
+		slog.Debug("Skipping mutating tool in read-only mode", "tool", t.Tool.Name)
+		return
+	}
+	mcp.AddTool(withOrgIDParameter(withInstanceParameter(t.Tool, grafanaInstancesFromEnv())), t.Handler)
 }
 
 // MustTool creates a new Tool from the given name, description, and toolHandler.
@@ -62,6 +106,23 @@ func MustTool[T any, R any](
 	return Tool{Tool: tool, Handler: handler}
 }
 
+// MustMutatingTool is like MustTool, for tools which modify state. The
+// returned Tool has Mutating set, so it isn't registered in read-only mode.
+// It panics if the tool is annotated as read-only, which would tell clients
+// the opposite.
+func MustMutatingTool[T any, R any](
+	name, description string,
+	toolHandler ToolHandlerFunc[T, R],
+	options ...mcp.ToolOption,
+) Tool {
+	t := MustTool(name, description, toolHandler, options...)
+	if !IsMutating(t.Tool) {
+		panic(fmt.Sprintf("mutating tool %s is annotated as read-only", name))
+	}
+	t.Mutating = true
+	return t
+}
+
 // ToolHandlerFunc is the type of a handler function for a tool.
 // T is the request parameter type (must be a struct with jsonschema tags), and R is the response type which can be a string, struct, or *mcp.CallToolResult.
 type ToolHandlerFunc[T any, R any] = func(ctx context.Context, request T) (R, error)
@@ -108,6 +169,23 @@ func ConvertTool[T any, R any](name, description string, toolHandler ToolHandler
 			attribute.String("mcp.tool.description", description),
 		)
 
+		// Datasources are cached for the duration of a single tool call, so
+		// that edits and deletions are seen by the next call even though the
+		// context of a stdio server or an SSE session lives much longer.
+		if DatasourceCacheFromContext(ctx) != nil {
+			ctx = WithDatasourceCache(ctx, NewDatasourceCache())
+		}
+
+		ctx, err := withGrafanaInstanceFromRequest(ctx, request)
+		if err == nil {
+			ctx, err = withOrgIDFromRequest(ctx, request)
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
 		argBytes, err := json.Marshal(request.Params.Arguments)
 		if err != nil {
 			span.RecordError(err)
@@ -124,7 +202,7 @@ func ConvertTool[T any, R any](name, description string, toolHandler ToolHandler
 		if err := json.Unmarshal(argBytes, unmarshaledArgs); err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "failed to unmarshal arguments")
-			return nil, fmt.Errorf("unmarshal args: %s", err)
+			return toolErrorResult(NewError(ErrKindValidation, "invalid arguments", err)), nil
 		}
 
 		// Need to dereference the unmarshaled arguments
@@ -174,15 +252,10 @@ func ConvertTool[T any, R any](name, description string, toolHandler ToolHandler
 			if errors.As(handlerErr, &hardErr) {
 				return nil, hardErr.Err
 			}
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{
-						Type: "text",
-						Text: handlerErr.Error(),
-					},
-				},
-				IsError: true,
-			}, nil
+			if kind := ErrorKindOf(handlerErr); kind != "" {
+				span.SetAttributes(attribute.String("mcp.tool.error_kind", string(kind)))
+			}
+			return toolErrorResult(handlerErr), nil
 		}
 
 		// Tool execution completed successfully
@@ -265,6 +338,9 @@ func ConvertTool[T any, R any](name, description string, toolHandler ToolHandler
 	for _, option := range options {
 		option(&t)
 	}
+	if err := checkToolExamples(t, argType); err != nil {
+		return zero, nil, fmt.Errorf("tool %s: %w", name, err)
+	}
 	return t, handler, nil
 }
 
@@ -296,3 +372,22 @@ var (
 		CommentMap:                 nil,
 	}
 )
+
+// toolErrorResult converts an error returned by a tool into a result with
+// IsError set, recording the kind of error, if known, in its _meta field
+// under ErrorKindMetaKey.
+func toolErrorResult(err error) *mcp.CallToolResult {
+	result := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: err.Error(),
+			},
+		},
+		IsError: true,
+	}
+	if kind := ErrorKindOf(err); kind != "" {
+		result.Meta = mcp.NewMetaFromMap(map[string]any{ErrorKindMetaKey: string(kind)})
+	}
+	return result
+}
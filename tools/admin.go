@@ -2,13 +2,20 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
 	"github.com/grafana/grafana-openapi-client-go/client/access_control"
+	"github.com/grafana/grafana-openapi-client-go/client/dashboards"
 	"github.com/grafana/grafana-openapi-client-go/client/org"
+	"github.com/grafana/grafana-openapi-client-go/client/signed_in_user"
 	"github.com/grafana/grafana-openapi-client-go/client/teams"
 	"github.com/grafana/grafana-openapi-client-go/models"
 	mcpgrafana "github.com/grafana/mcp-grafana"
@@ -33,13 +40,53 @@ func listTeams(ctx context.Context, args ListTeamsParams) (*models.SearchTeamQue
 
 var ListTeams = mcpgrafana.MustTool(
 	"list_teams",
-	"Search for Grafana teams by a query string. Returns a list of matching teams with details like name, ID, and URL.",
+	"Search for Grafana teams by a query string. Returns a list of matching teams with details like name, ID, member count, and URL. Use get_team_members to list the members of a team.",
 	listTeams,
 	mcp.WithTitleAnnotation("List teams"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+type GetTeamMembersParams struct {
+	TeamID int64 `json:"teamId" jsonschema:"required,description=The ID of the team\\, as returned by list_teams"`
+}
+
+type teamMemberSummary struct {
+	UserID int64  `json:"userId"`
+	Login  string `json:"login"`
+	Email  string `json:"email,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+func getTeamMembers(ctx context.Context, args GetTeamMembersParams) ([]teamMemberSummary, error) {
+	if args.TeamID <= 0 {
+		return nil, fmt.Errorf("invalid teamId: %d, must be greater than 0", args.TeamID)
+	}
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	params := teams.NewGetTeamMembersParamsWithContext(ctx).WithTeamID(strconv.FormatInt(args.TeamID, 10))
+	resp, err := c.Teams.GetTeamMembersWithParams(params)
+	if err != nil {
+		return nil, fmt.Errorf("get members of team %d: %w", args.TeamID, err)
+	}
+	members := make([]teamMemberSummary, 0, len(resp.Payload))
+	for _, m := range resp.Payload {
+		if m == nil {
+			continue
+		}
+		members = append(members, teamMemberSummary{UserID: m.UserID, Login: m.Login, Email: m.Email, Name: m.Name})
+	}
+	return members, nil
+}
+
+var GetTeamMembers = mcpgrafana.MustTool(
+	"get_team_members",
+	"List the members of a Grafana team by its ID, with their login, email and name. Useful for finding who to route an incident or alert to.",
+	getTeamMembers,
+	mcp.WithTitleAnnotation("Get team members"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
 type ListUsersByOrgParams struct{}
 
 func listUsersByOrg(ctx context.Context, args ListUsersByOrgParams) ([]*models.OrgUserDTO, error) {
@@ -62,6 +109,132 @@ var ListUsersByOrg = mcpgrafana.MustTool(
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+const DefaultListUsersLimit = 100
+
+type ListUsersParams struct {
+	Query string `json:"query,omitempty" jsonschema:"description=Optionally\\, only return users whose login\\, email or name contains this string\\, ignoring case"`
+	Limit int    `json:"limit,omitempty" jsonschema:"default=100,description=The maximum number of users to return"`
+	Page  int    `json:"page,omitempty" jsonschema:"default=1,description=The page number to return"`
+}
+
+func (p ListUsersParams) validate() error {
+	if p.Limit < 0 {
+		return fmt.Errorf("invalid limit: %d, must be greater than 0", p.Limit)
+	}
+	if p.Page < 0 {
+		return fmt.Errorf("invalid page: %d, must be greater than 0", p.Page)
+	}
+	return nil
+}
+
+type userSummary struct {
+	Login string `json:"login"`
+	Email string `json:"email,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Role  string `json:"role"`
+}
+
+// userList is a single page of the users in an organization.
+type userList struct {
+	Users []userSummary `json:"users"`
+	// Total is the number of users matching the query, across all pages.
+	Total int `json:"total"`
+	// HasMore is true if there are further pages of results.
+	HasMore bool `json:"hasMore"`
+}
+
+func listUsers(ctx context.Context, args ListUsersParams) (*userList, error) {
+	if err := args.validate(); err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	limit := args.Limit
+	if limit == 0 {
+		limit = DefaultListUsersLimit
+	}
+	page := max(args.Page, 1)
+
+	// The OpenAPI client only covers the unpaginated list of the current
+	// organization's users, so search them directly to page on the server.
+	cfg := mcpgrafana.GrafanaConfigFromContext(ctx)
+	transport, err := mcpgrafana.BuildTransport(&cfg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create custom transport: %w", err)
+	}
+	transport = NewAuthRoundTripper(transport, cfg.AccessToken, cfg.IDToken, cfg.APIKey, cfg.BasicAuth)
+	transport = mcpgrafana.NewOrgIDRoundTripper(transport, cfg.OrgID)
+	client := &http.Client{Transport: mcpgrafana.NewUserAgentTransport(transport)}
+
+	params := url.Values{}
+	params.Set("perpage", strconv.Itoa(limit))
+	params.Set("page", strconv.Itoa(page))
+	if args.Query != "" {
+		params.Set("query", args.Query)
+	}
+	searchURL := strings.TrimRight(cfg.URL, "/") + "/api/org/users/search?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+	if resp.StatusCode != http.StatusOK {
+		body := readErrorBody(resp.Body)
+		return nil, mcpgrafana.NewHTTPError(resp.StatusCode, fmt.Sprintf("list users: HTTP %d - %s", resp.StatusCode, summarizeErrorBody(body)))
+	}
+	var result models.SearchOrgUsersQueryResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("list users: decode response: %w", err)
+	}
+
+	users := []userSummary{}
+	for _, u := range result.OrgUsers {
+		if u == nil {
+			continue
+		}
+		users = append(users, userSummary{Login: u.Login, Email: u.Email, Name: u.Name, Role: u.Role})
+	}
+	return &userList{
+		Users:   users,
+		Total:   int(result.TotalCount),
+		HasMore: int64(page*limit) < result.TotalCount,
+	}, nil
+}
+
+var ListUsers = mcpgrafana.MustTool(
+	"list_users",
+	"List the users in the current Grafana organization, with their login, email, name and organization role. Optionally filter by a substring of the login, email or name, for example to find a user's email from their name. Results are paginated with limit and page.",
+	listUsers,
+	mcp.WithTitleAnnotation("List users"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type ListOrganizationsParams struct{}
+
+func listOrganizations(ctx context.Context, args ListOrganizationsParams) ([]*models.UserOrgDTO, error) {
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	params := signed_in_user.NewGetSignedInUserOrgListParamsWithContext(ctx)
+	resp, err := c.SignedInUser.GetSignedInUserOrgListWithParams(params)
+	if err != nil {
+		return nil, fmt.Errorf("list organizations: %w", err)
+	}
+	return resp.Payload, nil
+}
+
+var ListOrganizations = mcpgrafana.MustTool(
+	"list_organizations",
+	"List the Grafana organizations the current user is a member of, with their ID, name and the user's role in each. Pass an organization's ID as the orgId parameter of other tools to run them in that organization.",
+	listOrganizations,
+	mcp.WithTitleAnnotation("List organizations"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
 type ListAllRolesParams struct {
 	DelegatableOnly bool `json:"delegatableOnly,omitempty" jsonschema:"description=Optional: If set true only return roles that can be delegated by current user"`
 }
@@ -241,14 +414,110 @@ var GetResourceDescription = mcpgrafana.MustTool(
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+type GetDashboardPermissionsParams struct {
+	UID string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+}
+
+// dashboardPermissionNames are the names of the legacy dashboard permission
+// levels, for Grafana versions which don't send them.
+var dashboardPermissionNames = map[models.PermissionType]string{
+	1: "View",
+	2: "Edit",
+	4: "Admin",
+}
+
+type dashboardPermission struct {
+	// Exactly one of Role, Team and User is set, saying who the entry grants
+	// the permission to.
+	Role       string `json:"role,omitempty"`
+	Team       string `json:"team,omitempty"`
+	TeamID     int64  `json:"teamId,omitempty"`
+	User       string `json:"user,omitempty"`
+	UserEmail  string `json:"userEmail,omitempty"`
+	UserID     int64  `json:"userId,omitempty"`
+	Permission string `json:"permission"`
+	// Inherited is true for entries set on a parent folder, which have to be
+	// changed on that folder.
+	Inherited     bool   `json:"inherited"`
+	InheritedFrom string `json:"inheritedFrom,omitempty"`
+}
+
+type dashboardPermissions struct {
+	DashboardUID string                `json:"dashboardUid"`
+	Permissions  []dashboardPermission `json:"permissions"`
+	Note         string                `json:"note,omitempty"`
+}
+
+func getDashboardPermissions(ctx context.Context, args GetDashboardPermissionsParams) (*dashboardPermissions, error) {
+	if args.UID == "" {
+		return nil, fmt.Errorf("uid is required")
+	}
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	params := dashboards.NewGetDashboardPermissionsListByUIDParamsWithContext(ctx).WithUID(args.UID)
+	resp, err := c.Dashboards.GetDashboardPermissionsListByUIDWithParams(params)
+	if err != nil {
+		return nil, fmt.Errorf("get permissions of dashboard %s: %w", args.UID, err)
+	}
+
+	result := &dashboardPermissions{
+		DashboardUID: args.UID,
+		Permissions:  make([]dashboardPermission, 0, len(resp.Payload)),
+	}
+	inherited := 0
+	for _, acl := range resp.Payload {
+		if acl == nil {
+			continue
+		}
+		perm := dashboardPermission{
+			Role:       acl.Role,
+			Team:       acl.Team,
+			TeamID:     acl.TeamID,
+			User:       acl.UserLogin,
+			UserEmail:  acl.UserEmail,
+			UserID:     acl.UserID,
+			Permission: acl.PermissionName,
+			Inherited:  acl.Inherited,
+		}
+		if perm.Permission == "" {
+			perm.Permission = dashboardPermissionNames[acl.Permission]
+		}
+		if acl.Inherited {
+			inherited++
+			// Inherited entries describe the folder they are set on.
+			perm.InheritedFrom = acl.FolderUID
+			if acl.Title != "" {
+				perm.InheritedFrom = fmt.Sprintf("folder %q (%s)", acl.Title, acl.UID)
+			}
+		}
+		result.Permissions = append(result.Permissions, perm)
+	}
+	if inherited > 0 {
+		result.Note = fmt.Sprintf("%d of %d permissions are inherited from the dashboard's folder and can only be changed on that folder.", inherited, len(result.Permissions))
+	}
+	return result, nil
+}
+
+var GetDashboardPermissions = mcpgrafana.MustTool(
+	"get_dashboard_permissions",
+	"Get who can view, edit or administer a dashboard by its UID. Returns each permission entry with the role, team or user it applies to and its level (View, Edit or Admin), marking entries inherited from the dashboard's folder. Useful for troubleshooting access problems.",
+	getDashboardPermissions,
+	mcp.WithTitleAnnotation("Get dashboard permissions"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
 func AddAdminTools(mcp *server.MCPServer) {
 	ListTeams.Register(mcp)
+	GetTeamMembers.Register(mcp)
 	ListUsersByOrg.Register(mcp)
+	ListUsers.Register(mcp)
+	ListOrganizations.Register(mcp)
 	ListAllRoles.Register(mcp)
 	GetRoleDetails.Register(mcp)
 	GetRoleAssignments.Register(mcp)
 	ListUserRoles.Register(mcp)
 	ListTeamRoles.Register(mcp)
 	GetResourcePermissions.Register(mcp)
+	GetDashboardPermissions.Register(mcp)
 	GetResourceDescription.Register(mcp)
 }
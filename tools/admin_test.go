@@ -5,8 +5,12 @@ package tools
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
+	"github.com/grafana/grafana-openapi-client-go/models"
 	mcpgrafana "github.com/grafana/mcp-grafana"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -176,3 +180,166 @@ func TestAdminToolsUnit(t *testing.T) {
 		})
 	})
 }
+
+func TestListOrganizations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/user/orgs", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"orgId": 1, "name": "Main Org.", "role": "Admin"}, {"orgId": 3, "name": "Ops", "role": "Viewer"}]`))
+	}))
+	defer server.Close()
+
+	orgs, err := listOrganizations(mockCtxWithClient(server), ListOrganizationsParams{})
+	require.NoError(t, err)
+	assert.Equal(t, []*models.UserOrgDTO{
+		{OrgID: 1, Name: "Main Org.", Role: "Admin"},
+		{OrgID: 3, Name: "Ops", Role: "Viewer"},
+	}, orgs)
+}
+
+func TestListUsers(t *testing.T) {
+	var gotQuery url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/org/users/search", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		gotQuery = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		if gotQuery.Get("query") == "forbidden" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "Permission denied"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"totalCount": 4,
+			"orgUsers": [
+				{"userId": 1, "login": "admin", "email": "admin@example.com", "name": "", "role": "Admin"},
+				{"userId": 2, "login": "jdoe", "email": "jane@example.com", "name": "Jane Doe", "role": "Editor"},
+				{"userId": 3, "login": "bsmith", "email": "bob@example.com", "name": "Bob Smith", "role": "Viewer"}
+			],
+			"page": 1,
+			"perPage": 3
+		}`))
+	}))
+	defer server.Close()
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL, APIKey: "test-key"})
+
+	t.Run("searches on the server", func(t *testing.T) {
+		result, err := listUsers(ctx, ListUsersParams{Query: "example", Limit: 3})
+		require.NoError(t, err)
+		assert.Equal(t, url.Values{"query": {"example"}, "perpage": {"3"}, "page": {"1"}}, gotQuery)
+		assert.Equal(t, &userList{
+			Users: []userSummary{
+				{Login: "admin", Email: "admin@example.com", Role: "Admin"},
+				{Login: "jdoe", Email: "jane@example.com", Name: "Jane Doe", Role: "Editor"},
+				{Login: "bsmith", Email: "bob@example.com", Name: "Bob Smith", Role: "Viewer"},
+			},
+			Total:   4,
+			HasMore: true,
+		}, result)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		_, err := listUsers(ctx, ListUsersParams{})
+		require.NoError(t, err)
+		assert.Equal(t, url.Values{"perpage": {"100"}, "page": {"1"}}, gotQuery)
+
+		result, err := listUsers(ctx, ListUsersParams{Limit: 3, Page: 2})
+		require.NoError(t, err)
+		assert.Equal(t, "2", gotQuery.Get("page"))
+		assert.False(t, result.HasMore)
+
+		_, err = listUsers(ctx, ListUsersParams{Page: -1})
+		assert.ErrorContains(t, err, "invalid page")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := listUsers(ctx, ListUsersParams{Query: "forbidden"})
+		require.ErrorContains(t, err, "Permission denied")
+		assert.Equal(t, mcpgrafana.ErrKindAuth, mcpgrafana.ErrorKindOf(err))
+	})
+}
+
+func TestListTeams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/teams/search", r.URL.Path)
+		assert.Equal(t, "sre", r.URL.Query().Get("query"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"totalCount": 1, "page": 1, "perPage": 1000, "teams": [{"id": 7, "uid": "sre", "name": "SRE", "memberCount": 2}]}`))
+	}))
+	defer server.Close()
+
+	result, err := listTeams(mockCtxWithClient(server), ListTeamsParams{Query: "sre"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.TotalCount)
+	require.Len(t, result.Teams, 1)
+	assert.Equal(t, int64(7), *result.Teams[0].ID)
+	assert.Equal(t, "SRE", *result.Teams[0].Name)
+	assert.Equal(t, int64(2), *result.Teams[0].MemberCount)
+}
+
+func TestGetTeamMembers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/teams/7/members", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"teamId": 7, "userId": 2, "login": "jdoe", "email": "jane@example.com", "name": "Jane Doe", "avatarUrl": "/avatar/1"},
+			{"teamId": 7, "userId": 3, "login": "bsmith", "email": "bob@example.com", "name": "", "avatarUrl": "/avatar/2"}
+		]`))
+	}))
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	members, err := getTeamMembers(ctx, GetTeamMembersParams{TeamID: 7})
+	require.NoError(t, err)
+	assert.Equal(t, []teamMemberSummary{
+		{UserID: 2, Login: "jdoe", Email: "jane@example.com", Name: "Jane Doe"},
+		{UserID: 3, Login: "bsmith", Email: "bob@example.com"},
+	}, members)
+
+	_, err = getTeamMembers(ctx, GetTeamMembersParams{})
+	assert.ErrorContains(t, err, "invalid teamId")
+}
+
+func TestGetDashboardPermissions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/dashboards/uid/dash-1/permissions", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"dashboardId": 10, "uid": "ops", "title": "Ops", "isFolder": true, "inherited": true, "role": "Viewer", "permission": 1, "permissionName": "View"},
+			{"dashboardId": 10, "uid": "ops", "title": "Ops", "isFolder": true, "inherited": true, "teamId": 7, "team": "SRE", "permission": 2, "permissionName": "Edit"},
+			{"dashboardId": 42, "uid": "dash-1", "title": "API", "userId": 2, "userLogin": "jdoe", "userEmail": "jane@example.com", "permission": 4, "permissionName": "Admin"},
+			{"dashboardId": 42, "uid": "dash-1", "title": "API", "role": "Editor", "permission": 2}
+		]`))
+	}))
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	result, err := getDashboardPermissions(ctx, GetDashboardPermissionsParams{UID: "dash-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "dash-1", result.DashboardUID)
+	assert.Equal(t, []dashboardPermission{
+		{Role: "Viewer", Permission: "View", Inherited: true, InheritedFrom: `folder "Ops" (ops)`},
+		{Team: "SRE", TeamID: 7, Permission: "Edit", Inherited: true, InheritedFrom: `folder "Ops" (ops)`},
+		{User: "jdoe", UserEmail: "jane@example.com", UserID: 2, Permission: "Admin"},
+		{Role: "Editor", Permission: "Edit"},
+	}, result.Permissions)
+	assert.Equal(t, "2 of 4 permissions are inherited from the dashboard's folder and can only be changed on that folder.", result.Note)
+
+	t.Run("direct permissions only", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"dashboardId": 42, "uid": "dash-2", "role": "Viewer", "permission": 1, "permissionName": "View"}]`))
+		}))
+		defer server.Close()
+
+		result, err := getDashboardPermissions(mockCtxWithClient(server), GetDashboardPermissionsParams{UID: "dash-2"})
+		require.NoError(t, err)
+		assert.Equal(t, []dashboardPermission{{Role: "Viewer", Permission: "View"}}, result.Permissions)
+		assert.Empty(t, result.Note)
+	})
+
+	t.Run("missing uid", func(t *testing.T) {
+		_, err := getDashboardPermissions(ctx, GetDashboardPermissionsParams{})
+		assert.ErrorContains(t, err, "uid is required")
+	})
+}
@@ -3,16 +3,24 @@ package tools
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-openapi/strfmt"
 	"github.com/grafana/grafana-openapi-client-go/client/provisioning"
 	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/prometheus/alertmanager/config"
+	amlabels "github.com/prometheus/alertmanager/pkg/labels"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 
 	mcpgrafana "github.com/grafana/mcp-grafana"
@@ -28,8 +36,12 @@ type ListAlertRulesParams struct {
 	Page           int        `json:"page,omitempty" jsonschema:"default=1,description=The page number to return"`
 	DatasourceUID  *string    `json:"datasourceUid,omitempty" jsonschema:"description=Optional: UID of a Prometheus or Loki datasource to query for datasource-managed alert rules. If omitted\\, returns Grafana-managed rules."`
 	LabelSelectors []Selector `json:"label_selectors,omitempty" jsonschema:"description=Optionally\\, a list of matchers to filter alert rules by labels"`
+	State          string     `json:"state,omitempty" jsonschema:"description=Optionally\\, only return rules currently in this state: 'firing'\\, 'pending' or 'inactive' (normal)"`
 }
 
+// alertRuleStates are the states list_alert_rules can filter by.
+var alertRuleStates = []string{"firing", "pending", "inactive"}
+
 func (p ListAlertRulesParams) validate() error {
 	if p.Limit < 0 {
 		return fmt.Errorf("invalid limit: %d, must be greater than 0", p.Limit)
@@ -37,6 +49,9 @@ func (p ListAlertRulesParams) validate() error {
 	if p.Page < 0 {
 		return fmt.Errorf("invalid page: %d, must be greater than 0", p.Page)
 	}
+	if p.State != "" && !slices.Contains(alertRuleStates, p.State) {
+		return fmt.Errorf("invalid state: %q, must be one of: %s", p.State, strings.Join(alertRuleStates, ", "))
+	}
 
 	return nil
 }
@@ -56,7 +71,16 @@ type alertRuleSummary struct {
 	Annotations    map[string]string `json:"annotations,omitempty"`
 }
 
-func listAlertRules(ctx context.Context, args ListAlertRulesParams) ([]alertRuleSummary, error) {
+// alertRuleList is a single page of alert rules.
+type alertRuleList struct {
+	Rules []alertRuleSummary `json:"rules"`
+	// Total is the number of rules matching the filters, across all pages.
+	Total int `json:"total"`
+	// HasMore is true if there are further pages of results.
+	HasMore bool `json:"hasMore"`
+}
+
+func listAlertRules(ctx context.Context, args ListAlertRulesParams) (*alertRuleList, error) {
 	if err := args.validate(); err != nil {
 		return nil, fmt.Errorf("list alert rules: %w", err)
 	}
@@ -66,18 +90,54 @@ func listAlertRules(ctx context.Context, args ListAlertRulesParams) ([]alertRule
 		return listDatasourceAlertRules(ctx, args)
 	}
 
+	alertingClient, err := newAlertingClientFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list alert rules (alerting client): %w", err)
+	}
+
+	var mergedRules []mergedAlertRule
+	if alertingClient.api == alertingAPILegacy {
+		mergedRules, err = listLegacyAlertRules(ctx, alertingClient)
+	} else {
+		mergedRules, err = listUnifiedAlertRules(ctx, alertingClient)
+		if err != nil && alertingClient.api == alertingAPIAuto && isNotFound(err) {
+			slog.Debug("Unified alerting API not found, falling back to legacy alerting", "error", err)
+			mergedRules, err = listLegacyAlertRules(ctx, alertingClient)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	filteredRules, err := filterMergedAlertRules(mergedRules, args.LabelSelectors)
+	if err != nil {
+		return nil, fmt.Errorf("list alert rules: %w", err)
+	}
+	filteredRules = filterMergedAlertRulesByState(filteredRules, args.State)
+
+	paginatedRules, hasMore, err := applyPaginationToMerged(filteredRules, args.Limit, args.Page)
+	if err != nil {
+		return nil, fmt.Errorf("list alert rules: %w", err)
+	}
+
+	return &alertRuleList{
+		Rules:   summarizeMergedAlertRules(paginatedRules),
+		Total:   len(filteredRules),
+		HasMore: hasMore,
+	}, nil
+}
+
+// listUnifiedAlertRules lists the rules of Grafana unified alerting, merging
+// their configuration with their runtime state.
+func listUnifiedAlertRules(ctx context.Context, alertingClient *alertingClient) ([]mergedAlertRule, error) {
 	// Get configuration data from provisioning API (has UIDs, configuration)
 	c := mcpgrafana.GrafanaClientFromContext(ctx)
-	provisioningResponse, err := c.Provisioning.GetAlertRules()
+	provisioningResponse, err := c.Provisioning.GetAlertRulesWithParams(provisioning.NewGetAlertRulesParamsWithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("list alert rules (provisioning): %w", err)
 	}
 
 	// Get runtime state data from alerting client API (has state, health, etc.)
-	alertingClient, err := newAlertingClientFromContext(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("list alert rules (alerting client): %w", err)
-	}
 	runtimeResponse, err := alertingClient.GetRules(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("list alert rules (runtime): %w", err)
@@ -90,19 +150,57 @@ func listAlertRules(ctx context.Context, args ListAlertRulesParams) ([]alertRule
 	}
 
 	// Merge the data from both APIs
-	mergedRules := mergeAlertRuleData(provisioningResponse.Payload, runtimeRules)
+	return mergeAlertRuleData(provisioningResponse.Payload, runtimeRules), nil
+}
 
-	filteredRules, err := filterMergedAlertRules(mergedRules, args.LabelSelectors)
-	if err != nil {
-		return nil, fmt.Errorf("list alert rules: %w", err)
-	}
+// legacyAlertStates maps legacy alerting states to the unified alerting
+// states used by list_alert_rules.
+var legacyAlertStates = map[string]string{
+	"alerting": "firing",
+	"pending":  "pending",
+	"ok":       "inactive",
+	"no_data":  "inactive",
+	"paused":   "inactive",
+}
 
-	paginatedRules, err := applyPaginationToMerged(filteredRules, args.Limit, args.Page)
+// listLegacyAlertRules lists the dashboard alerts of legacy alerting as alert
+// rules. Legacy alerts have no UID, labels or folder, so the rule UID is the
+// alert ID and the dashboard and panel are given as the annotations unified
+// alerting uses for them.
+func listLegacyAlertRules(ctx context.Context, alertingClient *alertingClient) ([]mergedAlertRule, error) {
+	alerts, err := alertingClient.GetLegacyAlerts(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list alert rules: %w", err)
+		return nil, fmt.Errorf("list alert rules (legacy): %w", err)
 	}
 
-	return summarizeMergedAlertRules(paginatedRules), nil
+	rules := make([]mergedAlertRule, 0, len(alerts))
+	for _, a := range alerts {
+		health := "ok"
+		switch {
+		case a.ExecutionError != "":
+			health = "error"
+		case a.State == "no_data":
+			health = "nodata"
+		}
+		rule := mergedAlertRule{
+			UID:    strconv.FormatInt(a.ID, 10),
+			Title:  a.Name,
+			State:  legacyAlertStates[a.State],
+			Health: health,
+			Annotations: map[string]string{
+				"__dashboardUid__": a.DashboardUID,
+				"__panelId__":      strconv.FormatInt(a.PanelID, 10),
+			},
+		}
+		if !a.EvalDate.IsZero() {
+			rule.LastEvaluation = a.EvalDate.Format(time.RFC3339)
+		}
+		if a.State == "alerting" || a.State == "pending" {
+			rule.ActiveAt = a.NewStateDate.Format(time.RFC3339)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
 }
 
 // mergedAlertRule combines data from both provisioning API and runtime API
@@ -207,6 +305,23 @@ func filterMergedAlertRules(rules []mergedAlertRule, selectors []Selector) ([]me
 	return filteredResult, nil
 }
 
+// filterMergedAlertRulesByState keeps the rules whose current state is
+// state. Rules without runtime state never match. An empty state keeps all
+// rules.
+func filterMergedAlertRulesByState(rules []mergedAlertRule, state string) []mergedAlertRule {
+	if state == "" {
+		return rules
+	}
+
+	filteredResult := []mergedAlertRule{}
+	for _, rule := range rules {
+		if strings.EqualFold(rule.State, state) {
+			filteredResult = append(filteredResult, rule)
+		}
+	}
+	return filteredResult
+}
+
 // matchesSelectorsForMerged checks if a merged alert rule matches all provided selectors
 func matchesSelectorsForMerged(rule mergedAlertRule, selectors []Selector) (bool, error) {
 	// Convert map[string]string to labels.Labels for compatibility with selector
@@ -249,9 +364,11 @@ func summarizeMergedAlertRules(alertRules []mergedAlertRule) []alertRuleSummary
 	return result
 }
 
-// applyPaginationToMerged applies pagination to the list of merged alert rules.
-// It doesn't sort the items and relies on the order returned by the API.
-func applyPaginationToMerged(items []mergedAlertRule, limit, page int) ([]mergedAlertRule, error) {
+// applyPaginationToMerged returns the requested page of merged alert rules,
+// and whether there are further pages. A limit or page of 0 selects the
+// default. It doesn't sort the items and relies on the order returned by the
+// API.
+func applyPaginationToMerged(items []mergedAlertRule, limit, page int) ([]mergedAlertRule, bool, error) {
 	if limit == 0 {
 		limit = DefaultListAlertRulesLimit
 	}
@@ -263,20 +380,20 @@ func applyPaginationToMerged(items []mergedAlertRule, limit, page int) ([]merged
 	end := start + limit
 
 	if start >= len(items) {
-		return nil, nil
-	} else if end > len(items) {
-		return items[start:], nil
+		return nil, false, nil
+	} else if end >= len(items) {
+		return items[start:], false, nil
 	}
 
-	return items[start:end], nil
+	return items[start:end], true, nil
 }
 
 // listDatasourceAlertRules queries a Prometheus/Loki datasource for its alert rules
-func listDatasourceAlertRules(ctx context.Context, args ListAlertRulesParams) ([]alertRuleSummary, error) {
+func listDatasourceAlertRules(ctx context.Context, args ListAlertRulesParams) (*alertRuleList, error) {
 	dsUID := *args.DatasourceUID
 
 	// verify datasource exists, get its type
-	ds, err := getDatasourceByUID(ctx, GetDatasourceByUIDParams{UID: dsUID})
+	ds, err := datasourceInfo(ctx, dsUID)
 	if err != nil {
 		return nil, fmt.Errorf("datasource %s: %w", dsUID, err)
 	}
@@ -301,12 +418,17 @@ func listDatasourceAlertRules(ctx context.Context, args ListAlertRulesParams) ([
 	if err != nil {
 		return nil, fmt.Errorf("filtering rules: %w", err)
 	}
-	paginatedRules, err := applyPaginationToMerged(filteredRules, args.Limit, args.Page)
+	filteredRules = filterMergedAlertRulesByState(filteredRules, args.State)
+	paginatedRules, hasMore, err := applyPaginationToMerged(filteredRules, args.Limit, args.Page)
 	if err != nil {
 		return nil, fmt.Errorf("pagination: %w", err)
 	}
 
-	return summarizeMergedAlertRules(paginatedRules), nil
+	return &alertRuleList{
+		Rules:   summarizeMergedAlertRules(paginatedRules),
+		Total:   len(filteredRules),
+		HasMore: hasMore,
+	}, nil
 }
 
 // isRulerDatasource checks if datasource type supports Prometheus ruler API (currently Prometheus/Loki)
@@ -367,7 +489,7 @@ func formatDuration(seconds float64) string {
 
 var ListAlertRules = mcpgrafana.MustTool(
 	"list_alert_rules",
-	"Lists Grafana alert rules, returning a summary including UID, title, current state (e.g., 'pending', 'firing', 'inactive'), and labels. Optionally query datasource-managed rules from Prometheus or Loki by providing datasourceUid. Supports filtering by labels using selectors and pagination. Example label selector: `[{'name': 'severity', 'type': '=', 'value': 'critical'}]`. Inactive state means the alert state is normal, not firing",
+	"Lists Grafana alert rules, returning a summary including UID, title, current state (e.g., 'pending', 'firing', 'inactive'), and labels. Optionally query datasource-managed rules from Prometheus or Loki by providing datasourceUid. Supports filtering by labels using selectors and pagination (default limit 100); the response includes the total number of matching rules and a hasMore flag. Example label selector: `[{'name': 'severity', 'type': '=', 'value': 'critical'}]`. Set state to 'firing', 'pending' or 'inactive' to only return rules currently in that state, for example to see what is firing during an incident. Inactive state means the alert state is normal, not firing",
 	listAlertRules,
 	mcp.WithTitleAnnotation("List alert rules"),
 	mcp.WithIdempotentHintAnnotation(true),
@@ -392,7 +514,7 @@ func getAlertRuleByUID(ctx context.Context, args GetAlertRuleByUIDParams) (*mode
 	}
 
 	c := mcpgrafana.GrafanaClientFromContext(ctx)
-	alertRule, err := c.Provisioning.GetAlertRule(args.UID)
+	alertRule, err := c.Provisioning.GetAlertRuleWithParams(provisioning.NewGetAlertRuleParamsWithContext(ctx).WithUID(args.UID))
 	if err != nil {
 		return nil, fmt.Errorf("get alert rule by uid %s: %w", args.UID, err)
 	}
@@ -408,10 +530,289 @@ var GetAlertRuleByUID = mcpgrafana.MustTool(
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+type GetAlertRuleParams struct {
+	UID string `json:"uid" jsonschema:"required,description=The uid of the alert rule"`
+}
+
+func (p GetAlertRuleParams) validate() error {
+	if p.UID == "" {
+		return fmt.Errorf("uid is required")
+	}
+	return nil
+}
+
+// alertRuleDetail is an alert rule's full definition together with its
+// current state.
+type alertRuleDetail struct {
+	Rule *models.ProvisionedAlertRule `json:"rule"`
+
+	// State is empty if the rule has not been evaluated yet.
+	State          string `json:"state,omitempty"`
+	Health         string `json:"health,omitempty"`
+	LastEvaluation string `json:"lastEvaluation,omitempty"`
+	ActiveAt       string `json:"activeAt,omitempty"`
+	LastError      string `json:"lastError,omitempty"`
+	Evaluated      bool   `json:"evaluated"`
+}
+
+func getAlertRule(ctx context.Context, args GetAlertRuleParams) (*alertRuleDetail, error) {
+	if err := args.validate(); err != nil {
+		return nil, fmt.Errorf("get alert rule: %w", err)
+	}
+
+	alertingClient, err := newAlertingClientFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get alert rule (alerting client): %w", err)
+	}
+	if alertingClient.api == alertingAPILegacy {
+		return nil, fmt.Errorf("get alert rule: %w", errUnifiedAlertingRequired)
+	}
+
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	provisioned, err := c.Provisioning.GetAlertRuleWithParams(provisioning.NewGetAlertRuleParamsWithContext(ctx).WithUID(args.UID))
+	if err != nil {
+		return nil, fmt.Errorf("get alert rule %s (provisioning): %w", args.UID, err)
+	}
+	detail := &alertRuleDetail{Rule: provisioned.Payload}
+
+	runtimeResponse, err := alertingClient.GetRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get alert rule %s (runtime): %w", args.UID, err)
+	}
+	runtime, found := findRuntimeAlertRule(runtimeResponse, provisioned.Payload)
+	if !found || runtime.LastEvaluation.IsZero() {
+		// Rules only have a meaningful state once they have been evaluated;
+		// until they are scheduled they're missing from the runtime API.
+		return detail, nil
+	}
+	detail.Evaluated = true
+	detail.State = runtime.State
+	detail.Health = runtime.Health
+	detail.LastError = runtime.LastError
+	detail.LastEvaluation = runtime.LastEvaluation.Format(time.RFC3339)
+	if runtime.ActiveAt != nil && !runtime.ActiveAt.IsZero() {
+		detail.ActiveAt = runtime.ActiveAt.Format(time.RFC3339)
+	}
+	return detail, nil
+}
+
+// findRuntimeAlertRule finds the runtime state of a provisioned rule, by UID
+// or, for Grafana versions which don't return rule UIDs, by title.
+func findRuntimeAlertRule(runtimeResponse *rulesResponse, rule *models.ProvisionedAlertRule) (alertingRule, bool) {
+	title := ""
+	if rule.Title != nil {
+		title = *rule.Title
+	}
+	var byTitle *alertingRule
+	for _, group := range runtimeResponse.Data.RuleGroups {
+		for i, runtime := range group.Rules {
+			if runtime.UID != "" && runtime.UID == rule.UID {
+				return runtime, true
+			}
+			if runtime.UID == "" && runtime.Name == title && byTitle == nil {
+				byTitle = &group.Rules[i]
+			}
+		}
+	}
+	if byTitle != nil {
+		return *byTitle, true
+	}
+	return alertingRule{}, false
+}
+
+var GetAlertRule = mcpgrafana.MustTool(
+	"get_alert_rule",
+	"Retrieves a single Grafana alert rule by its UID, returning its full definition (queries, condition, pending period ('for'), labels, annotations and no data/error handling) together with its current state ('firing', 'pending' or 'inactive'), health and last evaluation time. 'evaluated' is false, and state is empty, if the rule has not been evaluated yet. Prefer this over list_alert_rules when the rule UID is already known.",
+	getAlertRule,
+	mcp.WithTitleAnnotation("Get alert rule with state"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type EvaluateAlertRuleParams struct {
+	UID  string `json:"uid" jsonschema:"required,description=The uid of the Grafana-managed alert rule to evaluate"`
+	Time string `json:"time,omitempty" jsonschema:"description=Optionally\\, the time to evaluate the rule at: 'now'\\, a time relative to now such as 'now-1h'\\, an RFC3339 timestamp or Unix seconds. The rule's query time ranges are relative to it. Defaults to now"`
+}
+
+func (p EvaluateAlertRuleParams) validate() error {
+	if p.UID == "" {
+		return fmt.Errorf("uid is required")
+	}
+	return nil
+}
+
+// Instance states reported by evaluate_alert_rule. They are the outcome of a
+// single evaluation, so a rule with a pending period reports instances whose
+// condition is met as firing, even though the rule would be pending first.
+const (
+	evaluatedStateFiring = "firing"
+	evaluatedStateNormal = "normal"
+	evaluatedStateNoData = "nodata"
+)
+
+// evaluatedAlertInstance is one alert instance computed by evaluating a rule.
+type evaluatedAlertInstance struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	State  string            `json:"state"`
+	// Values holds the value of each query and expression computed for
+	// the instance, by ref ID. Values are nil if they were null.
+	Values map[string]*float64 `json:"values"`
+}
+
+// alertRuleEvaluation is the outcome of evaluating an alert rule on demand.
+type alertRuleEvaluation struct {
+	UID         string                   `json:"uid"`
+	Title       string                   `json:"title"`
+	Condition   string                   `json:"condition"`
+	EvaluatedAt string                   `json:"evaluatedAt"`
+	Firing      int                      `json:"firing"`
+	Instances   []evaluatedAlertInstance `json:"instances"`
+	// Errors holds the error of each query or expression which failed, by
+	// ref ID.
+	Errors map[string]string `json:"errors,omitempty"`
+	Note   string            `json:"note,omitempty"`
+}
+
+// evaluatedSeries is the last value of a series returned by a query or
+// expression.
+type evaluatedSeries struct {
+	labels map[string]string
+	value  *float64
+}
+
+func evaluateAlertRule(ctx context.Context, args EvaluateAlertRuleParams) (*alertRuleEvaluation, error) {
+	if err := args.validate(); err != nil {
+		return nil, fmt.Errorf("evaluate alert rule: %w", err)
+	}
+	now, err := timeOrDefault(args.Time, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("evaluate alert rule: %w", err)
+	}
+
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	provisioned, err := c.Provisioning.GetAlertRuleWithParams(provisioning.NewGetAlertRuleParamsWithContext(ctx).WithUID(args.UID))
+	if err != nil {
+		return nil, fmt.Errorf("evaluate alert rule %s (provisioning): %w", args.UID, err)
+	}
+	rule := provisioned.Payload
+	if rule.Condition == nil || *rule.Condition == "" {
+		return nil, fmt.Errorf("evaluate alert rule %s: rule has no condition", args.UID)
+	}
+
+	alertingClient, err := newAlertingClientFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate alert rule (alerting client): %w", err)
+	}
+	response, err := alertingClient.EvalQueries(ctx, rule.Data, *rule.Condition, now)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate alert rule %s: %w", args.UID, err)
+	}
+
+	result := &alertRuleEvaluation{
+		UID:         rule.UID,
+		Condition:   *rule.Condition,
+		EvaluatedAt: now.UTC().Format(time.RFC3339),
+		Instances:   []evaluatedAlertInstance{},
+	}
+	if rule.Title != nil {
+		result.Title = *rule.Title
+	}
+
+	series := make(map[string][]evaluatedSeries, len(response.Responses))
+	for refID, res := range response.Responses {
+		if res.Error != nil {
+			if result.Errors == nil {
+				result.Errors = map[string]string{}
+			}
+			result.Errors[refID] = res.Error.Error()
+			continue
+		}
+		series[refID] = evaluatedSeriesFromFrames(res.Frames)
+	}
+
+	for _, s := range series[*rule.Condition] {
+		instance := evaluatedAlertInstance{Labels: s.labels, State: evaluatedStateNormal, Values: map[string]*float64{}}
+		if s.value != nil && *s.value != 0 {
+			instance.State = evaluatedStateFiring
+			result.Firing++
+		}
+		for refID, refSeries := range series {
+			for _, rs := range refSeries {
+				if labelsSubset(rs.labels, s.labels) {
+					instance.Values[refID] = rs.value
+					break
+				}
+			}
+		}
+		result.Instances = append(result.Instances, instance)
+	}
+	if len(result.Instances) == 0 && result.Errors[*rule.Condition] == "" {
+		result.Instances = append(result.Instances, evaluatedAlertInstance{State: evaluatedStateNoData, Values: map[string]*float64{}})
+	}
+	slices.SortFunc(result.Instances, func(a, b evaluatedAlertInstance) int {
+		return strings.Compare(toLabelSet(a.Labels).String(), toLabelSet(b.Labels).String())
+	})
+
+	if result.Firing > 0 && rule.For != nil && *rule.For > 0 {
+		result.Note = fmt.Sprintf("The rule has a pending period of %s, so instances whose condition is met are pending for that long before they fire.", time.Duration(*rule.For))
+	}
+	return result, nil
+}
+
+// evaluatedSeriesFromFrames returns the last value of each numeric field of
+// the frames returned for a query or expression, with its labels.
+func evaluatedSeriesFromFrames(frames data.Frames) []evaluatedSeries {
+	var series []evaluatedSeries
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if !field.Type().Numeric() {
+				continue
+			}
+			s := evaluatedSeries{labels: map[string]string(field.Labels)}
+			if n := field.Len(); n > 0 {
+				if v, err := field.NullableFloatAt(n - 1); err == nil {
+					s.value = v
+				}
+			}
+			series = append(series, s)
+		}
+	}
+	return series
+}
+
+// labelsSubset reports whether every label in subset has the same value in
+// labels.
+func labelsSubset(subset, labels map[string]string) bool {
+	for k, v := range subset {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func toLabelSet(labels map[string]string) model.LabelSet {
+	set := make(model.LabelSet, len(labels))
+	for k, v := range labels {
+		set[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return set
+}
+
+var EvaluateAlertRule = mcpgrafana.MustTool(
+	"evaluate_alert_rule",
+	"Evaluates a Grafana-managed alert rule on demand, identified by its UID, at the given time (default now), and returns the alert instances it produces with their state ('firing', 'normal' or 'nodata') and the value of each of the rule's queries and expressions for each instance. Use this to explain why a rule is or isn't firing. The evaluation doesn't change the rule's state or send any notifications. It reflects a single evaluation, so the rule's pending period isn't applied.",
+	evaluateAlertRule,
+	mcp.WithTitleAnnotation("Evaluate alert rule"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
 type ListContactPointsParams struct {
-	DatasourceUID *string `json:"datasourceUid,omitempty" jsonschema:"description=Optional: UID of an Alertmanager-compatible datasource to query for receivers. If omitted\\, returns Grafana-managed contact points."`
-	Limit         int     `json:"limit,omitempty" jsonschema:"description=The maximum number of results to return. Default is 100."`
-	Name          *string `json:"name,omitempty" jsonschema:"description=Filter contact points by name"`
+	DatasourceUID   *string `json:"datasourceUid,omitempty" jsonschema:"description=Optional: UID of an Alertmanager-compatible datasource to query for receivers. If omitted\\, returns Grafana-managed contact points."`
+	Limit           int     `json:"limit,omitempty" jsonschema:"description=The maximum number of results to return. Default is 100."`
+	Name            *string `json:"name,omitempty" jsonschema:"description=Filter contact points by name"`
+	IncludeSettings bool    `json:"includeSettings,omitempty" jsonschema:"description=If true\\, include the settings of each Grafana-managed contact point. Secrets such as tokens\\, passwords and webhook URLs are redacted."`
 }
 
 func (p ListContactPointsParams) validate() error {
@@ -425,6 +826,8 @@ type contactPointSummary struct {
 	UID  string  `json:"uid"`
 	Name string  `json:"name"`
 	Type *string `json:"type,omitempty"`
+	// Settings is only set when requested, with secrets redacted.
+	Settings map[string]any `json:"settings,omitempty"`
 }
 
 func listContactPoints(ctx context.Context, args ListContactPointsParams) ([]contactPointSummary, error) {
@@ -454,21 +857,85 @@ func listContactPoints(ctx context.Context, args ListContactPointsParams) ([]con
 		return nil, fmt.Errorf("list contact points: %w", err)
 	}
 
-	return summarizeContactPoints(filteredContactPoints), nil
+	return summarizeContactPoints(filteredContactPoints, args.IncludeSettings), nil
 }
 
-func summarizeContactPoints(contactPoints []*models.EmbeddedContactPoint) []contactPointSummary {
+func summarizeContactPoints(contactPoints []*models.EmbeddedContactPoint, includeSettings bool) []contactPointSummary {
 	result := make([]contactPointSummary, 0, len(contactPoints))
 	for _, cp := range contactPoints {
-		result = append(result, contactPointSummary{
+		summary := contactPointSummary{
 			UID:  cp.UID,
 			Name: cp.Name,
 			Type: cp.Type,
-		})
+		}
+		if includeSettings {
+			if settings, ok := cp.Settings.(map[string]any); ok {
+				summary.Settings = redactContactPointSettings(settings)
+			}
+		}
+		result = append(result, summary)
 	}
 	return result
 }
 
+// redactedSettingValue replaces secret contact point settings. It is the
+// same placeholder Grafana uses for secure settings.
+const redactedSettingValue = "[REDACTED]"
+
+// secretSettingKeyParts are substrings of contact point setting names which
+// hold secrets, compared case-insensitively.
+var secretSettingKeyParts = []string{"password", "token", "secret", "key", "credentials", "signature", "webhook"}
+
+// redactContactPointSettings returns a copy of contact point settings with
+// secrets redacted. URLs are reduced to their scheme and host, since webhook
+// URLs often embed a token in their path or query.
+func redactContactPointSettings(settings map[string]any) map[string]any {
+	redacted := make(map[string]any, len(settings))
+	for k, v := range settings {
+		lower := strings.ToLower(k)
+		switch {
+		case v == nil || v == "":
+			redacted[k] = v
+		case containsAny(lower, secretSettingKeyParts):
+			redacted[k] = redactedSettingValue
+		case strings.Contains(lower, "url"):
+			redacted[k] = redactURL(v)
+		default:
+			if nested, ok := v.(map[string]any); ok {
+				redacted[k] = redactContactPointSettings(nested)
+			} else {
+				redacted[k] = v
+			}
+		}
+	}
+	return redacted
+}
+
+func containsAny(s string, parts []string) bool {
+	for _, part := range parts {
+		if strings.Contains(s, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL keeps only the scheme and host of a URL setting.
+func redactURL(v any) any {
+	s, ok := v.(string)
+	if !ok || s == redactedSettingValue {
+		return redactedSettingValue
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return redactedSettingValue
+	}
+	if u.Path == "" && u.RawQuery == "" && u.User == nil {
+		return s
+	}
+	return fmt.Sprintf("%s://%s/%s", u.Scheme, u.Host, redactedSettingValue)
+}
+
 func applyLimitToContactPoints(items []*models.EmbeddedContactPoint, limit int) ([]*models.EmbeddedContactPoint, error) {
 	if limit == 0 {
 		limit = DefaultListContactPointsLimit
@@ -486,7 +953,7 @@ func listAlertmanagerReceivers(ctx context.Context, args ListContactPointsParams
 	dsUID := *args.DatasourceUID
 
 	// verify datasource exists and is Alertmanager type
-	ds, err := getDatasourceByUID(ctx, GetDatasourceByUIDParams{UID: dsUID})
+	ds, err := datasourceInfo(ctx, dsUID)
 	if err != nil {
 		return nil, fmt.Errorf("datasource %s: %w", dsUID, err)
 	}
@@ -561,13 +1028,725 @@ func filterContactPointsByName(cps []contactPointSummary, name string) []contact
 
 var ListContactPoints = mcpgrafana.MustTool(
 	"list_contact_points",
-	"Lists Grafana notification contact points, returning a summary including UID, name, and type for each. Optionally query Alertmanager receivers by providing datasourceUid. Supports filtering by name - exact match - and limiting the number of results.",
+	"Lists Grafana notification contact points, returning a summary including UID, name, and type for each. Set includeSettings to also return each contact point's settings with secrets redacted. Optionally query Alertmanager receivers by providing datasourceUid. Supports filtering by name - exact match - and limiting the number of results.",
 	listContactPoints,
 	mcp.WithTitleAnnotation("List notification contact points"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+type GetNotificationPolicyTreeParams struct{}
+
+// notificationPolicy is one route of the notification policy tree. The tree
+// is flattened depth first, so a policy's children follow it with a depth one
+// greater.
+type notificationPolicy struct {
+	Depth    int      `json:"depth"`
+	Matchers []string `json:"matchers,omitempty"`
+	// ContactPoint is the policy's own contact point, or the one it inherits
+	// from its parent if it doesn't set one.
+	ContactPoint         string   `json:"contactPoint,omitempty"`
+	InheritsContactPoint bool     `json:"inheritsContactPoint,omitempty"`
+	Continue             bool     `json:"continue,omitempty"`
+	GroupBy              []string `json:"groupBy,omitempty"`
+	GroupWait            string   `json:"groupWait,omitempty"`
+	GroupInterval        string   `json:"groupInterval,omitempty"`
+	RepeatInterval       string   `json:"repeatInterval,omitempty"`
+	MuteTimeIntervals    []string `json:"muteTimeIntervals,omitempty"`
+	ActiveTimeIntervals  []string `json:"activeTimeIntervals,omitempty"`
+}
+
+type notificationPolicyTree struct {
+	// Tree is the policy tree rendered as indented text.
+	Tree     string               `json:"tree"`
+	Policies []notificationPolicy `json:"policies"`
+}
+
+func getNotificationPolicyTree(ctx context.Context, args GetNotificationPolicyTreeParams) (*notificationPolicyTree, error) {
+	root, err := fetchNotificationPolicyTree(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get notification policy tree: %w", err)
+	}
+
+	policies, err := flattenNotificationPolicies(root, 0, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("get notification policy tree: %w", err)
+	}
+	return &notificationPolicyTree{
+		Tree:     formatNotificationPolicies(policies),
+		Policies: policies,
+	}, nil
+}
+
+func fetchNotificationPolicyTree(ctx context.Context) (*models.Route, error) {
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	resp, err := c.Provisioning.GetPolicyTreeWithParams(provisioning.NewGetPolicyTreeParamsWithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.Payload == nil {
+		return nil, fmt.Errorf("empty notification policy tree")
+	}
+	return resp.Payload, nil
+}
+
+// flattenNotificationPolicies appends the route and its children, depth
+// first, to policies.
+func flattenNotificationPolicies(route *models.Route, depth int, parentContactPoint string, policies []notificationPolicy) ([]notificationPolicy, error) {
+	matchers, err := notificationPolicyMatchers(route)
+	if err != nil {
+		return nil, err
+	}
+	policy := notificationPolicy{
+		Depth:               depth,
+		Matchers:            matcherStrings(matchers),
+		ContactPoint:        route.Receiver,
+		Continue:            route.Continue,
+		GroupBy:             route.GroupBy,
+		GroupWait:           route.GroupWait,
+		GroupInterval:       route.GroupInterval,
+		RepeatInterval:      route.RepeatInterval,
+		MuteTimeIntervals:   route.MuteTimeIntervals,
+		ActiveTimeIntervals: route.ActiveTimeIntervals,
+	}
+	if policy.ContactPoint == "" && parentContactPoint != "" {
+		policy.ContactPoint = parentContactPoint
+		policy.InheritsContactPoint = true
+	}
+	policies = append(policies, policy)
+	for _, child := range route.Routes {
+		if child == nil {
+			continue
+		}
+		if policies, err = flattenNotificationPolicies(child, depth+1, policy.ContactPoint, policies); err != nil {
+			return nil, err
+		}
+	}
+	return policies, nil
+}
+
+// notificationPolicyMatchers returns a route's matchers. Routes may use any of
+// the matcher formats Alertmanager has supported over time.
+func notificationPolicyMatchers(route *models.Route) (amlabels.Matchers, error) {
+	var matchers amlabels.Matchers
+	add := func(op, name, value string) error {
+		matchType, ok := notificationPolicyMatchTypes[op]
+		if !ok {
+			return fmt.Errorf("invalid matcher operator %q for label %q", op, name)
+		}
+		m, err := amlabels.NewMatcher(matchType, name, value)
+		if err != nil {
+			return fmt.Errorf("invalid matcher for label %q: %w", name, err)
+		}
+		matchers = append(matchers, m)
+		return nil
+	}
+
+	for _, m := range route.ObjectMatchers {
+		if len(m) != 3 {
+			return nil, fmt.Errorf("invalid object matcher %v: expected a label name, operator and value", []string(m))
+		}
+		if err := add(m[1], m[0], m[2]); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range route.Matchers {
+		if m == nil || m.Name == nil || m.Value == nil {
+			continue
+		}
+		isRegex := m.IsRegex != nil && *m.IsRegex
+		var op string
+		switch {
+		case isRegex && m.IsEqual:
+			op = "=~"
+		case isRegex:
+			op = "!~"
+		case m.IsEqual:
+			op = "="
+		default:
+			op = "!="
+		}
+		if err := add(op, *m.Name, *m.Value); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(route.Match)) {
+		if err := add("=", name, route.Match[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(route.MatchRe)) {
+		if err := add("=~", name, route.MatchRe[name]); err != nil {
+			return nil, err
+		}
+	}
+	return matchers, nil
+}
+
+var notificationPolicyMatchTypes = map[string]amlabels.MatchType{
+	"=":  amlabels.MatchEqual,
+	"!=": amlabels.MatchNotEqual,
+	"=~": amlabels.MatchRegexp,
+	"!~": amlabels.MatchNotRegexp,
+}
+
+// matcherStrings formats matchers in PromQL style, e.g. severity="critical".
+func matcherStrings(matchers amlabels.Matchers) []string {
+	if len(matchers) == 0 {
+		return nil
+	}
+	result := make([]string, len(matchers))
+	for i, m := range matchers {
+		result[i] = m.String()
+	}
+	return result
+}
+
+// formatNotificationPolicies renders flattened policies as an indented tree,
+// one policy per line.
+func formatNotificationPolicies(policies []notificationPolicy) string {
+	var b strings.Builder
+	for _, p := range policies {
+		b.WriteString(strings.Repeat("  ", p.Depth))
+		fmt.Fprintf(&b, "[depth %d] ", p.Depth)
+		if p.Depth == 0 {
+			b.WriteString("default policy")
+		} else if len(p.Matchers) == 0 {
+			b.WriteString("{} (matches all alerts)")
+		} else {
+			b.WriteString("{" + strings.Join(p.Matchers, ", ") + "}")
+		}
+		b.WriteString(" -> " + p.ContactPoint)
+		if p.InheritsContactPoint {
+			b.WriteString(" (inherited)")
+		}
+		var details []string
+		if len(p.GroupBy) > 0 {
+			details = append(details, "group by: "+strings.Join(p.GroupBy, ", "))
+		}
+		if len(p.MuteTimeIntervals) > 0 {
+			details = append(details, "muted during: "+strings.Join(p.MuteTimeIntervals, ", "))
+		}
+		if len(p.ActiveTimeIntervals) > 0 {
+			details = append(details, "active during: "+strings.Join(p.ActiveTimeIntervals, ", "))
+		}
+		if p.Continue {
+			details = append(details, "continue matching")
+		}
+		if len(details) > 0 {
+			b.WriteString(" [" + strings.Join(details, "; ") + "]")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+var GetNotificationPolicyTree = mcpgrafana.MustTool(
+	"get_notification_policy_tree",
+	"Retrieves the Grafana Alerting notification policy tree, which decides which contact point each alert is sent to. Returns the policies flattened depth first, each with its depth, label matchers, contact point (inherited from its parent if not set), grouping, timing and mute timings, plus the whole tree rendered as indented text. An alert is routed to the first matching child policy at each level, or to further siblings too if 'continue' is set; if no child matches it uses the parent's contact point.",
+	getNotificationPolicyTree,
+	mcp.WithTitleAnnotation("Get notification policy tree"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type EvaluateNotificationRouteParams struct {
+	Labels map[string]string `json:"labels" jsonschema:"required,description=The labels of the alert to route\\, e.g. {\"severity\": \"critical\"\\, \"team\": \"db\"}"`
+}
+
+func (p EvaluateNotificationRouteParams) validate() error {
+	if len(p.Labels) == 0 {
+		return fmt.Errorf("labels are required")
+	}
+	return nil
+}
+
+// notificationRouteStep is a policy an alert matched on its way through the
+// notification policy tree.
+type notificationRouteStep struct {
+	Depth        int      `json:"depth"`
+	Matchers     []string `json:"matchers,omitempty"`
+	ContactPoint string   `json:"contactPoint,omitempty"`
+	Continue     bool     `json:"continue,omitempty"`
+}
+
+// notificationRouteMatch is a policy an alert is routed to, with the path of
+// policies leading to it from the default policy.
+type notificationRouteMatch struct {
+	ContactPoint string                  `json:"contactPoint"`
+	Path         []notificationRouteStep `json:"path"`
+}
+
+type notificationRouteEvaluation struct {
+	// ContactPoints are the distinct contact points the alert is sent to.
+	ContactPoints []string                 `json:"contactPoints"`
+	Matches       []notificationRouteMatch `json:"matches"`
+}
+
+func evaluateNotificationRoute(ctx context.Context, args EvaluateNotificationRouteParams) (*notificationRouteEvaluation, error) {
+	if err := args.validate(); err != nil {
+		return nil, fmt.Errorf("evaluate notification route: %w", err)
+	}
+
+	root, err := fetchNotificationPolicyTree(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate notification route: %w", err)
+	}
+	matches, err := matchNotificationRoute(root, args.Labels, 0, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate notification route: %w", err)
+	}
+
+	result := &notificationRouteEvaluation{ContactPoints: []string{}, Matches: matches}
+	for _, m := range matches {
+		if !slices.Contains(result.ContactPoints, m.ContactPoint) {
+			result.ContactPoints = append(result.ContactPoints, m.ContactPoint)
+		}
+	}
+	return result, nil
+}
+
+// matchNotificationRoute routes an alert with the given labels through route
+// the way Alertmanager does: if the route matches, the alert goes to the
+// first matching child, and to later matching children too while the matched
+// children have continue set. If no child matches, the alert goes to the
+// route itself. It returns nil if the route doesn't match.
+func matchNotificationRoute(route *models.Route, alertLabels map[string]string, depth int, parentContactPoint string, path []notificationRouteStep) ([]notificationRouteMatch, error) {
+	matchers, err := notificationPolicyMatchers(route)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matchers {
+		if !m.Matches(alertLabels[m.Name]) {
+			return nil, nil
+		}
+	}
+
+	contactPoint := route.Receiver
+	if contactPoint == "" {
+		contactPoint = parentContactPoint
+	}
+	path = append(slices.Clone(path), notificationRouteStep{
+		Depth:        depth,
+		Matchers:     matcherStrings(matchers),
+		ContactPoint: contactPoint,
+		Continue:     route.Continue,
+	})
+
+	var matches []notificationRouteMatch
+	for _, child := range route.Routes {
+		if child == nil {
+			continue
+		}
+		childMatches, err := matchNotificationRoute(child, alertLabels, depth+1, contactPoint, path)
+		if err != nil {
+			return nil, err
+		}
+		if len(childMatches) == 0 {
+			continue
+		}
+		matches = append(matches, childMatches...)
+		if !child.Continue {
+			break
+		}
+	}
+	if len(matches) == 0 {
+		matches = []notificationRouteMatch{{ContactPoint: contactPoint, Path: path}}
+	}
+	return matches, nil
+}
+
+var EvaluateNotificationRoute = mcpgrafana.MustTool(
+	"evaluate_notification_route",
+	"Works out which contact points an alert with the given labels would be sent to, by walking the Grafana Alerting notification policy tree locally with Alertmanager's routing rules, without firing an alert. Returns the distinct contact points, and for each matched policy the path of policies from the default policy to it with the matchers that matched at each level. Mute and active time intervals are not taken into account.",
+	evaluateNotificationRoute,
+	mcp.WithTitleAnnotation("Evaluate notification routing"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type TestContactPointParams struct {
+	Name string `json:"name" jsonschema:"required,description=The name of the Grafana-managed contact point to send a test notification to"`
+}
+
+// contactPointTestResult is the outcome of testing each integration of a
+// contact point.
+type contactPointTestResult struct {
+	ContactPoint string               `json:"contactPoint"`
+	NotifiedAt   time.Time            `json:"notifiedAt"`
+	Integrations []testReceiverResult `json:"integrations"`
+}
+
+func testContactPoint(ctx context.Context, args TestContactPointParams) (*contactPointTestResult, error) {
+	if args.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	params := provisioning.NewGetContactpointsParams().WithContext(ctx).WithName(&args.Name)
+	response, err := c.Provisioning.GetContactpoints(params)
+	if err != nil {
+		return nil, fmt.Errorf("get contact point %s: %w", args.Name, err)
+	}
+	if len(response.Payload) == 0 {
+		return nil, fmt.Errorf("contact point %s not found", args.Name)
+	}
+
+	receiver := testReceiver{Name: args.Name}
+	for _, cp := range response.Payload {
+		receiver.GrafanaManagedReceiverConfigs = append(receiver.GrafanaManagedReceiverConfigs, testIntegrationFromContactPoint(cp))
+	}
+
+	client, err := newAlertingClientFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating alerting client: %w", err)
+	}
+	resp, err := client.TestReceivers(ctx, []testReceiver{receiver})
+	if err != nil {
+		return nil, fmt.Errorf("test contact point %s: %w", args.Name, err)
+	}
+
+	result := &contactPointTestResult{ContactPoint: args.Name, NotifiedAt: resp.NotifiedAt, Integrations: []testReceiverResult{}}
+	for _, r := range resp.Receivers {
+		result.Integrations = append(result.Integrations, r.Configs...)
+	}
+	return result, nil
+}
+
+// testIntegrationFromContactPoint converts a provisioned contact point
+// integration into one which can be tested. Grafana returns secure settings
+// redacted, so they're sent as secure fields for Grafana to fill in.
+func testIntegrationFromContactPoint(cp *models.EmbeddedContactPoint) testReceiverIntegration {
+	integration := testReceiverIntegration{
+		UID:                   cp.UID,
+		Name:                  cp.Name,
+		DisableResolveMessage: cp.DisableResolveMessage,
+		Settings:              map[string]any{},
+	}
+	if cp.Type != nil {
+		integration.Type = *cp.Type
+	}
+	if settings, ok := cp.Settings.(map[string]any); ok {
+		for k, v := range settings {
+			if v == redactedSettingValue {
+				if integration.SecureFields == nil {
+					integration.SecureFields = map[string]bool{}
+				}
+				integration.SecureFields[k] = true
+				continue
+			}
+			integration.Settings[k] = v
+		}
+	}
+	return integration
+}
+
+var TestContactPoint = mcpgrafana.MustMutatingTool(
+	"test_contact_point",
+	"Sends a test notification through every integration of a Grafana-managed contact point, identified by name, and returns the delivery status of each integration. Use this to debug alert delivery. Note that this sends a real notification to the configured destinations.",
+	testContactPoint,
+	mcp.WithTitleAnnotation("Test contact point"),
+	mcp.WithIdempotentHintAnnotation(false),
+)
+
+type CreateAlertSilenceParams struct {
+	Matchers  []LabelMatcher `json:"matchers" jsonschema:"required,description=Label matchers selecting the alerts to silence. All must match"`
+	Duration  string         `json:"duration" jsonschema:"required,description=How long the silence lasts from now (e.g. '30m'\\, '2h'\\, '1d')"`
+	Comment   string         `json:"comment" jsonschema:"required,description=Why the alerts are being silenced"`
+	CreatedBy string         `json:"createdBy" jsonschema:"required,description=Who is creating the silence"`
+}
+
+func (p CreateAlertSilenceParams) validate() error {
+	if len(p.Matchers) == 0 {
+		return fmt.Errorf("at least one matcher is required")
+	}
+	if p.Duration == "" {
+		return fmt.Errorf("duration is required")
+	}
+	if p.Comment == "" {
+		return fmt.Errorf("comment is required")
+	}
+	if p.CreatedBy == "" {
+		return fmt.Errorf("createdBy is required")
+	}
+	return nil
+}
+
+// createAlertSilenceResult describes a newly created silence.
+type createAlertSilenceResult struct {
+	SilenceID string    `json:"silenceId"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+}
+
+func createAlertSilence(ctx context.Context, args CreateAlertSilenceParams) (*createAlertSilenceResult, error) {
+	if err := args.validate(); err != nil {
+		return nil, fmt.Errorf("create alert silence: %w", err)
+	}
+	matchers, err := toSilenceMatchers(args.Matchers)
+	if err != nil {
+		return nil, fmt.Errorf("create alert silence: %w", err)
+	}
+	duration, err := parseSilenceDuration(args.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("create alert silence: %w", err)
+	}
+
+	client, err := newAlertingClientFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating alerting client: %w", err)
+	}
+
+	startsAt := time.Now().UTC()
+	silence := postableSilence{
+		Matchers:  matchers,
+		StartsAt:  startsAt,
+		EndsAt:    startsAt.Add(duration),
+		CreatedBy: args.CreatedBy,
+		Comment:   args.Comment,
+	}
+	id, err := client.CreateSilence(ctx, silence)
+	if err != nil {
+		return nil, fmt.Errorf("create alert silence: %w", err)
+	}
+	return &createAlertSilenceResult{SilenceID: id, StartsAt: silence.StartsAt, EndsAt: silence.EndsAt}, nil
+}
+
+// toSilenceMatchers converts label matchers into Alertmanager silence
+// matchers.
+func toSilenceMatchers(matchers []LabelMatcher) ([]silenceMatcher, error) {
+	result := make([]silenceMatcher, 0, len(matchers))
+	for _, m := range matchers {
+		if m.Name == "" {
+			return nil, fmt.Errorf("matcher name is required")
+		}
+		matchType, ok := matchTypeMap[m.Type]
+		if !ok {
+			return nil, fmt.Errorf("invalid matcher type %q for label %s, must be one of: =, !=, =~, !~", m.Type, m.Name)
+		}
+		result = append(result, silenceMatcher{
+			Name:    m.Name,
+			Value:   m.Value,
+			IsRegex: matchType == labels.MatchRegexp || matchType == labels.MatchNotRegexp,
+			IsEqual: matchType == labels.MatchEqual || matchType == labels.MatchRegexp,
+		})
+	}
+	return result, nil
+}
+
+// parseSilenceDuration parses a Prometheus style duration such as '2h' or
+// '1d', which must be positive.
+func parseSilenceDuration(s string) (time.Duration, error) {
+	d, err := model.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be positive", s)
+	}
+	return time.Duration(d), nil
+}
+
+var CreateAlertSilence = mcpgrafana.MustMutatingTool(
+	"create_alert_silence",
+	"Creates a silence in the Grafana Alertmanager, muting notifications for alerts matching all of the given label matchers from now for the given duration (e.g. '2h'). Use this to acknowledge a known issue. Returns the ID of the created silence along with when it starts and ends.",
+	createAlertSilence,
+	mcp.WithTitleAnnotation("Create alert silence"),
+	mcp.WithIdempotentHintAnnotation(false),
+)
+
+// silenceStates are the states list_alert_silences can filter by.
+var silenceStates = []string{"active", "pending", "expired"}
+
+type ListAlertSilencesParams struct {
+	State string `json:"state,omitempty" jsonschema:"description=Optionally\\, only return silences in this state: 'active'\\, 'pending' or 'expired'"`
+}
+
+func (p ListAlertSilencesParams) validate() error {
+	if p.State != "" && !slices.Contains(silenceStates, p.State) {
+		return fmt.Errorf("invalid state: %q, must be one of: %s", p.State, strings.Join(silenceStates, ", "))
+	}
+	return nil
+}
+
+type alertSilenceSummary struct {
+	ID        string         `json:"id"`
+	State     string         `json:"state"`
+	Matchers  []LabelMatcher `json:"matchers"`
+	StartsAt  time.Time      `json:"startsAt"`
+	EndsAt    time.Time      `json:"endsAt"`
+	CreatedBy string         `json:"createdBy,omitempty"`
+	Comment   string         `json:"comment,omitempty"`
+}
+
+func listAlertSilences(ctx context.Context, args ListAlertSilencesParams) ([]alertSilenceSummary, error) {
+	if err := args.validate(); err != nil {
+		return nil, fmt.Errorf("list alert silences: %w", err)
+	}
+
+	client, err := newAlertingClientFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating alerting client: %w", err)
+	}
+	silences, err := client.GetSilences(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list alert silences: %w", err)
+	}
+
+	result := []alertSilenceSummary{}
+	for _, s := range silences {
+		if args.State != "" && s.Status.State != args.State {
+			continue
+		}
+		result = append(result, alertSilenceSummary{
+			ID:        s.ID,
+			State:     s.Status.State,
+			Matchers:  fromSilenceMatchers(s.Matchers),
+			StartsAt:  s.StartsAt,
+			EndsAt:    s.EndsAt,
+			CreatedBy: s.CreatedBy,
+			Comment:   s.Comment,
+		})
+	}
+	return result, nil
+}
+
+// fromSilenceMatchers converts Alertmanager silence matchers into label
+// matchers, the inverse of toSilenceMatchers.
+func fromSilenceMatchers(matchers []silenceMatcher) []LabelMatcher {
+	result := make([]LabelMatcher, 0, len(matchers))
+	for _, m := range matchers {
+		matchType := "="
+		switch {
+		case m.IsEqual && m.IsRegex:
+			matchType = "=~"
+		case !m.IsEqual && m.IsRegex:
+			matchType = "!~"
+		case !m.IsEqual:
+			matchType = "!="
+		}
+		result = append(result, LabelMatcher{Name: m.Name, Value: m.Value, Type: matchType})
+	}
+	return result
+}
+
+var ListAlertSilences = mcpgrafana.MustTool(
+	"list_alert_silences",
+	"Lists silences of the Grafana Alertmanager, including their ID, state ('active', 'pending' or 'expired'), label matchers, start and end times, creator and comment. Optionally filter by state, for example to see which alerts are currently silenced.",
+	listAlertSilences,
+	mcp.WithTitleAnnotation("List alert silences"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type ListAlertmanagerAlertGroupsParams struct {
+	Active    *bool `json:"active,omitempty" jsonschema:"description=Whether to include active alerts\\, which are neither silenced nor inhibited. Defaults to true"`
+	Silenced  *bool `json:"silenced,omitempty" jsonschema:"description=Whether to include silenced alerts. Defaults to true"`
+	Inhibited *bool `json:"inhibited,omitempty" jsonschema:"description=Whether to include inhibited alerts. Defaults to true"`
+}
+
+// alertGroupSummary is a group of alerts which are notified together.
+type alertGroupSummary struct {
+	// Labels are the labels the alerts are grouped by.
+	Labels   map[string]string `json:"labels"`
+	Receiver string            `json:"receiver"`
+	Alerts   []alertSummary    `json:"alerts"`
+}
+
+type alertSummary struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// State is 'active', 'suppressed' (silenced or inhibited) or
+	// 'unprocessed'.
+	State        string    `json:"state"`
+	SilencedBy   []string  `json:"silencedBy,omitempty"`
+	InhibitedBy  []string  `json:"inhibitedBy,omitempty"`
+	StartsAt     time.Time `json:"startsAt"`
+	Fingerprint  string    `json:"fingerprint,omitempty"`
+	GeneratorURL string    `json:"generatorURL,omitempty"`
+}
+
+func listAlertmanagerAlertGroups(ctx context.Context, args ListAlertmanagerAlertGroupsParams) ([]alertGroupSummary, error) {
+	client, err := newAlertingClientFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating alerting client: %w", err)
+	}
+
+	query := url.Values{}
+	for name, value := range map[string]*bool{"active": args.Active, "silenced": args.Silenced, "inhibited": args.Inhibited} {
+		if value != nil {
+			query.Set(name, strconv.FormatBool(*value))
+		}
+	}
+	groups, err := client.GetAlertGroups(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list alert groups: %w", err)
+	}
+
+	result := make([]alertGroupSummary, 0, len(groups))
+	for _, g := range groups {
+		// Groups with no alerts left after filtering aren't worth returning.
+		if len(g.Alerts) == 0 {
+			continue
+		}
+		group := alertGroupSummary{
+			Labels:   g.Labels,
+			Receiver: g.Receiver.Name,
+			Alerts:   make([]alertSummary, 0, len(g.Alerts)),
+		}
+		for _, a := range g.Alerts {
+			group.Alerts = append(group.Alerts, alertSummary{
+				Labels:       a.Labels,
+				Annotations:  a.Annotations,
+				State:        a.Status.State,
+				SilencedBy:   a.Status.SilencedBy,
+				InhibitedBy:  a.Status.InhibitedBy,
+				StartsAt:     a.StartsAt,
+				Fingerprint:  a.Fingerprint,
+				GeneratorURL: a.GeneratorURL,
+			})
+		}
+		result = append(result, group)
+	}
+	return result, nil
+}
+
+var ListAlertmanagerAlertGroups = mcpgrafana.MustTool(
+	"list_alertmanager_alert_groups",
+	"Lists the alerts currently held by the Grafana Alertmanager, grouped as they are notified: each group has the labels its notification policy groups by, its contact point (receiver) and its alerts, each with labels, annotations, start time and state ('active', or 'suppressed' with the IDs of the silences or alerts silencing or inhibiting it). This is a deduplicated view of what is firing. Not to be confused with list_alert_groups, which lists Grafana OnCall alert groups. Set active, silenced or inhibited to false to leave those alerts out, for example silenced=false to see only what isn't silenced.",
+	listAlertmanagerAlertGroups,
+	mcp.WithTitleAnnotation("List Alertmanager alert groups"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type ExpireSilenceParams struct {
+	SilenceID string `json:"silenceId" jsonschema:"required,description=The ID of the silence to expire"`
+}
+
+func expireSilence(ctx context.Context, args ExpireSilenceParams) (string, error) {
+	if args.SilenceID == "" {
+		return "", fmt.Errorf("expire silence: silenceId is required")
+	}
+
+	client, err := newAlertingClientFromContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("creating alerting client: %w", err)
+	}
+	if err := client.ExpireSilence(ctx, args.SilenceID); err != nil {
+		return "", fmt.Errorf("expire silence: %w", err)
+	}
+	return fmt.Sprintf("Silence %s expired successfully", args.SilenceID), nil
+}
+
+var ExpireSilence = mcpgrafana.MustMutatingTool(
+	"expire_silence",
+	"Expires a silence of the Grafana Alertmanager by its ID, ending it early so that notifications for the alerts it matched resume.",
+	expireSilence,
+	mcp.WithTitleAnnotation("Expire silence"),
+	mcp.WithIdempotentHintAnnotation(true),
+)
+
 type CreateAlertRuleParams struct {
 	Title             string               `json:"title" jsonschema:"required,description=The title of the alert rule"`
 	RuleGroup         string               `json:"ruleGroup" jsonschema:"required,description=The rule group name"`
@@ -674,7 +1853,7 @@ func createAlertRule(ctx context.Context, args CreateAlertRuleParams) (*models.P
 	return response.Payload, nil
 }
 
-var CreateAlertRule = mcpgrafana.MustTool(
+var CreateAlertRule = mcpgrafana.MustMutatingTool(
 	"create_alert_rule",
 	"Creates a new Grafana alert rule with the specified configuration. Requires title, rule group, folder UID, condition, query data, no data state, execution error state, and duration settings.",
 	createAlertRule,
@@ -787,7 +1966,7 @@ func updateAlertRule(ctx context.Context, args UpdateAlertRuleParams) (*models.P
 	return response.Payload, nil
 }
 
-var UpdateAlertRule = mcpgrafana.MustTool(
+var UpdateAlertRule = mcpgrafana.MustMutatingTool(
 	"update_alert_rule",
 	"Updates an existing Grafana alert rule identified by its UID. Requires all the same parameters as creating a new rule.",
 	updateAlertRule,
@@ -822,7 +2001,7 @@ func deleteAlertRule(ctx context.Context, args DeleteAlertRuleParams) (string, e
 	return fmt.Sprintf("Alert rule %s deleted successfully", args.UID), nil
 }
 
-var DeleteAlertRule = mcpgrafana.MustTool(
+var DeleteAlertRule = mcpgrafana.MustMutatingTool(
 	"delete_alert_rule",
 	"Deletes a Grafana alert rule by its UID. This action cannot be undone.",
 	deleteAlertRule,
@@ -833,10 +2012,19 @@ var DeleteAlertRule = mcpgrafana.MustTool(
 func AddAlertingTools(mcp *server.MCPServer, enableWriteTools bool) {
 	ListAlertRules.Register(mcp)
 	GetAlertRuleByUID.Register(mcp)
+	GetAlertRule.Register(mcp)
+	EvaluateAlertRule.Register(mcp)
 	if enableWriteTools {
 		CreateAlertRule.Register(mcp)
 		UpdateAlertRule.Register(mcp)
 		DeleteAlertRule.Register(mcp)
+		TestContactPoint.Register(mcp)
+		CreateAlertSilence.Register(mcp)
+		ExpireSilence.Register(mcp)
 	}
 	ListContactPoints.Register(mcp)
+	GetNotificationPolicyTree.Register(mcp)
+	EvaluateNotificationRoute.Register(mcp)
+	ListAlertSilences.Register(mcp)
+	ListAlertmanagerAlertGroups.Register(mcp)
 }
@@ -1,16 +1,20 @@
 package tools
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-openapi/runtime"
 	"github.com/prometheus/alertmanager/api/v2/models"
 	"github.com/prometheus/alertmanager/config"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
@@ -18,14 +22,84 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/grafana-openapi-client-go/client"
+	grafanamodels "github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	mcpgrafana "github.com/grafana/mcp-grafana"
 )
 
 const (
 	defaultTimeout    = 30 * time.Second
 	rulesEndpointPath = "/api/prometheus/grafana/api/v1/rules"
+	// legacyAlertsEndpointPath lists the dashboard alerts of Grafana's legacy
+	// alerting, which was replaced by unified alerting in Grafana 9.
+	legacyAlertsEndpointPath = "/api/alerts"
+
+	// unifiedAlertingMajorVersion is the first Grafana major version with
+	// unified alerting enabled by default.
+	unifiedAlertingMajorVersion = 9
+)
+
+// alertingAPI is the flavour of Grafana alerting API the alerting tools use.
+type alertingAPI int
+
+const (
+	// alertingAPIAuto tries unified alerting, falling back to legacy alerting
+	// if Grafana doesn't have the unified endpoints.
+	alertingAPIAuto alertingAPI = iota
+	alertingAPIUnified
+	alertingAPILegacy
 )
 
+// alertingAPIForVersion picks the alerting API for a Grafana version such as
+// "8.5.2" or "v10", as configured with GRAFANA_API_VERSION. An empty or
+// unparseable version means alertingAPIAuto.
+func alertingAPIForVersion(version string) alertingAPI {
+	if version == "" {
+		return alertingAPIAuto
+	}
+	majorStr, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	major, err := strconv.Atoi(majorStr)
+	if err != nil || major <= 0 {
+		slog.Warn("invalid GRAFANA_API_VERSION value, detecting the alerting API instead", "value", version)
+		return alertingAPIAuto
+	}
+	if major < unifiedAlertingMajorVersion {
+		return alertingAPILegacy
+	}
+	return alertingAPIUnified
+}
+
+// errUnifiedAlertingRequired is returned by operations which only exist in
+// unified alerting when Grafana is configured as using legacy alerting.
+var errUnifiedAlertingRequired = errors.New("this operation requires Grafana unified alerting (Grafana 9 or later), but GRAFANA_API_VERSION is set to an older version")
+
+// alertingAPIError is returned for non-2xx responses from the Grafana API.
+type alertingAPIError struct {
+	statusCode int
+	message    string
+}
+
+func (e *alertingAPIError) Error() string {
+	return fmt.Sprintf("grafana API returned status code %d: %s", e.statusCode, e.message)
+}
+
+// Code returns the status code of the response, so that the error is
+// classified by mcpgrafana.ErrorKindOf.
+func (e *alertingAPIError) Code() int {
+	return e.statusCode
+}
+
+// isNotFound reports whether err is a 404 response from the Grafana API,
+// from either the alerting client or the OpenAPI client.
+func isNotFound(err error) bool {
+	var alertingErr *alertingAPIError
+	if errors.As(err, &alertingErr) {
+		return alertingErr.statusCode == http.StatusNotFound
+	}
+	var apiErr *runtime.APIError
+	return errors.As(err, &apiErr) && apiErr.IsCode(http.StatusNotFound)
+}
+
 type alertingClient struct {
 	baseURL     *url.URL
 	accessToken string
@@ -33,6 +107,7 @@ type alertingClient struct {
 	apiKey      string
 	basicAuth   *url.Userinfo
 	orgID       int64
+	api         alertingAPI
 	httpClient  *http.Client
 }
 
@@ -51,6 +126,7 @@ func newAlertingClientFromContext(ctx context.Context) (*alertingClient, error)
 		apiKey:      cfg.APIKey,
 		basicAuth:   cfg.BasicAuth,
 		orgID:       cfg.OrgID,
+		api:         alertingAPIForVersion(cfg.APIVersion),
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
@@ -67,9 +143,33 @@ func newAlertingClientFromContext(ctx context.Context) (*alertingClient, error)
 }
 
 func (c *alertingClient) makeRequest(ctx context.Context, path string) (*http.Response, error) {
-	p := c.baseURL.JoinPath(path).String()
+	return c.doRequest(ctx, http.MethodGet, path, nil)
+}
+
+// doRequest sends a request with an optional JSON body, returning an error
+// for any non-2xx response.
+func (c *alertingClient) doRequest(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	return c.doRequestWithQuery(ctx, method, path, nil, body)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p, nil)
+// doRequestWithQuery is doRequest with optional query parameters.
+func (c *alertingClient) doRequestWithQuery(ctx context.Context, method, path string, query url.Values, body any) (*http.Response, error) {
+	u := c.baseURL.JoinPath(path)
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	p := u.String()
+
+	var bodyReader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body for %s: %w", p, err)
+		}
+		bodyReader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request to %s: %w", p, err)
 	}
@@ -97,10 +197,10 @@ func (c *alertingClient) makeRequest(ctx context.Context, path string) (*http.Re
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request to %s: %w", p, err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes := readErrorBody(resp.Body)
 		_ = resp.Body.Close() //nolint:errcheck
-		return nil, fmt.Errorf("grafana API returned status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, &alertingAPIError{statusCode: resp.StatusCode, message: summarizeErrorBody(bodyBytes)}
 	}
 
 	return resp, nil
@@ -170,6 +270,36 @@ type alert struct {
 	Value       string        `json:"value"`
 }
 
+// legacyAlert is a dashboard alert of Grafana's legacy alerting.
+type legacyAlert struct {
+	ID             int64     `json:"id"`
+	DashboardUID   string    `json:"dashboardUid"`
+	PanelID        int64     `json:"panelId"`
+	Name           string    `json:"name"`
+	State          string    `json:"state"`
+	NewStateDate   time.Time `json:"newStateDate"`
+	EvalDate       time.Time `json:"evalDate"`
+	ExecutionError string    `json:"executionError"`
+	URL            string    `json:"url"`
+}
+
+// GetLegacyAlerts lists the dashboard alerts of Grafana's legacy alerting.
+func (c *alertingClient) GetLegacyAlerts(ctx context.Context) ([]legacyAlert, error) {
+	resp, err := c.makeRequest(ctx, legacyAlertsEndpointPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get legacy alerts from Grafana API: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+
+	var alerts []legacyAlert
+	if err := json.NewDecoder(resp.Body).Decode(&alerts); err != nil {
+		return nil, fmt.Errorf("failed to decode legacy alerts response from %s: %w", legacyAlertsEndpointPath, err)
+	}
+	return alerts, nil
+}
+
 // GetDatasourceRules queries a datasource's Prometheus ruler API
 func (c *alertingClient) GetDatasourceRules(ctx context.Context, datasourceUID string) (*v1.RulesResult, error) {
 	// use the Grafana unified endpoint - maybe we need to use the datasource proxy endpoint in the future as this
@@ -267,3 +397,237 @@ func (c *alertingClient) GetAlertmanagerConfig(ctx context.Context, datasourceUI
 
 	return &cfg, nil
 }
+
+const testReceiversEndpointPath = "/api/alertmanager/grafana/config/api/v1/receivers/test"
+
+type testReceiversRequest struct {
+	Receivers []testReceiver `json:"receivers"`
+}
+
+type testReceiver struct {
+	Name                          string                    `json:"name"`
+	GrafanaManagedReceiverConfigs []testReceiverIntegration `json:"grafana_managed_receiver_configs"`
+}
+
+// testReceiverIntegration is a contact point integration to test. Secure
+// settings are omitted from Settings and listed in SecureFields instead, so
+// that Grafana uses the stored values.
+type testReceiverIntegration struct {
+	UID                   string          `json:"uid,omitempty"`
+	Name                  string          `json:"name"`
+	Type                  string          `json:"type"`
+	DisableResolveMessage bool            `json:"disableResolveMessage"`
+	Settings              map[string]any  `json:"settings"`
+	SecureFields          map[string]bool `json:"secureFields,omitempty"`
+}
+
+type testReceiversResponse struct {
+	NotifiedAt time.Time `json:"notified_at"`
+	Receivers  []struct {
+		Name    string               `json:"name"`
+		Configs []testReceiverResult `json:"grafana_managed_receiver_configs"`
+	} `json:"receivers"`
+}
+
+// testReceiverResult is the outcome of testing a single integration.
+type testReceiverResult struct {
+	Name   string `json:"name"`
+	UID    string `json:"uid,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// TestReceivers sends a test notification through the given receivers of the
+// Grafana Alertmanager.
+func (c *alertingClient) TestReceivers(ctx context.Context, receivers []testReceiver) (*testReceiversResponse, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, testReceiversEndpointPath, testReceiversRequest{Receivers: receivers})
+	if err != nil {
+		return nil, fmt.Errorf("failed to test receivers: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+
+	var result testReceiversResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode test receivers response: %w", err)
+	}
+	return &result, nil
+}
+
+const (
+	// grafanaAlertmanagerAPIPath is the base path of the Alertmanager API of
+	// the Grafana-managed Alertmanager.
+	grafanaAlertmanagerAPIPath = "/api/alertmanager/grafana/api/v2"
+	silencesEndpointPath       = grafanaAlertmanagerAPIPath + "/silences"
+	silenceEndpointPath        = grafanaAlertmanagerAPIPath + "/silence"
+	alertGroupsEndpointPath    = grafanaAlertmanagerAPIPath + "/alerts/groups"
+)
+
+// silenceMatcher is an Alertmanager silence matcher.
+type silenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+type postableSilence struct {
+	Matchers  []silenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+}
+
+type postSilenceResponse struct {
+	SilenceID string `json:"silenceID"`
+}
+
+// CreateSilence creates a silence in the Grafana Alertmanager, returning its
+// ID.
+func (c *alertingClient) CreateSilence(ctx context.Context, silence postableSilence) (string, error) {
+	if c.api == alertingAPILegacy {
+		return "", errUnifiedAlertingRequired
+	}
+	resp, err := c.doRequest(ctx, http.MethodPost, silencesEndpointPath, silence)
+	if err != nil {
+		return "", fmt.Errorf("failed to create silence: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+
+	var result postSilenceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode create silence response: %w", err)
+	}
+	return result.SilenceID, nil
+}
+
+// gettableSilence is a silence as returned by the Alertmanager API.
+type gettableSilence struct {
+	ID     string `json:"id"`
+	Status struct {
+		// State is one of active, pending or expired.
+		State string `json:"state"`
+	} `json:"status"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+	Matchers  []silenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+}
+
+// GetSilences lists the silences of the Grafana Alertmanager.
+func (c *alertingClient) GetSilences(ctx context.Context) ([]gettableSilence, error) {
+	if c.api == alertingAPILegacy {
+		return nil, errUnifiedAlertingRequired
+	}
+	resp, err := c.makeRequest(ctx, silencesEndpointPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get silences: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+
+	var result []gettableSilence
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode silences response: %w", err)
+	}
+	return result, nil
+}
+
+// ExpireSilence ends the silence with the given ID.
+func (c *alertingClient) ExpireSilence(ctx context.Context, id string) error {
+	if c.api == alertingAPILegacy {
+		return errUnifiedAlertingRequired
+	}
+	resp, err := c.doRequest(ctx, http.MethodDelete, silenceEndpointPath+"/"+url.PathEscape(id), nil)
+	if err != nil {
+		return fmt.Errorf("failed to expire silence %s: %w", id, err)
+	}
+	_ = resp.Body.Close() //nolint:errcheck
+	return nil
+}
+
+// gettableAlertGroup is a group of alerts as returned by the Alertmanager
+// API: the alerts sharing the values of the labels their notification policy
+// groups by.
+type gettableAlertGroup struct {
+	Labels   map[string]string `json:"labels"`
+	Receiver struct {
+		Name string `json:"name"`
+	} `json:"receiver"`
+	Alerts []gettableAlert `json:"alerts"`
+}
+
+// gettableAlert is an alert as returned by the Alertmanager API.
+type gettableAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	Status      struct {
+		// State is one of active, suppressed or unprocessed.
+		State       string   `json:"state"`
+		SilencedBy  []string `json:"silencedBy"`
+		InhibitedBy []string `json:"inhibitedBy"`
+	} `json:"status"`
+	StartsAt     time.Time `json:"startsAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	Fingerprint  string    `json:"fingerprint"`
+	GeneratorURL string    `json:"generatorURL"`
+}
+
+// GetAlertGroups lists the alert groups of the Grafana Alertmanager. query
+// holds the active, silenced and inhibited filters of the Alertmanager API.
+func (c *alertingClient) GetAlertGroups(ctx context.Context, query url.Values) ([]gettableAlertGroup, error) {
+	if c.api == alertingAPILegacy {
+		return nil, errUnifiedAlertingRequired
+	}
+	resp, err := c.doRequestWithQuery(ctx, http.MethodGet, alertGroupsEndpointPath, query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert groups: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+
+	var result []gettableAlertGroup
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode alert groups response: %w", err)
+	}
+	return result, nil
+}
+
+// evalQueriesEndpointPath evaluates alert rule queries and expressions
+// without saving anything or sending notifications.
+const evalQueriesEndpointPath = "/api/v1/eval"
+
+type evalQueriesRequest struct {
+	Data      []*grafanamodels.AlertQuery `json:"data"`
+	Condition string                      `json:"condition"`
+	Now       time.Time                   `json:"now"`
+}
+
+// EvalQueries evaluates the queries and expressions of an alert rule as of
+// now, returning the frames computed for each of them by ref ID.
+func (c *alertingClient) EvalQueries(ctx context.Context, queries []*grafanamodels.AlertQuery, condition string, now time.Time) (*backend.QueryDataResponse, error) {
+	if c.api == alertingAPILegacy {
+		return nil, errUnifiedAlertingRequired
+	}
+	resp, err := c.doRequest(ctx, http.MethodPost, evalQueriesEndpointPath, evalQueriesRequest{Data: queries, Condition: condition, Now: now})
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate queries: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+
+	var result backend.QueryDataResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode evaluation response: %w", err)
+	}
+	return &result, nil
+}
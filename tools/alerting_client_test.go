@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/prometheus/prometheus/model/labels"
@@ -89,6 +90,39 @@ func TestAlertingClient_GetRules_Error(t *testing.T) {
 		require.ErrorContains(t, err, "grafana API returned status code 500: internal server error")
 	})
 
+	t.Run("bad request with Grafana error body", func(t *testing.T) {
+		server, client := setupMockServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, err := w.Write([]byte(`{"message":"invalid rule query","messageId":"alerting.invalidQuery","traceID":"abc123"}`))
+			require.NoError(t, err)
+		})
+		defer server.Close()
+
+		rules, err := client.GetRules(context.Background())
+		require.Error(t, err)
+		require.Nil(t, rules)
+		require.ErrorContains(t, err, "grafana API returned status code 400: invalid rule query (messageId: alerting.invalidQuery, traceID: abc123)")
+	})
+
+	t.Run("internal server error with large HTML body", func(t *testing.T) {
+		html := "<html><body>" + strings.Repeat("<p>error</p>", 1000) + "</body></html>"
+		server, client := setupMockServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusInternalServerError)
+			_, err := w.Write([]byte(html))
+			require.NoError(t, err)
+		})
+		defer server.Close()
+
+		rules, err := client.GetRules(context.Background())
+		require.Error(t, err)
+		require.Nil(t, rules)
+		require.ErrorContains(t, err, "grafana API returned status code 500: <html><body><p>error</p>")
+		require.ErrorContains(t, err, "... (truncated)")
+		require.Less(t, len(err.Error()), maxErrorBodyBytes+200)
+	})
+
 	t.Run("network error", func(t *testing.T) {
 		server, client := setupMockServer(func(w http.ResponseWriter, r *http.Request) {})
 		server.Close()
@@ -82,7 +82,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 		result, err := listAlertRules(ctx, ListAlertRulesParams{})
 		require.NoError(t, err)
 
-		require.ElementsMatch(t, allExpectedRules, clearState(result))
+		require.ElementsMatch(t, allExpectedRules, clearState(result.Rules))
 	})
 
 	t.Run("list alert rules with pagination", func(t *testing.T) {
@@ -94,7 +94,8 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			Page:  1,
 		})
 		require.NoError(t, err)
-		require.Len(t, result1, 1)
+		require.Len(t, result1.Rules, 1)
+		require.True(t, result1.HasMore)
 
 		// Get the second page with limit 1
 		result2, err := listAlertRules(ctx, ListAlertRulesParams{
@@ -102,7 +103,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			Page:  2,
 		})
 		require.NoError(t, err)
-		require.Len(t, result2, 1)
+		require.Len(t, result2.Rules, 1)
 
 		// Get the third page with limit 1
 		result3, err := listAlertRules(ctx, ListAlertRulesParams{
@@ -110,7 +111,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			Page:  3,
 		})
 		require.NoError(t, err)
-		require.Len(t, result3, 1)
+		require.Len(t, result3.Rules, 1)
 
 		// The next page is empty
 		result4, err := listAlertRules(ctx, ListAlertRulesParams{
@@ -118,14 +119,14 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			Page:  4,
 		})
 		require.NoError(t, err)
-		require.Empty(t, result4)
+		require.Empty(t, result4.Rules)
 	})
 
 	t.Run("list alert rules without the page and limit params", func(t *testing.T) {
 		ctx := newTestContext()
 		result, err := listAlertRules(ctx, ListAlertRulesParams{})
 		require.NoError(t, err)
-		require.ElementsMatch(t, allExpectedRules, clearState(result))
+		require.ElementsMatch(t, allExpectedRules, clearState(result.Rules))
 	})
 
 	t.Run("list alert rules with selectors that match", func(t *testing.T) {
@@ -144,7 +145,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			},
 		})
 		require.NoError(t, err)
-		require.ElementsMatch(t, allExpectedRules, clearState(result))
+		require.ElementsMatch(t, allExpectedRules, clearState(result.Rules))
 	})
 
 	t.Run("list alert rules with selectors that don't match", func(t *testing.T) {
@@ -163,7 +164,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			},
 		})
 		require.NoError(t, err)
-		require.Empty(t, result)
+		require.Empty(t, result.Rules)
 	})
 
 	t.Run("list alert rules with multiple selectors", func(t *testing.T) {
@@ -191,7 +192,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			},
 		})
 		require.NoError(t, err)
-		require.ElementsMatch(t, []alertRuleSummary{rule2}, clearState(result))
+		require.ElementsMatch(t, []alertRuleSummary{rule2}, clearState(result.Rules))
 	})
 
 	t.Run("list alert rules with regex matcher", func(t *testing.T) {
@@ -210,7 +211,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			},
 		})
 		require.NoError(t, err)
-		require.ElementsMatch(t, []alertRuleSummary{rule1}, clearState(result))
+		require.ElementsMatch(t, []alertRuleSummary{rule1}, clearState(result.Rules))
 	})
 
 	t.Run("list alert rules with selectors and pagination", func(t *testing.T) {
@@ -231,8 +232,8 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			Page:  1,
 		})
 		require.NoError(t, err)
-		require.Len(t, result, 1)
-		require.ElementsMatch(t, []alertRuleSummary{rule1}, clearState(result))
+		require.Len(t, result.Rules, 1)
+		require.ElementsMatch(t, []alertRuleSummary{rule1}, clearState(result.Rules))
 
 		// Second page
 		result, err = listAlertRules(ctx, ListAlertRulesParams{
@@ -251,8 +252,8 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			Page:  2,
 		})
 		require.NoError(t, err)
-		require.Len(t, result, 1)
-		require.ElementsMatch(t, []alertRuleSummary{rule2}, clearState(result))
+		require.Len(t, result.Rules, 1)
+		require.ElementsMatch(t, []alertRuleSummary{rule2}, clearState(result.Rules))
 	})
 
 	t.Run("list alert rules with not equals operator", func(t *testing.T) {
@@ -271,7 +272,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			},
 		})
 		require.NoError(t, err)
-		require.ElementsMatch(t, allExpectedRules, clearState(result))
+		require.ElementsMatch(t, allExpectedRules, clearState(result.Rules))
 	})
 
 	t.Run("list alert rules with not matches operator", func(t *testing.T) {
@@ -290,7 +291,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			},
 		})
 		require.NoError(t, err)
-		require.ElementsMatch(t, allExpectedRules, clearState(result))
+		require.ElementsMatch(t, allExpectedRules, clearState(result.Rules))
 	})
 
 	t.Run("list alert rules with non-existent label", func(t *testing.T) {
@@ -310,7 +311,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			},
 		})
 		require.NoError(t, err)
-		require.Empty(t, result)
+		require.Empty(t, result.Rules)
 	})
 
 	t.Run("list alert rules with non-existent label and inequality", func(t *testing.T) {
@@ -330,7 +331,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			},
 		})
 		require.NoError(t, err)
-		require.ElementsMatch(t, allExpectedRules, clearState(result))
+		require.ElementsMatch(t, allExpectedRules, clearState(result.Rules))
 	})
 
 	t.Run("list alert rules with a limit that is larger than the number of rules", func(t *testing.T) {
@@ -340,7 +341,7 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			Page:  1,
 		})
 		require.NoError(t, err)
-		require.ElementsMatch(t, allExpectedRules, clearState(result))
+		require.ElementsMatch(t, allExpectedRules, clearState(result.Rules))
 	})
 
 	t.Run("list alert rules with a page that doesn't exist", func(t *testing.T) {
@@ -350,7 +351,9 @@ func TestAlertingTools_ListAlertRules(t *testing.T) {
 			Page:  1000,
 		})
 		require.NoError(t, err)
-		require.Empty(t, result)
+		require.Empty(t, result.Rules)
+		require.Equal(t, len(allExpectedRules), result.Total)
+		require.False(t, result.HasMore)
 	})
 
 	t.Run("list alert rules with invalid page parameter", func(t *testing.T) {
@@ -1140,11 +1143,11 @@ func TestAlertingTools_ListAlertRules_Datasource(t *testing.T) {
 			DatasourceUID: &dsUID,
 		})
 		require.NoError(t, err)
-		require.NotEmpty(t, result, "Expected Prometheus to have alert rules configured")
+		require.NotEmpty(t, result.Rules, "Expected Prometheus to have alert rules configured")
 
 		// Verify we got Prometheus rules
 		foundFiring := false
-		for _, rule := range result {
+		for _, rule := range result.Rules {
 			require.NotEmpty(t, rule.Title)
 			// Check if we found our test rule
 			if rule.Title == "PrometheusTestAlertFiring" {
@@ -1173,7 +1176,7 @@ func TestAlertingTools_ListAlertRules_Datasource(t *testing.T) {
 		require.NoError(t, err)
 
 		// All returned rules should have severity=warning
-		for _, rule := range result {
+		for _, rule := range result.Rules {
 			require.Equal(t, "warning", rule.Labels["severity"])
 		}
 	})
@@ -1213,7 +1216,7 @@ func TestAlertingTools_ListAlertRules_Datasource(t *testing.T) {
 		if err != nil {
 			t.Logf("Loki ruler query failed (this may be expected): %v", err)
 		} else {
-			t.Logf("Loki ruler returned %d rules", len(result))
+			t.Logf("Loki ruler returned %d rules", len(result.Rules))
 		}
 	})
 }
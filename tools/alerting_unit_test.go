@@ -1,10 +1,18 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-openapi-client-go/models"
 	"github.com/stretchr/testify/require"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
 )
 
 // Unit tests for parameter validation (no integration tag needed)
@@ -413,3 +421,783 @@ func TestBuiltInValidationCatchesInvalidData(t *testing.T) {
 		require.NoError(t, err, "Simple validation doesn't check length constraints")
 	})
 }
+
+func TestApplyPaginationToMerged(t *testing.T) {
+	items := make([]mergedAlertRule, 5)
+	for i := range items {
+		items[i] = mergedAlertRule{UID: string(rune('a' + i))}
+	}
+
+	t.Run("zero limit uses default", func(t *testing.T) {
+		page, hasMore, err := applyPaginationToMerged(items, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, page, 5)
+		require.False(t, hasMore)
+	})
+
+	t.Run("first page has more", func(t *testing.T) {
+		page, hasMore, err := applyPaginationToMerged(items, 2, 1)
+		require.NoError(t, err)
+		require.Equal(t, []mergedAlertRule{{UID: "a"}, {UID: "b"}}, page)
+		require.True(t, hasMore)
+	})
+
+	t.Run("partial last page", func(t *testing.T) {
+		page, hasMore, err := applyPaginationToMerged(items, 2, 3)
+		require.NoError(t, err)
+		require.Equal(t, []mergedAlertRule{{UID: "e"}}, page)
+		require.False(t, hasMore)
+	})
+
+	t.Run("exact last page", func(t *testing.T) {
+		page, hasMore, err := applyPaginationToMerged(items, 5, 1)
+		require.NoError(t, err)
+		require.Len(t, page, 5)
+		require.False(t, hasMore)
+	})
+
+	t.Run("page past the end is empty", func(t *testing.T) {
+		page, hasMore, err := applyPaginationToMerged(items, 2, 4)
+		require.NoError(t, err)
+		require.Empty(t, page)
+		require.False(t, hasMore)
+	})
+}
+
+func TestListAlertRulesStateFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/provisioning/alert-rules":
+			_, _ = w.Write([]byte(`[
+				{"uid": "rule-firing", "title": "High CPU"},
+				{"uid": "rule-normal", "title": "Disk full"},
+				{"uid": "rule-pending", "title": "Error rate"},
+				{"uid": "rule-unevaluated", "title": "New rule"}
+			]`))
+		case rulesEndpointPath:
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"groups": [{"name": "group", "rules": [
+				{"name": "High CPU", "state": "firing", "health": "ok"},
+				{"name": "Disk full", "state": "inactive", "health": "ok"},
+				{"name": "Error rate", "state": "pending", "health": "ok"}
+			]}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	uids := func(list *alertRuleList) []string {
+		var result []string
+		for _, r := range list.Rules {
+			result = append(result, r.UID)
+		}
+		return result
+	}
+
+	t.Run("firing excludes normal rules", func(t *testing.T) {
+		result, err := listAlertRules(ctx, ListAlertRulesParams{State: "firing"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"rule-firing"}, uids(result))
+		require.Equal(t, 1, result.Total)
+		require.False(t, result.HasMore)
+	})
+
+	t.Run("inactive", func(t *testing.T) {
+		result, err := listAlertRules(ctx, ListAlertRulesParams{State: "inactive"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"rule-normal"}, uids(result))
+	})
+
+	t.Run("no state returns all rules", func(t *testing.T) {
+		result, err := listAlertRules(ctx, ListAlertRulesParams{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"rule-firing", "rule-normal", "rule-pending", "rule-unevaluated"}, uids(result))
+	})
+
+	t.Run("invalid state", func(t *testing.T) {
+		_, err := listAlertRules(ctx, ListAlertRulesParams{State: "normal"})
+		require.EqualError(t, err, `list alert rules: invalid state: "normal", must be one of: firing, pending, inactive`)
+	})
+}
+
+func TestToSilenceMatchers(t *testing.T) {
+	t.Run("serializes each match type", func(t *testing.T) {
+		matchers, err := toSilenceMatchers([]LabelMatcher{
+			{Name: "alertname", Type: "=", Value: "HighCPU"},
+			{Name: "env", Type: "!=", Value: "dev"},
+			{Name: "instance", Type: "=~", Value: "web-.*"},
+			{Name: "job", Type: "!~", Value: "test.*"},
+		})
+		require.NoError(t, err)
+
+		b, err := json.Marshal(matchers)
+		require.NoError(t, err)
+		require.JSONEq(t, `[
+			{"name": "alertname", "value": "HighCPU", "isRegex": false, "isEqual": true},
+			{"name": "env", "value": "dev", "isRegex": false, "isEqual": false},
+			{"name": "instance", "value": "web-.*", "isRegex": true, "isEqual": true},
+			{"name": "job", "value": "test.*", "isRegex": true, "isEqual": false}
+		]`, string(b))
+	})
+
+	t.Run("empty type means equal", func(t *testing.T) {
+		matchers, err := toSilenceMatchers([]LabelMatcher{{Name: "alertname", Value: "HighCPU"}})
+		require.NoError(t, err)
+		require.Equal(t, []silenceMatcher{{Name: "alertname", Value: "HighCPU", IsEqual: true}}, matchers)
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		_, err := toSilenceMatchers([]LabelMatcher{{Name: "alertname", Type: "==", Value: "HighCPU"}})
+		require.EqualError(t, err, `invalid matcher type "==" for label alertname, must be one of: =, !=, =~, !~`)
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		_, err := toSilenceMatchers([]LabelMatcher{{Type: "=", Value: "HighCPU"}})
+		require.EqualError(t, err, "matcher name is required")
+	})
+}
+
+func TestParseSilenceDuration(t *testing.T) {
+	for input, expected := range map[string]time.Duration{
+		"30m":   30 * time.Minute,
+		"2h":    2 * time.Hour,
+		"1d":    24 * time.Hour,
+		"1h30m": 90 * time.Minute,
+	} {
+		d, err := parseSilenceDuration(input)
+		require.NoError(t, err, input)
+		require.Equal(t, expected, d, input)
+	}
+
+	for _, input := range []string{"", "2 hours", "-1h", "0s", "h"} {
+		_, err := parseSilenceDuration(input)
+		require.Error(t, err, input)
+		require.Contains(t, err.Error(), "invalid duration", input)
+	}
+}
+
+func TestCreateAlertSilence(t *testing.T) {
+	var body postableSilence
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, silencesEndpointPath, r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"silenceID": "silence-1"}`))
+	}))
+	defer server.Close()
+
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL})
+
+	t.Run("creates silence", func(t *testing.T) {
+		result, err := createAlertSilence(ctx, CreateAlertSilenceParams{
+			Matchers:  []LabelMatcher{{Name: "alertname", Type: "=", Value: "HighCPU"}},
+			Duration:  "2h",
+			Comment:   "Known issue, fix is rolling out",
+			CreatedBy: "oncall",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "silence-1", result.SilenceID)
+		require.Equal(t, 2*time.Hour, result.EndsAt.Sub(result.StartsAt))
+		require.WithinDuration(t, time.Now(), result.StartsAt, time.Minute)
+
+		require.Equal(t, []silenceMatcher{{Name: "alertname", Value: "HighCPU", IsEqual: true}}, body.Matchers)
+		require.Equal(t, "Known issue, fix is rolling out", body.Comment)
+		require.Equal(t, "oncall", body.CreatedBy)
+		require.True(t, body.StartsAt.Equal(result.StartsAt))
+		require.True(t, body.EndsAt.Equal(result.EndsAt))
+	})
+
+	t.Run("invalid duration", func(t *testing.T) {
+		_, err := createAlertSilence(ctx, CreateAlertSilenceParams{
+			Matchers:  []LabelMatcher{{Name: "alertname", Type: "=", Value: "HighCPU"}},
+			Duration:  "forever",
+			Comment:   "Known issue",
+			CreatedBy: "oncall",
+		})
+		require.ErrorContains(t, err, `create alert silence: invalid duration "forever"`)
+	})
+
+	t.Run("requires matchers", func(t *testing.T) {
+		_, err := createAlertSilence(ctx, CreateAlertSilenceParams{Duration: "2h", Comment: "Known issue", CreatedBy: "oncall"})
+		require.EqualError(t, err, "create alert silence: at least one matcher is required")
+	})
+}
+
+func TestListAlertSilences(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, silencesEndpointPath, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"id": "s-active", "status": {"state": "active"}, "matchers": [{"name": "alertname", "value": "HighCPU", "isRegex": false, "isEqual": true}],
+			 "startsAt": "2025-01-01T00:00:00Z", "endsAt": "2025-01-01T02:00:00Z", "createdBy": "oncall", "comment": "Known issue"},
+			{"id": "s-expired", "status": {"state": "expired"}, "matchers": [{"name": "instance", "value": "web-.*", "isRegex": true, "isEqual": false}],
+			 "startsAt": "2024-12-01T00:00:00Z", "endsAt": "2024-12-01T01:00:00Z", "createdBy": "oncall", "comment": "Maintenance"},
+			{"id": "s-pending", "status": {"state": "pending"}, "matchers": [{"name": "env", "value": "dev", "isRegex": false, "isEqual": false}],
+			 "startsAt": "2030-01-01T00:00:00Z", "endsAt": "2030-01-01T01:00:00Z", "createdBy": "oncall", "comment": "Upgrade"}
+		]`))
+	}))
+	defer server.Close()
+
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL})
+
+	t.Run("all silences", func(t *testing.T) {
+		result, err := listAlertSilences(ctx, ListAlertSilencesParams{})
+		require.NoError(t, err)
+		require.Len(t, result, 3)
+		require.Equal(t, []LabelMatcher{{Name: "instance", Value: "web-.*", Type: "!~"}}, result[1].Matchers)
+		require.Equal(t, []LabelMatcher{{Name: "env", Value: "dev", Type: "!="}}, result[2].Matchers)
+	})
+
+	t.Run("state filter", func(t *testing.T) {
+		result, err := listAlertSilences(ctx, ListAlertSilencesParams{State: "active"})
+		require.NoError(t, err)
+		require.Equal(t, []alertSilenceSummary{{
+			ID:        "s-active",
+			State:     "active",
+			Matchers:  []LabelMatcher{{Name: "alertname", Value: "HighCPU", Type: "="}},
+			StartsAt:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndsAt:    time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC),
+			CreatedBy: "oncall",
+			Comment:   "Known issue",
+		}}, result)
+	})
+
+	t.Run("invalid state", func(t *testing.T) {
+		_, err := listAlertSilences(ctx, ListAlertSilencesParams{State: "muted"})
+		require.EqualError(t, err, `list alert silences: invalid state: "muted", must be one of: active, pending, expired`)
+	})
+}
+
+func TestExpireSilence(t *testing.T) {
+	var method, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		if path != silenceEndpointPath+"/silence-1" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "silence not found"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL})
+
+	t.Run("expires the given silence", func(t *testing.T) {
+		result, err := expireSilence(ctx, ExpireSilenceParams{SilenceID: "silence-1"})
+		require.NoError(t, err)
+		require.Equal(t, "Silence silence-1 expired successfully", result)
+		require.Equal(t, http.MethodDelete, method)
+		require.Equal(t, "/api/alertmanager/grafana/api/v2/silence/silence-1", path)
+	})
+
+	t.Run("unknown silence", func(t *testing.T) {
+		_, err := expireSilence(ctx, ExpireSilenceParams{SilenceID: "missing"})
+		require.ErrorContains(t, err, "status code 404")
+	})
+
+	t.Run("requires id", func(t *testing.T) {
+		_, err := expireSilence(ctx, ExpireSilenceParams{})
+		require.EqualError(t, err, "expire silence: silenceId is required")
+	})
+}
+
+func TestListAlertmanagerAlertGroups(t *testing.T) {
+	var query map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, alertGroupsEndpointPath, r.URL.Path)
+		query = r.URL.Query()
+		silenced := `{"labels": {"alertname": "DiskFull", "instance": "db-1"}, "annotations": {"summary": "Disk is full"},
+			 "status": {"state": "suppressed", "silencedBy": ["s-1"], "inhibitedBy": []}, "startsAt": "2025-01-01T00:05:00Z", "fingerprint": "f2"}`
+		if r.URL.Query().Get("silenced") == "false" {
+			silenced = ""
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"labels": {"alertname": "HighCPU"}, "receiver": {"name": "oncall"}, "alerts": [
+				{"labels": {"alertname": "HighCPU", "instance": "web-1"}, "annotations": {"summary": "CPU is high"},
+				 "status": {"state": "active", "silencedBy": [], "inhibitedBy": []}, "startsAt": "2025-01-01T00:00:00Z",
+				 "fingerprint": "f1", "generatorURL": "http://grafana/alerting/grafana/rule-1/view"},
+				{"labels": {"alertname": "HighCPU", "instance": "web-2"},
+				 "status": {"state": "active", "silencedBy": [], "inhibitedBy": []}, "startsAt": "2025-01-01T00:01:00Z", "fingerprint": "f3"}
+			]},
+			{"labels": {"alertname": "DiskFull"}, "receiver": {"name": "storage-team"}, "alerts": [` + silenced + `]}
+		]`))
+	}))
+	defer server.Close()
+
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL})
+
+	t.Run("alerts are grouped", func(t *testing.T) {
+		result, err := listAlertmanagerAlertGroups(ctx, ListAlertmanagerAlertGroupsParams{})
+		require.NoError(t, err)
+		require.Empty(t, query)
+		require.Len(t, result, 2)
+
+		require.Equal(t, map[string]string{"alertname": "HighCPU"}, result[0].Labels)
+		require.Equal(t, "oncall", result[0].Receiver)
+		require.Len(t, result[0].Alerts, 2)
+		require.Equal(t, alertSummary{
+			Labels:       map[string]string{"alertname": "HighCPU", "instance": "web-1"},
+			Annotations:  map[string]string{"summary": "CPU is high"},
+			State:        "active",
+			SilencedBy:   []string{},
+			InhibitedBy:  []string{},
+			StartsAt:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			Fingerprint:  "f1",
+			GeneratorURL: "http://grafana/alerting/grafana/rule-1/view",
+		}, result[0].Alerts[0])
+
+		require.Equal(t, "storage-team", result[1].Receiver)
+		require.Equal(t, "suppressed", result[1].Alerts[0].State)
+		require.Equal(t, []string{"s-1"}, result[1].Alerts[0].SilencedBy)
+	})
+
+	t.Run("silenced alerts can be left out", func(t *testing.T) {
+		silenced := false
+		result, err := listAlertmanagerAlertGroups(ctx, ListAlertmanagerAlertGroupsParams{Silenced: &silenced})
+		require.NoError(t, err)
+		require.Equal(t, map[string][]string{"silenced": {"false"}}, query)
+		// The group left without alerts is dropped.
+		require.Len(t, result, 1)
+		require.Equal(t, "oncall", result[0].Receiver)
+	})
+
+	t.Run("requires unified alerting", func(t *testing.T) {
+		ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL, APIVersion: "8.5.0"})
+		_, err := listAlertmanagerAlertGroups(ctx, ListAlertmanagerAlertGroupsParams{})
+		require.ErrorIs(t, err, errUnifiedAlertingRequired)
+	})
+}
+
+func TestAlertingAPIForVersion(t *testing.T) {
+	for version, want := range map[string]alertingAPI{
+		"":       alertingAPIAuto,
+		"8.5.2":  alertingAPILegacy,
+		"v7":     alertingAPILegacy,
+		"9.0.0":  alertingAPIUnified,
+		"11.2":   alertingAPIUnified,
+		"latest": alertingAPIAuto,
+	} {
+		require.Equal(t, want, alertingAPIForVersion(version), "version %q", version)
+	}
+}
+
+func TestListAlertRulesLegacyFallback(t *testing.T) {
+	var unifiedCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case legacyAlertsEndpointPath:
+			_, _ = w.Write([]byte(`[
+				{"id": 1, "dashboardUid": "dash", "panelId": 2, "name": "High CPU", "state": "alerting", "newStateDate": "2022-01-01T10:00:00Z", "evalDate": "2022-01-01T10:05:00Z"},
+				{"id": 2, "dashboardUid": "dash", "panelId": 3, "name": "Disk full", "state": "ok", "newStateDate": "2022-01-01T09:00:00Z", "evalDate": "2022-01-01T10:05:00Z"},
+				{"id": 3, "dashboardUid": "other", "panelId": 1, "name": "Queue depth", "state": "no_data", "newStateDate": "2022-01-01T09:00:00Z", "evalDate": "0001-01-01T00:00:00Z"}
+			]`))
+		default:
+			// Unified alerting endpoints don't exist on legacy instances.
+			unifiedCalls++
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	newCtx := func(version string) context.Context {
+		cfg := mcpgrafana.GrafanaConfig{URL: server.URL, APIVersion: version}
+		ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+		return mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+	}
+
+	t.Run("falls back to legacy alerting when unified alerting is not found", func(t *testing.T) {
+		unifiedCalls = 0
+		result, err := listAlertRules(newCtx(""), ListAlertRulesParams{})
+		require.NoError(t, err)
+		require.Equal(t, 1, unifiedCalls)
+		require.Equal(t, 3, result.Total)
+		require.Equal(t, alertRuleSummary{
+			UID:            "1",
+			Title:          "High CPU",
+			State:          "firing",
+			Health:         "ok",
+			LastEvaluation: "2022-01-01T10:05:00Z",
+			Annotations:    map[string]string{"__dashboardUid__": "dash", "__panelId__": "2"},
+		}, result.Rules[0])
+		require.Equal(t, "inactive", result.Rules[1].State)
+		require.Equal(t, "nodata", result.Rules[2].Health)
+		require.Empty(t, result.Rules[2].LastEvaluation)
+	})
+
+	t.Run("state filter applies to legacy alerts", func(t *testing.T) {
+		result, err := listAlertRules(newCtx(""), ListAlertRulesParams{State: "inactive"})
+		require.NoError(t, err)
+		require.Len(t, result.Rules, 2)
+	})
+
+	t.Run("legacy version skips unified alerting", func(t *testing.T) {
+		unifiedCalls = 0
+		result, err := listAlertRules(newCtx("8.5.2"), ListAlertRulesParams{})
+		require.NoError(t, err)
+		require.Zero(t, unifiedCalls)
+		require.Equal(t, 3, result.Total)
+	})
+
+	t.Run("unified version does not fall back", func(t *testing.T) {
+		_, err := listAlertRules(newCtx("10.4.0"), ListAlertRulesParams{})
+		require.ErrorContains(t, err, "list alert rules (provisioning)")
+	})
+
+	t.Run("silences require unified alerting", func(t *testing.T) {
+		unifiedCalls = 0
+		_, err := listAlertSilences(newCtx("8.5.2"), ListAlertSilencesParams{})
+		require.ErrorIs(t, err, errUnifiedAlertingRequired)
+		require.Zero(t, unifiedCalls)
+	})
+}
+
+func TestGetAlertRule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/provisioning/alert-rules/rule-firing":
+			_, _ = w.Write([]byte(`{
+				"uid": "rule-firing", "title": "High CPU", "folderUID": "alerts", "ruleGroup": "group",
+				"condition": "B", "for": "5m", "noDataState": "NoData", "execErrState": "Error",
+				"labels": {"severity": "critical"}, "annotations": {"summary": "CPU is high"},
+				"data": [{"refId": "A", "datasourceUid": "prom", "model": {"expr": "cpu > 0.9"}}]
+			}`))
+		case "/api/v1/provisioning/alert-rules/rule-new":
+			_, _ = w.Write([]byte(`{"uid": "rule-new", "title": "New rule", "folderUID": "alerts", "ruleGroup": "group", "condition": "A"}`))
+		case rulesEndpointPath:
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"groups": [{"name": "group", "rules": [
+				{"uid": "other", "name": "High CPU", "state": "inactive", "health": "ok", "lastEvaluation": "2025-01-01T10:05:00Z"},
+				{"uid": "rule-firing", "name": "High CPU", "state": "firing", "health": "ok", "activeAt": "2025-01-01T10:00:00Z", "lastEvaluation": "2025-01-01T10:05:00Z"},
+				{"uid": "rule-new", "name": "New rule", "state": "inactive", "health": "unknown", "lastEvaluation": "0001-01-01T00:00:00Z"}
+			]}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	t.Run("firing rule", func(t *testing.T) {
+		result, err := getAlertRule(ctx, GetAlertRuleParams{UID: "rule-firing"})
+		require.NoError(t, err)
+		require.Equal(t, "High CPU", *result.Rule.Title)
+		require.Equal(t, "5m0s", result.Rule.For.String())
+		require.Equal(t, map[string]string{"severity": "critical"}, result.Rule.Labels)
+		require.Equal(t, map[string]string{"summary": "CPU is high"}, result.Rule.Annotations)
+		require.Len(t, result.Rule.Data, 1)
+		require.True(t, result.Evaluated)
+		require.Equal(t, "firing", result.State)
+		require.Equal(t, "ok", result.Health)
+		require.Equal(t, "2025-01-01T10:05:00Z", result.LastEvaluation)
+		require.Equal(t, "2025-01-01T10:00:00Z", result.ActiveAt)
+	})
+
+	t.Run("rule that has never evaluated", func(t *testing.T) {
+		result, err := getAlertRule(ctx, GetAlertRuleParams{UID: "rule-new"})
+		require.NoError(t, err)
+		require.Equal(t, "New rule", *result.Rule.Title)
+		require.False(t, result.Evaluated)
+		require.Empty(t, result.State)
+		require.Empty(t, result.LastEvaluation)
+		require.Empty(t, result.ActiveAt)
+	})
+
+	t.Run("missing rule", func(t *testing.T) {
+		_, err := getAlertRule(ctx, GetAlertRuleParams{UID: "missing"})
+		require.ErrorContains(t, err, "get alert rule missing (provisioning)")
+	})
+
+	t.Run("uid is required", func(t *testing.T) {
+		_, err := getAlertRule(ctx, GetAlertRuleParams{})
+		require.EqualError(t, err, "get alert rule: uid is required")
+	})
+}
+
+func TestEvaluateAlertRule(t *testing.T) {
+	// evalResponse is a response of the eval endpoint for a rule whose query
+	// A returns one series per instance, reduced by B and compared to a
+	// threshold by C.
+	evalResponse := func(cpuValue, threshold float64) string {
+		firing := 0
+		if cpuValue > threshold {
+			firing = 1
+		}
+		frame := func(refID string, instance string, value any) string {
+			return fmt.Sprintf(`{"schema": {"refId": %q, "fields": [{"name": %q, "type": "number", "typeInfo": {"frame": "float64", "nullable": true}, "labels": {"instance": %q}}]}, "data": {"values": [[%v]]}}`, refID, refID, instance, value)
+		}
+		return fmt.Sprintf(`{"results": {
+			"A": {"status": 200, "frames": [{"schema": {"refId": "A", "fields": [
+				{"name": "Time", "type": "time", "typeInfo": {"frame": "time.Time"}},
+				{"name": "Value", "type": "number", "typeInfo": {"frame": "float64", "nullable": true}, "labels": {"instance": "web-1"}}
+			]}, "data": {"values": [[1735725600000, 1735725660000], [0.1, %v]]}}]},
+			"B": {"status": 200, "frames": [%s]},
+			"C": {"status": 200, "frames": [%s]}
+		}}`, cpuValue, frame("B", "web-1", cpuValue), frame("C", "web-1", firing))
+	}
+
+	var cpuValue float64
+	var evalRequest map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/provisioning/alert-rules/high-cpu":
+			_, _ = w.Write([]byte(`{
+				"uid": "high-cpu", "title": "High CPU", "folderUID": "alerts", "ruleGroup": "group",
+				"condition": "C", "for": "5m",
+				"data": [
+					{"refId": "A", "datasourceUid": "prom", "relativeTimeRange": {"from": 600, "to": 0}, "model": {"expr": "cpu"}},
+					{"refId": "B", "datasourceUid": "__expr__", "model": {"type": "reduce", "expression": "A", "reducer": "last"}},
+					{"refId": "C", "datasourceUid": "__expr__", "model": {"type": "threshold", "expression": "B"}}
+				]
+			}`))
+		case evalQueriesEndpointPath:
+			require.Equal(t, http.MethodPost, r.Method)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&evalRequest))
+			_, _ = w.Write([]byte(evalResponse(cpuValue, 0.9)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	t.Run("firing condition", func(t *testing.T) {
+		cpuValue = 0.95
+		result, err := evaluateAlertRule(ctx, EvaluateAlertRuleParams{UID: "high-cpu", Time: "2025-01-01T10:01:00Z"})
+		require.NoError(t, err)
+
+		require.Equal(t, "C", evalRequest["condition"])
+		require.Equal(t, "2025-01-01T10:01:00Z", evalRequest["now"])
+		require.Len(t, evalRequest["data"], 3)
+
+		require.Equal(t, "High CPU", result.Title)
+		require.Equal(t, "2025-01-01T10:01:00Z", result.EvaluatedAt)
+		require.Equal(t, 1, result.Firing)
+		require.Len(t, result.Instances, 1)
+		instance := result.Instances[0]
+		require.Equal(t, evaluatedStateFiring, instance.State)
+		require.Equal(t, map[string]string{"instance": "web-1"}, instance.Labels)
+		require.Len(t, instance.Values, 3)
+		require.Equal(t, 0.95, *instance.Values["A"])
+		require.Equal(t, 0.95, *instance.Values["B"])
+		require.Equal(t, 1.0, *instance.Values["C"])
+		require.Contains(t, result.Note, "pending period of 5m0s")
+	})
+
+	t.Run("non-firing condition", func(t *testing.T) {
+		cpuValue = 0.5
+		result, err := evaluateAlertRule(ctx, EvaluateAlertRuleParams{UID: "high-cpu"})
+		require.NoError(t, err)
+
+		now, err := time.Parse(time.RFC3339, evalRequest["now"].(string))
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now(), now, time.Minute)
+
+		require.Equal(t, 0, result.Firing)
+		require.Len(t, result.Instances, 1)
+		require.Equal(t, evaluatedStateNormal, result.Instances[0].State)
+		require.Equal(t, 0.5, *result.Instances[0].Values["B"])
+		require.Equal(t, 0.0, *result.Instances[0].Values["C"])
+		require.Empty(t, result.Note)
+	})
+
+	t.Run("missing rule", func(t *testing.T) {
+		_, err := evaluateAlertRule(ctx, EvaluateAlertRuleParams{UID: "missing"})
+		require.ErrorContains(t, err, "evaluate alert rule missing (provisioning)")
+	})
+
+	t.Run("invalid time", func(t *testing.T) {
+		_, err := evaluateAlertRule(ctx, EvaluateAlertRuleParams{UID: "high-cpu", Time: "yesterday"})
+		require.ErrorContains(t, err, `invalid time "yesterday"`)
+	})
+}
+
+func TestFindRuntimeAlertRuleByTitle(t *testing.T) {
+	var runtimeResponse rulesResponse
+	runtimeResponse.Data.RuleGroups = []ruleGroup{{Rules: []alertingRule{
+		{Name: "Disk full", State: "inactive"},
+		{Name: "High CPU", State: "pending"},
+	}}}
+	title := "High CPU"
+
+	runtime, found := findRuntimeAlertRule(&runtimeResponse, &models.ProvisionedAlertRule{UID: "rule", Title: &title})
+	require.True(t, found)
+	require.Equal(t, "pending", runtime.State)
+}
+
+func TestGetNotificationPolicyTree(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/provisioning/policies", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"receiver": "default-email",
+			"group_by": ["grafana_folder", "alertname"],
+			"routes": [
+				{
+					"receiver": "pagerduty",
+					"object_matchers": [["severity", "=", "critical"]],
+					"continue": true,
+					"routes": [
+						{"object_matchers": [["team", "=~", "db|storage"]], "mute_time_intervals": ["weekends"]}
+					]
+				},
+				{"receiver": "slack", "object_matchers": [["team", "!=", "frontend"]]}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	result, err := getNotificationPolicyTree(ctx, GetNotificationPolicyTreeParams{})
+	require.NoError(t, err)
+
+	require.Equal(t, []notificationPolicy{
+		{Depth: 0, ContactPoint: "default-email", GroupBy: []string{"grafana_folder", "alertname"}},
+		{Depth: 1, Matchers: []string{`severity="critical"`}, ContactPoint: "pagerduty", Continue: true},
+		{Depth: 2, Matchers: []string{`team=~"db|storage"`}, ContactPoint: "pagerduty", InheritsContactPoint: true, MuteTimeIntervals: []string{"weekends"}},
+		{Depth: 1, Matchers: []string{`team!="frontend"`}, ContactPoint: "slack"},
+	}, result.Policies)
+
+	require.Equal(t, `[depth 0] default policy -> default-email [group by: grafana_folder, alertname]
+  [depth 1] {severity="critical"} -> pagerduty [continue matching]
+    [depth 2] {team=~"db|storage"} -> pagerduty (inherited) [muted during: weekends]
+  [depth 1] {team!="frontend"} -> slack
+`, result.Tree)
+}
+
+func TestNotificationPolicyMatchers(t *testing.T) {
+	isRegex, notRegex := true, false
+	name, value := "env", "prod.*"
+	route := &models.Route{
+		Matchers: models.Matchers{
+			{Name: &name, Value: &value, IsRegex: &isRegex, IsEqual: true},
+			{Name: &name, Value: &value, IsRegex: &notRegex},
+		},
+		Match:   map[string]string{"b": "2", "a": "1"},
+		MatchRe: models.MatchRegexps{"c": "x|y"},
+	}
+	matchers, err := notificationPolicyMatchers(route)
+	require.NoError(t, err)
+	require.Equal(t, []string{`env=~"prod.*"`, `env!="prod.*"`, `a="1"`, `b="2"`, `c=~"x|y"`}, matcherStrings(matchers))
+
+	_, err = notificationPolicyMatchers(&models.Route{ObjectMatchers: models.ObjectMatchers{{"team", "=~", "db("}}})
+	require.ErrorContains(t, err, `invalid matcher for label "team"`)
+
+	_, err = notificationPolicyMatchers(&models.Route{ObjectMatchers: models.ObjectMatchers{{"team", "==", "db"}}})
+	require.EqualError(t, err, `invalid matcher operator "==" for label "team"`)
+}
+
+func TestEvaluateNotificationRoute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/provisioning/policies", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"receiver": "default-email",
+			"routes": [
+				{
+					"receiver": "pagerduty",
+					"object_matchers": [["severity", "=", "critical"]],
+					"continue": true,
+					"routes": [
+						{"receiver": "db-oncall", "object_matchers": [["team", "=~", "db|storage"]]},
+						{"object_matchers": [["team", "=~", "db.*"]]}
+					]
+				},
+				{"receiver": "slack", "object_matchers": [["team", "!=", "frontend"], ["env", "!~", "dev|test"]]},
+				{"receiver": "never", "object_matchers": [["team", "=", "db"]]}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	root := notificationRouteStep{Depth: 0, ContactPoint: "default-email"}
+	critical := notificationRouteStep{Depth: 1, Matchers: []string{`severity="critical"`}, ContactPoint: "pagerduty", Continue: true}
+
+	t.Run("labels matching a nested route", func(t *testing.T) {
+		result, err := evaluateNotificationRoute(ctx, EvaluateNotificationRouteParams{
+			Labels: map[string]string{"severity": "critical", "team": "db", "env": "prod"},
+		})
+		require.NoError(t, err)
+		// The critical policy continues, so the alert also goes to slack, but
+		// only to the first matching child of the critical policy.
+		require.Equal(t, []string{"db-oncall", "slack"}, result.ContactPoints)
+		require.Equal(t, []notificationRouteMatch{
+			{
+				ContactPoint: "db-oncall",
+				Path: []notificationRouteStep{
+					root,
+					critical,
+					{Depth: 2, Matchers: []string{`team=~"db|storage"`}, ContactPoint: "db-oncall"},
+				},
+			},
+			{
+				ContactPoint: "slack",
+				Path: []notificationRouteStep{
+					root,
+					{Depth: 1, Matchers: []string{`team!="frontend"`, `env!~"dev|test"`}, ContactPoint: "slack"},
+				},
+			},
+		}, result.Matches)
+	})
+
+	t.Run("labels matching a policy but none of its children", func(t *testing.T) {
+		result, err := evaluateNotificationRoute(ctx, EvaluateNotificationRouteParams{
+			Labels: map[string]string{"severity": "critical", "team": "frontend"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"pagerduty"}, result.ContactPoints)
+		require.Equal(t, []notificationRouteStep{root, critical}, result.Matches[0].Path)
+	})
+
+	t.Run("labels only matching the root", func(t *testing.T) {
+		result, err := evaluateNotificationRoute(ctx, EvaluateNotificationRouteParams{
+			Labels: map[string]string{"severity": "warning", "team": "frontend"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"default-email"}, result.ContactPoints)
+		require.Equal(t, []notificationRouteMatch{{ContactPoint: "default-email", Path: []notificationRouteStep{root}}}, result.Matches)
+	})
+
+	t.Run("regex matchers are anchored", func(t *testing.T) {
+		result, err := evaluateNotificationRoute(ctx, EvaluateNotificationRouteParams{
+			Labels: map[string]string{"severity": "critical", "team": "dbx", "env": "dev"},
+		})
+		require.NoError(t, err)
+		// "dbx" doesn't match db|storage, so falls through to the db.* child,
+		// which inherits the pagerduty contact point.
+		require.Equal(t, []string{"pagerduty"}, result.ContactPoints)
+		require.Len(t, result.Matches[0].Path, 3)
+	})
+
+	t.Run("labels are required", func(t *testing.T) {
+		_, err := evaluateNotificationRoute(ctx, EvaluateNotificationRouteParams{})
+		require.EqualError(t, err, "evaluate notification route: labels are required")
+	})
+}
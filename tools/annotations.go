@@ -2,9 +2,12 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 
+	"github.com/go-openapi/runtime"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
@@ -60,7 +63,7 @@ func getAnnotations(ctx context.Context, args GetAnnotationsInput) (*annotations
 
 var GetAnnotationsTool = mcpgrafana.MustTool(
 	"get_annotations",
-	"Fetch Grafana annotations using filters such as dashboard UID, time range and tags.",
+	"List Grafana annotations using filters such as dashboard UID, time range and tags.",
 	getAnnotations,
 	mcp.WithTitleAnnotation("Get Annotations"),
 	mcp.WithIdempotentHintAnnotation(true),
@@ -72,15 +75,27 @@ type CreateAnnotationInput struct {
 	DashboardID  int64          `json:"dashboardId,omitempty"  jsonschema:"description=Deprecated. Use dashboardUID"`
 	DashboardUID string         `json:"dashboardUID,omitempty" jsonschema:"description=Preferred dashboard UID"`
 	PanelID      int64          `json:"panelId,omitempty"      jsonschema:"description=Panel ID"`
-	Time         int64          `json:"time,omitempty"         jsonschema:"description=Start time epoch ms"`
-	TimeEnd      int64          `json:"timeEnd,omitempty"      jsonschema:"description=End time epoch ms"`
+	Time         int64          `json:"time,omitempty"         jsonschema:"description=Start time epoch ms. Defaults to now"`
+	TimeEnd      int64          `json:"timeEnd,omitempty"      jsonschema:"description=End time epoch ms. Set to create a region annotation spanning time to timeEnd"`
 	Tags         []string       `json:"tags,omitempty"         jsonschema:"description=Optional list of tags"`
-	Text         string         `json:"text"                   jsonschema:"description=Annotation text required"`
+	Text         string         `json:"text"                   jsonschema:"required,description=Annotation text"`
 	Data         map[string]any `json:"data,omitempty"         jsonschema:"description=Optional JSON payload"`
 }
 
 // createAnnotation sends a POST request to create a Grafana annotation.
 func createAnnotation(ctx context.Context, args CreateAnnotationInput) (*annotations.PostAnnotationOK, error) {
+	if args.Text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+	if args.TimeEnd != 0 {
+		if args.Time == 0 {
+			return nil, fmt.Errorf("time is required when timeEnd is set")
+		}
+		if args.TimeEnd < args.Time {
+			return nil, fmt.Errorf("timeEnd (%d) must not be before time (%d)", args.TimeEnd, args.Time)
+		}
+	}
+
 	c := mcpgrafana.GrafanaClientFromContext(ctx)
 
 	req := models.PostAnnotationsCmd{
@@ -94,7 +109,7 @@ func createAnnotation(ctx context.Context, args CreateAnnotationInput) (*annotat
 		Data:         args.Data,
 	}
 
-	resp, err := c.Annotations.PostAnnotation(&req)
+	resp, err := c.Annotations.PostAnnotationWithParams(annotations.NewPostAnnotationParamsWithContext(ctx).WithBody(&req))
 	if err != nil {
 		return nil, fmt.Errorf("create annotation: %w", err)
 	}
@@ -102,9 +117,9 @@ func createAnnotation(ctx context.Context, args CreateAnnotationInput) (*annotat
 	return resp, nil
 }
 
-var CreateAnnotationTool = mcpgrafana.MustTool(
+var CreateAnnotationTool = mcpgrafana.MustMutatingTool(
 	"create_annotation",
-	"Create a new annotation on a dashboard or panel.",
+	"Create a new annotation, optionally on a dashboard or panel. Set only time for a point annotation (e.g. marking a deploy), or both time and timeEnd for a region annotation.",
 	createAnnotation,
 	mcp.WithTitleAnnotation("Create Annotation"),
 	mcp.WithIdempotentHintAnnotation(false),
@@ -129,7 +144,7 @@ func createAnnotationGraphiteFormat(ctx context.Context, args CreateGraphiteAnno
 		Data: args.Data,
 	}
 
-	resp, err := c.Annotations.PostGraphiteAnnotation(req)
+	resp, err := c.Annotations.PostGraphiteAnnotationWithParams(annotations.NewPostGraphiteAnnotationParamsWithContext(ctx).WithBody(req))
 	if err != nil {
 		return nil, fmt.Errorf("create graphite annotation: %w", err)
 	}
@@ -137,7 +152,7 @@ func createAnnotationGraphiteFormat(ctx context.Context, args CreateGraphiteAnno
 	return resp, nil
 }
 
-var CreateGraphiteAnnotationTool = mcpgrafana.MustTool(
+var CreateGraphiteAnnotationTool = mcpgrafana.MustMutatingTool(
 	"create_graphite_annotation",
 	"Create an annotation using Graphite annotation format.",
 	createAnnotationGraphiteFormat,
@@ -167,7 +182,7 @@ func updateAnnotation(ctx context.Context, args UpdateAnnotationInput) (*annotat
 		Data:    args.Data,
 	}
 
-	resp, err := c.Annotations.UpdateAnnotation(annotationID, req)
+	resp, err := c.Annotations.UpdateAnnotationWithParams(annotations.NewUpdateAnnotationParamsWithContext(ctx).WithAnnotationID(annotationID).WithBody(req))
 	if err != nil {
 		return nil, fmt.Errorf("update annotation: %w", err)
 	}
@@ -175,7 +190,7 @@ func updateAnnotation(ctx context.Context, args UpdateAnnotationInput) (*annotat
 	return resp, nil
 }
 
-var UpdateAnnotationTool = mcpgrafana.MustTool(
+var UpdateAnnotationTool = mcpgrafana.MustMutatingTool(
 	"update_annotation",
 	"Updates all properties of an annotation that matches the specified ID. Sends a full update (PUT). For partial updates, use patch_annotation instead.",
 	updateAnnotation,
@@ -217,14 +232,14 @@ func patchAnnotation(ctx context.Context, args PatchAnnotationInput) (*annotatio
 		body.Data = args.Data
 	}
 
-	resp, err := c.Annotations.PatchAnnotation(id, body)
+	resp, err := c.Annotations.PatchAnnotationWithParams(annotations.NewPatchAnnotationParamsWithContext(ctx).WithAnnotationID(id).WithBody(body))
 	if err != nil {
 		return nil, fmt.Errorf("patch annotation: %w", err)
 	}
 	return resp, nil
 }
 
-var PatchAnnotationTool = mcpgrafana.MustTool(
+var PatchAnnotationTool = mcpgrafana.MustMutatingTool(
 	"patch_annotation",
 	"Updates only the provided properties of an annotation. Fields omitted are not modified. Use update_annotation for full replacement.",
 	patchAnnotation,
@@ -233,6 +248,38 @@ var PatchAnnotationTool = mcpgrafana.MustTool(
 	mcp.WithIdempotentHintAnnotation(false),
 )
 
+// DeleteAnnotationInput identifies the annotation to delete.
+type DeleteAnnotationInput struct {
+	ID int64 `json:"id" jsonschema:"required,description=ID of the annotation to delete"`
+}
+
+// deleteAnnotation deletes an annotation using its ID.
+func deleteAnnotation(ctx context.Context, args DeleteAnnotationInput) (*models.SuccessResponseBody, error) {
+	if args.ID <= 0 {
+		return nil, fmt.Errorf("id must be a positive annotation ID")
+	}
+
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	resp, err := c.Annotations.DeleteAnnotationByIDWithParams(annotations.NewDeleteAnnotationByIDParamsWithContext(ctx).WithAnnotationID(strconv.FormatInt(args.ID, 10)))
+	if err != nil {
+		var apiErr *runtime.APIError
+		if errors.As(err, &apiErr) && apiErr.IsCode(http.StatusNotFound) {
+			return nil, fmt.Errorf("annotation %d not found", args.ID)
+		}
+		return nil, fmt.Errorf("delete annotation %d: %w", args.ID, err)
+	}
+	return resp.Payload, nil
+}
+
+var DeleteAnnotationTool = mcpgrafana.MustMutatingTool(
+	"delete_annotation",
+	"Deletes the annotation with the specified ID. Use get_annotations to find the ID of an annotation, for example to clean up annotations created during an experiment.",
+	deleteAnnotation,
+	mcp.WithTitleAnnotation("Delete Annotation"),
+	mcp.WithDestructiveHintAnnotation(true),
+	mcp.WithIdempotentHintAnnotation(true),
+)
+
 // GetAnnotationTagsInput defines filters for retrieving annotation tags.
 type GetAnnotationTagsInput struct {
 	Tag   *string `json:"tag,omitempty"   jsonschema:"description=Optional filter by tag name"`
@@ -272,6 +319,7 @@ func AddAnnotationTools(mcp *server.MCPServer, enableWriteTools bool) {
 		CreateGraphiteAnnotationTool.Register(mcp)
 		UpdateAnnotationTool.Register(mcp)
 		PatchAnnotationTool.Register(mcp)
+		DeleteAnnotationTool.Register(mcp)
 	}
 	GetAnnotationTagsTool.Register(mcp)
 }
@@ -273,3 +273,91 @@ func TestGetAnnotationTags_UsesCorrectQueryParams(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestCreateAnnotation_PointAndRegion(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/annotations", r.URL.Path)
+		body = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 1, "message": "Annotation added"}`))
+	}))
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	t.Run("point annotation", func(t *testing.T) {
+		_, err := createAnnotation(ctx, CreateAnnotationInput{
+			DashboardUID: "dash",
+			Time:         1700000000000,
+			Text:         "Deployed v1.2.3",
+			Tags:         []string{"deploy"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "dash", body["dashboardUID"])
+		assert.Equal(t, float64(1700000000000), body["time"])
+		assert.NotContains(t, body, "timeEnd")
+		assert.Equal(t, []any{"deploy"}, body["tags"])
+	})
+
+	t.Run("region annotation", func(t *testing.T) {
+		_, err := createAnnotation(ctx, CreateAnnotationInput{
+			Time:    1700000000000,
+			TimeEnd: 1700000600000,
+			Text:    "Outage",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, float64(1700000000000), body["time"])
+		assert.Equal(t, float64(1700000600000), body["timeEnd"])
+		assert.Equal(t, "Outage", body["text"])
+	})
+
+	t.Run("invalid region", func(t *testing.T) {
+		body = nil
+		_, err := createAnnotation(ctx, CreateAnnotationInput{Time: 2000, TimeEnd: 1000, Text: "backwards"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must not be before time")
+
+		_, err = createAnnotation(ctx, CreateAnnotationInput{TimeEnd: 1000, Text: "no start"})
+		require.Error(t, err)
+		assert.Nil(t, body, "invalid annotations should not be sent")
+	})
+
+	t.Run("text is required", func(t *testing.T) {
+		_, err := createAnnotation(ctx, CreateAnnotationInput{Time: 1000})
+		require.Error(t, err)
+	})
+}
+
+func TestDeleteAnnotation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/annotations/42" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Annotation not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"message": "Annotation deleted"}`))
+	}))
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	t.Run("success", func(t *testing.T) {
+		resp, err := deleteAnnotation(ctx, DeleteAnnotationInput{ID: 42})
+		require.NoError(t, err)
+		assert.Equal(t, "Annotation deleted", resp.Message)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := deleteAnnotation(ctx, DeleteAnnotationInput{ID: 7})
+		require.Error(t, err)
+		assert.Equal(t, "annotation 7 not found", err.Error())
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		_, err := deleteAnnotation(ctx, DeleteAnnotationInput{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "positive annotation ID")
+	})
+}
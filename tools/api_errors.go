@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+// maxErrorBodyBytes caps how much of an error response body is included in
+// returned errors, so that huge (often HTML) error pages don't flood the
+// caller's context.
+const maxErrorBodyBytes = 2048
+
+// grafanaErrorResponse is the error envelope returned by most Grafana APIs.
+type grafanaErrorResponse struct {
+	Message   string `json:"message"`
+	MessageID string `json:"messageId"`
+	TraceID   string `json:"traceID"`
+}
+
+// readErrorBody reads at most maxErrorBodyBytes (plus one byte, so that
+// truncation can be detected) from an error response body.
+func readErrorBody(r io.Reader) []byte {
+	body, _ := io.ReadAll(io.LimitReader(r, maxErrorBodyBytes+1))
+	return body
+}
+
+// summarizeErrorBody turns an error response body into a string suitable for
+// inclusion in an error message. Grafana's JSON error envelope is unpacked
+// into its message, messageId and traceID; any other body is included as-is,
+// truncated to maxErrorBodyBytes.
+func summarizeErrorBody(body []byte) string {
+	var errResp grafanaErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Message != "" {
+		var details []string
+		if errResp.MessageID != "" {
+			details = append(details, "messageId: "+errResp.MessageID)
+		}
+		if errResp.TraceID != "" {
+			details = append(details, "traceID: "+errResp.TraceID)
+		}
+		if len(details) == 0 {
+			return errResp.Message
+		}
+		return fmt.Sprintf("%s (%s)", errResp.Message, strings.Join(details, ", "))
+	}
+
+	s := strings.TrimSpace(string(body))
+	if len(body) > maxErrorBodyBytes {
+		return strings.ToValidUTF8(strings.TrimSpace(string(body[:maxErrorBodyBytes])), "") + "... (truncated)"
+	}
+	return s
+}
+
+// datasourceAccessDeniedError is returned when Grafana refuses a datasource
+// proxy request with 403 Forbidden. On Grafana Enterprise this usually means
+// the authenticated user lacks permission to query the datasource.
+type datasourceAccessDeniedError struct {
+	uid    string
+	user   string
+	detail string
+}
+
+func (e *datasourceAccessDeniedError) Error() string {
+	who := "the authenticated user"
+	if e.user != "" {
+		who = fmt.Sprintf("the authenticated user (forwarded user %q)", e.user)
+	}
+	msg := fmt.Sprintf("access denied: %s lacks access to datasource %q", who, e.uid)
+	if e.detail != "" {
+		msg += ": " + e.detail
+	}
+	return msg
+}
+
+// Code returns 403 Forbidden, so that the error is classified as an
+// authorization error by mcpgrafana.ErrorKindOf.
+func (e *datasourceAccessDeniedError) Code() int {
+	return http.StatusForbidden
+}
+
+// datasourceAccessRoundTripper turns 403 responses from Grafana's datasource
+// proxy into a datasourceAccessDeniedError, so that permission problems are
+// reported clearly whichever client library made the request.
+type datasourceAccessRoundTripper struct {
+	underlying http.RoundTripper
+	uid        string
+	user       string
+}
+
+func newDatasourceAccessRoundTripper(rt http.RoundTripper, uid string, cfg mcpgrafana.GrafanaConfig) *datasourceAccessRoundTripper {
+	return &datasourceAccessRoundTripper{
+		underlying: rt,
+		uid:        uid,
+		user:       mcpgrafana.ForwardedUser(cfg),
+	}
+}
+
+func (rt *datasourceAccessRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.underlying.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		return resp, nil
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+	return nil, &datasourceAccessDeniedError{
+		uid:    rt.uid,
+		user:   rt.user,
+		detail: summarizeErrorBody(readErrorBody(resp.Body)),
+	}
+}
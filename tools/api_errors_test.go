@@ -0,0 +1,40 @@
+//go:build unit
+
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeErrorBody(t *testing.T) {
+	t.Run("grafana error envelope", func(t *testing.T) {
+		body := []byte(`{"message":"Dashboard not found","messageId":"dashboards.notFound","traceID":"0af7651916cd43dd8448eb211c80319c"}`)
+		assert.Equal(t, "Dashboard not found (messageId: dashboards.notFound, traceID: 0af7651916cd43dd8448eb211c80319c)", summarizeErrorBody(body))
+	})
+
+	t.Run("message only", func(t *testing.T) {
+		assert.Equal(t, "bad request", summarizeErrorBody([]byte(`{"message":"bad request"}`)))
+	})
+
+	t.Run("JSON without a message is returned as-is", func(t *testing.T) {
+		assert.Equal(t, `{"error":"boom"}`, summarizeErrorBody([]byte(`{"error":"boom"}`)))
+	})
+
+	t.Run("plain text body", func(t *testing.T) {
+		assert.Equal(t, "internal server error", summarizeErrorBody([]byte("internal server error\n")))
+	})
+
+	t.Run("large body is truncated", func(t *testing.T) {
+		body := []byte(strings.Repeat("x", maxErrorBodyBytes+1))
+		summary := summarizeErrorBody(body)
+		assert.Equal(t, strings.Repeat("x", maxErrorBodyBytes)+"... (truncated)", summary)
+	})
+
+	t.Run("readErrorBody stops just past the cap", func(t *testing.T) {
+		body := readErrorBody(strings.NewReader(strings.Repeat("x", 10*maxErrorBodyBytes)))
+		assert.Len(t, body, maxErrorBodyBytes+1)
+	})
+}
@@ -8,7 +8,6 @@ import (
 	"io"
 	"net/http"
 	"strings"
-	"time"
 
 	mcpgrafana "github.com/grafana/mcp-grafana"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -40,13 +39,13 @@ func newAssertsClient(ctx context.Context) (*Client, error) {
 }
 
 type GetAssertionsParams struct {
-	StartTime  time.Time `json:"startTime" jsonschema:"required,description=The start time in RFC3339 format"`
-	EndTime    time.Time `json:"endTime" jsonschema:"required,description=The end time in RFC3339 format"`
-	EntityType string    `json:"entityType" jsonschema:"description=The type of the entity to list (e.g. Service\\, Node\\, Pod\\, etc.)"`
-	EntityName string    `json:"entityName" jsonschema:"description=The name of the entity to list"`
-	Env        string    `json:"env,omitempty" jsonschema:"description=The env of the entity to list"`
-	Site       string    `json:"site,omitempty" jsonschema:"description=The site of the entity to list"`
-	Namespace  string    `json:"namespace,omitempty" jsonschema:"description=The namespace of the entity to list"`
+	StartTime  string `json:"startTime" jsonschema:"required,description=The start time as RFC3339\\, Unix seconds or relative to now (e.g. 'now-1h')"`
+	EndTime    string `json:"endTime" jsonschema:"required,description=The end time as RFC3339\\, Unix seconds or relative to now (e.g. 'now')"`
+	EntityType string `json:"entityType" jsonschema:"description=The type of the entity to list (e.g. Service\\, Node\\, Pod\\, etc.)"`
+	EntityName string `json:"entityName" jsonschema:"description=The name of the entity to list"`
+	Env        string `json:"env,omitempty" jsonschema:"description=The env of the entity to list"`
+	Site       string `json:"site,omitempty" jsonschema:"description=The site of the entity to list"`
+	Namespace  string `json:"namespace,omitempty" jsonschema:"description=The namespace of the entity to list"`
 }
 
 type scope struct {
@@ -95,13 +94,22 @@ func (c *Client) fetchAssertsData(ctx context.Context, urlPath string, method st
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return "", mcpgrafana.NewHTTPError(resp.StatusCode, fmt.Sprintf("request failed with status %d: %s", resp.StatusCode, summarizeErrorBody(body)))
 	}
 
 	return string(body), nil
 }
 
 func getAssertions(ctx context.Context, args GetAssertionsParams) (string, error) {
+	startTime, err := parseTime(args.StartTime)
+	if err != nil {
+		return "", fmt.Errorf("parsing start time: %w", err)
+	}
+	endTime, err := parseTime(args.EndTime)
+	if err != nil {
+		return "", fmt.Errorf("parsing end time: %w", err)
+	}
+
 	client, err := newAssertsClient(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to create Asserts client: %w", err)
@@ -109,8 +117,8 @@ func getAssertions(ctx context.Context, args GetAssertionsParams) (string, error
 
 	// Create request body
 	reqBody := requestBody{
-		StartTime: args.StartTime.UnixMilli(),
-		EndTime:   args.EndTime.UnixMilli(),
+		StartTime: startTime.UnixMilli(),
+		EndTime:   endTime.UnixMilli(),
 		EntityKeys: []entity{
 			{
 				Name:  args.EntityName,
@@ -26,8 +26,8 @@ func TestAssertsCloudIntegration(t *testing.T) {
 
 		// Test parameters for a known service in the environment
 		params := GetAssertionsParams{
-			StartTime:  startTime,
-			EndTime:    endTime,
+			StartTime:  startTime.Format(time.RFC3339),
+			EndTime:    endTime.Format(time.RFC3339),
 			EntityType: "Service", // Adjust these values based on your actual environment
 			EntityName: "model-builder",
 			Env:        "dev-us-central-0",
@@ -65,8 +65,8 @@ func TestAssertTools(t *testing.T) {
 		defer server.Close()
 
 		result, err := getAssertions(ctx, GetAssertionsParams{
-			StartTime:  startTime,
-			EndTime:    endTime,
+			StartTime:  startTime.Format(time.RFC3339),
+			EndTime:    endTime.Format(time.RFC3339),
 			EntityType: "Service",
 			EntityName: "mongodb",
 			Env:        "asserts-demo",
@@ -114,8 +114,8 @@ func TestAssertTools(t *testing.T) {
 		defer server.Close()
 
 		result, err := getAssertions(ctx, GetAssertionsParams{
-			StartTime:  startTime,
-			EndTime:    endTime,
+			StartTime:  startTime.Format(time.RFC3339),
+			EndTime:    endTime.Format(time.RFC3339),
 			EntityType: "Service",
 			EntityName: "mongodb",
 			Env:        "asserts-demo",
@@ -0,0 +1,96 @@
+//go:build unit
+// +build unit
+
+package tools
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+// TestToolCallCancellation checks that cancelling the context of a tool call
+// aborts the request it is making to Grafana, rather than leaving it running
+// after the client has gone away.
+func TestToolCallCancellation(t *testing.T) {
+	for _, tc := range []struct {
+		tool      mcpgrafana.Tool
+		arguments map[string]any
+		path      string
+	}{
+		{
+			tool: ListDatasources,
+			path: "/api/datasources",
+		},
+		{
+			tool:      GetDashboardByUID,
+			arguments: map[string]any{"uid": "slow"},
+			path:      "/api/dashboards/uid/slow",
+		},
+		{
+			tool:      QueryLokiLogs,
+			arguments: map[string]any{"datasourceUid": "loki-uid", "logql": `{app="slow"}`},
+			path:      "/api/datasources/proxy/uid/loki-uid/loki/api/v1/query_range",
+		},
+	} {
+		t.Run(tc.tool.Tool.Name, func(t *testing.T) {
+			arrived := make(chan struct{})
+			aborted := make(chan struct{})
+			server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.path, r.URL.Path)
+				close(arrived)
+				select {
+				case <-r.Context().Done():
+					close(aborted)
+				case <-time.After(10 * time.Second):
+				}
+			})
+			defer server.Close()
+
+			ctx, cancel := context.WithCancel(mockDatasourceCtx(server, nil))
+			defer cancel()
+
+			request := mcp.CallToolRequest{}
+			request.Params.Name = tc.tool.Tool.Name
+			request.Params.Arguments = tc.arguments
+
+			done := make(chan *mcp.CallToolResult, 1)
+			go func() {
+				result, err := tc.tool.Handler(ctx, request)
+				assert.NoError(t, err)
+				done <- result
+			}()
+
+			select {
+			case <-arrived:
+			case <-time.After(5 * time.Second):
+				t.Fatal("request never reached the server")
+			}
+			start := time.Now()
+			cancel()
+
+			select {
+			case result := <-done:
+				require.NotNil(t, result)
+				assert.True(t, result.IsError)
+				require.Len(t, result.Content, 1)
+				assert.Contains(t, result.Content[0].(mcp.TextContent).Text, context.Canceled.Error())
+			case <-time.After(5 * time.Second):
+				t.Fatal("tool call didn't return after its context was cancelled")
+			}
+			select {
+			case <-aborted:
+			case <-time.After(5 * time.Second):
+				t.Fatal("outbound request wasn't aborted")
+			}
+			assert.Less(t, time.Since(start), time.Second)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+// ToolCategory is a named group of related tools which are enabled or
+// disabled together.
+type ToolCategory string
+
+const (
+	CategorySearch      ToolCategory = "search"
+	CategoryDatasource  ToolCategory = "datasource"
+	CategoryIncident    ToolCategory = "incident"
+	CategoryPrometheus  ToolCategory = "prometheus"
+	CategoryLoki        ToolCategory = "loki"
+	CategoryAlerting    ToolCategory = "alerting"
+	CategoryDashboard   ToolCategory = "dashboard"
+	CategoryFolder      ToolCategory = "folder"
+	CategoryOnCall      ToolCategory = "oncall"
+	CategoryAsserts     ToolCategory = "asserts"
+	CategorySift        ToolCategory = "sift"
+	CategoryAdmin       ToolCategory = "admin"
+	CategoryPyroscope   ToolCategory = "pyroscope"
+	CategoryNavigation  ToolCategory = "navigation"
+	CategoryAnnotations ToolCategory = "annotations"
+	CategoryRendering   ToolCategory = "rendering"
+	CategoryInfo        ToolCategory = "info"
+	CategoryTempo       ToolCategory = "tempo"
+)
+
+// DisabledToolCategoriesEnvVar is a comma separated list of tool categories
+// which should not be registered, regardless of which categories are enabled.
+const DisabledToolCategoriesEnvVar = "GRAFANA_DISABLED_TOOL_CATEGORIES"
+
+// ReadOnlyEnvVar enables read-only mode when set to a true value.
+const ReadOnlyEnvVar = "GRAFANA_READ_ONLY"
+
+// toolRegistration associates a tool category with the function that
+// registers the tools belonging to it.
+type toolRegistration struct {
+	category ToolCategory
+	add      func(mcp *server.MCPServer, enableWriteTools bool)
+}
+
+// toolRegistrations lists every tool category and how to register it, in
+// registration order.
+var toolRegistrations = []toolRegistration{
+	{CategorySearch, func(mcp *server.MCPServer, _ bool) { AddSearchTools(mcp) }},
+	{CategoryDatasource, func(mcp *server.MCPServer, _ bool) { AddDatasourceTools(mcp) }},
+	{CategoryIncident, AddIncidentTools},
+	{CategoryPrometheus, func(mcp *server.MCPServer, _ bool) { AddPrometheusTools(mcp) }},
+	{CategoryLoki, func(mcp *server.MCPServer, _ bool) { AddLokiTools(mcp) }},
+	{CategoryAlerting, AddAlertingTools},
+	{CategoryDashboard, AddDashboardTools},
+	{CategoryFolder, AddFolderTools},
+	{CategoryOnCall, func(mcp *server.MCPServer, _ bool) { AddOnCallTools(mcp) }},
+	{CategoryAsserts, func(mcp *server.MCPServer, _ bool) { AddAssertsTools(mcp) }},
+	{CategorySift, AddSiftTools},
+	{CategoryAdmin, func(mcp *server.MCPServer, _ bool) { AddAdminTools(mcp) }},
+	{CategoryPyroscope, func(mcp *server.MCPServer, _ bool) { AddPyroscopeTools(mcp) }},
+	{CategoryNavigation, AddNavigationTools},
+	{CategoryAnnotations, AddAnnotationTools},
+	{CategoryRendering, func(mcp *server.MCPServer, _ bool) { AddRenderingTools(mcp) }},
+	{CategoryInfo, func(mcp *server.MCPServer, _ bool) { AddInfoTools(mcp) }},
+	{CategoryTempo, func(mcp *server.MCPServer, _ bool) { AddTempoTools(mcp) }},
+}
+
+// AllToolCategories returns every known tool category.
+func AllToolCategories() []ToolCategory {
+	categories := make([]ToolCategory, 0, len(toolRegistrations))
+	for _, r := range toolRegistrations {
+		categories = append(categories, r.category)
+	}
+	return categories
+}
+
+// ParseToolCategories parses a comma separated list of tool categories,
+// ignoring surrounding whitespace and empty entries.
+func ParseToolCategories(s string) []ToolCategory {
+	var categories []ToolCategory
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			categories = append(categories, ToolCategory(c))
+		}
+	}
+	return categories
+}
+
+// DisabledToolCategoriesFromEnv returns the tool categories listed in the
+// GRAFANA_DISABLED_TOOL_CATEGORIES environment variable.
+func DisabledToolCategoriesFromEnv() []ToolCategory {
+	return ParseToolCategories(os.Getenv(DisabledToolCategoriesEnvVar))
+}
+
+// ReadOnlyFromEnv reports whether read-only mode is enabled by the
+// GRAFANA_READ_ONLY environment variable.
+func ReadOnlyFromEnv() bool {
+	readOnly, err := strconv.ParseBool(os.Getenv(ReadOnlyEnvVar))
+	return err == nil && readOnly
+}
+
+type addToolsConfig struct {
+	enabled          []ToolCategory
+	disabled         []ToolCategory
+	enableWriteTools bool
+	readOnly         bool
+}
+
+// AddToolsOption configures which tools AddTools registers.
+type AddToolsOption func(*addToolsConfig)
+
+// WithEnabledCategories restricts registration to the given categories. By
+// default all categories are enabled.
+func WithEnabledCategories(categories ...ToolCategory) AddToolsOption {
+	return func(c *addToolsConfig) {
+		c.enabled = categories
+	}
+}
+
+// WithDisabledCategories skips registration of the given categories, even if
+// they are enabled.
+func WithDisabledCategories(categories ...ToolCategory) AddToolsOption {
+	return func(c *addToolsConfig) {
+		c.disabled = append(c.disabled, categories...)
+	}
+}
+
+// WithWriteTools controls whether tools which modify Grafana state are
+// registered. Write tools are enabled by default.
+func WithWriteTools(enabled bool) AddToolsOption {
+	return func(c *addToolsConfig) {
+		c.enableWriteTools = enabled
+	}
+}
+
+// WithReadOnly enables read-only mode, in which no mutating tools are
+// registered. This is stricter than disabling write tools: any tool whose
+// registration is flagged as mutating is skipped, even outside of the
+// write-gated set.
+func WithReadOnly(readOnly bool) AddToolsOption {
+	return func(c *addToolsConfig) {
+		c.readOnly = readOnly
+	}
+}
+
+// AddTools registers the tools of every enabled, non-disabled category with
+// the MCP server.
+func AddTools(mcp *server.MCPServer, opts ...AddToolsOption) {
+	cfg := addToolsConfig{
+		enabled:          AllToolCategories(),
+		enableWriteTools: true,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	// Tool.Register skips mutating tools on a read-only server.
+	mcpgrafana.SetReadOnly(mcp, cfg.readOnly)
+
+	for _, r := range toolRegistrations {
+		if !slices.Contains(cfg.enabled, r.category) {
+			slog.Debug("Not enabling tools", "category", r.category)
+			continue
+		}
+		if slices.Contains(cfg.disabled, r.category) {
+			slog.Info("Disabling tools", "category", r.category)
+			continue
+		}
+		slog.Debug("Enabling tools", "category", r.category)
+		r.add(mcp, cfg.enableWriteTools && !cfg.readOnly)
+	}
+}
@@ -0,0 +1,103 @@
+//go:build unit
+
+package tools
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+func registeredToolNames(s *server.MCPServer) map[string]bool {
+	names := map[string]bool{}
+	for name := range s.ListTools() {
+		names[name] = true
+	}
+	return names
+}
+
+func TestAddTools(t *testing.T) {
+	t.Run("all categories enabled by default", func(t *testing.T) {
+		s := server.NewMCPServer("test", "0.0.0")
+		AddTools(s)
+		names := registeredToolNames(s)
+		assert.True(t, names["list_teams"])
+		assert.True(t, names["search_dashboards"])
+		assert.True(t, names["update_dashboard"])
+	})
+
+	t.Run("disabling admin removes admin tools", func(t *testing.T) {
+		s := server.NewMCPServer("test", "0.0.0")
+		AddTools(s, WithDisabledCategories(CategoryAdmin))
+		names := registeredToolNames(s)
+		for _, name := range []string{"list_teams", "list_users_by_org", "list_all_roles"} {
+			assert.False(t, names[name], "expected %s to be absent", name)
+		}
+		assert.True(t, names["search_dashboards"])
+	})
+
+	t.Run("only enabled categories are registered", func(t *testing.T) {
+		s := server.NewMCPServer("test", "0.0.0")
+		AddTools(s, WithEnabledCategories(CategorySearch))
+		names := registeredToolNames(s)
+		assert.True(t, names["search_dashboards"])
+		assert.False(t, names["list_datasources"])
+	})
+
+	t.Run("disabled categories from env", func(t *testing.T) {
+		t.Setenv(DisabledToolCategoriesEnvVar, " admin, Loki ,")
+		assert.Equal(t, []ToolCategory{CategoryAdmin, CategoryLoki}, DisabledToolCategoriesFromEnv())
+
+		s := server.NewMCPServer("test", "0.0.0")
+		AddTools(s, WithDisabledCategories(DisabledToolCategoriesFromEnv()...))
+		names := registeredToolNames(s)
+		assert.False(t, names["list_teams"])
+		assert.False(t, names["query_loki_logs"])
+		assert.True(t, names["query_prometheus"])
+	})
+
+	t.Run("write tools can be disabled", func(t *testing.T) {
+		s := server.NewMCPServer("test", "0.0.0")
+		AddTools(s, WithWriteTools(false))
+		names := registeredToolNames(s)
+		assert.False(t, names["update_dashboard"])
+		assert.True(t, names["get_dashboard_by_uid"])
+	})
+
+	t.Run("read-only mode skips mutating tools", func(t *testing.T) {
+		s := server.NewMCPServer("test", "0.0.0")
+		AddTools(s, WithReadOnly(true))
+		names := registeredToolNames(s)
+		assert.False(t, names["create_incident"])
+		assert.False(t, names["update_dashboard"])
+		assert.False(t, names["create_annotation"])
+		assert.False(t, names["delete_annotation"])
+		assert.False(t, names["test_contact_point"])
+		assert.True(t, names["get_annotations"])
+		assert.True(t, names["search_dashboards"])
+		// Cross-check the registrations against the annotations clients see.
+		for _, tool := range s.ListTools() {
+			assert.NotNil(t, tool.Tool.Annotations.ReadOnlyHint, tool.Tool.Name)
+			assert.True(t, *tool.Tool.Annotations.ReadOnlyHint, tool.Tool.Name)
+		}
+	})
+
+	t.Run("read-only mode from env", func(t *testing.T) {
+		t.Setenv(ReadOnlyEnvVar, "true")
+		assert.True(t, ReadOnlyFromEnv())
+		t.Setenv(ReadOnlyEnvVar, "not-a-bool")
+		assert.False(t, ReadOnlyFromEnv())
+	})
+}
+
+func TestToolMutating(t *testing.T) {
+	assert.True(t, CreateIncident.Mutating)
+	assert.True(t, UpdateDashboard.Mutating)
+	assert.False(t, SearchDashboards.Mutating)
+	assert.True(t, mcpgrafana.IsMutating(CreateIncident.Tool))
+	assert.True(t, mcpgrafana.IsMutating(UpdateDashboard.Tool))
+	assert.False(t, mcpgrafana.IsMutating(SearchDashboards.Tool))
+}
@@ -3,26 +3,41 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/url"
+	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/PaesslerAG/gval"
 	"github.com/PaesslerAG/jsonpath"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
+	"github.com/grafana/grafana-openapi-client-go/client/dashboards"
+	"github.com/grafana/grafana-openapi-client-go/client/datasources"
+	"github.com/grafana/grafana-openapi-client-go/client/folders"
+	"github.com/grafana/grafana-openapi-client-go/client/library_elements"
+	"github.com/grafana/grafana-openapi-client-go/client/search"
+	"github.com/grafana/grafana-openapi-client-go/client/signed_in_user"
 	"github.com/grafana/grafana-openapi-client-go/models"
 	mcpgrafana "github.com/grafana/mcp-grafana"
+	"github.com/prometheus/common/model"
 )
 
 type GetDashboardByUIDParams struct {
-	UID string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+	UID           string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+	SummarizeOnly bool   `json:"summarizeOnly,omitempty" jsonschema:"description=Return a compact summary (title\\, uid\\, folder\\, tags and the id\\, title\\, type and datasource UID of every panel) instead of the full dashboard JSON"`
 }
 
 func getDashboardByUID(ctx context.Context, args GetDashboardByUIDParams) (*models.DashboardFullWithMeta, error) {
 	c := mcpgrafana.GrafanaClientFromContext(ctx)
-	dashboard, err := c.Dashboards.GetDashboardByUID(args.UID)
+	dashboard, err := c.Dashboards.GetDashboardByUIDWithParams(dashboards.NewGetDashboardByUIDParamsWithContext(ctx).WithUID(args.UID))
 	if err != nil {
 		return nil, fmt.Errorf("get dashboard by uid %s: %w", args.UID, err)
 	}
@@ -122,23 +137,135 @@ func updateDashboardWithFullJSON(ctx context.Context, args UpdateDashboardParams
 		Overwrite: args.Overwrite,
 		UserID:    args.UserID,
 	}
-	dashboard, err := c.Dashboards.PostDashboard(cmd)
+	dashboard, err := c.Dashboards.PostDashboardWithParams(dashboards.NewPostDashboardParamsWithContext(ctx).WithBody(cmd))
 	if err != nil {
 		return nil, fmt.Errorf("unable to save dashboard: %w", err)
 	}
 	return dashboard.Payload, nil
 }
 
+// DashboardCompactSummary is the compact form of a dashboard returned by
+// get_dashboard_by_uid when summarizeOnly is set.
+type DashboardCompactSummary struct {
+	UID         string                `json:"uid"`
+	Title       string                `json:"title"`
+	FolderUID   string                `json:"folderUid,omitempty"`
+	FolderTitle string                `json:"folderTitle,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Panels      []CompactPanelSummary `json:"panels"`
+}
+
+// CompactPanelSummary identifies a panel without its targets, transformations
+// or field config. Row is the title of the row the panel belongs to, if any.
+type CompactPanelSummary struct {
+	ID            int    `json:"id"`
+	Title         string `json:"title"`
+	Type          string `json:"type"`
+	DatasourceUID string `json:"datasourceUid,omitempty"`
+	Row           string `json:"row,omitempty"`
+}
+
+// getDashboard is the handler for get_dashboard_by_uid, returning either the
+// full dashboard or its compact summary.
+func getDashboard(ctx context.Context, args GetDashboardByUIDParams) (any, error) {
+	dashboard, err := getDashboardByUID(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if !args.SummarizeOnly {
+		return dashboard, nil
+	}
+	return summarizeDashboard(args.UID, dashboard)
+}
+
+// summarizeDashboard builds a compact summary of a dashboard, flattening
+// panels nested in rows.
+func summarizeDashboard(uid string, dashboard *models.DashboardFullWithMeta) (*DashboardCompactSummary, error) {
+	db, ok := dashboard.Dashboard.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dashboard is not a JSON object")
+	}
+
+	summary := &DashboardCompactSummary{
+		UID:    uid,
+		Title:  safeString(db, "title"),
+		Tags:   safeStringSlice(db, "tags"),
+		Panels: []CompactPanelSummary{},
+	}
+	if dashboard.Meta != nil {
+		summary.FolderUID = dashboard.Meta.FolderUID
+		summary.FolderTitle = dashboard.Meta.FolderTitle
+	}
+
+	var row string
+	for _, p := range safeArray(db, "panels") {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if safeString(panel, "type") == "row" {
+			row = safeString(panel, "title")
+			summary.Panels = append(summary.Panels, compactPanelSummary(panel, ""))
+			// Collapsed rows hold their panels; expanded rows are followed by them.
+			for _, nested := range safeArray(panel, "panels") {
+				if nestedPanel, ok := nested.(map[string]interface{}); ok {
+					summary.Panels = append(summary.Panels, compactPanelSummary(nestedPanel, row))
+				}
+			}
+			continue
+		}
+		summary.Panels = append(summary.Panels, compactPanelSummary(panel, row))
+	}
+	return summary, nil
+}
+
+func compactPanelSummary(panel map[string]interface{}, row string) CompactPanelSummary {
+	return CompactPanelSummary{
+		ID:            safeInt(panel, "id"),
+		Title:         safeString(panel, "title"),
+		Type:          safeString(panel, "type"),
+		DatasourceUID: panelDatasourceUID(panel),
+		Row:           row,
+	}
+}
+
+// panelDatasourceUID returns the UID of a panel's datasource, falling back to
+// the datasource of its first target which has one. Legacy dashboards which
+// reference datasources by name return the name.
+func panelDatasourceUID(panel map[string]interface{}) string {
+	if uid := datasourceRefUID(panel["datasource"]); uid != "" {
+		return uid
+	}
+	for _, t := range safeArray(panel, "targets") {
+		if target, ok := t.(map[string]interface{}); ok {
+			if uid := datasourceRefUID(target["datasource"]); uid != "" {
+				return uid
+			}
+		}
+	}
+	return ""
+}
+
+func datasourceRefUID(ref interface{}) string {
+	switch v := ref.(type) {
+	case map[string]interface{}:
+		return safeString(v, "uid")
+	case string:
+		return v
+	}
+	return ""
+}
+
 var GetDashboardByUID = mcpgrafana.MustTool(
 	"get_dashboard_by_uid",
-	"Retrieves the complete dashboard, including panels, variables, and settings, for a specific dashboard identified by its UID. WARNING: Large dashboards can consume significant context window space. Consider using get_dashboard_summary for overview or get_dashboard_property for specific data instead.",
-	getDashboardByUID,
+	"Retrieves the complete dashboard, including panels, variables, and settings, for a specific dashboard identified by its UID. WARNING: Large dashboards can consume significant context window space. Set summarizeOnly to get just the title, folder, tags and a flat list of panels (id, title, type, datasource UID), or consider using get_dashboard_summary for overview or get_dashboard_property for specific data instead.",
+	getDashboard,
 	mcp.WithTitleAnnotation("Get dashboard details"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
-var UpdateDashboard = mcpgrafana.MustTool(
+var UpdateDashboard = mcpgrafana.MustMutatingTool(
 	"update_dashboard",
 	"Create or update a dashboard using either full JSON or efficient patch operations. For new dashboards\\, provide the 'dashboard' field. For updating existing dashboards\\, use 'uid' + 'operations' for better context window efficiency. Patch operations support complex JSONPaths like '$.panels[0].targets[0].expr'\\, '$.panels[1].title'\\, '$.panels[2].targets[0].datasource'\\, etc. Supports appending to arrays using '/- ' syntax: '$.panels/- ' appends to panels array\\, '$.panels[2]/- ' appends to nested array at index 2.",
 	updateDashboard,
@@ -146,228 +273,1656 @@ var UpdateDashboard = mcpgrafana.MustTool(
 	mcp.WithDestructiveHintAnnotation(true),
 )
 
-type DashboardPanelQueriesParams struct {
-	UID string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+type UpdateDashboardPanelQueryParams struct {
+	UID     string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+	PanelID int    `json:"panelId" jsonschema:"required,description=The ID of the panel holding the query"`
+	RefID   string `json:"refId" jsonschema:"required,description=The refId of the panel target (query) to update\\, e.g. 'A'"`
+	Expr    string `json:"expr" jsonschema:"required,description=The new query expression of the target"`
+	Message string `json:"message,omitempty" jsonschema:"description=Set a commit message for the version history"`
 }
 
-type datasourceInfo struct {
-	UID  string `json:"uid"`
-	Type string `json:"type"`
+// updateDashboardPanelQuery replaces the expression of a single panel target
+// and saves the dashboard at the version it was fetched at, so that
+// concurrent edits are reported as conflicts instead of being overwritten.
+func updateDashboardPanelQuery(ctx context.Context, args UpdateDashboardPanelQueryParams) (*models.PostDashboardOKBody, error) {
+	if args.RefID == "" {
+		return nil, fmt.Errorf("refId is required")
+	}
+
+	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams{UID: args.UID})
+	if err != nil {
+		return nil, fmt.Errorf("get dashboard by uid: %w", err)
+	}
+
+	db, ok := dashboard.Dashboard.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dashboard is not a JSON object")
+	}
+	if dashboard.Meta != nil && dashboard.Meta.Version != 0 {
+		db["version"] = dashboard.Meta.Version
+	}
+
+	var panel map[string]interface{}
+	for _, p := range collectPanels(safeArray(db, "panels")) {
+		if id, ok := p["id"].(float64); ok && int(id) == args.PanelID {
+			panel = p
+			break
+		}
+	}
+	if panel == nil {
+		return nil, fmt.Errorf("no panel with id %d found in dashboard %s", args.PanelID, args.UID)
+	}
+
+	var target map[string]interface{}
+	for _, t := range safeArray(panel, "targets") {
+		if t, ok := t.(map[string]interface{}); ok && safeString(t, "refId") == args.RefID {
+			target = t
+			break
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("no target with refId '%s' found in panel %d", args.RefID, args.PanelID)
+	}
+	target["expr"] = args.Expr
+
+	folderUID := ""
+	if dashboard.Meta != nil {
+		folderUID = dashboard.Meta.FolderUID
+	}
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	resp, err := c.Dashboards.PostDashboardWithParams(dashboards.NewPostDashboardParamsWithContext(ctx).WithBody(&models.SaveDashboardCommand{
+		Dashboard: db,
+		FolderUID: folderUID,
+		Message:   args.Message,
+	}))
+	if err != nil {
+		var conflict *dashboards.PostDashboardPreconditionFailed
+		if errors.As(err, &conflict) {
+			return nil, fmt.Errorf("dashboard %s was changed by someone else since version %v, fetch it again and retry", args.UID, db["version"])
+		}
+		return nil, fmt.Errorf("unable to save dashboard: %w", err)
+	}
+	return resp.Payload, nil
 }
 
-type panelQuery struct {
-	Title      string         `json:"title"`
-	Query      string         `json:"query"`
-	Datasource datasourceInfo `json:"datasource"`
+var UpdateDashboardPanelQuery = mcpgrafana.MustMutatingTool(
+	"update_dashboard_panel_query",
+	"Replace the query expression of a single panel target, identified by the panel 'panelId' and the target 'refId', without sending the whole dashboard. The dashboard is saved at the version it was read at: if someone else changed it in the meantime, the update fails with a conflict error and nothing is overwritten.",
+	updateDashboardPanelQuery,
+	mcp.WithTitleAnnotation("Update dashboard panel query"),
+	mcp.WithDestructiveHintAnnotation(true),
+)
+
+const (
+	defaultPanelType   = "timeseries"
+	defaultPanelWidth  = 12
+	defaultPanelHeight = 8
+	// dashboardGridColumns is the width of Grafana's dashboard grid.
+	dashboardGridColumns = 24
+)
+
+// DashboardGridPos is the position and size of a panel on the dashboard grid.
+type DashboardGridPos struct {
+	X int `json:"x" jsonschema:"description=The column of the panel's left edge (0-23)"`
+	Y int `json:"y" jsonschema:"description=The row of the panel's top edge"`
+	W int `json:"w" jsonschema:"description=The width of the panel in columns (1-24)"`
+	H int `json:"h" jsonschema:"description=The height of the panel in rows"`
 }
 
-func GetDashboardPanelQueriesTool(ctx context.Context, args DashboardPanelQueriesParams) ([]panelQuery, error) {
-	result := make([]panelQuery, 0)
+// DashboardPanelSpec is the minimal description of a panel accepted by
+// create_dashboard.
+type DashboardPanelSpec struct {
+	Title         string            `json:"title" jsonschema:"required,description=The title of the panel"`
+	Type          string            `json:"type,omitempty" jsonschema:"default=timeseries,description=The panel type\\, e.g. 'timeseries'\\, 'stat'\\, 'gauge'\\, 'table' or 'logs'"`
+	DatasourceUID string            `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource the panel queries"`
+	Expr          string            `json:"expr" jsonschema:"required,description=The query expression of the panel\\, e.g. a PromQL or LogQL query"`
+	GridPos       *DashboardGridPos `json:"gridPos,omitempty" jsonschema:"description=Optionally\\, the position and size of the panel. By default panels are 12 columns wide and 8 rows high\\, laid out two per row in order"`
+}
 
-	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams(args))
-	if err != nil {
-		return result, fmt.Errorf("get dashboard by uid: %w", err)
+type CreateDashboardParams struct {
+	Title     string               `json:"title" jsonschema:"required,description=The title of the dashboard"`
+	FolderUID string               `json:"folderUid,omitempty" jsonschema:"description=The UID of the folder to create the dashboard in. Defaults to the General folder"`
+	Panels    []DashboardPanelSpec `json:"panels" jsonschema:"description=The panels of the dashboard"`
+	Message   string               `json:"message,omitempty" jsonschema:"description=Set a commit message for the version history"`
+}
+
+// CreateDashboardResult identifies a dashboard created by create_dashboard or
+// import_dashboard.
+type CreateDashboardResult struct {
+	UID     string `json:"uid"`
+	URL     string `json:"url"`
+	Version int64  `json:"version,omitempty"`
+}
+
+// buildDashboardFromSpec assembles the JSON model of a new dashboard from
+// panel specs. datasourceTypes maps each datasource UID used by the panels to
+// its type, for the panels' datasource references. Panels are numbered from 1
+// in order, and those without a position are laid out left to right, top to
+// bottom, below any explicitly positioned panels.
+func buildDashboardFromSpec(title string, specs []DashboardPanelSpec, datasourceTypes map[string]string) map[string]interface{} {
+	nextY := 0
+	for _, spec := range specs {
+		if spec.GridPos != nil {
+			nextY = max(nextY, spec.GridPos.Y+spec.GridPos.H)
+		}
+	}
+	nextX := 0
+
+	panels := make([]interface{}, 0, len(specs))
+	for i, spec := range specs {
+		var pos DashboardGridPos
+		if spec.GridPos != nil {
+			pos = *spec.GridPos
+		} else {
+			if nextX+defaultPanelWidth > dashboardGridColumns {
+				nextX = 0
+				nextY += defaultPanelHeight
+			}
+			pos = DashboardGridPos{X: nextX, Y: nextY, W: defaultPanelWidth, H: defaultPanelHeight}
+			nextX += defaultPanelWidth
+		}
+
+		panelType := spec.Type
+		if panelType == "" {
+			panelType = defaultPanelType
+		}
+		datasource := map[string]interface{}{
+			"type": datasourceTypes[spec.DatasourceUID],
+			"uid":  spec.DatasourceUID,
+		}
+		panels = append(panels, map[string]interface{}{
+			"id":    i + 1,
+			"type":  panelType,
+			"title": spec.Title,
+			"gridPos": map[string]interface{}{
+				"x": pos.X,
+				"y": pos.Y,
+				"w": pos.W,
+				"h": pos.H,
+			},
+			"datasource": datasource,
+			"targets": []interface{}{
+				map[string]interface{}{
+					"refId":      "A",
+					"datasource": datasource,
+					"expr":       spec.Expr,
+				},
+			},
+		})
 	}
 
-	db, ok := dashboard.Dashboard.(map[string]any)
-	if !ok {
-		return result, fmt.Errorf("dashboard is not a JSON object")
+	return map[string]interface{}{
+		"title":         title,
+		"editable":      true,
+		"schemaVersion": 39,
+		"time": map[string]interface{}{
+			"from": "now-6h",
+			"to":   "now",
+		},
+		"panels": panels,
 	}
-	panels, ok := db["panels"].([]any)
-	if !ok {
-		return result, fmt.Errorf("panels is not a JSON array")
+}
+
+// validateDashboardPanelSpec checks a panel spec, returning an error naming
+// the offending panel.
+func validateDashboardPanelSpec(i int, spec DashboardPanelSpec) error {
+	if spec.Title == "" {
+		return fmt.Errorf("panel %d: title is required", i)
+	}
+	if spec.DatasourceUID == "" {
+		return fmt.Errorf("panel %d (%s): datasourceUid is required", i, spec.Title)
+	}
+	if spec.Expr == "" {
+		return fmt.Errorf("panel %d (%s): expr is required", i, spec.Title)
+	}
+	if pos := spec.GridPos; pos != nil {
+		if pos.W <= 0 || pos.H <= 0 || pos.X < 0 || pos.Y < 0 || pos.X+pos.W > dashboardGridColumns {
+			return fmt.Errorf("panel %d (%s): invalid gridPos %+v, panels must fit in %d columns with a positive width and height", i, spec.Title, *pos, dashboardGridColumns)
+		}
 	}
+	return nil
+}
 
-	for _, p := range panels {
-		panel, ok := p.(map[string]any)
-		if !ok {
+func createDashboard(ctx context.Context, args CreateDashboardParams) (*CreateDashboardResult, error) {
+	if args.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	datasourceTypes := make(map[string]string)
+	for i, spec := range args.Panels {
+		if err := validateDashboardPanelSpec(i, spec); err != nil {
+			return nil, err
+		}
+		if _, ok := datasourceTypes[spec.DatasourceUID]; ok {
 			continue
 		}
-		title, _ := panel["title"].(string)
+		ds, err := datasourceInfo(ctx, spec.DatasourceUID)
+		if err != nil {
+			return nil, fmt.Errorf("panel %d (%s): %w", i, spec.Title, err)
+		}
+		datasourceTypes[spec.DatasourceUID] = ds.Type
+	}
 
-		var datasourceInfo datasourceInfo
-		if dsField, dsExists := panel["datasource"]; dsExists && dsField != nil {
-			if dsMap, ok := dsField.(map[string]any); ok {
-				if uid, ok := dsMap["uid"].(string); ok {
-					datasourceInfo.UID = uid
-				}
-				if dsType, ok := dsMap["type"].(string); ok {
-					datasourceInfo.Type = dsType
-				}
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	resp, err := c.Dashboards.PostDashboardWithParams(dashboards.NewPostDashboardParamsWithContext(ctx).WithBody(&models.SaveDashboardCommand{
+		Dashboard: buildDashboardFromSpec(args.Title, args.Panels, datasourceTypes),
+		FolderUID: args.FolderUID,
+		Message:   args.Message,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create dashboard: %w", err)
+	}
+
+	return savedDashboardResult(ctx, resp.Payload), nil
+}
+
+// savedDashboardResult returns the UID, absolute URL and version of a saved
+// dashboard. Grafana's URL already includes any sub-path it is served from,
+// which the configured URL also ends with, so it is only added once.
+func savedDashboardResult(ctx context.Context, payload *models.PostDashboardOKBody) *CreateDashboardResult {
+	result := &CreateDashboardResult{}
+	if payload.UID != nil {
+		result.UID = *payload.UID
+	}
+	if payload.URL != nil {
+		baseURL := strings.TrimRight(mcpgrafana.GrafanaConfigFromContext(ctx).URL, "/")
+		path := *payload.URL
+		if base, err := url.Parse(baseURL); err == nil && base.Path != "" {
+			if rest, ok := strings.CutPrefix(path, base.Path); ok && strings.HasPrefix(rest, "/") {
+				path = rest
 			}
 		}
+		result.URL = baseURL + path
+	}
+	if payload.Version != nil {
+		result.Version = *payload.Version
+	}
+	return result
+}
 
-		targets, ok := panel["targets"].([]any)
-		if !ok {
-			continue
+var CreateDashboard = mcpgrafana.MustMutatingTool(
+	"create_dashboard",
+	"Create a new dashboard from a minimal spec, without writing dashboard JSON. Takes a title, an optional folder UID and a list of panels, each with a title, type (default 'timeseries'), datasource UID, query expression and optional grid position. Panels without a position are laid out two per row. Returns the UID and URL of the new dashboard. Use update_dashboard to make further changes.",
+	createDashboard,
+	mcp.WithTitleAnnotation("Create dashboard"),
+)
+
+type ExportDashboardParams struct {
+	UID string `json:"uid" jsonschema:"required,description=The UID of the dashboard to export"`
+}
+
+// dashboardInput is an entry of an exported dashboard's __inputs, which the
+// importer asks to be mapped to a datasource of the target instance.
+type dashboardInput struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	PluginID    string `json:"pluginId"`
+	PluginName  string `json:"pluginName"`
+}
+
+// dashboardRequirement is an entry of an exported dashboard's __requires,
+// listing the plugins it uses.
+type dashboardRequirement struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// builtInDatasources are the datasource UIDs and names which exist on every
+// Grafana instance, so are left as is in exports.
+var builtInDatasources = []string{"grafana", "-- Grafana --", "-- Mixed --", "-- Dashboard --"}
+
+var nonInputNameChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// dashboardExporter replaces the concrete datasource references of a
+// dashboard with ${DS_*} inputs, as Grafana's "Export for sharing externally"
+// does.
+type dashboardExporter struct {
+	ctx    context.Context
+	inputs []dashboardInput
+	// inputsByRef maps the datasource UIDs and names referenced by the
+	// dashboard to the index of their input, and inputsByUID maps the UIDs of
+	// the datasources they resolve to.
+	inputsByRef map[string]int
+	inputsByUID map[string]int
+	panelTypes  []string
+}
+
+func exportDashboard(ctx context.Context, args ExportDashboardParams) (map[string]interface{}, error) {
+	if args.UID == "" {
+		return nil, fmt.Errorf("uid is required")
+	}
+	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams{UID: args.UID})
+	if err != nil {
+		return nil, err
+	}
+	db, ok := dashboard.Dashboard.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dashboard %s is not a JSON object", args.UID)
+	}
+
+	e := &dashboardExporter{ctx: ctx, inputsByRef: map[string]int{}, inputsByUID: map[string]int{}}
+	if err := e.templatize(db); err != nil {
+		return nil, fmt.Errorf("export dashboard %s: %w", args.UID, err)
+	}
+	for _, panel := range collectPanels(safeArray(db, "panels")) {
+		if t := safeString(panel, "type"); t != "" && t != "row" && !slices.Contains(e.panelTypes, t) {
+			e.panelTypes = append(e.panelTypes, t)
 		}
-		for _, t := range targets {
-			target, ok := t.(map[string]any)
-			if !ok {
+	}
+
+	// The id is specific to the source instance and the version restarts on
+	// the target.
+	db["id"] = nil
+	db["version"] = 0
+	db["__inputs"] = e.inputs
+	db["__requires"] = e.requirements()
+	return db, nil
+}
+
+// templatize walks a dashboard's JSON, replacing every datasource reference
+// with a reference to an input.
+func (e *dashboardExporter) templatize(node interface{}) error {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		// Visit keys in order so that inputs are numbered deterministically.
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			if key == "datasource" {
+				ref, err := e.templatizeRef(v[key])
+				if err != nil {
+					return err
+				}
+				v[key] = ref
 				continue
 			}
-			expr, _ := target["expr"].(string)
-			if expr != "" {
-				result = append(result, panelQuery{
-					Title:      title,
-					Query:      expr,
-					Datasource: datasourceInfo,
-				})
+			if err := e.templatize(v[key]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := e.templatize(item); err != nil {
+				return err
 			}
 		}
 	}
+	return nil
+}
 
-	return result, nil
+// templatizeRef returns a datasource reference, either an object with a uid
+// or, in older dashboards, a datasource name, with the datasource replaced by
+// its input. References to variables and built-in datasources are returned
+// unchanged.
+func (e *dashboardExporter) templatizeRef(ref interface{}) (interface{}, error) {
+	switch v := ref.(type) {
+	case map[string]interface{}:
+		uid := safeString(v, "uid")
+		if isPortableDatasourceRef(uid) {
+			return v, nil
+		}
+		input, err := e.input(uid, true)
+		if err != nil {
+			return nil, err
+		}
+		v["uid"] = "${" + input.Name + "}"
+		if safeString(v, "type") == "" {
+			v["type"] = input.PluginID
+		}
+		return v, nil
+	case string:
+		if isPortableDatasourceRef(v) {
+			return v, nil
+		}
+		input, err := e.input(v, false)
+		if err != nil {
+			return nil, err
+		}
+		return "${" + input.Name + "}", nil
+	}
+	return ref, nil
 }
 
-var GetDashboardPanelQueries = mcpgrafana.MustTool(
-	"get_dashboard_panel_queries",
-	"Use this tool to retrieve panel queries and information from a Grafana dashboard. When asked about panel queries, queries in a dashboard, or what queries a dashboard contains, call this tool with the dashboard UID. The datasource is an object with fields `uid` (which may be a concrete UID or a template variable like \"$datasource\") and `type`. If the datasource UID is a template variable, it won't be usable directly for queries. Returns an array of objects, each representing a panel, with fields: title, query, and datasource (an object with uid and type).",
-	GetDashboardPanelQueriesTool,
-	mcp.WithTitleAnnotation("Get dashboard panel queries"),
+func isPortableDatasourceRef(ref string) bool {
+	return ref == "" || strings.HasPrefix(ref, "$") || slices.Contains(builtInDatasources, ref)
+}
+
+// input returns the input for the datasource with the given UID or name,
+// adding one if it is the first reference to it.
+func (e *dashboardExporter) input(ref string, byUID bool) (dashboardInput, error) {
+	if i, ok := e.inputsByRef[ref]; ok {
+		return e.inputs[i], nil
+	}
+
+	var ds *models.DataSource
+	if byUID {
+		var err error
+		if ds, err = datasourceInfo(e.ctx, ref); err != nil {
+			return dashboardInput{}, err
+		}
+	} else {
+		resp, err := mcpgrafana.GrafanaClientFromContext(e.ctx).Datasources.GetDataSourceByName(ref)
+		if err != nil {
+			return dashboardInput{}, fmt.Errorf("get datasource by name %s: %w", ref, err)
+		}
+		ds = resp.Payload
+	}
+
+	// The same datasource may be referenced by both UID and name.
+	i, ok := e.inputsByUID[ds.UID]
+	if !ok {
+		base := "DS_" + strings.Trim(nonInputNameChars.ReplaceAllString(strings.ToUpper(ds.Name), "_"), "_")
+		name := base
+		for n := 2; slices.ContainsFunc(e.inputs, func(in dashboardInput) bool { return in.Name == name }); n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		e.inputs = append(e.inputs, dashboardInput{
+			Name:       name,
+			Label:      ds.Name,
+			Type:       "datasource",
+			PluginID:   ds.Type,
+			PluginName: ds.Type,
+		})
+		i = len(e.inputs) - 1
+		e.inputsByUID[ds.UID] = i
+	}
+	e.inputsByRef[ref] = i
+	return e.inputs[i], nil
+}
+
+func (e *dashboardExporter) requirements() []dashboardRequirement {
+	requires := []dashboardRequirement{}
+	for _, input := range e.inputs {
+		if !slices.ContainsFunc(requires, func(r dashboardRequirement) bool { return r.ID == input.PluginID }) {
+			requires = append(requires, dashboardRequirement{Type: "datasource", ID: input.PluginID, Name: input.PluginName})
+		}
+	}
+	for _, t := range e.panelTypes {
+		requires = append(requires, dashboardRequirement{Type: "panel", ID: t, Name: t})
+	}
+	return requires
+}
+
+var ExportDashboard = mcpgrafana.MustTool(
+	"export_dashboard",
+	"Export a dashboard as JSON ready to be imported into another Grafana instance or provisioned, like Grafana's 'Export for sharing externally'. The id is set to null and the version to 0, concrete datasource references are replaced with ${DS_*} input variables described in a generated __inputs list, and the plugins used are listed in __requires. References to dashboard variables and built-in datasources are kept as is.",
+	exportDashboard,
+	mcp.WithTitleAnnotation("Export dashboard"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
-// GetDashboardPropertyParams defines parameters for getting specific dashboard properties
-type GetDashboardPropertyParams struct {
-	UID      string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
-	JSONPath string `json:"jsonPath" jsonschema:"required,description=JSONPath expression to extract specific data (e.g.\\, '$.panels[0].title' for first panel title\\, '$.panels[*].title' for all panel titles\\, '$.templating.list' for variables)"`
+type ImportDashboardParams struct {
+	Dashboard   map[string]interface{} `json:"dashboard" jsonschema:"required,description=The dashboard JSON to import\\, such as one returned by export_dashboard"`
+	FolderUID   string                 `json:"folderUid,omitempty" jsonschema:"description=The UID of the folder to import the dashboard into. Defaults to the General folder"`
+	Overwrite   bool                   `json:"overwrite,omitempty" jsonschema:"description=Replace an existing dashboard with the same UID or title in the folder. Otherwise importing fails if one exists"`
+	Datasources map[string]string      `json:"datasources,omitempty" jsonschema:"description=Maps the name of each datasource input in the dashboard's __inputs (e.g. DS_PROMETHEUS) to the UID of the datasource to use on this instance. Required for every datasource input"`
+	Message     string                 `json:"message,omitempty" jsonschema:"description=Set a commit message for the version history"`
 }
 
-// getDashboardProperty retrieves specific parts of a dashboard using JSONPath expressions.
-// This helps reduce context window usage by fetching only the needed data.
-func getDashboardProperty(ctx context.Context, args GetDashboardPropertyParams) (interface{}, error) {
-	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams{UID: args.UID})
-	if err != nil {
-		return nil, fmt.Errorf("get dashboard by uid: %w", err)
+func importDashboard(ctx context.Context, args ImportDashboardParams) (*CreateDashboardResult, error) {
+	if args.Dashboard == nil {
+		return nil, fmt.Errorf("dashboard is required")
 	}
+	db := args.Dashboard
 
-	// Convert dashboard to JSON for JSONPath processing
-	dashboardJSON, err := json.Marshal(dashboard.Dashboard)
+	// Resolve the dashboard's inputs as Grafana's import does: datasource
+	// inputs to the datasources given, and constants to their value.
+	var replacements, missing []string
+	for _, item := range safeArray(db, "__inputs") {
+		input, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := safeString(input, "name")
+		switch safeString(input, "type") {
+		case "datasource":
+			uid, ok := args.Datasources[name]
+			if !ok || uid == "" {
+				missing = append(missing, name)
+				continue
+			}
+			replacements = append(replacements, "${"+name+"}", uid)
+		case "constant":
+			replacements = append(replacements, "${"+name+"}", safeString(input, "value"))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no datasource UID given for inputs %s, map each to a datasource of this instance in datasources", strings.Join(missing, ", "))
+	}
+	if len(replacements) > 0 {
+		db = replaceDashboardStrings(db, strings.NewReplacer(replacements...)).(map[string]interface{})
+	}
+	delete(db, "__inputs")
+	delete(db, "__requires")
+	// Dashboards are matched by UID; an id from another instance would refer
+	// to an unrelated dashboard.
+	db["id"] = nil
+
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	resp, err := c.Dashboards.PostDashboardWithParams(dashboards.NewPostDashboardParamsWithContext(ctx).WithBody(&models.SaveDashboardCommand{
+		Dashboard: db,
+		FolderUID: args.FolderUID,
+		Message:   args.Message,
+		Overwrite: args.Overwrite,
+	}))
 	if err != nil {
-		return nil, fmt.Errorf("marshal dashboard to JSON: %w", err)
+		var conflict *dashboards.PostDashboardPreconditionFailed
+		if errors.As(err, &conflict) {
+			reason := "a dashboard with the same UID or title already exists"
+			if conflict.Payload != nil && conflict.Payload.Message != nil {
+				reason = *conflict.Payload.Message
+			}
+			return nil, fmt.Errorf("dashboard conflict: %s, set overwrite to replace it", reason)
+		}
+		return nil, fmt.Errorf("unable to import dashboard: %w", err)
+	}
+	return savedDashboardResult(ctx, resp.Payload), nil
+}
+
+// replaceDashboardStrings returns a copy of a dashboard's JSON with r applied
+// to every string value.
+func replaceDashboardStrings(node interface{}, r *strings.Replacer) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		replaced := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			replaced[key] = replaceDashboardStrings(value, r)
+		}
+		return replaced
+	case []interface{}:
+		replaced := make([]interface{}, len(v))
+		for i, value := range v {
+			replaced[i] = replaceDashboardStrings(value, r)
+		}
+		return replaced
+	case string:
+		return r.Replace(v)
+	}
+	return node
+}
+
+var ImportDashboard = mcpgrafana.MustMutatingTool(
+	"import_dashboard",
+	"Import a dashboard from its full JSON, such as one exported with export_dashboard from another instance, into an optional folder. Every datasource input listed in the dashboard's __inputs must be mapped to the UID of a datasource of this instance in 'datasources'; the ${DS_*} references are replaced accordingly. If a dashboard with the same UID or title exists the import fails with a conflict error, unless 'overwrite' is set. Returns the UID and URL of the imported dashboard.",
+	importDashboard,
+	mcp.WithTitleAnnotation("Import dashboard"),
+	mcp.WithDestructiveHintAnnotation(true),
+)
+
+type DiffDashboardVersionsParams struct {
+	UID         string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+	FromVersion int64  `json:"fromVersion" jsonschema:"required,description=The earlier version to compare"`
+	ToVersion   int64  `json:"toVersion" jsonschema:"required,description=The later version to compare"`
+}
+
+// DashboardVersionDiff summarizes what changed between two versions of a
+// dashboard. Changes lists dashboard level changes, such as to the title,
+// tags or variables.
+type DashboardVersionDiff struct {
+	UID           string                `json:"uid"`
+	FromVersion   int64                 `json:"fromVersion"`
+	ToVersion     int64                 `json:"toVersion"`
+	Changes       []string              `json:"changes,omitempty"`
+	AddedPanels   []CompactPanelSummary `json:"addedPanels,omitempty"`
+	RemovedPanels []CompactPanelSummary `json:"removedPanels,omitempty"`
+	ChangedPanels []DashboardPanelDiff  `json:"changedPanels,omitempty"`
+}
+
+// DashboardPanelDiff lists the changes to a panel present in both versions.
+type DashboardPanelDiff struct {
+	ID      int      `json:"id"`
+	Title   string   `json:"title"`
+	Changes []string `json:"changes"`
+}
+
+func diffDashboardVersions(ctx context.Context, args DiffDashboardVersionsParams) (*DashboardVersionDiff, error) {
+	if args.UID == "" {
+		return nil, fmt.Errorf("uid is required")
+	}
+	if args.FromVersion <= 0 || args.ToVersion <= 0 {
+		return nil, fmt.Errorf("fromVersion and toVersion must be positive version numbers")
+	}
+	from, err := getDashboardVersion(ctx, args.UID, args.FromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := getDashboardVersion(ctx, args.UID, args.ToVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &DashboardVersionDiff{UID: args.UID, FromVersion: args.FromVersion, ToVersion: args.ToVersion}
+	if before, after := safeString(from, "title"), safeString(to, "title"); before != after {
+		diff.Changes = append(diff.Changes, fmt.Sprintf("title changed from %q to %q", before, after))
+	}
+	if before, after := safeStringSlice(from, "tags"), safeStringSlice(to, "tags"); !slices.Equal(before, after) {
+		diff.Changes = append(diff.Changes, fmt.Sprintf("tags changed from %v to %v", before, after))
+	}
+	diff.Changes = append(diff.Changes, diffDashboardVariables(from, to)...)
+
+	fromPanels := panelsByID(from)
+	toPanels := panelsByID(to)
+	for _, id := range slices.Sorted(maps.Keys(toPanels)) {
+		after := toPanels[id]
+		before, ok := fromPanels[id]
+		if !ok {
+			diff.AddedPanels = append(diff.AddedPanels, compactPanelSummary(after, ""))
+			continue
+		}
+		if changes := diffDashboardPanel(before, after); len(changes) > 0 {
+			diff.ChangedPanels = append(diff.ChangedPanels, DashboardPanelDiff{ID: id, Title: safeString(after, "title"), Changes: changes})
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(fromPanels)) {
+		if _, ok := toPanels[id]; !ok {
+			diff.RemovedPanels = append(diff.RemovedPanels, compactPanelSummary(fromPanels[id], ""))
+		}
+	}
+	return diff, nil
+}
+
+// getDashboardVersion returns the JSON model of a version of a dashboard.
+func getDashboardVersion(ctx context.Context, uid string, version int64) (map[string]interface{}, error) {
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	resp, err := c.Dashboards.GetDashboardVersionByUIDWithParams(dashboards.NewGetDashboardVersionByUIDParamsWithContext(ctx).WithUID(uid).WithDashboardVersionID(version))
+	if err != nil {
+		return nil, fmt.Errorf("get version %d of dashboard %s: %w", version, uid, err)
+	}
+	db, ok := resp.Payload.Data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("version %d of dashboard %s is not a JSON object", version, uid)
+	}
+	return db, nil
+}
+
+// panelsByID returns a dashboard's panels, including those nested in rows, by
+// their ID.
+func panelsByID(db map[string]interface{}) map[int]map[string]interface{} {
+	panels := make(map[int]map[string]interface{})
+	for _, panel := range collectPanels(safeArray(db, "panels")) {
+		panels[safeInt(panel, "id")] = panel
+	}
+	return panels
+}
+
+// diffDashboardPanel describes the changes between two versions of a panel:
+// to its title, type, datasource and queries, and whether its options, field
+// config or transformations changed. Layout changes are ignored.
+func diffDashboardPanel(before, after map[string]interface{}) []string {
+	var changes []string
+	for _, key := range []string{"title", "type"} {
+		if b, a := safeString(before, key), safeString(after, key); b != a {
+			changes = append(changes, fmt.Sprintf("%s changed from %q to %q", key, b, a))
+		}
+	}
+	if b, a := panelDatasourceUID(before), panelDatasourceUID(after); b != a {
+		changes = append(changes, fmt.Sprintf("datasource changed from %q to %q", b, a))
+	}
+
+	beforeQueries := targetQueriesByRefID(before)
+	afterQueries := targetQueriesByRefID(after)
+	for _, refID := range slices.Sorted(maps.Keys(afterQueries)) {
+		b, ok := beforeQueries[refID]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("query %s added: %s", refID, afterQueries[refID]))
+		case b != afterQueries[refID]:
+			changes = append(changes, fmt.Sprintf("query %s changed from %s to %s", refID, b, afterQueries[refID]))
+		}
+	}
+	for _, refID := range slices.Sorted(maps.Keys(beforeQueries)) {
+		if _, ok := afterQueries[refID]; !ok {
+			changes = append(changes, fmt.Sprintf("query %s removed: %s", refID, beforeQueries[refID]))
+		}
+	}
+
+	for _, key := range []string{"options", "fieldConfig", "transformations"} {
+		if !reflect.DeepEqual(before[key], after[key]) {
+			changes = append(changes, key+" changed")
+		}
+	}
+	return changes
+}
+
+// targetQueriesByRefID returns the query text of each of a panel's targets.
+func targetQueriesByRefID(panel map[string]interface{}) map[string]string {
+	queries := make(map[string]string)
+	for _, t := range safeArray(panel, "targets") {
+		target, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		queries[safeString(target, "refId")] = targetQuery(target)
+	}
+	return queries
+}
+
+// targetQuery returns the query text of a target, whichever field its
+// datasource keeps it in.
+func targetQuery(target map[string]interface{}) string {
+	for _, key := range []string{"expr", "query", "rawSql", "expression"} {
+		if query := safeString(target, key); query != "" {
+			return query
+		}
+	}
+	return ""
+}
+
+func diffDashboardVariables(from, to map[string]interface{}) []string {
+	variables := func(db map[string]interface{}) map[string]map[string]interface{} {
+		byName := make(map[string]map[string]interface{})
+		for _, v := range safeArray(safeObject(db, "templating"), "list") {
+			if variable, ok := v.(map[string]interface{}); ok {
+				byName[safeString(variable, "name")] = variable
+			}
+		}
+		return byName
+	}
+	before, after := variables(from), variables(to)
+
+	var changes []string
+	for _, name := range slices.Sorted(maps.Keys(after)) {
+		b, ok := before[name]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("variable $%s added", name))
+		case variableQuery(b) != variableQuery(after[name]):
+			changes = append(changes, fmt.Sprintf("query of variable $%s changed from %s to %s", name, variableQuery(b), variableQuery(after[name])))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, fmt.Sprintf("variable $%s removed", name))
+		}
+	}
+	return changes
+}
+
+var DiffDashboardVersions = mcpgrafana.MustTool(
+	"diff_dashboard_versions",
+	"Compare two versions of a dashboard from its version history and summarize what changed between them: panels added and removed, and for other panels changes to their title, type, datasource and queries, and whether their options, field config or transformations changed. Also reports changes to the dashboard's title, tags and variables. Layout changes are ignored. Useful to explain what an edit did; the current version number is in the dashboard's 'version' field.",
+	diffDashboardVersions,
+	mcp.WithTitleAnnotation("Diff dashboard versions"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type DashboardPanelQueriesParams struct {
+	UID string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+}
+
+type panelDatasource struct {
+	UID  string `json:"uid"`
+	Type string `json:"type"`
+}
+
+type panelQuery struct {
+	Title      string          `json:"title"`
+	Query      string          `json:"query"`
+	Datasource panelDatasource `json:"datasource"`
+}
+
+func GetDashboardPanelQueriesTool(ctx context.Context, args DashboardPanelQueriesParams) ([]panelQuery, error) {
+	result := make([]panelQuery, 0)
+
+	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams{UID: args.UID})
+	if err != nil {
+		return result, fmt.Errorf("get dashboard by uid: %w", err)
+	}
+
+	db, ok := dashboard.Dashboard.(map[string]any)
+	if !ok {
+		return result, fmt.Errorf("dashboard is not a JSON object")
+	}
+	panels, ok := db["panels"].([]any)
+	if !ok {
+		return result, fmt.Errorf("panels is not a JSON array")
+	}
+
+	for _, p := range panels {
+		panel, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		title, _ := panel["title"].(string)
+
+		var datasource panelDatasource
+		if dsField, dsExists := panel["datasource"]; dsExists && dsField != nil {
+			if dsMap, ok := dsField.(map[string]any); ok {
+				if uid, ok := dsMap["uid"].(string); ok {
+					datasource.UID = uid
+				}
+				if dsType, ok := dsMap["type"].(string); ok {
+					datasource.Type = dsType
+				}
+			}
+		}
+
+		targets, ok := panel["targets"].([]any)
+		if !ok {
+			continue
+		}
+		for _, t := range targets {
+			target, ok := t.(map[string]any)
+			if !ok {
+				continue
+			}
+			expr, _ := target["expr"].(string)
+			if expr != "" {
+				result = append(result, panelQuery{
+					Title:      title,
+					Query:      expr,
+					Datasource: datasource,
+				})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+var GetDashboardPanelQueries = mcpgrafana.MustTool(
+	"get_dashboard_panel_queries",
+	"Use this tool to retrieve panel queries and information from a Grafana dashboard. When asked about panel queries, queries in a dashboard, or what queries a dashboard contains, call this tool with the dashboard UID. The datasource is an object with fields `uid` (which may be a concrete UID or a template variable like \"$datasource\") and `type`. If the datasource UID is a template variable, it won't be usable directly for queries. Returns an array of objects, each representing a panel, with fields: title, query, and datasource (an object with uid and type).",
+	GetDashboardPanelQueriesTool,
+	mcp.WithTitleAnnotation("Get dashboard panel queries"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type ListDashboardQueriesParams struct {
+	UID string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+}
+
+// DashboardPanelQueries holds the queries of one panel of a dashboard.
+type DashboardPanelQueries struct {
+	PanelID int              `json:"panelId"`
+	Title   string           `json:"title"`
+	Type    string           `json:"type"`
+	Row     string           `json:"row,omitempty"`
+	Queries []DashboardQuery `json:"queries"`
+}
+
+// DashboardQuery is a single target of a panel. DatasourceUID is the
+// target's own datasource, falling back to the panel's, and may be a
+// template variable such as "$datasource".
+type DashboardQuery struct {
+	RefID         string `json:"refId"`
+	Query         string `json:"query"`
+	DatasourceUID string `json:"datasourceUid,omitempty"`
+	Legend        string `json:"legend,omitempty"`
+	Hidden        bool   `json:"hidden,omitempty"`
+}
+
+// nonQueryPanelTypes are panel types which never have queries.
+var nonQueryPanelTypes = map[string]bool{"row": true, "text": true}
+
+func listDashboardQueries(ctx context.Context, args ListDashboardQueriesParams) ([]DashboardPanelQueries, error) {
+	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams{UID: args.UID})
+	if err != nil {
+		return nil, err
+	}
+	db, ok := dashboard.Dashboard.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dashboard is not a JSON object")
+	}
+
+	result := []DashboardPanelQueries{}
+	var row string
+	add := func(panel map[string]interface{}, row string) {
+		if nonQueryPanelTypes[safeString(panel, "type")] {
+			return
+		}
+		if queries := dashboardPanelQueries(panel); len(queries) > 0 {
+			result = append(result, DashboardPanelQueries{
+				PanelID: safeInt(panel, "id"),
+				Title:   safeString(panel, "title"),
+				Type:    safeString(panel, "type"),
+				Row:     row,
+				Queries: queries,
+			})
+		}
+	}
+	for _, p := range safeArray(db, "panels") {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if safeString(panel, "type") == "row" {
+			row = safeString(panel, "title")
+			// Collapsed rows hold their panels; expanded rows are followed by them.
+			for _, nested := range safeArray(panel, "panels") {
+				if nestedPanel, ok := nested.(map[string]interface{}); ok {
+					add(nestedPanel, row)
+				}
+			}
+			continue
+		}
+		add(panel, row)
+	}
+	return result, nil
+}
+
+// dashboardPanelQueries returns the targets of a panel which have a query.
+func dashboardPanelQueries(panel map[string]interface{}) []DashboardQuery {
+	panelDatasource := datasourceRefUID(panel["datasource"])
+	var queries []DashboardQuery
+	for _, t := range safeArray(panel, "targets") {
+		target, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		query := targetQuery(target)
+		if query == "" {
+			continue
+		}
+		datasource := datasourceRefUID(target["datasource"])
+		if datasource == "" {
+			datasource = panelDatasource
+		}
+		queries = append(queries, DashboardQuery{
+			RefID:         safeString(target, "refId"),
+			Query:         query,
+			DatasourceUID: datasource,
+			Legend:        safeString(target, "legendFormat"),
+			Hidden:        safeGet(target, "hide", false),
+		})
+	}
+	return queries
+}
+
+var ListDashboardQueries = mcpgrafana.MustTool(
+	"list_dashboard_queries",
+	"List every query on a dashboard, grouped by panel, to understand what a dashboard measures without fetching its full JSON. Each panel has its id, title, type, the row it is in and its queries; each query has its refId, query text (the PromQL 'expr', LogQL, SQL or other query of its datasource), datasource UID (which may be a template variable like '$datasource') and legend, and is marked hidden if it is disabled. Panels without queries, such as text panels and rows, are skipped, and panels in collapsed rows are included.",
+	listDashboardQueries,
+	mcp.WithTitleAnnotation("List dashboard queries"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+// GetDashboardPropertyParams defines parameters for getting specific dashboard properties
+type GetDashboardPropertyParams struct {
+	UID      string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+	JSONPath string `json:"jsonPath" jsonschema:"required,description=JSONPath expression to extract specific data (e.g.\\, '$.panels[0].title' for first panel title\\, '$.panels[*].title' for all panel titles\\, '$.templating.list' for variables)"`
+}
+
+// getDashboardProperty retrieves specific parts of a dashboard using JSONPath expressions.
+// This helps reduce context window usage by fetching only the needed data.
+func getDashboardProperty(ctx context.Context, args GetDashboardPropertyParams) (interface{}, error) {
+	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams{UID: args.UID})
+	if err != nil {
+		return nil, fmt.Errorf("get dashboard by uid: %w", err)
+	}
+
+	// Convert dashboard to JSON for JSONPath processing
+	dashboardJSON, err := json.Marshal(dashboard.Dashboard)
+	if err != nil {
+		return nil, fmt.Errorf("marshal dashboard to JSON: %w", err)
+	}
+
+	var dashboardData interface{}
+	if err := json.Unmarshal(dashboardJSON, &dashboardData); err != nil {
+		return nil, fmt.Errorf("unmarshal dashboard JSON: %w", err)
+	}
+
+	// Apply JSONPath expression
+	builder := gval.Full(jsonpath.Language())
+	path, err := builder.NewEvaluable(args.JSONPath)
+	if err != nil {
+		return nil, fmt.Errorf("create JSONPath evaluable '%s': %w", args.JSONPath, err)
+	}
+
+	result, err := path(ctx, dashboardData)
+	if err != nil {
+		return nil, fmt.Errorf("apply JSONPath '%s': %w", args.JSONPath, err)
+	}
+
+	return result, nil
+}
+
+var GetDashboardProperty = mcpgrafana.MustTool(
+	"get_dashboard_property",
+	"Get specific parts of a dashboard using JSONPath expressions to minimize context window usage. Common paths: '$.title' (title)\\, '$.panels[*].title' (all panel titles)\\, '$.panels[0]' (first panel)\\, '$.templating.list' (variables)\\, '$.tags' (tags)\\, '$.panels[*].targets[*].expr' (all queries). Use this instead of get_dashboard_by_uid when you only need specific dashboard properties.",
+	getDashboardProperty,
+	mcp.WithTitleAnnotation("Get dashboard property"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+// GetDashboardSummaryParams defines parameters for getting a dashboard summary
+type GetDashboardSummaryParams struct {
+	UID string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+}
+
+// DashboardSummary provides a compact overview of a dashboard without the full JSON
+type DashboardSummary struct {
+	UID         string                `json:"uid"`
+	Title       string                `json:"title"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	PanelCount  int                   `json:"panelCount"`
+	Panels      []PanelSummary        `json:"panels"`
+	Variables   []VariableSummary     `json:"variables,omitempty"`
+	TimeRange   TimeRangeSummary      `json:"timeRange"`
+	Refresh     string                `json:"refresh,omitempty"`
+	Meta        *models.DashboardMeta `json:"meta,omitempty"`
+}
+
+type PanelSummary struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	QueryCount  int    `json:"queryCount"`
+}
+
+type VariableSummary struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Label string `json:"label,omitempty"`
+}
+
+type TimeRangeSummary struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// getDashboardSummary provides a compact overview of a dashboard to help with context management
+func getDashboardSummary(ctx context.Context, args GetDashboardSummaryParams) (*DashboardSummary, error) {
+	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams{UID: args.UID})
+	if err != nil {
+		return nil, fmt.Errorf("get dashboard by uid: %w", err)
+	}
+
+	db, ok := dashboard.Dashboard.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dashboard is not a JSON object")
+	}
+
+	summary := &DashboardSummary{
+		UID:  args.UID,
+		Meta: dashboard.Meta,
+	}
+
+	// Extract basic info using helper functions
+	extractBasicDashboardInfo(db, summary)
+
+	// Extract time range
+	summary.TimeRange = extractTimeRange(db)
+
+	// Extract panel summaries
+	if panels := safeArray(db, "panels"); panels != nil {
+		summary.PanelCount = len(panels)
+		for _, p := range panels {
+			if panelObj, ok := p.(map[string]interface{}); ok {
+				summary.Panels = append(summary.Panels, extractPanelSummary(panelObj))
+			}
+		}
+	}
+
+	// Extract variable summaries
+	if templating := safeObject(db, "templating"); templating != nil {
+		if list := safeArray(templating, "list"); list != nil {
+			for _, v := range list {
+				if variable, ok := v.(map[string]interface{}); ok {
+					summary.Variables = append(summary.Variables, extractVariableSummary(variable))
+				}
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+var GetDashboardSummary = mcpgrafana.MustTool(
+	"get_dashboard_summary",
+	"Get a compact summary of a dashboard including title\\, panel count\\, panel types\\, variables\\, and other metadata without the full JSON. Use this for dashboard overview and planning modifications without consuming large context windows.",
+	getDashboardSummary,
+	mcp.WithTitleAnnotation("Get dashboard summary"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+// GetDashboardPanelParams defines parameters for getting a single dashboard panel
+type GetDashboardPanelParams struct {
+	UID     string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+	Title   string `json:"title,omitempty" jsonschema:"description=Case-insensitive substring of the panel title. All panels whose title contains it are returned"`
+	PanelID *int   `json:"panelId,omitempty" jsonschema:"description=The ID of the panel. Takes precedence over title when both are set"`
+}
+
+// getDashboardPanel returns the full JSON definition of the panels in a
+// dashboard matching the given ID or title, including panels nested in
+// collapsed rows.
+func getDashboardPanel(ctx context.Context, args GetDashboardPanelParams) ([]map[string]interface{}, error) {
+	if args.PanelID == nil && args.Title == "" {
+		return nil, fmt.Errorf("either panelId or title must be provided")
+	}
+
+	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams{UID: args.UID})
+	if err != nil {
+		return nil, fmt.Errorf("get dashboard by uid: %w", err)
+	}
+
+	db, ok := dashboard.Dashboard.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dashboard is not a JSON object")
+	}
+
+	title := strings.ToLower(args.Title)
+	matches := make([]map[string]interface{}, 0)
+	for _, panel := range collectPanels(safeArray(db, "panels")) {
+		if args.PanelID != nil {
+			if id, ok := panel["id"].(float64); ok && int(id) == *args.PanelID {
+				matches = append(matches, panel)
+			}
+			continue
+		}
+		if strings.Contains(strings.ToLower(safeString(panel, "title")), title) {
+			matches = append(matches, panel)
+		}
+	}
+
+	if len(matches) == 0 {
+		if args.PanelID != nil {
+			return nil, fmt.Errorf("no panel with id %d found in dashboard %s", *args.PanelID, args.UID)
+		}
+		return nil, fmt.Errorf("no panel with title matching '%s' found in dashboard %s", args.Title, args.UID)
+	}
+	return matches, nil
+}
+
+// collectPanels flattens a list of panels, including the panels nested inside
+// collapsed rows.
+func collectPanels(panels []interface{}) []map[string]interface{} {
+	var result []map[string]interface{}
+	for _, p := range panels {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		result = append(result, panel)
+		if nested := safeArray(panel, "panels"); nested != nil {
+			result = append(result, collectPanels(nested)...)
+		}
+	}
+	return result
+}
+
+var GetDashboardPanel = mcpgrafana.MustTool(
+	"get_dashboard_panel",
+	"Get the full JSON definition of a single dashboard panel, including its targets (queries) and datasource references, without fetching the whole dashboard. Look the panel up by 'panelId' or by a case-insensitive substring of its 'title'. Returns an array of matching panels; if several panels match the title, all of them are returned with their ids so you can pick the right one and refetch it by 'panelId'.",
+	getDashboardPanel,
+	mcp.WithTitleAnnotation("Get dashboard panel"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type GetDashboardVariablesParams struct {
+	UID string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+}
+
+// DashboardVariable describes a dashboard template variable and the values
+// it can take.
+type DashboardVariable struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Label string `json:"label,omitempty"`
+	// Current holds the currently selected values; several for multi-value
+	// variables.
+	Current    []string `json:"current"`
+	Multi      bool     `json:"multi,omitempty"`
+	IncludeAll bool     `json:"includeAll,omitempty"`
+	// Options are the values the variable can take, when they are static.
+	Options []string `json:"options,omitempty"`
+	// DynamicOptions is true for variables whose options are only known
+	// once Grafana resolves them, such as query variables.
+	DynamicOptions bool   `json:"dynamicOptions,omitempty"`
+	Datasource     string `json:"datasource,omitempty"`
+	Query          string `json:"query,omitempty"`
+}
+
+// getDashboardVariables returns the template variables of a dashboard.
+func getDashboardVariables(ctx context.Context, args GetDashboardVariablesParams) ([]DashboardVariable, error) {
+	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams{UID: args.UID})
+	if err != nil {
+		return nil, fmt.Errorf("get dashboard by uid: %w", err)
+	}
+
+	db, ok := dashboard.Dashboard.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dashboard is not a JSON object")
+	}
+
+	variables := []DashboardVariable{}
+	if templating := safeObject(db, "templating"); templating != nil {
+		for _, v := range safeArray(templating, "list") {
+			if variable, ok := v.(map[string]interface{}); ok {
+				variables = append(variables, extractDashboardVariable(variable))
+			}
+		}
+	}
+	return variables, nil
+}
+
+// extractDashboardVariable describes a variable from its templating JSON.
+// Options are only resolved for variables which define them statically.
+func extractDashboardVariable(variable map[string]interface{}) DashboardVariable {
+	result := DashboardVariable{
+		Name:       safeString(variable, "name"),
+		Type:       safeString(variable, "type"),
+		Label:      safeString(variable, "label"),
+		Current:    []string{},
+		Multi:      safeGet(variable, "multi", false),
+		IncludeAll: safeGet(variable, "includeAll", false),
 	}
 
-	var dashboardData interface{}
-	if err := json.Unmarshal(dashboardJSON, &dashboardData); err != nil {
-		return nil, fmt.Errorf("unmarshal dashboard JSON: %w", err)
+	if current := safeObject(variable, "current"); current != nil {
+		switch value := current["value"].(type) {
+		case string:
+			result.Current = []string{value}
+		case []interface{}:
+			for _, v := range value {
+				if str, ok := v.(string); ok {
+					result.Current = append(result.Current, str)
+				}
+			}
+		}
 	}
 
-	// Apply JSONPath expression
-	builder := gval.Full(jsonpath.Language())
-	path, err := builder.NewEvaluable(args.JSONPath)
-	if err != nil {
-		return nil, fmt.Errorf("create JSONPath evaluable '%s': %w", args.JSONPath, err)
+	query := variableQuery(variable)
+	switch result.Type {
+	case "query", "datasource":
+		result.DynamicOptions = true
+		result.Datasource = datasourceRefUID(variable["datasource"])
+		result.Query = query
+	case "custom", "interval":
+		result.Options = variableOptions(variable, query)
 	}
+	return result
+}
 
-	result, err := path(ctx, dashboardData)
-	if err != nil {
-		return nil, fmt.Errorf("apply JSONPath '%s': %w", args.JSONPath, err)
+// variableQuery returns the query of a variable, which is either a string or,
+// for some datasources, an object holding the query.
+func variableQuery(variable map[string]interface{}) string {
+	switch query := variable["query"].(type) {
+	case string:
+		return query
+	case map[string]interface{}:
+		return safeString(query, "query")
 	}
+	return ""
+}
 
-	return result, nil
+// variableOptions returns the values of a static variable's options, falling
+// back to parsing its comma separated query when the options aren't saved.
+func variableOptions(variable map[string]interface{}, query string) []string {
+	var options []string
+	for _, o := range safeArray(variable, "options") {
+		if option, ok := o.(map[string]interface{}); ok {
+			if value, ok := option["value"].(string); ok {
+				options = append(options, value)
+			}
+		}
+	}
+	if len(options) > 0 {
+		return options
+	}
+	for _, part := range strings.Split(query, ",") {
+		// Custom variables can give options as "text : value".
+		if _, value, ok := strings.Cut(part, " : "); ok {
+			part = value
+		}
+		if part = strings.TrimSpace(part); part != "" {
+			options = append(options, part)
+		}
+	}
+	return options
 }
 
-var GetDashboardProperty = mcpgrafana.MustTool(
-	"get_dashboard_property",
-	"Get specific parts of a dashboard using JSONPath expressions to minimize context window usage. Common paths: '$.title' (title)\\, '$.panels[*].title' (all panel titles)\\, '$.panels[0]' (first panel)\\, '$.templating.list' (variables)\\, '$.tags' (tags)\\, '$.panels[*].targets[*].expr' (all queries). Use this instead of get_dashboard_by_uid when you only need specific dashboard properties.",
-	getDashboardProperty,
-	mcp.WithTitleAnnotation("Get dashboard property"),
+var GetDashboardVariables = mcpgrafana.MustTool(
+	"get_dashboard_variables",
+	"Get the template variables of a dashboard, including each variable's name, type, and currently selected values. For custom and interval variables the valid options are returned. Query variables have options resolved dynamically by Grafana, so their datasource and query are returned instead; use resolve_dashboard_variable_options to resolve them. Use this to pick valid values when building dashboard links with variables.",
+	getDashboardVariables,
+	mcp.WithTitleAnnotation("Get dashboard variables"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
-// GetDashboardSummaryParams defines parameters for getting a dashboard summary
-type GetDashboardSummaryParams struct {
-	UID string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
-}
+const (
+	defaultVariableOptionsLimit = 100
+	maxVariableOptionsLimit     = 1000
+)
 
-// DashboardSummary provides a compact overview of a dashboard without the full JSON
-type DashboardSummary struct {
-	UID         string                `json:"uid"`
-	Title       string                `json:"title"`
-	Description string                `json:"description,omitempty"`
-	Tags        []string              `json:"tags,omitempty"`
-	PanelCount  int                   `json:"panelCount"`
-	Panels      []PanelSummary        `json:"panels"`
-	Variables   []VariableSummary     `json:"variables,omitempty"`
-	TimeRange   TimeRangeSummary      `json:"timeRange"`
-	Refresh     string                `json:"refresh,omitempty"`
-	Meta        *models.DashboardMeta `json:"meta,omitempty"`
+type ResolveDashboardVariableOptionsParams struct {
+	UID       string            `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+	Variable  string            `json:"variable" jsonschema:"required,description=The name of the variable to resolve the options of"`
+	Variables map[string]string `json:"variables,omitempty" jsonschema:"description=Optionally\\, values of other variables referenced by the variable's query or datasource\\, by name. Variables not given use their current value"`
+	Limit     int               `json:"limit,omitempty" jsonschema:"default=100,description=Optionally\\, the maximum number of options to return (max: 1000)"`
 }
 
-type PanelSummary struct {
-	ID          int    `json:"id"`
-	Title       string `json:"title"`
-	Type        string `json:"type"`
-	Description string `json:"description,omitempty"`
-	QueryCount  int    `json:"queryCount"`
+// ResolvedVariableOptions are the options of a dashboard variable, as
+// resolved against its datasource. Total is the number of options before
+// the limit was applied.
+type ResolvedVariableOptions struct {
+	Name           string   `json:"name"`
+	Type           string   `json:"type"`
+	DatasourceUID  string   `json:"datasourceUid,omitempty"`
+	DatasourceType string   `json:"datasourceType,omitempty"`
+	Query          string   `json:"query,omitempty"`
+	Options        []string `json:"options"`
+	Total          int      `json:"total"`
 }
 
-type VariableSummary struct {
-	Name  string `json:"name"`
-	Type  string `json:"type"`
-	Label string `json:"label,omitempty"`
-}
+var (
+	// The variable query functions of the Prometheus datasource.
+	promLabelValuesQuery = regexp.MustCompile(`^\s*label_values\(\s*(?:(.+?)\s*,\s*)?([a-zA-Z_][a-zA-Z0-9_.]*)\s*\)\s*$`)
+	promLabelNamesQuery  = regexp.MustCompile(`^\s*label_names\(\s*(.*?)\s*\)\s*$`)
+	promMetricsQuery     = regexp.MustCompile(`^\s*metrics\(\s*(.*?)\s*\)\s*$`)
+	promQueryResultQuery = regexp.MustCompile(`^\s*query_result\(\s*(.+)\s*\)\s*$`)
 
-type TimeRangeSummary struct {
-	From string `json:"from"`
-	To   string `json:"to"`
-}
+	// variableReference matches the $name, ${name} and [[name]] references
+	// to variables in a query. The format of ${name:format} is ignored.
+	variableReference = regexp.MustCompile(`\$(\w+)|\$\{(\w+)(?::[^}]*)?\}|\[\[(\w+)\]\]`)
+)
 
-// getDashboardSummary provides a compact overview of a dashboard to help with context management
-func getDashboardSummary(ctx context.Context, args GetDashboardSummaryParams) (*DashboardSummary, error) {
-	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams(args))
+// resolveDashboardVariableOptions runs a dashboard variable's query against
+// its datasource and returns the options it resolves to.
+func resolveDashboardVariableOptions(ctx context.Context, args ResolveDashboardVariableOptionsParams) (*ResolvedVariableOptions, error) {
+	dashboard, err := getDashboardByUID(ctx, GetDashboardByUIDParams{UID: args.UID})
 	if err != nil {
 		return nil, fmt.Errorf("get dashboard by uid: %w", err)
 	}
-
 	db, ok := dashboard.Dashboard.(map[string]interface{})
 	if !ok {
 		return nil, fmt.Errorf("dashboard is not a JSON object")
 	}
 
-	summary := &DashboardSummary{
-		UID:  args.UID,
-		Meta: dashboard.Meta,
+	var variable map[string]interface{}
+	var names []string
+	values := map[string]string{}
+	for _, v := range safeArray(safeObject(db, "templating"), "list") {
+		vm, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := safeString(vm, "name")
+		names = append(names, name)
+		if name == args.Variable {
+			variable = vm
+		}
+		values[name] = variableInterpolationValue(vm)
+	}
+	maps.Copy(values, args.Variables)
+	if variable == nil {
+		return nil, fmt.Errorf("variable %q not found in dashboard %s, expected one of: %s", args.Variable, args.UID, strings.Join(names, ", "))
 	}
 
-	// Extract basic info using helper functions
-	extractBasicDashboardInfo(db, summary)
+	result := &ResolvedVariableOptions{
+		Name: args.Variable,
+		Type: safeString(variable, "type"),
+	}
+	var options []string
+	switch result.Type {
+	case "custom", "interval":
+		options = variableOptions(variable, variableQuery(variable))
+	case "query":
+		ds, err := variableDatasource(ctx, variable["datasource"], values)
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %w", args.Variable, err)
+		}
+		result.DatasourceUID = ds.UID
+		result.DatasourceType = ds.Type
+		switch ds.Type {
+		case "prometheus":
+			result.Query = interpolateVariables(variableQuery(variable), values)
+			options, err = resolvePrometheusVariableQuery(ctx, ds.UID, result.Query)
+		case "loki":
+			result.Query, options, err = resolveLokiVariableQuery(ctx, ds.UID, variable["query"], values)
+		default:
+			return nil, fmt.Errorf("variable %q: resolving the options of %s datasources is not supported, only prometheus and loki", args.Variable, ds.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %w", args.Variable, err)
+		}
+	default:
+		return nil, fmt.Errorf("variable %q is a %s variable, only query, custom and interval variables have options", args.Variable, result.Type)
+	}
 
-	// Extract time range
-	summary.TimeRange = extractTimeRange(db)
+	options, err = filterVariableOptions(options, safeString(variable, "regex"))
+	if err != nil {
+		return nil, fmt.Errorf("variable %q: %w", args.Variable, err)
+	}
+	result.Total = len(options)
+	limit := clampLogLines(args.Limit, defaultVariableOptionsLimit, maxVariableOptionsLimit)
+	if len(options) > limit {
+		options = options[:limit]
+	}
+	result.Options = options
+	return result, nil
+}
 
-	// Extract panel summaries
-	if panels := safeArray(db, "panels"); panels != nil {
-		summary.PanelCount = len(panels)
-		for _, p := range panels {
-			if panelObj, ok := p.(map[string]interface{}); ok {
-				summary.Panels = append(summary.Panels, extractPanelSummary(panelObj))
+// variableInterpolationValue returns the value a variable is replaced with
+// in queries: its current value, a regex alternation of its values for
+// multi-value variables, or its all value when "All" is selected.
+func variableInterpolationValue(variable map[string]interface{}) string {
+	current := extractDashboardVariable(variable).Current
+	switch {
+	case len(current) == 1 && current[0] == "$__all":
+		if allValue := safeString(variable, "allValue"); allValue != "" {
+			return allValue
+		}
+		return ".*"
+	case len(current) == 1:
+		return current[0]
+	case len(current) > 1:
+		quoted := make([]string, len(current))
+		for i, v := range current {
+			quoted[i] = regexp.QuoteMeta(v)
+		}
+		return "(" + strings.Join(quoted, "|") + ")"
+	}
+	return ""
+}
+
+// interpolateVariables replaces the references to variables in s with their
+// values. References to unknown variables, such as Grafana's built in
+// $__interval, are left as they are.
+func interpolateVariables(s string, values map[string]string) string {
+	return variableReference.ReplaceAllStringFunc(s, func(ref string) string {
+		m := variableReference.FindStringSubmatch(ref)
+		name := m[1] + m[2] + m[3]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// variableDatasource looks up the datasource of a query variable, which may
+// itself be given by a datasource variable. Variables without a datasource
+// use the default datasource.
+func variableDatasource(ctx context.Context, ref interface{}, values map[string]string) (*models.DataSource, error) {
+	uid := interpolateVariables(datasourceRefUID(ref), values)
+	if uid != "" {
+		return datasourceInfo(ctx, uid)
+	}
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	resp, err := c.Datasources.GetDataSourcesWithParams(datasources.NewGetDataSourcesParamsWithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list datasources: %w", err)
+	}
+	for _, ds := range resp.Payload {
+		if ds.IsDefault {
+			return datasourceInfo(ctx, ds.UID)
+		}
+	}
+	return nil, fmt.Errorf("no datasource set and there is no default datasource")
+}
+
+// resolvePrometheusVariableQuery runs one of the Prometheus variable query
+// functions: label_values, label_names, metrics or query_result.
+func resolvePrometheusVariableQuery(ctx context.Context, uid, query string) ([]string, error) {
+	promClient, err := promClientFromContext(ctx, uid, "")
+	if err != nil {
+		return nil, fmt.Errorf("getting Prometheus client: %w", err)
+	}
+
+	var matchers []string
+	switch {
+	case promLabelValuesQuery.MatchString(query):
+		m := promLabelValuesQuery.FindStringSubmatch(query)
+		if m[1] != "" {
+			matchers = []string{m[1]}
+		}
+		labelValues, _, err := promClient.LabelValues(ctx, m[2], matchers, time.Time{}, time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("listing Prometheus label values: %w", err)
+		}
+		options := make([]string, len(labelValues))
+		for i, v := range labelValues {
+			options[i] = string(v)
+		}
+		return options, nil
+
+	case promLabelNamesQuery.MatchString(query):
+		if m := promLabelNamesQuery.FindStringSubmatch(query); m[1] != "" {
+			matchers = []string{m[1]}
+		}
+		labelNames, _, err := promClient.LabelNames(ctx, matchers, time.Time{}, time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("listing Prometheus label names: %w", err)
+		}
+		return labelNames, nil
+
+	case promMetricsQuery.MatchString(query):
+		re, err := regexp.Compile(promMetricsQuery.FindStringSubmatch(query)[1])
+		if err != nil {
+			return nil, fmt.Errorf("compiling metrics regex: %w", err)
+		}
+		names, _, err := promClient.LabelValues(ctx, "__name__", nil, time.Time{}, time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("listing Prometheus metric names: %w", err)
+		}
+		var options []string
+		for _, name := range names {
+			if re.MatchString(string(name)) {
+				options = append(options, string(name))
 			}
 		}
+		return options, nil
+
+	case promQueryResultQuery.MatchString(query):
+		value, _, err := promClient.Query(ctx, promQueryResultQuery.FindStringSubmatch(query)[1], time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("querying Prometheus: %w", err)
+		}
+		vector, ok := value.(model.Vector)
+		if !ok {
+			return nil, fmt.Errorf("query_result expects an instant vector, got %s", value.Type())
+		}
+		options := make([]string, len(vector))
+		for i, sample := range vector {
+			options[i] = fmt.Sprintf("%s %s %d", sample.Metric, sample.Value, sample.Timestamp)
+		}
+		return options, nil
 	}
+	return nil, fmt.Errorf("unsupported Prometheus variable query %q, expected label_values(), label_names(), metrics() or query_result()", query)
+}
 
-	// Extract variable summaries
-	if templating := safeObject(db, "templating"); templating != nil {
-		if list := safeArray(templating, "list"); list != nil {
-			for _, v := range list {
-				if variable, ok := v.(map[string]interface{}); ok {
-					summary.Variables = append(summary.Variables, extractVariableSummary(variable))
-				}
+// resolveLokiVariableQuery runs a Loki variable query. It is either an
+// object with the type (0 for label names, 1 for label values), label and
+// stream selector, or a label_names() or label_values() query string. It
+// returns the query as run along with the options.
+func resolveLokiVariableQuery(ctx context.Context, uid string, query interface{}, values map[string]string) (string, []string, error) {
+	var label, stream string
+	labelValues := false
+	switch q := query.(type) {
+	case map[string]interface{}:
+		if _, ok := q["type"]; !ok {
+			return resolveLokiVariableQuery(ctx, uid, safeString(q, "query"), values)
+		}
+		labelValues = safeGet(q, "type", float64(0)) == 1
+		label = interpolateVariables(safeString(q, "label"), values)
+		stream = interpolateVariables(safeString(q, "stream"), values)
+	case string:
+		q = interpolateVariables(q, values)
+		switch {
+		case promLabelValuesQuery.MatchString(q):
+			m := promLabelValuesQuery.FindStringSubmatch(q)
+			labelValues, stream, label = true, m[1], m[2]
+		case promLabelNamesQuery.MatchString(q):
+		default:
+			return "", nil, fmt.Errorf("unsupported Loki variable query %q, expected label_names() or label_values()", q)
+		}
+	default:
+		return "", nil, fmt.Errorf("variable has no query")
+	}
+
+	if !labelValues {
+		options, err := listLokiLabelNames(ctx, ListLokiLabelNamesParams{DatasourceUID: uid})
+		return "label_names()", options, err
+	}
+	if label == "" {
+		return "", nil, fmt.Errorf("label values query without a label")
+	}
+	executed := fmt.Sprintf("label_values(%s)", label)
+	if stream != "" {
+		executed = fmt.Sprintf("label_values(%s, %s)", stream, label)
+	}
+	options, err := listLokiLabelValues(ctx, ListLokiLabelValuesParams{DatasourceUID: uid, LabelName: label, Query: stream})
+	return executed, options, err
+}
+
+// filterVariableOptions applies a variable's regex, given as /pattern/ with
+// optional flags, to its options. Options not matching are dropped; if the
+// regex has a capture group, the option becomes the captured text, or the
+// text captured by the group named "value". Duplicates are removed.
+func filterVariableOptions(options []string, pattern string) ([]string, error) {
+	if pattern == "" {
+		return options, nil
+	}
+	if strings.HasPrefix(pattern, "/") {
+		if end := strings.LastIndex(pattern, "/"); end > 0 {
+			flags := pattern[end+1:]
+			pattern = pattern[1:end]
+			if strings.Contains(flags, "i") {
+				pattern = "(?i)" + pattern
 			}
 		}
 	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid variable regex: %w", err)
+	}
 
-	return summary, nil
+	seen := map[string]bool{}
+	filtered := []string{}
+	for _, option := range options {
+		m := re.FindStringSubmatch(option)
+		if m == nil {
+			continue
+		}
+		value := option
+		if i := re.SubexpIndex("value"); i > 0 {
+			value = m[i]
+		} else if len(m) > 1 {
+			value = m[1]
+		}
+		if !seen[value] {
+			seen[value] = true
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered, nil
 }
 
-var GetDashboardSummary = mcpgrafana.MustTool(
-	"get_dashboard_summary",
-	"Get a compact summary of a dashboard including title\\, panel count\\, panel types\\, variables\\, and other metadata without the full JSON. Use this for dashboard overview and planning modifications without consuming large context windows.",
-	getDashboardSummary,
-	mcp.WithTitleAnnotation("Get dashboard summary"),
+var ResolveDashboardVariableOptions = mcpgrafana.MustTool(
+	"resolve_dashboard_variable_options",
+	"Resolve the options of a dashboard variable by running its query against its datasource, as Grafana does when the dashboard is loaded. Supports Prometheus label_values(), label_names(), metrics() and query_result() queries and Loki label name and label value queries. References to other variables in the query use their current values unless given in variables. The variable's regex is applied. Custom and interval variables return their static options. Use this to find valid values of query variables, which get_dashboard_variables can't list.",
+	resolveDashboardVariableOptions,
+	mcp.WithTitleAnnotation("Resolve dashboard variable options"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
 )
@@ -644,12 +2199,274 @@ func extractVariableSummary(variable map[string]interface{}) VariableSummary {
 	}
 }
 
+const DefaultListLibraryPanelsLimit = 100
+
+// libraryElementKinds maps the kinds of library element list_library_panels
+// can filter by to Grafana's kind numbers.
+var libraryElementKinds = map[string]int64{"panel": 1, "variable": 2}
+
+type ListLibraryPanelsParams struct {
+	Query        string `json:"query,omitempty" jsonschema:"description=Optionally\\, only return library panels whose name or description contains this string"`
+	FolderUID    string `json:"folderUid,omitempty" jsonschema:"description=Optionally\\, only return library panels in the folder with this UID. Use 'general' for the General folder"`
+	Kind         string `json:"kind,omitempty" jsonschema:"enum=panel,enum=variable,default=panel,description=The kind of library element to return: 'panel' or 'variable'"`
+	Type         string `json:"type,omitempty" jsonschema:"description=Optionally\\, only return library panels of this panel type\\, such as 'timeseries' or 'stat'"`
+	IncludeModel bool   `json:"includeModel,omitempty" jsonschema:"description=Include the JSON model of each library panel. Models can be large\\, so prefer get_library_panel for the panels you need"`
+	Limit        int    `json:"limit,omitempty" jsonschema:"default=100,description=The maximum number of library panels to return"`
+	Page         int    `json:"page,omitempty" jsonschema:"default=1,description=The page number to return"`
+}
+
+func (p ListLibraryPanelsParams) validate() error {
+	if p.Limit < 0 {
+		return fmt.Errorf("invalid limit: %d, must be greater than 0", p.Limit)
+	}
+	if p.Page < 0 {
+		return fmt.Errorf("invalid page: %d, must be greater than 0", p.Page)
+	}
+	if _, ok := libraryElementKinds[p.Kind]; p.Kind != "" && !ok {
+		return fmt.Errorf("invalid kind: %q, must be one of: panel, variable", p.Kind)
+	}
+	return nil
+}
+
+// libraryPanel is a library element, as returned by the library panel tools.
+type libraryPanel struct {
+	UID                 string `json:"uid"`
+	Name                string `json:"name"`
+	Kind                string `json:"kind"`
+	Type                string `json:"type,omitempty"`
+	Description         string `json:"description,omitempty"`
+	FolderUID           string `json:"folderUid,omitempty"`
+	FolderName          string `json:"folderName,omitempty"`
+	ConnectedDashboards int64  `json:"connectedDashboards"`
+	Version             int64  `json:"version"`
+	Model               any    `json:"model,omitempty"`
+}
+
+// libraryPanelList is a single page of library panels.
+type libraryPanelList struct {
+	LibraryPanels []libraryPanel `json:"libraryPanels"`
+	// Total is the number of library panels matching the filters, across
+	// all pages.
+	Total int64 `json:"total"`
+	// HasMore is true if there are further pages of results.
+	HasMore bool `json:"hasMore"`
+}
+
+func toLibraryPanel(element *models.LibraryElementDTO, includeModel bool) libraryPanel {
+	panel := libraryPanel{
+		UID:         element.UID,
+		Name:        element.Name,
+		Type:        element.Type,
+		Description: element.Description,
+		FolderUID:   element.FolderUID,
+		Version:     element.Version,
+	}
+	for kind, n := range libraryElementKinds {
+		if n == element.Kind {
+			panel.Kind = kind
+		}
+	}
+	if element.Meta != nil {
+		panel.FolderName = element.Meta.FolderName
+		panel.ConnectedDashboards = element.Meta.ConnectedDashboards
+	}
+	if includeModel {
+		panel.Model = element.Model
+	}
+	return panel
+}
+
+func listLibraryPanels(ctx context.Context, args ListLibraryPanelsParams) (*libraryPanelList, error) {
+	if err := args.validate(); err != nil {
+		return nil, fmt.Errorf("list library panels: %w", err)
+	}
+	limit := int64(args.Limit)
+	if limit == 0 {
+		limit = DefaultListLibraryPanelsLimit
+	}
+	page := int64(max(args.Page, 1))
+	kind := libraryElementKinds["panel"]
+	if args.Kind != "" {
+		kind = libraryElementKinds[args.Kind]
+	}
+
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	params := library_elements.NewGetLibraryElementsParamsWithContext(ctx).
+		WithKind(&kind).
+		WithPerPage(&limit).
+		WithPage(&page)
+	if args.Query != "" {
+		params.SetSearchString(&args.Query)
+	}
+	if args.Type != "" {
+		params.SetTypeFilter(&args.Type)
+	}
+	if args.FolderUID != "" {
+		// The library elements API filters by folder ID, the General folder
+		// being 0.
+		folderID := "0"
+		if args.FolderUID != "general" {
+			folder, err := c.Folders.GetFolderByUIDWithParams(folders.NewGetFolderByUIDParamsWithContext(ctx).WithFolderUID(args.FolderUID))
+			if err != nil {
+				return nil, fmt.Errorf("list library panels: get folder %s: %w", args.FolderUID, err)
+			}
+			folderID = strconv.FormatInt(folder.Payload.ID, 10)
+		}
+		params.SetFolderFilter(&folderID)
+	}
+
+	resp, err := c.LibraryElements.GetLibraryElements(params)
+	if err != nil {
+		return nil, fmt.Errorf("list library panels: %w", err)
+	}
+	result := &libraryPanelList{LibraryPanels: []libraryPanel{}}
+	if resp.Payload.Result == nil {
+		return result, nil
+	}
+	for _, element := range resp.Payload.Result.Elements {
+		result.LibraryPanels = append(result.LibraryPanels, toLibraryPanel(element, args.IncludeModel))
+	}
+	result.Total = resp.Payload.Result.TotalCount
+	result.HasMore = page*limit < result.Total
+	return result, nil
+}
+
+var ListLibraryPanels = mcpgrafana.MustTool(
+	"list_library_panels",
+	"Lists Grafana library panels, the panels shared between dashboards, returning the UID, name, panel type, folder and number of connected dashboards of each. Optionally filter by name or description, folder UID, kind ('panel', the default, or 'variable') and panel type. Supports pagination (default limit 100); the response includes the total number of matching library panels and a hasMore flag. Use get_library_panel to fetch the JSON model of a library panel.",
+	listLibraryPanels,
+	mcp.WithTitleAnnotation("List library panels"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type GetLibraryPanelParams struct {
+	UID string `json:"uid" jsonschema:"required,description=The UID of the library panel"`
+}
+
+func getLibraryPanel(ctx context.Context, args GetLibraryPanelParams) (*libraryPanel, error) {
+	if args.UID == "" {
+		return nil, fmt.Errorf("get library panel: uid is required")
+	}
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	resp, err := c.LibraryElements.GetLibraryElementByUIDWithParams(library_elements.NewGetLibraryElementByUIDParamsWithContext(ctx).WithLibraryElementUID(args.UID))
+	if err != nil {
+		return nil, fmt.Errorf("get library panel %s: %w", args.UID, err)
+	}
+	if resp.Payload.Result == nil {
+		return nil, fmt.Errorf("get library panel %s: empty response", args.UID)
+	}
+	panel := toLibraryPanel(resp.Payload.Result, true)
+	return &panel, nil
+}
+
+var GetLibraryPanel = mcpgrafana.MustTool(
+	"get_library_panel",
+	"Retrieves a Grafana library panel by its UID, returning its name, panel type, folder, number of connected dashboards and its full JSON model, as used in the dashboards it is connected to.",
+	getLibraryPanel,
+	mcp.WithTitleAnnotation("Get library panel"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type DashboardStarParams struct {
+	UID string `json:"uid" jsonschema:"required,description=The UID of the dashboard"`
+}
+
+// DashboardStarResult reports whether a dashboard is starred, and for which
+// user if a user is forwarded to Grafana. Otherwise the star belongs to the
+// user or service account the server authenticates as.
+type DashboardStarResult struct {
+	UID     string `json:"uid"`
+	Starred bool   `json:"starred"`
+	User    string `json:"user,omitempty"`
+}
+
+func starDashboard(ctx context.Context, args DashboardStarParams) (*DashboardStarResult, error) {
+	if args.UID == "" {
+		return nil, fmt.Errorf("star dashboard: uid is required")
+	}
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	if _, err := c.SignedInUser.StarDashboardByUIDWithParams(signed_in_user.NewStarDashboardByUIDParamsWithContext(ctx).WithDashboardUID(args.UID)); err != nil {
+		return nil, fmt.Errorf("star dashboard %s: %w", args.UID, err)
+	}
+	return &DashboardStarResult{UID: args.UID, Starred: true, User: mcpgrafana.ForwardedUser(mcpgrafana.GrafanaConfigFromContext(ctx))}, nil
+}
+
+func unstarDashboard(ctx context.Context, args DashboardStarParams) (*DashboardStarResult, error) {
+	if args.UID == "" {
+		return nil, fmt.Errorf("unstar dashboard: uid is required")
+	}
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	if _, err := c.SignedInUser.UnstarDashboardByUIDWithParams(signed_in_user.NewUnstarDashboardByUIDParamsWithContext(ctx).WithDashboardUID(args.UID)); err != nil {
+		return nil, fmt.Errorf("unstar dashboard %s: %w", args.UID, err)
+	}
+	return &DashboardStarResult{UID: args.UID, Starred: false, User: mcpgrafana.ForwardedUser(mcpgrafana.GrafanaConfigFromContext(ctx))}, nil
+}
+
+type ListStarredDashboardsParams struct {
+	Query string `json:"query,omitempty" jsonschema:"description=Optionally\\, only return starred dashboards whose title matches this query"`
+}
+
+func listStarredDashboards(ctx context.Context, args ListStarredDashboardsParams) (models.HitList, error) {
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	starred := true
+	params := search.NewSearchParamsWithContext(ctx).WithStarred(&starred).WithType(&dashboardTypeStr)
+	if args.Query != "" {
+		params.SetQuery(&args.Query)
+	}
+	resp, err := c.Search.Search(params)
+	if err != nil {
+		return nil, fmt.Errorf("list starred dashboards: %w", err)
+	}
+	return resp.Payload, nil
+}
+
+var StarDashboard = mcpgrafana.MustMutatingTool(
+	"star_dashboard",
+	"Star a dashboard by UID, adding it to the favorites of the current user, to bookmark a dashboard found relevant during an investigation. Stars belong to the user forwarded to Grafana, if any, or else to the user or service account the server authenticates as.",
+	starDashboard,
+	mcp.WithTitleAnnotation("Star dashboard"),
+	mcp.WithDestructiveHintAnnotation(false),
+)
+
+var UnstarDashboard = mcpgrafana.MustMutatingTool(
+	"unstar_dashboard",
+	"Unstar a dashboard by UID, removing it from the favorites of the current user.",
+	unstarDashboard,
+	mcp.WithTitleAnnotation("Unstar dashboard"),
+	mcp.WithDestructiveHintAnnotation(false),
+)
+
+var ListStarredDashboards = mcpgrafana.MustTool(
+	"list_starred_dashboards",
+	"List the dashboards starred by the current user, optionally filtered by title. Returns each dashboard's title, UID, folder, tags and URL.",
+	listStarredDashboards,
+	mcp.WithTitleAnnotation("List starred dashboards"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
 func AddDashboardTools(mcp *server.MCPServer, enableWriteTools bool) {
 	GetDashboardByUID.Register(mcp)
 	if enableWriteTools {
 		UpdateDashboard.Register(mcp)
+		UpdateDashboardPanelQuery.Register(mcp)
+		CreateDashboard.Register(mcp)
+		ImportDashboard.Register(mcp)
+		StarDashboard.Register(mcp)
+		UnstarDashboard.Register(mcp)
 	}
 	GetDashboardPanelQueries.Register(mcp)
+	ListDashboardQueries.Register(mcp)
 	GetDashboardProperty.Register(mcp)
 	GetDashboardSummary.Register(mcp)
+	GetDashboardPanel.Register(mcp)
+	GetDashboardVariables.Register(mcp)
+	ResolveDashboardVariableOptions.Register(mcp)
+	ExportDashboard.Register(mcp)
+	DiffDashboardVersions.Register(mcp)
+	ListLibraryPanels.Register(mcp)
+	GetLibraryPanel.Register(mcp)
+	ListStarredDashboards.Register(mcp)
 }
@@ -0,0 +1,1189 @@
+//go:build unit
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+// panelFixtureDashboard has two panels titled "Request rate" (one nested in a
+// collapsed row) so that title lookups are ambiguous.
+const panelFixtureDashboard = `{
+	"dashboard": {
+		"uid": "panels",
+		"title": "Panels",
+		"panels": [
+			{
+				"id": 1,
+				"title": "Request rate",
+				"type": "timeseries",
+				"datasource": {"type": "prometheus", "uid": "prom-uid"},
+				"targets": [{"refId": "A", "expr": "sum(rate(http_requests_total[5m]))"}]
+			},
+			{
+				"id": 2,
+				"title": "Error logs",
+				"type": "logs",
+				"datasource": {"type": "loki", "uid": "loki-uid"},
+				"targets": [{"refId": "A", "expr": "{app=\"api\"} |= \"error\""}]
+			},
+			{
+				"id": 3,
+				"title": "Backend",
+				"type": "row",
+				"collapsed": true,
+				"panels": [
+					{
+						"id": 4,
+						"title": "Backend request rate",
+						"type": "timeseries",
+						"datasource": {"type": "prometheus", "uid": "prom-uid"},
+						"targets": [{"refId": "A", "expr": "sum(rate(backend_requests_total[5m]))"}]
+					}
+				]
+			}
+		]
+	},
+	"meta": {"folderUid": "folder"}
+}`
+
+// rowsFixtureDashboard has a top-level panel, an expanded row followed by its
+// panels, and a collapsed row holding its panels.
+const rowsFixtureDashboard = `{
+	"dashboard": {
+		"uid": "rows",
+		"title": "Rows",
+		"tags": ["team-a", "prod"],
+		"panels": [
+			{
+				"id": 1,
+				"title": "Overview",
+				"type": "stat",
+				"datasource": {"type": "prometheus", "uid": "prom-uid"},
+				"targets": [{"refId": "A", "expr": "up"}],
+				"fieldConfig": {"defaults": {"unit": "short"}}
+			},
+			{"id": 2, "title": "Frontend", "type": "row", "collapsed": false, "panels": []},
+			{
+				"id": 3,
+				"title": "Frontend latency",
+				"type": "timeseries",
+				"targets": [{"refId": "A", "datasource": {"type": "prometheus", "uid": "prom-frontend"}, "expr": "histogram_quantile(0.99, rate(latency_bucket[5m]))"}],
+				"transformations": [{"id": "reduce"}]
+			},
+			{
+				"id": 4,
+				"title": "Backend",
+				"type": "row",
+				"collapsed": true,
+				"panels": [
+					{
+						"id": 5,
+						"title": "Backend logs",
+						"type": "logs",
+						"datasource": {"type": "loki", "uid": "loki-uid"},
+						"targets": [{"refId": "A", "expr": "{app=\"backend\"}"}]
+					},
+					{"id": 6, "title": "Legacy", "type": "graph", "datasource": "Old Prometheus"}
+				]
+			}
+		]
+	},
+	"meta": {"folderUid": "folder-uid", "folderTitle": "Team A"}
+}`
+
+// variablesFixtureDashboard has custom, query and interval variables.
+const variablesFixtureDashboard = `{
+	"dashboard": {
+		"uid": "variables",
+		"title": "Variables",
+		"templating": {
+			"list": [
+				{
+					"name": "env",
+					"label": "Environment",
+					"type": "custom",
+					"query": "prod,staging,dev",
+					"multi": true,
+					"current": {"text": ["prod", "staging"], "value": ["prod", "staging"]},
+					"options": [
+						{"text": "prod", "value": "prod", "selected": true},
+						{"text": "staging", "value": "staging", "selected": true},
+						{"text": "dev", "value": "dev", "selected": false}
+					]
+				},
+				{
+					"name": "region",
+					"type": "custom",
+					"query": "Europe : eu-west-1, America : us-east-1",
+					"current": {"text": "Europe", "value": "eu-west-1"}
+				},
+				{
+					"name": "job",
+					"type": "query",
+					"datasource": {"type": "prometheus", "uid": "prom-uid"},
+					"query": {"query": "label_values(up, job)", "refId": "PrometheusVariableQueryEditor-VariableQuery"},
+					"includeAll": true,
+					"current": {"text": "All", "value": "$__all"},
+					"options": []
+				},
+				{
+					"name": "interval",
+					"type": "interval",
+					"query": "1m,5m,10m,1h",
+					"current": {"text": "5m", "value": "5m"}
+				}
+			]
+		}
+	},
+	"meta": {"slug": "variables"}
+}`
+
+// queriesFixtureDashboard mixes Prometheus and Loki panels with text panels
+// and a collapsed row.
+const queriesFixtureDashboard = `{
+	"dashboard": {
+		"uid": "queries",
+		"title": "Queries",
+		"panels": [
+			{"id": 1, "title": "About", "type": "text", "options": {"content": "# Service overview"}},
+			{
+				"id": 2,
+				"title": "Request rate",
+				"type": "timeseries",
+				"datasource": {"type": "prometheus", "uid": "prom-uid"},
+				"targets": [
+					{"refId": "A", "expr": "sum by (status) (rate(http_requests_total[5m]))", "legendFormat": "{{status}}"},
+					{"refId": "B", "expr": "sum(rate(http_requests_total[1h]))", "legendFormat": "hourly", "hide": true}
+				]
+			},
+			{
+				"id": 3,
+				"title": "Mixed",
+				"type": "timeseries",
+				"datasource": {"type": "datasource", "uid": "-- Mixed --"},
+				"targets": [
+					{"refId": "A", "datasource": {"type": "prometheus", "uid": "$datasource"}, "expr": "up"},
+					{"refId": "B", "datasource": {"type": "loki", "uid": "loki-uid"}, "expr": "sum(count_over_time({app=\"api\"}[5m]))"}
+				]
+			},
+			{"id": 4, "title": "Empty", "type": "timeseries", "datasource": {"type": "prometheus", "uid": "prom-uid"}, "targets": [{"refId": "A"}]},
+			{
+				"id": 5,
+				"title": "Logs",
+				"type": "row",
+				"collapsed": true,
+				"panels": [
+					{"id": 6, "title": "Notes", "type": "text"},
+					{
+						"id": 7,
+						"title": "Error logs",
+						"type": "logs",
+						"datasource": {"type": "loki", "uid": "loki-uid"},
+						"targets": [{"refId": "A", "expr": "{app=\"api\"} |= \"error\""}]
+					}
+				]
+			}
+		]
+	},
+	"meta": {}
+}`
+
+func newDashboardTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	dashboards := map[string]string{
+		"/api/dashboards/uid/panels":    panelFixtureDashboard,
+		"/api/dashboards/uid/rows":      rowsFixtureDashboard,
+		"/api/dashboards/uid/variables": variablesFixtureDashboard,
+		"/api/dashboards/uid/queries":   queriesFixtureDashboard,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dashboard, ok := dashboards[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Dashboard not found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(dashboard))
+	}))
+}
+
+func TestGetDashboardPanel(t *testing.T) {
+	server := newDashboardTestServer(t)
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	t.Run("by id", func(t *testing.T) {
+		id := 2
+		panels, err := getDashboardPanel(ctx, GetDashboardPanelParams{UID: "panels", PanelID: &id})
+		require.NoError(t, err)
+		require.Len(t, panels, 1)
+		assert.Equal(t, "Error logs", panels[0]["title"])
+		assert.Equal(t, map[string]any{"type": "loki", "uid": "loki-uid"}, panels[0]["datasource"])
+		targets, ok := panels[0]["targets"].([]any)
+		require.True(t, ok)
+		require.Len(t, targets, 1)
+		assert.Equal(t, `{app="api"} |= "error"`, targets[0].(map[string]any)["expr"])
+	})
+
+	t.Run("by id inside collapsed row", func(t *testing.T) {
+		id := 4
+		panels, err := getDashboardPanel(ctx, GetDashboardPanelParams{UID: "panels", PanelID: &id})
+		require.NoError(t, err)
+		require.Len(t, panels, 1)
+		assert.Equal(t, "Backend request rate", panels[0]["title"])
+	})
+
+	t.Run("title matching several panels returns all of them", func(t *testing.T) {
+		panels, err := getDashboardPanel(ctx, GetDashboardPanelParams{UID: "panels", Title: "request RATE"})
+		require.NoError(t, err)
+		require.Len(t, panels, 2)
+		assert.Equal(t, float64(1), panels[0]["id"])
+		assert.Equal(t, float64(4), panels[1]["id"])
+	})
+
+	t.Run("id takes precedence over title", func(t *testing.T) {
+		id := 1
+		panels, err := getDashboardPanel(ctx, GetDashboardPanelParams{UID: "panels", Title: "Error", PanelID: &id})
+		require.NoError(t, err)
+		require.Len(t, panels, 1)
+		assert.Equal(t, "Request rate", panels[0]["title"])
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		_, err := getDashboardPanel(ctx, GetDashboardPanelParams{UID: "panels", Title: "latency"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no panel with title matching 'latency'")
+
+		id := 42
+		_, err = getDashboardPanel(ctx, GetDashboardPanelParams{UID: "panels", PanelID: &id})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no panel with id 42")
+	})
+
+	t.Run("requires id or title", func(t *testing.T) {
+		_, err := getDashboardPanel(ctx, GetDashboardPanelParams{UID: "panels"})
+		require.Error(t, err)
+	})
+}
+
+func TestListDashboardQueries(t *testing.T) {
+	server := newDashboardTestServer(t)
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	panels, err := listDashboardQueries(ctx, ListDashboardQueriesParams{UID: "queries"})
+	require.NoError(t, err)
+	assert.Equal(t, []DashboardPanelQueries{
+		{
+			PanelID: 2,
+			Title:   "Request rate",
+			Type:    "timeseries",
+			Queries: []DashboardQuery{
+				{RefID: "A", Query: "sum by (status) (rate(http_requests_total[5m]))", DatasourceUID: "prom-uid", Legend: "{{status}}"},
+				{RefID: "B", Query: "sum(rate(http_requests_total[1h]))", DatasourceUID: "prom-uid", Legend: "hourly", Hidden: true},
+			},
+		},
+		{
+			PanelID: 3,
+			Title:   "Mixed",
+			Type:    "timeseries",
+			Queries: []DashboardQuery{
+				{RefID: "A", Query: "up", DatasourceUID: "$datasource"},
+				{RefID: "B", Query: `sum(count_over_time({app="api"}[5m]))`, DatasourceUID: "loki-uid"},
+			},
+		},
+		{
+			PanelID: 7,
+			Title:   "Error logs",
+			Type:    "logs",
+			Row:     "Logs",
+			Queries: []DashboardQuery{
+				{RefID: "A", Query: `{app="api"} |= "error"`, DatasourceUID: "loki-uid"},
+			},
+		},
+	}, panels)
+
+	_, err = listDashboardQueries(ctx, ListDashboardQueriesParams{UID: "missing"})
+	require.Error(t, err)
+}
+
+func TestGetDashboardSummarizeOnly(t *testing.T) {
+	server := newDashboardTestServer(t)
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	t.Run("summary flattens rows and nested panels", func(t *testing.T) {
+		result, err := getDashboard(ctx, GetDashboardByUIDParams{UID: "rows", SummarizeOnly: true})
+		require.NoError(t, err)
+		summary, ok := result.(*DashboardCompactSummary)
+		require.True(t, ok)
+
+		assert.Equal(t, &DashboardCompactSummary{
+			UID:         "rows",
+			Title:       "Rows",
+			FolderUID:   "folder-uid",
+			FolderTitle: "Team A",
+			Tags:        []string{"team-a", "prod"},
+			Panels: []CompactPanelSummary{
+				{ID: 1, Title: "Overview", Type: "stat", DatasourceUID: "prom-uid"},
+				{ID: 2, Title: "Frontend", Type: "row"},
+				{ID: 3, Title: "Frontend latency", Type: "timeseries", DatasourceUID: "prom-frontend", Row: "Frontend"},
+				{ID: 4, Title: "Backend", Type: "row"},
+				{ID: 5, Title: "Backend logs", Type: "logs", DatasourceUID: "loki-uid", Row: "Backend"},
+				{ID: 6, Title: "Legacy", Type: "graph", DatasourceUID: "Old Prometheus", Row: "Backend"},
+			},
+		}, summary)
+	})
+
+	t.Run("full dashboard by default", func(t *testing.T) {
+		result, err := getDashboard(ctx, GetDashboardByUIDParams{UID: "rows"})
+		require.NoError(t, err)
+		dashboard, ok := result.(*models.DashboardFullWithMeta)
+		require.True(t, ok)
+		db, ok := dashboard.Dashboard.(map[string]any)
+		require.True(t, ok)
+		panels, ok := db["panels"].([]any)
+		require.True(t, ok)
+		assert.Contains(t, panels[0].(map[string]any), "fieldConfig")
+	})
+}
+
+func TestUpdateDashboardPanelQuery(t *testing.T) {
+	const dashboard = `{
+		"dashboard": {
+			"uid": "panels",
+			"title": "Panels",
+			"version": 3,
+			"panels": [
+				{
+					"id": 3,
+					"title": "Backend",
+					"type": "row",
+					"collapsed": true,
+					"panels": [
+						{
+							"id": 4,
+							"title": "Backend request rate",
+							"type": "timeseries",
+							"targets": [
+								{"refId": "A", "expr": "sum(rate(backend_requests_total[5m]))"},
+								{"refId": "B", "expr": "sum(rate(backend_errors_total[5m]))"}
+							]
+						}
+					]
+				}
+			]
+		},
+		"meta": {"folderUid": "folder", "version": 3}
+	}`
+
+	newServer := func(t *testing.T, status int, saved *map[string]any) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/api/dashboards/uid/panels":
+				_, _ = w.Write([]byte(dashboard))
+			case r.Method == http.MethodPost && r.URL.Path == "/api/dashboards/db":
+				require.NoError(t, json.NewDecoder(r.Body).Decode(saved))
+				w.WriteHeader(status)
+				if status == http.StatusPreconditionFailed {
+					_, _ = w.Write([]byte(`{"message": "The dashboard has been changed by someone else", "status": "version-mismatch"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"id": 1, "uid": "panels", "status": "success", "version": 4}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	}
+
+	t.Run("replaces the expression of the target", func(t *testing.T) {
+		var saved map[string]any
+		server := newServer(t, http.StatusOK, &saved)
+		defer server.Close()
+		ctx := mockCtxWithClient(server)
+
+		result, err := updateDashboardPanelQuery(ctx, UpdateDashboardPanelQueryParams{
+			UID:     "panels",
+			PanelID: 4,
+			RefID:   "B",
+			Expr:    "sum(rate(backend_errors_total[1m]))",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), *result.Version)
+
+		assert.Equal(t, "folder", saved["folderUid"])
+		assert.NotContains(t, saved, "overwrite")
+		db := saved["dashboard"].(map[string]any)
+		assert.Equal(t, float64(3), db["version"])
+		row := db["panels"].([]any)[0].(map[string]any)
+		targets := row["panels"].([]any)[0].(map[string]any)["targets"].([]any)
+		assert.Equal(t, "sum(rate(backend_requests_total[5m]))", targets[0].(map[string]any)["expr"])
+		assert.Equal(t, "sum(rate(backend_errors_total[1m]))", targets[1].(map[string]any)["expr"])
+	})
+
+	t.Run("version conflict", func(t *testing.T) {
+		var saved map[string]any
+		server := newServer(t, http.StatusPreconditionFailed, &saved)
+		defer server.Close()
+		ctx := mockCtxWithClient(server)
+
+		_, err := updateDashboardPanelQuery(ctx, UpdateDashboardPanelQueryParams{
+			UID:     "panels",
+			PanelID: 4,
+			RefID:   "A",
+			Expr:    "up",
+		})
+		require.EqualError(t, err, "dashboard panels was changed by someone else since version 3, fetch it again and retry")
+	})
+
+	t.Run("unknown target", func(t *testing.T) {
+		var saved map[string]any
+		server := newServer(t, http.StatusOK, &saved)
+		defer server.Close()
+		ctx := mockCtxWithClient(server)
+
+		_, err := updateDashboardPanelQuery(ctx, UpdateDashboardPanelQueryParams{
+			UID:     "panels",
+			PanelID: 4,
+			RefID:   "C",
+			Expr:    "up",
+		})
+		require.EqualError(t, err, "no target with refId 'C' found in panel 4")
+		assert.Nil(t, saved)
+	})
+}
+
+func TestGetDashboardVariables(t *testing.T) {
+	server := newDashboardTestServer(t)
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	t.Run("custom, query and interval variables", func(t *testing.T) {
+		variables, err := getDashboardVariables(ctx, GetDashboardVariablesParams{UID: "variables"})
+		require.NoError(t, err)
+		assert.Equal(t, []DashboardVariable{
+			{
+				Name:    "env",
+				Type:    "custom",
+				Label:   "Environment",
+				Current: []string{"prod", "staging"},
+				Multi:   true,
+				Options: []string{"prod", "staging", "dev"},
+			},
+			{
+				Name:    "region",
+				Type:    "custom",
+				Current: []string{"eu-west-1"},
+				Options: []string{"eu-west-1", "us-east-1"},
+			},
+			{
+				Name:           "job",
+				Type:           "query",
+				Current:        []string{"$__all"},
+				IncludeAll:     true,
+				DynamicOptions: true,
+				Datasource:     "prom-uid",
+				Query:          "label_values(up, job)",
+			},
+			{
+				Name:    "interval",
+				Type:    "interval",
+				Current: []string{"5m"},
+				Options: []string{"1m", "5m", "10m", "1h"},
+			},
+		}, variables)
+	})
+
+	t.Run("dashboard without variables", func(t *testing.T) {
+		variables, err := getDashboardVariables(ctx, GetDashboardVariablesParams{UID: "panels"})
+		require.NoError(t, err)
+		assert.Empty(t, variables)
+	})
+
+	t.Run("unknown dashboard", func(t *testing.T) {
+		_, err := getDashboardVariables(ctx, GetDashboardVariablesParams{UID: "missing"})
+		assert.Error(t, err)
+	})
+}
+
+func TestBuildDashboardFromSpec(t *testing.T) {
+	db := buildDashboardFromSpec("Service overview", []DashboardPanelSpec{
+		{Title: "Request rate", DatasourceUID: "prom-uid", Expr: "sum(rate(http_requests_total[5m]))"},
+		{Title: "Errors", Type: "logs", DatasourceUID: "loki-uid", Expr: `{app="api"} |= "error"`},
+	}, map[string]string{"prom-uid": "prometheus", "loki-uid": "loki"})
+
+	assert.Equal(t, "Service overview", db["title"])
+	assert.NotContains(t, db, "uid")
+	assert.NotContains(t, db, "id")
+
+	// Round trip through JSON to check what Grafana receives.
+	b, err := json.Marshal(db)
+	require.NoError(t, err)
+	var decoded struct {
+		Panels []struct {
+			ID         int               `json:"id"`
+			Type       string            `json:"type"`
+			Title      string            `json:"title"`
+			GridPos    DashboardGridPos  `json:"gridPos"`
+			Datasource map[string]string `json:"datasource"`
+			Targets    []struct {
+				RefID      string            `json:"refId"`
+				Expr       string            `json:"expr"`
+				Datasource map[string]string `json:"datasource"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	require.Len(t, decoded.Panels, 2)
+
+	first, second := decoded.Panels[0], decoded.Panels[1]
+	assert.Equal(t, 1, first.ID)
+	assert.Equal(t, 2, second.ID)
+	assert.Equal(t, "timeseries", first.Type)
+	assert.Equal(t, "logs", second.Type)
+	assert.Equal(t, DashboardGridPos{X: 0, Y: 0, W: 12, H: 8}, first.GridPos)
+	assert.Equal(t, DashboardGridPos{X: 12, Y: 0, W: 12, H: 8}, second.GridPos)
+	assert.Equal(t, map[string]string{"type": "prometheus", "uid": "prom-uid"}, first.Datasource)
+	assert.Equal(t, map[string]string{"type": "loki", "uid": "loki-uid"}, second.Datasource)
+	require.Len(t, second.Targets, 1)
+	assert.Equal(t, "A", second.Targets[0].RefID)
+	assert.Equal(t, `{app="api"} |= "error"`, second.Targets[0].Expr)
+	assert.Equal(t, second.Datasource, second.Targets[0].Datasource)
+}
+
+func TestBuildDashboardFromSpecLayout(t *testing.T) {
+	db := buildDashboardFromSpec("Layout", []DashboardPanelSpec{
+		{Title: "Header", DatasourceUID: "prom-uid", Expr: "up", GridPos: &DashboardGridPos{X: 0, Y: 0, W: 24, H: 4}},
+		{Title: "A", DatasourceUID: "prom-uid", Expr: "up"},
+		{Title: "B", DatasourceUID: "prom-uid", Expr: "up"},
+		{Title: "C", DatasourceUID: "prom-uid", Expr: "up"},
+	}, map[string]string{"prom-uid": "prometheus"})
+
+	var positions []map[string]any
+	for _, p := range db["panels"].([]any) {
+		positions = append(positions, p.(map[string]any)["gridPos"].(map[string]any))
+	}
+	assert.Equal(t, []map[string]any{
+		{"x": 0, "y": 0, "w": 24, "h": 4},
+		{"x": 0, "y": 4, "w": 12, "h": 8},
+		{"x": 12, "y": 4, "w": 12, "h": 8},
+		{"x": 0, "y": 12, "w": 12, "h": 8},
+	}, positions)
+}
+
+func TestSavedDashboardResult(t *testing.T) {
+	payload := func(path string) *models.PostDashboardOKBody {
+		uid, version := "new-uid", int64(3)
+		return &models.PostDashboardOKBody{UID: &uid, URL: &path, Version: &version}
+	}
+	for _, tc := range []struct {
+		name       string
+		grafanaURL string
+		path       string
+		want       string
+	}{
+		{name: "root", grafanaURL: "http://grafana:3000", path: "/d/new-uid/title", want: "http://grafana:3000/d/new-uid/title"},
+		{name: "trailing slash", grafanaURL: "http://grafana:3000/", path: "/d/new-uid/title", want: "http://grafana:3000/d/new-uid/title"},
+		{name: "sub-path", grafanaURL: "http://example.com/grafana", path: "/grafana/d/new-uid/title", want: "http://example.com/grafana/d/new-uid/title"},
+		{name: "sub-path with trailing slash", grafanaURL: "http://example.com/grafana/", path: "/grafana/d/new-uid/title", want: "http://example.com/grafana/d/new-uid/title"},
+		{name: "sub-path prefix of another path", grafanaURL: "http://example.com/grafana", path: "/grafana-old/d/new-uid/title", want: "http://example.com/grafana/grafana-old/d/new-uid/title"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: tc.grafanaURL})
+			assert.Equal(t, &CreateDashboardResult{UID: "new-uid", URL: tc.want, Version: 3}, savedDashboardResult(ctx, payload(tc.path)))
+		})
+	}
+}
+
+func TestCreateDashboard(t *testing.T) {
+	var saved map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/datasources/uid/prom-uid":
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/dashboards/db":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&saved))
+			_, _ = w.Write([]byte(`{"id": 7, "uid": "new-uid", "url": "/d/new-uid/service-overview", "status": "success", "version": 1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not found"}`))
+		}
+	}))
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("creates the dashboard in the folder", func(t *testing.T) {
+		result, err := createDashboard(ctx, CreateDashboardParams{
+			Title:     "Service overview",
+			FolderUID: "folder",
+			Panels: []DashboardPanelSpec{
+				{Title: "Request rate", DatasourceUID: "prom-uid", Expr: "sum(rate(http_requests_total[5m]))"},
+				{Title: "Up", Type: "stat", DatasourceUID: "prom-uid", Expr: "up"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &CreateDashboardResult{UID: "new-uid", URL: server.URL + "/d/new-uid/service-overview", Version: 1}, result)
+
+		assert.Equal(t, "folder", saved["folderUid"])
+		assert.NotContains(t, saved, "overwrite")
+		db := saved["dashboard"].(map[string]any)
+		assert.Equal(t, "Service overview", db["title"])
+		require.Len(t, db["panels"], 2)
+		panel := db["panels"].([]any)[1].(map[string]any)
+		assert.Equal(t, map[string]any{"type": "prometheus", "uid": "prom-uid"}, panel["datasource"])
+	})
+
+	t.Run("unknown datasource", func(t *testing.T) {
+		saved = nil
+		_, err := createDashboard(ctx, CreateDashboardParams{
+			Title:  "Broken",
+			Panels: []DashboardPanelSpec{{Title: "Missing", DatasourceUID: "missing-uid", Expr: "up"}},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "panel 0 (Missing): datasource with UID 'missing-uid' not found")
+		assert.Nil(t, saved)
+	})
+
+	t.Run("invalid grid position", func(t *testing.T) {
+		_, err := createDashboard(ctx, CreateDashboardParams{
+			Title: "Broken",
+			Panels: []DashboardPanelSpec{
+				{Title: "Too wide", DatasourceUID: "prom-uid", Expr: "up", GridPos: &DashboardGridPos{X: 12, W: 24, H: 8}},
+			},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "panel 0 (Too wide): invalid gridPos")
+	})
+}
+
+func TestExportDashboard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/dashboards/uid/export":
+			_, _ = w.Write([]byte(`{
+				"meta": {"slug": "export"},
+				"dashboard": {
+					"id": 42, "uid": "export", "title": "Export me", "version": 17,
+					"annotations": {"list": [{"name": "Annotations & Alerts", "datasource": {"type": "grafana", "uid": "-- Grafana --"}}]},
+					"templating": {"list": [
+						{"name": "ds", "type": "datasource", "query": "prometheus"},
+						{"name": "job", "type": "query", "datasource": {"type": "prometheus", "uid": "prom-uid"}, "query": "label_values(job)"}
+					]},
+					"panels": [
+						{"id": 1, "type": "timeseries", "datasource": {"type": "prometheus", "uid": "prom-uid"},
+						 "targets": [{"refId": "A", "datasource": {"type": "prometheus", "uid": "prom-uid"}, "expr": "up"}]},
+						{"id": 2, "type": "row", "collapsed": true, "panels": [
+							{"id": 3, "type": "logs", "datasource": "Loki Prod", "targets": [{"refId": "A", "expr": "{job=\"api\"}"}]}
+						]},
+						{"id": 4, "type": "timeseries", "datasource": {"uid": "${ds}"}}
+					]
+				}
+			}`))
+		case "/api/datasources/uid/prom-uid":
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+		case "/api/datasources/name/Loki Prod":
+			_, _ = w.Write([]byte(`{"uid": "loki-uid", "name": "Loki Prod", "type": "loki"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not found"}`))
+		}
+	}))
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("templatizes the datasources", func(t *testing.T) {
+		result, err := exportDashboard(ctx, ExportDashboardParams{UID: "export"})
+		require.NoError(t, err)
+
+		// Round trip through JSON to compare what the tool returns.
+		b, err := json.Marshal(result)
+		require.NoError(t, err)
+		var export map[string]any
+		require.NoError(t, json.Unmarshal(b, &export))
+
+		assert.Nil(t, export["id"])
+		assert.Contains(t, export, "id")
+		assert.Equal(t, float64(0), export["version"])
+		assert.Equal(t, "export", export["uid"])
+
+		assert.Equal(t, []any{
+			map[string]any{"name": "DS_PROMETHEUS", "label": "Prometheus", "description": "", "type": "datasource", "pluginId": "prometheus", "pluginName": "prometheus"},
+			map[string]any{"name": "DS_LOKI_PROD", "label": "Loki Prod", "description": "", "type": "datasource", "pluginId": "loki", "pluginName": "loki"},
+		}, export["__inputs"])
+		assert.Equal(t, []any{
+			map[string]any{"type": "datasource", "id": "prometheus", "name": "prometheus"},
+			map[string]any{"type": "datasource", "id": "loki", "name": "loki"},
+			map[string]any{"type": "panel", "id": "timeseries", "name": "timeseries"},
+			map[string]any{"type": "panel", "id": "logs", "name": "logs"},
+		}, export["__requires"])
+
+		panels := export["panels"].([]any)
+		prom := map[string]any{"type": "prometheus", "uid": "${DS_PROMETHEUS}"}
+		assert.Equal(t, prom, panels[0].(map[string]any)["datasource"])
+		assert.Equal(t, prom, panels[0].(map[string]any)["targets"].([]any)[0].(map[string]any)["datasource"])
+		nested := panels[1].(map[string]any)["panels"].([]any)[0].(map[string]any)
+		assert.Equal(t, "${DS_LOKI_PROD}", nested["datasource"])
+		assert.Equal(t, map[string]any{"uid": "${ds}"}, panels[2].(map[string]any)["datasource"])
+
+		variables := export["templating"].(map[string]any)["list"].([]any)
+		assert.Equal(t, prom, variables[1].(map[string]any)["datasource"])
+		annotation := export["annotations"].(map[string]any)["list"].([]any)[0].(map[string]any)
+		assert.Equal(t, map[string]any{"type": "grafana", "uid": "-- Grafana --"}, annotation["datasource"])
+	})
+
+	t.Run("missing dashboard", func(t *testing.T) {
+		_, err := exportDashboard(ctx, ExportDashboardParams{UID: "missing"})
+		require.ErrorContains(t, err, "get dashboard by uid missing")
+	})
+}
+
+func TestImportDashboard(t *testing.T) {
+	const export = `{
+		"__inputs": [
+			{"name": "DS_PROMETHEUS", "label": "Prometheus", "type": "datasource", "pluginId": "prometheus"},
+			{"name": "VAR_ENV", "label": "env", "type": "constant", "value": "prod"}
+		],
+		"__requires": [{"type": "datasource", "id": "prometheus", "name": "prometheus"}],
+		"id": null, "uid": "imported", "title": "Imported", "version": 0,
+		"panels": [
+			{"id": 1, "type": "timeseries", "datasource": {"type": "prometheus", "uid": "${DS_PROMETHEUS}"},
+			 "targets": [{"refId": "A", "datasource": {"type": "prometheus", "uid": "${DS_PROMETHEUS}"}, "expr": "up{env=\"${VAR_ENV}\"}"}]}
+		]
+	}`
+	dashboard := func(t *testing.T) map[string]any {
+		var db map[string]any
+		require.NoError(t, json.Unmarshal([]byte(export), &db))
+		return db
+	}
+
+	var saved map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost || r.URL.Path != "/api/dashboards/db" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		saved = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&saved))
+		if overwrite, _ := saved["overwrite"].(bool); !overwrite {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_, _ = w.Write([]byte(`{"message": "A dashboard with the same uid already exists", "status": "name-exists"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": 9, "uid": "imported", "url": "/d/imported/imported", "status": "success", "version": 2}`))
+	}))
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("resolves the inputs", func(t *testing.T) {
+		saved = nil
+		result, err := importDashboard(ctx, ImportDashboardParams{
+			Dashboard:   dashboard(t),
+			FolderUID:   "folder",
+			Overwrite:   true,
+			Datasources: map[string]string{"DS_PROMETHEUS": "prom-uid"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &CreateDashboardResult{UID: "imported", URL: server.URL + "/d/imported/imported", Version: 2}, result)
+
+		assert.Equal(t, "folder", saved["folderUid"])
+		db := saved["dashboard"].(map[string]any)
+		assert.NotContains(t, db, "__inputs")
+		assert.NotContains(t, db, "__requires")
+		panel := db["panels"].([]any)[0].(map[string]any)
+		assert.Equal(t, map[string]any{"type": "prometheus", "uid": "prom-uid"}, panel["datasource"])
+		target := panel["targets"].([]any)[0].(map[string]any)
+		assert.Equal(t, map[string]any{"type": "prometheus", "uid": "prom-uid"}, target["datasource"])
+		assert.Equal(t, `up{env="prod"}`, target["expr"])
+	})
+
+	t.Run("conflict without overwrite", func(t *testing.T) {
+		_, err := importDashboard(ctx, ImportDashboardParams{
+			Dashboard:   dashboard(t),
+			Datasources: map[string]string{"DS_PROMETHEUS": "prom-uid"},
+		})
+		require.EqualError(t, err, "dashboard conflict: A dashboard with the same uid already exists, set overwrite to replace it")
+	})
+
+	t.Run("missing datasource mapping", func(t *testing.T) {
+		saved = nil
+		_, err := importDashboard(ctx, ImportDashboardParams{Dashboard: dashboard(t), Overwrite: true})
+		require.EqualError(t, err, "no datasource UID given for inputs DS_PROMETHEUS, map each to a datasource of this instance in datasources")
+		assert.Nil(t, saved)
+	})
+}
+
+func TestDiffDashboardVersions(t *testing.T) {
+	versions := map[string]string{
+		"/api/dashboards/uid/svc/versions/3": `{"version": 3, "data": {
+			"uid": "svc", "title": "Service", "version": 3,
+			"templating": {"list": [{"name": "job", "type": "query", "query": "label_values(up, job)"}]},
+			"panels": [
+				{"id": 1, "title": "Requests", "type": "timeseries", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+				 "datasource": {"type": "prometheus", "uid": "prom-uid"},
+				 "targets": [{"refId": "A", "expr": "sum(rate(http_requests_total[5m]))"}]},
+				{"id": 2, "title": "Old errors", "type": "stat", "targets": [{"refId": "A", "expr": "errors"}]}
+			]
+		}}`,
+		"/api/dashboards/uid/svc/versions/4": `{"version": 4, "data": {
+			"uid": "svc", "title": "Service", "version": 4,
+			"templating": {"list": [{"name": "job", "type": "query", "query": "label_values(up, job)"}]},
+			"panels": [
+				{"id": 1, "title": "Requests", "type": "timeseries", "gridPos": {"x": 0, "y": 8, "w": 12, "h": 8},
+				 "datasource": {"type": "prometheus", "uid": "prom-uid"},
+				 "targets": [{"refId": "A", "expr": "sum by (job) (rate(http_requests_total[5m]))"}]},
+				{"id": 2, "title": "Old errors", "type": "stat", "targets": [{"refId": "A", "expr": "errors"}]},
+				{"id": 3, "title": "Latency", "type": "timeseries", "datasource": {"type": "prometheus", "uid": "prom-uid"},
+				 "targets": [{"refId": "A", "expr": "histogram_quantile(0.99, rate(http_request_duration_seconds_bucket[5m]))"}]}
+			]
+		}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, ok := versions[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Dashboard version not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	t.Run("added panel and changed query", func(t *testing.T) {
+		result, err := diffDashboardVersions(ctx, DiffDashboardVersionsParams{UID: "svc", FromVersion: 3, ToVersion: 4})
+		require.NoError(t, err)
+		assert.Equal(t, &DashboardVersionDiff{
+			UID:         "svc",
+			FromVersion: 3,
+			ToVersion:   4,
+			AddedPanels: []CompactPanelSummary{{ID: 3, Title: "Latency", Type: "timeseries", DatasourceUID: "prom-uid"}},
+			ChangedPanels: []DashboardPanelDiff{{
+				ID:      1,
+				Title:   "Requests",
+				Changes: []string{"query A changed from sum(rate(http_requests_total[5m])) to sum by (job) (rate(http_requests_total[5m]))"},
+			}},
+		}, result)
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		result, err := diffDashboardVersions(ctx, DiffDashboardVersionsParams{UID: "svc", FromVersion: 4, ToVersion: 3})
+		require.NoError(t, err)
+		assert.Equal(t, []CompactPanelSummary{{ID: 3, Title: "Latency", Type: "timeseries", DatasourceUID: "prom-uid"}}, result.RemovedPanels)
+		assert.Empty(t, result.AddedPanels)
+	})
+
+	t.Run("missing version", func(t *testing.T) {
+		_, err := diffDashboardVersions(ctx, DiffDashboardVersionsParams{UID: "svc", FromVersion: 3, ToVersion: 5})
+		require.ErrorContains(t, err, "get version 5 of dashboard svc")
+	})
+}
+
+// resolveVariablesFixtureDashboard has query variables on Prometheus and
+// Loki, one of which references another variable.
+const resolveVariablesFixtureDashboard = `{
+	"dashboard": {
+		"uid": "resolve",
+		"title": "Resolve",
+		"templating": {
+			"list": [
+				{
+					"name": "job",
+					"type": "query",
+					"datasource": {"type": "prometheus", "uid": "prom-uid"},
+					"query": {"qryType": 1, "query": "label_values(up, job)", "refId": "PrometheusVariableQueryEditor-VariableQuery"},
+					"current": {"text": "api", "value": "api"}
+				},
+				{
+					"name": "instance",
+					"type": "query",
+					"datasource": {"type": "prometheus", "uid": "prom-uid"},
+					"query": "label_values(up{job=\"$job\"}, instance)",
+					"regex": "/(.*):9090/",
+					"current": {"text": "All", "value": "$__all"}
+				},
+				{
+					"name": "app",
+					"type": "query",
+					"datasource": {"type": "loki", "uid": "loki-uid"},
+					"query": {"type": 1, "label": "app", "stream": "{job=\"$job\"}", "refId": "LokiVariableQueryEditor-VariableQuery"},
+					"current": {"text": "nginx", "value": "nginx"}
+				},
+				{
+					"name": "search",
+					"type": "textbox",
+					"query": "error"
+				}
+			]
+		}
+	},
+	"meta": {"slug": "resolve"}
+}`
+
+func TestResolveDashboardVariableOptions(t *testing.T) {
+	var gotMatch []string
+	var gotStream string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/dashboards/uid/resolve":
+			_, _ = w.Write([]byte(resolveVariablesFixtureDashboard))
+		case "/api/datasources/uid/prom-uid":
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+		case "/api/datasources/uid/loki-uid":
+			_, _ = w.Write([]byte(`{"uid": "loki-uid", "name": "Loki", "type": "loki"}`))
+		case "/api/datasources/proxy/uid/prom-uid/api/v1/label/job/values":
+			require.NoError(t, r.ParseForm())
+			gotMatch = r.Form["match[]"]
+			_, _ = w.Write([]byte(`{"status": "success", "data": ["api", "worker", "db"]}`))
+		case "/api/datasources/proxy/uid/prom-uid/api/v1/label/instance/values":
+			require.NoError(t, r.ParseForm())
+			gotMatch = r.Form["match[]"]
+			_, _ = w.Write([]byte(`{"status": "success", "data": ["api-1:9090", "api-2:9090", "api-2:9091", "api-3:8080"]}`))
+		case "/api/datasources/proxy/uid/loki-uid/loki/api/v1/label/app/values":
+			gotStream = r.URL.Query().Get("query")
+			_, _ = w.Write([]byte(`{"status": "success", "data": ["nginx", "api"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "not found"}`))
+		}
+	}))
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("prometheus label_values", func(t *testing.T) {
+		result, err := resolveDashboardVariableOptions(ctx, ResolveDashboardVariableOptionsParams{UID: "resolve", Variable: "job"})
+		require.NoError(t, err)
+		assert.Equal(t, &ResolvedVariableOptions{
+			Name:           "job",
+			Type:           "query",
+			DatasourceUID:  "prom-uid",
+			DatasourceType: "prometheus",
+			Query:          "label_values(up, job)",
+			Options:        []string{"api", "worker", "db"},
+			Total:          3,
+		}, result)
+		assert.Equal(t, []string{"up"}, gotMatch)
+	})
+
+	t.Run("prometheus label_values referencing a variable with a regex", func(t *testing.T) {
+		result, err := resolveDashboardVariableOptions(ctx, ResolveDashboardVariableOptionsParams{UID: "resolve", Variable: "instance"})
+		require.NoError(t, err)
+		assert.Equal(t, `label_values(up{job="api"}, instance)`, result.Query)
+		assert.Equal(t, []string{`up{job="api"}`}, gotMatch)
+		assert.Equal(t, []string{"api-1", "api-2"}, result.Options)
+	})
+
+	t.Run("variables override current values", func(t *testing.T) {
+		result, err := resolveDashboardVariableOptions(ctx, ResolveDashboardVariableOptionsParams{UID: "resolve", Variable: "instance", Variables: map[string]string{"job": "worker"}, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`up{job="worker"}`}, gotMatch)
+		assert.Equal(t, []string{"api-1"}, result.Options)
+		assert.Equal(t, 2, result.Total)
+	})
+
+	t.Run("loki label values", func(t *testing.T) {
+		result, err := resolveDashboardVariableOptions(ctx, ResolveDashboardVariableOptionsParams{UID: "resolve", Variable: "app"})
+		require.NoError(t, err)
+		assert.Equal(t, `{job="api"}`, gotStream)
+		assert.Equal(t, `label_values({job="api"}, app)`, result.Query)
+		assert.Equal(t, []string{"api", "nginx"}, result.Options)
+	})
+
+	t.Run("variable without options", func(t *testing.T) {
+		_, err := resolveDashboardVariableOptions(ctx, ResolveDashboardVariableOptionsParams{UID: "resolve", Variable: "search"})
+		assert.ErrorContains(t, err, "textbox variable")
+	})
+
+	t.Run("unknown variable", func(t *testing.T) {
+		_, err := resolveDashboardVariableOptions(ctx, ResolveDashboardVariableOptionsParams{UID: "resolve", Variable: "missing"})
+		assert.ErrorContains(t, err, "expected one of: job, instance, app, search")
+	})
+}
+
+func TestLibraryPanels(t *testing.T) {
+	const cpuPanel = `{
+		"uid": "cpu-panel", "name": "CPU usage", "kind": 1, "type": "timeseries",
+		"description": "CPU usage by instance", "folderUid": "infra", "version": 3,
+		"model": {"type": "timeseries", "title": "CPU usage", "targets": [{"refId": "A", "expr": "rate(cpu_seconds_total[5m])"}]},
+		"meta": {"folderName": "Infrastructure", "folderUid": "infra", "connectedDashboards": 4}
+	}`
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/library-elements":
+			query = r.URL.Query()
+			_, _ = w.Write([]byte(`{"result": {"totalCount": 3, "page": 1, "perPage": 2, "elements": [` + cpuPanel + `,
+				{"uid": "mem-panel", "name": "Memory usage", "kind": 1, "type": "stat", "version": 1, "model": {"type": "stat"}}
+			]}}`))
+		case "/api/library-elements/cpu-panel":
+			_, _ = w.Write([]byte(`{"result": ` + cpuPanel + `}`))
+		case "/api/folders/infra":
+			_, _ = w.Write([]byte(`{"id": 42, "uid": "infra", "title": "Infrastructure"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Library element could not be found"}`))
+		}
+	}))
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	t.Run("list", func(t *testing.T) {
+		result, err := listLibraryPanels(ctx, ListLibraryPanelsParams{Query: "usage", FolderUID: "infra", Type: "timeseries", Limit: 2})
+		require.NoError(t, err)
+
+		assert.Equal(t, "1", query.Get("kind"))
+		assert.Equal(t, "usage", query.Get("searchString"))
+		assert.Equal(t, "42", query.Get("folderFilter"))
+		assert.Equal(t, "timeseries", query.Get("typeFilter"))
+		assert.Equal(t, "2", query.Get("perPage"))
+		assert.Equal(t, "1", query.Get("page"))
+
+		assert.Equal(t, int64(3), result.Total)
+		assert.True(t, result.HasMore)
+		require.Len(t, result.LibraryPanels, 2)
+		assert.Equal(t, libraryPanel{
+			UID:                 "cpu-panel",
+			Name:                "CPU usage",
+			Kind:                "panel",
+			Type:                "timeseries",
+			Description:         "CPU usage by instance",
+			FolderUID:           "infra",
+			FolderName:          "Infrastructure",
+			ConnectedDashboards: 4,
+			Version:             3,
+		}, result.LibraryPanels[0])
+		assert.Equal(t, "mem-panel", result.LibraryPanels[1].UID)
+	})
+
+	t.Run("list variables with models in the General folder", func(t *testing.T) {
+		result, err := listLibraryPanels(ctx, ListLibraryPanelsParams{FolderUID: "general", Kind: "variable", IncludeModel: true})
+		require.NoError(t, err)
+		assert.Equal(t, "2", query.Get("kind"))
+		assert.Equal(t, "0", query.Get("folderFilter"))
+		assert.Equal(t, "100", query.Get("perPage"))
+		assert.False(t, result.HasMore)
+		assert.Equal(t, map[string]any{"type": "stat"}, result.LibraryPanels[1].Model)
+	})
+
+	t.Run("invalid kind", func(t *testing.T) {
+		_, err := listLibraryPanels(ctx, ListLibraryPanelsParams{Kind: "row"})
+		assert.EqualError(t, err, `list library panels: invalid kind: "row", must be one of: panel, variable`)
+	})
+
+	t.Run("get by uid", func(t *testing.T) {
+		result, err := getLibraryPanel(ctx, GetLibraryPanelParams{UID: "cpu-panel"})
+		require.NoError(t, err)
+		assert.Equal(t, "CPU usage", result.Name)
+		assert.Equal(t, "timeseries", result.Type)
+		assert.Equal(t, 4, int(result.ConnectedDashboards))
+		model, ok := result.Model.(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, "CPU usage", model["title"])
+		assert.Len(t, model["targets"], 1)
+	})
+
+	t.Run("get missing", func(t *testing.T) {
+		_, err := getLibraryPanel(ctx, GetLibraryPanelParams{UID: "missing"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "get library panel missing")
+	})
+}
+
+func TestDashboardStars(t *testing.T) {
+	starred := map[string]bool{"starred-uid": true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stars must apply to the forwarded user.
+		assert.Equal(t, "alice", r.Header.Get("X-WEBAUTH-USER"))
+		w.Header().Set("Content-Type", "application/json")
+		uid, isStar := strings.CutPrefix(r.URL.Path, "/api/user/stars/dashboard/uid/")
+		switch {
+		case isStar && r.Method == http.MethodPost:
+			starred[uid] = true
+			_, _ = w.Write([]byte(`{"message": "Dashboard starred!"}`))
+		case isStar && r.Method == http.MethodDelete:
+			if !starred[uid] {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message": "Star not found"}`))
+				return
+			}
+			delete(starred, uid)
+			_, _ = w.Write([]byte(`{"message": "Dashboard unstarred"}`))
+		case r.URL.Path == "/api/search":
+			assert.Equal(t, "true", r.URL.Query().Get("starred"))
+			assert.Equal(t, "dash-db", r.URL.Query().Get("type"))
+			hits := []map[string]any{}
+			for uid := range starred {
+				hits = append(hits, map[string]any{"uid": uid, "title": uid, "type": "dash-db", "isStarred": true})
+			}
+			_ = json.NewEncoder(w).Encode(hits)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{
+		URL:          server.URL,
+		ExtraHeaders: map[string]string{"X-WEBAUTH-USER": "alice"},
+	})
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	t.Run("star", func(t *testing.T) {
+		result, err := starDashboard(ctx, DashboardStarParams{UID: "new-uid"})
+		require.NoError(t, err)
+		assert.Equal(t, &DashboardStarResult{UID: "new-uid", Starred: true, User: "alice"}, result)
+		assert.True(t, starred["new-uid"])
+	})
+
+	t.Run("list", func(t *testing.T) {
+		hits, err := listStarredDashboards(ctx, ListStarredDashboardsParams{})
+		require.NoError(t, err)
+		uids := []string{}
+		for _, hit := range hits {
+			uids = append(uids, hit.UID)
+		}
+		assert.ElementsMatch(t, []string{"starred-uid", "new-uid"}, uids)
+	})
+
+	t.Run("unstar", func(t *testing.T) {
+		result, err := unstarDashboard(ctx, DashboardStarParams{UID: "starred-uid"})
+		require.NoError(t, err)
+		assert.Equal(t, &DashboardStarResult{UID: "starred-uid", Starred: false, User: "alice"}, result)
+		assert.False(t, starred["starred-uid"])
+
+		_, err = unstarDashboard(ctx, DashboardStarParams{UID: "starred-uid"})
+		require.Error(t, err)
+		assert.Equal(t, mcpgrafana.ErrKindNotFound, mcpgrafana.ErrorKindOf(err))
+	})
+
+	t.Run("uid is required", func(t *testing.T) {
+		_, err := starDashboard(ctx, DashboardStarParams{})
+		assert.ErrorContains(t, err, "uid is required")
+	})
+
+	t.Run("star and unstar are mutating", func(t *testing.T) {
+		assert.True(t, mcpgrafana.IsMutating(StarDashboard.Tool))
+		assert.True(t, mcpgrafana.IsMutating(UnstarDashboard.Tool))
+		assert.False(t, mcpgrafana.IsMutating(ListStarredDashboards.Tool))
+	})
+}
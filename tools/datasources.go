@@ -2,18 +2,25 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
+	"github.com/grafana/grafana-openapi-client-go/client/datasources"
 	"github.com/grafana/grafana-openapi-client-go/models"
 	mcpgrafana "github.com/grafana/mcp-grafana"
 )
 
 type ListDatasourcesParams struct {
 	Type string `json:"type,omitempty" jsonschema:"description=The type of datasources to search for. For example\\, 'prometheus'\\, 'loki'\\, 'tempo'\\, etc..."`
+	Name string `json:"name,omitempty" jsonschema:"description=Only return datasources whose name contains this string (case-insensitive)"`
 }
 
 type dataSourceSummary struct {
@@ -26,26 +33,32 @@ type dataSourceSummary struct {
 
 func listDatasources(ctx context.Context, args ListDatasourcesParams) ([]dataSourceSummary, error) {
 	c := mcpgrafana.GrafanaClientFromContext(ctx)
-	resp, err := c.Datasources.GetDataSources()
+	resp, err := c.Datasources.GetDataSourcesWithParams(datasources.NewGetDataSourcesParamsWithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("list datasources: %w", err)
 	}
-	datasources := filterDatasources(resp.Payload, args.Type)
+	datasources := filterDatasources(resp.Payload, args.Type, args.Name)
 	return summarizeDatasources(datasources), nil
 }
 
-// filterDatasources returns only datasources of the specified type `t`. If `t`
-// is an empty string no filtering is done.
-func filterDatasources(datasources models.DataSourceList, t string) models.DataSourceList {
-	if t == "" {
+// filterDatasources returns only datasources of the specified type `t` whose
+// name contains `name`. Both comparisons are case-insensitive, and empty
+// values disable the corresponding filter.
+func filterDatasources(datasources models.DataSourceList, t, name string) models.DataSourceList {
+	if t == "" && name == "" {
 		return datasources
 	}
 	filtered := models.DataSourceList{}
 	t = strings.ToLower(t)
+	name = strings.ToLower(name)
 	for _, ds := range datasources {
-		if strings.Contains(strings.ToLower(ds.Type), t) {
-			filtered = append(filtered, ds)
+		if t != "" && !strings.Contains(strings.ToLower(ds.Type), t) {
+			continue
 		}
+		if name != "" && !strings.Contains(strings.ToLower(ds.Name), name) {
+			continue
+		}
+		filtered = append(filtered, ds)
 	}
 	return filtered
 }
@@ -66,7 +79,7 @@ func summarizeDatasources(dataSources models.DataSourceList) []dataSourceSummary
 
 var ListDatasources = mcpgrafana.MustTool(
 	"list_datasources",
-	"List available Grafana datasources. Optionally filter by datasource type (e.g., 'prometheus', 'loki'). Returns a summary list including ID, UID, name, type, and default status.",
+	"List available Grafana datasources. Optionally filter by datasource type (e.g., 'prometheus', 'loki') and/or a case-insensitive name substring. Returns a summary list including ID, UID, name, type, and default status.",
 	listDatasources,
 	mcp.WithTitleAnnotation("List datasources"),
 	mcp.WithIdempotentHintAnnotation(true),
@@ -78,14 +91,32 @@ type GetDatasourceByUIDParams struct {
 }
 
 func getDatasourceByUID(ctx context.Context, args GetDatasourceByUIDParams) (*models.DataSource, error) {
+	return datasourceInfo(ctx, args.UID)
+}
+
+// datasourceInfo looks up the datasource with the given UID. Lookups are
+// cached in the context's datasource cache, if there is one, so tools should
+// use this rather than calling the datasources API directly.
+func datasourceInfo(ctx context.Context, uid string) (*models.DataSource, error) {
+	cache := mcpgrafana.DatasourceCacheFromContext(ctx)
+	if cache != nil {
+		if ds, ok := cache.Get(uid); ok {
+			return ds, nil
+		}
+	}
+
 	c := mcpgrafana.GrafanaClientFromContext(ctx)
-	datasource, err := c.Datasources.GetDataSourceByUID(args.UID)
+	datasource, err := c.Datasources.GetDataSourceByUIDWithParams(datasources.NewGetDataSourceByUIDParamsWithContext(ctx).WithUID(uid))
 	if err != nil {
 		// Check if it's a 404 Not Found Error
 		if strings.Contains(err.Error(), "404") {
-			return nil, fmt.Errorf("datasource with UID '%s' not found. Please check if the datasource exists and is accessible", args.UID)
+			return nil, fmt.Errorf("datasource with UID '%s' not found. Please check if the datasource exists and is accessible", uid)
 		}
-		return nil, fmt.Errorf("get datasource by uid %s: %w", args.UID, err)
+		return nil, fmt.Errorf("get datasource by uid %s: %w", uid, err)
+	}
+
+	if cache != nil {
+		cache.Set(uid, datasource.Payload)
 	}
 	return datasource.Payload, nil
 }
@@ -105,24 +136,203 @@ type GetDatasourceByNameParams struct {
 
 func getDatasourceByName(ctx context.Context, args GetDatasourceByNameParams) (*models.DataSource, error) {
 	c := mcpgrafana.GrafanaClientFromContext(ctx)
-	datasource, err := c.Datasources.GetDataSourceByName(args.Name)
+	datasource, err := c.Datasources.GetDataSourceByNameWithParams(datasources.NewGetDataSourceByNameParamsWithContext(ctx).WithName(args.Name))
 	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return nil, datasourceNameNotFoundError(ctx, args.Name)
+		}
 		return nil, fmt.Errorf("get datasource by name %s: %w", args.Name, err)
 	}
 	return datasource.Payload, nil
 }
 
+// maxDatasourceNameSuggestions caps the number of close matches listed when a
+// datasource name isn't found.
+const maxDatasourceNameSuggestions = 5
+
+// datasourceNameNotFoundError builds a not-found error for the given name,
+// listing datasources with similar names so the caller can correct typos or
+// differences in casing.
+func datasourceNameNotFoundError(ctx context.Context, name string) error {
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	resp, err := c.Datasources.GetDataSourcesWithParams(datasources.NewGetDataSourcesParamsWithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("datasource with name '%s' not found", name)
+	}
+	suggestions := closeDatasourceNames(resp.Payload, name)
+	if len(suggestions) == 0 {
+		return fmt.Errorf("datasource with name '%s' not found. Use list_datasources to see the available datasources", name)
+	}
+	return fmt.Errorf("datasource with name '%s' not found. Did you mean: %s?", name, strings.Join(suggestions, ", "))
+}
+
+// closeDatasourceNames returns the names and UIDs of datasources whose names
+// are similar to name, closest first. A name is similar if one contains the
+// other ignoring case, or if it is within a small edit distance.
+func closeDatasourceNames(datasources models.DataSourceList, name string) []string {
+	type match struct {
+		label    string
+		distance int
+	}
+	target := strings.ToLower(name)
+	maxDistance := max(2, len(target)/3)
+	var matches []match
+	for _, ds := range datasources {
+		candidate := strings.ToLower(ds.Name)
+		distance := levenshtein(target, candidate)
+		if distance > maxDistance && !strings.Contains(candidate, target) && !strings.Contains(target, candidate) {
+			continue
+		}
+		matches = append(matches, match{label: fmt.Sprintf("'%s' (uid %s)", ds.Name, ds.UID), distance: distance})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+
+	suggestions := make([]string, 0, min(len(matches), maxDatasourceNameSuggestions))
+	for _, m := range matches[:min(len(matches), maxDatasourceNameSuggestions)] {
+		suggestions = append(suggestions, m.label)
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
+
 var GetDatasourceByName = mcpgrafana.MustTool(
 	"get_datasource_by_name",
-	"Retrieves detailed information about a specific datasource using its name. Returns the full datasource model, including UID, type, URL, access settings, JSON data, and secure JSON field status.",
+	"Retrieves detailed information about a specific datasource using its name. Use this to translate a datasource name into the UID required by the query tools. Returns the full datasource model, including UID, type, URL, access settings, JSON data, and secure JSON field status. If no datasource has the exact name, the error lists datasources with similar names.",
 	getDatasourceByName,
 	mcp.WithTitleAnnotation("Get datasource by name"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+type CheckDatasourceHealthParams struct {
+	UID string `json:"uid" jsonschema:"required,description=The UID of the datasource to check"`
+}
+
+// Datasource health statuses. Grafana reports OK or ERROR; NOT_SUPPORTED is
+// returned for datasources whose plugin has no health check.
+const (
+	datasourceHealthOK           = "OK"
+	datasourceHealthError        = "ERROR"
+	datasourceHealthNotSupported = "NOT_SUPPORTED"
+)
+
+// maxDatasourceHealthBodyBytes caps the size of a health check response read.
+const maxDatasourceHealthBodyBytes = 1024 * 1024
+
+// DatasourceHealth is the result of a datasource's health check. Details
+// holds any plugin specific details the check returned.
+type DatasourceHealth struct {
+	UID     string `json:"uid"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+func checkDatasourceHealth(ctx context.Context, args CheckDatasourceHealthParams) (*DatasourceHealth, error) {
+	if args.UID == "" {
+		return nil, fmt.Errorf("uid is required")
+	}
+	// Looking the datasource up first tells a missing datasource apart from
+	// a plugin without a health check, which Grafana both report as 404s.
+	ds, err := datasourceInfo(ctx, args.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := mcpgrafana.GrafanaConfigFromContext(ctx)
+	transport, err := mcpgrafana.BuildTransport(&cfg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create custom transport: %w", err)
+	}
+	transport = NewAuthRoundTripper(transport, cfg.AccessToken, cfg.IDToken, cfg.APIKey, cfg.BasicAuth)
+	transport = mcpgrafana.NewOrgIDRoundTripper(transport, cfg.OrgID)
+	client := &http.Client{Transport: mcpgrafana.NewUserAgentTransport(transport)}
+
+	healthURL := fmt.Sprintf("%s/api/datasources/uid/%s/health", strings.TrimRight(cfg.URL, "/"), url.PathEscape(args.UID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("check health of datasource %s: %w", args.UID, err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+	// Details can hold plugin specific output, such as a verbose error
+	// message, well beyond the size kept of error bodies.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDatasourceHealthBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("check health of datasource %s: reading response: %w", args.UID, err)
+	}
+
+	health := &DatasourceHealth{UID: ds.UID, Name: ds.Name, Type: ds.Type}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusBadRequest:
+		// A failing check is reported as a 400 with the same body as a
+		// passing one.
+		var result struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details any    `json:"details"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("check health of datasource %s: status %d: %s", args.UID, resp.StatusCode, summarizeErrorBody(body))
+		}
+		health.Status = strings.ToUpper(result.Status)
+		if health.Status == "" {
+			health.Status = datasourceHealthOK
+			if resp.StatusCode != http.StatusOK {
+				health.Status = datasourceHealthError
+			}
+		}
+		health.Message = result.Message
+		health.Details = result.Details
+	case http.StatusNotFound, http.StatusNotImplemented:
+		health.Status = datasourceHealthNotSupported
+		health.Message = fmt.Sprintf("the %s plugin doesn't support health checks", ds.Type)
+	default:
+		return nil, fmt.Errorf("check health of datasource %s: status %d: %s", args.UID, resp.StatusCode, summarizeErrorBody(body))
+	}
+	return health, nil
+}
+
+var CheckDatasourceHealth = mcpgrafana.MustTool(
+	"check_datasource_health",
+	"Run the health check of a datasource by its UID, as the 'Save & test' button in Grafana does, to verify it works before querying it. Returns the status ('OK' or 'ERROR'), the message and any details returned by the check. Datasources whose plugin has no health check return the status 'NOT_SUPPORTED'.",
+	checkDatasourceHealth,
+	mcp.WithTitleAnnotation("Check datasource health"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
 func AddDatasourceTools(mcp *server.MCPServer) {
 	ListDatasources.Register(mcp)
 	GetDatasourceByUID.Register(mcp)
 	GetDatasourceByName.Register(mcp)
+	CheckDatasourceHealth.Register(mcp)
 }
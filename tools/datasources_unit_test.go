@@ -0,0 +1,230 @@
+//go:build unit
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDatasourcesTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/datasources", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"id": 1, "uid": "prom-main", "name": "Prometheus Main", "type": "prometheus", "isDefault": true},
+			{"id": 2, "uid": "loki-main", "name": "Loki Main", "type": "loki"},
+			{"id": 3, "uid": "prom-edge", "name": "Edge Metrics", "type": "prometheus"},
+		})
+	}))
+}
+
+func TestListDatasources_Filters(t *testing.T) {
+	server := newDatasourcesTestServer(t)
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	t.Run("type filter excludes other types", func(t *testing.T) {
+		result, err := listDatasources(ctx, ListDatasourcesParams{Type: "prometheus"})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		for _, ds := range result {
+			assert.Equal(t, "prometheus", ds.Type)
+		}
+		assert.Equal(t, "prom-main", result[0].UID)
+		assert.Equal(t, "Prometheus Main", result[0].Name)
+		assert.True(t, result[0].IsDefault)
+	})
+
+	t.Run("type filter is case-insensitive", func(t *testing.T) {
+		result, err := listDatasources(ctx, ListDatasourcesParams{Type: "LOKI"})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "loki-main", result[0].UID)
+	})
+
+	t.Run("name substring filter", func(t *testing.T) {
+		result, err := listDatasources(ctx, ListDatasourcesParams{Name: "main"})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "prom-main", result[0].UID)
+		assert.Equal(t, "loki-main", result[1].UID)
+	})
+
+	t.Run("type and name filters combine", func(t *testing.T) {
+		result, err := listDatasources(ctx, ListDatasourcesParams{Type: "prometheus", Name: "edge"})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "prom-edge", result[0].UID)
+	})
+}
+
+func TestDatasourceInfoCache(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/datasources/uid/prom-main", r.URL.Path)
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 1, "uid": "prom-main", "name": "Prometheus Main", "type": "prometheus"})
+	}))
+	defer server.Close()
+
+	t.Run("cached within a context", func(t *testing.T) {
+		calls.Store(0)
+		ctx := mcpgrafana.WithDatasourceCache(mockCtxWithClient(server), mcpgrafana.NewDatasourceCache())
+
+		first, err := datasourceInfo(ctx, "prom-main")
+		require.NoError(t, err)
+		second, err := getDatasourceByUID(ctx, GetDatasourceByUIDParams{UID: "prom-main"})
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, "prometheus", first.Type)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("not cached without a cache in the context", func(t *testing.T) {
+		calls.Store(0)
+		ctx := mockCtxWithClient(server)
+
+		_, err := datasourceInfo(ctx, "prom-main")
+		require.NoError(t, err)
+		_, err = datasourceInfo(ctx, "prom-main")
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
+
+func TestGetDatasourceByName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/datasources/name/Prometheus Main":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id": 1, "uid": "prom-main", "name": "Prometheus Main", "type": "prometheus", "url": "http://prometheus:9090",
+			})
+		case "/api/datasources":
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"id": 1, "uid": "prom-main", "name": "Prometheus Main", "type": "prometheus"},
+				{"id": 2, "uid": "loki-main", "name": "Loki Main", "type": "loki"},
+				{"id": 3, "uid": "prom-edge", "name": "Edge Metrics", "type": "prometheus"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Data source not found"}`))
+		}
+	}))
+	defer server.Close()
+	ctx := mockCtxWithClient(server)
+
+	t.Run("exact match", func(t *testing.T) {
+		ds, err := getDatasourceByName(ctx, GetDatasourceByNameParams{Name: "Prometheus Main"})
+		require.NoError(t, err)
+		assert.Equal(t, "prom-main", ds.UID)
+		assert.Equal(t, "prometheus", ds.Type)
+		assert.Equal(t, "http://prometheus:9090", ds.URL)
+	})
+
+	t.Run("not found lists close matches", func(t *testing.T) {
+		_, err := getDatasourceByName(ctx, GetDatasourceByNameParams{Name: "prometheus-main"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "datasource with name 'prometheus-main' not found")
+		assert.Contains(t, err.Error(), "'Prometheus Main' (uid prom-main)")
+		assert.NotContains(t, err.Error(), "Loki Main")
+	})
+
+	t.Run("not found without close matches", func(t *testing.T) {
+		_, err := getDatasourceByName(ctx, GetDatasourceByNameParams{Name: "tempo"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Use list_datasources")
+	})
+}
+
+func TestCheckDatasourceHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.Equal(t, "Bearer test", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/datasources/uid/prom-uid":
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+		case "/api/datasources/uid/prom-uid/health":
+			_, _ = w.Write([]byte(`{"status": "OK", "message": "Successfully queried the Prometheus API."}`))
+		case "/api/datasources/uid/loki-uid":
+			_, _ = w.Write([]byte(`{"uid": "loki-uid", "name": "Loki", "type": "loki"}`))
+		case "/api/datasources/uid/loki-uid/health":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status": "ERROR", "message": "Unable to connect with Loki.", "details": {"verboseMessage": "dial tcp: connection refused"}}`))
+		case "/api/datasources/uid/tempo-uid":
+			_, _ = w.Write([]byte(`{"uid": "tempo-uid", "name": "Tempo", "type": "tempo"}`))
+		case "/api/datasources/uid/tempo-uid/health":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, `{"status": "ERROR", "message": "Unable to connect with Tempo.", "details": {"verboseMessage": %q}}`, strings.Repeat("x", 8*1024))
+		case "/api/datasources/uid/json-uid":
+			_, _ = w.Write([]byte(`{"uid": "json-uid", "name": "JSON", "type": "marcusolsson-json-datasource"}`))
+		case "/api/datasources/uid/json-uid/health":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Plugin not found"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Data source not found"}`))
+		}
+	}))
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("healthy", func(t *testing.T) {
+		result, err := checkDatasourceHealth(ctx, CheckDatasourceHealthParams{UID: "prom-uid"})
+		require.NoError(t, err)
+		assert.Equal(t, &DatasourceHealth{
+			UID:     "prom-uid",
+			Name:    "Prometheus",
+			Type:    "prometheus",
+			Status:  "OK",
+			Message: "Successfully queried the Prometheus API.",
+		}, result)
+	})
+
+	t.Run("errored", func(t *testing.T) {
+		result, err := checkDatasourceHealth(ctx, CheckDatasourceHealthParams{UID: "loki-uid"})
+		require.NoError(t, err)
+		assert.Equal(t, &DatasourceHealth{
+			UID:     "loki-uid",
+			Name:    "Loki",
+			Type:    "loki",
+			Status:  "ERROR",
+			Message: "Unable to connect with Loki.",
+			Details: map[string]any{"verboseMessage": "dial tcp: connection refused"},
+		}, result)
+	})
+
+	t.Run("errored with long details", func(t *testing.T) {
+		result, err := checkDatasourceHealth(ctx, CheckDatasourceHealthParams{UID: "tempo-uid"})
+		require.NoError(t, err)
+		assert.Equal(t, "ERROR", result.Status)
+		assert.Equal(t, map[string]any{"verboseMessage": strings.Repeat("x", 8*1024)}, result.Details)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		result, err := checkDatasourceHealth(ctx, CheckDatasourceHealthParams{UID: "json-uid"})
+		require.NoError(t, err)
+		assert.Equal(t, "NOT_SUPPORTED", result.Status)
+		assert.Equal(t, "the marcusolsson-json-datasource plugin doesn't support health checks", result.Message)
+	})
+
+	t.Run("missing datasource", func(t *testing.T) {
+		_, err := checkDatasourceHealth(ctx, CheckDatasourceHealthParams{UID: "missing-uid"})
+		require.ErrorContains(t, err, "datasource with UID 'missing-uid' not found")
+	})
+}
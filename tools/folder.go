@@ -7,6 +7,8 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
+	"github.com/grafana/grafana-openapi-client-go/client/folders"
+	"github.com/grafana/grafana-openapi-client-go/client/search"
 	"github.com/grafana/grafana-openapi-client-go/models"
 	mcpgrafana "github.com/grafana/mcp-grafana"
 )
@@ -31,14 +33,14 @@ func createFolder(ctx context.Context, args CreateFolderParams) (*models.Folder,
 		cmd.ParentUID = args.ParentUID
 	}
 
-	resp, err := c.Folders.CreateFolder(cmd)
+	resp, err := c.Folders.CreateFolderWithParams(folders.NewCreateFolderParamsWithContext(ctx).WithBody(cmd))
 	if err != nil {
 		return nil, fmt.Errorf("create folder '%s': %w", args.Title, err)
 	}
 	return resp.Payload, nil
 }
 
-var CreateFolder = mcpgrafana.MustTool(
+var CreateFolder = mcpgrafana.MustMutatingTool(
 	"create_folder",
 	"Create a Grafana folder. Provide a title and optional UID. Returns the created folder.",
 	createFolder,
@@ -46,7 +48,111 @@ var CreateFolder = mcpgrafana.MustTool(
 	mcp.WithIdempotentHintAnnotation(false),
 )
 
+type ListFoldersParams struct {
+	ParentUID string `json:"parentUid,omitempty" jsonschema:"description=Only list the direct children of the folder with this UID. If omitted\\, all folders are listed with their nesting depth."`
+}
+
+// FolderSummary describes a folder and its position in the folder hierarchy.
+type FolderSummary struct {
+	UID       string `json:"uid"`
+	Title     string `json:"title"`
+	ParentUID string `json:"parentUid,omitempty"`
+	// Depth is the nesting level of the folder, starting at 0 for top-level
+	// folders (or for direct children of the requested parent).
+	Depth int `json:"depth"`
+}
+
+const (
+	// maxFolderDepth bounds the folder hierarchy walk. Grafana limits nesting
+	// to 8 levels.
+	maxFolderDepth = 8
+	// folderPageSize is the largest page Grafana's search API returns.
+	folderPageSize = 5000
+)
+
+func listFolders(ctx context.Context, args ListFoldersParams) ([]FolderSummary, error) {
+	all, err := listAllFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	children := map[string][]*models.Hit{}
+	byUID := make(map[string]bool, len(all))
+	for _, f := range all {
+		byUID[f.UID] = true
+	}
+	for _, f := range all {
+		parent := f.FolderUID
+		// Folders whose parent can't be seen, for lack of permission, are
+		// listed at the top level.
+		if args.ParentUID == "" && !byUID[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], f)
+	}
+
+	if args.ParentUID != "" {
+		result := make([]FolderSummary, 0, len(children[args.ParentUID]))
+		for _, f := range children[args.ParentUID] {
+			result = append(result, FolderSummary{UID: f.UID, Title: f.Title, ParentUID: f.FolderUID})
+		}
+		return result, nil
+	}
+
+	result := make([]FolderSummary, 0, len(all))
+	seen := map[string]bool{}
+	var walk func(parentUID string, depth int)
+	walk = func(parentUID string, depth int) {
+		for _, f := range children[parentUID] {
+			if seen[f.UID] {
+				continue
+			}
+			seen[f.UID] = true
+			result = append(result, FolderSummary{UID: f.UID, Title: f.Title, ParentUID: f.FolderUID, Depth: depth})
+			if depth+1 < maxFolderDepth {
+				walk(f.UID, depth+1)
+			}
+		}
+	}
+	walk("", 0)
+	return result, nil
+}
+
+// listAllFolders returns every folder the user can see, at any depth, with
+// the UID of its parent folder, fetching all pages. Unlike /api/folders,
+// which only lists one level at a time, the search API returns nested
+// folders too, so the hierarchy can be built from a single listing.
+func listAllFolders(ctx context.Context) ([]*models.Hit, error) {
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	var result []*models.Hit
+	limit := int64(folderPageSize)
+	for page := int64(1); ; page++ {
+		params := search.NewSearchParamsWithContext(ctx).WithType(&folderTypeStr).WithLimit(&limit).WithPage(&page)
+		resp, err := c.Search.Search(params)
+		if err != nil {
+			return nil, fmt.Errorf("list folders: %w", err)
+		}
+		for _, f := range resp.Payload {
+			if f != nil {
+				result = append(result, f)
+			}
+		}
+		if len(resp.Payload) < folderPageSize {
+			return result, nil
+		}
+	}
+}
+
+var ListFolders = mcpgrafana.MustTool(
+	"list_folders",
+	"List Grafana folders. By default all folders are returned as a flat list in hierarchy order, each with its UID, title, parent UID and nesting depth (0 for top-level folders). Provide parentUid to list only the direct children of a folder.",
+	listFolders,
+	mcp.WithTitleAnnotation("List folders"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
 func AddFolderTools(mcp *server.MCPServer, enableWriteTools bool) {
+	ListFolders.Register(mcp)
 	if enableWriteTools {
 		CreateFolder.Register(mcp)
 	}
@@ -0,0 +1,93 @@
+//go:build unit
+
+package tools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFoldersTestServer serves the given folders from the search API, as a
+// single flat list with each folder's parent in folderUid, and counts the
+// requests made.
+func newFoldersTestServer(t *testing.T, folders []map[string]any) (*httptest.Server, *int) {
+	t.Helper()
+	requests := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/search", r.URL.Path)
+		require.Equal(t, "dash-folder", r.URL.Query().Get("type"))
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(folders)
+	})), &requests
+}
+
+func TestListFolders(t *testing.T) {
+	t.Run("flat org", func(t *testing.T) {
+		server, requests := newFoldersTestServer(t, []map[string]any{
+			{"uid": "team-a", "title": "Team A", "type": "dash-folder"},
+			{"uid": "team-b", "title": "Team B", "type": "dash-folder"},
+		})
+		defer server.Close()
+
+		result, err := listFolders(mockCtxWithClient(server), ListFoldersParams{})
+		require.NoError(t, err)
+		assert.Equal(t, []FolderSummary{
+			{UID: "team-a", Title: "Team A"},
+			{UID: "team-b", Title: "Team B"},
+		}, result)
+		assert.Equal(t, 1, *requests)
+	})
+
+	nested := []map[string]any{
+		{"uid": "apps", "title": "Apps", "type": "dash-folder"},
+		{"uid": "databases", "title": "Databases", "type": "dash-folder", "folderUid": "platform"},
+		{"uid": "networking", "title": "Networking", "type": "dash-folder", "folderUid": "platform"},
+		{"uid": "platform", "title": "Platform", "type": "dash-folder"},
+		{"uid": "postgres", "title": "Postgres", "type": "dash-folder", "folderUid": "databases"},
+	}
+
+	t.Run("nested hierarchy in one request", func(t *testing.T) {
+		server, requests := newFoldersTestServer(t, nested)
+		defer server.Close()
+
+		result, err := listFolders(mockCtxWithClient(server), ListFoldersParams{})
+		require.NoError(t, err)
+		assert.Equal(t, []FolderSummary{
+			{UID: "apps", Title: "Apps"},
+			{UID: "platform", Title: "Platform"},
+			{UID: "databases", Title: "Databases", ParentUID: "platform", Depth: 1},
+			{UID: "postgres", Title: "Postgres", ParentUID: "databases", Depth: 2},
+			{UID: "networking", Title: "Networking", ParentUID: "platform", Depth: 1},
+		}, result)
+		assert.Equal(t, 1, *requests)
+	})
+
+	t.Run("direct children of a parent", func(t *testing.T) {
+		server, _ := newFoldersTestServer(t, nested)
+		defer server.Close()
+
+		result, err := listFolders(mockCtxWithClient(server), ListFoldersParams{ParentUID: "platform"})
+		require.NoError(t, err)
+		assert.Equal(t, []FolderSummary{
+			{UID: "databases", Title: "Databases", ParentUID: "platform"},
+			{UID: "networking", Title: "Networking", ParentUID: "platform"},
+		}, result)
+	})
+
+	t.Run("folders under a parent which can't be seen", func(t *testing.T) {
+		server, _ := newFoldersTestServer(t, []map[string]any{
+			{"uid": "team-a", "title": "Team A", "type": "dash-folder", "folderUid": "restricted"},
+		})
+		defer server.Close()
+
+		result, err := listFolders(mockCtxWithClient(server), ListFoldersParams{})
+		require.NoError(t, err)
+		assert.Equal(t, []FolderSummary{{UID: "team-a", Title: "Team A", ParentUID: "restricted"}}, result)
+	})
+}
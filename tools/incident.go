@@ -2,7 +2,13 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
 
 	"github.com/grafana/incident-go"
 	mcpgrafana "github.com/grafana/mcp-grafana"
@@ -10,45 +16,75 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 )
 
+const (
+	defaultIncidentLimit = 10
+	maxIncidentLimit     = 100
+)
+
 type ListIncidentsParams struct {
-	Limit  int    `json:"limit" jsonschema:"default=10,description=The maximum number of incidents to return"`
+	Limit  int    `json:"limit" jsonschema:"default=10,description=The maximum number of incidents to return (max: 100)"`
 	Drill  bool   `json:"drill" jsonschema:"description=Whether to include drill incidents"`
-	Status string `json:"status" jsonschema:"description=The status of the incidents to include. Valid values: 'active'\\, 'resolved'"`
+	Status string `json:"status" jsonschema:"enum=active,enum=resolved,description=The status of the incidents to include. Valid values: 'active'\\, 'resolved'"`
 }
 
 func listIncidents(ctx context.Context, args ListIncidentsParams) (*incident.QueryIncidentPreviewsResponse, error) {
+	if args.Status != "" && args.Status != "active" && args.Status != "resolved" {
+		return nil, fmt.Errorf("invalid status %q, must be 'active' or 'resolved'", args.Status)
+	}
+
 	c := mcpgrafana.IncidentClientFromContext(ctx)
 	is := incident.NewIncidentsService(c)
 
-	// Set default limit to 10 if not specified
 	limit := args.Limit
 	if limit <= 0 {
-		limit = 10
+		limit = defaultIncidentLimit
 	}
+	limit = min(limit, maxIncidentLimit)
 
-	query := ""
+	var filters []string
 	if !args.Drill {
-		query = "isdrill:false"
+		filters = append(filters, "isdrill:false")
 	}
 	if args.Status != "" {
-		query += fmt.Sprintf(" status:%s", args.Status)
-	}
-	incidents, err := is.QueryIncidentPreviews(ctx, incident.QueryIncidentPreviewsRequest{
-		Query: incident.IncidentPreviewsQuery{
-			QueryString:    query,
-			OrderDirection: "DESC",
-			Limit:          limit,
-		},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("list incidents: %w", err)
+		filters = append(filters, "status:"+args.Status)
+	}
+	query := incident.IncidentPreviewsQuery{
+		QueryString:    strings.Join(filters, " "),
+		OrderDirection: "DESC",
+		Limit:          limit,
+	}
+
+	// Follow the cursor until there are enough incidents, returning them
+	// with the cursor of the last page.
+	incidents := &incident.QueryIncidentPreviewsResponse{
+		IncidentPreviews: []incident.IncidentPreview{},
+		Query:            query,
+	}
+	for len(incidents.IncidentPreviews) < limit {
+		pageQuery := query
+		pageQuery.Limit = limit - len(incidents.IncidentPreviews)
+		resp, err := is.QueryIncidentPreviews(ctx, incident.QueryIncidentPreviewsRequest{
+			Query:  pageQuery,
+			Cursor: incidents.Cursor,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list incidents: %w", err)
+		}
+		incidents.IncidentPreviews = append(incidents.IncidentPreviews, resp.IncidentPreviews...)
+		incidents.Cursor = resp.Cursor
+		if !resp.Cursor.HasMore || len(resp.IncidentPreviews) == 0 {
+			break
+		}
+	}
+	if len(incidents.IncidentPreviews) > limit {
+		incidents.IncidentPreviews = incidents.IncidentPreviews[:limit]
 	}
 	return incidents, nil
 }
 
 var ListIncidents = mcpgrafana.MustTool(
 	"list_incidents",
-	"List Grafana incidents. Allows filtering by status ('active', 'resolved') and optionally including drill incidents. Returns a preview list with basic details.",
+	"List Grafana incidents, most recent first. Allows filtering by status ('active', 'resolved') and optionally including drill incidents. Returns a preview list of up to 'limit' incidents (default 10, max 100) with basic details, such as their ID, title, severity, status and created time.",
 	listIncidents,
 	mcp.WithTitleAnnotation("List incidents"),
 	mcp.WithIdempotentHintAnnotation(true),
@@ -56,22 +92,103 @@ var ListIncidents = mcpgrafana.MustTool(
 )
 
 type CreateIncidentParams struct {
-	Title         string                   `json:"title" jsonschema:"description=The title of the incident"`
-	Severity      string                   `json:"severity" jsonschema:"description=The severity of the incident"`
+	Title         string                   `json:"title" jsonschema:"required,description=The title of the incident"`
+	Severity      string                   `json:"severity" jsonschema:"description=The severity of the incident\\, one of the severities configured in Grafana Incident (e.g. 'critical'\\, 'major' or 'minor')"`
 	RoomPrefix    string                   `json:"roomPrefix" jsonschema:"description=The prefix of the room to create the incident in"`
 	IsDrill       bool                     `json:"isDrill" jsonschema:"description=Whether the incident is a drill incident"`
 	Status        string                   `json:"status" jsonschema:"description=The status of the incident"`
-	AttachCaption string                   `json:"attachCaption" jsonschema:"description=The caption of the attachment"`
-	AttachURL     string                   `json:"attachUrl" jsonschema:"description=The URL of the attachment"`
-	Labels        []incident.IncidentLabel `json:"labels" jsonschema:"description=The labels to add to the incident"`
+	AttachCaption string                   `json:"attachCaption" jsonschema:"description=The caption of the attachment\\, e.g. the title of the dashboard which triggered the incident"`
+	AttachURL     string                   `json:"attachUrl" jsonschema:"description=The URL of the attachment\\, e.g. the URL of the dashboard which triggered the incident"`
+	Labels        []incident.IncidentLabel `json:"labels" jsonschema:"description=The labels to add to the incident. Only the label text is used; the key defaults to 'tags'"`
+}
+
+// incidentSeverity is a severity configured in Grafana Incident.
+type incidentSeverity struct {
+	SeverityID   string `json:"severityID"`
+	DisplayLabel string `json:"displayLabel"`
+}
+
+// fetchIncidentSeverities returns the severities configured in Grafana
+// Incident. incident-go has no client for the severities service, so it is
+// called directly, authenticating the way the generated clients do.
+func fetchIncidentSeverities(ctx context.Context, c *incident.Client) ([]incidentSeverity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.RemoteHost+"SeveritiesService.GetOrgSeverities", strings.NewReader("{}"))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Accept", "application/json; charset=utf-8")
+	if c.BeforeRequest != nil {
+		if err := c.BeforeRequest(req); err != nil {
+			return nil, err
+		}
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	var body struct {
+		Severities []incidentSeverity `json:"severities"`
+		Error      string             `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding severities (status %d): %w", resp.StatusCode, err)
+	}
+	if body.Error != "" {
+		return nil, errors.New(body.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return body.Severities, nil
+}
+
+// resolveIncidentSeverity checks severity against the severities configured
+// in Grafana Incident, ignoring case, and returns it as configured. If none
+// are configured, severity is passed on as is for the API to validate.
+func resolveIncidentSeverity(ctx context.Context, c *incident.Client, severity string) (string, error) {
+	severities, err := fetchIncidentSeverities(ctx, c)
+	if err != nil {
+		return "", fmt.Errorf("fetching incident severities to validate %q: %w", severity, err)
+	}
+	if len(severities) == 0 {
+		slog.Debug("No incident severities configured, not validating severity", "severity", severity)
+		return severity, nil
+	}
+	labels := make([]string, 0, len(severities))
+	for _, s := range severities {
+		if strings.EqualFold(s.DisplayLabel, severity) || s.SeverityID == severity {
+			return s.DisplayLabel, nil
+		}
+		labels = append(labels, s.DisplayLabel)
+	}
+	return "", fmt.Errorf("invalid severity %q, must be one of: %s", severity, strings.Join(labels, ", "))
 }
 
 func createIncident(ctx context.Context, args CreateIncidentParams) (*incident.Incident, error) {
+	if strings.TrimSpace(args.Title) == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
 	c := mcpgrafana.IncidentClientFromContext(ctx)
+	severity := args.Severity
+	if severity != "" {
+		var err error
+		if severity, err = resolveIncidentSeverity(ctx, c, severity); err != nil {
+			return nil, err
+		}
+	}
+
 	is := incident.NewIncidentsService(c)
 	incident, err := is.CreateIncident(ctx, incident.CreateIncidentRequest{
 		Title:         args.Title,
-		Severity:      args.Severity,
+		Severity:      severity,
 		RoomPrefix:    args.RoomPrefix,
 		IsDrill:       args.IsDrill,
 		Status:        args.Status,
@@ -85,20 +202,27 @@ func createIncident(ctx context.Context, args CreateIncidentParams) (*incident.I
 	return &incident.Incident, nil
 }
 
-var CreateIncident = mcpgrafana.MustTool(
+var CreateIncident = mcpgrafana.MustMutatingTool(
 	"create_incident",
-	"Create a new Grafana incident. Requires title, severity, and room prefix. Allows setting status and labels. This tool should be used judiciously and sparingly, and only after confirmation from the user, as it may notify or alarm lots of people.",
+	"Create a new Grafana incident. Requires title, severity, and room prefix. The severity must be one of those configured in Grafana Incident. Allows setting status and labels, and attaching a link, such as the dashboard which triggered the incident. This tool should be used judiciously and sparingly, and only after confirmation from the user, as it may notify or alarm lots of people.",
 	createIncident,
 	mcp.WithTitleAnnotation("Create incident"),
 )
 
-type AddActivityToIncidentParams struct {
-	IncidentID string `json:"incidentId" jsonschema:"description=The ID of the incident to add the activity to"`
-	Body       string `json:"body" jsonschema:"description=The body of the activity. URLs will be parsed and attached as context"`
-	EventTime  string `json:"eventTime" jsonschema:"description=The time that the activity occurred. If not provided\\, the current time will be used"`
+type AddIncidentActivityParams struct {
+	IncidentID string `json:"incidentId" jsonschema:"required,description=The ID of the incident to add the activity to"`
+	Body       string `json:"body" jsonschema:"required,description=The body of the activity. URLs will be parsed and attached as context"`
+	EventTime  string `json:"eventTime,omitempty" jsonschema:"description=The time that the activity occurred. If not provided\\, the current time will be used"`
 }
 
-func addActivityToIncident(ctx context.Context, args AddActivityToIncidentParams) (*incident.ActivityItem, error) {
+func addIncidentActivity(ctx context.Context, args AddIncidentActivityParams) (*incident.ActivityItem, error) {
+	if strings.TrimSpace(args.IncidentID) == "" {
+		return nil, fmt.Errorf("incidentId is required")
+	}
+	if strings.TrimSpace(args.Body) == "" {
+		return nil, fmt.Errorf("body is required")
+	}
+
 	c := mcpgrafana.IncidentClientFromContext(ctx)
 	as := incident.NewActivityService(c)
 	activity, err := as.AddActivity(ctx, incident.AddActivityRequest{
@@ -108,15 +232,24 @@ func addActivityToIncident(ctx context.Context, args AddActivityToIncidentParams
 		EventTime:    args.EventTime,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("add activity to incident: %w", err)
+		return nil, fmt.Errorf("add activity to incident %s: %w", args.IncidentID, err)
 	}
 	return &activity.ActivityItem, nil
 }
 
-var AddActivityToIncident = mcpgrafana.MustTool(
+var AddIncidentActivity = mcpgrafana.MustMutatingTool(
+	"add_incident_activity",
+	"Add a note (userNote activity) to an existing incident's timeline using its ID. The note body can include URLs which will be attached as context. Use this to add context to an incident. Returns the created activity, including its activityItemID, createdTime and eventTime.",
+	addIncidentActivity,
+	mcp.WithTitleAnnotation("Add activity to incident"),
+)
+
+// AddActivityToIncident keeps the tool's previous name working for existing
+// clients.
+var AddActivityToIncident = mcpgrafana.MustMutatingTool(
 	"add_activity_to_incident",
-	"Add a note (userNote activity) to an existing incident's timeline using its ID. The note body can include URLs which will be attached as context. Use this to add context to an incident.",
-	addActivityToIncident,
+	"Deprecated: use add_incident_activity instead. Add a note (userNote activity) to an existing incident's timeline using its ID.",
+	addIncidentActivity,
 	mcp.WithTitleAnnotation("Add activity to incident"),
 )
 
@@ -124,6 +257,7 @@ func AddIncidentTools(mcp *server.MCPServer, enableWriteTools bool) {
 	ListIncidents.Register(mcp)
 	if enableWriteTools {
 		CreateIncident.Register(mcp)
+		AddIncidentActivity.Register(mcp)
 		AddActivityToIncident.Register(mcp)
 	}
 	GetIncident.Register(mcp)
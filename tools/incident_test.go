@@ -5,10 +5,17 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/grafana/incident-go"
 	mcpgrafana "github.com/grafana/mcp-grafana"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -30,9 +37,10 @@ func TestIncidentTools(t *testing.T) {
 
 	t.Run("create incident", func(t *testing.T) {
 		ctx := newIncidentTestContext()
+		// The stub client can't fetch the configured severities to validate
+		// one against, so no severity is given; see TestCreateIncident.
 		result, err := createIncident(ctx, CreateIncidentParams{
 			Title:         "high latency in web requests",
-			Severity:      "minor",
 			RoomPrefix:    "test",
 			IsDrill:       true,
 			Status:        "active",
@@ -46,9 +54,9 @@ func TestIncidentTools(t *testing.T) {
 		assert.Equal(t, "active", result.Status)
 	})
 
-	t.Run("add activity to incident", func(t *testing.T) {
+	t.Run("add incident activity", func(t *testing.T) {
 		ctx := newIncidentTestContext()
-		result, err := addActivityToIncident(ctx, AddActivityToIncidentParams{
+		result, err := addIncidentActivity(ctx, AddIncidentActivityParams{
 			IncidentID: "123",
 			Body:       "The incident was created by user-123",
 			EventTime:  "2021-08-07T11:58:23Z",
@@ -58,3 +66,221 @@ func TestIncidentTools(t *testing.T) {
 		assert.Equal(t, "2021-08-07T11:58:23Z", result.EventTime)
 	})
 }
+
+func TestListIncidents(t *testing.T) {
+	// The server holds 150 incidents, every third resolved, and returns them
+	// in pages of at most 40, honouring the status filter.
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/IncidentsService.QueryIncidentPreviews", r.URL.Path)
+		var req incident.QueryIncidentPreviewsRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		queries = append(queries, req.Query.QueryString)
+
+		var matching []incident.IncidentPreview
+		for i := range 150 {
+			status := "active"
+			if i%3 == 2 {
+				status = "resolved"
+			}
+			if strings.Contains(req.Query.QueryString, "status:") && !strings.Contains(req.Query.QueryString, "status:"+status) {
+				continue
+			}
+			matching = append(matching, incident.IncidentPreview{
+				IncidentID:    strconv.Itoa(i),
+				Title:         fmt.Sprintf("incident %d", i),
+				SeverityLabel: "minor",
+				Status:        status,
+				CreatedTime:   "2025-01-02T03:04:05Z",
+			})
+		}
+		start := 0
+		if req.Cursor.NextValue != "" {
+			start, _ = strconv.Atoi(req.Cursor.NextValue)
+		}
+		end := min(start+min(req.Query.Limit, 40), len(matching))
+
+		resp := incident.QueryIncidentPreviewsResponse{IncidentPreviews: matching[start:end]}
+		if end < len(matching) {
+			resp.Cursor = incident.Cursor{HasMore: true, NextValue: strconv.Itoa(end)}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer server.Close()
+	ctx := mcpgrafana.WithIncidentClient(context.Background(), incident.NewClient(server.URL+"/api/v1/", "token"))
+
+	t.Run("active only", func(t *testing.T) {
+		queries = nil
+		result, err := listIncidents(ctx, ListIncidentsParams{Status: "active", Limit: 5})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"isdrill:false status:active"}, queries)
+		require.Len(t, result.IncidentPreviews, 5)
+		for _, inc := range result.IncidentPreviews {
+			assert.Equal(t, "active", inc.Status)
+		}
+		assert.Equal(t, incident.IncidentPreview{IncidentID: "0", Title: "incident 0", SeverityLabel: "minor", Status: "active", CreatedTime: "2025-01-02T03:04:05Z"}, result.IncidentPreviews[0])
+		assert.Equal(t, 5, result.Query.Limit)
+		assert.True(t, result.Cursor.HasMore)
+	})
+
+	t.Run("follows the cursor up to the limit", func(t *testing.T) {
+		queries = nil
+		result, err := listIncidents(ctx, ListIncidentsParams{Limit: 90})
+		require.NoError(t, err)
+		assert.Len(t, result.IncidentPreviews, 90)
+		assert.Len(t, queries, 3)
+		assert.Equal(t, "89", result.IncidentPreviews[89].IncidentID)
+	})
+
+	t.Run("limit is capped", func(t *testing.T) {
+		result, err := listIncidents(ctx, ListIncidentsParams{Limit: 1000, Drill: true})
+		require.NoError(t, err)
+		assert.Len(t, result.IncidentPreviews, maxIncidentLimit)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		_, err := listIncidents(ctx, ListIncidentsParams{Status: "closed"})
+		require.EqualError(t, err, `invalid status "closed", must be 'active' or 'resolved'`)
+	})
+}
+
+func TestCreateIncident(t *testing.T) {
+	var created *incident.CreateIncidentRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/v1/SeveritiesService.GetOrgSeverities":
+			_, _ = w.Write([]byte(`{"severities": [
+				{"severityID": "sev-1", "displayLabel": "Critical", "level": 1},
+				{"severityID": "sev-2", "displayLabel": "Major", "level": 2},
+				{"severityID": "sev-3", "displayLabel": "Minor", "level": 3}
+			]}`))
+		case "/api/v1/IncidentsService.CreateIncident":
+			var req incident.CreateIncidentRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			created = &req
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"incident": map[string]any{
+				"incidentID": "7",
+				"title":      req.Title,
+				"severity":   req.Severity,
+				"status":     "active",
+				"labels":     req.Labels,
+			}}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	ctx := mcpgrafana.WithIncidentClient(context.Background(), incident.NewClient(server.URL+"/api/v1/", "token"))
+
+	t.Run("with labels and an attachment", func(t *testing.T) {
+		created = nil
+		result, err := createIncident(ctx, CreateIncidentParams{
+			Title:         "Checkout errors",
+			Severity:      "critical",
+			RoomPrefix:    "inc",
+			AttachCaption: "Checkout dashboard",
+			AttachURL:     "https://grafana.example.com/d/checkout",
+			Labels:        []incident.IncidentLabel{{Label: "checkout"}, {Key: "team", Label: "payments"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "7", result.IncidentID)
+		assert.Equal(t, "Critical", result.Severity)
+
+		require.NotNil(t, created)
+		assert.Equal(t, "Critical", created.Severity)
+		assert.Equal(t, "inc", created.RoomPrefix)
+		assert.Equal(t, "Checkout dashboard", created.AttachCaption)
+		assert.Equal(t, "https://grafana.example.com/d/checkout", created.AttachURL)
+		assert.Equal(t, []incident.IncidentLabel{{Label: "checkout"}, {Key: "team", Label: "payments"}}, created.Labels)
+	})
+
+	t.Run("invalid severity", func(t *testing.T) {
+		created = nil
+		_, err := createIncident(ctx, CreateIncidentParams{Title: "Checkout errors", Severity: "sev0", RoomPrefix: "inc"})
+		require.EqualError(t, err, `invalid severity "sev0", must be one of: Critical, Major, Minor`)
+		assert.Nil(t, created)
+	})
+
+	t.Run("requires a title", func(t *testing.T) {
+		_, err := createIncident(ctx, CreateIncidentParams{Severity: "minor"})
+		require.EqualError(t, err, "title is required")
+	})
+
+	t.Run("severities can't be fetched", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v1/SeveritiesService.GetOrgSeverities", r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error": "permission denied"}`))
+		}))
+		defer failing.Close()
+		ctx := mcpgrafana.WithIncidentClient(context.Background(), incident.NewClient(failing.URL+"/api/v1/", "token"))
+
+		_, err := createIncident(ctx, CreateIncidentParams{Title: "Checkout errors", Severity: "critical", RoomPrefix: "inc"})
+		require.EqualError(t, err, `fetching incident severities to validate "critical": permission denied`)
+	})
+}
+
+func TestAddIncidentActivity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/ActivityService.AddActivity", r.URL.Path)
+		var req incident.AddActivityRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "userNote", req.ActivityKind)
+
+		w.Header().Set("Content-Type", "application/json")
+		if req.IncidentID != "42" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": "incident not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"activityItem": {
+			"activityItemID": "activity-1",
+			"incidentID": "42",
+			"activityKind": "userNote",
+			"body": "` + req.Body + `",
+			"createdTime": "2025-01-02T03:04:05Z",
+			"eventTime": "2025-01-02T03:04:05Z"
+		}}`))
+	}))
+	defer server.Close()
+	ctx := mcpgrafana.WithIncidentClient(context.Background(), incident.NewClient(server.URL+"/api/v1/", "token"))
+
+	t.Run("creates a note", func(t *testing.T) {
+		result, err := addIncidentActivity(ctx, AddIncidentActivityParams{
+			IncidentID: "42",
+			Body:       "Rolled back the deployment",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "activity-1", result.ActivityItemID)
+		assert.Equal(t, "2025-01-02T03:04:05Z", result.CreatedTime)
+		assert.Equal(t, "Rolled back the deployment", result.Body)
+	})
+
+	t.Run("invalid incident id", func(t *testing.T) {
+		_, err := addIncidentActivity(ctx, AddIncidentActivityParams{
+			IncidentID: "missing",
+			Body:       "Rolled back the deployment",
+		})
+		require.EqualError(t, err, "add activity to incident missing: incident not found")
+	})
+
+	t.Run("requires an incident id and body", func(t *testing.T) {
+		_, err := addIncidentActivity(ctx, AddIncidentActivityParams{Body: "note"})
+		require.EqualError(t, err, "incidentId is required")
+		_, err = addIncidentActivity(ctx, AddIncidentActivityParams{IncidentID: "42"})
+		require.EqualError(t, err, "body is required")
+	})
+
+	t.Run("previous tool name", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"incidentId": "42", "body": "Rolled back the deployment"}
+		result, err := AddActivityToIncident.Handler(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "activity-1")
+	})
+}
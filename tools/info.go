@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/runtime"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/grafana/grafana-openapi-client-go/client/admin"
+	"github.com/grafana/grafana-openapi-client-go/client/datasources"
+	"github.com/grafana/grafana-openapi-client-go/client/health"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+type GetGrafanaInfoParams struct{}
+
+// GrafanaInfo describes the Grafana instance the server is connected to.
+type GrafanaInfo struct {
+	Version  string `json:"version"`
+	Commit   string `json:"commit,omitempty"`
+	Database string `json:"database"`
+	// UnifiedAlertingEnabled is nil when it couldn't be determined, e.g.
+	// because the admin settings aren't accessible.
+	UnifiedAlertingEnabled *bool  `json:"unifiedAlertingEnabled,omitempty"`
+	Note                   string `json:"note,omitempty"`
+}
+
+func getGrafanaInfo(ctx context.Context, args GetGrafanaInfoParams) (*GrafanaInfo, error) {
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	health, err := c.Health.GetHealthWithParams(health.NewGetHealthParamsWithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get health: %w", err)
+	}
+	info := &GrafanaInfo{
+		Version:  health.Payload.Version,
+		Commit:   health.Payload.Commit,
+		Database: health.Payload.Database,
+	}
+
+	settings, err := c.Admin.AdminGetSettingsWithParams(admin.NewAdminGetSettingsParamsWithContext(ctx))
+	if err != nil {
+		var unauthorized *admin.AdminGetSettingsUnauthorized
+		var forbidden *admin.AdminGetSettingsForbidden
+		if errors.As(err, &unauthorized) || errors.As(err, &forbidden) {
+			info.Note = "admin settings are not accessible with the current credentials, so whether unified alerting is enabled is unknown"
+			return info, nil
+		}
+		return nil, fmt.Errorf("get admin settings: %w", err)
+	}
+	info.UnifiedAlertingEnabled = unifiedAlertingEnabled(settings.Payload["unified_alerting"]["enabled"], info.Version)
+	return info, nil
+}
+
+// unifiedAlertingEnabled interprets the unified_alerting.enabled setting. It
+// is unset by default, in which case unified alerting is enabled from
+// Grafana 9 onwards.
+func unifiedAlertingEnabled(setting, version string) *bool {
+	if enabled, err := strconv.ParseBool(setting); err == nil {
+		return &enabled
+	}
+	major, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2)[0])
+	if err != nil {
+		return nil
+	}
+	enabled := major >= 9
+	return &enabled
+}
+
+var GetGrafanaInfo = mcpgrafana.MustTool(
+	"get_grafana_info",
+	"Get information about the connected Grafana instance: its version, commit, database health and whether unified alerting is enabled. Use this to decide which features and APIs are available. Whether unified alerting is enabled is only reported when the credentials can read the admin settings.",
+	getGrafanaInfo,
+	mcp.WithTitleAnnotation("Get Grafana info"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+const (
+	// maxSelftestDatasources caps the number of datasources selftest checks
+	// when none are named, so that instances with hundreds of datasources
+	// don't turn it into a long running scan.
+	maxSelftestDatasources = 50
+
+	// maxConcurrentHealthChecks is the number of datasource health checks
+	// selftest runs at once.
+	maxConcurrentHealthChecks = 8
+)
+
+type SelftestParams struct {
+	DatasourceUIDs []string `json:"datasourceUids,omitempty" jsonschema:"description=The UIDs of the datasources to check. Defaults to all datasources (up to 50)"`
+}
+
+// SelftestCheck is the outcome of checking one target: Grafana itself or a
+// datasource. Datasources whose plugin has no health check are skipped.
+type SelftestCheck struct {
+	Target  string `json:"target"`
+	UID     string `json:"uid,omitempty"`
+	Type    string `json:"type,omitempty"`
+	OK      bool   `json:"ok"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SelftestResult summarizes a selftest run. OK is true if every check passed
+// or was skipped.
+type SelftestResult struct {
+	OK     bool            `json:"ok"`
+	Checks []SelftestCheck `json:"checks"`
+	Note   string          `json:"note,omitempty"`
+}
+
+func selftest(ctx context.Context, args SelftestParams) (*SelftestResult, error) {
+	c := mcpgrafana.GrafanaClientFromContext(ctx)
+	result := &SelftestResult{}
+
+	grafana := SelftestCheck{Target: "grafana"}
+	if health, err := c.Health.GetHealthWithParams(health.NewGetHealthParamsWithContext(ctx)); err != nil {
+		grafana.Error = err.Error()
+	} else {
+		grafana.OK = health.Payload.Database == "ok"
+		grafana.Message = fmt.Sprintf("Grafana %s, database %s", health.Payload.Version, health.Payload.Database)
+	}
+	result.Checks = append(result.Checks, grafana)
+
+	// A failure to list or check datasources is recorded as a failed check
+	// rather than returned, so that the other checks are still reported.
+	var targets []SelftestCheck
+	if len(args.DatasourceUIDs) > 0 {
+		for _, uid := range args.DatasourceUIDs {
+			targets = append(targets, SelftestCheck{Target: "datasource", UID: uid})
+		}
+	} else {
+		resp, err := c.Datasources.GetDataSourcesWithParams(datasources.NewGetDataSourcesParamsWithContext(ctx))
+		if err != nil {
+			result.Checks = append(result.Checks, SelftestCheck{Target: "datasources", Error: fmt.Sprintf("list datasources: %s", err)})
+		} else {
+			for _, ds := range resp.Payload {
+				targets = append(targets, SelftestCheck{Target: "datasource " + ds.Name, UID: ds.UID, Type: ds.Type})
+			}
+			if len(targets) > maxSelftestDatasources {
+				result.Note = fmt.Sprintf("only the first %d of %d datasources were checked, name the others in datasourceUids to check them", maxSelftestDatasources, len(targets))
+				targets = targets[:maxSelftestDatasources]
+			}
+		}
+	}
+
+	sem := make(chan struct{}, maxConcurrentHealthChecks)
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(check *SelftestCheck) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			resp, err := c.Datasources.CheckDatasourceHealthWithUIDWithParams(datasources.NewCheckDatasourceHealthWithUIDParamsWithContext(ctx).WithUID(check.UID))
+			if err != nil {
+				if ds, ok := healthCheckNotSupported(ctx, check.UID, err); ok {
+					check.Skipped = true
+					check.Message = fmt.Sprintf("the %s plugin doesn't support health checks", ds.Type)
+					return
+				}
+				check.Error = healthCheckError(err)
+				return
+			}
+			check.OK = true
+			if resp.Payload != nil {
+				check.Message = resp.Payload.Message
+			}
+		}(&targets[i])
+	}
+	wg.Wait()
+	result.Checks = append(result.Checks, targets...)
+
+	result.OK = true
+	for _, check := range result.Checks {
+		result.OK = result.OK && (check.OK || check.Skipped)
+	}
+	return result, nil
+}
+
+// healthCheckNotSupported reports whether a failed health check means the
+// datasource's plugin has no health check, returning the datasource. As in
+// checkDatasourceHealth, Grafana reports this as a 404 or 501, and the
+// datasource is looked up to tell it apart from a missing datasource.
+func healthCheckNotSupported(ctx context.Context, uid string, err error) (*models.DataSource, bool) {
+	var apiErr *runtime.APIError
+	if !errors.As(err, &apiErr) || !(apiErr.IsCode(http.StatusNotFound) || apiErr.IsCode(http.StatusNotImplemented)) {
+		return nil, false
+	}
+	ds, err := datasourceInfo(ctx, uid)
+	if err != nil {
+		return nil, false
+	}
+	return ds, true
+}
+
+// healthCheckError returns the message of a failed datasource health check,
+// which Grafana reports in the body of an error response.
+func healthCheckError(err error) string {
+	var (
+		badRequest   *datasources.CheckDatasourceHealthWithUIDBadRequest
+		unauthorized *datasources.CheckDatasourceHealthWithUIDUnauthorized
+		forbidden    *datasources.CheckDatasourceHealthWithUIDForbidden
+		internal     *datasources.CheckDatasourceHealthWithUIDInternalServerError
+	)
+	var payload *models.ErrorResponseBody
+	switch {
+	case errors.As(err, &badRequest):
+		payload = badRequest.Payload
+	case errors.As(err, &unauthorized):
+		payload = unauthorized.Payload
+	case errors.As(err, &forbidden):
+		payload = forbidden.Payload
+	case errors.As(err, &internal):
+		payload = internal.Payload
+	}
+	if payload != nil && payload.Message != nil && *payload.Message != "" {
+		return *payload.Message
+	}
+	return err.Error()
+}
+
+var Selftest = mcpgrafana.MustTool(
+	"selftest",
+	"Check that the server can reach Grafana and that each datasource is healthy. Calls Grafana's health endpoint and then the health check of every datasource (or only those given in datasourceUids), returning a per-target summary with 'ok' and the health message or error. Datasources whose plugin has no health check are marked 'skipped' rather than failed. A failing datasource doesn't stop the other checks. Use this to diagnose connectivity or configuration problems.",
+	selftest,
+	mcp.WithTitleAnnotation("Run self-test"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+func AddInfoTools(mcp *server.MCPServer) {
+	GetGrafanaInfo.Register(mcp)
+	Selftest.Register(mcp)
+}
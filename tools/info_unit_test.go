@@ -0,0 +1,200 @@
+//go:build unit
+
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGrafanaInfoTestServer(t *testing.T, settingsStatus int, settings string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/health":
+			_, _ = w.Write([]byte(`{"commit": "abc123", "database": "ok", "version": "11.3.0"}`))
+		case "/api/admin/settings":
+			w.WriteHeader(settingsStatus)
+			_, _ = w.Write([]byte(settings))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGetGrafanaInfo(t *testing.T) {
+	t.Run("full access", func(t *testing.T) {
+		server := newGrafanaInfoTestServer(t, http.StatusOK, `{"unified_alerting": {"enabled": "false"}}`)
+		defer server.Close()
+
+		info, err := getGrafanaInfo(mockCtxWithClient(server), GetGrafanaInfoParams{})
+		require.NoError(t, err)
+		assert.Equal(t, "11.3.0", info.Version)
+		assert.Equal(t, "abc123", info.Commit)
+		assert.Equal(t, "ok", info.Database)
+		require.NotNil(t, info.UnifiedAlertingEnabled)
+		assert.False(t, *info.UnifiedAlertingEnabled)
+		assert.Empty(t, info.Note)
+	})
+
+	t.Run("unset setting defaults by version", func(t *testing.T) {
+		server := newGrafanaInfoTestServer(t, http.StatusOK, `{"unified_alerting": {}}`)
+		defer server.Close()
+
+		info, err := getGrafanaInfo(mockCtxWithClient(server), GetGrafanaInfoParams{})
+		require.NoError(t, err)
+		require.NotNil(t, info.UnifiedAlertingEnabled)
+		assert.True(t, *info.UnifiedAlertingEnabled)
+	})
+
+	t.Run("restricted access falls back to health", func(t *testing.T) {
+		server := newGrafanaInfoTestServer(t, http.StatusForbidden, `{"message": "Permission denied"}`)
+		defer server.Close()
+
+		info, err := getGrafanaInfo(mockCtxWithClient(server), GetGrafanaInfoParams{})
+		require.NoError(t, err)
+		assert.Equal(t, "11.3.0", info.Version)
+		assert.Equal(t, "ok", info.Database)
+		assert.Nil(t, info.UnifiedAlertingEnabled)
+		assert.Contains(t, info.Note, "not accessible")
+	})
+
+	t.Run("other settings errors are returned", func(t *testing.T) {
+		server := newGrafanaInfoTestServer(t, http.StatusInternalServerError, `{"message": "boom"}`)
+		defer server.Close()
+
+		_, err := getGrafanaInfo(mockCtxWithClient(server), GetGrafanaInfoParams{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "get admin settings")
+	})
+}
+
+func TestUnifiedAlertingEnabled(t *testing.T) {
+	enabled := unifiedAlertingEnabled("true", "8.5.0")
+	require.NotNil(t, enabled)
+	assert.True(t, *enabled)
+
+	enabled = unifiedAlertingEnabled("", "8.5.0")
+	require.NotNil(t, enabled)
+	assert.False(t, *enabled)
+
+	enabled = unifiedAlertingEnabled("", "v10.0.0")
+	require.NotNil(t, enabled)
+	assert.True(t, *enabled)
+
+	assert.Nil(t, unifiedAlertingEnabled("", "unknown"))
+}
+
+// selftestDatasourceTypes are the types of the datasources served by
+// newSelftestServer.
+var selftestDatasourceTypes = map[string]string{"prom-uid": "prometheus", "loki-uid": "loki", "tempo-uid": "tempo"}
+
+// newSelftestServer serves the given health check statuses by datasource UID;
+// datasources without a status have no health check.
+func newSelftestServer(t *testing.T, health map[string]int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/health":
+			_, _ = w.Write([]byte(`{"commit": "abc123", "database": "ok", "version": "11.3.0"}`))
+			return
+		case "/api/datasources":
+			_, _ = w.Write([]byte(`[
+				{"id": 1, "uid": "prom-uid", "name": "Prometheus", "type": "prometheus"},
+				{"id": 2, "uid": "loki-uid", "name": "Loki", "type": "loki"},
+				{"id": 3, "uid": "tempo-uid", "name": "Tempo", "type": "tempo"}
+			]`))
+			return
+		}
+		uid, isHealth := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/datasources/uid/"), "/health")
+		if !isHealth {
+			if typ, ok := selftestDatasourceTypes[uid]; ok {
+				_, _ = fmt.Fprintf(w, `{"uid": %q, "type": %q}`, uid, typ)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Data source not found"}`))
+			return
+		}
+		status, ok := health[uid]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Data source not found"}`))
+			return
+		}
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte(`{"message": "Data source is working", "status": "OK"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"message": "dial tcp: connection refused", "status": "ERROR"}`))
+	}))
+}
+
+func TestSelftest(t *testing.T) {
+	grafana := SelftestCheck{Target: "grafana", OK: true, Message: "Grafana 11.3.0, database ok"}
+
+	t.Run("all healthy", func(t *testing.T) {
+		server := newSelftestServer(t, map[string]int{"prom-uid": http.StatusOK, "loki-uid": http.StatusOK, "tempo-uid": http.StatusOK})
+		defer server.Close()
+
+		result, err := selftest(mockCtxWithClient(server), SelftestParams{})
+		require.NoError(t, err)
+		assert.Equal(t, &SelftestResult{
+			OK: true,
+			Checks: []SelftestCheck{
+				grafana,
+				{Target: "datasource Prometheus", UID: "prom-uid", Type: "prometheus", OK: true, Message: "Data source is working"},
+				{Target: "datasource Loki", UID: "loki-uid", Type: "loki", OK: true, Message: "Data source is working"},
+				{Target: "datasource Tempo", UID: "tempo-uid", Type: "tempo", OK: true, Message: "Data source is working"},
+			},
+		}, result)
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		server := newSelftestServer(t, map[string]int{"prom-uid": http.StatusOK, "loki-uid": http.StatusBadRequest, "tempo-uid": http.StatusOK})
+		defer server.Close()
+
+		result, err := selftest(mockCtxWithClient(server), SelftestParams{})
+		require.NoError(t, err)
+		assert.False(t, result.OK)
+		require.Len(t, result.Checks, 4)
+		assert.Equal(t, grafana, result.Checks[0])
+		assert.True(t, result.Checks[1].OK)
+		assert.Equal(t, SelftestCheck{Target: "datasource Loki", UID: "loki-uid", Type: "loki", Error: "dial tcp: connection refused"}, result.Checks[2])
+		assert.True(t, result.Checks[3].OK)
+	})
+
+	t.Run("datasources without a health check are skipped", func(t *testing.T) {
+		server := newSelftestServer(t, map[string]int{"prom-uid": http.StatusOK, "loki-uid": http.StatusOK})
+		defer server.Close()
+
+		result, err := selftest(mockCtxWithClient(server), SelftestParams{})
+		require.NoError(t, err)
+		assert.True(t, result.OK)
+		require.Len(t, result.Checks, 4)
+		assert.Equal(t, SelftestCheck{Target: "datasource Tempo", UID: "tempo-uid", Type: "tempo", Skipped: true, Message: "the tempo plugin doesn't support health checks"}, result.Checks[3])
+	})
+
+	t.Run("named datasources", func(t *testing.T) {
+		server := newSelftestServer(t, map[string]int{"prom-uid": http.StatusOK})
+		defer server.Close()
+
+		result, err := selftest(mockCtxWithClient(server), SelftestParams{DatasourceUIDs: []string{"prom-uid", "missing-uid"}})
+		require.NoError(t, err)
+		assert.False(t, result.OK)
+		require.Len(t, result.Checks, 3)
+		assert.Equal(t, SelftestCheck{Target: "datasource", UID: "prom-uid", OK: true, Message: "Data source is working"}, result.Checks[1])
+		assert.Equal(t, "missing-uid", result.Checks[2].UID)
+		assert.False(t, result.Checks[2].OK)
+		assert.NotEmpty(t, result.Checks[2].Error)
+	})
+}
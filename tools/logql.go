@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+// logqlFormHint is appended to LogQL validation errors to show the expected
+// shape of a query.
+const logqlFormHint = `LogQL queries must contain a stream selector such as {app="foo"}, optionally followed by a pipeline (e.g. {app="foo"} |= "error") or wrapped in a metric query (e.g. rate({app="foo"}[5m]))`
+
+// validateLogQL performs a lightweight syntax check of a LogQL query so that
+// obviously broken queries get a helpful error instead of a cryptic one from
+// Loki. It is deliberately permissive: it only rejects empty queries,
+// unbalanced brackets, missing or empty stream selectors and malformed label
+// matchers, and leaves everything else to Loki. Errors are of kind
+// mcpgrafana.ErrKindValidation.
+func validateLogQL(query string) error {
+	if err := checkLogQL(query); err != nil {
+		return mcpgrafana.NewError(mcpgrafana.ErrKindValidation, "", err)
+	}
+	return nil
+}
+
+func checkLogQL(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("invalid LogQL query: query is empty. %s", logqlFormHint)
+	}
+
+	var stack []rune
+	selectorStart := -1
+	selectors := 0
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '"', '`':
+			// Skip string literals, which may contain any characters.
+			end := skipStringLiteral(runes, i)
+			if end < 0 {
+				return fmt.Errorf("invalid LogQL query: unterminated string starting at position %d. %s", i, logqlFormHint)
+			}
+			i = end
+		case '{', '(', '[':
+			if r == '{' {
+				selectors++
+				selectorStart = i
+				if j := nextNonSpace(runes, i+1); j < len(runes) && runes[j] == '}' {
+					return fmt.Errorf("invalid LogQL query: empty stream selector {}; at least one label matcher is required. %s", logqlFormHint)
+				}
+			}
+			stack = append(stack, r)
+		case '}', ')', ']':
+			open := map[rune]rune{'}': '{', ')': '(', ']': '['}[r]
+			if len(stack) == 0 || stack[len(stack)-1] != open {
+				return fmt.Errorf("invalid LogQL query: unexpected '%c' at position %d. %s", r, i, logqlFormHint)
+			}
+			stack = stack[:len(stack)-1]
+			if r == '}' {
+				if err := validateLabelMatchers(string(runes[selectorStart+1 : i])); err != nil {
+					return fmt.Errorf("invalid LogQL query: %w. %s", err, logqlFormHint)
+				}
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("invalid LogQL query: unclosed '%c'. %s", stack[len(stack)-1], logqlFormHint)
+	}
+	if selectors == 0 {
+		return fmt.Errorf("invalid LogQL query: missing stream selector. %s", logqlFormHint)
+	}
+	return nil
+}
+
+// isLogQLMetricQuery reports whether query is a metric query, such as
+// rate({app="foo"}[5m]), rather than a log query. Log queries always start
+// with their stream selector, while metric queries start with a function,
+// an aggregation or a parenthesis.
+func isLogQLMetricQuery(query string) bool {
+	return !strings.HasPrefix(strings.TrimSpace(query), "{")
+}
+
+func nextNonSpace(runes []rune, i int) int {
+	for i < len(runes) && (runes[i] == ' ' || runes[i] == '\t' || runes[i] == '\n' || runes[i] == '\r') {
+		i++
+	}
+	return i
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,14 +16,20 @@ import (
 	mcpgrafana "github.com/grafana/mcp-grafana"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/common/model"
 )
 
 const (
 	// DefaultLokiLogLimit is the default number of log lines to return if not specified
-	DefaultLokiLogLimit = 10
+	DefaultLokiLogLimit = 100
 
 	// MaxLokiLogLimit is the maximum number of log lines that can be requested
-	MaxLokiLogLimit = 100
+	MaxLokiLogLimit = 5000
+
+	// defaultLokiLogContextLines and maxLokiLogContextLines bound the number
+	// of lines get_loki_log_context returns on either side of a log line.
+	defaultLokiLogContextLines = 10
+	maxLokiLogContextLines     = 100
 )
 
 type Client struct {
@@ -59,23 +66,38 @@ type Pattern struct {
 	TotalCount int64  `json:"totalCount"`
 }
 
-func newLokiClient(ctx context.Context, uid string) (*Client, error) {
+// newLokiClient returns a client querying the Loki datasource uid through
+// Grafana's datasource proxy. tenantID, if set, selects the tenant queried on
+// a multi-tenant Loki; see mcpgrafana.NewTenantIDRoundTripper.
+func newLokiClient(ctx context.Context, uid, tenantID string) (*Client, error) {
 	// First check if the datasource exists
-	_, err := getDatasourceByUID(ctx, GetDatasourceByUIDParams{UID: uid})
+	ds, err := datasourceInfo(ctx, uid)
 	if err != nil {
 		return nil, err
 	}
 
 	cfg := mcpgrafana.GrafanaConfigFromContext(ctx)
-	url := fmt.Sprintf("%s/api/datasources/proxy/uid/%s", strings.TrimRight(cfg.URL, "/"), uid)
 
 	// Create custom transport with TLS configuration if available
 	transport, err := mcpgrafana.BuildTransport(&cfg, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create custom transport: %w", err)
 	}
-	transport = NewAuthRoundTripper(transport, cfg.AccessToken, cfg.IDToken, cfg.APIKey, cfg.BasicAuth)
-	transport = mcpgrafana.NewOrgIDRoundTripper(transport, cfg.OrgID)
+
+	var url string
+	if endpoint, ok := mcpgrafana.GrafanaCloudEndpointFor(cfg, ds.URL, ds.BasicAuthUser); ok {
+		url = endpoint.URL
+		transport = endpoint.RoundTripper(transport)
+	} else {
+		url = fmt.Sprintf("%s/api/datasources/proxy/uid/%s", strings.TrimRight(cfg.URL, "/"), uid)
+		transport = NewAuthRoundTripper(transport, cfg.AccessToken, cfg.IDToken, cfg.APIKey, cfg.BasicAuth)
+		transport = mcpgrafana.NewOrgIDRoundTripper(transport, cfg.OrgID)
+	}
+	transport = newDatasourceAccessRoundTripper(transport, uid, cfg)
+	transport, err = mcpgrafana.NewTenantIDRoundTripper(transport, cfg, tenantID)
+	if err != nil {
+		return nil, err
+	}
 
 	client := &http.Client{
 		Transport: mcpgrafana.NewUserAgentTransport(
@@ -129,8 +151,8 @@ func (c *Client) makeRequest(ctx context.Context, method, urlPath string, params
 
 	// Check for non-200 status code
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("loki API returned status code %d: %s", resp.StatusCode, string(bodyBytes))
+		bodyBytes := readErrorBody(resp.Body)
+		return nil, mcpgrafana.NewHTTPError(resp.StatusCode, fmt.Sprintf("loki API returned status code %d: %s", resp.StatusCode, summarizeErrorBody(bodyBytes)))
 	}
 
 	// Read the response body with a limit to prevent memory issues
@@ -149,14 +171,26 @@ func (c *Client) makeRequest(ctx context.Context, method, urlPath string, params
 	return bytes.TrimSpace(bodyBytes), nil
 }
 
-// fetchData is a generic method to fetch data from Loki API
-func (c *Client) fetchData(ctx context.Context, urlPath string, startRFC3339, endRFC3339 string) ([]string, error) {
-	params := url.Values{}
-	if startRFC3339 != "" {
-		params.Add("start", startRFC3339)
+// fetchData is a generic method to fetch label data from Loki API. The query
+// is an optional stream selector restricting the streams considered. The
+// time range defaults to the default query range.
+func (c *Client) fetchData(ctx context.Context, urlPath string, query, startRFC3339, endRFC3339 string) ([]string, error) {
+	startRFC3339, endRFC3339 = getDefaultTimeRange(ctx, startRFC3339, endRFC3339)
+	// Resolved to whole seconds, so that label requests for relative times
+	// made in quick succession can share cached responses.
+	start, err := parseTime(startRFC3339)
+	if err != nil {
+		return nil, fmt.Errorf("parsing start time: %w", err)
 	}
-	if endRFC3339 != "" {
-		params.Add("end", endRFC3339)
+	end, err := parseTime(endRFC3339)
+	if err != nil {
+		return nil, fmt.Errorf("parsing end time: %w", err)
+	}
+	params := url.Values{}
+	params.Add("start", start.UTC().Format(time.RFC3339))
+	params.Add("end", end.UTC().Format(time.RFC3339))
+	if query != "" {
+		params.Add("query", query)
 	}
 
 	bodyBytes, err := c.makeRequest(ctx, "GET", urlPath, params)
@@ -227,18 +261,19 @@ func (rt *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 // ListLokiLabelNamesParams defines the parameters for listing Loki label names
 type ListLokiLabelNamesParams struct {
 	DatasourceUID string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
-	StartRFC3339  string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query in RFC3339 format (defaults to 1 hour ago)"`
-	EndRFC3339    string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query in RFC3339 format (defaults to now)"`
+	StartRFC3339  string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndRFC3339    string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query as RFC3339\\, Unix seconds or relative to now. Defaults to now"`
+	TenantID      string `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Loki\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
 }
 
 // listLokiLabelNames lists all label names in a Loki datasource
 func listLokiLabelNames(ctx context.Context, args ListLokiLabelNamesParams) ([]string, error) {
-	client, err := newLokiClient(ctx, args.DatasourceUID)
+	client, err := newLokiClient(ctx, args.DatasourceUID, args.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("creating Loki client: %w", err)
 	}
 
-	result, err := client.fetchData(ctx, "/loki/api/v1/labels", args.StartRFC3339, args.EndRFC3339)
+	result, err := client.fetchData(ctx, "/loki/api/v1/labels", "", args.StartRFC3339, args.EndRFC3339)
 	if err != nil {
 		return nil, err
 	}
@@ -264,21 +299,29 @@ var ListLokiLabelNames = mcpgrafana.MustTool(
 type ListLokiLabelValuesParams struct {
 	DatasourceUID string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
 	LabelName     string `json:"labelName" jsonschema:"required,description=The name of the label to retrieve values for (e.g. 'app'\\, 'env'\\, 'pod')"`
-	StartRFC3339  string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query in RFC3339 format (defaults to 1 hour ago)"`
-	EndRFC3339    string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query in RFC3339 format (defaults to now)"`
+	StartRFC3339  string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndRFC3339    string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query as RFC3339\\, Unix seconds or relative to now. Defaults to now"`
+	Query         string `json:"query,omitempty" jsonschema:"description=Optionally\\, a LogQL stream selector (e.g. {namespace=\"prod\"}) restricting which streams values are returned for"`
+	TenantID      string `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Loki\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
 }
 
 // listLokiLabelValues lists all values for a specific label in a Loki datasource
 func listLokiLabelValues(ctx context.Context, args ListLokiLabelValuesParams) ([]string, error) {
-	client, err := newLokiClient(ctx, args.DatasourceUID)
+	if args.Query != "" {
+		if err := validateLogQL(args.Query); err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := newLokiClient(ctx, args.DatasourceUID, args.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("creating Loki client: %w", err)
 	}
 
 	// Use the client's fetchData method
-	urlPath := fmt.Sprintf("/loki/api/v1/label/%s/values", args.LabelName)
+	urlPath := fmt.Sprintf("/loki/api/v1/label/%s/values", url.PathEscape(args.LabelName))
 
-	result, err := client.fetchData(ctx, urlPath, args.StartRFC3339, args.EndRFC3339)
+	result, err := client.fetchData(ctx, urlPath, args.Query, args.StartRFC3339, args.EndRFC3339)
 	if err != nil {
 		return nil, err
 	}
@@ -288,13 +331,14 @@ func listLokiLabelValues(ctx context.Context, args ListLokiLabelValuesParams) ([
 		return []string{}, nil
 	}
 
+	sort.Strings(result)
 	return result, nil
 }
 
 // ListLokiLabelValues is a tool for listing Loki label values
 var ListLokiLabelValues = mcpgrafana.MustTool(
 	"list_loki_label_values",
-	"Retrieves all unique values associated with a specific `labelName` within a Loki datasource and time range. Returns a list of string values (e.g., for `labelName=\"env\"`, might return `[\"prod\", \"staging\", \"dev\"]`). Useful for discovering filter options. Defaults to the last hour if the time range is omitted.",
+	"Retrieves all unique values associated with a specific `labelName` within a Loki datasource and time range. Returns a sorted list of string values (e.g., for `labelName=\"env\"`, might return `[\"dev\", \"prod\", \"staging\"]`). Optionally restrict the values to streams matching a `query` stream selector. Useful for discovering filter options. Defaults to the last hour if the time range is omitted.",
 	listLokiLabelValues,
 	mcp.WithTitleAnnotation("List Loki label values"),
 	mcp.WithIdempotentHintAnnotation(true),
@@ -322,9 +366,21 @@ type lokiQueryResponse struct {
 	Data   struct {
 		ResultType string          `json:"resultType"` // "streams", "vector", or "matrix"
 		Result     json.RawMessage `json:"result"`     // Unmarshal based on resultType
+		Stats      struct {
+			Summary *LokiQueryStats `json:"summary"`
+		} `json:"stats"`
 	} `json:"data"`
 }
 
+// LokiQueryStats is the summary of the execution stats Loki returns with a
+// query result. ExecTime is in seconds.
+type LokiQueryStats struct {
+	BytesProcessedPerSecond int64   `json:"bytesProcessedPerSecond"`
+	TotalBytesProcessed     int64   `json:"totalBytesProcessed"`
+	TotalLinesProcessed     int64   `json:"totalLinesProcessed"`
+	ExecTime                float64 `json:"execTime"`
+}
+
 // MetricValue represents a single metric data point with timestamp and value
 type MetricValue struct {
 	Timestamp string  `json:"timestamp"`
@@ -332,10 +388,10 @@ type MetricValue struct {
 }
 
 // addTimeRangeParams adds start and end time parameters to the URL values
-// It handles conversion from RFC3339 to Unix nanoseconds
+// It handles conversion from any format parseTime accepts to Unix nanoseconds
 func addTimeRangeParams(params url.Values, startRFC3339, endRFC3339 string) error {
 	if startRFC3339 != "" {
-		startTime, err := time.Parse(time.RFC3339, startRFC3339)
+		startTime, err := parseTime(startRFC3339)
 		if err != nil {
 			return fmt.Errorf("parsing start time: %w", err)
 		}
@@ -343,7 +399,7 @@ func addTimeRangeParams(params url.Values, startRFC3339, endRFC3339 string) erro
 	}
 
 	if endRFC3339 != "" {
-		endTime, err := time.Parse(time.RFC3339, endRFC3339)
+		endTime, err := parseTime(endRFC3339)
 		if err != nil {
 			return fmt.Errorf("parsing end time: %w", err)
 		}
@@ -353,26 +409,12 @@ func addTimeRangeParams(params url.Values, startRFC3339, endRFC3339 string) erro
 	return nil
 }
 
-// getDefaultTimeRange returns default start and end times if not provided
-// Returns start time (1 hour ago) and end time (now) in RFC3339 format
-func getDefaultTimeRange(startRFC3339, endRFC3339 string) (string, string) {
-	if startRFC3339 == "" {
-		// Default to 1 hour ago if not specified
-		startRFC3339 = time.Now().Add(-1 * time.Hour).Format(time.RFC3339)
-	}
-	if endRFC3339 == "" {
-		// Default to now if not specified
-		endRFC3339 = time.Now().Format(time.RFC3339)
-	}
-	return startRFC3339, endRFC3339
-}
-
 // fetchQueryParams contains parameters for fetching Loki query results
 type fetchQueryParams struct {
 	Query       string
 	QueryType   string // "instant" or "range" (default)
-	Start       string // RFC3339
-	End         string // RFC3339
+	Start       string // Any format parseTime accepts
+	End         string // Any format parseTime accepts
 	Limit       int    // For log queries
 	Direction   string // For log queries
 	StepSeconds int    // For range metric queries
@@ -390,22 +432,18 @@ func (c *Client) fetchQuery(ctx context.Context, p fetchQueryParams) (*lokiQuery
 		// Instant queries use /query endpoint with a single "time" parameter
 		endpoint = "/loki/api/v1/query"
 
-		// For instant queries, use end time if provided, otherwise start time
-		var queryTime string
-		if p.End != "" {
-			queryTime = p.End
-		} else if p.Start != "" {
+		// For instant queries, use end time if provided, otherwise start
+		// time, otherwise now
+		queryTime := p.End
+		if queryTime == "" {
 			queryTime = p.Start
 		}
-
-		if queryTime != "" {
-			t, err := time.Parse(time.RFC3339, queryTime)
-			if err != nil {
-				return nil, fmt.Errorf("parsing query time: %w", err)
-			}
-			// Loki instant query accepts time as Unix timestamp in seconds (float)
-			params.Add("time", fmt.Sprintf("%d", t.Unix()))
+		t, err := timeOrDefault(queryTime, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("parsing query time: %w", err)
 		}
+		// Loki instant query accepts time as Unix timestamp in seconds (float)
+		params.Add("time", fmt.Sprintf("%d", t.Unix()))
 	} else {
 		// Range queries use /query_range endpoint with start/end
 		endpoint = "/loki/api/v1/query_range"
@@ -449,14 +487,17 @@ func (c *Client) fetchQuery(ctx context.Context, p fetchQueryParams) (*lokiQuery
 
 // QueryLokiLogsParams defines the parameters for querying Loki logs
 type QueryLokiLogsParams struct {
-	DatasourceUID string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
-	LogQL         string `json:"logql" jsonschema:"required,description=The LogQL query to execute against Loki. This can be a simple label matcher or a complex query with filters\\, parsers\\, and expressions. Supports full LogQL syntax including label matchers\\, filter operators\\, pattern expressions\\, and pipeline operations."`
-	StartRFC3339  string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query in RFC3339 format"`
-	EndRFC3339    string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query in RFC3339 format"`
-	Limit         int    `json:"limit,omitempty" jsonschema:"default=10,description=Optionally\\, the maximum number of log lines to return (max: 100)"`
-	Direction     string `json:"direction,omitempty" jsonschema:"description=Optionally\\, the direction of the query: 'forward' (oldest first) or 'backward' (newest first\\, default)"`
-	QueryType     string `json:"queryType,omitempty" jsonschema:"description=Query type: 'range' (default) or 'instant'. Instant queries return a single value at one point in time. Range queries return values over a time window. Use 'instant' for metric queries when you want the current value."`
-	StepSeconds   int    `json:"stepSeconds,omitempty" jsonschema:"description=Resolution step in seconds for range metric queries. When running metric queries with queryType='range'\\, this controls the time resolution of the returned data points."`
+	DatasourceUID  string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
+	LogQL          string `json:"logql" jsonschema:"required,description=The LogQL query to execute against Loki. This can be a simple label matcher or a complex query with filters\\, parsers\\, and expressions. Supports full LogQL syntax including label matchers\\, filter operators\\, pattern expressions\\, and pipeline operations."`
+	StartRFC3339   string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time for range queries"`
+	EndRFC3339     string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query as RFC3339\\, Unix seconds or relative to now. Defaults to now for range queries"`
+	Limit          int    `json:"limit,omitempty" jsonschema:"default=100,description=Optionally\\, the maximum number of log lines to return (max: 5000)"`
+	Direction      string `json:"direction,omitempty" jsonschema:"enum=backward,enum=forward,description=Optionally\\, the direction of the query: 'forward' (oldest first) or 'backward' (newest first\\, default)"`
+	QueryType      string `json:"queryType,omitempty" jsonschema:"description=Query type: 'range' (default) or 'instant'. Instant queries return a single value at one point in time. Range queries return values over a time window. Use 'instant' for metric queries when you want the current value."`
+	StepSeconds    int    `json:"stepSeconds,omitempty" jsonschema:"description=Resolution step in seconds for range metric queries. When running metric queries with queryType='range'\\, this controls the time resolution of the returned data points."`
+	TimeoutSeconds int    `json:"timeoutSeconds,omitempty" jsonschema:"description=Optionally\\, a timeout in seconds for this query overriding the default client timeout. Useful for expensive queries over long windows. Capped at a server configured maximum (120s by default)"`
+	TenantID       string `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Loki\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
+	IncludeStats   bool   `json:"includeStats,omitempty" jsonschema:"description=If true\\, also return Loki's execution stats for the query: bytes processed per second\\, total bytes and lines processed and execution time in seconds. Useful to understand how expensive a query is. Off by default"`
 }
 
 // LogEntry represents a single log entry or metric sample with metadata
@@ -468,15 +509,29 @@ type LogEntry struct {
 	Labels    map[string]string `json:"labels"`
 }
 
+// QueryLokiLogsResult is the result of query_loki_logs. Count is the number
+// of entries returned; for log queries, Limit is the limit applied, so a
+// Count equal to it means there may be more lines. Stats is only set if
+// requested with includeStats.
+type QueryLokiLogsResult struct {
+	Entries []LogEntry      `json:"entries"`
+	Count   int             `json:"count"`
+	Limit   int             `json:"limit,omitempty"`
+	Stats   *LokiQueryStats `json:"stats,omitempty"`
+}
+
 // enforceLogLimit ensures a log limit value is within acceptable bounds
 func enforceLogLimit(requestedLimit int) int {
-	if requestedLimit <= 0 {
-		return DefaultLokiLogLimit
-	}
-	if requestedLimit > MaxLokiLogLimit {
-		return MaxLokiLogLimit
+	return clampLogLines(requestedLimit, DefaultLokiLogLimit, MaxLokiLogLimit)
+}
+
+// clampLogLines returns requested within (0, maxLines], or defaultLines if
+// it isn't positive.
+func clampLogLines(requested, defaultLines, maxLines int) int {
+	if requested <= 0 {
+		return defaultLines
 	}
-	return requestedLimit
+	return min(requested, maxLines)
 }
 
 // parseMetricValue parses a metric value from Loki response (string or number)
@@ -506,9 +561,43 @@ func parseMetricTimestamp(raw json.RawMessage) (string, error) {
 	return fmt.Sprintf("%.3f", ts), nil
 }
 
+// parseLokiStreams parses the log lines of a "streams" query result.
+func parseLokiStreams(result json.RawMessage) ([]LogEntry, error) {
+	var streams []LokiLogStream
+	if err := json.Unmarshal(result, &streams); err != nil {
+		return nil, fmt.Errorf("parsing streams result: %w", err)
+	}
+
+	var entries []LogEntry
+	for _, stream := range streams {
+		for _, value := range stream.Values {
+			if len(value) >= 2 {
+				// Parse log line
+				var logLine string
+				if err := json.Unmarshal(value[1], &logLine); err != nil {
+					continue // Skip invalid log lines
+				}
+				entries = append(entries, LogEntry{
+					Timestamp: string(value[0]), // Nanoseconds as string
+					Line:      logLine,
+					Labels:    stream.Stream,
+				})
+			}
+		}
+	}
+	return entries, nil
+}
+
 // queryLokiLogs queries logs from a Loki datasource using LogQL
-func queryLokiLogs(ctx context.Context, args QueryLokiLogsParams) ([]LogEntry, error) {
-	client, err := newLokiClient(ctx, args.DatasourceUID)
+func queryLokiLogs(ctx context.Context, args QueryLokiLogsParams) (*QueryLokiLogsResult, error) {
+	if err := validateLogQL(args.LogQL); err != nil {
+		return nil, err
+	}
+	if args.Direction != "" && args.Direction != "forward" && args.Direction != "backward" {
+		return nil, fmt.Errorf("invalid direction %q, must be 'forward' or 'backward'", args.Direction)
+	}
+
+	client, err := newLokiClient(ctx, args.DatasourceUID, args.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("creating Loki client: %w", err)
 	}
@@ -521,7 +610,7 @@ func queryLokiLogs(ctx context.Context, args QueryLokiLogsParams) ([]LogEntry, e
 		endTime = args.EndRFC3339
 	} else {
 		// For range queries, apply defaults
-		startTime, endTime = getDefaultTimeRange(args.StartRFC3339, args.EndRFC3339)
+		startTime, endTime = getDefaultTimeRange(ctx, args.StartRFC3339, args.EndRFC3339)
 	}
 
 	// Apply limit constraints (only relevant for log queries)
@@ -533,6 +622,12 @@ func queryLokiLogs(ctx context.Context, args QueryLokiLogsParams) ([]LogEntry, e
 		direction = "backward" // Most recent logs first
 	}
 
+	ctx, cancel, timeout, err := withToolTimeout(ctx, args.TimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
 	// Execute the query
 	response, err := client.fetchQuery(ctx, fetchQueryParams{
 		Query:       args.LogQL,
@@ -544,37 +639,21 @@ func queryLokiLogs(ctx context.Context, args QueryLokiLogsParams) ([]LogEntry, e
 		StepSeconds: args.StepSeconds,
 	})
 	if err != nil {
-		return nil, err
+		return nil, wrapTimeoutError(ctx, "query_loki_logs", timeout, err)
 	}
 
 	// Parse results based on resultType
 	var entries []LogEntry
+	result := &QueryLokiLogsResult{}
 
 	switch response.Data.ResultType {
 	case "streams":
 		// Log query results
-		var streams []LokiLogStream
-		if err := json.Unmarshal(response.Data.Result, &streams); err != nil {
-			return nil, fmt.Errorf("parsing streams result: %w", err)
-		}
-
-		for _, stream := range streams {
-			for _, value := range stream.Values {
-				if len(value) >= 2 {
-					// Parse log line
-					var logLine string
-					if err := json.Unmarshal(value[1], &logLine); err != nil {
-						continue // Skip invalid log lines
-					}
-
-					entries = append(entries, LogEntry{
-						Timestamp: string(value[0]), // Nanoseconds as string
-						Line:      logLine,
-						Labels:    stream.Stream,
-					})
-				}
-			}
+		entries, err = parseLokiStreams(response.Data.Result)
+		if err != nil {
+			return nil, err
 		}
+		result.Limit = limit
 
 	case "vector":
 		// Instant metric query results
@@ -645,20 +724,158 @@ func queryLokiLogs(ctx context.Context, args QueryLokiLogsParams) ([]LogEntry, e
 
 	// Return empty slice if no entries found
 	if entries == nil {
-		return []LogEntry{}, nil
+		entries = []LogEntry{}
 	}
-
-	return entries, nil
+	result.Entries = entries
+	result.Count = len(entries)
+	if args.IncludeStats {
+		result.Stats = response.Data.Stats.Summary
+	}
+	return result, nil
 }
 
 // QueryLokiLogs is a tool for querying logs from Loki
 var QueryLokiLogs = mcpgrafana.MustTool(
 	"query_loki_logs",
-	"Executes a LogQL query against a Loki datasource to retrieve log entries or metric values. Returns a list of results, each containing a timestamp, labels, and either a log line (`line`) or a numeric metric value (`value`). Defaults to the last hour, a limit of 10 entries, and 'backward' direction (newest first). Supports full LogQL syntax for log and metric queries (e.g., `{app=\"foo\"} |= \"error\"`, `rate({app=\"bar\"}[1m])`). Prefer using `query_loki_stats` first to check stream size and `list_loki_label_names` and `list_loki_label_values` to verify labels exist.",
+	"Executes a LogQL query against a Loki datasource to retrieve log entries or metric values. Returns a list of results, each containing a timestamp, labels, and either a log line (`line`) or a numeric metric value (`value`). Returns `entries` and their `count`; for log queries `limit` is the limit applied, so a count equal to it means more lines may match. Defaults to the last hour, a limit of 100 entries (at most 5000), and 'backward' direction (newest first); use 'forward' for the oldest lines first. Set `includeStats` to also get Loki's execution stats (bytes and lines processed, execution time) to gauge a query's cost. Supports full LogQL syntax for log and metric queries (e.g., `{app=\"foo\"} |= \"error\"`, `rate({app=\"bar\"}[1m])`). Prefer using `query_loki_stats` first to check stream size and `list_loki_label_names` and `list_loki_label_values` to verify labels exist.",
 	queryLokiLogs,
 	mcp.WithTitleAnnotation("Query Loki logs"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
+	mcpgrafana.WithExamples(
+		map[string]any{"datasourceUid": "loki", "logql": `{app="api", namespace="prod"} |= "error"`, "startRfc3339": "now-1h", "limit": 50},
+		map[string]any{"datasourceUid": "loki", "logql": `{app="api"} | json | status >= 500 | line_format "{{.method}} {{.path}}"`, "direction": "forward"},
+		map[string]any{"datasourceUid": "loki", "logql": `sum by (level) (count_over_time({app="api"}[5m]))`, "startRfc3339": "now-6h", "stepSeconds": 300},
+	),
+)
+
+// lokiLogContextWindow is how far before and after the target timestamp
+// get_loki_log_context looks for surrounding lines.
+const lokiLogContextWindow = time.Hour
+
+// GetLokiLogContextParams defines the parameters for fetching the lines
+// around a log line
+type GetLokiLogContextParams struct {
+	DatasourceUID string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
+	LogQL         string `json:"logql" jsonschema:"required,description=The stream selector of the log line (e.g. '{app=\"foo\"\\, pod=\"foo-1\"}'). Line filters can be added to narrow the context"`
+	Timestamp     string `json:"timestamp" jsonschema:"required,description=The timestamp of the log line\\, either in nanoseconds as returned by query_loki_logs or in RFC3339 format"`
+	LinesBefore   int    `json:"linesBefore,omitempty" jsonschema:"default=10,description=The number of lines to return before the log line (max: 100)"`
+	LinesAfter    int    `json:"linesAfter,omitempty" jsonschema:"default=10,description=The number of lines to return after the log line (max: 100)"`
+	TenantID      string `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Loki\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
+}
+
+// parseLokiTimestamp parses a timestamp given either as Unix nanoseconds or
+// in RFC3339 format.
+func parseLokiTimestamp(timestamp string) (time.Time, error) {
+	if ns, ok := logEntryNanos(timestamp); ok {
+		return time.Unix(0, ns), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: must be Unix nanoseconds or RFC3339", timestamp)
+	}
+	return t, nil
+}
+
+// fetchLogLines runs a log query over [start, end) and returns its lines.
+func (c *Client) fetchLogLines(ctx context.Context, query string, start, end time.Time, limit int, direction string) ([]LogEntry, error) {
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("start", strconv.FormatInt(start.UnixNano(), 10))
+	params.Add("end", strconv.FormatInt(end.UnixNano(), 10))
+	params.Add("limit", strconv.Itoa(limit))
+	params.Add("direction", direction)
+
+	bodyBytes, err := c.makeRequest(ctx, "GET", "/loki/api/v1/query_range", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var queryResponse lokiQueryResponse
+	if err := json.Unmarshal(bodyBytes, &queryResponse); err != nil {
+		return nil, fmt.Errorf("unmarshalling response (content: %s): %w", string(bodyBytes), err)
+	}
+	if queryResponse.Status != "success" {
+		return nil, fmt.Errorf("loki API returned unexpected response format: %s", string(bodyBytes))
+	}
+	if queryResponse.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("expected a log query, got a %s result", queryResponse.Data.ResultType)
+	}
+	return parseLokiStreams(queryResponse.Data.Result)
+}
+
+// sortLogEntries sorts log lines oldest first.
+func sortLogEntries(entries []LogEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		ti, _ := logEntryNanos(entries[i].Timestamp)
+		tj, _ := logEntryNanos(entries[j].Timestamp)
+		return ti < tj
+	})
+}
+
+// logEntryNanos parses the timestamp of a LogEntry, which is Unix
+// nanoseconds as the JSON string Loki returns, quotes included.
+func logEntryNanos(timestamp string) (int64, bool) {
+	ns, err := strconv.ParseInt(strings.Trim(timestamp, `"`), 10, 64)
+	return ns, err == nil
+}
+
+// getLokiLogContext returns the lines logged around a timestamp, oldest
+// first. The lines at the timestamp itself are included between the lines
+// before and after it.
+func getLokiLogContext(ctx context.Context, args GetLokiLogContextParams) ([]LogEntry, error) {
+	if err := validateLogQL(args.LogQL); err != nil {
+		return nil, err
+	}
+	target, err := parseLokiTimestamp(args.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	linesBefore := clampLogLines(args.LinesBefore, defaultLokiLogContextLines, maxLokiLogContextLines)
+	linesAfter := clampLogLines(args.LinesAfter, defaultLokiLogContextLines, maxLokiLogContextLines)
+
+	client, err := newLokiClient(ctx, args.DatasourceUID, args.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("creating Loki client: %w", err)
+	}
+
+	// The end of a range query is exclusive, so the backward query returns
+	// the lines strictly before the target, newest first.
+	before, err := client.fetchLogLines(ctx, args.LogQL, target.Add(-lokiLogContextWindow), target, linesBefore, "backward")
+	if err != nil {
+		return nil, fmt.Errorf("fetching lines before %s: %w", args.Timestamp, err)
+	}
+	// The forward query starts at the target, so ask for one more line to
+	// make room for the target line itself.
+	after, err := client.fetchLogLines(ctx, args.LogQL, target, target.Add(lokiLogContextWindow), linesAfter+1, "forward")
+	if err != nil {
+		return nil, fmt.Errorf("fetching lines after %s: %w", args.Timestamp, err)
+	}
+
+	afterCount := 0
+	entries := make([]LogEntry, 0, len(before)+len(after))
+	entries = append(entries, before...)
+	for _, entry := range after {
+		if ns, _ := logEntryNanos(entry.Timestamp); ns != target.UnixNano() {
+			if afterCount == linesAfter {
+				continue
+			}
+			afterCount++
+		}
+		entries = append(entries, entry)
+	}
+	sortLogEntries(entries)
+	return entries, nil
+}
+
+// GetLokiLogContext is a tool for fetching the lines around a log line
+var GetLokiLogContext = mcpgrafana.MustTool(
+	"get_loki_log_context",
+	"Fetches the log lines immediately before and after a log line, for context when investigating an interesting line found with query_loki_logs. Takes the stream selector and timestamp of the line and returns up to linesBefore lines before it (default 10), the line itself, and up to linesAfter lines after it (default 10), oldest first. Lines are looked for within an hour either side of the timestamp.",
+	getLokiLogContext,
+	mcp.WithTitleAnnotation("Get Loki log context"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
 )
 
 // fetchStats is a method to fetch stats data from Loki API
@@ -738,19 +955,24 @@ func (c *Client) fetchPatterns(ctx context.Context, query, startRFC3339, endRFC3
 type QueryLokiStatsParams struct {
 	DatasourceUID string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
 	LogQL         string `json:"logql" jsonschema:"required,description=The LogQL matcher expression to execute. This parameter only accepts label matcher expressions and does not support full LogQL queries. Line filters\\, pattern operations\\, and metric aggregations are not supported by the stats API endpoint. Only simple label selectors can be used here."`
-	StartRFC3339  string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query in RFC3339 format"`
-	EndRFC3339    string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query in RFC3339 format"`
+	StartRFC3339  string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndRFC3339    string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query as RFC3339\\, Unix seconds or relative to now. Defaults to now"`
+	TenantID      string `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Loki\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
 }
 
 // queryLokiStats queries stats from a Loki datasource using LogQL
 func queryLokiStats(ctx context.Context, args QueryLokiStatsParams) (*Stats, error) {
-	client, err := newLokiClient(ctx, args.DatasourceUID)
+	if err := validateLogQL(args.LogQL); err != nil {
+		return nil, err
+	}
+
+	client, err := newLokiClient(ctx, args.DatasourceUID, args.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("creating Loki client: %w", err)
 	}
 
 	// Get default time range if not provided
-	startTime, endTime := getDefaultTimeRange(args.StartRFC3339, args.EndRFC3339)
+	startTime, endTime := getDefaultTimeRange(ctx, args.StartRFC3339, args.EndRFC3339)
 
 	stats, err := client.fetchStats(ctx, args.LogQL, startTime, endTime)
 	if err != nil {
@@ -768,26 +990,34 @@ var QueryLokiStats = mcpgrafana.MustTool(
 	mcp.WithTitleAnnotation("Get Loki log statistics"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
+	mcpgrafana.WithExamples(
+		map[string]any{"datasourceUid": "loki", "logql": `{app="api", namespace="prod"}`, "startRfc3339": "now-24h"},
+	),
 )
 
 // QueryLokiPatternsParams defines the parameters for querying Loki patterns
 type QueryLokiPatternsParams struct {
 	DatasourceUID string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
 	LogQL         string `json:"logql" jsonschema:"required,description=A LogQL stream selector to identify the logs to analyze for patterns (e.g. {job=\"foo\"\\, namespace=\"bar\"})"`
-	StartRFC3339  string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query in RFC3339 format (defaults to 1 hour ago)"`
-	EndRFC3339    string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query in RFC3339 format (defaults to now)"`
+	StartRFC3339  string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndRFC3339    string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query as RFC3339\\, Unix seconds or relative to now. Defaults to now"`
 	Step          string `json:"step,omitempty" jsonschema:"description=Optionally\\, the query resolution step (e.g. '5m')"`
+	TenantID      string `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Loki\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
 }
 
 // queryLokiPatterns queries detected log patterns from a Loki datasource
 func queryLokiPatterns(ctx context.Context, args QueryLokiPatternsParams) ([]Pattern, error) {
-	client, err := newLokiClient(ctx, args.DatasourceUID)
+	if err := validateLogQL(args.LogQL); err != nil {
+		return nil, err
+	}
+
+	client, err := newLokiClient(ctx, args.DatasourceUID, args.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("creating Loki client: %w", err)
 	}
 
 	// Get default time range if not provided
-	startTime, endTime := getDefaultTimeRange(args.StartRFC3339, args.EndRFC3339)
+	startTime, endTime := getDefaultTimeRange(ctx, args.StartRFC3339, args.EndRFC3339)
 
 	patterns, err := client.fetchPatterns(ctx, args.LogQL, startTime, endTime, args.Step)
 	if err != nil {
@@ -807,11 +1037,94 @@ var QueryLokiPatterns = mcpgrafana.MustTool(
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+// QueryLokiMetricsParams defines the parameters for running a LogQL metric
+// query over a time range
+type QueryLokiMetricsParams struct {
+	DatasourceUID  string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
+	LogQL          string `json:"logql" jsonschema:"required,description=The LogQL metric query to execute\\, such as 'sum by (level) (rate({app=\"foo\"}[5m]))'. Log queries returning lines are rejected; use query_loki_logs for those"`
+	StartRFC3339   string `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndRFC3339     string `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query as RFC3339\\, Unix seconds or relative to now. Defaults to now"`
+	StepSeconds    int    `json:"stepSeconds,omitempty" jsonschema:"description=Optionally\\, the resolution step in seconds. Defaults to Loki's own choice for the time range"`
+	MaxResultBytes int    `json:"maxResultBytes,omitempty" jsonschema:"description=Results whose JSON is larger than this many bytes are returned as a per-series summary (min\\, max\\, avg and point count) instead of every point. Defaults to 196608 (192KiB)"`
+	MaxPoints      int    `json:"maxPoints,omitempty" jsonschema:"description=Optionally\\, the maximum number of points to return per series. Longer series are downsampled with the LTTB algorithm\\, which keeps their shape\\, and the result notes the reduction. At least 3. Unlimited by default"`
+	TimeoutSeconds int    `json:"timeoutSeconds,omitempty" jsonschema:"description=Optionally\\, a timeout in seconds for this query overriding the default client timeout. Capped at a server configured maximum (120s by default)"`
+	TenantID       string `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Loki\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
+}
+
+// queryLokiMetrics runs a LogQL metric query against /query_range and
+// returns its matrix in the same form as query_prometheus.
+func queryLokiMetrics(ctx context.Context, args QueryLokiMetricsParams) (any, error) {
+	if err := validateLogQL(args.LogQL); err != nil {
+		return nil, err
+	}
+	if !isLogQLMetricQuery(args.LogQL) {
+		return nil, fmt.Errorf("invalid LogQL metric query: %q is a log query. Wrap it in a range aggregation such as rate(%s [5m]) or count_over_time(%s [5m]), or use query_loki_logs to get its lines", args.LogQL, args.LogQL, args.LogQL)
+	}
+	if args.StepSeconds < 0 {
+		return nil, fmt.Errorf("stepSeconds must be positive")
+	}
+	if args.MaxResultBytes < 0 {
+		return nil, fmt.Errorf("maxResultBytes must be positive")
+	}
+	if args.MaxPoints < 0 || args.MaxPoints > 0 && args.MaxPoints < minPrometheusMaxPoints {
+		return nil, fmt.Errorf("maxPoints must be at least %d", minPrometheusMaxPoints)
+	}
+
+	client, err := newLokiClient(ctx, args.DatasourceUID, args.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("creating Loki client: %w", err)
+	}
+
+	ctx, cancel, timeout, err := withToolTimeout(ctx, args.TimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	startTime, endTime := getDefaultTimeRange(ctx, args.StartRFC3339, args.EndRFC3339)
+	response, err := client.fetchQuery(ctx, fetchQueryParams{
+		Query:       args.LogQL,
+		QueryType:   "range",
+		Start:       startTime,
+		End:         endTime,
+		StepSeconds: args.StepSeconds,
+	})
+	if err != nil {
+		return nil, wrapTimeoutError(ctx, "query_loki_metrics", timeout, err)
+	}
+	if response.Data.ResultType != model.ValMatrix.String() {
+		return nil, fmt.Errorf("unexpected result type %q for a metric query, expected %q", response.Data.ResultType, model.ValMatrix.String())
+	}
+
+	// Loki returns matrices in the same format as Prometheus.
+	var matrix model.Matrix
+	if err := json.Unmarshal(response.Data.Result, &matrix); err != nil {
+		return nil, fmt.Errorf("parsing matrix result: %w", err)
+	}
+	if matrix == nil {
+		matrix = model.Matrix{}
+	}
+	return prometheusResultForOutput(matrix, args.MaxResultBytes, args.MaxPoints)
+}
+
+// QueryLokiMetrics is a tool for running LogQL metric queries over a time
+// range
+var QueryLokiMetrics = mcpgrafana.MustTool(
+	"query_loki_metrics",
+	"Executes a LogQL metric query (e.g. `rate({app=\"foo\"} |= \"error\" [5m])` or `sum by (level) (count_over_time({app=\"foo\"}[1m]))`) over a time range against a Loki datasource and returns the resulting series as a matrix, in the same format as `query_prometheus` range queries: each series has its `metric` labels and `values` as [timestamp, value] pairs. Defaults to the last hour. Large results are summarized per series, and `maxPoints` downsamples long series. Log queries are rejected; use `query_loki_logs` to get log lines.",
+	queryLokiMetrics,
+	mcp.WithTitleAnnotation("Query Loki metrics"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
 // AddLokiTools registers all Loki tools with the MCP server
 func AddLokiTools(mcp *server.MCPServer) {
 	ListLokiLabelNames.Register(mcp)
 	ListLokiLabelValues.Register(mcp)
 	QueryLokiStats.Register(mcp)
 	QueryLokiLogs.Register(mcp)
+	QueryLokiMetrics.Register(mcp)
 	QueryLokiPatterns.Register(mcp)
+	GetLokiLogContext.Register(mcp)
 }
@@ -0,0 +1,636 @@
+//go:build unit
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockDatasourceCtx returns a context whose Grafana client and config both
+// point at the given test server, so datasource lookups and proxied
+// datasource requests are served by it.
+func mockDatasourceCtx(server *httptest.Server, extraHeaders map[string]string) context.Context {
+	ctx := mockCtxWithClient(server)
+	return mcpgrafana.WithGrafanaConfig(ctx, mcpgrafana.GrafanaConfig{
+		URL:          server.URL,
+		APIKey:       "test",
+		ExtraHeaders: extraHeaders,
+	})
+}
+
+// newLokiTestServer serves the datasource lookup for uid "loki-uid" and
+// delegates everything under its proxy path to the given handler.
+func newLokiTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/datasources/uid/loki-uid" {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"uid": "loki-uid", "name": "Loki", "type": "loki"})
+			return
+		}
+		handler(w, r)
+	}))
+}
+
+func TestQueryLokiStats(t *testing.T) {
+	t.Run("returns stats from index/stats endpoint", func(t *testing.T) {
+		server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/datasources/proxy/uid/loki-uid/loki/api/v1/index/stats", r.URL.Path)
+			assert.Equal(t, `{app="nginx"}`, r.URL.Query().Get("query"))
+			assert.NotEmpty(t, r.URL.Query().Get("start"))
+			assert.NotEmpty(t, r.URL.Query().Get("end"))
+			assert.Equal(t, "Bearer test", r.Header.Get("Authorization"))
+			assert.Equal(t, "forwarded", r.Header.Get("X-Forwarded-Test"))
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"streams": 5, "chunks": 50, "entries": 10000, "bytes": 512000}`))
+		})
+		defer server.Close()
+
+		ctx := mockDatasourceCtx(server, map[string]string{"X-Forwarded-Test": "forwarded"})
+		stats, err := queryLokiStats(ctx, QueryLokiStatsParams{
+			DatasourceUID: "loki-uid",
+			LogQL:         `{app="nginx"}`,
+			StartRFC3339:  "2025-01-01T00:00:00Z",
+			EndRFC3339:    "2025-01-01T01:00:00Z",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &Stats{Streams: 5, Chunks: 50, Entries: 10000, Bytes: 512000}, stats)
+	})
+
+	t.Run("propagates Loki errors", func(t *testing.T) {
+		server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("parse error"))
+		})
+		defer server.Close()
+
+		_, err := queryLokiStats(mockDatasourceCtx(server, nil), QueryLokiStatsParams{
+			DatasourceUID: "loki-uid",
+			LogQL:         `{app="nginx"} |= "error"`,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "loki API returned status code 400: parse error")
+	})
+}
+
+func TestListLokiLabelValues(t *testing.T) {
+	t.Run("returns sorted values for the time range and selector", func(t *testing.T) {
+		server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/datasources/proxy/uid/loki-uid/loki/api/v1/label/namespace/values", r.URL.Path)
+			assert.Equal(t, "2025-01-01T00:00:00Z", r.URL.Query().Get("start"))
+			assert.Equal(t, "2025-01-01T01:00:00Z", r.URL.Query().Get("end"))
+			assert.Equal(t, `{cluster="eu"}`, r.URL.Query().Get("query"))
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"status": "success", "data": ["prod", "dev", "staging"]}`))
+		})
+		defer server.Close()
+
+		values, err := listLokiLabelValues(mockDatasourceCtx(server, nil), ListLokiLabelValuesParams{
+			DatasourceUID: "loki-uid",
+			LabelName:     "namespace",
+			StartRFC3339:  "2025-01-01T00:00:00Z",
+			EndRFC3339:    "2025-01-01T01:00:00Z",
+			Query:         `{cluster="eu"}`,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dev", "prod", "staging"}, values)
+	})
+
+	t.Run("defaults the time range and omits an empty selector", func(t *testing.T) {
+		server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.NotEmpty(t, r.URL.Query().Get("start"))
+			assert.NotEmpty(t, r.URL.Query().Get("end"))
+			assert.False(t, r.URL.Query().Has("query"))
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"status": "success", "data": ["a"]}`))
+		})
+		defer server.Close()
+
+		values, err := listLokiLabelValues(mockDatasourceCtx(server, nil), ListLokiLabelValuesParams{
+			DatasourceUID: "loki-uid",
+			LabelName:     "app",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, values)
+	})
+
+	t.Run("empty result", func(t *testing.T) {
+		server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"status": "success"}`))
+		})
+		defer server.Close()
+
+		values, err := listLokiLabelValues(mockDatasourceCtx(server, nil), ListLokiLabelValuesParams{
+			DatasourceUID: "loki-uid",
+			LabelName:     "missing",
+		})
+		require.NoError(t, err)
+		assert.NotNil(t, values)
+		assert.Empty(t, values)
+	})
+}
+
+func TestValidateLogQL(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{name: "empty", query: "  ", wantErr: "query is empty"},
+		{name: "unclosed brace", query: `{app="nginx"`, wantErr: "unclosed '{'"},
+		{name: "unexpected closing brace", query: `app="nginx"}`, wantErr: "unexpected '}'"},
+		{name: "mismatched brackets", query: `rate({app="nginx"}[5m)]`, wantErr: "unexpected ')'"},
+		{name: "empty selector", query: `{ } |= "error"`, wantErr: "empty stream selector"},
+		{name: "missing selector", query: `app="nginx" |= "error"`, wantErr: "missing stream selector"},
+		{name: "unterminated string", query: `{app="nginx}`, wantErr: "unterminated string"},
+		{name: "malformed matcher", query: `{app=nginx} |= "error"`, wantErr: `invalid label matcher "app=nginx"`},
+		{name: "simple selector", query: `{app="nginx"}`},
+		{name: "pipeline", query: `{app="nginx", env=~"prod|staging"} |= "error" | json | line_format "{{.msg}} [{{.level}}" | status >= 500`},
+		{name: "metric query", query: `sum by (level) (count_over_time({app="nginx"} | logfmt [5m]))`},
+		{name: "escaped quote and backticks", query: "{app=\"ng\\\"inx\"} |~ `^\\{` != \"}\""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateLogQL(tc.query)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+			assert.Contains(t, err.Error(), `{app="foo"}`)
+		})
+	}
+}
+
+func TestQueryLokiLogsRejectsInvalidLogQL(t *testing.T) {
+	server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+	defer server.Close()
+
+	_, err := queryLokiLogs(mockDatasourceCtx(server, nil), QueryLokiLogsParams{
+		DatasourceUID: "loki-uid",
+		LogQL:         `{app="nginx" |= "error"`,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid LogQL query")
+}
+
+func TestQueryLokiLogsIncludeStats(t *testing.T) {
+	server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "streams", "result": [
+			{"stream": {"app": "nginx"}, "values": [["1700000000000000000", "first"]]}
+		], "stats": {"summary": {"bytesProcessedPerSecond": 2048000, "linesProcessedPerSecond": 1000, "totalBytesProcessed": 1024000, "totalLinesProcessed": 500, "execTime": 0.5, "queueTime": 0.01}}}}`))
+	})
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("stats requested", func(t *testing.T) {
+		result, err := queryLokiLogs(ctx, QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`, IncludeStats: true})
+		require.NoError(t, err)
+		assert.Equal(t, &LokiQueryStats{
+			BytesProcessedPerSecond: 2048000,
+			TotalBytesProcessed:     1024000,
+			TotalLinesProcessed:     500,
+			ExecTime:                0.5,
+		}, result.Stats)
+	})
+
+	t.Run("stats omitted by default", func(t *testing.T) {
+		result, err := queryLokiLogs(ctx, QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`})
+		require.NoError(t, err)
+		assert.Nil(t, result.Stats)
+		out, err := json.Marshal(result)
+		require.NoError(t, err)
+		assert.NotContains(t, string(out), "stats")
+	})
+}
+
+func TestQueryLokiMetrics(t *testing.T) {
+	var gotQuery url.Values
+	server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/datasources/proxy/uid/loki-uid/loki/api/v1/query_range", r.URL.Path)
+		gotQuery = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+			{"metric": {"level": "error"}, "values": [[1700000000, "0.5"], [1700000060, "1.25"]]},
+			{"metric": {"level": "info"}, "values": [[1700000000, "10"], [1700000060, "12"], [1700000120, "11"], [1700000180, "9"], [1700000240, "14"]]}
+		]}}`))
+	})
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("rate query returns series", func(t *testing.T) {
+		result, err := queryLokiMetrics(ctx, QueryLokiMetricsParams{
+			DatasourceUID: "loki-uid",
+			LogQL:         `sum by (level) (rate({app="nginx"}[5m]))`,
+			StartRFC3339:  "2023-11-14T22:13:20Z",
+			EndRFC3339:    "2023-11-14T23:13:20Z",
+			StepSeconds:   60,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `sum by (level) (rate({app="nginx"}[5m]))`, gotQuery.Get("query"))
+		assert.Equal(t, "60", gotQuery.Get("step"))
+		assert.Equal(t, "1700000000000000000", gotQuery.Get("start"))
+		assert.Equal(t, "1700003600000000000", gotQuery.Get("end"))
+		assert.Empty(t, gotQuery.Get("limit"))
+
+		matrix, ok := result.(model.Matrix)
+		require.True(t, ok, "expected a matrix, got %T", result)
+		require.Len(t, matrix, 2)
+		assert.Equal(t, model.LabelValue("error"), matrix[0].Metric["level"])
+		assert.Equal(t, []model.SamplePair{
+			{Timestamp: 1700000000000, Value: 0.5},
+			{Timestamp: 1700000060000, Value: 1.25},
+		}, matrix[0].Values)
+		assert.Len(t, matrix[1].Values, 5)
+
+		// The JSON matches that of query_prometheus range queries.
+		out, err := json.Marshal(result)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `{"metric":{"level":"error"},"values":[[1700000000,"0.5"],[1700000060,"1.25"]]}`)
+	})
+
+	t.Run("maxPoints downsamples series", func(t *testing.T) {
+		result, err := queryLokiMetrics(ctx, QueryLokiMetricsParams{DatasourceUID: "loki-uid", LogQL: `rate({app="nginx"}[5m])`, MaxPoints: 3})
+		require.NoError(t, err)
+		downsampled, ok := result.(prometheusDownsampledMatrix)
+		require.True(t, ok, "expected a downsampled matrix, got %T", result)
+		assert.Equal(t, 7, downsampled.OriginalPoints)
+		assert.Equal(t, 5, downsampled.Points)
+	})
+
+	t.Run("log queries are rejected", func(t *testing.T) {
+		gotQuery = nil
+		_, err := queryLokiMetrics(ctx, QueryLokiMetricsParams{DatasourceUID: "loki-uid", LogQL: ` {app="nginx"} |= "error"`})
+		require.ErrorContains(t, err, "is a log query")
+		assert.Nil(t, gotQuery, "no query should be sent")
+	})
+
+	t.Run("invalid LogQL is rejected", func(t *testing.T) {
+		_, err := queryLokiMetrics(ctx, QueryLokiMetricsParams{DatasourceUID: "loki-uid", LogQL: `rate({app="nginx"[5m])`})
+		require.ErrorContains(t, err, "invalid LogQL query")
+	})
+}
+
+func TestQueryLokiTenantID(t *testing.T) {
+	var gotTenant string
+	server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotTenant = r.Header.Get("X-Scope-OrgID")
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/proxy/uid/loki-uid/loki/api/v1/index/stats" {
+			_, _ = w.Write([]byte(`{"streams": 1, "chunks": 1, "entries": 1, "bytes": 1}`))
+			return
+		}
+		if strings.Contains(r.URL.Path, "/label") || strings.HasSuffix(r.URL.Path, "/patterns") {
+			_, _ = w.Write([]byte(`{"status": "success", "data": []}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "streams", "result": []}}`))
+	})
+	defer server.Close()
+
+	t.Run("tenant parameter", func(t *testing.T) {
+		gotTenant = ""
+		_, err := queryLokiLogs(mockDatasourceCtx(server, nil), QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`, TenantID: "team-a"})
+		require.NoError(t, err)
+		assert.Equal(t, "team-a", gotTenant)
+
+		gotTenant = ""
+		_, err = queryLokiStats(mockDatasourceCtx(server, nil), QueryLokiStatsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`, TenantID: "team-a"})
+		require.NoError(t, err)
+		assert.Equal(t, "team-a", gotTenant)
+	})
+
+	t.Run("default tenant", func(t *testing.T) {
+		gotTenant = ""
+		ctx := mcpgrafana.WithGrafanaConfig(mockCtxWithClient(server), mcpgrafana.GrafanaConfig{URL: server.URL, APIKey: "test", DefaultTenantID: "default"})
+		_, err := queryLokiLogs(ctx, QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`})
+		require.NoError(t, err)
+		assert.Equal(t, "default", gotTenant)
+	})
+
+	t.Run("no tenant", func(t *testing.T) {
+		gotTenant = "unset"
+		_, err := queryLokiLogs(mockDatasourceCtx(server, nil), QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`})
+		require.NoError(t, err)
+		assert.Empty(t, gotTenant)
+	})
+
+	t.Run("forwarded header takes precedence", func(t *testing.T) {
+		gotTenant = ""
+		ctx := mockDatasourceCtx(server, map[string]string{"X-Scope-OrgID": "forwarded"})
+		_, err := queryLokiLogs(ctx, QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`})
+		require.NoError(t, err)
+		assert.Equal(t, "forwarded", gotTenant)
+
+		_, err = queryLokiLogs(ctx, QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`, TenantID: "team-a"})
+		require.ErrorContains(t, err, `tenantId "team-a" conflicts with the tenant "forwarded"`)
+		assert.Equal(t, mcpgrafana.ErrKindValidation, mcpgrafana.ErrorKindOf(err))
+	})
+
+	t.Run("label, pattern and context tools", func(t *testing.T) {
+		ctx := mockDatasourceCtx(server, nil)
+
+		gotTenant = ""
+		_, err := listLokiLabelNames(ctx, ListLokiLabelNamesParams{DatasourceUID: "loki-uid", TenantID: "team-a"})
+		require.NoError(t, err)
+		assert.Equal(t, "team-a", gotTenant)
+
+		gotTenant = ""
+		_, err = listLokiLabelValues(ctx, ListLokiLabelValuesParams{DatasourceUID: "loki-uid", LabelName: "app", TenantID: "team-a"})
+		require.NoError(t, err)
+		assert.Equal(t, "team-a", gotTenant)
+
+		gotTenant = ""
+		_, err = queryLokiPatterns(ctx, QueryLokiPatternsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`, TenantID: "team-a"})
+		require.NoError(t, err)
+		assert.Equal(t, "team-a", gotTenant)
+
+		gotTenant = ""
+		_, err = getLokiLogContext(ctx, GetLokiLogContextParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`, Timestamp: "1700000000000000000", TenantID: "team-a"})
+		require.NoError(t, err)
+		assert.Equal(t, "team-a", gotTenant)
+	})
+}
+
+func TestQueryLokiLogsLimitAndDirection(t *testing.T) {
+	var gotLimit, gotDirection string
+	server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/datasources/proxy/uid/loki-uid/loki/api/v1/query_range", r.URL.Path)
+		gotLimit = r.URL.Query().Get("limit")
+		gotDirection = r.URL.Query().Get("direction")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "streams", "result": [
+			{"stream": {"app": "nginx"}, "values": [["1700000000000000000", "first"], ["1700000001000000000", "second"]]}
+		]}}`))
+	})
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("defaults", func(t *testing.T) {
+		result, err := queryLokiLogs(ctx, QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`})
+		require.NoError(t, err)
+		assert.Equal(t, "100", gotLimit)
+		assert.Equal(t, "backward", gotDirection)
+		assert.Equal(t, 2, result.Count)
+		assert.Equal(t, DefaultLokiLogLimit, result.Limit)
+		assert.Equal(t, []string{"first", "second"}, logLines(result.Entries))
+	})
+
+	t.Run("forward with a custom limit", func(t *testing.T) {
+		result, err := queryLokiLogs(ctx, QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`, Limit: 250, Direction: "forward"})
+		require.NoError(t, err)
+		assert.Equal(t, "250", gotLimit)
+		assert.Equal(t, "forward", gotDirection)
+		assert.Equal(t, 250, result.Limit)
+	})
+
+	t.Run("hard max", func(t *testing.T) {
+		result, err := queryLokiLogs(ctx, QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`, Limit: 100000})
+		require.NoError(t, err)
+		assert.Equal(t, "5000", gotLimit)
+		assert.Equal(t, MaxLokiLogLimit, result.Limit)
+	})
+
+	t.Run("invalid direction", func(t *testing.T) {
+		gotDirection = ""
+		_, err := queryLokiLogs(ctx, QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"}`, Direction: "sideways"})
+		require.EqualError(t, err, `invalid direction "sideways", must be 'forward' or 'backward'`)
+		assert.Empty(t, gotDirection)
+	})
+}
+
+// newLokiLogContextServer serves query_range requests from a single stream
+// with one line a second, honouring start, end, limit and direction the way
+// Loki does: start is inclusive, end is exclusive, and a backward query
+// returns the newest lines first.
+func newLokiLogContextServer(t *testing.T, base time.Time, count int) *httptest.Server {
+	t.Helper()
+	return newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/datasources/proxy/uid/loki-uid/loki/api/v1/query_range", r.URL.Path)
+		q := r.URL.Query()
+		assert.Equal(t, `{app="nginx"}`, q.Get("query"))
+		start, err := strconv.ParseInt(q.Get("start"), 10, 64)
+		require.NoError(t, err)
+		end, err := strconv.ParseInt(q.Get("end"), 10, 64)
+		require.NoError(t, err)
+		limit, err := strconv.Atoi(q.Get("limit"))
+		require.NoError(t, err)
+
+		var values [][]string
+		for i := range count {
+			ts := base.Add(time.Duration(i) * time.Second).UnixNano()
+			if ts >= start && ts < end {
+				values = append(values, []string{strconv.FormatInt(ts, 10), fmt.Sprintf("line %d", i)})
+			}
+		}
+		if q.Get("direction") == "backward" {
+			slices.Reverse(values)
+		}
+		if len(values) > limit {
+			values = values[:limit]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status": "success",
+			"data": map[string]any{
+				"resultType": "streams",
+				"result": []map[string]any{
+					{"stream": map[string]string{"app": "nginx"}, "values": values},
+				},
+			},
+		})
+	})
+}
+
+func logLines(entries []LogEntry) []string {
+	lines := make([]string, len(entries))
+	for i, entry := range entries {
+		lines[i] = entry.Line
+	}
+	return lines
+}
+
+func TestGetLokiLogContext(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := newLokiLogContextServer(t, base, 20)
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("returns the requested lines around the target oldest first", func(t *testing.T) {
+		entries, err := getLokiLogContext(ctx, GetLokiLogContextParams{
+			DatasourceUID: "loki-uid",
+			LogQL:         `{app="nginx"}`,
+			Timestamp:     strconv.FormatInt(base.Add(10*time.Second).UnixNano(), 10),
+			LinesBefore:   3,
+			LinesAfter:    2,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"line 7", "line 8", "line 9", "line 10", "line 11", "line 12"}, logLines(entries))
+		for i := 1; i < len(entries); i++ {
+			assert.Less(t, entries[i-1].Timestamp, entries[i].Timestamp)
+		}
+		assert.Equal(t, map[string]string{"app": "nginx"}, entries[0].Labels)
+	})
+
+	t.Run("accepts timestamps as returned by query_loki_logs", func(t *testing.T) {
+		entries, err := getLokiLogContext(ctx, GetLokiLogContextParams{
+			DatasourceUID: "loki-uid",
+			LogQL:         `{app="nginx"}`,
+			Timestamp:     strconv.Quote(strconv.FormatInt(base.Add(10*time.Second).UnixNano(), 10)),
+			LinesBefore:   1,
+			LinesAfter:    1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"line 9", "line 10", "line 11"}, logLines(entries))
+		assert.Equal(t, strconv.Quote(strconv.FormatInt(base.Add(10*time.Second).UnixNano(), 10)), entries[1].Timestamp)
+	})
+
+	t.Run("accepts RFC3339 timestamps", func(t *testing.T) {
+		entries, err := getLokiLogContext(ctx, GetLokiLogContextParams{
+			DatasourceUID: "loki-uid",
+			LogQL:         `{app="nginx"}`,
+			Timestamp:     base.Add(5 * time.Second).Format(time.RFC3339),
+			LinesBefore:   1,
+			LinesAfter:    1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"line 4", "line 5", "line 6"}, logLines(entries))
+	})
+
+	t.Run("returns fewer lines at the edges of the stream", func(t *testing.T) {
+		entries, err := getLokiLogContext(ctx, GetLokiLogContextParams{
+			DatasourceUID: "loki-uid",
+			LogQL:         `{app="nginx"}`,
+			Timestamp:     strconv.FormatInt(base.Add(18*time.Second).UnixNano(), 10),
+			LinesBefore:   2,
+			LinesAfter:    5,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"line 16", "line 17", "line 18", "line 19"}, logLines(entries))
+	})
+
+	t.Run("defaults the line counts", func(t *testing.T) {
+		entries, err := getLokiLogContext(ctx, GetLokiLogContextParams{
+			DatasourceUID: "loki-uid",
+			LogQL:         `{app="nginx"}`,
+			Timestamp:     strconv.FormatInt(base.Add(10*time.Second).UnixNano(), 10),
+		})
+		require.NoError(t, err)
+		assert.Len(t, entries, 20)
+		assert.Equal(t, "line 0", entries[0].Line)
+		assert.Equal(t, "line 19", entries[19].Line)
+	})
+
+	t.Run("rejects invalid timestamps", func(t *testing.T) {
+		_, err := getLokiLogContext(ctx, GetLokiLogContextParams{
+			DatasourceUID: "loki-uid",
+			LogQL:         `{app="nginx"}`,
+			Timestamp:     "yesterday",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid timestamp")
+	})
+}
+
+func TestLokiErrorKinds(t *testing.T) {
+	server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/label/missing/values") {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`404 page not found`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`parse error at line 1, col 5`))
+	})
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	_, err := listLokiLabelValues(ctx, ListLokiLabelValuesParams{DatasourceUID: "loki-uid", LabelName: "missing"})
+	require.Error(t, err)
+	assert.Equal(t, mcpgrafana.ErrKindNotFound, mcpgrafana.ErrorKindOf(err))
+
+	_, err = queryLokiLogs(ctx, QueryLokiLogsParams{DatasourceUID: "loki-uid", LogQL: `{app="nginx"`})
+	require.Error(t, err)
+	assert.Equal(t, mcpgrafana.ErrKindValidation, mcpgrafana.ErrorKindOf(err))
+}
+
+func TestLokiDatasourceAccessDenied(t *testing.T) {
+	server := newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message": "access denied to datasource"}`))
+	})
+	defer server.Close()
+
+	t.Run("names the datasource and forwarded user", func(t *testing.T) {
+		ctx := mockDatasourceCtx(server, map[string]string{"X-Webauth-User": "alice"})
+		_, err := queryLokiLogs(ctx, QueryLokiLogsParams{
+			DatasourceUID: "loki-uid",
+			LogQL:         `{app="nginx"}`,
+		})
+		require.Error(t, err)
+		var denied *datasourceAccessDeniedError
+		require.ErrorAs(t, err, &denied)
+		assert.Contains(t, err.Error(), `access denied: the authenticated user (forwarded user "alice") lacks access to datasource "loki-uid": access denied to datasource`)
+		assert.Equal(t, mcpgrafana.ErrKindAuth, mcpgrafana.ErrorKindOf(err))
+	})
+
+	t.Run("without a forwarded user", func(t *testing.T) {
+		_, err := listLokiLabelNames(mockDatasourceCtx(server, nil), ListLokiLabelNamesParams{DatasourceUID: "loki-uid"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `access denied: the authenticated user lacks access to datasource "loki-uid"`)
+	})
+}
+
+func TestLokiGrafanaCloudEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/datasources/uid/loki-uid", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"uid": "loki-uid", "name": "grafanacloud-mystack-logs", "type": "loki",
+			"url": "https://logs-prod-006.grafana.net", "basicAuth": true, "basicAuthUser": "654321",
+		})
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: "https://mystack.grafana.net", APIKey: "test"}
+
+	t.Run("hosted endpoint with a cloud token", func(t *testing.T) {
+		cfg := cfg
+		cfg.CloudAccessPolicyToken = "glc_token"
+		ctx := mcpgrafana.WithGrafanaConfig(mockCtxWithClient(server), cfg)
+		client, err := newLokiClient(ctx, "loki-uid", "")
+		require.NoError(t, err)
+		assert.Equal(t, "https://logs-prod-006.grafana.net/loki/api/v1/query_range", client.buildURL("/loki/api/v1/query_range"))
+	})
+
+	t.Run("datasource proxy without a cloud token", func(t *testing.T) {
+		ctx := mcpgrafana.WithGrafanaConfig(mockCtxWithClient(server), cfg)
+		client, err := newLokiClient(ctx, "loki-uid", "")
+		require.NoError(t, err)
+		assert.Equal(t, "https://mystack.grafana.net/api/datasources/proxy/uid/loki-uid/loki/api/v1/query_range", client.buildURL("/loki/api/v1/query_range"))
+	})
+}
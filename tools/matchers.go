@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// labelMatcherOperators are the operators accepted between a label name and
+// value in both PromQL and LogQL selectors.
+var labelMatcherOperators = []string{"=", "!=", "=~", "!~"}
+
+// labelMatcherHint is appended to label matcher validation errors to show the
+// expected shape of a matcher.
+const labelMatcherHint = `matchers look like name="value", using one of the operators =, !=, =~ or !~ and a quoted value`
+
+// validateLabelMatchers checks the syntax of the comma separated label
+// matchers inside a selector's braces, e.g. `app="foo", env=~"prod|dev"`, and
+// returns an error naming the first malformed matcher. Label names may be
+// quoted, and a quoted name on its own is accepted as a PromQL metric name.
+// Regular expressions are not compiled; that is left to the datasource.
+func validateLabelMatchers(matchers string) error {
+	runes := []rune(matchers)
+	i := nextNonSpace(runes, 0)
+	for i < len(runes) {
+		start := i
+		invalid := func(format string, args ...any) error {
+			return fmt.Errorf("invalid label matcher %q: %s; %s", matcherText(runes, start), fmt.Sprintf(format, args...), labelMatcherHint)
+		}
+
+		quotedName := false
+		switch {
+		case isQuote(runes[i]):
+			end := skipStringLiteral(runes, i)
+			if end < 0 {
+				return invalid("unterminated label name")
+			}
+			i = end + 1
+			quotedName = true
+		case isLabelNameStart(runes[i]):
+			for i < len(runes) && isLabelNameChar(runes[i]) {
+				i++
+			}
+		default:
+			return invalid("expected a label name, found %q", runes[i])
+		}
+
+		i = nextNonSpace(runes, i)
+		if quotedName && (i == len(runes) || runes[i] == ',') {
+			// A quoted metric name, e.g. {"my.metric", job="foo"}.
+			i = nextNonSpace(runes, i+1)
+			continue
+		}
+
+		opStart := i
+		for i < len(runes) && strings.ContainsRune("=!~<>", runes[i]) {
+			i++
+		}
+		op := string(runes[opStart:i])
+		if op == "" {
+			return invalid("missing operator after the label name")
+		}
+		if !slices.Contains(labelMatcherOperators, op) {
+			return invalid("unknown operator %q", op)
+		}
+
+		i = nextNonSpace(runes, i)
+		if i == len(runes) {
+			return invalid("missing value")
+		}
+		if !isQuote(runes[i]) {
+			return invalid("value must be a quoted string")
+		}
+		end := skipStringLiteral(runes, i)
+		if end < 0 {
+			return invalid("unterminated value")
+		}
+
+		i = nextNonSpace(runes, end+1)
+		if i < len(runes) {
+			if runes[i] != ',' {
+				return invalid("expected ',' between matchers, found %q", runes[i])
+			}
+			i = nextNonSpace(runes, i+1)
+		}
+	}
+	return nil
+}
+
+// validateLabelMatcher checks a structured label matcher, as accepted by the
+// tools taking a list of selectors.
+func validateLabelMatcher(m LabelMatcher) error {
+	if m.Name == "" {
+		return fmt.Errorf("invalid label matcher: label name is empty; %s", labelMatcherHint)
+	}
+	if m.Type != "" && !slices.Contains(labelMatcherOperators, m.Type) {
+		return fmt.Errorf("invalid label matcher for label %q: unknown operator %q; %s", m.Name, m.Type, labelMatcherHint)
+	}
+	return nil
+}
+
+// validateSelectors checks every matcher of the given selectors.
+func validateSelectors(selectors []Selector) error {
+	for _, s := range selectors {
+		for _, m := range s.Filters {
+			if err := validateLabelMatcher(m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validatePromQLMatchers checks the label matchers of every selector in a
+// PromQL expression. Anything outside the braces is left to Prometheus.
+func validatePromQLMatchers(expr string) error {
+	runes := []rune(expr)
+	open := -1
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '#':
+			// Skip comments, which run to the end of the line.
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case isQuote(r):
+			end := skipStringLiteral(runes, i)
+			if end < 0 {
+				return fmt.Errorf("invalid PromQL query: unterminated string starting at position %d", i)
+			}
+			i = end
+		case r == '{':
+			open = i
+		case r == '}' && open >= 0:
+			if err := validateLabelMatchers(string(runes[open+1 : i])); err != nil {
+				return fmt.Errorf("invalid PromQL query: %w", err)
+			}
+			open = -1
+		}
+	}
+	return nil
+}
+
+// matcherText returns the matcher starting at start, up to the next comma
+// outside a string, for use in error messages.
+func matcherText(runes []rune, start int) string {
+	for i := start; i < len(runes); i++ {
+		switch {
+		case isQuote(runes[i]):
+			end := skipStringLiteral(runes, i)
+			if end < 0 {
+				return strings.TrimSpace(string(runes[start:]))
+			}
+			i = end
+		case runes[i] == ',':
+			return strings.TrimSpace(string(runes[start:i]))
+		}
+	}
+	return strings.TrimSpace(string(runes[start:]))
+}
+
+// skipStringLiteral returns the index of the closing quote of the PromQL or
+// LogQL string literal starting at start, or -1 if it is unterminated.
+// Backtick strings are raw; the others support backslash escapes.
+func skipStringLiteral(runes []rune, start int) int {
+	quote := runes[start]
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func isQuote(r rune) bool {
+	return r == '"' || r == '\'' || r == '`'
+}
+
+func isLabelNameStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isLabelNameChar(r rune) bool {
+	return isLabelNameStart(r) || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,90 @@
+//go:build unit
+
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLabelMatchers(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		matchers string
+		wantErr  string
+	}{
+		{name: "equal", matchers: `app="nginx"`},
+		{name: "not equal", matchers: `app!="nginx"`},
+		{name: "regex", matchers: `env=~"prod|staging"`},
+		{name: "not regex", matchers: `env!~"dev.*"`},
+		{name: "several matchers with spaces", matchers: ` app = "nginx" , env=~'prod', path!~` + "`/api/.*`"},
+		{name: "trailing comma", matchers: `app="nginx",`},
+		{name: "empty", matchers: ``},
+		{name: "escaped quote in value", matchers: `msg="say \"hi\", bye"`},
+		{name: "quoted metric name", matchers: `"http.requests.total", job="api"`},
+		{name: "quoted label name", matchers: `"service.name"="api"`},
+		{name: "unquoted value", matchers: `app=nginx`, wantErr: `invalid label matcher "app=nginx": value must be a quoted string`},
+		{name: "double equals", matchers: `app=="nginx"`, wantErr: `invalid label matcher "app==\"nginx\"": unknown operator "=="`},
+		{name: "reversed regex operator", matchers: `app~="nginx"`, wantErr: `unknown operator "~="`},
+		{name: "missing operator", matchers: `app "nginx"`, wantErr: "missing operator after the label name"},
+		{name: "missing value", matchers: `app=`, wantErr: "missing value"},
+		{name: "unterminated value", matchers: `app="nginx`, wantErr: "unterminated value"},
+		{name: "missing comma", matchers: `app="nginx" env="prod"`, wantErr: `invalid label matcher "app=\"nginx\" env=\"prod\"": expected ',' between matchers`},
+		{name: "bad second matcher", matchers: `app="nginx", env=prod`, wantErr: `invalid label matcher "env=prod"`},
+		{name: "invalid label name", matchers: `1app="nginx"`, wantErr: "expected a label name"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateLabelMatchers(tc.matchers)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+			assert.Contains(t, err.Error(), `name="value"`)
+		})
+	}
+}
+
+func TestValidatePromQLMatchers(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		expr    string
+		wantErr string
+	}{
+		{name: "no selectors", expr: `vector(1)`},
+		{name: "empty selector", expr: `up{}`},
+		{name: "aggregation", expr: `sum by (job) (rate(http_requests_total{job="api", code=~"5.."}[5m])) / ignoring(code) group_left sum(up{job="api"})`},
+		{name: "braces in strings", expr: `label_replace(up{job="api"}, "x", "{}", "job", "(.*)")`},
+		{name: "comment", expr: "up{job=\"api\"} # don't worry"},
+		{name: "malformed matcher", expr: `rate(http_requests_total{job=api}[5m])`, wantErr: `invalid PromQL query: invalid label matcher "job=api"`},
+		{name: "unterminated string", expr: `up{job="api}`, wantErr: "unterminated string"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePromQLMatchers(tc.expr)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestValidateSelectors(t *testing.T) {
+	assert.NoError(t, validateSelectors([]Selector{{Filters: []LabelMatcher{
+		{Name: "job", Value: "api"},
+		{Name: "env", Value: "prod|dev", Type: "=~"},
+	}}}))
+
+	err := validateSelectors([]Selector{{Filters: []LabelMatcher{{Name: "job", Value: "api", Type: "=="}}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid label matcher for label "job": unknown operator "=="`)
+
+	err = validateSelectors([]Selector{{Filters: []LabelMatcher{{Value: "api"}}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "label name is empty")
+}
@@ -1,10 +1,17 @@
 package tools
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -103,6 +110,504 @@ var GenerateDeeplink = mcpgrafana.MustTool(
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
-func AddNavigationTools(mcp *server.MCPServer) {
+type GenerateExploreURLParams struct {
+	DatasourceUID  string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
+	DatasourceType string `json:"datasourceType" jsonschema:"required,description=The type of the datasource: prometheus or loki"`
+	Expr           string `json:"expr" jsonschema:"required,description=The PromQL or LogQL query expression"`
+	From           string `json:"from,omitempty" jsonschema:"description=Start time (e.g.\\, 'now-1h' or an RFC3339 timestamp). Defaults to 'now-1h'"`
+	To             string `json:"to,omitempty" jsonschema:"description=End time (e.g.\\, 'now' or an RFC3339 timestamp). Defaults to 'now'"`
+}
+
+// exploreDatasourceRef references the datasource of an Explore pane or query.
+type exploreDatasourceRef struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+// exploreQuery is a query of an Explore pane. Prometheus and Loki both
+// store their expression in expr but select range queries differently.
+type exploreQuery struct {
+	RefID      string               `json:"refId"`
+	Datasource exploreDatasourceRef `json:"datasource"`
+	Expr       string               `json:"expr"`
+	EditorMode string               `json:"editorMode"`
+	Range      bool                 `json:"range,omitempty"`
+	QueryType  string               `json:"queryType,omitempty"`
+}
+
+// exploreState is the state of an Explore pane, as encoded in the left
+// query parameter of Explore URLs.
+type exploreState struct {
+	Datasource string         `json:"datasource"`
+	Queries    []exploreQuery `json:"queries"`
+	Range      struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"range"`
+}
+
+func generateExploreURL(ctx context.Context, args GenerateExploreURLParams) (string, error) {
+	config := mcpgrafana.GrafanaConfigFromContext(ctx)
+	baseURL := strings.TrimRight(config.URL, "/")
+
+	if baseURL == "" {
+		return "", fmt.Errorf("grafana url not configured. Please set GRAFANA_URL environment variable or X-Grafana-URL header")
+	}
+	if args.DatasourceUID == "" {
+		return "", fmt.Errorf("datasourceUid is required")
+	}
+	if args.Expr == "" {
+		return "", fmt.Errorf("expr is required")
+	}
+
+	dsType := strings.ToLower(args.DatasourceType)
+	query := exploreQuery{
+		RefID:      "A",
+		Datasource: exploreDatasourceRef{Type: dsType, UID: args.DatasourceUID},
+		Expr:       args.Expr,
+		EditorMode: "code",
+	}
+	switch dsType {
+	case "prometheus":
+		query.Range = true
+	case "loki":
+		query.QueryType = "range"
+	default:
+		return "", fmt.Errorf("unsupported datasource type: %s. Supported types are: prometheus, loki", args.DatasourceType)
+	}
+
+	state := exploreState{
+		Datasource: args.DatasourceUID,
+		Queries:    []exploreQuery{query},
+	}
+	state.Range.From = stringOrDefault(args.From, "now-1h")
+	state.Range.To = stringOrDefault(args.To, "now")
+
+	left, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("marshal explore state: %w", err)
+	}
+	params := url.Values{}
+	params.Set("orgId", strconv.FormatInt(max(config.OrgID, 1), 10))
+	params.Set("left", string(left))
+	return fmt.Sprintf("%s/explore?%s", baseURL, params.Encode()), nil
+}
+
+var GenerateExploreURL = mcpgrafana.MustTool(
+	"generate_explore_url",
+	"Generate a Grafana Explore URL that opens a Prometheus or Loki query over a time range, so it can be shared as a clickable link. Requires the datasource UID and type (prometheus or loki) and the query expression. The time range defaults to the last hour.",
+	generateExploreURL,
+	mcp.WithTitleAnnotation("Generate Explore URL"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type GenerateDashboardPanelURLParams struct {
+	DashboardUID string            `json:"dashboardUid" jsonschema:"required,description=The UID of the dashboard"`
+	PanelID      int               `json:"panelId" jsonschema:"required,description=The ID of the panel to link to"`
+	From         string            `json:"from,omitempty" jsonschema:"description=Start time (e.g.\\, 'now-1h' or an RFC3339 timestamp)"`
+	To           string            `json:"to,omitempty" jsonschema:"description=End time (e.g.\\, 'now' or an RFC3339 timestamp)"`
+	Variables    map[string]string `json:"variables,omitempty" jsonschema:"description=Template variable values keyed by variable name (without the 'var-' prefix)"`
+}
+
+func generateDashboardPanelURL(ctx context.Context, args GenerateDashboardPanelURLParams) (string, error) {
+	config := mcpgrafana.GrafanaConfigFromContext(ctx)
+	baseURL := strings.TrimRight(config.URL, "/")
+
+	if baseURL == "" {
+		return "", fmt.Errorf("grafana url not configured. Please set GRAFANA_URL environment variable or X-Grafana-URL header")
+	}
+
+	// Only link to panels that exist, so the link doesn't silently open the
+	// whole dashboard.
+	panelID := args.PanelID
+	if _, err := getDashboardPanel(ctx, GetDashboardPanelParams{UID: args.DashboardUID, PanelID: &panelID}); err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("viewPanel", strconv.Itoa(args.PanelID))
+	if args.From != "" {
+		params.Set("from", args.From)
+	}
+	if args.To != "" {
+		params.Set("to", args.To)
+	}
+	for name, value := range args.Variables {
+		params.Set("var-"+name, value)
+	}
+	return fmt.Sprintf("%s/d/%s?%s", baseURL, url.PathEscape(args.DashboardUID), params.Encode()), nil
+}
+
+var GenerateDashboardPanelURL = mcpgrafana.MustTool(
+	"generate_dashboard_panel_url",
+	"Generate a URL that opens a single dashboard panel (viewPanel), optionally over a time range and with template variable values, so it can be cited as a clickable link. Checks that the panel exists on the dashboard before generating the link.",
+	generateDashboardPanelURL,
+	mcp.WithTitleAnnotation("Generate dashboard panel URL"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type ResolveShortURLParams struct {
+	URL string `json:"url" jsonschema:"required,description=The short URL to resolve\\, e.g. 'https://grafana.example.com/goto/abc123?orgId=1'\\, or just its UID"`
+}
+
+// ResolvedShortURL is the target of a Grafana short URL. Dashboard and
+// Explore targets are parsed, so that their queries can be run again.
+type ResolvedShortURL struct {
+	UID string `json:"uid"`
+	// URL is the full target URL, and Path the target relative to Grafana's
+	// URL.
+	URL  string `json:"url"`
+	Path string `json:"path"`
+	// Kind is "dashboard", "explore" or "other".
+	Kind      string     `json:"kind"`
+	TimeRange *TimeRange `json:"timeRange,omitempty"`
+
+	DashboardUID string            `json:"dashboardUid,omitempty"`
+	PanelID      *int              `json:"panelId,omitempty"`
+	Variables    map[string]string `json:"variables,omitempty"`
+
+	// DatasourceUID is the datasource of the first Explore pane. Queries
+	// holds the queries of all panes, each with its own datasource.
+	DatasourceUID string          `json:"datasourceUid,omitempty"`
+	Queries       []ShortURLQuery `json:"queries,omitempty"`
+}
+
+// ShortURLQuery is a query of an Explore pane a short URL links to.
+type ShortURLQuery struct {
+	RefID          string `json:"refId,omitempty"`
+	DatasourceUID  string `json:"datasourceUid,omitempty"`
+	DatasourceType string `json:"datasourceType,omitempty"`
+	Expr           string `json:"expr,omitempty"`
+}
+
+// shortURLUID returns the UID of a short URL given as a full URL, a /goto/
+// path or a bare UID.
+func shortURLUID(shortURL string) (string, error) {
+	shortURL = strings.TrimSpace(shortURL)
+	if _, after, ok := strings.Cut(shortURL, "/goto/"); ok {
+		shortURL, _, _ = strings.Cut(after, "?")
+		shortURL, _, _ = strings.Cut(shortURL, "#")
+		shortURL = strings.TrimRight(shortURL, "/")
+	}
+	if shortURL == "" || strings.ContainsAny(shortURL, "/?#") {
+		return "", mcpgrafana.NewError(mcpgrafana.ErrKindValidation, fmt.Sprintf("invalid short URL %q: expected a URL like https://grafana.example.com/goto/abc123 or its UID", shortURL), nil)
+	}
+	return shortURL, nil
+}
+
+// newShortURLClient returns a client for Grafana's short URL endpoints,
+// which the OpenAPI client doesn't cover.
+func newShortURLClient(config mcpgrafana.GrafanaConfig) (*http.Client, error) {
+	transport, err := mcpgrafana.BuildTransport(&config, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create custom transport: %w", err)
+	}
+	transport = NewAuthRoundTripper(transport, config.AccessToken, config.IDToken, config.APIKey, config.BasicAuth)
+	transport = mcpgrafana.NewOrgIDRoundTripper(transport, config.OrgID)
+	return &http.Client{
+		Transport: mcpgrafana.NewUserAgentTransport(transport),
+		Timeout:   10 * time.Second,
+	}, nil
+}
+
+func resolveShortURL(ctx context.Context, args ResolveShortURLParams) (*ResolvedShortURL, error) {
+	config := mcpgrafana.GrafanaConfigFromContext(ctx)
+	baseURL := strings.TrimRight(config.URL, "/")
+
+	if baseURL == "" {
+		return nil, fmt.Errorf("grafana url not configured. Please set GRAFANA_URL environment variable or X-Grafana-URL header")
+	}
+	uid, err := shortURLUID(args.URL)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base url: %w", err)
+	}
+
+	httpClient, err := newShortURLClient(config)
+	if err != nil {
+		return nil, err
+	}
+	// Grafana redirects short URLs to their target, which is all we need.
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	gotoURL := base.JoinPath("goto", uid)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gotoURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("resolve short url %s: %w", uid, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	location := resp.Header.Get("Location")
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400 && location != "":
+	case resp.StatusCode < 300 || resp.StatusCode == http.StatusNotFound:
+		// Grafana serves its UI rather than redirecting when the short URL
+		// doesn't exist.
+		return nil, mcpgrafana.NewError(mcpgrafana.ErrKindNotFound, fmt.Sprintf("short URL %s not found: it may have expired after going unused, or belong to another organization", uid), nil)
+	default:
+		body := readErrorBody(resp.Body)
+		return nil, mcpgrafana.NewHTTPError(resp.StatusCode, fmt.Sprintf("resolve short url %s: HTTP %d - %s", uid, resp.StatusCode, summarizeErrorBody(body)))
+	}
+
+	target, err := gotoURL.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("parse short url target %q: %w", location, err)
+	}
+	// Grafana sends requests it can't authenticate to its login page.
+	if strings.HasSuffix(strings.TrimRight(target.Path, "/"), "/login") {
+		return nil, mcpgrafana.NewError(mcpgrafana.ErrKindAuth, fmt.Sprintf("resolve short url %s: Grafana redirected to its login page, check the configured credentials", uid), nil)
+	}
+	return parseShortURLTarget(uid, baseURL, base.Path, target), nil
+}
+
+// parseShortURLTarget describes the target of a short URL. Grafana redirects
+// to its own root URL, which may differ from the URL the server was given,
+// so the target is made relative to Grafana's sub path and joined to
+// baseURL.
+func parseShortURLTarget(uid, baseURL, subPath string, target *url.URL) *ResolvedShortURL {
+	path := strings.TrimPrefix(target.Path, strings.TrimRight(subPath, "/"))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	relative := path
+	if target.RawQuery != "" {
+		relative += "?" + target.RawQuery
+	}
+	result := &ResolvedShortURL{
+		UID:  uid,
+		URL:  baseURL + relative,
+		Path: relative,
+		Kind: "other",
+	}
+
+	query := target.Query()
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case segments[0] == "explore":
+		result.Kind = "explore"
+		parseExploreTarget(result, query)
+	case (segments[0] == "d" || segments[0] == "d-solo") && len(segments) > 1:
+		result.Kind = "dashboard"
+		result.DashboardUID = segments[1]
+		panel := query.Get("viewPanel")
+		if panel == "" {
+			panel = query.Get("panelId")
+		}
+		if id, err := strconv.Atoi(strings.TrimPrefix(panel, "panel-")); err == nil {
+			result.PanelID = &id
+		}
+		for name, values := range query {
+			if variable, ok := strings.CutPrefix(name, "var-"); ok && len(values) > 0 {
+				if result.Variables == nil {
+					result.Variables = map[string]string{}
+				}
+				result.Variables[variable] = values[0]
+			}
+		}
+	}
+	if result.TimeRange == nil && (query.Get("from") != "" || query.Get("to") != "") {
+		result.TimeRange = &TimeRange{From: query.Get("from"), To: query.Get("to")}
+	}
+	return result
+}
+
+// shortURLExplorePane is an Explore pane as encoded in the panes query
+// parameter of Explore URLs, or the left parameter of older ones.
+type shortURLExplorePane struct {
+	Datasource string `json:"datasource"`
+	Queries    []struct {
+		RefID string `json:"refId"`
+		// Datasource is a reference object in current Grafana versions and a
+		// name in older ones, which is ignored.
+		Datasource json.RawMessage `json:"datasource"`
+		Expr       string          `json:"expr"`
+		Query      string          `json:"query"`
+	} `json:"queries"`
+	Range *TimeRange `json:"range"`
+}
+
+// parseExploreTarget fills in the datasource, queries and time range of an
+// Explore short URL target. Panes which can't be parsed are skipped, leaving
+// only the path.
+func parseExploreTarget(result *ResolvedShortURL, query url.Values) {
+	var panes []shortURLExplorePane
+	if raw := query.Get("panes"); raw != "" {
+		var byID map[string]shortURLExplorePane
+		if err := json.Unmarshal([]byte(raw), &byID); err == nil {
+			ids := slices.Sorted(maps.Keys(byID))
+			for _, id := range ids {
+				panes = append(panes, byID[id])
+			}
+		}
+	} else if raw := query.Get("left"); raw != "" {
+		var pane shortURLExplorePane
+		if err := json.Unmarshal([]byte(raw), &pane); err == nil {
+			panes = append(panes, pane)
+		}
+	}
+
+	for i, pane := range panes {
+		if i == 0 {
+			result.DatasourceUID = pane.Datasource
+			if pane.Range != nil {
+				result.TimeRange = pane.Range
+			}
+		}
+		for _, q := range pane.Queries {
+			sq := ShortURLQuery{RefID: q.RefID, DatasourceUID: pane.Datasource, Expr: q.Expr}
+			if sq.Expr == "" {
+				sq.Expr = q.Query
+			}
+			var ds exploreDatasourceRef
+			if err := json.Unmarshal(q.Datasource, &ds); err == nil {
+				sq.DatasourceType = ds.Type
+				if ds.UID != "" {
+					sq.DatasourceUID = ds.UID
+				}
+			}
+			result.Queries = append(result.Queries, sq)
+		}
+	}
+}
+
+var ResolveShortURL = mcpgrafana.MustTool(
+	"resolve_short_url",
+	"Resolve a Grafana short URL (/goto/<uid>), as shared from dashboards and Explore, to its full target. Returns the target URL and path and, for dashboards, the dashboard UID, panel ID, template variables and time range, or, for Explore, the datasource, queries and time range, so the link can be explained or its queries run again. Fails with a not found error if the short URL doesn't exist or has expired.",
+	resolveShortURL,
+	mcp.WithTitleAnnotation("Resolve short URL"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type CreateShortURLParams struct {
+	Path string `json:"path" jsonschema:"required,description=The Grafana path to shorten\\, relative to Grafana's URL\\, e.g. '/d/abc123/my-dashboard?from=now-6h&to=now' or an Explore path as returned by generate_explore_url. A full URL is accepted if it points at the configured Grafana"`
+}
+
+// CreatedShortURL is a short URL created for sharing.
+type CreatedShortURL struct {
+	UID  string `json:"uid"`
+	URL  string `json:"url"`
+	Path string `json:"path"`
+}
+
+// shortURLPath returns path relative to Grafana's URL, without a leading
+// slash, as Grafana stores short URL targets. Full URLs must point at
+// baseURL, so that short URLs can't redirect elsewhere.
+func shortURLPath(base *url.URL, path string) (string, error) {
+	invalid := func(reason string) error {
+		return mcpgrafana.NewError(mcpgrafana.ErrKindValidation, fmt.Sprintf("invalid path %q: %s", path, reason), nil)
+	}
+
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", invalid("path is required")
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", invalid(err.Error())
+	}
+	relative := path
+	if u.Scheme != "" || u.Host != "" {
+		prefix := strings.TrimRight(base.String(), "/")
+		rest, ok := strings.CutPrefix(path, prefix)
+		if !strings.EqualFold(u.Scheme, base.Scheme) || !strings.EqualFold(u.Host, base.Host) || !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+			return "", invalid(fmt.Sprintf("must be relative to the configured Grafana URL %s", prefix))
+		}
+		relative = rest
+	}
+	relative = strings.TrimLeft(relative, "/")
+	if relative == "" {
+		return "", invalid("path is required")
+	}
+	if p, _, _ := strings.Cut(relative, "?"); slices.Contains(strings.Split(p, "/"), "..") {
+		return "", invalid("path must not contain '..'")
+	}
+	return relative, nil
+}
+
+func createShortURL(ctx context.Context, args CreateShortURLParams) (*CreatedShortURL, error) {
+	config := mcpgrafana.GrafanaConfigFromContext(ctx)
+	baseURL := strings.TrimRight(config.URL, "/")
+
+	if baseURL == "" {
+		return nil, fmt.Errorf("grafana url not configured. Please set GRAFANA_URL environment variable or X-Grafana-URL header")
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base url: %w", err)
+	}
+	path, err := shortURLPath(base, args.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := newShortURLClient(config)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]string{"path": path})
+	if err != nil {
+		return nil, fmt.Errorf("marshal short url: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base.JoinPath("api", "short-urls").String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("create short url: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body := readErrorBody(resp.Body)
+		return nil, mcpgrafana.NewHTTPError(resp.StatusCode, fmt.Sprintf("create short url: HTTP %d - %s", resp.StatusCode, summarizeErrorBody(body)))
+	}
+	var created struct {
+		UID string `json:"uid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("decode short url: %w", err)
+	}
+
+	// Link through the configured URL, as the other navigation tools do,
+	// rather than the root URL Grafana returns.
+	params := url.Values{}
+	params.Set("orgId", strconv.FormatInt(max(config.OrgID, 1), 10))
+	return &CreatedShortURL{
+		UID:  created.UID,
+		URL:  fmt.Sprintf("%s/goto/%s?%s", baseURL, url.PathEscape(created.UID), params.Encode()),
+		Path: "/" + path,
+	}, nil
+}
+
+var CreateShortURL = mcpgrafana.MustMutatingTool(
+	"create_short_url",
+	"Create a Grafana short URL (/goto/<uid>) for a dashboard, panel or Explore path, to hand people a compact link instead of a long one. Takes a path relative to Grafana, such as one returned by generate_explore_url or generate_dashboard_panel_url, and returns the absolute short URL. Short URLs which go unused are eventually deleted by Grafana.",
+	createShortURL,
+	mcp.WithTitleAnnotation("Create short URL"),
+	mcp.WithIdempotentHintAnnotation(false),
+	mcp.WithDestructiveHintAnnotation(false),
+)
+
+func AddNavigationTools(mcp *server.MCPServer, enableWriteTools bool) {
 	GenerateDeeplink.Register(mcp)
+	GenerateExploreURL.Register(mcp)
+	GenerateDashboardPanelURL.Register(mcp)
+	ResolveShortURL.Register(mcp)
+	if enableWriteTools {
+		CreateShortURL.Register(mcp)
+	}
 }
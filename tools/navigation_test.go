@@ -2,6 +2,8 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -143,3 +145,88 @@ func TestGenerateDeeplink(t *testing.T) {
 		assert.Contains(t, err.Error(), "datasourceUid is required")
 	})
 }
+
+func TestGenerateExploreURL(t *testing.T) {
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{
+		URL: "http://localhost:3000/",
+	})
+
+	// parseLeft checks the link points at Explore and decodes its left pane.
+	parseLeft := func(t *testing.T, link string) (url.Values, map[string]any) {
+		t.Helper()
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+		assert.Equal(t, "localhost:3000", u.Host)
+		assert.Equal(t, "/explore", u.Path)
+		var left map[string]any
+		require.NoError(t, json.Unmarshal([]byte(u.Query().Get("left")), &left))
+		return u.Query(), left
+	}
+
+	t.Run("Prometheus query", func(t *testing.T) {
+		result, err := generateExploreURL(ctx, GenerateExploreURLParams{
+			DatasourceUID:  "prom-uid",
+			DatasourceType: "prometheus",
+			Expr:           `sum by (code) (rate(http_requests_total{job="api"}[5m]))`,
+			From:           "now-6h",
+			To:             "now",
+		})
+		require.NoError(t, err)
+
+		query, left := parseLeft(t, result)
+		assert.Equal(t, "1", query.Get("orgId"))
+		assert.Equal(t, map[string]any{
+			"datasource": "prom-uid",
+			"queries": []any{map[string]any{
+				"refId":      "A",
+				"datasource": map[string]any{"type": "prometheus", "uid": "prom-uid"},
+				"expr":       `sum by (code) (rate(http_requests_total{job="api"}[5m]))`,
+				"editorMode": "code",
+				"range":      true,
+			}},
+			"range": map[string]any{"from": "now-6h", "to": "now"},
+		}, left)
+	})
+
+	t.Run("Loki query with default time range", func(t *testing.T) {
+		result, err := generateExploreURL(ctx, GenerateExploreURLParams{
+			DatasourceUID:  "loki-uid",
+			DatasourceType: "Loki",
+			Expr:           `{app="api"} |= "error" & more`,
+		})
+		require.NoError(t, err)
+		assert.NotContains(t, result, " ", "the query must be URL-encoded")
+
+		_, left := parseLeft(t, result)
+		assert.Equal(t, map[string]any{
+			"datasource": "loki-uid",
+			"queries": []any{map[string]any{
+				"refId":      "A",
+				"datasource": map[string]any{"type": "loki", "uid": "loki-uid"},
+				"expr":       `{app="api"} |= "error" & more`,
+				"editorMode": "code",
+				"queryType":  "range",
+			}},
+			"range": map[string]any{"from": "now-1h", "to": "now"},
+		}, left)
+	})
+
+	t.Run("Error cases", func(t *testing.T) {
+		_, err := generateExploreURL(ctx, GenerateExploreURLParams{
+			DatasourceUID:  "tempo-uid",
+			DatasourceType: "tempo",
+			Expr:           "{}",
+		})
+		require.EqualError(t, err, "unsupported datasource type: tempo. Supported types are: prometheus, loki")
+
+		_, err = generateExploreURL(ctx, GenerateExploreURLParams{DatasourceUID: "prom-uid", DatasourceType: "prometheus"})
+		require.EqualError(t, err, "expr is required")
+
+		_, err = generateExploreURL(context.Background(), GenerateExploreURLParams{
+			DatasourceUID:  "prom-uid",
+			DatasourceType: "prometheus",
+			Expr:           "up",
+		})
+		require.ErrorContains(t, err, "grafana url not configured")
+	})
+}
@@ -0,0 +1,202 @@
+//go:build unit
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDashboardPanelURL(t *testing.T) {
+	server := newDashboardTestServer(t)
+	defer server.Close()
+	ctx := mcpgrafana.WithGrafanaConfig(mockCtxWithClient(server), mcpgrafana.GrafanaConfig{URL: "http://localhost:3000"})
+
+	t.Run("without template variables", func(t *testing.T) {
+		result, err := generateDashboardPanelURL(ctx, GenerateDashboardPanelURLParams{
+			DashboardUID: "panels",
+			PanelID:      2,
+			From:         "now-6h",
+			To:           "now",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:3000/d/panels?from=now-6h&to=now&viewPanel=2", result)
+	})
+
+	t.Run("with template variables", func(t *testing.T) {
+		result, err := generateDashboardPanelURL(ctx, GenerateDashboardPanelURLParams{
+			DashboardUID: "panels",
+			PanelID:      4,
+			Variables:    map[string]string{"cluster": "prod-eu", "namespace": "checkout & payments"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:3000/d/panels?var-cluster=prod-eu&var-namespace=checkout+%26+payments&viewPanel=4", result)
+	})
+
+	t.Run("unknown panel", func(t *testing.T) {
+		_, err := generateDashboardPanelURL(ctx, GenerateDashboardPanelURLParams{DashboardUID: "panels", PanelID: 42})
+		require.EqualError(t, err, "no panel with id 42 found in dashboard panels")
+	})
+
+	t.Run("unknown dashboard", func(t *testing.T) {
+		_, err := generateDashboardPanelURL(ctx, GenerateDashboardPanelURLParams{DashboardUID: "missing", PanelID: 1})
+		require.ErrorContains(t, err, "get dashboard by uid")
+	})
+}
+
+func TestResolveShortURL(t *testing.T) {
+	panes := `{"abc":{"datasource":"prom-uid","queries":[{"refId":"A","expr":"rate(http_requests_total[5m])","datasource":{"type":"prometheus","uid":"prom-uid"}}],"range":{"from":"now-6h","to":"now"}}}`
+	targets := map[string]string{
+		// Grafana redirects to its configured root URL, which may differ
+		// from the URL the server was given.
+		"explore":   "http://grafana.internal:3000/grafana/explore?orgId=1&schemaVersion=1&panes=" + url.QueryEscape(panes),
+		"legacy":    "/grafana/explore?orgId=1&left=" + url.QueryEscape(`{"datasource":"loki-uid","queries":[{"refId":"A","expr":"{app=\"api\"}"}],"range":{"from":"1700000000000","to":"1700003600000"}}`),
+		"dashboard": "/grafana/d/dash-uid/my-dashboard?orgId=1&from=now-1h&to=now&var-cluster=prod&viewPanel=panel-4",
+		// Grafana sends unauthenticated requests to its login page.
+		"login": "/grafana/login",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/grafana/goto/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "Permission denied"}`))
+		default:
+			target, ok := targets[strings.TrimPrefix(r.URL.Path, "/grafana/goto/")]
+			if !ok {
+				// Grafana serves its UI for unknown short URLs.
+				w.Header().Set("Content-Type", "text/html")
+				_, _ = w.Write([]byte("<html></html>"))
+				return
+			}
+			http.Redirect(w, r, target, http.StatusFound)
+		}
+	}))
+	defer server.Close()
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL + "/grafana", APIKey: "test-key"})
+
+	t.Run("explore", func(t *testing.T) {
+		result, err := resolveShortURL(ctx, ResolveShortURLParams{URL: server.URL + "/grafana/goto/explore?orgId=1"})
+		require.NoError(t, err)
+		assert.Equal(t, "explore", result.UID)
+		assert.Equal(t, "explore", result.Kind)
+		assert.True(t, strings.HasPrefix(result.URL, server.URL+"/grafana/explore?orgId=1&schemaVersion=1&panes="), result.URL)
+		assert.True(t, strings.HasPrefix(result.Path, "/explore?"), result.Path)
+		assert.Equal(t, "prom-uid", result.DatasourceUID)
+		assert.Equal(t, &TimeRange{From: "now-6h", To: "now"}, result.TimeRange)
+		assert.Equal(t, []ShortURLQuery{{RefID: "A", DatasourceUID: "prom-uid", DatasourceType: "prometheus", Expr: "rate(http_requests_total[5m])"}}, result.Queries)
+	})
+
+	t.Run("legacy explore by uid", func(t *testing.T) {
+		result, err := resolveShortURL(ctx, ResolveShortURLParams{URL: "legacy"})
+		require.NoError(t, err)
+		assert.Equal(t, "explore", result.Kind)
+		assert.Equal(t, "loki-uid", result.DatasourceUID)
+		assert.Equal(t, &TimeRange{From: "1700000000000", To: "1700003600000"}, result.TimeRange)
+		assert.Equal(t, []ShortURLQuery{{RefID: "A", DatasourceUID: "loki-uid", Expr: `{app="api"}`}}, result.Queries)
+	})
+
+	t.Run("dashboard", func(t *testing.T) {
+		result, err := resolveShortURL(ctx, ResolveShortURLParams{URL: "/goto/dashboard"})
+		require.NoError(t, err)
+		assert.Equal(t, "dashboard", result.Kind)
+		assert.Equal(t, server.URL+"/grafana/d/dash-uid/my-dashboard?orgId=1&from=now-1h&to=now&var-cluster=prod&viewPanel=panel-4", result.URL)
+		assert.Equal(t, "dash-uid", result.DashboardUID)
+		require.NotNil(t, result.PanelID)
+		assert.Equal(t, 4, *result.PanelID)
+		assert.Equal(t, map[string]string{"cluster": "prod"}, result.Variables)
+		assert.Equal(t, &TimeRange{From: "now-1h", To: "now"}, result.TimeRange)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		_, err := resolveShortURL(ctx, ResolveShortURLParams{URL: "expired"})
+		require.ErrorContains(t, err, "short URL expired not found")
+		assert.Equal(t, mcpgrafana.ErrKindNotFound, mcpgrafana.ErrorKindOf(err))
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		_, err := resolveShortURL(ctx, ResolveShortURLParams{URL: "forbidden"})
+		require.ErrorContains(t, err, "Permission denied")
+		assert.Equal(t, mcpgrafana.ErrKindAuth, mcpgrafana.ErrorKindOf(err))
+	})
+
+	t.Run("redirected to login", func(t *testing.T) {
+		_, err := resolveShortURL(ctx, ResolveShortURLParams{URL: "login"})
+		require.ErrorContains(t, err, "Grafana redirected to its login page")
+		assert.Equal(t, mcpgrafana.ErrKindAuth, mcpgrafana.ErrorKindOf(err))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := resolveShortURL(ctx, ResolveShortURLParams{URL: "https://grafana.example.com/d/abc"})
+		require.ErrorContains(t, err, "invalid short URL")
+		assert.Equal(t, mcpgrafana.ErrKindValidation, mcpgrafana.ErrorKindOf(err))
+	})
+}
+
+func TestCreateShortURL(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/grafana/api/short-urls", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		var body struct {
+			Path string `json:"path"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		paths = append(paths, body.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"uid": "abc123", "url": "http://grafana.internal:3000/grafana/goto/abc123?orgId=1"}`))
+	}))
+	defer server.Close()
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL + "/grafana", APIKey: "test-key", OrgID: 2})
+
+	t.Run("relative path", func(t *testing.T) {
+		paths = nil
+		result, err := createShortURL(ctx, CreateShortURLParams{Path: "/explore?orgId=2&left=%7B%7D"})
+		require.NoError(t, err)
+		assert.Equal(t, &CreatedShortURL{
+			UID:  "abc123",
+			URL:  server.URL + "/grafana/goto/abc123?orgId=2",
+			Path: "/explore?orgId=2&left=%7B%7D",
+		}, result)
+		assert.Equal(t, []string{"explore?orgId=2&left=%7B%7D"}, paths)
+	})
+
+	t.Run("full url of the configured grafana", func(t *testing.T) {
+		paths = nil
+		result, err := createShortURL(ctx, CreateShortURLParams{Path: server.URL + "/grafana/d/dash-uid?viewPanel=4"})
+		require.NoError(t, err)
+		assert.Equal(t, "/d/dash-uid?viewPanel=4", result.Path)
+		assert.Equal(t, []string{"d/dash-uid?viewPanel=4"}, paths)
+	})
+
+	for _, tc := range []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{name: "external url", path: "https://evil.example.com/grafana/d/abc", wantErr: "must be relative to the configured Grafana URL"},
+		{name: "protocol relative url", path: "//evil.example.com/d/abc", wantErr: "must be relative to the configured Grafana URL"},
+		{name: "outside grafana's sub path", path: server.URL + "/other/d/abc", wantErr: "must be relative to the configured Grafana URL"},
+		{name: "sibling of grafana's sub path", path: server.URL + "/grafana-old/d/abc", wantErr: "must be relative to the configured Grafana URL"},
+		{name: "parent directory", path: "/d/../../admin", wantErr: "must not contain '..'"},
+		{name: "empty", path: " ", wantErr: "path is required"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			paths = nil
+			_, err := createShortURL(ctx, CreateShortURLParams{Path: tc.path})
+			require.ErrorContains(t, err, tc.wantErr)
+			assert.Equal(t, mcpgrafana.ErrKindValidation, mcpgrafana.ErrorKindOf(err))
+			assert.Empty(t, paths)
+		})
+	}
+}
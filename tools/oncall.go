@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	aapi "github.com/grafana/amixr-api-go-client"
 	"github.com/grafana/grafana-openapi-client-go/client"
@@ -166,7 +168,7 @@ func getOnCallShiftServiceFromContext(ctx context.Context) (*aapi.OnCallShiftSer
 type ListOnCallSchedulesParams struct {
 	TeamID     string `json:"teamId,omitempty" jsonschema:"description=The ID of the team to list schedules for"`
 	ScheduleID string `json:"scheduleId,omitempty" jsonschema:"description=The ID of the schedule to get details for. If provided\\, returns only that schedule's details"`
-	Page       int    `json:"page,omitempty" jsonschema:"description=The page number to return (1-based)"`
+	Page       int    `json:"page,omitempty" jsonschema:"description=The page number to return (1-based). If not provided\\, all pages are returned"`
 }
 
 // ScheduleSummary represents a simplified view of an OnCall schedule
@@ -202,21 +204,28 @@ func listOnCallSchedules(ctx context.Context, args ListOnCallSchedulesParams) ([
 	}
 
 	listOptions := &aapi.ListScheduleOptions{}
-	if args.Page > 0 {
-		listOptions.Page = args.Page
-	}
 	if args.TeamID != "" {
 		listOptions.TeamID = args.TeamID
 	}
 
-	response, _, err := scheduleService.ListSchedules(listOptions)
-	if err != nil {
-		return nil, fmt.Errorf("listing OnCall schedules: %w", err)
+	var schedules []*aapi.Schedule
+	if args.Page > 0 {
+		listOptions.Page = args.Page
+		response, _, err := scheduleService.ListSchedules(listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("listing OnCall schedules: %w", err)
+		}
+		schedules = response.Schedules
+	} else {
+		schedules, err = listAllOnCallSchedules(scheduleService, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("listing OnCall schedules: %w", err)
+		}
 	}
 
 	// Convert schedules to summaries
-	summaries := make([]*ScheduleSummary, 0, len(response.Schedules))
-	for _, schedule := range response.Schedules {
+	summaries := make([]*ScheduleSummary, 0, len(schedules))
+	for _, schedule := range schedules {
 		summary := &ScheduleSummary{
 			ID:       schedule.ID,
 			Name:     schedule.Name,
@@ -232,9 +241,26 @@ func listOnCallSchedules(ctx context.Context, args ListOnCallSchedulesParams) ([
 	return summaries, nil
 }
 
+// listAllOnCallSchedules lists the schedules matching opts, following the
+// next page links of the API until the last page.
+func listAllOnCallSchedules(scheduleService *aapi.ScheduleService, opts *aapi.ListScheduleOptions) ([]*aapi.Schedule, error) {
+	var schedules []*aapi.Schedule
+	for {
+		response, _, err := scheduleService.ListSchedules(opts)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, response.Schedules...)
+		if response.Next == nil || len(response.Schedules) == 0 {
+			return schedules, nil
+		}
+		opts.Page = max(opts.Page, 1) + 1
+	}
+}
+
 var ListOnCallSchedules = mcpgrafana.MustTool(
 	"list_oncall_schedules",
-	"List Grafana OnCall schedules, optionally filtering by team ID. If a specific schedule ID is provided, retrieves details for only that schedule. Returns a list of schedule summaries including ID, name, team ID, timezone, and shift IDs. Supports pagination.",
+	"List Grafana OnCall schedules, optionally filtering by team ID. If a specific schedule ID is provided, retrieves details for only that schedule. Returns a list of schedule summaries including ID, name, team ID, timezone, and shift IDs. All pages are returned unless a specific page is requested.",
 	listOnCallSchedules,
 	mcp.WithTitleAnnotation("List OnCall schedules"),
 	mcp.WithIdempotentHintAnnotation(true),
@@ -268,63 +294,165 @@ var GetOnCallShift = mcpgrafana.MustTool(
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+// OnCallUser is a user currently on call, with the handles to contact them.
+type OnCallUser struct {
+	ID          string `json:"id" jsonschema:"description=The ID of the user"`
+	Username    string `json:"username" jsonschema:"description=The username of the user"`
+	Email       string `json:"email,omitempty" jsonschema:"description=The email address of the user"`
+	SlackUserID string `json:"slackUserId,omitempty" jsonschema:"description=The Slack user ID of the user\\, if connected"`
+	ShiftEnd    string `json:"shiftEnd" jsonschema:"description=When the user's current shift ends"`
+}
+
 // CurrentOnCallUsers represents the currently on-call users for a schedule
 type CurrentOnCallUsers struct {
-	ScheduleID   string       `json:"scheduleId" jsonschema:"description=The ID of the schedule"`
-	ScheduleName string       `json:"scheduleName" jsonschema:"description=The name of the schedule"`
-	Users        []*aapi.User `json:"users" jsonschema:"description=List of users currently on call"`
+	ScheduleID   string        `json:"scheduleId" jsonschema:"description=The ID of the schedule"`
+	ScheduleName string        `json:"scheduleName" jsonschema:"description=The name of the schedule"`
+	Users        []*OnCallUser `json:"users" jsonschema:"description=List of users currently on call"`
 }
 
 type GetCurrentOnCallUsersParams struct {
-	ScheduleID string `json:"scheduleId" jsonschema:"required,description=The ID of the schedule to get current on-call users for"`
+	ScheduleID string `json:"scheduleId,omitempty" jsonschema:"description=The ID of the schedule to get current on-call users for"`
+	TeamID     string `json:"teamId,omitempty" jsonschema:"description=The ID of a team to get current on-call users for across all of its schedules. Ignored if scheduleId is set"`
 }
 
-func getCurrentOnCallUsers(ctx context.Context, args GetCurrentOnCallUsersParams) (*CurrentOnCallUsers, error) {
-	scheduleService, err := getScheduleServiceFromContext(ctx)
+// onCallFinalShift is a shift of the final schedule, which has overrides and
+// swaps applied.
+type onCallFinalShift struct {
+	UserPK       string    `json:"user_pk"`
+	UserEmail    string    `json:"user_email"`
+	UserUsername string    `json:"user_username"`
+	ShiftStart   time.Time `json:"shift_start"`
+	ShiftEnd     time.Time `json:"shift_end"`
+}
+
+// onCallUserDetails holds the contact handles of an OnCall user, which are
+// not part of aapi.User.
+type onCallUserDetails struct {
+	Slack []struct {
+		UserID string `json:"user_id"`
+	} `json:"slack"`
+}
+
+// getCurrentOnCallUsers returns a *CurrentOnCallUsers for a schedule ID, or
+// a []*CurrentOnCallUsers with one entry per schedule for a team ID.
+func getCurrentOnCallUsers(ctx context.Context, args GetCurrentOnCallUsersParams) (any, error) {
+	if args.ScheduleID == "" && args.TeamID == "" {
+		return nil, fmt.Errorf("either scheduleId or teamId must be provided")
+	}
+
+	client, err := oncallClientFromContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("getting OnCall schedule service: %w", err)
+		return nil, fmt.Errorf("getting OnCall client: %w", err)
+	}
+	scheduleService := aapi.NewScheduleService(client)
+
+	now := time.Now().UTC()
+	users := map[string]*OnCallUser{}
+	if args.ScheduleID != "" {
+		schedule, _, err := scheduleService.GetSchedule(args.ScheduleID, &aapi.GetScheduleOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("getting schedule %s: %w", args.ScheduleID, err)
+		}
+		return currentOnCallUsersOfSchedule(client, schedule, now, users)
 	}
 
-	schedule, _, err := scheduleService.GetSchedule(args.ScheduleID, &aapi.GetScheduleOptions{})
+	schedules, err := listAllOnCallSchedules(scheduleService, &aapi.ListScheduleOptions{TeamID: args.TeamID})
+	if err != nil {
+		return nil, fmt.Errorf("listing schedules of team %s: %w", args.TeamID, err)
+	}
+	results := make([]*CurrentOnCallUsers, 0, len(schedules))
+	for _, schedule := range schedules {
+		result, err := currentOnCallUsersOfSchedule(client, schedule, now, users)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// currentOnCallUsersOfSchedule returns the users on call at now according to
+// the final shifts of a schedule. Users already looked up are reused from
+// users, and new ones are added to it.
+func currentOnCallUsersOfSchedule(client *aapi.Client, schedule *aapi.Schedule, now time.Time, users map[string]*OnCallUser) (*CurrentOnCallUsers, error) {
+	shifts, err := listOnCallFinalShifts(client, schedule.ID, now)
 	if err != nil {
-		return nil, fmt.Errorf("getting schedule %s: %w", args.ScheduleID, err)
+		return nil, err
 	}
 
-	// Create the result with the schedule info
 	result := &CurrentOnCallUsers{
 		ScheduleID:   schedule.ID,
 		ScheduleName: schedule.Name,
-		Users:        make([]*aapi.User, 0, len(schedule.OnCallNow)),
+		Users:        []*OnCallUser{},
 	}
-
-	// If there are no users on call, return early
-	if len(schedule.OnCallNow) == 0 {
-		return result, nil
+	seen := map[string]bool{}
+	for _, shift := range shifts {
+		if shift.UserPK == "" || seen[shift.UserPK] || now.Before(shift.ShiftStart) || !now.Before(shift.ShiftEnd) {
+			continue
+		}
+		seen[shift.UserPK] = true
+
+		user, ok := users[shift.UserPK]
+		if !ok {
+			user = &OnCallUser{
+				ID:       shift.UserPK,
+				Username: shift.UserUsername,
+				Email:    shift.UserEmail,
+			}
+			// The Slack handle is best effort: the user is on call
+			// whether or not it can be looked up.
+			var details onCallUserDetails
+			if req, err := client.NewRequest("GET", fmt.Sprintf("users/%s/", shift.UserPK), nil); err == nil {
+				if _, err := client.Do(req, &details); err == nil && len(details.Slack) > 0 {
+					user.SlackUserID = details.Slack[0].UserID
+				}
+			}
+			users[shift.UserPK] = user
+		}
+		onCall := *user
+		onCall.ShiftEnd = shift.ShiftEnd.Format(time.RFC3339)
+		result.Users = append(result.Users, &onCall)
 	}
+	return result, nil
+}
 
-	// Get the user service to fetch user details
-	userService, err := getUserServiceFromContext(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("getting OnCall user service: %w", err)
+// listOnCallFinalShifts returns the final shifts of a schedule on the day
+// of t (in UTC).
+func listOnCallFinalShifts(client *aapi.Client, scheduleID string, t time.Time) ([]onCallFinalShift, error) {
+	type finalShiftsOptions struct {
+		aapi.ListOptions
+		StartDate string `url:"start_date"`
+		EndDate   string `url:"end_date"`
+	}
+	opts := &finalShiftsOptions{
+		StartDate: t.Format(time.DateOnly),
+		EndDate:   t.Format(time.DateOnly),
 	}
 
-	// Fetch details for each user currently on call
-	for _, userID := range schedule.OnCallNow {
-		user, _, err := userService.GetUser(userID, &aapi.GetUserOptions{})
+	var shifts []onCallFinalShift
+	for {
+		req, err := client.NewRequest("GET", fmt.Sprintf("schedules/%s/final_shifts/", scheduleID), opts)
 		if err != nil {
-			// Log the error but continue with other users
-			fmt.Printf("Error fetching user %s: %v\n", userID, err)
-			continue
+			return nil, fmt.Errorf("creating final shifts request: %w", err)
+		}
+		var response struct {
+			aapi.PaginatedResponse
+			Results []onCallFinalShift `json:"results"`
+		}
+		if _, err := client.Do(req, &response); err != nil {
+			return nil, fmt.Errorf("getting final shifts of schedule %s: %w", scheduleID, err)
+		}
+		shifts = append(shifts, response.Results...)
+		if response.Next == nil {
+			return shifts, nil
 		}
-		result.Users = append(result.Users, user)
+		opts.Page = max(opts.Page, 1) + 1
 	}
-
-	return result, nil
 }
 
 var GetCurrentOnCallUsers = mcpgrafana.MustTool(
 	"get_current_oncall_users",
-	"Get the list of users currently on-call for a specific Grafana OnCall schedule ID. Returns the schedule ID, name, and a list of detailed user objects for those currently on call.",
+	"Get the users currently on call for a Grafana OnCall schedule ID, or for every schedule of a team ID. Uses the final schedule (with overrides and swaps applied) evaluated at the current time. For a schedule ID returns an object with the schedule ID, name and the users on call right now, including their username, email, Slack user ID when connected, and when their shift ends. For a team ID returns a list of such objects, one per schedule. A schedule with nobody on call has an empty users list.",
 	getCurrentOnCallUsers,
 	mcp.WithTitleAnnotation("Get current on-call users"),
 	mcp.WithIdempotentHintAnnotation(true),
@@ -509,6 +637,177 @@ var GetAlertGroup = mcpgrafana.MustTool(
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+type GetOnCallEscalationChainParams struct {
+	EscalationChainID string `json:"escalationChainId,omitempty" jsonschema:"description=The ID of the escalation chain. Either this or integrationId is required"`
+	IntegrationID     string `json:"integrationId,omitempty" jsonschema:"description=The ID of an integration whose default route's escalation chain to get"`
+}
+
+// EscalationStep is one step of an OnCall escalation chain.
+type EscalationStep struct {
+	Position int    `json:"position"`
+	Type     string `json:"type"`
+	// Description says what the step does in words, e.g. "Wait 5m0s".
+	Description string   `json:"description"`
+	WaitSeconds int      `json:"waitSeconds,omitempty"`
+	UserIDs     []string `json:"userIds,omitempty"`
+	ScheduleID  string   `json:"scheduleId,omitempty"`
+	TeamID      string   `json:"teamId,omitempty"`
+	UserGroupID string   `json:"userGroupId,omitempty"`
+	WebhookID   string   `json:"webhookId,omitempty"`
+	Important   bool     `json:"important,omitempty"`
+}
+
+type EscalationChainDetails struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	TeamID string            `json:"teamId,omitempty"`
+	Steps  []*EscalationStep `json:"steps"`
+}
+
+func getOnCallEscalationChain(ctx context.Context, args GetOnCallEscalationChainParams) (*EscalationChainDetails, error) {
+	if (args.EscalationChainID == "") == (args.IntegrationID == "") {
+		return nil, fmt.Errorf("exactly one of escalationChainId or integrationId must be provided")
+	}
+
+	client, err := oncallClientFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting OnCall client: %w", err)
+	}
+
+	chainID := args.EscalationChainID
+	if args.IntegrationID != "" {
+		integration, _, err := aapi.NewIntegrationService(client).GetIntegration(args.IntegrationID, &aapi.GetIntegrationOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("getting OnCall integration %s: %w", args.IntegrationID, err)
+		}
+		if integration.DefaultRoute == nil || integration.DefaultRoute.EscalationChainId == nil || *integration.DefaultRoute.EscalationChainId == "" {
+			return nil, fmt.Errorf("OnCall integration %s has no escalation chain on its default route", args.IntegrationID)
+		}
+		chainID = *integration.DefaultRoute.EscalationChainId
+	}
+
+	chain, _, err := aapi.NewEscalationChainService(client).GetEscalationChain(chainID, &aapi.GetEscalationChainOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting OnCall escalation chain %s: %w", chainID, err)
+	}
+	policies, err := listOnCallEscalationPolicies(client, chainID)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(policies, func(a, b *aapi.Escalation) int { return a.Position - b.Position })
+
+	steps := make([]*EscalationStep, 0, len(policies))
+	for _, p := range policies {
+		steps = append(steps, escalationStepFromPolicy(p))
+	}
+	return &EscalationChainDetails{ID: chain.ID, Name: chain.Name, TeamID: chain.TeamId, Steps: steps}, nil
+}
+
+// listOnCallEscalationPolicies returns the escalation policies, i.e. steps,
+// of an escalation chain.
+func listOnCallEscalationPolicies(client *aapi.Client, chainID string) ([]*aapi.Escalation, error) {
+	type escalationPoliciesOptions struct {
+		aapi.ListOptions
+		EscalationChainID string `url:"escalation_chain_id"`
+	}
+	opts := &escalationPoliciesOptions{EscalationChainID: chainID}
+
+	var policies []*aapi.Escalation
+	for {
+		req, err := client.NewRequest("GET", "escalation_policies/", opts)
+		if err != nil {
+			return nil, fmt.Errorf("creating escalation policies request: %w", err)
+		}
+		var response aapi.PaginatedEscalationsResponse
+		if _, err := client.Do(req, &response); err != nil {
+			return nil, fmt.Errorf("getting escalation policies of escalation chain %s: %w", chainID, err)
+		}
+		policies = append(policies, response.Escalations...)
+		if response.Next == nil {
+			return policies, nil
+		}
+		opts.Page = max(opts.Page, 1) + 1
+	}
+}
+
+func escalationStepFromPolicy(p *aapi.Escalation) *EscalationStep {
+	step := &EscalationStep{
+		Position:    p.Position,
+		Type:        derefString(p.Type),
+		ScheduleID:  derefString(p.NotifyOnCallFromSchedule),
+		TeamID:      derefString(p.TeamToNotify),
+		UserGroupID: derefString(p.GroupToNotify),
+		WebhookID:   derefString(p.ActionToTrigger),
+		Important:   p.Important != nil && *p.Important,
+	}
+	if p.PersonsToNotify != nil {
+		step.UserIDs = *p.PersonsToNotify
+	}
+	if p.PersonsToNotifyEachTime != nil {
+		step.UserIDs = append(step.UserIDs, *p.PersonsToNotifyEachTime...)
+	}
+	if p.Duration != nil {
+		step.WaitSeconds = *p.Duration
+	}
+
+	important := ""
+	if step.Important {
+		important = " (important)"
+	}
+	switch step.Type {
+	case "wait":
+		step.Description = fmt.Sprintf("Wait %s", time.Duration(step.WaitSeconds)*time.Second)
+	case "notify_persons":
+		step.Description = fmt.Sprintf("Notify users %s%s", strings.Join(step.UserIDs, ", "), important)
+	case "notify_person_next_each_time":
+		step.Description = fmt.Sprintf("Notify the next of users %s, in turn each time%s", strings.Join(step.UserIDs, ", "), important)
+	case "notify_on_call_from_schedule":
+		step.Description = fmt.Sprintf("Notify the users on call in schedule %s%s", step.ScheduleID, important)
+	case "notify_team_members":
+		step.Description = fmt.Sprintf("Notify the members of team %s%s", step.TeamID, important)
+	case "notify_user_group":
+		step.Description = fmt.Sprintf("Notify Slack user group %s%s", step.UserGroupID, important)
+	case "notify_whole_channel":
+		step.Description = "Notify the whole Slack channel"
+	case "trigger_webhook", "trigger_action":
+		step.Description = fmt.Sprintf("Trigger outgoing webhook %s", step.WebhookID)
+	case "notify_if_time_from_to":
+		step.Description = fmt.Sprintf("Continue escalation only between %s and %s (UTC)", derefString(p.NotifyIfTimeFrom), derefString(p.NotifyIfTimeTo))
+	case "notify_if_num_alerts_in_window":
+		step.Description = fmt.Sprintf("Continue escalation only if more than %d alerts arrive within %d minutes", derefInt(p.NumAlertsInWindow), derefInt(p.NumMinutesInWindow))
+	case "repeat_escalation":
+		step.Description = "Repeat the escalation from the first step (up to 5 times)"
+	case "resolve":
+		step.Description = "Resolve the alert group"
+	default:
+		step.Description = step.Type
+	}
+	return step
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func derefInt(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+var GetOnCallEscalationChain = mcpgrafana.MustTool(
+	"get_oncall_escalation_chain",
+	"Get a Grafana OnCall escalation chain by its ID, or the escalation chain of an integration's default route by integration ID. Returns the chain's name and its steps in order, each with its type (e.g. 'wait', 'notify_persons', 'notify_on_call_from_schedule'), a description of what it does and its targets: user IDs, schedule ID, team ID, Slack user group ID or webhook ID, and the wait in seconds for wait steps.",
+	getOnCallEscalationChain,
+	mcp.WithTitleAnnotation("Get OnCall escalation chain"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
 func AddOnCallTools(mcp *server.MCPServer) {
 	ListOnCallSchedules.Register(mcp)
 	GetOnCallShift.Register(mcp)
@@ -517,4 +816,5 @@ func AddOnCallTools(mcp *server.MCPServer) {
 	ListOnCallUsers.Register(mcp)
 	ListAlertGroups.Register(mcp)
 	GetAlertGroup.Register(mcp)
+	GetOnCallEscalationChain.Register(mcp)
 }
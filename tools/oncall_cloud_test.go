@@ -129,14 +129,14 @@ func TestCloudGetCurrentOnCallUsers(t *testing.T) {
 			ScheduleID: scheduleID,
 		})
 		require.NoError(t, err, "Should not error when getting current on-call users")
-		assert.NotNil(t, result, "Result should not be nil")
-		assert.Equal(t, scheduleID, result.ScheduleID, "Should return the correct schedule")
-		assert.NotEmpty(t, result.ScheduleName, "Schedule should have a name")
-		assert.NotNil(t, result.Users, "Users field should be present")
-
-		// Assert that Users is of type []*aapi.User
-		if len(result.Users) > 0 {
-			user := result.Users[0]
+		require.IsType(t, &CurrentOnCallUsers{}, result, "Should return the requested schedule")
+		current := result.(*CurrentOnCallUsers)
+		assert.Equal(t, scheduleID, current.ScheduleID, "Should return the correct schedule")
+		assert.NotEmpty(t, current.ScheduleName, "Schedule should have a name")
+		assert.NotNil(t, current.Users, "Users field should be present")
+
+		if len(current.Users) > 0 {
+			user := current.Users[0]
 			assert.NotEmpty(t, user.ID, "User should have an ID")
 			assert.NotEmpty(t, user.Username, "User should have a username")
 		}
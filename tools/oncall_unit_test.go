@@ -0,0 +1,195 @@
+//go:build unit
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOnCallTestServer serves the IRM plugin settings pointing at itself and
+// the given OnCall API responses, keyed by path and query, or by path alone.
+func newOnCallTestServer(t *testing.T, responses map[string]string) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/plugins/grafana-irm-app/settings" {
+			_, _ = fmt.Fprintf(w, `{"jsonData": {"onCallApiUrl": "%s/oncall"}}`, server.URL)
+			return
+		}
+		body, ok := responses[r.URL.Path+"?"+r.URL.RawQuery]
+		if !ok {
+			body, ok = responses[r.URL.Path]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"detail": "Not found."}`))
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	return server
+}
+
+func TestGetCurrentOnCallUsers(t *testing.T) {
+	now := time.Now().UTC()
+	shift := func(user, username string, start, end time.Time) string {
+		return fmt.Sprintf(`{"user_pk": %q, "user_email": "%s@example.com", "user_username": %q, "shift_start": %q, "shift_end": %q}`,
+			user, username, username, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	server := newOnCallTestServer(t, map[string]string{
+		"/oncall/api/v1/schedules/SPRIMARY/": `{"id": "SPRIMARY", "name": "Primary", "team_id": "TEAM1"}`,
+		"/oncall/api/v1/schedules/SPRIMARY/final_shifts/": `{"count": 3, "next": null, "previous": null, "results": [` +
+			shift("UALICE", "alice", now.Add(-8*time.Hour), now.Add(-time.Hour)) + `,` +
+			shift("UBOB", "bob", now.Add(-time.Hour), now.Add(7*time.Hour)) + `,` +
+			shift("UALICE", "alice", now.Add(7*time.Hour), now.Add(15*time.Hour)) + `]}`,
+		"/oncall/api/v1/schedules/SEMPTY/":              `{"id": "SEMPTY", "name": "Weekends", "team_id": "TEAM1"}`,
+		"/oncall/api/v1/schedules/SEMPTY/final_shifts/": `{"count": 0, "next": null, "previous": null, "results": []}`,
+		"/oncall/api/v1/schedules/?team_id=TEAM1": `{"count": 2, "next": null, "previous": null, "results": [
+			{"id": "SPRIMARY", "name": "Primary", "team_id": "TEAM1"},
+			{"id": "SEMPTY", "name": "Weekends", "team_id": "TEAM1"}
+		]}`,
+		"/oncall/api/v1/users/UBOB/": `{"id": "UBOB", "username": "bob", "email": "bob@example.com", "slack": [{"user_id": "USLACKBOB", "team_id": "TSLACK"}]}`,
+	})
+	defer server.Close()
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL, APIKey: "test"})
+
+	bob := &OnCallUser{
+		ID:          "UBOB",
+		Username:    "bob",
+		Email:       "bob@example.com",
+		SlackUserID: "USLACKBOB",
+		ShiftEnd:    now.Add(7 * time.Hour).Format(time.RFC3339),
+	}
+
+	t.Run("populated schedule", func(t *testing.T) {
+		result, err := getCurrentOnCallUsers(ctx, GetCurrentOnCallUsersParams{ScheduleID: "SPRIMARY"})
+		require.NoError(t, err)
+		assert.Equal(t, &CurrentOnCallUsers{ScheduleID: "SPRIMARY", ScheduleName: "Primary", Users: []*OnCallUser{bob}}, result)
+	})
+
+	t.Run("nobody on call", func(t *testing.T) {
+		result, err := getCurrentOnCallUsers(ctx, GetCurrentOnCallUsersParams{ScheduleID: "SEMPTY"})
+		require.NoError(t, err)
+		assert.Equal(t, &CurrentOnCallUsers{ScheduleID: "SEMPTY", ScheduleName: "Weekends", Users: []*OnCallUser{}}, result)
+	})
+
+	t.Run("team schedules", func(t *testing.T) {
+		result, err := getCurrentOnCallUsers(ctx, GetCurrentOnCallUsersParams{TeamID: "TEAM1"})
+		require.NoError(t, err)
+		assert.Equal(t, []*CurrentOnCallUsers{
+			{ScheduleID: "SPRIMARY", ScheduleName: "Primary", Users: []*OnCallUser{bob}},
+			{ScheduleID: "SEMPTY", ScheduleName: "Weekends", Users: []*OnCallUser{}},
+		}, result)
+	})
+
+	t.Run("requires a schedule or team", func(t *testing.T) {
+		_, err := getCurrentOnCallUsers(ctx, GetCurrentOnCallUsersParams{})
+		require.EqualError(t, err, "either scheduleId or teamId must be provided")
+	})
+}
+
+func TestListOnCallSchedules(t *testing.T) {
+	server := newOnCallTestServer(t, map[string]string{
+		"/oncall/api/v1/schedules/?": `{"count": 3, "next": "https://oncall.example.com/api/v1/schedules/?page=2", "previous": null, "results": [
+			{"id": "SPRIMARY", "name": "Primary", "team_id": "TEAM1", "time_zone": "Europe/Paris", "shifts": ["SHIFT1"]},
+			{"id": "SSECONDARY", "name": "Secondary", "team_id": "TEAM1", "time_zone": "UTC"}
+		]}`,
+		"/oncall/api/v1/schedules/?page=2": `{"count": 3, "next": null, "previous": "https://oncall.example.com/api/v1/schedules/", "results": [
+			{"id": "SOTHER", "name": "Other team", "team_id": "TEAM2", "time_zone": "America/New_York"}
+		]}`,
+		"/oncall/api/v1/schedules/?team_id=TEAM2": `{"count": 1, "next": null, "previous": null, "results": [
+			{"id": "SOTHER", "name": "Other team", "team_id": "TEAM2", "time_zone": "America/New_York"}
+		]}`,
+	})
+	defer server.Close()
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL, APIKey: "test"})
+
+	other := &ScheduleSummary{ID: "SOTHER", Name: "Other team", TeamID: "TEAM2", Timezone: "America/New_York"}
+
+	t.Run("all schedules across pages", func(t *testing.T) {
+		result, err := listOnCallSchedules(ctx, ListOnCallSchedulesParams{})
+		require.NoError(t, err)
+		assert.Equal(t, []*ScheduleSummary{
+			{ID: "SPRIMARY", Name: "Primary", TeamID: "TEAM1", Timezone: "Europe/Paris", Shifts: []string{"SHIFT1"}},
+			{ID: "SSECONDARY", Name: "Secondary", TeamID: "TEAM1", Timezone: "UTC"},
+			other,
+		}, result)
+	})
+
+	t.Run("single page", func(t *testing.T) {
+		result, err := listOnCallSchedules(ctx, ListOnCallSchedulesParams{Page: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []*ScheduleSummary{other}, result)
+	})
+
+	t.Run("filtered by team", func(t *testing.T) {
+		result, err := listOnCallSchedules(ctx, ListOnCallSchedulesParams{TeamID: "TEAM2"})
+		require.NoError(t, err)
+		assert.Equal(t, []*ScheduleSummary{other}, result)
+	})
+}
+
+func TestGetOnCallEscalationChain(t *testing.T) {
+	server := newOnCallTestServer(t, map[string]string{
+		"/oncall/api/v1/escalation_chains/FCHAIN/": `{"id": "FCHAIN", "name": "Critical", "team_id": "TEAM1"}`,
+		"/oncall/api/v1/escalation_policies/?escalation_chain_id=FCHAIN": `{"count": 4, "next": "https://oncall.example.com/api/v1/escalation_policies/?escalation_chain_id=FCHAIN&page=2", "previous": null, "results": [
+			{"id": "E3", "escalation_chain_id": "FCHAIN", "position": 2, "type": "notify_on_call_from_schedule", "notify_on_call_from_schedule": "SPRIMARY", "important": true},
+			{"id": "E1", "escalation_chain_id": "FCHAIN", "position": 0, "type": "notify_persons", "persons_to_notify": ["UALICE", "UBOB"]}
+		]}`,
+		"/oncall/api/v1/escalation_policies/?escalation_chain_id=FCHAIN&page=2": `{"count": 4, "next": null, "previous": null, "results": [
+			{"id": "E2", "escalation_chain_id": "FCHAIN", "position": 1, "type": "wait", "duration": 300},
+			{"id": "E4", "escalation_chain_id": "FCHAIN", "position": 3, "type": "trigger_webhook", "action_to_trigger": "WHOOK"}
+		]}`,
+		"/oncall/api/v1/integrations/CINT/":   `{"id": "CINT", "name": "Alertmanager", "default_route": {"id": "RDEFAULT", "escalation_chain_id": "FCHAIN"}}`,
+		"/oncall/api/v1/integrations/CEMPTY/": `{"id": "CEMPTY", "name": "Webhook", "default_route": {"id": "RDEFAULT2", "escalation_chain_id": null}}`,
+	})
+	defer server.Close()
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL, APIKey: "test"})
+
+	expected := &EscalationChainDetails{
+		ID:     "FCHAIN",
+		Name:   "Critical",
+		TeamID: "TEAM1",
+		Steps: []*EscalationStep{
+			{Position: 0, Type: "notify_persons", Description: "Notify users UALICE, UBOB", UserIDs: []string{"UALICE", "UBOB"}},
+			{Position: 1, Type: "wait", Description: "Wait 5m0s", WaitSeconds: 300},
+			{Position: 2, Type: "notify_on_call_from_schedule", Description: "Notify the users on call in schedule SPRIMARY (important)", ScheduleID: "SPRIMARY", Important: true},
+			{Position: 3, Type: "trigger_webhook", Description: "Trigger outgoing webhook WHOOK", WebhookID: "WHOOK"},
+		},
+	}
+
+	t.Run("by escalation chain", func(t *testing.T) {
+		result, err := getOnCallEscalationChain(ctx, GetOnCallEscalationChainParams{EscalationChainID: "FCHAIN"})
+		require.NoError(t, err)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("by integration", func(t *testing.T) {
+		result, err := getOnCallEscalationChain(ctx, GetOnCallEscalationChainParams{IntegrationID: "CINT"})
+		require.NoError(t, err)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("integration without escalation chain", func(t *testing.T) {
+		_, err := getOnCallEscalationChain(ctx, GetOnCallEscalationChainParams{IntegrationID: "CEMPTY"})
+		require.EqualError(t, err, "OnCall integration CEMPTY has no escalation chain on its default route")
+	})
+
+	t.Run("requires exactly one id", func(t *testing.T) {
+		_, err := getOnCallEscalationChain(ctx, GetOnCallEscalationChainParams{})
+		require.EqualError(t, err, "exactly one of escalationChainId or integrationId must be provided")
+		_, err = getOnCallEscalationChain(ctx, GetOnCallEscalationChainParams{EscalationChainID: "FCHAIN", IntegrationID: "CINT"})
+		require.EqualError(t, err, "exactly one of escalationChainId or integrationId must be provided")
+	})
+}
@@ -2,12 +2,16 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
-	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	mcpgrafana "github.com/grafana/mcp-grafana"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -28,15 +32,14 @@ var (
 	}
 )
 
-func promClientFromContext(ctx context.Context, uid string) (promv1.API, error) {
+func promClientFromContext(ctx context.Context, uid, tenantID string) (promv1.API, error) {
 	// First check if the datasource exists
-	_, err := getDatasourceByUID(ctx, GetDatasourceByUIDParams{UID: uid})
+	ds, err := datasourceInfo(ctx, uid)
 	if err != nil {
 		return nil, err
 	}
 
 	cfg := mcpgrafana.GrafanaConfigFromContext(ctx)
-	url := fmt.Sprintf("%s/api/datasources/uid/%s/resources", strings.TrimRight(cfg.URL, "/"), uid)
 
 	// Create custom transport with TLS configuration if available
 	rt, err := mcpgrafana.BuildTransport(&cfg, api.DefaultRoundTripper)
@@ -44,28 +47,45 @@ func promClientFromContext(ctx context.Context, uid string) (promv1.API, error)
 		return nil, fmt.Errorf("failed to create custom transport: %w", err)
 	}
 
-	if cfg.AccessToken != "" && cfg.IDToken != "" {
-		rt = config.NewHeadersRoundTripper(&config.Headers{
-			Headers: map[string]config.Header{
-				"X-Access-Token": {
-					Secrets: []config.Secret{config.Secret(cfg.AccessToken)},
-				},
-				"X-Grafana-Id": {
-					Secrets: []config.Secret{config.Secret(cfg.IDToken)},
+	var url string
+	if endpoint, ok := mcpgrafana.GrafanaCloudEndpointFor(cfg, ds.URL, ds.BasicAuthUser); ok {
+		// Datasources hosted by a Grafana Cloud stack are queried directly
+		// with the Cloud access policy token.
+		url = endpoint.URL
+		rt = endpoint.RoundTripper(rt)
+	} else {
+		// Otherwise always go through Grafana's datasource proxy, so that any
+		// datasource level auth (e.g. basic auth) configured in Grafana is
+		// applied.
+		url = fmt.Sprintf("%s/api/datasources/proxy/uid/%s", strings.TrimRight(cfg.URL, "/"), uid)
+		if cfg.AccessToken != "" && cfg.IDToken != "" {
+			rt = config.NewHeadersRoundTripper(&config.Headers{
+				Headers: map[string]config.Header{
+					"X-Access-Token": {
+						Secrets: []config.Secret{config.Secret(cfg.AccessToken)},
+					},
+					"X-Grafana-Id": {
+						Secrets: []config.Secret{config.Secret(cfg.IDToken)},
+					},
 				},
-			},
-		}, rt)
-	} else if cfg.APIKey != "" {
-		rt = config.NewAuthorizationCredentialsRoundTripper(
-			"Bearer", config.NewInlineSecret(cfg.APIKey), rt,
-		)
-	} else if cfg.BasicAuth != nil {
-		password, _ := cfg.BasicAuth.Password()
-		rt = config.NewBasicAuthRoundTripper(config.NewInlineSecret(cfg.BasicAuth.Username()), config.NewInlineSecret(password), rt)
-	}
+			}, rt)
+		} else if cfg.APIKey != "" {
+			rt = config.NewAuthorizationCredentialsRoundTripper(
+				"Bearer", config.NewInlineSecret(cfg.APIKey), rt,
+			)
+		} else if cfg.BasicAuth != nil {
+			password, _ := cfg.BasicAuth.Password()
+			rt = config.NewBasicAuthRoundTripper(config.NewInlineSecret(cfg.BasicAuth.Username()), config.NewInlineSecret(password), rt)
+		}
 
-	// Wrap with org ID support
-	rt = mcpgrafana.NewOrgIDRoundTripper(rt, cfg.OrgID)
+		// Wrap with org ID support
+		rt = mcpgrafana.NewOrgIDRoundTripper(rt, cfg.OrgID)
+	}
+	rt = newDatasourceAccessRoundTripper(rt, uid, cfg)
+	rt, err = mcpgrafana.NewTenantIDRoundTripper(rt, cfg, tenantID)
+	if err != nil {
+		return nil, err
+	}
 
 	c, err := api.NewClient(api.Config{
 		Address:      url,
@@ -80,13 +100,14 @@ func promClientFromContext(ctx context.Context, uid string) (promv1.API, error)
 
 type ListPrometheusMetricMetadataParams struct {
 	DatasourceUID  string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
-	Limit          int    `json:"limit" jsonschema:"default=10,description=The maximum number of metrics to return"`
+	Limit          int    `json:"limit" jsonschema:"default=10,description=The maximum number of metrics to return metadata for"`
 	LimitPerMetric int    `json:"limitPerMetric" jsonschema:"description=The maximum number of metrics to return per metric"`
-	Metric         string `json:"metric" jsonschema:"description=The metric to query"`
+	Metric         string `json:"metric" jsonschema:"description=Only return metadata for this metric name. If omitted metadata for all metrics is returned up to the limit."`
+	TenantID       string `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Mimir or Cortex\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
 }
 
 func listPrometheusMetricMetadata(ctx context.Context, args ListPrometheusMetricMetadataParams) (map[string][]promv1.Metadata, error) {
-	promClient, err := promClientFromContext(ctx, args.DatasourceUID)
+	promClient, err := promClientFromContext(ctx, args.DatasourceUID, args.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("getting Prometheus client: %w", err)
 	}
@@ -105,7 +126,7 @@ func listPrometheusMetricMetadata(ctx context.Context, args ListPrometheusMetric
 
 var ListPrometheusMetricMetadata = mcpgrafana.MustTool(
 	"list_prometheus_metric_metadata",
-	"List Prometheus metric metadata. Returns metadata about metrics currently scraped from targets. Note: This endpoint is experimental.",
+	"List Prometheus metric metadata. Returns the type (counter, gauge, histogram, summary), help text and unit of metrics currently scraped from targets, keyed by metric name. Use this to learn how to query a metric, e.g. whether it needs rate(). Optionally filter to a single metric; otherwise metadata for all metrics is returned up to the limit. Note: This endpoint is experimental.",
 	listPrometheusMetricMetadata,
 	mcp.WithTitleAnnotation("List Prometheus metric metadata"),
 	mcp.WithIdempotentHintAnnotation(true),
@@ -113,26 +134,108 @@ var ListPrometheusMetricMetadata = mcpgrafana.MustTool(
 )
 
 type QueryPrometheusParams struct {
-	DatasourceUID string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
-	Expr          string `json:"expr" jsonschema:"required,description=The PromQL expression to query"`
-	StartTime     string `json:"startTime" jsonschema:"required,description=The start time. Supported formats are RFC3339 or relative to now (e.g. 'now'\\, 'now-1.5h'\\, 'now-2h45m'). Valid time units are 'ns'\\, 'us' (or 'µs')\\, 'ms'\\, 's'\\, 'm'\\, 'h'\\, 'd'."`
-	EndTime       string `json:"endTime,omitempty" jsonschema:"description=The end time. Required if queryType is 'range'\\, ignored if queryType is 'instant' Supported formats are RFC3339 or relative to now (e.g. 'now'\\, 'now-1.5h'\\, 'now-2h45m'). Valid time units are 'ns'\\, 'us' (or 'µs')\\, 'ms'\\, 's'\\, 'm'\\, 'h'\\, 'd'."`
-	StepSeconds   int    `json:"stepSeconds,omitempty" jsonschema:"description=The time series step size in seconds. Required if queryType is 'range'\\, ignored if queryType is 'instant'"`
-	QueryType     string `json:"queryType,omitempty" jsonschema:"description=The type of query to use. Either 'range' or 'instant'"`
+	DatasourceUID    string   `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
+	Expr             string   `json:"expr,omitempty" jsonschema:"description=The PromQL expression to query. Either expr or queries must be provided"`
+	Queries          []string `json:"queries,omitempty" jsonschema:"description=Several PromQL expressions to run in one call with the same time range and step. Mutually exclusive with expr. The result maps each expression to its result or error"`
+	StartTime        string   `json:"startTime,omitempty" jsonschema:"description=The start time of a range query\\, or the time of an instant query. Supported formats are RFC3339\\, Unix seconds or relative to now (e.g. 'now'\\, 'now-90m'\\, 'now-2h45m'). Valid time units are 'ns'\\, 'us' (or 'µs')\\, 'ms'\\, 's'\\, 'm'\\, 'h'\\, 'd'. Defaults to the default query range (1 hour unless configured) before the end time for range queries\\, and to now for instant queries"`
+	EndTime          string   `json:"endTime,omitempty" jsonschema:"description=The end time of a range query\\, ignored if queryType is 'instant'. Supported formats are RFC3339\\, Unix seconds or relative to now (e.g. 'now'\\, 'now-90m'\\, 'now-2h45m'). Valid time units are 'ns'\\, 'us' (or 'µs')\\, 'ms'\\, 's'\\, 'm'\\, 'h'\\, 'd'. Defaults to now"`
+	StepSeconds      int      `json:"stepSeconds,omitempty" jsonschema:"description=The time series step size in seconds. Takes precedence over 'step'. Ignored if queryType is 'instant'"`
+	Step             string   `json:"step,omitempty" jsonschema:"description=The time series step size as a duration (e.g. '15s'\\, '5m'\\, '1h') or 'auto'. If omitted or 'auto' and stepSeconds is not set\\, a step is chosen that yields roughly 1000 points over the time range. Ignored if queryType is 'instant'"`
+	QueryType        string   `json:"queryType,omitempty" jsonschema:"description=The type of query to use. Either 'range' or 'instant'"`
+	Format           string   `json:"format,omitempty" jsonschema:"description=The output format. Either 'json' (default) or 'table'. 'table' renders a compact text table with one column per label and a value column sorted by value descending; for range queries the latest value of each series is shown"`
+	MaxRows          int      `json:"maxRows,omitempty" jsonschema:"description=The maximum number of rows to include when format is 'table'. Defaults to 50"`
+	MaxResultBytes   int      `json:"maxResultBytes,omitempty" jsonschema:"description=Range query results whose JSON is larger than this many bytes are returned as a per-series summary (min\\, max\\, avg and point count) instead of every point. Defaults to 196608 (192KiB)"`
+	MaxPoints        int      `json:"maxPoints,omitempty" jsonschema:"description=Optionally\\, the maximum number of points to return per series of a range query. Longer series are downsampled with the LTTB algorithm\\, which keeps the shape of the series (peaks\\, dips and trends)\\, and the result notes the reduction. At least 3. Unlimited by default"`
+	TimeoutSeconds   int      `json:"timeoutSeconds,omitempty" jsonschema:"description=Optionally\\, a timeout in seconds for this query overriding the default client timeout. Useful for expensive range queries over long windows. Capped at a server configured maximum (120s by default)"`
+	IncludeExemplars bool     `json:"includeExemplars,omitempty" jsonschema:"description=If true\\, also fetch exemplars over the same time range and include their trace IDs keyed by series. Useful for linking latency histograms to traces. Only supported for range queries"`
+	TenantID         string   `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Mimir or Cortex\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
+}
+
+// defaultMaxPrometheusResultBytes is the size above which range query
+// results are summarized when maxResultBytes isn't set. It leaves room below
+// mcpgrafana.DefaultMaxResponseBytes for the rest of the tool result.
+const defaultMaxPrometheusResultBytes = 192 * 1024
+
+// minPrometheusMaxPoints is the smallest maxPoints accepted: downsampling
+// always keeps the first and last points and at least one in between.
+const minPrometheusMaxPoints = 3
+
+// maxConcurrentPrometheusQueries bounds how many expressions given via
+// queries are run against Prometheus at the same time.
+const maxConcurrentPrometheusQueries = 4
+
+// defaultTableMaxRows is the number of rows rendered by the table output
+// format when maxRows isn't set.
+const defaultTableMaxRows = 50
+
+// autoStepTargetPoints is the approximate number of points per series that
+// an automatically calculated step aims for.
+const autoStepTargetPoints = 1000
+
+// autoStepCandidates are the "clean" step durations an automatically
+// calculated step is rounded up to.
+var autoStepCandidates = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	15 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+}
+
+// calculateAutoStep returns a step for the given time range that yields at
+// most roughly autoStepTargetPoints points, rounded up to a clean duration.
+func calculateAutoStep(start, end time.Time) time.Duration {
+	raw := end.Sub(start) / autoStepTargetPoints
+	for _, candidate := range autoStepCandidates {
+		if raw <= candidate {
+			return candidate
+		}
+	}
+	// Beyond a day, round up to a whole number of days.
+	day := 24 * time.Hour
+	return ((raw + day - 1) / day) * day
 }
 
-func parseTime(timeStr string) (time.Time, error) {
-	tr := gtime.TimeRange{
-		From: timeStr,
-		Now:  time.Now(),
+// resolveStep determines the step to use for a range query.
+func resolveStep(args QueryPrometheusParams, start, end time.Time) (time.Duration, error) {
+	if args.StepSeconds > 0 {
+		return time.Duration(args.StepSeconds) * time.Second, nil
+	}
+	if args.StepSeconds < 0 {
+		return 0, fmt.Errorf("stepSeconds must be positive")
+	}
+	step := strings.TrimSpace(args.Step)
+	if step == "" || strings.EqualFold(step, "auto") {
+		return calculateAutoStep(start, end), nil
 	}
-	return tr.ParseFrom()
+	d, err := model.ParseDuration(step)
+	if err != nil {
+		return 0, fmt.Errorf("parsing step %q: %w", args.Step, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("step must be positive")
+	}
+	return time.Duration(d), nil
 }
 
-func queryPrometheus(ctx context.Context, args QueryPrometheusParams) (model.Value, error) {
-	promClient, err := promClientFromContext(ctx, args.DatasourceUID)
+// executePrometheusQuery runs the query described by args and returns the
+// result along with the step used (zero for instant queries).
+func executePrometheusQuery(ctx context.Context, args QueryPrometheusParams) (model.Value, time.Duration, error) {
+	promClient, err := promClientFromContext(ctx, args.DatasourceUID, args.TenantID)
 	if err != nil {
-		return nil, fmt.Errorf("getting Prometheus client: %w", err)
+		return nil, 0, fmt.Errorf("getting Prometheus client: %w", err)
 	}
 
 	queryType := args.QueryType
@@ -140,52 +243,611 @@ func queryPrometheus(ctx context.Context, args QueryPrometheusParams) (model.Val
 		queryType = "range"
 	}
 
-	var startTime time.Time
-	startTime, err = parseTime(args.StartTime)
-	if err != nil {
-		return nil, fmt.Errorf("parsing start time: %w", err)
-	}
-
 	switch queryType {
 	case "range":
-		if args.StepSeconds == 0 {
-			return nil, fmt.Errorf("stepSeconds must be provided when queryType is 'range'")
+		startTime, endTime, err := defaultTimeRange(ctx, args.StartTime, args.EndTime)
+		if err != nil {
+			return nil, 0, err
 		}
 
-		var endTime time.Time
-		endTime, err = parseTime(args.EndTime)
+		step, err := resolveStep(args, startTime, endTime)
 		if err != nil {
-			return nil, fmt.Errorf("parsing end time: %w", err)
+			return nil, 0, err
 		}
-
-		step := time.Duration(args.StepSeconds) * time.Second
 		result, _, err := promClient.QueryRange(ctx, args.Expr, promv1.Range{
 			Start: startTime,
 			End:   endTime,
 			Step:  step,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("querying Prometheus range: %w", err)
+			return nil, 0, fmt.Errorf("querying Prometheus range: %w", err)
 		}
-		return result, nil
+		return result, step, nil
 	case "instant":
-		result, _, err := promClient.Query(ctx, args.Expr, startTime)
+		// The start time is the time of an instant query.
+		queryTime, err := timeOrDefault(args.StartTime, time.Now())
+		if err != nil {
+			return nil, 0, fmt.Errorf("parsing start time: %w", err)
+		}
+		result, _, err := promClient.Query(ctx, args.Expr, queryTime)
+		if err != nil {
+			return nil, 0, fmt.Errorf("querying Prometheus instant: %w", err)
+		}
+		return result, 0, nil
+	}
+
+	return nil, 0, fmt.Errorf("invalid query type: %s", queryType)
+}
+
+func queryPrometheus(ctx context.Context, args QueryPrometheusParams) (*mcp.CallToolResult, error) {
+	format := strings.ToLower(args.Format)
+	if format != "" && format != "json" && format != "table" {
+		return nil, fmt.Errorf("invalid format %q: must be 'json' or 'table'", args.Format)
+	}
+	if args.MaxRows < 0 {
+		return nil, fmt.Errorf("maxRows must be positive")
+	}
+	if args.MaxResultBytes < 0 {
+		return nil, fmt.Errorf("maxResultBytes must be positive")
+	}
+	if args.MaxPoints < 0 || args.MaxPoints > 0 && args.MaxPoints < minPrometheusMaxPoints {
+		return nil, fmt.Errorf("maxPoints must be at least %d", minPrometheusMaxPoints)
+	}
+
+	ctx, cancel, timeout, err := withToolTimeout(ctx, args.TimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	if args.Expr != "" && len(args.Queries) > 0 {
+		return nil, fmt.Errorf("expr and queries are mutually exclusive")
+	}
+	if args.Expr == "" && len(args.Queries) == 0 {
+		return nil, fmt.Errorf("either expr or queries must be provided")
+	}
+	if args.IncludeExemplars && len(args.Queries) > 0 {
+		return nil, fmt.Errorf("includeExemplars is not supported with queries")
+	}
+	for _, expr := range append([]string{args.Expr}, args.Queries...) {
+		if err := validatePromQL(expr); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args.Queries) > 0 {
+		return queryPrometheusMany(ctx, args, format, timeout)
+	}
+
+	if args.IncludeExemplars && args.QueryType == "instant" {
+		return nil, fmt.Errorf("includeExemplars is only supported for range queries")
+	}
+
+	result, step, err := executePrometheusQuery(ctx, args)
+	if err != nil {
+		return nil, wrapTimeoutError(ctx, "query_prometheus", timeout, err)
+	}
+
+	// Exemplars are only fetched when asked for, since it costs an extra
+	// query.
+	var exemplars map[string][]prometheusExemplar
+	if args.IncludeExemplars {
+		exemplars, err = queryPrometheusExemplars(ctx, args)
+		if err != nil {
+			return nil, wrapTimeoutError(ctx, "query_prometheus", timeout, err)
+		}
+	}
+
+	var text string
+	if format == "table" {
+		maxRows := args.MaxRows
+		if maxRows == 0 {
+			maxRows = defaultTableMaxRows
+		}
+		text = formatPrometheusTable(result, maxRows)
+		if args.IncludeExemplars {
+			text += formatPrometheusExemplars(exemplars)
+		}
+	} else {
+		v, err := prometheusResultForOutput(result, args.MaxResultBytes, args.MaxPoints)
+		if err != nil {
+			return nil, err
+		}
+		if args.IncludeExemplars {
+			v = prometheusResultWithExemplars{Result: v, Exemplars: exemplars}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling Prometheus result: %w", err)
+		}
+		text = string(b)
+	}
+	res := mcp.NewToolResultText(text)
+	if step > 0 {
+		// Surface the step so callers can see what was used, particularly
+		// when it was calculated automatically.
+		res.Meta = mcp.NewMetaFromMap(map[string]any{
+			"step":        model.Duration(step).String(),
+			"stepSeconds": step.Seconds(),
+		})
+	}
+	return res, nil
+}
+
+// prometheusQueryOutcome is the result of one expression run via queries.
+// Exactly one of Result and Error is set.
+type prometheusQueryOutcome struct {
+	Result model.Value `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// prometheusQueryOutput is how the outcome of one expression run via queries
+// is returned, with large results summarized.
+type prometheusQueryOutput struct {
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// queryPrometheusMany runs each of args.Queries concurrently, at most
+// maxConcurrentPrometheusQueries at a time. A failing expression is
+// reported in its outcome rather than failing the whole call.
+func queryPrometheusMany(ctx context.Context, args QueryPrometheusParams, format string, timeout time.Duration) (*mcp.CallToolResult, error) {
+	// Deduplicate, keeping the order the expressions were given in.
+	exprs := make([]string, 0, len(args.Queries))
+	seen := make(map[string]bool, len(args.Queries))
+	for _, expr := range args.Queries {
+		if expr == "" {
+			return nil, fmt.Errorf("queries must not contain empty expressions")
+		}
+		if !seen[expr] {
+			seen[expr] = true
+			exprs = append(exprs, expr)
+		}
+	}
+
+	outcomes := make([]prometheusQueryOutcome, len(exprs))
+	steps := make([]time.Duration, len(exprs))
+	sem := make(chan struct{}, maxConcurrentPrometheusQueries)
+	var wg sync.WaitGroup
+	for i, expr := range exprs {
+		wg.Add(1)
+		go func(i int, expr string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			queryArgs := args
+			queryArgs.Expr = expr
+			queryArgs.Queries = nil
+			result, step, err := executePrometheusQuery(ctx, queryArgs)
+			if err != nil {
+				outcomes[i].Error = wrapTimeoutError(ctx, "query_prometheus", timeout, err).Error()
+				return
+			}
+			outcomes[i].Result = result
+			steps[i] = step
+		}(i, expr)
+	}
+	wg.Wait()
+
+	var text string
+	if format == "table" {
+		maxRows := args.MaxRows
+		if maxRows == 0 {
+			maxRows = defaultTableMaxRows
+		}
+		var sb strings.Builder
+		for i, expr := range exprs {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			fmt.Fprintf(&sb, "# %s\n", expr)
+			if outcomes[i].Error != "" {
+				fmt.Fprintf(&sb, "error: %s\n", outcomes[i].Error)
+				continue
+			}
+			sb.WriteString(formatPrometheusTable(outcomes[i].Result, maxRows))
+		}
+		text = sb.String()
+	} else {
+		byExpr := make(map[string]prometheusQueryOutput, len(exprs))
+		for i, expr := range exprs {
+			if outcomes[i].Error != "" {
+				byExpr[expr] = prometheusQueryOutput{Error: outcomes[i].Error}
+				continue
+			}
+			v, err := prometheusResultForOutput(outcomes[i].Result, args.MaxResultBytes, args.MaxPoints)
+			if err != nil {
+				return nil, err
+			}
+			byExpr[expr] = prometheusQueryOutput{Result: v}
+		}
+		b, err := json.Marshal(byExpr)
 		if err != nil {
-			return nil, fmt.Errorf("querying Prometheus instant: %w", err)
+			return nil, fmt.Errorf("marshaling Prometheus results: %w", err)
+		}
+		text = string(b)
+	}
+
+	res := mcp.NewToolResultText(text)
+	// Every expression shares the same time range and step arguments, so
+	// any successful range query reports the step used for all of them.
+	for _, step := range steps {
+		if step > 0 {
+			res.Meta = mcp.NewMetaFromMap(map[string]any{
+				"step":        model.Duration(step).String(),
+				"stepSeconds": step.Seconds(),
+			})
+			break
 		}
+	}
+	return res, nil
+}
+
+// prometheusMatrixSummary stands in for a range query result too large to
+// return in full.
+type prometheusMatrixSummary struct {
+	ResultType string                    `json:"resultType"`
+	Summarized bool                      `json:"summarized"`
+	Note       string                    `json:"note"`
+	Series     []prometheusSeriesSummary `json:"series"`
+}
+
+// prometheusSeriesSummary summarizes the points of one series.
+type prometheusSeriesSummary struct {
+	Metric model.Metric      `json:"metric"`
+	Points int               `json:"points"`
+	Start  model.Time        `json:"start"`
+	End    model.Time        `json:"end"`
+	Min    model.SampleValue `json:"min"`
+	Max    model.SampleValue `json:"max"`
+	Avg    model.SampleValue `json:"avg"`
+}
+
+// prometheusDownsampledMatrix is a range query result with series
+// downsampled to at most maxPoints points. ReductionRatio is the number of
+// points in the original result per point returned.
+type prometheusDownsampledMatrix struct {
+	ResultType     string       `json:"resultType"`
+	Downsampled    bool         `json:"downsampled"`
+	Note           string       `json:"note"`
+	OriginalPoints int          `json:"originalPoints"`
+	Points         int          `json:"points"`
+	ReductionRatio float64      `json:"reductionRatio"`
+	Result         model.Matrix `json:"result"`
+}
+
+// prometheusResultForOutput returns result as is, unless it's a range query
+// result. Range query results with series longer than maxPoints are
+// downsampled, and results whose JSON is still larger than maxBytes are
+// replaced by a per-series summary. A maxBytes of 0 selects the default,
+// and a maxPoints of 0 disables downsampling.
+func prometheusResultForOutput(result model.Value, maxBytes, maxPoints int) (any, error) {
+	matrix, ok := result.(model.Matrix)
+	if !ok {
 		return result, nil
 	}
+	if maxBytes == 0 {
+		maxBytes = defaultMaxPrometheusResultBytes
+	}
+	output := matrix
+	originalPoints, points := 0, 0
+	if maxPoints > 0 {
+		output = make(model.Matrix, len(matrix))
+		for i, series := range matrix {
+			downsampled := *series
+			downsampled.Values = downsampleLTTB(series.Values, maxPoints)
+			output[i] = &downsampled
+			originalPoints += len(series.Values)
+			points += len(downsampled.Values)
+		}
+	}
+	b, err := json.Marshal(output)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling Prometheus result: %w", err)
+	}
+	if len(b) > maxBytes {
+		// Summarize the original points, so that the summary is exact.
+		return summarizePrometheusMatrix(matrix, len(b), maxBytes), nil
+	}
+	if points == originalPoints {
+		return matrix, nil
+	}
+	ratio := math.Round(float64(originalPoints)/float64(points)*100) / 100
+	return prometheusDownsampledMatrix{
+		ResultType:     model.ValMatrix.String(),
+		Downsampled:    true,
+		Note:           fmt.Sprintf("Series longer than maxPoints (%d) are downsampled with LTTB, which keeps their shape but not every point: %d points were reduced to %d (%.2fx). Narrow the time range or raise maxPoints to get every point.", maxPoints, originalPoints, points, ratio),
+		OriginalPoints: originalPoints,
+		Points:         points,
+		ReductionRatio: ratio,
+		Result:         output,
+	}, nil
+}
+
+// downsampleLTTB reduces points to at most threshold points with the
+// Largest-Triangle-Three-Buckets algorithm, which keeps the first and last
+// points and, from each bucket in between, the point forming the largest
+// triangle with the previously kept point and the average of the next
+// bucket. Series of at most threshold points, or thresholds below 3, are
+// returned unchanged.
+func downsampleLTTB(points []model.SamplePair, threshold int) []model.SamplePair {
+	if threshold < minPrometheusMaxPoints || len(points) <= threshold {
+		return points
+	}
+
+	sampled := make([]model.SamplePair, 0, threshold)
+	sampled = append(sampled, points[0])
+	every := float64(len(points)-2) / float64(threshold-2)
+	a := 0
+	for i := 0; i < threshold-2; i++ {
+		// The average of the next bucket is the third point of the triangle.
+		avgStart := int(float64(i+1)*every) + 1
+		avgEnd := min(int(float64(i+2)*every)+1, len(points))
+		var avgX, avgY float64
+		for _, p := range points[avgStart:avgEnd] {
+			avgX += float64(p.Timestamp)
+			avgY += float64(p.Value)
+		}
+		n := float64(avgEnd - avgStart)
+		avgX /= n
+		avgY /= n
+
+		// Areas involving NaN values are never the largest, so if none can
+		// be computed the first point of the bucket is kept.
+		rangeStart := int(float64(i)*every) + 1
+		rangeEnd := int(float64(i+1)*every) + 1
+		ax, ay := float64(points[a].Timestamp), float64(points[a].Value)
+		next, maxArea := rangeStart, -1.0
+		for j := rangeStart; j < rangeEnd; j++ {
+			area := math.Abs((ax-avgX)*(float64(points[j].Value)-ay) - (ax-float64(points[j].Timestamp))*(avgY-ay))
+			if area > maxArea {
+				next, maxArea = j, area
+			}
+		}
+		sampled = append(sampled, points[next])
+		a = next
+	}
+	return append(sampled, points[len(points)-1])
+}
+
+// summarizePrometheusMatrix reduces each series of a range query result to
+// its point count, time range, and the min, max and average of its float
+// values.
+func summarizePrometheusMatrix(matrix model.Matrix, size, maxBytes int) prometheusMatrixSummary {
+	summary := prometheusMatrixSummary{
+		ResultType: model.ValMatrix.String(),
+		Summarized: true,
+		Note:       fmt.Sprintf("The result is %d bytes, more than maxResultBytes (%d), so each series is summarized instead of returning every point. Narrow the time range, increase the step or aggregate the query to get the points.", size, maxBytes),
+		Series:     make([]prometheusSeriesSummary, 0, len(matrix)),
+	}
+	for _, series := range matrix {
+		s := prometheusSeriesSummary{
+			Metric: series.Metric,
+			Points: len(series.Values) + len(series.Histograms),
+			Min:    model.SampleValue(math.NaN()),
+			Max:    model.SampleValue(math.NaN()),
+			Avg:    model.SampleValue(math.NaN()),
+		}
+		if len(series.Values) > 0 {
+			s.Start = series.Values[0].Timestamp
+			s.End = series.Values[len(series.Values)-1].Timestamp
+		}
+		for _, h := range series.Histograms {
+			if s.Start == 0 || h.Timestamp < s.Start {
+				s.Start = h.Timestamp
+			}
+			if h.Timestamp > s.End {
+				s.End = h.Timestamp
+			}
+		}
+
+		var sum float64
+		var count int
+		for _, p := range series.Values {
+			v := float64(p.Value)
+			if math.IsNaN(v) {
+				continue
+			}
+			if count == 0 || v < float64(s.Min) {
+				s.Min = p.Value
+			}
+			if count == 0 || v > float64(s.Max) {
+				s.Max = p.Value
+			}
+			sum += v
+			count++
+		}
+		if count > 0 {
+			s.Avg = model.SampleValue(sum / float64(count))
+		}
+		summary.Series = append(summary.Series, s)
+	}
+	return summary
+}
+
+// prometheusExemplar is an exemplar attached to a series, with the trace ID
+// pulled out of its labels.
+type prometheusExemplar struct {
+	TraceID   string            `json:"traceId,omitempty"`
+	Value     model.SampleValue `json:"value"`
+	Timestamp time.Time         `json:"timestamp"`
+	// Labels holds any exemplar labels other than the trace ID.
+	Labels model.LabelSet `json:"labels,omitempty"`
+}
+
+// prometheusResultWithExemplars is the JSON output of query_prometheus when
+// exemplars are requested.
+type prometheusResultWithExemplars struct {
+	// Result is either the query result or its summary.
+	Result any `json:"result"`
+	// Exemplars are keyed by the labels of the series they belong to, which
+	// for histograms are the bucket series rather than the query result.
+	Exemplars map[string][]prometheusExemplar `json:"exemplars"`
+}
+
+// exemplarTraceIDLabels are the exemplar label names commonly used to hold a
+// trace ID, in order of preference.
+var exemplarTraceIDLabels = []model.LabelName{"trace_id", "traceID", "traceId", "TraceID"}
+
+// queryPrometheusExemplars fetches the exemplars of the query over the range
+// of the query, keyed by series.
+func queryPrometheusExemplars(ctx context.Context, args QueryPrometheusParams) (map[string][]prometheusExemplar, error) {
+	promClient, err := promClientFromContext(ctx, args.DatasourceUID, args.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("getting Prometheus client: %w", err)
+	}
+	startTime, endTime, err := defaultTimeRange(ctx, args.StartTime, args.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := promClient.QueryExemplars(ctx, args.Expr, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("querying Prometheus exemplars: %w", err)
+	}
+	exemplars := make(map[string][]prometheusExemplar, len(results))
+	for _, r := range results {
+		series := r.SeriesLabels.String()
+		for _, e := range r.Exemplars {
+			labels := e.Labels.Clone()
+			exemplar := prometheusExemplar{
+				Value:     e.Value,
+				Timestamp: e.Timestamp.Time().UTC(),
+			}
+			for _, name := range exemplarTraceIDLabels {
+				if traceID, ok := labels[name]; ok {
+					exemplar.TraceID = string(traceID)
+					delete(labels, name)
+					break
+				}
+			}
+			if len(labels) > 0 {
+				exemplar.Labels = labels
+			}
+			exemplars[series] = append(exemplars[series], exemplar)
+		}
+	}
+	return exemplars, nil
+}
+
+// formatPrometheusExemplars renders exemplars as a text table to follow a
+// table formatted query result.
+func formatPrometheusExemplars(exemplars map[string][]prometheusExemplar) string {
+	if len(exemplars) == 0 {
+		return "\nno exemplars\n"
+	}
+	series := make([]string, 0, len(exemplars))
+	for s := range exemplars {
+		series = append(series, s)
+	}
+	sort.Strings(series)
+
+	var b strings.Builder
+	b.WriteString("\nexemplars:\n")
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "series\ttrace_id\tvalue\ttimestamp")
+	for _, s := range series {
+		for _, e := range exemplars[s] {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s, e.TraceID, e.Value, e.Timestamp.Format(time.RFC3339))
+		}
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+// tableRow is a single row of a Prometheus result rendered as a table.
+type tableRow struct {
+	labels model.Metric
+	value  model.SampleValue
+}
+
+// formatPrometheusTable renders a Prometheus result as a text table with one
+// column per label name and a trailing value column, sorted by value
+// descending and truncated to maxRows rows. Matrix results are rendered
+// using the latest sample of each series.
+func formatPrometheusTable(result model.Value, maxRows int) string {
+	var rows []tableRow
+	switch v := result.(type) {
+	case model.Vector:
+		for _, sample := range v {
+			rows = append(rows, tableRow{labels: sample.Metric, value: sample.Value})
+		}
+	case model.Matrix:
+		for _, stream := range v {
+			if len(stream.Values) == 0 {
+				continue
+			}
+			rows = append(rows, tableRow{labels: stream.Metric, value: stream.Values[len(stream.Values)-1].Value})
+		}
+	case *model.Scalar:
+		rows = append(rows, tableRow{value: v.Value})
+	case *model.String:
+		return fmt.Sprintf("value\n%s\n", v.Value)
+	}
+
+	if len(rows) == 0 {
+		return "no data\n"
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := float64(rows[i].value), float64(rows[j].value)
+		// Sort NaN values last.
+		if math.IsNaN(a) || math.IsNaN(b) {
+			return !math.IsNaN(a) && math.IsNaN(b)
+		}
+		return a > b
+	})
+
+	nameSet := map[model.LabelName]struct{}{}
+	for _, row := range rows {
+		for name := range row.labels {
+			nameSet[name] = struct{}{}
+		}
+	}
+	names := make([]model.LabelName, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
 
-	return nil, fmt.Errorf("invalid query type: %s", queryType)
+	total := len(rows)
+	if total > maxRows {
+		rows = rows[:maxRows]
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t", name)
+	}
+	fmt.Fprintln(w, "value")
+	for _, row := range rows {
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t", row.labels[name])
+		}
+		fmt.Fprintln(w, row.value.String())
+	}
+	_ = w.Flush()
+
+	if total > len(rows) {
+		fmt.Fprintf(&sb, "(truncated: showing %d of %d rows)\n", len(rows), total)
+	}
+	return sb.String()
 }
 
 var QueryPrometheus = mcpgrafana.MustTool(
 	"query_prometheus",
-	"Query Prometheus using a PromQL expression. Supports both instant queries (at a single point in time) and range queries (over a time range). Time can be specified either in RFC3339 format or as relative time expressions like 'now', 'now-1h', 'now-30m', etc.",
+	"Query Prometheus using a PromQL expression. Supports both instant queries (at a single point in time) and range queries (over a time range). Time can be specified either in RFC3339 format or as relative time expressions like 'now', 'now-1h', 'now-30m', etc. For range queries the step is calculated automatically if not provided; the step used is returned in the result metadata. Set format to 'table' for a compact text table of the series when many series are returned. Set includeExemplars on range queries to also return exemplar trace IDs keyed by series, which can be looked up in a tracing datasource. Range query results larger than maxResultBytes are summarized per series (min, max, avg and point count) rather than returned in full, which is noted in the result. Set maxPoints to downsample long range query series to at most that many points while keeping their shape; the reduction is noted in the result. To run several expressions over the same time range in one call, pass them in queries instead of expr; the result maps each expression to its result or error.",
 	queryPrometheus,
 	mcp.WithTitleAnnotation("Query Prometheus metrics"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
+	mcpgrafana.WithExamples(
+		map[string]any{"datasourceUid": "prometheus", "expr": `sum by (job) (rate(http_requests_total{code=~"5.."}[5m]))`, "startTime": "now-6h", "endTime": "now"},
+		map[string]any{"datasourceUid": "prometheus", "expr": `up{job="api"}`, "queryType": "instant", "format": "table"},
+		map[string]any{"datasourceUid": "prometheus", "queries": []string{`sum(rate(http_requests_total[5m]))`, `sum(rate(http_requests_total{code=~"5.."}[5m]))`}, "startTime": "now-1h", "step": "1m"},
+	),
 )
 
 type ListPrometheusMetricNamesParams struct {
@@ -193,10 +855,11 @@ type ListPrometheusMetricNamesParams struct {
 	Regex         string `json:"regex" jsonschema:"description=The regex to match against the metric names"`
 	Limit         int    `json:"limit,omitempty" jsonschema:"default=10,description=The maximum number of results to return"`
 	Page          int    `json:"page,omitempty" jsonschema:"default=1,description=The page number to return"`
+	TenantID      string `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Mimir or Cortex\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
 }
 
 func listPrometheusMetricNames(ctx context.Context, args ListPrometheusMetricNamesParams) ([]string, error) {
-	promClient, err := promClientFromContext(ctx, args.DatasourceUID)
+	promClient, err := promClientFromContext(ctx, args.DatasourceUID, args.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("getting Prometheus client: %w", err)
 	}
@@ -308,13 +971,18 @@ func (s Selector) Matches(lbls labels.Labels) (bool, error) {
 type ListPrometheusLabelNamesParams struct {
 	DatasourceUID string     `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
 	Matches       []Selector `json:"matches,omitempty" jsonschema:"description=Optionally\\, a list of label matchers to filter the results by"`
-	StartRFC3339  string     `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the time range to filter the results by"`
-	EndRFC3339    string     `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the time range to filter the results by"`
+	StartRFC3339  string     `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the time range to filter the results by. Supported formats are RFC3339\\, Unix seconds or relative to now (e.g. 'now-1h')"`
+	EndRFC3339    string     `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the time range to filter the results by. Supported formats are RFC3339\\, Unix seconds or relative to now (e.g. 'now')"`
 	Limit         int        `json:"limit,omitempty" jsonschema:"default=100,description=Optionally\\, the maximum number of results to return"`
+	TenantID      string     `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Mimir or Cortex\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
 }
 
 func listPrometheusLabelNames(ctx context.Context, args ListPrometheusLabelNamesParams) ([]string, error) {
-	promClient, err := promClientFromContext(ctx, args.DatasourceUID)
+	if err := validateSelectors(args.Matches); err != nil {
+		return nil, err
+	}
+
+	promClient, err := promClientFromContext(ctx, args.DatasourceUID, args.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("getting Prometheus client: %w", err)
 	}
@@ -326,12 +994,12 @@ func listPrometheusLabelNames(ctx context.Context, args ListPrometheusLabelNames
 
 	var startTime, endTime time.Time
 	if args.StartRFC3339 != "" {
-		if startTime, err = time.Parse(time.RFC3339, args.StartRFC3339); err != nil {
+		if startTime, err = parseTime(args.StartRFC3339); err != nil {
 			return nil, fmt.Errorf("parsing start time: %w", err)
 		}
 	}
 	if args.EndRFC3339 != "" {
-		if endTime, err = time.Parse(time.RFC3339, args.EndRFC3339); err != nil {
+		if endTime, err = parseTime(args.EndRFC3339); err != nil {
 			return nil, fmt.Errorf("parsing end time: %w", err)
 		}
 	}
@@ -345,6 +1013,9 @@ func listPrometheusLabelNames(ctx context.Context, args ListPrometheusLabelNames
 	if err != nil {
 		return nil, fmt.Errorf("listing Prometheus label names: %w", err)
 	}
+	if labelNames == nil {
+		labelNames = []string{}
+	}
 
 	// Apply limit
 	if len(labelNames) > limit {
@@ -367,13 +1038,18 @@ type ListPrometheusLabelValuesParams struct {
 	DatasourceUID string     `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
 	LabelName     string     `json:"labelName" jsonschema:"required,description=The name of the label to query"`
 	Matches       []Selector `json:"matches,omitempty" jsonschema:"description=Optionally\\, a list of selectors to filter the results by"`
-	StartRFC3339  string     `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query"`
-	EndRFC3339    string     `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query"`
+	StartRFC3339  string     `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query. Supported formats are RFC3339\\, Unix seconds or relative to now (e.g. 'now-1h')"`
+	EndRFC3339    string     `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query. Supported formats are RFC3339\\, Unix seconds or relative to now (e.g. 'now')"`
 	Limit         int        `json:"limit,omitempty" jsonschema:"default=100,description=Optionally\\, the maximum number of results to return"`
+	TenantID      string     `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Mimir or Cortex\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
 }
 
 func listPrometheusLabelValues(ctx context.Context, args ListPrometheusLabelValuesParams) (model.LabelValues, error) {
-	promClient, err := promClientFromContext(ctx, args.DatasourceUID)
+	if err := validateSelectors(args.Matches); err != nil {
+		return nil, err
+	}
+
+	promClient, err := promClientFromContext(ctx, args.DatasourceUID, args.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("getting Prometheus client: %w", err)
 	}
@@ -385,12 +1061,12 @@ func listPrometheusLabelValues(ctx context.Context, args ListPrometheusLabelValu
 
 	var startTime, endTime time.Time
 	if args.StartRFC3339 != "" {
-		if startTime, err = time.Parse(time.RFC3339, args.StartRFC3339); err != nil {
+		if startTime, err = parseTime(args.StartRFC3339); err != nil {
 			return nil, fmt.Errorf("parsing start time: %w", err)
 		}
 	}
 	if args.EndRFC3339 != "" {
-		if endTime, err = time.Parse(time.RFC3339, args.EndRFC3339); err != nil {
+		if endTime, err = parseTime(args.EndRFC3339); err != nil {
 			return nil, fmt.Errorf("parsing end time: %w", err)
 		}
 	}
@@ -422,10 +1098,194 @@ var ListPrometheusLabelValues = mcpgrafana.MustTool(
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+// defaultPrometheusSeriesLimit is the maximum number of series
+// find_prometheus_series returns by default.
+const defaultPrometheusSeriesLimit = 100
+
+type FindPrometheusSeriesParams struct {
+	DatasourceUID string     `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
+	Matches       []Selector `json:"matches" jsonschema:"required,description=One or more selectors. Series matching any of them are returned"`
+	StartRFC3339  string     `json:"startRfc3339,omitempty" jsonschema:"description=Optionally\\, the start time of the time range as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndRFC3339    string     `json:"endRfc3339,omitempty" jsonschema:"description=Optionally\\, the end time of the time range as RFC3339\\, Unix seconds or relative to now. Defaults to now"`
+	Limit         int        `json:"limit,omitempty" jsonschema:"default=100,description=Optionally\\, the maximum number of series to return"`
+	TenantID      string     `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Mimir or Cortex\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
+}
+
+// PrometheusSeriesList is a page of the series matching a set of selectors.
+type PrometheusSeriesList struct {
+	// Series are the label sets of the matching series, up to the limit.
+	Series []model.LabelSet `json:"series"`
+	// Total is the number of matching series before truncation.
+	Total int `json:"total"`
+	// Truncated is true if only the first Limit series are returned.
+	Truncated bool `json:"truncated"`
+}
+
+func findPrometheusSeries(ctx context.Context, args FindPrometheusSeriesParams) (*PrometheusSeriesList, error) {
+	if len(args.Matches) == 0 {
+		return nil, fmt.Errorf("at least one selector must be provided in matches")
+	}
+	if err := validateSelectors(args.Matches); err != nil {
+		return nil, err
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultPrometheusSeriesLimit
+	}
+
+	startTime, endTime, err := defaultTimeRange(ctx, args.StartRFC3339, args.EndRFC3339)
+	if err != nil {
+		return nil, err
+	}
+
+	promClient, err := promClientFromContext(ctx, args.DatasourceUID, args.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("getting Prometheus client: %w", err)
+	}
+
+	matchers := make([]string, 0, len(args.Matches))
+	for _, m := range args.Matches {
+		matchers = append(matchers, m.String())
+	}
+	series, _, err := promClient.Series(ctx, matchers, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("finding Prometheus series: %w", err)
+	}
+
+	result := &PrometheusSeriesList{
+		Series: series,
+		Total:  len(series),
+	}
+	if result.Series == nil {
+		result.Series = []model.LabelSet{}
+	}
+	if len(series) > limit {
+		result.Series = series[:limit]
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+var FindPrometheusSeries = mcpgrafana.MustTool(
+	"find_prometheus_series",
+	"Find the series matching one or more selectors in a Prometheus datasource over a time range (default: the last hour), returning the full label set of each series. Use it to discover exactly which label combinations exist before writing an aggregation. Returns at most 'limit' series (default 100), along with the total number of matching series and whether the list was truncated.",
+	findPrometheusSeries,
+	mcp.WithTitleAnnotation("Find Prometheus series"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+type ExplainPrometheusQueryParams struct {
+	DatasourceUID string `json:"datasourceUid" jsonschema:"required,description=The UID of the datasource to query"`
+	Expr          string `json:"expr" jsonschema:"required,description=The PromQL expression to explain"`
+	Time          string `json:"time,omitempty" jsonschema:"description=The time to evaluate the expression at. Supported formats are RFC3339\\, Unix seconds or relative to now (e.g. 'now-1h'). Defaults to now."`
+	TenantID      string `json:"tenantId,omitempty" jsonschema:"description=Optionally\\, the tenant to query on a multi-tenant Mimir or Cortex\\, sent in the X-Scope-OrgID header. Defaults to GRAFANA_DEFAULT_TENANT_ID if set. If the client forwards an X-Scope-OrgID header its tenant is used and a different tenantId is rejected"`
+}
+
+// highCardinalitySeriesThreshold is the number of series above which
+// explain_prometheus_query warns that a query is expensive.
+const highCardinalitySeriesThreshold = 1000
+
+// PrometheusQueryExplanation describes the shape of a query's result without
+// the result itself.
+type PrometheusQueryExplanation struct {
+	ResultType  string `json:"resultType"`
+	SeriesCount int    `json:"seriesCount"`
+	// LabelNames are the distinct label names present across all series,
+	// sorted, with the number of distinct values of each in
+	// LabelCardinality.
+	LabelNames       []string       `json:"labelNames"`
+	LabelCardinality map[string]int `json:"labelCardinality,omitempty"`
+	Warning          string         `json:"warning,omitempty"`
+}
+
+func explainPrometheusQuery(ctx context.Context, args ExplainPrometheusQueryParams) (*PrometheusQueryExplanation, error) {
+	if err := validatePromQL(args.Expr); err != nil {
+		return nil, err
+	}
+	ts := time.Now()
+	if args.Time != "" {
+		var err error
+		if ts, err = parseTime(args.Time); err != nil {
+			return nil, fmt.Errorf("parsing time: %w", err)
+		}
+	}
+
+	promClient, err := promClientFromContext(ctx, args.DatasourceUID, args.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("getting Prometheus client: %w", err)
+	}
+	// An instant query only evaluates a single point, which is far cheaper
+	// than the range query the caller may be planning.
+	result, _, err := promClient.Query(ctx, args.Expr, ts)
+	if err != nil {
+		return nil, fmt.Errorf("querying Prometheus instant: %w", err)
+	}
+	return explainPrometheusResult(result), nil
+}
+
+func explainPrometheusResult(result model.Value) *PrometheusQueryExplanation {
+	var metrics []model.Metric
+	explanation := &PrometheusQueryExplanation{LabelNames: []string{}}
+	switch v := result.(type) {
+	case model.Vector:
+		explanation.ResultType = "vector"
+		for _, s := range v {
+			metrics = append(metrics, s.Metric)
+		}
+	case model.Matrix:
+		explanation.ResultType = "matrix"
+		for _, s := range v {
+			metrics = append(metrics, s.Metric)
+		}
+	case *model.Scalar:
+		explanation.ResultType = "scalar"
+		explanation.SeriesCount = 1
+	case *model.String:
+		explanation.ResultType = "string"
+		explanation.SeriesCount = 1
+	}
+	if metrics == nil {
+		return explanation
+	}
+
+	explanation.SeriesCount = len(metrics)
+	values := map[string]map[model.LabelValue]struct{}{}
+	for _, m := range metrics {
+		for name, value := range m {
+			if values[string(name)] == nil {
+				values[string(name)] = map[model.LabelValue]struct{}{}
+			}
+			values[string(name)][value] = struct{}{}
+		}
+	}
+	explanation.LabelCardinality = make(map[string]int, len(values))
+	for name, vs := range values {
+		explanation.LabelNames = append(explanation.LabelNames, name)
+		explanation.LabelCardinality[name] = len(vs)
+	}
+	sort.Strings(explanation.LabelNames)
+	if explanation.SeriesCount > highCardinalitySeriesThreshold {
+		explanation.Warning = fmt.Sprintf("the query matches %d series; aggregate it (e.g. with sum by (...)) or add label matchers before running it as a range query", explanation.SeriesCount)
+	}
+	return explanation
+}
+
+var ExplainPrometheusQuery = mcpgrafana.MustTool(
+	"explain_prometheus_query",
+	"Estimate the cost of a PromQL query before running it. Evaluates the expression as a cheap instant query and returns the result type, the number of series matched, and the distinct label names present with the number of distinct values of each, without returning the data. Use this before running a range query with query_prometheus to avoid high-cardinality queries which return huge results.",
+	explainPrometheusQuery,
+	mcp.WithTitleAnnotation("Explain Prometheus query"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
 func AddPrometheusTools(mcp *server.MCPServer) {
 	ListPrometheusMetricMetadata.Register(mcp)
 	QueryPrometheus.Register(mcp)
+	ExplainPrometheusQuery.Register(mcp)
 	ListPrometheusMetricNames.Register(mcp)
 	ListPrometheusLabelNames.Register(mcp)
 	ListPrometheusLabelValues.Register(mcp)
+	FindPrometheusSeries.Register(mcp)
 }
@@ -238,7 +238,7 @@ func TestPrometheusQueries(t *testing.T) {
 		for _, step := range []int{15, 60, 300} {
 			t.Run(fmt.Sprintf("step=%d", step), func(t *testing.T) {
 				ctx := newTestContext()
-				result, err := queryPrometheus(ctx, QueryPrometheusParams{
+				result, _, err := executePrometheusQuery(ctx, QueryPrometheusParams{
 					DatasourceUID: "prometheus",
 					Expr:          "test",
 					StartTime:     start.Format(time.RFC3339),
@@ -260,7 +260,7 @@ func TestPrometheusQueries(t *testing.T) {
 	t.Run("query prometheus instant", func(t *testing.T) {
 		ctx := newTestContext()
 		beforeQuery := model.TimeFromUnix(time.Now().Unix())
-		result, err := queryPrometheus(ctx, QueryPrometheusParams{
+		result, _, err := executePrometheusQuery(ctx, QueryPrometheusParams{
 			DatasourceUID: "prometheus",
 			Expr:          "up",
 			StartTime:     time.Now().Format(time.RFC3339),
@@ -280,7 +280,7 @@ func TestPrometheusQueries(t *testing.T) {
 	t.Run("query prometheus instant with relative timestamps", func(t *testing.T) {
 		ctx := newTestContext()
 		beforeQuery := model.TimeFromUnix(time.Now().Unix())
-		result, err := queryPrometheus(ctx, QueryPrometheusParams{
+		result, _, err := executePrometheusQuery(ctx, QueryPrometheusParams{
 			DatasourceUID: "prometheus",
 			Expr:          "up",
 			StartTime:     "now",
@@ -304,7 +304,7 @@ func TestPrometheusQueries(t *testing.T) {
 	t.Run("query prometheus range with relative timestamps", func(t *testing.T) {
 		ctx := newTestContext()
 		beforeQuery := model.TimeFromUnix(time.Now().Unix())
-		result, err := queryPrometheus(ctx, QueryPrometheusParams{
+		result, _, err := executePrometheusQuery(ctx, QueryPrometheusParams{
 			DatasourceUID: "prometheus",
 			Expr:          "test",
 			StartTime:     "now-1h",
@@ -1,9 +1,23 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	mcpgrafana "github.com/grafana/mcp-grafana"
+	"github.com/mark3labs/mcp-go/mcp"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -115,3 +129,888 @@ func TestParseRelativeTime(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateAutoStep(t *testing.T) {
+	end := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		span     time.Duration
+		expected time.Duration
+	}{
+		{name: "5m", span: 5 * time.Minute, expected: time.Second},
+		{name: "6h", span: 6 * time.Hour, expected: 30 * time.Second},
+		{name: "7d", span: 7 * 24 * time.Hour, expected: 15 * time.Minute},
+		{name: "90d", span: 90 * 24 * time.Hour, expected: 3 * time.Hour},
+		{name: "5y", span: 5 * 365 * 24 * time.Hour, expected: 2 * 24 * time.Hour},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			step := calculateAutoStep(end.Add(-tc.span), end)
+			assert.Equal(t, tc.expected, step)
+			assert.LessOrEqual(t, int(tc.span/step), autoStepTargetPoints)
+		})
+	}
+}
+
+func TestResolveStep(t *testing.T) {
+	end := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	start := end.Add(-6 * time.Hour)
+
+	t.Run("explicit stepSeconds is used unchanged", func(t *testing.T) {
+		step, err := resolveStep(QueryPrometheusParams{StepSeconds: 1, Step: "5m"}, start, end)
+		require.NoError(t, err)
+		assert.Equal(t, time.Second, step)
+	})
+
+	t.Run("explicit step duration", func(t *testing.T) {
+		step, err := resolveStep(QueryPrometheusParams{Step: "5m"}, start, end)
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, step)
+	})
+
+	t.Run("omitted step is calculated", func(t *testing.T) {
+		step, err := resolveStep(QueryPrometheusParams{}, start, end)
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, step)
+	})
+
+	t.Run("auto step is calculated", func(t *testing.T) {
+		step, err := resolveStep(QueryPrometheusParams{Step: "auto"}, start, end)
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, step)
+	})
+
+	t.Run("invalid step", func(t *testing.T) {
+		_, err := resolveStep(QueryPrometheusParams{Step: "soon"}, start, end)
+		require.Error(t, err)
+	})
+}
+
+func TestFormatPrometheusTable(t *testing.T) {
+	t.Run("vector sorted by value descending", func(t *testing.T) {
+		vector := model.Vector{
+			{Metric: model.Metric{"job": "api", "instance": "a:9090"}, Value: 1.5},
+			{Metric: model.Metric{"job": "api", "instance": "b:9090"}, Value: 42},
+			{Metric: model.Metric{"job": "db"}, Value: 7},
+		}
+		expected := "instance  job  value\n" +
+			"b:9090    api  42\n" +
+			"          db   7\n" +
+			"a:9090    api  1.5\n"
+		assert.Equal(t, expected, formatPrometheusTable(vector, 50))
+	})
+
+	t.Run("vector truncated to maxRows", func(t *testing.T) {
+		vector := model.Vector{
+			{Metric: model.Metric{"job": "a"}, Value: 1},
+			{Metric: model.Metric{"job": "b"}, Value: 3},
+			{Metric: model.Metric{"job": "c"}, Value: 2},
+		}
+		expected := "job  value\n" +
+			"b    3\n" +
+			"c    2\n" +
+			"(truncated: showing 2 of 3 rows)\n"
+		assert.Equal(t, expected, formatPrometheusTable(vector, 2))
+	})
+
+	t.Run("scalar", func(t *testing.T) {
+		scalar := &model.Scalar{Value: 3.14, Timestamp: 0}
+		assert.Equal(t, "value\n3.14\n", formatPrometheusTable(scalar, 50))
+	})
+
+	t.Run("matrix uses latest sample", func(t *testing.T) {
+		matrix := model.Matrix{
+			{Metric: model.Metric{"job": "api"}, Values: []model.SamplePair{{Timestamp: 1, Value: 10}, {Timestamp: 2, Value: 5}}},
+			{Metric: model.Metric{"job": "db"}, Values: []model.SamplePair{{Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 8}}},
+		}
+		expected := "job  value\n" +
+			"db   8\n" +
+			"api  5\n"
+		assert.Equal(t, expected, formatPrometheusTable(matrix, 50))
+	})
+
+	t.Run("empty result", func(t *testing.T) {
+		assert.Equal(t, "no data\n", formatPrometheusTable(model.Vector{}, 50))
+	})
+}
+
+func TestPrometheusQueriesUseDatasourceProxy(t *testing.T) {
+	var queryPath, authHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/uid/prom-uid" {
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+			return
+		}
+		queryPath = r.URL.Path
+		authHeader = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "vector", "result": []}}`))
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL, APIKey: "secret-token"}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, cfg.APIKey, nil, 0))
+
+	_, _, err := executePrometheusQuery(ctx, QueryPrometheusParams{
+		DatasourceUID: "prom-uid",
+		Expr:          "up",
+		StartTime:     "now",
+		QueryType:     "instant",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/api/datasources/proxy/uid/prom-uid/api/v1/query", queryPath)
+	assert.Equal(t, "Bearer secret-token", authHeader)
+}
+
+func TestQueryPrometheusTenantID(t *testing.T) {
+	var gotTenant string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/uid/prom-uid" {
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Mimir", "type": "prometheus"}`))
+			return
+		}
+		gotTenant = r.Header.Get("X-Scope-OrgID")
+		if strings.Contains(r.URL.Path, "/api/v1/label") {
+			_, _ = w.Write([]byte(`{"status": "success", "data": []}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "vector", "result": []}}`))
+	}))
+	defer server.Close()
+
+	newCtx := func(cfg mcpgrafana.GrafanaConfig) context.Context {
+		cfg.URL = server.URL
+		cfg.APIKey = "secret-token"
+		ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+		return mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, cfg.APIKey, nil, 0))
+	}
+	params := QueryPrometheusParams{DatasourceUID: "prom-uid", Expr: "up", StartTime: "now", QueryType: "instant"}
+
+	t.Run("tenant parameter", func(t *testing.T) {
+		gotTenant = ""
+		args := params
+		args.TenantID = "team-a"
+		_, _, err := executePrometheusQuery(newCtx(mcpgrafana.GrafanaConfig{DefaultTenantID: "default"}), args)
+		require.NoError(t, err)
+		assert.Equal(t, "team-a", gotTenant)
+	})
+
+	t.Run("default tenant", func(t *testing.T) {
+		gotTenant = ""
+		_, _, err := executePrometheusQuery(newCtx(mcpgrafana.GrafanaConfig{DefaultTenantID: "default"}), params)
+		require.NoError(t, err)
+		assert.Equal(t, "default", gotTenant)
+	})
+
+	t.Run("forwarded header takes precedence", func(t *testing.T) {
+		gotTenant = ""
+		ctx := newCtx(mcpgrafana.GrafanaConfig{DefaultTenantID: "default", ExtraHeaders: map[string]string{"X-Scope-OrgID": "forwarded"}})
+		_, _, err := executePrometheusQuery(ctx, params)
+		require.NoError(t, err)
+		assert.Equal(t, "forwarded", gotTenant)
+
+		args := params
+		args.TenantID = "team-a"
+		_, _, err = executePrometheusQuery(ctx, args)
+		require.ErrorContains(t, err, `tenantId "team-a" conflicts with the tenant "forwarded"`)
+	})
+
+	t.Run("label tools", func(t *testing.T) {
+		ctx := newCtx(mcpgrafana.GrafanaConfig{DefaultTenantID: "default"})
+
+		gotTenant = ""
+		_, err := listPrometheusLabelNames(ctx, ListPrometheusLabelNamesParams{DatasourceUID: "prom-uid", TenantID: "team-a"})
+		require.NoError(t, err)
+		assert.Equal(t, "team-a", gotTenant)
+
+		gotTenant = ""
+		_, err = listPrometheusLabelValues(ctx, ListPrometheusLabelValuesParams{DatasourceUID: "prom-uid", LabelName: "job"})
+		require.NoError(t, err)
+		assert.Equal(t, "default", gotTenant)
+	})
+}
+
+func TestQueryPrometheusMultipleQueries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/uid/prom-uid" {
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+			return
+		}
+		if r.FormValue("query") == "up" {
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "vector", "result": [
+				{"metric": {"job": "api"}, "value": [1700000000, "1"]}
+			]}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status": "error", "errorType": "bad_data", "error": "parse error"}`))
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	t.Run("per query results and errors", func(t *testing.T) {
+		result, err := queryPrometheus(ctx, QueryPrometheusParams{
+			DatasourceUID: "prom-uid",
+			Queries:       []string{"up", "sum(", "up"},
+			StartTime:     "now",
+			QueryType:     "instant",
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Content, 1)
+
+		var outcomes map[string]struct {
+			Result json.RawMessage `json:"result"`
+			Error  string          `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &outcomes))
+		require.Len(t, outcomes, 2)
+
+		assert.Empty(t, outcomes["up"].Error)
+		var vector model.Vector
+		require.NoError(t, json.Unmarshal(outcomes["up"].Result, &vector))
+		require.Len(t, vector, 1)
+		assert.Equal(t, model.LabelValue("api"), vector[0].Metric["job"])
+
+		assert.Empty(t, outcomes["sum("].Result)
+		assert.Contains(t, outcomes["sum("].Error, "parse error")
+	})
+
+	t.Run("table format", func(t *testing.T) {
+		result, err := queryPrometheus(ctx, QueryPrometheusParams{
+			DatasourceUID: "prom-uid",
+			Queries:       []string{"up", "sum("},
+			StartTime:     "now",
+			QueryType:     "instant",
+			Format:        "table",
+		})
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "# up\n")
+		assert.Contains(t, text, "# sum(\nerror: ")
+	})
+
+	t.Run("expr and queries are mutually exclusive", func(t *testing.T) {
+		_, err := queryPrometheus(ctx, QueryPrometheusParams{
+			DatasourceUID: "prom-uid",
+			Expr:          "up",
+			Queries:       []string{"up"},
+			StartTime:     "now",
+		})
+		assert.EqualError(t, err, "expr and queries are mutually exclusive")
+	})
+
+	t.Run("neither expr nor queries", func(t *testing.T) {
+		_, err := queryPrometheus(ctx, QueryPrometheusParams{
+			DatasourceUID: "prom-uid",
+			StartTime:     "now",
+		})
+		assert.EqualError(t, err, "either expr or queries must be provided")
+	})
+
+	t.Run("malformed label matcher", func(t *testing.T) {
+		_, err := queryPrometheus(ctx, QueryPrometheusParams{
+			DatasourceUID: "prom-uid",
+			Queries:       []string{"up", `up{job=api}`},
+			StartTime:     "now",
+			QueryType:     "instant",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid label matcher "job=api": value must be a quoted string`)
+	})
+}
+
+func TestListPrometheusMetricMetadata(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/uid/prom-uid" {
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+			return
+		}
+		assert.Equal(t, "/api/datasources/proxy/uid/prom-uid/api/v1/metadata", r.URL.Path)
+		query = r.URL.Query()
+		if query.Get("metric") == "http_requests_total" {
+			_, _ = w.Write([]byte(`{"status": "success", "data": {
+				"http_requests_total": [{"type": "counter", "help": "Total HTTP requests.", "unit": ""}]
+			}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success", "data": {
+			"http_requests_total": [{"type": "counter", "help": "Total HTTP requests.", "unit": ""}],
+			"process_resident_memory_bytes": [{"type": "gauge", "help": "Resident memory size in bytes.", "unit": "bytes"}]
+		}}`))
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	t.Run("single metric", func(t *testing.T) {
+		result, err := listPrometheusMetricMetadata(ctx, ListPrometheusMetricMetadataParams{
+			DatasourceUID: "prom-uid",
+			Metric:        "http_requests_total",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "http_requests_total", query.Get("metric"))
+		assert.Equal(t, "10", query.Get("limit"))
+		require.Len(t, result, 1)
+		require.Len(t, result["http_requests_total"], 1)
+		assert.Equal(t, promv1.MetricTypeCounter, result["http_requests_total"][0].Type)
+		assert.Equal(t, "Total HTTP requests.", result["http_requests_total"][0].Help)
+	})
+
+	t.Run("all metrics up to limit", func(t *testing.T) {
+		result, err := listPrometheusMetricMetadata(ctx, ListPrometheusMetricMetadataParams{
+			DatasourceUID: "prom-uid",
+			Limit:         2,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, query.Get("metric"))
+		assert.Equal(t, "2", query.Get("limit"))
+		require.Len(t, result, 2)
+		memory := result["process_resident_memory_bytes"]
+		require.Len(t, memory, 1)
+		assert.Equal(t, promv1.MetricTypeGauge, memory[0].Type)
+		assert.Equal(t, "bytes", memory[0].Unit)
+	})
+}
+
+func TestQueryPrometheusExemplars(t *testing.T) {
+	var exemplarRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/datasources/uid/prom-uid":
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+		case "/api/datasources/proxy/uid/prom-uid/api/v1/query_range":
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+				{"metric": {"job": "api"}, "values": [[1700000000, "0.25"]]}
+			]}}`))
+		case "/api/datasources/proxy/uid/prom-uid/api/v1/query_exemplars":
+			exemplarRequests++
+			assert.NotEmpty(t, r.FormValue("start"))
+			assert.NotEmpty(t, r.FormValue("end"))
+			_, _ = w.Write([]byte(`{"status": "success", "data": [
+				{
+					"seriesLabels": {"__name__": "latency_seconds_bucket", "job": "api", "le": "0.5"},
+					"exemplars": [
+						{"labels": {"trace_id": "abc123"}, "value": "0.31", "timestamp": 1700000000.5},
+						{"labels": {"traceID": "def456", "span_id": "s1"}, "value": "0.42", "timestamp": 1700000001}
+					]
+				}
+			]}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+	args := QueryPrometheusParams{
+		DatasourceUID: "prom-uid",
+		Expr:          `histogram_quantile(0.99, rate(latency_seconds_bucket[5m]))`,
+		StartTime:     "now-1h",
+		EndTime:       "now",
+	}
+
+	t.Run("not fetched by default", func(t *testing.T) {
+		res, err := queryPrometheus(ctx, args)
+		require.NoError(t, err)
+		assert.Equal(t, 0, exemplarRequests)
+		assert.NotContains(t, res.Content[0].(mcp.TextContent).Text, "exemplars")
+	})
+
+	t.Run("trace ids attached by series", func(t *testing.T) {
+		withExemplars := args
+		withExemplars.IncludeExemplars = true
+		res, err := queryPrometheus(ctx, withExemplars)
+		require.NoError(t, err)
+		assert.Equal(t, 1, exemplarRequests)
+
+		var out struct {
+			Result    []map[string]any                `json:"result"`
+			Exemplars map[string][]prometheusExemplar `json:"exemplars"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &out))
+		require.Len(t, out.Result, 1)
+		series := `{__name__="latency_seconds_bucket", job="api", le="0.5"}`
+		require.Len(t, out.Exemplars[series], 2)
+		assert.Equal(t, "abc123", out.Exemplars[series][0].TraceID)
+		assert.Empty(t, out.Exemplars[series][0].Labels)
+		assert.Equal(t, "def456", out.Exemplars[series][1].TraceID)
+		assert.Equal(t, model.LabelSet{"span_id": "s1"}, out.Exemplars[series][1].Labels)
+	})
+
+	t.Run("table format", func(t *testing.T) {
+		withExemplars := args
+		withExemplars.IncludeExemplars = true
+		withExemplars.Format = "table"
+		res, err := queryPrometheus(ctx, withExemplars)
+		require.NoError(t, err)
+		text := res.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "exemplars:")
+		assert.Contains(t, text, "abc123")
+	})
+
+	t.Run("instant queries rejected", func(t *testing.T) {
+		instant := args
+		instant.QueryType = "instant"
+		instant.IncludeExemplars = true
+		_, err := queryPrometheus(ctx, instant)
+		require.Error(t, err)
+	})
+}
+
+func TestExplainPrometheusQuery(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/uid/prom-uid" {
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+			return
+		}
+		assert.Equal(t, "/api/datasources/proxy/uid/prom-uid/api/v1/query", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		query = r.Form
+		if query.Get("query") == "scalar(up)" {
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "scalar", "result": [1700000000, "1"]}}`))
+			return
+		}
+		// A high-cardinality vector: one series per pod, across two jobs.
+		var series []map[string]any
+		for i := 0; i < 1500; i++ {
+			series = append(series, map[string]any{
+				"metric": map[string]string{"__name__": "up", "job": []string{"api", "db"}[i%2], "pod": fmt.Sprintf("pod-%d", i)},
+				"value":  []any{1700000000, "1"},
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status": "success",
+			"data":   map[string]any{"resultType": "vector", "result": series},
+		})
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	t.Run("high cardinality vector", func(t *testing.T) {
+		result, err := explainPrometheusQuery(ctx, ExplainPrometheusQueryParams{DatasourceUID: "prom-uid", Expr: "up"})
+		require.NoError(t, err)
+		assert.Equal(t, "up", query.Get("query"))
+		assert.Equal(t, "vector", result.ResultType)
+		assert.Equal(t, 1500, result.SeriesCount)
+		assert.Equal(t, []string{"__name__", "job", "pod"}, result.LabelNames)
+		assert.Equal(t, map[string]int{"__name__": 1, "job": 2, "pod": 1500}, result.LabelCardinality)
+		assert.Contains(t, result.Warning, "1500 series")
+	})
+
+	t.Run("scalar", func(t *testing.T) {
+		result, err := explainPrometheusQuery(ctx, ExplainPrometheusQueryParams{DatasourceUID: "prom-uid", Expr: "scalar(up)", Time: "now-1h"})
+		require.NoError(t, err)
+		assert.Equal(t, &PrometheusQueryExplanation{
+			ResultType:  "scalar",
+			SeriesCount: 1,
+			LabelNames:  []string{},
+		}, result)
+	})
+}
+
+func TestPrometheusResultForOutput(t *testing.T) {
+	matrix := model.Matrix{
+		{
+			Metric: model.Metric{"job": "api"},
+			Values: []model.SamplePair{
+				{Timestamp: 1000, Value: 3},
+				{Timestamp: 2000, Value: 1},
+				{Timestamp: 3000, Value: model.SampleValue(math.NaN())},
+				{Timestamp: 4000, Value: 8},
+			},
+		},
+		{
+			Metric: model.Metric{"job": "db"},
+			Values: []model.SamplePair{{Timestamp: 1000, Value: 5}},
+		},
+	}
+	full, err := json.Marshal(matrix)
+	require.NoError(t, err)
+	size := len(full)
+
+	t.Run("result at the threshold is returned in full", func(t *testing.T) {
+		v, err := prometheusResultForOutput(matrix, size, 0)
+		require.NoError(t, err)
+		assert.Equal(t, matrix, v)
+	})
+
+	t.Run("result over the threshold is summarized", func(t *testing.T) {
+		v, err := prometheusResultForOutput(matrix, size-1, 0)
+		require.NoError(t, err)
+		summary, ok := v.(prometheusMatrixSummary)
+		require.True(t, ok)
+		assert.True(t, summary.Summarized)
+		assert.Equal(t, "matrix", summary.ResultType)
+		assert.Contains(t, summary.Note, fmt.Sprintf("The result is %d bytes, more than maxResultBytes (%d)", size, size-1))
+		assert.Equal(t, []prometheusSeriesSummary{
+			{Metric: model.Metric{"job": "api"}, Points: 4, Start: 1000, End: 4000, Min: 1, Max: 8, Avg: 4},
+			{Metric: model.Metric{"job": "db"}, Points: 1, Start: 1000, End: 1000, Min: 5, Max: 5, Avg: 5},
+		}, summary.Series)
+	})
+
+	t.Run("series without float values", func(t *testing.T) {
+		summary := summarizePrometheusMatrix(model.Matrix{{Metric: model.Metric{"job": "api"}}}, 100, 10)
+		require.Len(t, summary.Series, 1)
+		assert.True(t, math.IsNaN(float64(summary.Series[0].Avg)))
+		_, err := json.Marshal(summary)
+		require.NoError(t, err)
+	})
+
+	t.Run("instant results are never summarized", func(t *testing.T) {
+		vector := model.Vector{{Metric: model.Metric{"job": "api"}, Value: 1, Timestamp: 1000}}
+		v, err := prometheusResultForOutput(vector, 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, vector, v)
+	})
+}
+
+func TestDownsamplePrometheusResult(t *testing.T) {
+	series := func(n int) []model.SamplePair {
+		points := make([]model.SamplePair, n)
+		for i := range points {
+			points[i] = model.SamplePair{Timestamp: model.Time(i * 1000), Value: model.SampleValue(math.Sin(float64(i) / 10))}
+		}
+		return points
+	}
+
+	t.Run("long series are downsampled to at most maxPoints", func(t *testing.T) {
+		matrix := model.Matrix{
+			{Metric: model.Metric{"job": "api"}, Values: series(1000)},
+			{Metric: model.Metric{"job": "db"}, Values: series(10)},
+		}
+		v, err := prometheusResultForOutput(matrix, 1<<30, 50)
+		require.NoError(t, err)
+		downsampled, ok := v.(prometheusDownsampledMatrix)
+		require.True(t, ok)
+		assert.True(t, downsampled.Downsampled)
+		assert.Equal(t, 1010, downsampled.OriginalPoints)
+		assert.Equal(t, 60, downsampled.Points)
+		assert.Equal(t, 16.83, downsampled.ReductionRatio)
+		assert.Contains(t, downsampled.Note, "1010 points were reduced to 60 (16.83x)")
+
+		require.Len(t, downsampled.Result, 2)
+		api := downsampled.Result[0].Values
+		assert.Len(t, api, 50)
+		// The first and last points are kept, in order.
+		assert.Equal(t, matrix[0].Values[0], api[0])
+		assert.Equal(t, matrix[0].Values[999], api[49])
+		for i := 1; i < len(api); i++ {
+			assert.Less(t, api[i-1].Timestamp, api[i].Timestamp)
+		}
+		// The shape is kept: the peaks of the sine wave survive.
+		var peak model.SampleValue
+		for _, p := range api {
+			peak = max(peak, p.Value)
+		}
+		assert.InDelta(t, 1, float64(peak), 0.01)
+
+		// The short series passes through unchanged, and the input is
+		// left alone.
+		assert.Equal(t, matrix[1].Values, downsampled.Result[1].Values)
+		assert.Len(t, matrix[0].Values, 1000)
+	})
+
+	t.Run("short series pass through unchanged", func(t *testing.T) {
+		matrix := model.Matrix{{Metric: model.Metric{"job": "api"}, Values: series(10)}}
+		v, err := prometheusResultForOutput(matrix, 1<<30, 10)
+		require.NoError(t, err)
+		assert.Equal(t, matrix, v)
+	})
+
+	t.Run("NaN values", func(t *testing.T) {
+		points := series(100)
+		for i := 20; i < 40; i++ {
+			points[i].Value = model.SampleValue(math.NaN())
+		}
+		assert.Len(t, downsampleLTTB(points, 10), 10)
+	})
+
+	t.Run("maxPoints below 3 is rejected", func(t *testing.T) {
+		_, err := queryPrometheus(context.Background(), QueryPrometheusParams{DatasourceUID: "prom-uid", Expr: "up", StartTime: "now-1h", EndTime: "now", MaxPoints: 2})
+		assert.ErrorContains(t, err, "maxPoints must be at least 3")
+	})
+}
+
+func TestQueryPrometheusSummarizesLargeRangeResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/uid/prom-uid" {
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+			{"metric": {"job": "api"}, "values": [[1700000000, "1"], [1700000060, "2"], [1700000120, "3"]]}
+		]}}`))
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	query := func(maxResultBytes int) map[string]any {
+		t.Helper()
+		result, err := queryPrometheus(ctx, QueryPrometheusParams{
+			DatasourceUID:  "prom-uid",
+			Expr:           "up",
+			StartTime:      "now-1h",
+			EndTime:        "now",
+			MaxResultBytes: maxResultBytes,
+		})
+		require.NoError(t, err)
+		var v any
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &v))
+		if m, ok := v.(map[string]any); ok {
+			return m
+		}
+		return map[string]any{"points": v}
+	}
+
+	t.Run("small result is returned in full", func(t *testing.T) {
+		assert.NotContains(t, query(0), "summarized")
+	})
+
+	t.Run("large result is summarized", func(t *testing.T) {
+		v := query(10)
+		assert.Equal(t, true, v["summarized"])
+		series := v["series"].([]any)
+		require.Len(t, series, 1)
+		assert.Equal(t, float64(3), series[0].(map[string]any)["points"])
+		assert.Equal(t, "2", series[0].(map[string]any)["avg"])
+	})
+}
+
+func TestPrometheusDatasourceAccessDenied(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/uid/prom-uid" {
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message": "access denied to datasource"}`))
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{
+		URL:          server.URL,
+		ExtraHeaders: map[string]string{"X-Grafana-User-Email": "alice@example.com"},
+	}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	_, err := queryPrometheus(ctx, QueryPrometheusParams{
+		DatasourceUID: "prom-uid",
+		Expr:          "up",
+		StartTime:     "now",
+		QueryType:     "instant",
+	})
+	require.Error(t, err)
+	var denied *datasourceAccessDeniedError
+	require.ErrorAs(t, err, &denied)
+	assert.Contains(t, err.Error(), `access denied: the authenticated user (forwarded user "alice@example.com") lacks access to datasource "prom-uid": access denied to datasource`)
+}
+
+func TestListPrometheusLabelNames(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/uid/prom-uid" {
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+			return
+		}
+		require.Equal(t, "/api/datasources/proxy/uid/prom-uid/api/v1/labels", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		query = r.Form
+		if slices.Contains(query["match[]"], `{__name__='missing_metric'}`) {
+			_, _ = w.Write([]byte(`{"status": "success", "data": []}`))
+			return
+		}
+		if len(query["match[]"]) > 0 {
+			_, _ = w.Write([]byte(`{"status": "success", "data": ["__name__", "code", "job"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success", "data": ["__name__", "code", "instance", "job", "le"]}`))
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	t.Run("without matchers returns every label name", func(t *testing.T) {
+		names, err := listPrometheusLabelNames(ctx, ListPrometheusLabelNamesParams{DatasourceUID: "prom-uid"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"__name__", "code", "instance", "job", "le"}, names)
+		assert.Empty(t, query["match[]"])
+	})
+
+	t.Run("matchers restrict the label names to one metric", func(t *testing.T) {
+		names, err := listPrometheusLabelNames(ctx, ListPrometheusLabelNamesParams{
+			DatasourceUID: "prom-uid",
+			Matches: []Selector{{Filters: []LabelMatcher{
+				{Name: "__name__", Value: "http_requests_total", Type: "="},
+			}}},
+			StartRFC3339: "2025-01-01T00:00:00Z",
+			EndRFC3339:   "2025-01-01T01:00:00Z",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"__name__", "code", "job"}, names)
+		assert.Equal(t, []string{`{__name__='http_requests_total'}`}, query["match[]"])
+		assert.Equal(t, "1735689600", query.Get("start"))
+		assert.Equal(t, "1735693200", query.Get("end"))
+	})
+
+	t.Run("matchers selecting no series return no label names", func(t *testing.T) {
+		names, err := listPrometheusLabelNames(ctx, ListPrometheusLabelNamesParams{
+			DatasourceUID: "prom-uid",
+			Matches:       []Selector{{Filters: []LabelMatcher{{Name: "__name__", Value: "missing_metric"}}}},
+		})
+		require.NoError(t, err)
+		assert.NotNil(t, names)
+		assert.Empty(t, names)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		names, err := listPrometheusLabelNames(ctx, ListPrometheusLabelNamesParams{DatasourceUID: "prom-uid", Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"__name__", "code"}, names)
+	})
+}
+
+func TestFindPrometheusSeries(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/uid/prom-uid" {
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+			return
+		}
+		require.Equal(t, "/api/datasources/proxy/uid/prom-uid/api/v1/series", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		query = r.Form
+		_, _ = w.Write([]byte(`{"status": "success", "data": [
+			{"__name__": "http_requests_total", "job": "api", "code": "200"},
+			{"__name__": "http_requests_total", "job": "api", "code": "500"},
+			{"__name__": "http_requests_total", "job": "web", "code": "200"}
+		]}`))
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+
+	matches := []Selector{{Filters: []LabelMatcher{{Name: "__name__", Value: "http_requests_total", Type: "="}}}}
+
+	t.Run("returns the label sets of every matching series", func(t *testing.T) {
+		result, err := findPrometheusSeries(ctx, FindPrometheusSeriesParams{
+			DatasourceUID: "prom-uid",
+			Matches:       matches,
+			StartRFC3339:  "2025-01-01T00:00:00Z",
+			EndRFC3339:    "2025-01-01T01:00:00Z",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`{__name__='http_requests_total'}`}, query["match[]"])
+		assert.Equal(t, "1735689600", query.Get("start"))
+		assert.Equal(t, "1735693200", query.Get("end"))
+		assert.Equal(t, 3, result.Total)
+		assert.False(t, result.Truncated)
+		require.Len(t, result.Series, 3)
+		assert.Equal(t, model.LabelSet{"__name__": "http_requests_total", "job": "api", "code": "500"}, result.Series[1])
+	})
+
+	t.Run("truncates to the limit and reports the total", func(t *testing.T) {
+		result, err := findPrometheusSeries(ctx, FindPrometheusSeriesParams{
+			DatasourceUID: "prom-uid",
+			Matches:       matches,
+			Limit:         2,
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, query.Get("start"))
+		assert.NotEmpty(t, query.Get("end"))
+		assert.Equal(t, 3, result.Total)
+		assert.True(t, result.Truncated)
+		require.Len(t, result.Series, 2)
+		assert.Equal(t, model.LabelValue("200"), result.Series[0]["code"])
+		assert.Equal(t, model.LabelValue("500"), result.Series[1]["code"])
+	})
+
+	t.Run("requires a selector", func(t *testing.T) {
+		_, err := findPrometheusSeries(ctx, FindPrometheusSeriesParams{DatasourceUID: "prom-uid"})
+		require.EqualError(t, err, "at least one selector must be provided in matches")
+	})
+}
+
+func TestQueryPrometheusDefaultTimeRange(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/datasources/uid/prom-uid" {
+			_, _ = w.Write([]byte(`{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"}`))
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		form = r.Form
+		if strings.HasSuffix(r.URL.Path, "/query_range") {
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": []}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "vector", "result": []}}`))
+	}))
+	defer server.Close()
+
+	cfg := mcpgrafana.GrafanaConfig{URL: server.URL, DefaultQueryRange: 6 * time.Hour}
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), cfg)
+	ctx = mcpgrafana.WithGrafanaClient(ctx, mcpgrafana.NewGrafanaClient(ctx, server.URL, "", nil, 0))
+	formTime := func(key string) time.Time {
+		t.Helper()
+		seconds, err := strconv.ParseFloat(form.Get(key), 64)
+		require.NoError(t, err)
+		return time.Unix(int64(seconds), 0)
+	}
+
+	t.Run("range query defaults to the configured range ending now", func(t *testing.T) {
+		_, _, err := executePrometheusQuery(ctx, QueryPrometheusParams{DatasourceUID: "prom-uid", Expr: "up"})
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), formTime("end"), time.Minute)
+		assert.Equal(t, 6*time.Hour, formTime("end").Sub(formTime("start")))
+	})
+
+	t.Run("instant query defaults to now", func(t *testing.T) {
+		_, _, err := executePrometheusQuery(ctx, QueryPrometheusParams{DatasourceUID: "prom-uid", Expr: "up", QueryType: "instant"})
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), formTime("time"), time.Minute)
+	})
+
+	t.Run("explicit range wins", func(t *testing.T) {
+		_, _, err := executePrometheusQuery(ctx, QueryPrometheusParams{
+			DatasourceUID: "prom-uid",
+			Expr:          "up",
+			StartTime:     "1700000000",
+			EndTime:       "1700000600",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, time.Unix(1700000000, 0), formTime("start"))
+		assert.Equal(t, time.Unix(1700000600, 0), formTime("end"))
+	})
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+// promqlAtHint is appended to @ modifier validation errors to show the
+// accepted forms.
+const promqlAtHint = `the @ modifier takes a Unix timestamp in seconds, e.g. up @ 1600000000, or start() or end()`
+
+// promqlSubqueryHint is appended to subquery validation errors to show the
+// expected shape of a subquery.
+const promqlSubqueryHint = `subqueries look like rate(http_requests_total[5m])[1h:1m], with a range before the colon and an optional resolution after it`
+
+// validatePromQL performs a lightweight syntax check of a PromQL expression
+// so that obviously broken queries get a helpful error instead of a cryptic
+// one from Prometheus. It checks label matchers, @ modifier timestamps and
+// subquery ranges, and leaves everything else to Prometheus. Errors are of
+// kind mcpgrafana.ErrKindValidation.
+func validatePromQL(expr string) error {
+	if err := validatePromQLMatchers(expr); err != nil {
+		return mcpgrafana.NewError(mcpgrafana.ErrKindValidation, "", err)
+	}
+	if err := checkPromQLModifiers(expr); err != nil {
+		return mcpgrafana.NewError(mcpgrafana.ErrKindValidation, "", err)
+	}
+	return nil
+}
+
+// checkPromQLModifiers checks that every @ modifier is given a non-negative
+// timestamp, start() or end(), and that every subquery has a range.
+func checkPromQLModifiers(expr string) error {
+	runes := []rune(expr)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case isQuote(r):
+			end := skipStringLiteral(runes, i)
+			if end < 0 {
+				// Reported by validatePromQLMatchers.
+				return nil
+			}
+			i = end
+		case r == '@':
+			if err := checkPromQLAt(runes, i+1); err != nil {
+				return err
+			}
+		case r == '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if err := checkPromQLSubquery(string(runes[i+1 : end])); err != nil {
+				return err
+			}
+			i = end
+		}
+	}
+	return nil
+}
+
+// checkPromQLAt checks the argument of an @ modifier, which starts at the
+// given index.
+func checkPromQLAt(runes []rune, start int) error {
+	i := nextNonSpace(runes, start)
+	if i == len(runes) {
+		return fmt.Errorf("invalid PromQL query: missing timestamp after @; %s", promqlAtHint)
+	}
+
+	end := i
+	if runes[end] == '+' || runes[end] == '-' {
+		end++
+	}
+	for end < len(runes) && (isLabelNameChar(runes[end]) || runes[end] == '.') {
+		end++
+	}
+	arg := string(runes[i:end])
+
+	if arg == "start" || arg == "end" {
+		if j := nextNonSpace(runes, end); j < len(runes) && runes[j] == '(' {
+			if k := nextNonSpace(runes, j+1); k < len(runes) && runes[k] == ')' {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid PromQL query: invalid @ modifier %q; %s", "@ "+arg, promqlAtHint)
+	}
+	if arg == "" {
+		return fmt.Errorf("invalid PromQL query: invalid @ modifier: expected a timestamp, found %q; %s", runes[i], promqlAtHint)
+	}
+
+	ts, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(ts) || math.IsInf(ts, 0) {
+		return fmt.Errorf("invalid PromQL query: invalid @ modifier %q: timestamp is not a number; %s", "@ "+arg, promqlAtHint)
+	}
+	if ts < 0 {
+		return fmt.Errorf("invalid PromQL query: invalid @ modifier %q: timestamp is negative; %s", "@ "+arg, promqlAtHint)
+	}
+	return nil
+}
+
+// checkPromQLSubquery checks the contents of a range selector's brackets.
+// Plain ranges such as 5m are left to Prometheus; subqueries, which contain
+// a colon, must have a range. The resolution is optional and defaults to the
+// evaluation interval.
+func checkPromQLSubquery(brackets string) error {
+	rng, _, ok := strings.Cut(brackets, ":")
+	if !ok {
+		return nil
+	}
+	if strings.TrimSpace(rng) == "" {
+		return fmt.Errorf("invalid PromQL query: subquery [%s] has no range, so it is unbounded; %s", brackets, promqlSubqueryHint)
+	}
+	return nil
+}
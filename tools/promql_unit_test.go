@@ -0,0 +1,67 @@
+//go:build unit
+
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+func TestValidatePromQL(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		expr    string
+		wantErr string
+	}{
+		{name: "at timestamp", expr: `http_requests_total @ 1600000000`},
+		{name: "fractional at timestamp", expr: `rate(http_requests_total[5m] @ 1600000000.5)`},
+		{name: "at start and end", expr: `sum(rate(up[5m] @ start())) / sum(rate(up[5m] @ end( )))`},
+		{name: "at with offset", expr: `up offset 5m @ 1600000000`},
+		{name: "subquery", expr: `max_over_time(rate(http_requests_total[5m])[1h:1m])`},
+		{name: "subquery with default resolution", expr: `max_over_time(rate(http_requests_total[5m])[1h:])`},
+		{name: "at in string", expr: `up{email="me@example.com"}`},
+		{name: "at in comment", expr: "up # @ foo"},
+		{name: "invalid at", expr: `http_requests_total @ foo`, wantErr: `invalid @ modifier "@ foo": timestamp is not a number`},
+		{name: "negative at", expr: `up @ -100`, wantErr: `invalid @ modifier "@ -100": timestamp is negative`},
+		{name: "missing at timestamp", expr: `up @`, wantErr: "missing timestamp after @"},
+		{name: "at function without parens", expr: `up @ start`, wantErr: `invalid @ modifier "@ start"`},
+		{name: "unbounded subquery", expr: `max_over_time(rate(up[5m])[:1m])`, wantErr: "subquery [:1m] has no range"},
+		{name: "malformed matcher", expr: `up{job=api}`, wantErr: `invalid label matcher "job=api"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePromQL(tc.expr)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+			assert.Equal(t, mcpgrafana.ErrKindValidation, mcpgrafana.ErrorKindOf(err))
+		})
+	}
+}
+
+func TestQueryToolExamplesAreValid(t *testing.T) {
+	for _, tool := range []mcpgrafana.Tool{QueryPrometheus, QueryLokiLogs, QueryLokiStats} {
+		examples := mcpgrafana.ToolExamples(tool.Tool)
+		require.NotEmpty(t, examples, tool.Tool.Name)
+		for _, example := range examples {
+			if logql, ok := example["logql"].(string); ok {
+				assert.NoError(t, validateLogQL(logql), tool.Tool.Name)
+				continue
+			}
+			exprs, _ := example["queries"].([]any)
+			if expr, ok := example["expr"]; ok {
+				exprs = append(exprs, expr)
+			}
+			require.NotEmpty(t, exprs, tool.Tool.Name)
+			for _, expr := range exprs {
+				assert.NoError(t, validatePromQL(expr.(string)), tool.Tool.Name)
+			}
+		}
+	}
+}
@@ -2,12 +2,15 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"regexp"
+	"sort"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"connectrpc.com/connect"
@@ -45,24 +48,24 @@ var ListPyroscopeLabelNames = mcpgrafana.MustTool(
 type ListPyroscopeLabelNamesParams struct {
 	DataSourceUID string `json:"data_source_uid" jsonschema:"required,description=The UID of the datasource to query"`
 	Matchers      string `json:"matchers,omitempty" jsonschema:"Prometheus style matchers used t0 filter the result set (defaults to: {})"`
-	StartRFC3339  string `json:"start_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query in RFC3339 format (defaults to 1 hour ago)"`
-	EndRFC3339    string `json:"end_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query in RFC3339 format (defaults to now)"`
+	StartRFC3339  string `json:"start_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndRFC3339    string `json:"end_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query as RFC3339\\, Unix seconds or relative to now. Defaults to now"`
 }
 
 func listPyroscopeLabelNames(ctx context.Context, args ListPyroscopeLabelNamesParams) ([]string, error) {
 	args.Matchers = stringOrDefault(args.Matchers, "{}")
 
-	start, err := rfc3339OrDefault(args.StartRFC3339, time.Time{})
+	start, err := timeOrDefault(args.StartRFC3339, time.Time{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse start timestamp %q: %w", args.StartRFC3339, err)
 	}
 
-	end, err := rfc3339OrDefault(args.EndRFC3339, time.Time{})
+	end, err := timeOrDefault(args.EndRFC3339, time.Time{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse end timestamp %q: %w", args.EndRFC3339, err)
 	}
 
-	start, end, err = validateTimeRange(start, end)
+	start, end, err = validateTimeRange(ctx, start, end)
 	if err != nil {
 		return nil, err
 	}
@@ -105,8 +108,8 @@ type ListPyroscopeLabelValuesParams struct {
 	DataSourceUID string `json:"data_source_uid" jsonschema:"required,description=The UID of the datasource to query"`
 	Name          string `json:"name" jsonschema:"required,description=A label name"`
 	Matchers      string `json:"matchers,omitempty" jsonschema:"description=Optionally\\, Prometheus style matchers used to filter the result set (defaults to: {})"`
-	StartRFC3339  string `json:"start_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query in RFC3339 format (defaults to 1 hour ago)"`
-	EndRFC3339    string `json:"end_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query in RFC3339 format (defaults to now)"`
+	StartRFC3339  string `json:"start_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndRFC3339    string `json:"end_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query as RFC3339\\, Unix seconds or relative to now. Defaults to now"`
 }
 
 func listPyroscopeLabelValues(ctx context.Context, args ListPyroscopeLabelValuesParams) ([]string, error) {
@@ -117,17 +120,17 @@ func listPyroscopeLabelValues(ctx context.Context, args ListPyroscopeLabelValues
 
 	args.Matchers = stringOrDefault(args.Matchers, "{}")
 
-	start, err := rfc3339OrDefault(args.StartRFC3339, time.Time{})
+	start, err := timeOrDefault(args.StartRFC3339, time.Time{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse start timestamp %q: %w", args.StartRFC3339, err)
 	}
 
-	end, err := rfc3339OrDefault(args.EndRFC3339, time.Time{})
+	end, err := timeOrDefault(args.EndRFC3339, time.Time{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse end timestamp %q: %w", args.EndRFC3339, err)
 	}
 
-	start, end, err = validateTimeRange(start, end)
+	start, end, err = validateTimeRange(ctx, start, end)
 	if err != nil {
 		return nil, err
 	}
@@ -152,10 +155,12 @@ func listPyroscopeLabelValues(ctx context.Context, args ListPyroscopeLabelValues
 }
 
 const listPyroscopeProfileTypesToolPrompt = `
-Lists all available profile types available in a specified Pyroscope datasource and time range. Returns a list of all
-available profile types (example profile type: "process_cpu:cpu:nanoseconds:cpu:nanoseconds"). A profile type has the
-following structure: <name>:<sample type>:<sample unit>:<period type>:<period unit>. Not all profile types are available
-for every service. If the time range is not provided, it defaults to the last hour.
+Lists all available profile types available in a specified Pyroscope datasource and time range. Returns the ID of each
+profile type (example profile type ID: "process_cpu:cpu:nanoseconds:cpu:nanoseconds") along with its sample type and
+unit, e.g. "cpu" in "nanoseconds" or "alloc_space" in "bytes". A profile type ID has the following structure:
+<name>:<sample type>:<sample unit>:<period type>:<period unit>. Not all profile types are available for every service;
+pass label matchers (e.g. {service_name="foo"}) to only list the profile types of a service. If the time range is not
+provided, it defaults to the last hour.
 `
 
 var ListPyroscopeProfileTypes = mcpgrafana.MustTool(
@@ -169,22 +174,37 @@ var ListPyroscopeProfileTypes = mcpgrafana.MustTool(
 
 type ListPyroscopeProfileTypesParams struct {
 	DataSourceUID string `json:"data_source_uid" jsonschema:"required,description=The UID of the datasource to query"`
-	StartRFC3339  string `json:"start_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query in RFC3339 format (defaults to 1 hour ago)"`
-	EndRFC3339    string `json:"end_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query in RFC3339 format (defaults to now)"`
+	Matchers      string `json:"matchers,omitempty" jsonschema:"description=Optionally\\, Prometheus style matchers selecting the service or application to list profile types for (e.g. {service_name=\"foo\"}). Defaults to all services."`
+	StartRFC3339  string `json:"start_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndRFC3339    string `json:"end_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query as RFC3339\\, Unix seconds or relative to now. Defaults to now"`
 }
 
-func listPyroscopeProfileTypes(ctx context.Context, args ListPyroscopeProfileTypesParams) ([]string, error) {
-	start, err := rfc3339OrDefault(args.StartRFC3339, time.Time{})
+// PyroscopeProfileType is a profile type available in a Pyroscope
+// datasource.
+type PyroscopeProfileType struct {
+	ID         string `json:"id"`
+	SampleType string `json:"sample_type"`
+	SampleUnit string `json:"sample_unit"`
+	PeriodType string `json:"period_type"`
+	PeriodUnit string `json:"period_unit"`
+}
+
+// profileTypeLabel is the label Pyroscope uses to record the profile type ID
+// of a series, which allows listing the profile types of a single service.
+const profileTypeLabel = "__profile_type__"
+
+func listPyroscopeProfileTypes(ctx context.Context, args ListPyroscopeProfileTypesParams) ([]PyroscopeProfileType, error) {
+	start, err := timeOrDefault(args.StartRFC3339, time.Time{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse start timestamp %q: %w", args.StartRFC3339, err)
 	}
 
-	end, err := rfc3339OrDefault(args.EndRFC3339, time.Time{})
+	end, err := timeOrDefault(args.EndRFC3339, time.Time{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse end timestamp %q: %w", args.EndRFC3339, err)
 	}
 
-	start, end, err = validateTimeRange(start, end)
+	start, end, err = validateTimeRange(ctx, start, end)
 	if err != nil {
 		return nil, err
 	}
@@ -194,30 +214,65 @@ func listPyroscopeProfileTypes(ctx context.Context, args ListPyroscopeProfileTyp
 		return nil, fmt.Errorf("failed to create Pyroscope client: %w", err)
 	}
 
-	req := &querierv1.ProfileTypesRequest{
-		Start: start.UnixMilli(),
-		End:   end.UnixMilli(),
-	}
-	res, err := client.ProfileTypes(ctx, connect.NewRequest(req))
-	if err != nil {
-		return nil, fmt.Errorf("failed to call Pyroscope API: %w", err)
+	var ids []string
+	if matchers := strings.TrimSpace(args.Matchers); matchers != "" && matchers != "{}" {
+		// The ProfileTypes API can't be filtered, but the values of the
+		// profile type label can.
+		req := &typesv1.LabelValuesRequest{
+			Name:     profileTypeLabel,
+			Matchers: []string{matchers},
+			Start:    start.UnixMilli(),
+			End:      end.UnixMilli(),
+		}
+		res, err := client.LabelValues(ctx, connect.NewRequest(req))
+		if err != nil {
+			return nil, fmt.Errorf("failed to call Pyroscope API: %w", err)
+		}
+		ids = res.Msg.Names
+	} else {
+		req := &querierv1.ProfileTypesRequest{
+			Start: start.UnixMilli(),
+			End:   end.UnixMilli(),
+		}
+		res, err := client.ProfileTypes(ctx, connect.NewRequest(req))
+		if err != nil {
+			return nil, fmt.Errorf("failed to call Pyroscope API: %w", err)
+		}
+		for _, typ := range res.Msg.ProfileTypes {
+			ids = append(ids, fmt.Sprintf("%s:%s:%s:%s:%s", typ.Name, typ.SampleType, typ.SampleUnit, typ.PeriodType, typ.PeriodUnit))
+		}
 	}
 
-	profileTypes := make([]string, len(res.Msg.ProfileTypes))
-	for i, typ := range res.Msg.ProfileTypes {
-		profileTypes[i] = fmt.Sprintf("%s:%s:%s:%s:%s", typ.Name, typ.SampleType, typ.SampleUnit, typ.PeriodType, typ.PeriodUnit)
+	profileTypes := make([]PyroscopeProfileType, 0, len(ids))
+	for _, id := range ids {
+		profileTypes = append(profileTypes, parseProfileTypeID(id))
 	}
+	sort.Slice(profileTypes, func(i, j int) bool { return profileTypes[i].ID < profileTypes[j].ID })
 	return profileTypes, nil
 }
 
+// parseProfileTypeID splits a profile type ID of the form
+// <name>:<sample type>:<sample unit>:<period type>:<period unit>. IDs with
+// another shape only have their ID set.
+func parseProfileTypeID(id string) PyroscopeProfileType {
+	typ := PyroscopeProfileType{ID: id}
+	if parts := strings.Split(id, ":"); len(parts) == 5 {
+		typ.SampleType, typ.SampleUnit = parts[1], parts[2]
+		typ.PeriodType, typ.PeriodUnit = parts[3], parts[4]
+	}
+	return typ
+}
+
 const fetchPyroscopeProfileToolPrompt = `
-Fetches a profile from a Pyroscope data source for a given time range. By default, the time range is tha past 1 hour.
-The profile type is required, available profile types can be fetched via the list_pyroscope_profile_types tool. Not all
-profile types are available for every service. Expect some queries to return empty result sets, this indicates the
-profile type does not exist for that query. In such a case, consider trying a related profile type or giving up.
-Matchers are not required, but highly recommended, they are generally used to select an application by the service_name
-label (e.g. {service_name="foo"}). Use the list_pyroscope_label_names tool to fetch available label names, and the
-list_pyroscope_label_values tool to fetch available label values. The returned profile is in DOT format.
+Fetches a profile from a Pyroscope data source for a given time range and summarizes it. By default, the time range is
+the past 1 hour. The profile type is required, available profile types can be fetched via the
+list_pyroscope_profile_types tool. Not all profile types are available for every service. Expect some queries to return
+empty result sets, this indicates the profile type does not exist for that query. In such a case, consider trying a
+related profile type or giving up. Matchers are not required, but highly recommended, they are generally used to select
+an application by the service_name label (e.g. {service_name="foo"}). Use the list_pyroscope_label_names tool to fetch
+available label names, and the list_pyroscope_label_values tool to fetch available label values. Instead of the full
+flame graph, the result is a table of the top functions (20 by default) ranked by self or total value, along with their
+share of the whole profile.
 `
 
 var FetchPyroscopeProfile = mcpgrafana.MustTool(
@@ -234,8 +289,10 @@ type FetchPyroscopeProfileParams struct {
 	ProfileType   string `json:"profile_type" jsonschema:"required,description=Type profile type\\, use the list_pyroscope_profile_types tool to fetch available profile types"`
 	Matchers      string `json:"matchers,omitempty" jsonschema:"description=Optionally\\, Prometheus style matchers used to filter the result set (defaults to: {})"`
 	MaxNodeDepth  int    `json:"max_node_depth,omitempty" jsonschema:"description=Optionally\\, the maximum depth of nodes in the resulting profile. Less depth results in smaller profiles that execute faster\\, more depth result in larger profiles that have more detail. A value of -1 indicates to use an unbounded node depth (default: 100). Reducing max node depth from the default will negatively impact the accuracy of the profile"`
-	StartRFC3339  string `json:"start_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query in RFC3339 format (defaults to 1 hour ago)"`
-	EndRFC3339    string `json:"end_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query in RFC3339 format (defaults to now)"`
+	TopN          int    `json:"top_n,omitempty" jsonschema:"description=Optionally\\, the number of functions to return (default: 20)"`
+	SortBy        string `json:"sort_by,omitempty" jsonschema:"description=Optionally\\, rank functions by their self value (\"self\") or by their total value including callees (\"total\") (default: self)"`
+	StartRFC3339  string `json:"start_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the start time of the query as RFC3339\\, Unix seconds or relative to now (e.g. 'now-6h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndRFC3339    string `json:"end_rfc_3339,omitempty" jsonschema:"description=Optionally\\, the end time of the query as RFC3339\\, Unix seconds or relative to now. Defaults to now"`
 }
 
 func fetchPyroscopeProfile(ctx context.Context, args FetchPyroscopeProfileParams) (string, error) {
@@ -246,18 +303,26 @@ func fetchPyroscopeProfile(ctx context.Context, args FetchPyroscopeProfileParams
 	}
 
 	args.MaxNodeDepth = intOrDefault(args.MaxNodeDepth, 100)
+	args.TopN = intOrDefault(args.TopN, 20)
+	if args.TopN < 0 {
+		return "", fmt.Errorf("top_n must be positive, got %d", args.TopN)
+	}
+	args.SortBy = stringOrDefault(args.SortBy, "self")
+	if args.SortBy != "self" && args.SortBy != "total" {
+		return "", fmt.Errorf("invalid sort_by %q, must be one of: self, total", args.SortBy)
+	}
 
-	start, err := rfc3339OrDefault(args.StartRFC3339, time.Time{})
+	start, err := timeOrDefault(args.StartRFC3339, time.Time{})
 	if err != nil {
 		return "", fmt.Errorf("failed to parse start timestamp %q: %w", args.StartRFC3339, err)
 	}
 
-	end, err := rfc3339OrDefault(args.EndRFC3339, time.Time{})
+	end, err := timeOrDefault(args.EndRFC3339, time.Time{})
 	if err != nil {
 		return "", fmt.Errorf("failed to parse end timestamp %q: %w", args.EndRFC3339, err)
 	}
 
-	start, end, err = validateTimeRange(start, end)
+	start, end, err = validateTimeRange(ctx, start, end)
 	if err != nil {
 		return "", err
 	}
@@ -272,7 +337,7 @@ func fetchPyroscopeProfile(ctx context.Context, args FetchPyroscopeProfileParams
 		Matcher:     args.Matchers,
 		Start:       start,
 		End:         end,
-		Format:      "dot",
+		Format:      "json",
 		MaxNodes:    args.MaxNodeDepth,
 	}
 	res, err := client.Render(ctx, req)
@@ -280,8 +345,161 @@ func fetchPyroscopeProfile(ctx context.Context, args FetchPyroscopeProfileParams
 		return "", fmt.Errorf("failed to call Pyroscope API: %w", err)
 	}
 
-	res = cleanupDotProfile(res)
-	return res, nil
+	var profile flamebearerProfile
+	if err := json.Unmarshal([]byte(res), &profile); err != nil {
+		return "", fmt.Errorf("failed to decode Pyroscope profile: %w", err)
+	}
+	if profile.Flamebearer.NumTicks == 0 {
+		return "", fmt.Errorf("failed to call Pyroscope API: pyroscope API returned an empty response")
+	}
+	stats, err := summarizeFlamebearer(&profile)
+	if err != nil {
+		return "", err
+	}
+	return formatProfileSummary(&profile, stats, args.SortBy, args.TopN), nil
+}
+
+// flamebearerProfile is the JSON rendering of a profile returned by the
+// Pyroscope render endpoint.
+type flamebearerProfile struct {
+	Flamebearer struct {
+		Names []string `json:"names"`
+		// Levels holds the nodes of each level of the flame graph as groups
+		// of [x offset, total, self, name index], where the x offset is
+		// relative to the end of the previous node of the level.
+		Levels   [][]int64 `json:"levels"`
+		NumTicks int64     `json:"numTicks"`
+	} `json:"flamebearer"`
+	Metadata struct {
+		Format string `json:"format"`
+		Units  string `json:"units"`
+	} `json:"metadata"`
+}
+
+// profileFunctionStat is the self and total value of a function across all
+// of its call sites in a profile.
+type profileFunctionStat struct {
+	Name  string
+	Self  int64
+	Total int64
+}
+
+// summarizeFlamebearer aggregates the nodes of a flame graph by function.
+// Recursive calls only count once towards the total of a function.
+func summarizeFlamebearer(profile *flamebearerProfile) ([]profileFunctionStat, error) {
+	fb := profile.Flamebearer
+	if profile.Metadata.Format != "" && profile.Metadata.Format != "single" {
+		return nil, fmt.Errorf("unsupported profile format %q", profile.Metadata.Format)
+	}
+
+	type node struct {
+		x, total, self int64
+		name           int64
+		children       []int
+	}
+	levels := make([][]node, len(fb.Levels))
+	for l, level := range fb.Levels {
+		if len(level)%4 != 0 {
+			return nil, fmt.Errorf("malformed profile: level %d has %d values", l, len(level))
+		}
+		var prevEnd int64
+		for i := 0; i < len(level); i += 4 {
+			n := node{x: prevEnd + level[i], total: level[i+1], self: level[i+2], name: level[i+3]}
+			if n.name < 0 || n.name >= int64(len(fb.Names)) {
+				return nil, fmt.Errorf("malformed profile: name index %d out of range", n.name)
+			}
+			prevEnd = n.x + n.total
+			levels[l] = append(levels[l], n)
+		}
+		if l == 0 {
+			continue
+		}
+		// Nodes of a level are ordered by offset, so parents can be found
+		// by walking both levels together.
+		parents, p := levels[l-1], 0
+		for i, n := range levels[l] {
+			for p < len(parents) && parents[p].x+parents[p].total <= n.x {
+				p++
+			}
+			if p == len(parents) {
+				return nil, fmt.Errorf("malformed profile: node without a parent at level %d", l)
+			}
+			parents[p].children = append(parents[p].children, i)
+		}
+	}
+
+	stats := map[int64]*profileFunctionStat{}
+	onStack := map[int64]int{}
+	var walk func(l, i int)
+	walk = func(l, i int) {
+		n := levels[l][i]
+		// The root node is the whole profile rather than a function.
+		if l > 0 {
+			stat, ok := stats[n.name]
+			if !ok {
+				stat = &profileFunctionStat{Name: fb.Names[n.name]}
+				stats[n.name] = stat
+			}
+			stat.Self += n.self
+			if onStack[n.name] == 0 {
+				stat.Total += n.total
+			}
+			onStack[n.name]++
+			defer func() { onStack[n.name]-- }()
+		}
+		for _, c := range n.children {
+			walk(l+1, c)
+		}
+	}
+	if len(levels) > 0 {
+		for i := range levels[0] {
+			walk(0, i)
+		}
+	}
+
+	result := make([]profileFunctionStat, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, *stat)
+	}
+	return result, nil
+}
+
+// formatProfileSummary renders the topN functions of a profile, ranked by
+// their self or total value, as a text table.
+func formatProfileSummary(profile *flamebearerProfile, stats []profileFunctionStat, sortBy string, topN int) string {
+	key := func(s profileFunctionStat) int64 { return s.Self }
+	if sortBy == "total" {
+		key = func(s profileFunctionStat) int64 { return s.Total }
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if a, b := key(stats[i]), key(stats[j]); a != b {
+			return a > b
+		}
+		return stats[i].Name < stats[j].Name
+	})
+
+	total := len(stats)
+	if total > topN {
+		stats = stats[:topN]
+	}
+
+	numTicks := profile.Flamebearer.NumTicks
+	pct := func(v int64) string { return fmt.Sprintf("%.2f%%", 100*float64(v)/float64(numTicks)) }
+
+	var sb strings.Builder
+	units := stringOrDefault(profile.Metadata.Units, "samples")
+	fmt.Fprintf(&sb, "total: %d %s\n", numTicks, units)
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "self\tself%\ttotal\ttotal%\tfunction")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\n", s.Self, pct(s.Self), s.Total, pct(s.Total), s.Name)
+	}
+	_ = w.Flush()
+
+	if total > len(stats) {
+		fmt.Fprintf(&sb, "(truncated: showing %d of %d functions)\n", len(stats), total)
+	}
+	return sb.String()
 }
 
 func newPyroscopeClient(ctx context.Context, uid string) (*pyroscopeClient, error) {
@@ -293,6 +511,7 @@ func newPyroscopeClient(ctx context.Context, uid string) (*pyroscopeClient, erro
 	}
 	transport = NewAuthRoundTripper(transport, cfg.AccessToken, cfg.IDToken, cfg.APIKey, cfg.BasicAuth)
 	transport = mcpgrafana.NewOrgIDRoundTripper(transport, cfg.OrgID)
+	transport = newDatasourceAccessRoundTripper(transport, uid, cfg)
 
 	httpClient := &http.Client{
 		Transport: mcpgrafana.NewUserAgentTransport(
@@ -301,7 +520,7 @@ func newPyroscopeClient(ctx context.Context, uid string) (*pyroscopeClient, erro
 		Timeout: 10 * time.Second,
 	}
 
-	_, err = getDatasourceByUID(ctx, GetDatasourceByUIDParams{UID: uid})
+	_, err = datasourceInfo(ctx, uid)
 	if err != nil {
 		return nil, err
 	}
@@ -380,11 +599,11 @@ func (c *pyroscopeClient) get(ctx context.Context, path string, params url.Value
 	}()
 
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		body, err := io.ReadAll(res.Body)
-		if err != nil {
-			return nil, fmt.Errorf("pyroscope API failed with status code %d", res.StatusCode)
+		body := readErrorBody(res.Body)
+		if len(body) == 0 {
+			return nil, mcpgrafana.NewHTTPError(res.StatusCode, fmt.Sprintf("pyroscope API failed with status code %d", res.StatusCode))
 		}
-		return nil, fmt.Errorf("pyroscope API failed with status code %d: %s", res.StatusCode, string(body))
+		return nil, mcpgrafana.NewHTTPError(res.StatusCode, fmt.Sprintf("pyroscope API failed with status code %d: %s", res.StatusCode, summarizeErrorBody(body)))
 	}
 
 	const limit = 1 << 25 // 32 MiB
@@ -417,27 +636,13 @@ func stringOrDefault(s string, def string) string {
 	return s
 }
 
-func rfc3339OrDefault(s string, def time.Time) (time.Time, error) {
-	s = strings.TrimSpace(s)
-
-	var err error
-	if s != "" {
-		def, err = time.Parse(time.RFC3339, s)
-		if err != nil {
-			return time.Time{}, err
-		}
-	}
-
-	return def, nil
-}
-
-func validateTimeRange(start time.Time, end time.Time) (time.Time, time.Time, error) {
+func validateTimeRange(ctx context.Context, start time.Time, end time.Time) (time.Time, time.Time, error) {
 	if end.IsZero() {
 		end = time.Now()
 	}
 
 	if start.IsZero() {
-		start = end.Add(-1 * time.Hour)
+		start = end.Add(-defaultQueryRange(ctx))
 	}
 
 	if start.After(end) || start.Equal(end) {
@@ -446,15 +651,3 @@ func validateTimeRange(start time.Time, end time.Time) (time.Time, time.Time, er
 
 	return start, end, nil
 }
-
-var cleanupRegex = regexp.MustCompile(`(?m)(fontsize=\d+ )|(id="node\d+" )|(labeltooltip=".*?\)" )|(tooltip=".*?\)" )|(N\d+ -> N\d+).*|(N\d+ \[label="other.*\n)|(shape=box )|(fillcolor="#\w{6}")|(color="#\w{6}" )`)
-
-func cleanupDotProfile(profile string) string {
-	return cleanupRegex.ReplaceAllStringFunc(profile, func(match string) string {
-		// Preserve edge labels (e.g., "N1 -> N2")
-		if m := regexp.MustCompile(`^N\d+ -> N\d+`).FindString(match); m != "" {
-			return m
-		}
-		return ""
-	})
-}
@@ -50,7 +50,11 @@ func TestPyroscopeTools(t *testing.T) {
 			DataSourceUID: "pyroscope",
 		})
 		require.NoError(t, err)
-		require.ElementsMatch(t, types, []string{
+		ids := make([]string, len(types))
+		for i, typ := range types {
+			ids[i] = typ.ID
+		}
+		require.ElementsMatch(t, ids, []string{
 			"block:contentions:count:contentions:count",
 			"block:delay:nanoseconds:contentions:count",
 			"goroutines:goroutine:count:goroutine:count",
@@ -73,7 +77,7 @@ func TestPyroscopeTools(t *testing.T) {
 			Matchers:      `{service_name="pyroscope"}`,
 		})
 		require.NoError(t, err)
-		require.NotEmpty(t, profile)
+		require.Contains(t, profile, "self  self%")
 	})
 
 	t.Run("fetch empty Pyroscope profile", func(t *testing.T) {
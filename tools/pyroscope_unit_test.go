@@ -0,0 +1,203 @@
+//go:build unit
+
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"connectrpc.com/connect"
+	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	"github.com/grafana/pyroscope/api/gen/proto/go/querier/v1/querierv1connect"
+	typesv1 "github.com/grafana/pyroscope/api/gen/proto/go/types/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+// fakeQuerier implements the subset of the Pyroscope querier API used by the
+// tools.
+type fakeQuerier struct {
+	querierv1connect.UnimplementedQuerierServiceHandler
+	labelValues map[string][]string
+	matchers    []string
+}
+
+func (q *fakeQuerier) ProfileTypes(context.Context, *connect.Request[querierv1.ProfileTypesRequest]) (*connect.Response[querierv1.ProfileTypesResponse], error) {
+	return connect.NewResponse(&querierv1.ProfileTypesResponse{
+		ProfileTypes: []*typesv1.ProfileType{
+			{Name: "process_cpu", SampleType: "cpu", SampleUnit: "nanoseconds", PeriodType: "cpu", PeriodUnit: "nanoseconds"},
+			{Name: "memory", SampleType: "alloc_space", SampleUnit: "bytes", PeriodType: "space", PeriodUnit: "bytes"},
+		},
+	}), nil
+}
+
+func (q *fakeQuerier) LabelValues(_ context.Context, req *connect.Request[typesv1.LabelValuesRequest]) (*connect.Response[typesv1.LabelValuesResponse], error) {
+	q.matchers = req.Msg.Matchers
+	return connect.NewResponse(&typesv1.LabelValuesResponse{Names: q.labelValues[req.Msg.Name]}), nil
+}
+
+// newPyroscopeTestServer serves the datasource lookup for uid "pyroscope-uid",
+// the querier API from q under its proxy path, and everything else from
+// handler.
+func newPyroscopeTestServer(t *testing.T, q *fakeQuerier, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	const proxyPath = "/api/datasources/proxy/uid/pyroscope-uid"
+	mux := http.NewServeMux()
+	querierPath, querierHandler := querierv1connect.NewQuerierServiceHandler(q)
+	mux.Handle(proxyPath+querierPath, http.StripPrefix(proxyPath, querierHandler))
+	mux.HandleFunc("/api/datasources/uid/pyroscope-uid", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"uid": "pyroscope-uid", "name": "Pyroscope", "type": "grafana-pyroscope-datasource"}`))
+	})
+	if handler != nil {
+		mux.HandleFunc("/", handler)
+	}
+	return httptest.NewServer(mux)
+}
+
+func TestListPyroscopeProfileTypes(t *testing.T) {
+	q := &fakeQuerier{labelValues: map[string][]string{
+		profileTypeLabel: {
+			"memory:inuse_objects:count:space:bytes",
+			"process_cpu:cpu:nanoseconds:cpu:nanoseconds",
+		},
+	}}
+	server := newPyroscopeTestServer(t, q, nil)
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("all profile types", func(t *testing.T) {
+		types, err := listPyroscopeProfileTypes(ctx, ListPyroscopeProfileTypesParams{DataSourceUID: "pyroscope-uid"})
+		require.NoError(t, err)
+		assert.Equal(t, []PyroscopeProfileType{
+			{ID: "memory:alloc_space:bytes:space:bytes", SampleType: "alloc_space", SampleUnit: "bytes", PeriodType: "space", PeriodUnit: "bytes"},
+			{ID: "process_cpu:cpu:nanoseconds:cpu:nanoseconds", SampleType: "cpu", SampleUnit: "nanoseconds", PeriodType: "cpu", PeriodUnit: "nanoseconds"},
+		}, types)
+	})
+
+	t.Run("profile types of a service", func(t *testing.T) {
+		types, err := listPyroscopeProfileTypes(ctx, ListPyroscopeProfileTypesParams{
+			DataSourceUID: "pyroscope-uid",
+			Matchers:      `{service_name="checkout"}`,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`{service_name="checkout"}`}, q.matchers)
+		assert.Equal(t, []PyroscopeProfileType{
+			{ID: "memory:inuse_objects:count:space:bytes", SampleType: "inuse_objects", SampleUnit: "count", PeriodType: "space", PeriodUnit: "bytes"},
+			{ID: "process_cpu:cpu:nanoseconds:cpu:nanoseconds", SampleType: "cpu", SampleUnit: "nanoseconds", PeriodType: "cpu", PeriodUnit: "nanoseconds"},
+		}, types)
+	})
+}
+
+func TestParseProfileTypeID(t *testing.T) {
+	assert.Equal(t, PyroscopeProfileType{ID: "custom"}, parseProfileTypeID("custom"))
+	assert.Equal(t, PyroscopeProfileType{
+		ID:         "goroutines:goroutine:count:goroutine:count",
+		SampleType: "goroutine",
+		SampleUnit: "count",
+		PeriodType: "goroutine",
+		PeriodUnit: "count",
+	}, parseProfileTypeID("goroutines:goroutine:count:goroutine:count"))
+}
+
+func TestFetchPyroscopeProfile(t *testing.T) {
+	// main -> handle -> parse -> parse (recursive)
+	//      -> handle -> encode
+	//      -> gc
+	const profile = `{
+		"version": 1,
+		"flamebearer": {
+			"names": ["total", "main", "handle", "parse", "encode", "gc"],
+			"levels": [
+				[0, 100, 0, 0],
+				[0, 90, 5, 1, 0, 10, 10, 5],
+				[5, 85, 5, 2],
+				[5, 50, 20, 3, 0, 30, 30, 4],
+				[5, 30, 30, 3]
+			],
+			"numTicks": 100,
+			"maxSelf": 30
+		},
+		"metadata": {"format": "single", "units": "samples"}
+	}`
+
+	var query url.Values
+	server := newPyroscopeTestServer(t, &fakeQuerier{}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/datasources/proxy/uid/pyroscope-uid/pyroscope/render" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(profile))
+	})
+	defer server.Close()
+	ctx := mockDatasourceCtx(server, nil)
+
+	t.Run("top functions by self", func(t *testing.T) {
+		summary, err := fetchPyroscopeProfile(ctx, FetchPyroscopeProfileParams{
+			DataSourceUID: "pyroscope-uid",
+			ProfileType:   "process_cpu:cpu:nanoseconds:cpu:nanoseconds",
+			Matchers:      `service_name="checkout"`,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `process_cpu:cpu:nanoseconds:cpu:nanoseconds{service_name="checkout"}`, query.Get("query"))
+		assert.Equal(t, "json", query.Get("format"))
+		assert.Equal(t, "total: 100 samples\n"+
+			"self  self%   total  total%  function\n"+
+			"50    50.00%  50     50.00%  parse\n"+
+			"30    30.00%  30     30.00%  encode\n"+
+			"10    10.00%  10     10.00%  gc\n"+
+			"5     5.00%   85     85.00%  handle\n"+
+			"5     5.00%   90     90.00%  main\n", summary)
+	})
+
+	t.Run("top functions by total", func(t *testing.T) {
+		summary, err := fetchPyroscopeProfile(ctx, FetchPyroscopeProfileParams{
+			DataSourceUID: "pyroscope-uid",
+			ProfileType:   "process_cpu:cpu:nanoseconds:cpu:nanoseconds",
+			TopN:          2,
+			SortBy:        "total",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "total: 100 samples\n"+
+			"self  self%  total  total%  function\n"+
+			"5     5.00%  90     90.00%  main\n"+
+			"5     5.00%  85     85.00%  handle\n"+
+			"(truncated: showing 2 of 5 functions)\n", summary)
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		_, err := fetchPyroscopeProfile(ctx, FetchPyroscopeProfileParams{
+			DataSourceUID: "pyroscope-uid",
+			ProfileType:   "process_cpu:cpu:nanoseconds:cpu:nanoseconds",
+			SortBy:        "name",
+		})
+		require.EqualError(t, err, `invalid sort_by "name", must be one of: self, total`)
+	})
+}
+
+func TestPyroscopeDatasourceAccessDenied(t *testing.T) {
+	server := newPyroscopeTestServer(t, &fakeQuerier{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message": "access denied to datasource"}`))
+	})
+	defer server.Close()
+
+	ctx := mockDatasourceCtx(server, map[string]string{"X-Webauth-User": "alice"})
+	_, err := fetchPyroscopeProfile(ctx, FetchPyroscopeProfileParams{
+		DataSourceUID: "pyroscope-uid",
+		ProfileType:   "process_cpu:cpu:nanoseconds:cpu:nanoseconds",
+	})
+	require.Error(t, err)
+	var denied *datasourceAccessDeniedError
+	require.ErrorAs(t, err, &denied)
+	assert.Contains(t, err.Error(), `access denied: the authenticated user (forwarded user "alice") lacks access to datasource "pyroscope-uid": access denied to datasource`)
+	assert.Equal(t, mcpgrafana.ErrKindAuth, mcpgrafana.ErrorKindOf(err))
+}
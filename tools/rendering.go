@@ -92,11 +92,11 @@ func getPanelImage(ctx context.Context, args GetPanelImageParams) (*mcp.CallTool
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
+		body := readErrorBody(resp.Body)
 		if resp.StatusCode == http.StatusNotFound {
 			return nil, fmt.Errorf("image renderer not available. Ensure the Grafana Image Renderer service is installed and configured. See https://grafana.com/docs/grafana/latest/setup-grafana/image-rendering/")
 		}
-		return nil, fmt.Errorf("failed to render image: HTTP %d - %s", resp.StatusCode, string(body))
+		return nil, mcpgrafana.NewHTTPError(resp.StatusCode, fmt.Sprintf("failed to render image: HTTP %d - %s", resp.StatusCode, summarizeErrorBody(body)))
 	}
 
 	// Read the image data
@@ -2,8 +2,13 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strings"
 
+	"github.com/go-openapi/runtime"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
@@ -15,31 +20,150 @@ import (
 var dashboardTypeStr = "dash-db"
 var folderTypeStr = "dash-folder"
 
+// dashboardSortOptions are the sort orders accepted by search_dashboards.
+var dashboardSortOptions = []string{"alpha-asc", "alpha-desc", "views-recent", "errors-recent"}
+
+// usageInsightsSortOptions are the sort orders which only exist on Grafana
+// instances with usage insights, i.e. Grafana Enterprise and Grafana Cloud.
+var usageInsightsSortOptions = []string{"views-recent", "errors-recent"}
+
 type SearchDashboardsParams struct {
-	Query string `json:"query" jsonschema:"description=The query to search for"`
+	Query        string   `json:"query" jsonschema:"description=The query to search for"`
+	Tags         []string `json:"tags,omitempty" jsonschema:"description=Only return dashboards with these tags. Tags are matched case-insensitively"`
+	TagsMatchAll bool     `json:"tagsMatchAll,omitempty" jsonschema:"description=If true\\, only return dashboards that have every tag in 'tags'. If false (default)\\, return dashboards that have any of the tags"`
+	Sort         string   `json:"sort,omitempty" jsonschema:"enum=alpha-asc,enum=alpha-desc,enum=views-recent,enum=errors-recent,description=The order to return dashboards in. Defaults to Grafana's own order. views-recent and errors-recent sort by recent views or errors and need Grafana Enterprise or Grafana Cloud"`
+}
+
+func (p SearchDashboardsParams) validate() error {
+	if p.Sort != "" && !slices.Contains(dashboardSortOptions, p.Sort) {
+		return fmt.Errorf("invalid sort %q, must be one of: %s", p.Sort, strings.Join(dashboardSortOptions, ", "))
+	}
+	return nil
 }
 
 func searchDashboards(ctx context.Context, args SearchDashboardsParams) (models.HitList, error) {
+	if err := args.validate(); err != nil {
+		return nil, fmt.Errorf("search dashboards: %w", err)
+	}
+
+	tags := normalizeTags(args.Tags)
+	if args.TagsMatchAll || len(tags) <= 1 {
+		hits, err := searchDashboardHits(ctx, args, tags)
+		if err != nil {
+			return nil, err
+		}
+		return filterHitsByTags(hits, tags, args.TagsMatchAll), nil
+	}
+
+	// Grafana only supports AND semantics for repeated tag params, so OR
+	// matching runs one search per tag and merges the hits by UID.
+	var hits models.HitList
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tagHits, err := searchDashboardHits(ctx, args, []string{tag})
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range tagHits {
+			if hit == nil || seen[hit.UID] {
+				continue
+			}
+			seen[hit.UID] = true
+			hits = append(hits, hit)
+		}
+	}
+	return filterHitsByTags(hits, tags, false), nil
+}
+
+// searchDashboardHits runs a single search for args, matching every one of
+// the given tags.
+func searchDashboardHits(ctx context.Context, args SearchDashboardsParams, tags []string) (models.HitList, error) {
 	c := mcpgrafana.GrafanaClientFromContext(ctx)
 	params := search.NewSearchParamsWithContext(ctx)
+	if args.Sort != "" {
+		params.SetSort(&args.Sort)
+	}
 	if args.Query != "" {
 		params.SetQuery(&args.Query)
 		params.SetType(&dashboardTypeStr)
 	}
+	if len(tags) > 0 {
+		params.SetType(&dashboardTypeStr)
+		params.SetTag(tags)
+	}
 	search, err := c.Search.Search(params)
 	if err != nil {
+		if slices.Contains(usageInsightsSortOptions, args.Sort) && isUnknownSortError(err) {
+			return nil, fmt.Errorf("search dashboards sorted by %s, which needs usage insights from Grafana Enterprise or Grafana Cloud (try alpha-asc or alpha-desc instead): %w", args.Sort, err)
+		}
 		return nil, fmt.Errorf("search dashboards for %+v: %w", c, err)
 	}
 	return search.Payload, nil
 }
 
+// isUnknownSortError reports whether a search failed because Grafana doesn't
+// know the requested sort, which it rejects with a 400 or 422. Other errors,
+// such as authentication failures, are unrelated to the sort.
+func isUnknownSortError(err error) bool {
+	var unprocessable *search.SearchUnprocessableEntity
+	if errors.As(err, &unprocessable) {
+		return true
+	}
+	var apiErr *runtime.APIError
+	return errors.As(err, &apiErr) && apiErr.IsCode(http.StatusBadRequest)
+}
+
+// normalizeTags trims whitespace from the given tags and drops empty ones.
+func normalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// filterHitsByTags returns the hits that have all (matchAll) or any of the
+// given tags, comparing tags case-insensitively. If no tags are given the
+// hits are returned unchanged.
+func filterHitsByTags(hits models.HitList, tags []string, matchAll bool) models.HitList {
+	if len(tags) == 0 {
+		return hits
+	}
+	filtered := make(models.HitList, 0, len(hits))
+	for _, hit := range hits {
+		if hit == nil {
+			continue
+		}
+		hitTags := make(map[string]struct{}, len(hit.Tags))
+		for _, tag := range hit.Tags {
+			hitTags[strings.ToLower(tag)] = struct{}{}
+		}
+		matched := 0
+		for _, tag := range tags {
+			if _, ok := hitTags[strings.ToLower(tag)]; ok {
+				matched++
+			}
+		}
+		if (matchAll && matched == len(tags)) || (!matchAll && matched > 0) {
+			filtered = append(filtered, hit)
+		}
+	}
+	return filtered
+}
+
 var SearchDashboards = mcpgrafana.MustTool(
 	"search_dashboards",
-	"Search for Grafana dashboards by a query string. Returns a list of matching dashboards with details like title, UID, folder, tags, and URL.",
+	"Search for Grafana dashboards by a query string and/or tags. Tags are matched with OR semantics by default; set tagsMatchAll to require every tag. Set sort to 'alpha-asc' or 'alpha-desc' to sort by title, or, on Grafana Enterprise and Grafana Cloud, to 'views-recent' or 'errors-recent'. Returns a list of matching dashboards with details like title, UID, folder, tags, and URL.",
 	searchDashboards,
 	mcp.WithTitleAnnotation("Search dashboards"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
+	mcpgrafana.WithExamples(
+		map[string]any{"query": "kubernetes"},
+		map[string]any{"tags": []string{"slo", "production"}, "tagsMatchAll": true, "sort": "views-recent"},
+	),
 )
 
 type SearchFoldersParams struct {
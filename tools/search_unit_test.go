@@ -0,0 +1,186 @@
+//go:build unit
+
+package tools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSearchTestServer(t *testing.T, hits models.HitList, assertQuery func(r *http.Request)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/search", r.URL.Path)
+		if assertQuery != nil {
+			assertQuery(r)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(hits)
+	}))
+}
+
+func searchTestHits() models.HitList {
+	return models.HitList{
+		{UID: "payments-prod", Title: "Payments Prod", Tags: []string{"team:payments", "env:prod"}},
+		{UID: "payments-dev", Title: "Payments Dev", Tags: []string{"team:payments", "env:dev"}},
+		{UID: "search-prod", Title: "Search Prod", Tags: []string{"Team:Search", "ENV:PROD"}},
+		{UID: "untagged", Title: "Untagged"},
+	}
+}
+
+func hitUIDs(hits models.HitList) []string {
+	uids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		uids = append(uids, hit.UID)
+	}
+	return uids
+}
+
+func TestSearchDashboards_Tags(t *testing.T) {
+	t.Run("empty tags returns all results and sends no tag params", func(t *testing.T) {
+		server := newSearchTestServer(t, searchTestHits(), func(r *http.Request) {
+			assert.Empty(t, r.URL.Query()["tag"])
+		})
+		defer server.Close()
+
+		hits, err := searchDashboards(mockCtxWithClient(server), SearchDashboardsParams{Tags: []string{"", "  "}})
+		require.NoError(t, err)
+		assert.Len(t, hits, 4)
+	})
+
+	t.Run("single tag is forwarded and filtered", func(t *testing.T) {
+		server := newSearchTestServer(t, searchTestHits(), func(r *http.Request) {
+			assert.Equal(t, []string{"env:prod"}, r.URL.Query()["tag"])
+			assert.Equal(t, "dash-db", r.URL.Query().Get("type"))
+		})
+		defer server.Close()
+
+		hits, err := searchDashboards(mockCtxWithClient(server), SearchDashboardsParams{Tags: []string{"env:prod"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"payments-prod", "search-prod"}, hitUIDs(hits))
+	})
+
+	t.Run("match all forwards every tag and requires all of them", func(t *testing.T) {
+		server := newSearchTestServer(t, searchTestHits(), func(r *http.Request) {
+			assert.Equal(t, []string{"team:payments", "env:prod"}, r.URL.Query()["tag"])
+		})
+		defer server.Close()
+
+		hits, err := searchDashboards(mockCtxWithClient(server), SearchDashboardsParams{
+			Tags:         []string{"team:payments", "env:prod"},
+			TagsMatchAll: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"payments-prod"}, hitUIDs(hits))
+	})
+
+	t.Run("match any searches each tag and merges the matches", func(t *testing.T) {
+		// The server applies the tag filter, as Grafana does, returning
+		// hits which have every requested tag.
+		var searchedTags [][]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tags := r.URL.Query()["tag"]
+			searchedTags = append(searchedTags, tags)
+			hits := models.HitList{}
+			for _, hit := range searchTestHits() {
+				if len(filterHitsByTags(models.HitList{hit}, tags, true)) == 1 {
+					hits = append(hits, hit)
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(hits)
+		}))
+		defer server.Close()
+
+		hits, err := searchDashboards(mockCtxWithClient(server), SearchDashboardsParams{
+			Tags: []string{"env:dev", "team:payments"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"env:dev"}, {"team:payments"}}, searchedTags)
+		assert.Equal(t, []string{"payments-dev", "payments-prod"}, hitUIDs(hits))
+	})
+
+	t.Run("mixed-case tags are normalized", func(t *testing.T) {
+		server := newSearchTestServer(t, searchTestHits(), nil)
+		defer server.Close()
+
+		hits, err := searchDashboards(mockCtxWithClient(server), SearchDashboardsParams{
+			Tags:         []string{" TEAM:search ", "Env:Prod"},
+			TagsMatchAll: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"search-prod"}, hitUIDs(hits))
+	})
+}
+
+func TestSearchDashboards_Sort(t *testing.T) {
+	t.Run("alpha sort is forwarded", func(t *testing.T) {
+		for _, sort := range []string{"alpha-asc", "alpha-desc"} {
+			hits := searchTestHits()
+			if sort == "alpha-desc" {
+				slices.Reverse(hits)
+			}
+			server := newSearchTestServer(t, hits, func(r *http.Request) {
+				assert.Equal(t, sort, r.URL.Query().Get("sort"))
+			})
+
+			result, err := searchDashboards(mockCtxWithClient(server), SearchDashboardsParams{Sort: sort})
+			require.NoError(t, err)
+			assert.Equal(t, hitUIDs(hits), hitUIDs(result))
+			server.Close()
+		}
+	})
+
+	t.Run("no sort sends no sort param", func(t *testing.T) {
+		server := newSearchTestServer(t, searchTestHits(), func(r *http.Request) {
+			assert.False(t, r.URL.Query().Has("sort"))
+		})
+		defer server.Close()
+
+		_, err := searchDashboards(mockCtxWithClient(server), SearchDashboardsParams{})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid sort lists the valid sorts", func(t *testing.T) {
+		server := newSearchTestServer(t, searchTestHits(), func(r *http.Request) {
+			t.Error("no request should be made for an invalid sort")
+		})
+		defer server.Close()
+
+		_, err := searchDashboards(mockCtxWithClient(server), SearchDashboardsParams{Sort: "newest"})
+		require.EqualError(t, err, `search dashboards: invalid sort "newest", must be one of: alpha-asc, alpha-desc, views-recent, errors-recent`)
+	})
+
+	t.Run("usage insights sorts explain why they failed", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message": "sort option not found"}`))
+		}))
+		defer server.Close()
+
+		_, err := searchDashboards(mockCtxWithClient(server), SearchDashboardsParams{Sort: "views-recent"})
+		require.ErrorContains(t, err, "search dashboards sorted by views-recent, which needs usage insights from Grafana Enterprise or Grafana Cloud")
+	})
+
+	t.Run("other errors with usage insights sorts are not blamed on the sort", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"message": "database is locked"}`))
+		}))
+		defer server.Close()
+
+		_, err := searchDashboards(mockCtxWithClient(server), SearchDashboardsParams{Sort: "errors-recent"})
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "usage insights")
+	})
+}
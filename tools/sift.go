@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -169,13 +170,22 @@ func getSiftInvestigation(ctx context.Context, args GetSiftInvestigationParams)
 		return nil, fmt.Errorf("getting investigation: %w", err)
 	}
 
+	// Analyses only have results once the investigation is finished.
+	if investigation.Status == investigationStatusFinished {
+		analyses, err := client.getSiftAnalyses(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("getting analyses: %w", err)
+		}
+		investigation.Analyses.Items = analyses
+	}
+
 	return investigation, nil
 }
 
 // GetSiftInvestigation is a tool for retrieving an existing investigation
 var GetSiftInvestigation = mcpgrafana.MustTool(
 	"get_sift_investigation",
-	"Retrieves an existing Sift investigation by its UUID. The ID should be provided as a string in UUID format (e.g. '02adab7c-bf5b-45f2-9459-d71a2c29e11b').",
+	"Retrieves an existing Sift investigation by its UUID. The ID should be provided as a string in UUID format (e.g. '02adab7c-bf5b-45f2-9459-d71a2c29e11b'). The status of the investigation is one of pending, running, finished or failed; failed investigations include a failureReason. Once finished, the analyses of the investigation and their results are included, so this tool can be polled until the investigation completes.",
 	getSiftInvestigation,
 	mcp.WithTitleAnnotation("Get Sift investigation"),
 	mcp.WithIdempotentHintAnnotation(true),
@@ -252,19 +262,34 @@ func listSiftInvestigations(ctx context.Context, args ListSiftInvestigationsPara
 // ListSiftInvestigations is a tool for retrieving a list of investigations
 var ListSiftInvestigations = mcpgrafana.MustTool(
 	"list_sift_investigations",
-	"Retrieves a list of Sift investigations with an optional limit. If no limit is specified, defaults to 10 investigations.",
+	"Retrieves a list of recent Sift investigations with an optional limit, including their status. If no limit is specified, defaults to 10 investigations. Use get_sift_investigation to fetch the analyses of a finished investigation.",
 	listSiftInvestigations,
 	mcp.WithTitleAnnotation("List Sift investigations"),
 	mcp.WithIdempotentHintAnnotation(true),
 	mcp.WithReadOnlyHintAnnotation(true),
 )
 
+// parseSiftTimeRange parses the optional start and end of an investigation.
+// Unset times are left zero, for the defaults to be applied when the
+// investigation is created.
+func parseSiftTimeRange(startTime, endTime string) (time.Time, time.Time, error) {
+	start, err := timeOrDefault(startTime, time.Time{})
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parsing start time: %w", err)
+	}
+	end, err := timeOrDefault(endTime, time.Time{})
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parsing end time: %w", err)
+	}
+	return start, end, nil
+}
+
 // FindErrorPatternLogsParams defines the parameters for running an ErrorPatternLogs check
 type FindErrorPatternLogsParams struct {
 	Name   string            `json:"name" jsonschema:"required,description=The name of the investigation"`
 	Labels map[string]string `json:"labels" jsonschema:"required,description=Labels to scope the analysis"`
-	Start  time.Time         `json:"start,omitempty" jsonschema:"description=Start time for the investigation. Defaults to 30 minutes ago if not specified."`
-	End    time.Time         `json:"end,omitempty" jsonschema:"description=End time for the investigation. Defaults to now if not specified."`
+	Start  string            `json:"start,omitempty" jsonschema:"description=Start time for the investigation as RFC3339\\, Unix seconds or relative to now (e.g. 'now-1h'). Defaults to 30 minutes ago if not specified."`
+	End    string            `json:"end,omitempty" jsonschema:"description=End time for the investigation as RFC3339\\, Unix seconds or relative to now. Defaults to now if not specified."`
 }
 
 // findErrorPatternLogs creates an investigation with ErrorPatternLogs check, waits for it to complete, and returns the analysis
@@ -274,34 +299,138 @@ func findErrorPatternLogs(ctx context.Context, args FindErrorPatternLogsParams)
 		return nil, fmt.Errorf("creating Sift client: %w", err)
 	}
 
-	// Create the investigation request with ErrorPatternLogs check
+	created, err := createErrorPatternLogsInvestigation(ctx, client, args.Name, args.Labels, args.Start, args.End)
+	if err != nil {
+		return nil, err
+	}
+	completedInvestigation, err := client.waitForSiftInvestigation(ctx, created.ID, 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	return errorPatternLogsAnalysis(ctx, client, completedInvestigation)
+}
+
+// FindErrorPatternLogs is a tool for running an ErrorPatternLogs check
+var FindErrorPatternLogs = mcpgrafana.MustMutatingTool(
+	"find_error_pattern_logs",
+	"Searches Loki logs for elevated error patterns compared to the last day's average, waits for the analysis to complete, and returns the results including any patterns found.",
+	findErrorPatternLogs,
+	mcp.WithTitleAnnotation("Find error patterns in logs"),
+)
+
+// RunSiftErrorPatternLogsParams defines the parameters for running an ErrorPatternLogs check with a bounded wait
+type RunSiftErrorPatternLogsParams struct {
+	Name           string            `json:"name" jsonschema:"required,description=The name of the investigation"`
+	Labels         map[string]string `json:"labels" jsonschema:"required,description=Labels to scope the analysis"`
+	Start          string            `json:"start,omitempty" jsonschema:"description=Start time for the investigation as RFC3339\\, Unix seconds or relative to now (e.g. 'now-1h'). Defaults to 30 minutes ago if not specified."`
+	End            string            `json:"end,omitempty" jsonschema:"description=End time for the investigation as RFC3339\\, Unix seconds or relative to now. Defaults to now if not specified."`
+	MaxWaitSeconds int               `json:"maxWaitSeconds,omitempty" jsonschema:"description=How long to wait for the investigation to finish before returning its ID to poll later. Defaults to 60 seconds."`
+}
+
+// SiftErrorPatternLogsResult is the outcome of an ErrorPatternLogs check. If
+// the investigation didn't finish in time only its ID and status are set.
+type SiftErrorPatternLogsResult struct {
+	InvestigationID uuid.UUID           `json:"investigationId"`
+	Status          investigationStatus `json:"status"`
+	Message         string              `json:"message,omitempty"`
+	Patterns        []any               `json:"patterns,omitempty"`
+	Analysis        *analysis           `json:"analysis,omitempty"`
+}
+
+// runSiftErrorPatternLogs creates an investigation with ErrorPatternLogs check, waits a bounded time for it to complete, and returns the error patterns found
+func runSiftErrorPatternLogs(ctx context.Context, args RunSiftErrorPatternLogsParams) (*SiftErrorPatternLogsResult, error) {
+	if args.MaxWaitSeconds < 0 {
+		return nil, fmt.Errorf("maxWaitSeconds must not be negative")
+	}
+	maxWait := time.Duration(intOrDefault(args.MaxWaitSeconds, 60)) * time.Second
+
+	client, err := siftClientFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating Sift client: %w", err)
+	}
+
+	created, err := createErrorPatternLogsInvestigation(ctx, client, args.Name, args.Labels, args.Start, args.End)
+	if err != nil {
+		return nil, err
+	}
+
+	completedInvestigation, err := client.waitForSiftInvestigation(ctx, created.ID, maxWait)
+	if errors.Is(err, errSiftInvestigationTimeout) {
+		status := created.Status
+		if completedInvestigation != nil {
+			status = completedInvestigation.Status
+		}
+		return &SiftErrorPatternLogsResult{
+			InvestigationID: created.ID,
+			Status:          status,
+			Message:         fmt.Sprintf("The investigation did not finish within %s. Poll it with get_sift_investigation.", maxWait),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	errorPatternLogs, err := errorPatternLogsAnalysis(ctx, client, completedInvestigation)
+	if err != nil {
+		return nil, err
+	}
+
+	// Details has no patterns when none were found.
+	patterns, _ := errorPatternLogs.Result.Details["patterns"].([]any)
+	return &SiftErrorPatternLogsResult{
+		InvestigationID: completedInvestigation.ID,
+		Status:          completedInvestigation.Status,
+		Message:         errorPatternLogs.Result.Message,
+		Patterns:        patterns,
+		Analysis:        errorPatternLogs,
+	}, nil
+}
+
+// RunSiftErrorPatternLogs is a tool for running an ErrorPatternLogs check with a bounded wait
+var RunSiftErrorPatternLogs = mcpgrafana.MustMutatingTool(
+	"run_sift_error_pattern_logs",
+	"Runs a Sift investigation searching Loki logs for elevated error patterns compared to the last day's average, scoped to the given labels and time range. Waits up to maxWaitSeconds (60 by default) for the investigation to finish and returns the error patterns found along with example log lines. If the investigation is still running after that, returns its investigationId so it can be polled with get_sift_investigation.",
+	runSiftErrorPatternLogs,
+	mcp.WithTitleAnnotation("Run Sift error pattern check"),
+)
+
+// createErrorPatternLogsInvestigation creates an investigation with the ErrorPatternLogs check.
+func createErrorPatternLogsInvestigation(ctx context.Context, client *siftClient, name string, labels map[string]string, startTime, endTime string) (*Investigation, error) {
+	start, end, err := parseSiftTimeRange(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
 	requestData := investigationRequest{
-		Labels: args.Labels,
-		Start:  args.Start,
-		End:    args.End,
+		Labels: labels,
+		Start:  start,
+		End:    end,
 		Checks: []string{string(checkTypeErrorPatternLogs)},
 	}
 
 	investigation := &Investigation{
-		Name:       args.Name,
+		Name:       name,
 		GrafanaURL: client.url,
 		Status:     investigationStatusPending,
 	}
 
-	// Create the investigation and wait for it to complete
-	completedInvestigation, err := client.createSiftInvestigation(ctx, investigation, requestData)
+	created, err := client.createSiftInvestigation(ctx, investigation, requestData)
 	if err != nil {
 		return nil, fmt.Errorf("creating investigation: %w", err)
 	}
+	return created, nil
+}
 
-	// Get all analyses from the completed investigation
-	slog.Debug("Getting analyses", "investigation_id", completedInvestigation.ID)
-	analyses, err := client.getSiftAnalyses(ctx, completedInvestigation.ID)
+// errorPatternLogsAnalysis returns the ErrorPatternLogs analysis of a
+// completed investigation, with example log lines added to each pattern.
+func errorPatternLogsAnalysis(ctx context.Context, client *siftClient, investigation *Investigation) (*analysis, error) {
+	slog.Debug("Getting analyses", "investigation_id", investigation.ID)
+	analyses, err := client.getSiftAnalyses(ctx, investigation.ID)
 	if err != nil {
 		return nil, fmt.Errorf("getting analyses: %w", err)
 	}
 
-	// Find the ErrorPatternLogs analysis
 	var errorPatternLogsAnalysis *analysis
 	for i := range analyses {
 		if analyses[i].Name == string(checkTypeErrorPatternLogs) {
@@ -311,17 +440,14 @@ func findErrorPatternLogs(ctx context.Context, args FindErrorPatternLogsParams)
 	}
 
 	if errorPatternLogsAnalysis == nil {
-		return nil, fmt.Errorf("ErrorPatternLogs analysis not found in investigation %s", completedInvestigation.ID)
+		return nil, fmt.Errorf("ErrorPatternLogs analysis not found in investigation %s", investigation.ID)
 	}
 	slog.Debug("Found ErrorPatternLogs analysis", "analysis_id", errorPatternLogsAnalysis.ID)
 
-	datasourceUID := completedInvestigation.Datasources.LokiDatasource.UID
+	datasourceUID := investigation.Datasources.LokiDatasource.UID
 
-	if errorPatternLogsAnalysis.Result.Details == nil {
-		// No patterns found, return the analysis without examples
-		return errorPatternLogsAnalysis, nil
-	}
-	for _, pattern := range errorPatternLogsAnalysis.Result.Details["patterns"].([]any) {
+	patterns, _ := errorPatternLogsAnalysis.Result.Details["patterns"].([]any)
+	for _, pattern := range patterns {
 		patternMap, ok := pattern.(map[string]any)
 		if !ok {
 			continue
@@ -336,21 +462,12 @@ func findErrorPatternLogs(ctx context.Context, args FindErrorPatternLogsParams)
 	return errorPatternLogsAnalysis, nil
 }
 
-// FindErrorPatternLogs is a tool for running an ErrorPatternLogs check
-var FindErrorPatternLogs = mcpgrafana.MustTool(
-	"find_error_pattern_logs",
-	"Searches Loki logs for elevated error patterns compared to the last day's average, waits for the analysis to complete, and returns the results including any patterns found.",
-	findErrorPatternLogs,
-	mcp.WithTitleAnnotation("Find error patterns in logs"),
-	mcp.WithReadOnlyHintAnnotation(true),
-)
-
 // FindSlowRequestsParams defines the parameters for running an SlowRequests check
 type FindSlowRequestsParams struct {
 	Name   string            `json:"name" jsonschema:"required,description=The name of the investigation"`
 	Labels map[string]string `json:"labels" jsonschema:"required,description=Labels to scope the analysis"`
-	Start  time.Time         `json:"start,omitempty" jsonschema:"description=Start time for the investigation. Defaults to 30 minutes ago if not specified."`
-	End    time.Time         `json:"end,omitempty" jsonschema:"description=End time for the investigation. Defaults to now if not specified."`
+	Start  string            `json:"start,omitempty" jsonschema:"description=Start time for the investigation as RFC3339\\, Unix seconds or relative to now (e.g. 'now-1h'). Defaults to 30 minutes ago if not specified."`
+	End    string            `json:"end,omitempty" jsonschema:"description=End time for the investigation as RFC3339\\, Unix seconds or relative to now. Defaults to now if not specified."`
 }
 
 // findSlowRequests creates an investigation with SlowRequests check, waits for it to complete, and returns the analysis
@@ -360,11 +477,16 @@ func findSlowRequests(ctx context.Context, args FindSlowRequestsParams) (*analys
 		return nil, fmt.Errorf("creating Sift client: %w", err)
 	}
 
+	start, end, err := parseSiftTimeRange(args.Start, args.End)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create the investigation request with SlowRequests check
 	requestData := investigationRequest{
 		Labels: args.Labels,
-		Start:  args.Start,
-		End:    args.End,
+		Start:  start,
+		End:    end,
 		Checks: []string{string(checkTypeSlowRequests)},
 	}
 
@@ -375,10 +497,14 @@ func findSlowRequests(ctx context.Context, args FindSlowRequestsParams) (*analys
 	}
 
 	// Create the investigation and wait for it to complete
-	completedInvestigation, err := client.createSiftInvestigation(ctx, investigation, requestData)
+	created, err := client.createSiftInvestigation(ctx, investigation, requestData)
 	if err != nil {
 		return nil, fmt.Errorf("creating investigation: %w", err)
 	}
+	completedInvestigation, err := client.waitForSiftInvestigation(ctx, created.ID, 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get all analyses from the completed investigation
 	analyses, err := client.getSiftAnalyses(ctx, completedInvestigation.ID)
@@ -418,6 +544,7 @@ func AddSiftTools(mcp *server.MCPServer, enableWriteTools bool) {
 	ListSiftInvestigations.Register(mcp)
 	if enableWriteTools {
 		FindErrorPatternLogs.Register(mcp)
+		RunSiftErrorPatternLogs.Register(mcp)
 		FindSlowRequests.Register(mcp)
 	}
 }
@@ -450,8 +577,8 @@ func (c *siftClient) makeRequest(ctx context.Context, method, path string, body
 
 	// Check for non-200 status code (matching Loki client's logic)
 	if response.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(response.Body) // Read full body on error
-		return nil, fmt.Errorf("API request returned status code %d: %s", response.StatusCode, string(bodyBytes))
+		bodyBytes := readErrorBody(response.Body)
+		return nil, mcpgrafana.NewHTTPError(response.StatusCode, fmt.Sprintf("API request returned status code %d: %s", response.StatusCode, summarizeErrorBody(bodyBytes)))
 	}
 
 	// Read the response body with a limit to prevent memory issues
@@ -528,24 +655,40 @@ func (c *siftClient) createSiftInvestigation(ctx context.Context, investigation
 		return nil, fmt.Errorf("failed to unmarshal response body: %w. body: %s", err, buf)
 	}
 
-	// Poll for investigation completion
-	ticker := time.NewTicker(5 * time.Second)
+	return &investigationResponse.Data, nil
+}
+
+// siftPollInterval is how often investigations are polled while waiting for
+// them to complete.
+var siftPollInterval = 5 * time.Second
+
+// errSiftInvestigationTimeout is returned when an investigation doesn't
+// complete within the time waited for it.
+var errSiftInvestigationTimeout = errors.New("timeout waiting for investigation completion")
+
+// waitForSiftInvestigation polls an investigation until it finishes, fails
+// or the timeout elapses. On timeout, the last polled state of the
+// investigation is returned along with errSiftInvestigationTimeout.
+func (c *siftClient) waitForSiftInvestigation(ctx context.Context, id uuid.UUID, timeout time.Duration) (*Investigation, error) {
+	ticker := time.NewTicker(siftPollInterval)
 	defer ticker.Stop()
 
-	timeout := time.After(5 * time.Minute)
+	deadline := time.After(timeout)
 
+	var last *Investigation
 	for {
 		select {
 		case <-ctx.Done():
 			return nil, fmt.Errorf("context cancelled while waiting for investigation completion")
-		case <-timeout:
-			return nil, fmt.Errorf("timeout waiting for investigation completion after 5 minutes")
+		case <-deadline:
+			return last, fmt.Errorf("%w after %s", errSiftInvestigationTimeout, timeout)
 		case <-ticker.C:
-			slog.Debug("Polling investigation status", "investigation_id", investigationResponse.Data.ID)
-			investigation, err := c.getSiftInvestigation(ctx, investigationResponse.Data.ID)
+			slog.Debug("Polling investigation status", "investigation_id", id)
+			investigation, err := c.getSiftInvestigation(ctx, id)
 			if err != nil {
 				return nil, err
 			}
+			last = investigation
 
 			if investigation.Status == investigationStatusFailed {
 				return nil, fmt.Errorf("investigation failed: %s", investigation.FailureReason)
@@ -624,7 +767,7 @@ func (c *siftClient) listSiftInvestigations(ctx context.Context, limit int) ([]I
 
 func fetchErrorPatternLogExamples(ctx context.Context, patternMap map[string]any, datasourceUID string) ([]string, error) {
 	query, _ := patternMap["query"].(string)
-	logEntries, err := queryLokiLogs(ctx, QueryLokiLogsParams{
+	result, err := queryLokiLogs(ctx, QueryLokiLogsParams{
 		DatasourceUID: datasourceUID,
 		LogQL:         query,
 		Limit:         errorPatternLogExampleLimit,
@@ -633,7 +776,7 @@ func fetchErrorPatternLogExamples(ctx context.Context, patternMap map[string]any
 		return nil, fmt.Errorf("querying Loki: %w", err)
 	}
 	var examples []string
-	for _, entry := range logEntries {
+	for _, entry := range result.Entries {
 		if entry.Line != "" {
 			examples = append(examples, entry.Line)
 		}
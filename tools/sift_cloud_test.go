@@ -125,8 +125,8 @@ func TestCloudSiftInvestigations(t *testing.T) {
 				"cluster":   "dev-eu-west-2",
 				"slug":      "mcptests",
 			},
-			Start: time.Now().Add(-5 * time.Minute),
-			End:   time.Now(),
+			Start: time.Now().Add(-5 * time.Minute).Format(time.RFC3339),
+			End:   time.Now().Format(time.RFC3339),
 		})
 		require.NoError(t, err, "Should not error when finding error patterns")
 		assert.NotNil(t, analysis, "Result should not be nil")
@@ -136,4 +136,26 @@ func TestCloudSiftInvestigations(t *testing.T) {
 		assert.NotEmpty(t, analysis.InvestigationID, "Analysis should have an investigation ID")
 		assert.NotEmpty(t, analysis.Result.Message, "Analysis  should have a message")
 	})
+
+	t.Run("run sift error pattern logs", func(t *testing.T) {
+		result, err := runSiftErrorPatternLogs(ctx, RunSiftErrorPatternLogsParams{
+			Name: "Test Sift",
+			Labels: map[string]string{
+				"namespace": "hosted-grafana",
+				"cluster":   "dev-eu-west-2",
+				"slug":      "mcptests",
+			},
+			Start: time.Now().Add(-5 * time.Minute).Format(time.RFC3339),
+			End:   time.Now().Format(time.RFC3339),
+		})
+		require.NoError(t, err, "Should not error when finding error patterns")
+		require.NotNil(t, result, "Result should not be nil")
+		require.NotNil(t, result.Analysis, "Investigation should finish in time")
+		analysis := result.Analysis
+
+		// Verify all required fields are present
+		assert.NotEmpty(t, analysis.Name, "Analysis should have a name")
+		assert.NotEmpty(t, analysis.InvestigationID, "Analysis should have an investigation ID")
+		assert.NotEmpty(t, analysis.Result.Message, "Analysis  should have a message")
+	})
 }
@@ -0,0 +1,214 @@
+//go:build unit
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	mcpgrafana "github.com/grafana/mcp-grafana"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const siftInvestigationsPath = "/api/plugins/grafana-ml-app/resources/sift/api/v1/investigations"
+
+func TestGetSiftInvestigation(t *testing.T) {
+	pollingID := uuid.MustParse("02adab7c-bf5b-45f2-9459-d71a2c29e11b")
+	failedID := uuid.MustParse("6b1f8a2e-4c59-4d39-9a3b-1f3f4e5d6c7b")
+	analysisID := uuid.MustParse("9d3c5e1a-2b4f-4a6c-8d7e-0f1a2b3c4d5e")
+
+	// The polled investigation moves through a status on every request.
+	statuses := []investigationStatus{investigationStatusPending, investigationStatusRunning, investigationStatusFinished}
+	polls := 0
+	analysesRequests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case fmt.Sprintf("%s/%s", siftInvestigationsPath, pollingID):
+			status := statuses[min(polls, len(statuses)-1)]
+			polls++
+			_, _ = fmt.Fprintf(w, `{"status": "success", "data": {"id": %q, "name": "checkout errors", "status": %q}}`, pollingID, status)
+		case fmt.Sprintf("%s/%s/analyses", siftInvestigationsPath, pollingID):
+			analysesRequests++
+			_, _ = fmt.Fprintf(w, `{"status": "success", "data": [{
+				"id": %q,
+				"investigationId": %q,
+				"name": "ErrorPatternLogs",
+				"title": "Error patterns in logs",
+				"status": "finished",
+				"result": {"successful": true, "interesting": true, "message": "Found 2 elevated error patterns"}
+			}]}`, analysisID, pollingID)
+		case fmt.Sprintf("%s/%s", siftInvestigationsPath, failedID):
+			_, _ = fmt.Fprintf(w, `{"status": "success", "data": {"id": %q, "name": "broken", "status": "failed", "failureReason": "no datasources found"}}`, failedID)
+		case siftInvestigationsPath:
+			assert.Equal(t, "2", r.URL.Query().Get("limit"))
+			_, _ = fmt.Fprintf(w, `{"status": "success", "data": [
+				{"id": %q, "name": "checkout errors", "status": "running"},
+				{"id": %q, "name": "broken", "status": "failed", "failureReason": "no datasources found"}
+			]}`, pollingID, failedID)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status": "error", "error": "investigation not found"}`))
+		}
+	}))
+	defer server.Close()
+	ctx := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{URL: server.URL, APIKey: "test"})
+
+	t.Run("polling until finished", func(t *testing.T) {
+		for _, want := range []investigationStatus{investigationStatusPending, investigationStatusRunning} {
+			investigation, err := getSiftInvestigation(ctx, GetSiftInvestigationParams{ID: pollingID.String()})
+			require.NoError(t, err)
+			assert.Equal(t, want, investigation.Status)
+			assert.Empty(t, investigation.Analyses.Items)
+		}
+		assert.Zero(t, analysesRequests, "analyses are only fetched once the investigation is finished")
+
+		investigation, err := getSiftInvestigation(ctx, GetSiftInvestigationParams{ID: pollingID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, investigationStatusFinished, investigation.Status)
+		require.Len(t, investigation.Analyses.Items, 1)
+		assert.Equal(t, analysisID, investigation.Analyses.Items[0].ID)
+		assert.Equal(t, "ErrorPatternLogs", investigation.Analyses.Items[0].Name)
+		assert.True(t, investigation.Analyses.Items[0].Result.Interesting)
+		assert.Equal(t, "Found 2 elevated error patterns", investigation.Analyses.Items[0].Result.Message)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		investigation, err := getSiftInvestigation(ctx, GetSiftInvestigationParams{ID: failedID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, investigationStatusFailed, investigation.Status)
+		assert.Equal(t, "no datasources found", investigation.FailureReason)
+		assert.Empty(t, investigation.Analyses.Items)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		_, err := getSiftInvestigation(ctx, GetSiftInvestigationParams{ID: "not-a-uuid"})
+		require.ErrorContains(t, err, "invalid investigation ID format")
+	})
+
+	t.Run("list recent investigations", func(t *testing.T) {
+		investigations, err := listSiftInvestigations(ctx, ListSiftInvestigationsParams{Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, investigations, 2)
+		assert.Equal(t, investigationStatusRunning, investigations[0].Status)
+		assert.Equal(t, investigationStatusFailed, investigations[1].Status)
+		assert.Equal(t, "no datasources found", investigations[1].FailureReason)
+	})
+}
+
+func TestRunSiftErrorPatternLogs(t *testing.T) {
+	defer func(interval time.Duration) { siftPollInterval = interval }(siftPollInterval)
+	siftPollInterval = 10 * time.Millisecond
+
+	investigationID := uuid.MustParse("02adab7c-bf5b-45f2-9459-d71a2c29e11b")
+
+	// newServer serves an investigation that is running for the given
+	// number of polls before finishing.
+	newServer := func(t *testing.T, runningPolls int) *httptest.Server {
+		polls := 0
+		return newLokiTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == siftInvestigationsPath:
+				var payload struct {
+					Name        string               `json:"name"`
+					RequestData investigationRequest `json:"requestData"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+				assert.Equal(t, "checkout errors", payload.Name)
+				assert.Equal(t, map[string]string{"service": "checkout"}, payload.RequestData.Labels)
+				assert.Equal(t, []string{"ErrorPatternLogs"}, payload.RequestData.Checks)
+				_, _ = fmt.Fprintf(w, `{"status": "success", "data": {"id": %q, "status": "pending"}}`, investigationID)
+			case r.URL.Path == fmt.Sprintf("%s/%s", siftInvestigationsPath, investigationID):
+				status := investigationStatusRunning
+				if polls >= runningPolls {
+					status = investigationStatusFinished
+				}
+				polls++
+				_, _ = fmt.Fprintf(w, `{"status": "success", "data": {"id": %q, "status": %q, "datasources": {"lokiDatasource": {"uid": "loki-uid"}}}}`, investigationID, status)
+			case r.URL.Path == fmt.Sprintf("%s/%s/analyses", siftInvestigationsPath, investigationID):
+				_, _ = fmt.Fprintf(w, `{"status": "success", "data": [{
+					"investigationId": %q,
+					"name": "ErrorPatternLogs",
+					"status": "finished",
+					"result": {
+						"successful": true,
+						"interesting": true,
+						"message": "Found 1 elevated error pattern",
+						"details": {"patterns": [{"pattern": "failed to charge card <_>", "query": "{service=\"checkout\"} |= \"failed to charge card\""}]}
+					}
+				}]}`, investigationID)
+			case r.URL.Path == "/api/datasources/proxy/uid/loki-uid/loki/api/v1/query_range":
+				assert.Equal(t, `{service="checkout"} |= "failed to charge card"`, r.URL.Query().Get("query"))
+				_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "streams", "result": [
+					{"stream": {"service": "checkout"}, "values": [["1700000000000000000", "failed to charge card 4242"]]}
+				]}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+	}
+
+	args := RunSiftErrorPatternLogsParams{
+		Name:   "checkout errors",
+		Labels: map[string]string{"service": "checkout"},
+	}
+
+	t.Run("completed within timeout", func(t *testing.T) {
+		server := newServer(t, 2)
+		defer server.Close()
+
+		result, err := runSiftErrorPatternLogs(mockDatasourceCtx(server, nil), args)
+		require.NoError(t, err)
+		assert.Equal(t, investigationID, result.InvestigationID)
+		assert.Equal(t, investigationStatusFinished, result.Status)
+		assert.Equal(t, "Found 1 elevated error pattern", result.Message)
+		assert.Equal(t, []any{map[string]any{
+			"pattern":  "failed to charge card <_>",
+			"query":    `{service="checkout"} |= "failed to charge card"`,
+			"examples": []string{"failed to charge card 4242"},
+		}}, result.Patterns)
+	})
+
+	t.Run("find_error_pattern_logs returns the analysis", func(t *testing.T) {
+		server := newServer(t, 2)
+		defer server.Close()
+
+		result, err := findErrorPatternLogs(mockDatasourceCtx(server, nil), FindErrorPatternLogsParams{
+			Name:   args.Name,
+			Labels: args.Labels,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "ErrorPatternLogs", result.Name)
+		assert.Equal(t, "Found 1 elevated error pattern", result.Result.Message)
+		assert.Equal(t, []any{map[string]any{
+			"pattern":  "failed to charge card <_>",
+			"query":    `{service="checkout"} |= "failed to charge card"`,
+			"examples": []string{"failed to charge card 4242"},
+		}}, result.Result.Details["patterns"])
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		server := newServer(t, math.MaxInt)
+		defer server.Close()
+
+		args := args
+		args.MaxWaitSeconds = 1
+		result, err := runSiftErrorPatternLogs(mockDatasourceCtx(server, nil), args)
+		require.NoError(t, err)
+		assert.Equal(t, investigationID, result.InvestigationID)
+		assert.Equal(t, investigationStatusRunning, result.Status)
+		assert.Contains(t, result.Message, "get_sift_investigation")
+		assert.Nil(t, result.Analysis)
+		assert.Empty(t, result.Patterns)
+	})
+}
@@ -0,0 +1,427 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+const (
+	// DefaultTempoSearchLimit is the number of traces returned by
+	// search_traces when no limit is given.
+	DefaultTempoSearchLimit = 20
+
+	// MaxTempoSearchLimit is the maximum number of traces search_traces can
+	// return.
+	MaxTempoSearchLimit = 100
+
+	// maxTraceSpans caps the number of spans returned by get_trace, so that
+	// huge traces don't flood the caller's context.
+	maxTraceSpans = 500
+)
+
+func AddTempoTools(mcp *server.MCPServer) {
+	SearchTraces.Register(mcp)
+	GetTrace.Register(mcp)
+}
+
+type tempoClient struct {
+	http *http.Client
+	base *url.URL
+}
+
+func newTempoClient(ctx context.Context, uid string) (*tempoClient, error) {
+	ds, err := datasourceInfo(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(strings.ToLower(ds.Type), "tempo") {
+		return nil, fmt.Errorf("datasource %s is of type %s, not tempo", uid, ds.Type)
+	}
+
+	cfg := mcpgrafana.GrafanaConfigFromContext(ctx)
+	transport, err := mcpgrafana.BuildTransport(&cfg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create custom transport: %w", err)
+	}
+
+	var base *url.URL
+	if endpoint, ok := mcpgrafana.GrafanaCloudEndpointFor(cfg, ds.URL, ds.BasicAuthUser); ok {
+		transport = endpoint.RoundTripper(transport)
+		base, err = url.Parse(endpoint.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse datasource url: %w", err)
+		}
+	} else {
+		transport = NewAuthRoundTripper(transport, cfg.AccessToken, cfg.IDToken, cfg.APIKey, cfg.BasicAuth)
+		transport = mcpgrafana.NewOrgIDRoundTripper(transport, cfg.OrgID)
+		grafanaURL, err := url.Parse(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse base url: %w", err)
+		}
+		base = grafanaURL.JoinPath("api", "datasources", "proxy", "uid", uid)
+	}
+	transport = newDatasourceAccessRoundTripper(transport, uid, cfg)
+	return &tempoClient{
+		http: &http.Client{
+			Transport: mcpgrafana.NewUserAgentTransport(transport),
+		},
+		base: base,
+	}, nil
+}
+
+func (c *tempoClient) get(ctx context.Context, path string, params url.Values, v any) error {
+	u := c.base.JoinPath(path)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body := readErrorBody(resp.Body)
+		return mcpgrafana.NewHTTPError(resp.StatusCode, fmt.Sprintf("tempo API returned status code %d: %s", resp.StatusCode, summarizeErrorBody(body)))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024*48))
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decoding tempo response: %w", err)
+	}
+	return nil
+}
+
+type SearchTracesParams struct {
+	DatasourceUID string            `json:"datasourceUid" jsonschema:"required,description=The UID of the Tempo datasource to query"`
+	Service       string            `json:"service,omitempty" jsonschema:"description=Only return traces with spans from this service (the service.name resource attribute)"`
+	MinDuration   string            `json:"minDuration,omitempty" jsonschema:"description=Only return traces at least this long\\, as a duration such as '500ms' or '2s'"`
+	MaxDuration   string            `json:"maxDuration,omitempty" jsonschema:"description=Only return traces at most this long\\, as a duration such as '500ms' or '2s'"`
+	Tags          map[string]string `json:"tags,omitempty" jsonschema:"description=Span or resource attributes which matching traces must have\\, e.g. {\"http.status_code\": \"500\"}"`
+	StartTime     string            `json:"startTime,omitempty" jsonschema:"description=The start of the time range to search. Supported formats are RFC3339\\, Unix seconds or relative to now (e.g. 'now-1h'). Defaults to the default query range (1 hour unless configured) before the end time"`
+	EndTime       string            `json:"endTime,omitempty" jsonschema:"description=The end of the time range to search. Supported formats are RFC3339\\, Unix seconds or relative to now (e.g. 'now'). Defaults to now."`
+	Limit         int               `json:"limit,omitempty" jsonschema:"default=20,description=The maximum number of traces to return (max 100)"`
+}
+
+// tempoSearchResponse is the response of Tempo's /api/search endpoint.
+type tempoSearchResponse struct {
+	Traces []struct {
+		TraceID           string `json:"traceID"`
+		RootServiceName   string `json:"rootServiceName"`
+		RootTraceName     string `json:"rootTraceName"`
+		StartTimeUnixNano string `json:"startTimeUnixNano"`
+		DurationMs        int64  `json:"durationMs"`
+	} `json:"traces"`
+}
+
+// TraceSearchResult summarizes a trace matched by search_traces.
+type TraceSearchResult struct {
+	TraceID         string    `json:"traceId"`
+	RootServiceName string    `json:"rootServiceName"`
+	RootSpanName    string    `json:"rootSpanName"`
+	DurationMs      int64     `json:"durationMs"`
+	StartTime       time.Time `json:"startTime"`
+}
+
+func searchTraces(ctx context.Context, args SearchTracesParams) ([]TraceSearchResult, error) {
+	if args.Limit < 0 || args.Limit > MaxTempoSearchLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxTempoSearchLimit)
+	}
+	for name, d := range map[string]string{"minDuration": args.MinDuration, "maxDuration": args.MaxDuration} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", name, d, err)
+		}
+	}
+
+	start, end, err := parseTraceTimeRange(ctx, args.StartTime, args.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newTempoClient(ctx, args.DatasourceUID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("limit", strconv.Itoa(intOrDefault(args.Limit, DefaultTempoSearchLimit)))
+	if tags := tempoSearchTags(args.Service, args.Tags); tags != "" {
+		params.Set("tags", tags)
+	}
+	if args.MinDuration != "" {
+		params.Set("minDuration", args.MinDuration)
+	}
+	if args.MaxDuration != "" {
+		params.Set("maxDuration", args.MaxDuration)
+	}
+
+	var resp tempoSearchResponse
+	if err := client.get(ctx, "/api/search", params, &resp); err != nil {
+		return nil, fmt.Errorf("searching traces: %w", err)
+	}
+
+	results := make([]TraceSearchResult, 0, len(resp.Traces))
+	for _, t := range resp.Traces {
+		result := TraceSearchResult{
+			TraceID:         t.TraceID,
+			RootServiceName: t.RootServiceName,
+			RootSpanName:    t.RootTraceName,
+			DurationMs:      t.DurationMs,
+		}
+		if ns, err := strconv.ParseInt(t.StartTimeUnixNano, 10, 64); err == nil {
+			result.StartTime = time.Unix(0, ns).UTC()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// parseTraceTimeRange parses the start and end of a trace search, defaulting
+// to the default query range.
+func parseTraceTimeRange(ctx context.Context, startTime, endTime string) (time.Time, time.Time, error) {
+	start, end, err := defaultTimeRange(ctx, startTime, endTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start time must be before end time")
+	}
+	return start, end, nil
+}
+
+// tempoSearchTags builds the logfmt encoded tags parameter of a Tempo search.
+func tempoSearchTags(service string, tags map[string]string) string {
+	all := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		all[k] = v
+	}
+	if service != "" {
+		all["service.name"] = service
+	}
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := all[k]
+		if strings.ContainsAny(v, " \"=") {
+			v = strconv.Quote(v)
+		}
+		parts = append(parts, k+"="+v)
+	}
+	return strings.Join(parts, " ")
+}
+
+var SearchTraces = mcpgrafana.MustTool(
+	"search_traces",
+	"Search for traces in a Tempo datasource, optionally filtering by service, trace duration (e.g. minDuration '2s' to find slow traces) and span or resource attributes. Returns the ID, root service name, root span name, duration and start time of each matching trace. Use get_trace to fetch the spans of a trace. The time range defaults to the last hour.",
+	searchTraces,
+	mcp.WithTitleAnnotation("Search Tempo traces"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+	mcpgrafana.WithExamples(
+		map[string]any{"datasourceUid": "tempo", "service": "checkout", "minDuration": "2s", "startTime": "now-1h"},
+		map[string]any{"datasourceUid": "tempo", "tags": map[string]string{"http.status_code": "500"}, "limit": 10},
+	),
+)
+
+type GetTraceParams struct {
+	DatasourceUID string `json:"datasourceUid" jsonschema:"required,description=The UID of the Tempo datasource to query"`
+	TraceID       string `json:"traceId" jsonschema:"required,description=The ID of the trace to fetch"`
+}
+
+// tempoTrace is the OTLP JSON representation of a trace returned by Tempo.
+// Older versions of Tempo use batches and instrumentationLibrarySpans
+// rather than resourceSpans and scopeSpans.
+type tempoTrace struct {
+	Batches       []tempoResourceSpans `json:"batches"`
+	ResourceSpans []tempoResourceSpans `json:"resourceSpans"`
+}
+
+type tempoResourceSpans struct {
+	Resource struct {
+		Attributes []tempoAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans                  []tempoScopeSpans `json:"scopeSpans"`
+	InstrumentationLibrarySpans []tempoScopeSpans `json:"instrumentationLibrarySpans"`
+}
+
+type tempoScopeSpans struct {
+	Spans []tempoSpan `json:"spans"`
+}
+
+type tempoSpan struct {
+	TraceID           string           `json:"traceId"`
+	SpanID            string           `json:"spanId"`
+	ParentSpanID      string           `json:"parentSpanId"`
+	Name              string           `json:"name"`
+	Kind              string           `json:"kind"`
+	StartTimeUnixNano string           `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string           `json:"endTimeUnixNano"`
+	Attributes        []tempoAttribute `json:"attributes"`
+	Status            struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+type tempoAttribute struct {
+	Key   string                     `json:"key"`
+	Value map[string]json.RawMessage `json:"value"`
+}
+
+// TraceSpan is a single span of a trace returned by get_trace.
+type TraceSpan struct {
+	SpanID        string         `json:"spanId"`
+	ParentSpanID  string         `json:"parentSpanId,omitempty"`
+	Name          string         `json:"name"`
+	ServiceName   string         `json:"serviceName"`
+	Kind          string         `json:"kind,omitempty"`
+	StartTime     time.Time      `json:"startTime"`
+	DurationMs    float64        `json:"durationMs"`
+	StatusCode    string         `json:"statusCode,omitempty"`
+	StatusMessage string         `json:"statusMessage,omitempty"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+}
+
+// Trace is a trace returned by get_trace, with its spans sorted by start
+// time.
+type Trace struct {
+	TraceID   string      `json:"traceId"`
+	SpanCount int         `json:"spanCount"`
+	Truncated bool        `json:"truncated,omitempty"`
+	Spans     []TraceSpan `json:"spans"`
+}
+
+func getTrace(ctx context.Context, args GetTraceParams) (*Trace, error) {
+	traceID := strings.TrimSpace(args.TraceID)
+	if traceID == "" {
+		return nil, fmt.Errorf("traceId is required")
+	}
+
+	client, err := newTempoClient(ctx, args.DatasourceUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp tempoTrace
+	if err := client.get(ctx, "/api/traces/"+url.PathEscape(traceID), nil, &resp); err != nil {
+		return nil, fmt.Errorf("getting trace %s: %w", traceID, err)
+	}
+
+	trace := &Trace{TraceID: traceID, Spans: []TraceSpan{}}
+	for _, rs := range append(resp.Batches, resp.ResourceSpans...) {
+		serviceName, _ := attributeMap(rs.Resource.Attributes)["service.name"].(string)
+		for _, ss := range append(rs.ScopeSpans, rs.InstrumentationLibrarySpans...) {
+			for _, s := range ss.Spans {
+				trace.Spans = append(trace.Spans, convertTempoSpan(s, serviceName))
+			}
+		}
+	}
+	trace.SpanCount = len(trace.Spans)
+	sort.SliceStable(trace.Spans, func(i, j int) bool { return trace.Spans[i].StartTime.Before(trace.Spans[j].StartTime) })
+	if len(trace.Spans) > maxTraceSpans {
+		trace.Spans = trace.Spans[:maxTraceSpans]
+		trace.Truncated = true
+	}
+	return trace, nil
+}
+
+func convertTempoSpan(s tempoSpan, serviceName string) TraceSpan {
+	span := TraceSpan{
+		SpanID:        normalizeTraceID(s.SpanID),
+		ParentSpanID:  normalizeTraceID(s.ParentSpanID),
+		Name:          s.Name,
+		ServiceName:   serviceName,
+		Kind:          strings.TrimPrefix(s.Kind, "SPAN_KIND_"),
+		StatusCode:    strings.TrimPrefix(s.Status.Code, "STATUS_CODE_"),
+		StatusMessage: s.Status.Message,
+		Attributes:    attributeMap(s.Attributes),
+	}
+	start, startErr := strconv.ParseInt(s.StartTimeUnixNano, 10, 64)
+	end, endErr := strconv.ParseInt(s.EndTimeUnixNano, 10, 64)
+	if startErr == nil {
+		span.StartTime = time.Unix(0, start).UTC()
+		if endErr == nil && end >= start {
+			span.DurationMs = float64(end-start) / float64(time.Millisecond)
+		}
+	}
+	return span
+}
+
+// normalizeTraceID converts a base64 encoded trace or span ID, as returned by
+// Tempo's JSON API, to the usual hex representation. Hex IDs are returned
+// unchanged.
+func normalizeTraceID(id string) string {
+	if id == "" {
+		return ""
+	}
+	if _, err := hex.DecodeString(id); err == nil && (len(id) == 16 || len(id) == 32) {
+		return id
+	}
+	if b, err := base64.StdEncoding.DecodeString(id); err == nil && (len(b) == 8 || len(b) == 16) {
+		return hex.EncodeToString(b)
+	}
+	return id
+}
+
+// attributeMap converts OTLP attributes into a map of plain values.
+func attributeMap(attrs []tempoAttribute) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		for _, raw := range a.Value {
+			var v any
+			if err := json.Unmarshal(raw, &v); err == nil {
+				m[a.Key] = v
+			}
+			break
+		}
+	}
+	return m
+}
+
+var GetTrace = mcpgrafana.MustTool(
+	"get_trace",
+	"Fetch a trace by ID from a Tempo datasource. Returns the spans of the trace sorted by start time, each with its span ID, parent span ID, name, service name, kind, start time, duration, status and attributes. Very large traces are truncated to the first 500 spans.",
+	getTrace,
+	mcp.WithTitleAnnotation("Get Tempo trace"),
+	mcp.WithIdempotentHintAnnotation(true),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
@@ -0,0 +1,233 @@
+//go:build unit
+
+package tools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+// newTempoTestServer serves the datasource lookup for uid "tempo-uid" and
+// delegates everything under its proxy path to the given handler.
+func newTempoTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/datasources/uid/tempo-uid":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"uid": "tempo-uid", "name": "Tempo", "type": "tempo"})
+			return
+		case "/api/datasources/uid/loki-uid":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"uid": "loki-uid", "name": "Loki", "type": "loki"})
+			return
+		}
+		handler(w, r)
+	}))
+}
+
+func TestSearchTraces(t *testing.T) {
+	t.Run("builds the search request and converts results", func(t *testing.T) {
+		server := newTempoTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/datasources/proxy/uid/tempo-uid/api/search", r.URL.Path)
+			q := r.URL.Query()
+			assert.Equal(t, `http.status_code=500 service.name=checkout`, q.Get("tags"))
+			assert.Equal(t, "2s", q.Get("minDuration"))
+			assert.Equal(t, "", q.Get("maxDuration"))
+			assert.Equal(t, "1700000000", q.Get("start"))
+			assert.Equal(t, "1700003600", q.Get("end"))
+			assert.Equal(t, "20", q.Get("limit"))
+			assert.Equal(t, "Bearer test", r.Header.Get("Authorization"))
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"traces": [{
+				"traceID": "2f3e0cee77ae5dc9c17ade3689eb2e54",
+				"rootServiceName": "checkout",
+				"rootTraceName": "POST /checkout",
+				"startTimeUnixNano": "1700000100000000000",
+				"durationMs": 2500
+			}]}`))
+		})
+		defer server.Close()
+
+		results, err := searchTraces(mockDatasourceCtx(server, nil), SearchTracesParams{
+			DatasourceUID: "tempo-uid",
+			Service:       "checkout",
+			MinDuration:   "2s",
+			Tags:          map[string]string{"http.status_code": "500"},
+			StartTime:     "2023-11-14T22:13:20Z",
+			EndTime:       "2023-11-14T23:13:20Z",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []TraceSearchResult{{
+			TraceID:         "2f3e0cee77ae5dc9c17ade3689eb2e54",
+			RootServiceName: "checkout",
+			RootSpanName:    "POST /checkout",
+			DurationMs:      2500,
+			StartTime:       time.Unix(1700000100, 0).UTC(),
+		}}, results)
+	})
+
+	t.Run("quotes tag values containing spaces", func(t *testing.T) {
+		assert.Equal(t, `name="GET /api" service.name=api`, tempoSearchTags("api", map[string]string{"name": "GET /api"}))
+	})
+
+	t.Run("rejects invalid durations", func(t *testing.T) {
+		_, err := searchTraces(t.Context(), SearchTracesParams{DatasourceUID: "tempo-uid", MaxDuration: "slow"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid maxDuration")
+	})
+
+	t.Run("rejects non-tempo datasources", func(t *testing.T) {
+		server := newTempoTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		})
+		defer server.Close()
+
+		_, err := searchTraces(mockDatasourceCtx(server, nil), SearchTracesParams{DatasourceUID: "loki-uid"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not tempo")
+	})
+
+	t.Run("surfaces tempo errors", func(t *testing.T) {
+		server := newTempoTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`invalid tags`))
+		})
+		defer server.Close()
+
+		_, err := searchTraces(mockDatasourceCtx(server, nil), SearchTracesParams{DatasourceUID: "tempo-uid"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status code 400")
+		assert.Contains(t, err.Error(), "invalid tags")
+	})
+}
+
+func TestGetTrace(t *testing.T) {
+	t.Run("converts OTLP spans", func(t *testing.T) {
+		server := newTempoTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/datasources/proxy/uid/tempo-uid/api/traces/2f3e0cee77ae5dc9c17ade3689eb2e54", r.URL.Path)
+			assert.Equal(t, "application/json", r.Header.Get("Accept"))
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"batches": [{
+				"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "checkout"}}]},
+				"scopeSpans": [{"spans": [
+					{
+						"traceId": "Lz4M7neuXcnBet42iesuVA==",
+						"spanId": "AAAAAAAAAAI=",
+						"parentSpanId": "AAAAAAAAAAE=",
+						"name": "SELECT orders",
+						"kind": "SPAN_KIND_CLIENT",
+						"startTimeUnixNano": "1700000100500000000",
+						"endTimeUnixNano": "1700000101000000000",
+						"attributes": [{"key": "db.rows", "value": {"intValue": 3}}],
+						"status": {"code": "STATUS_CODE_ERROR", "message": "timeout"}
+					},
+					{
+						"traceId": "Lz4M7neuXcnBet42iesuVA==",
+						"spanId": "AAAAAAAAAAE=",
+						"name": "POST /checkout",
+						"kind": "SPAN_KIND_SERVER",
+						"startTimeUnixNano": "1700000100000000000",
+						"endTimeUnixNano": "1700000102500000000"
+					}
+				]}]
+			}]}`))
+		})
+		defer server.Close()
+
+		trace, err := getTrace(mockDatasourceCtx(server, nil), GetTraceParams{
+			DatasourceUID: "tempo-uid",
+			TraceID:       "2f3e0cee77ae5dc9c17ade3689eb2e54",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &Trace{
+			TraceID:   "2f3e0cee77ae5dc9c17ade3689eb2e54",
+			SpanCount: 2,
+			Spans: []TraceSpan{
+				{
+					SpanID:      "0000000000000001",
+					Name:        "POST /checkout",
+					ServiceName: "checkout",
+					Kind:        "SERVER",
+					StartTime:   time.Unix(1700000100, 0).UTC(),
+					DurationMs:  2500,
+				},
+				{
+					SpanID:        "0000000000000002",
+					ParentSpanID:  "0000000000000001",
+					Name:          "SELECT orders",
+					ServiceName:   "checkout",
+					Kind:          "CLIENT",
+					StartTime:     time.Unix(1700000100, 500000000).UTC(),
+					DurationMs:    500,
+					StatusCode:    "ERROR",
+					StatusMessage: "timeout",
+					Attributes:    map[string]any{"db.rows": float64(3)},
+				},
+			},
+		}, trace)
+	})
+
+	t.Run("trace not found", func(t *testing.T) {
+		server := newTempoTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`trace not found`))
+		})
+		defer server.Close()
+
+		_, err := getTrace(mockDatasourceCtx(server, nil), GetTraceParams{DatasourceUID: "tempo-uid", TraceID: "abc"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status code 404")
+	})
+
+	t.Run("requires a trace id", func(t *testing.T) {
+		_, err := getTrace(t.Context(), GetTraceParams{DatasourceUID: "tempo-uid", TraceID: " "})
+		require.Error(t, err)
+	})
+}
+
+func TestTempoGrafanaCloudEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"uid": "tempo-uid", "name": "grafanacloud-mystack-traces", "type": "tempo",
+			"url": "https://tempo-prod-04-prod-us-east-0.grafana.net/tempo", "basicAuth": true, "basicAuthUser": "987654",
+		})
+	}))
+	defer server.Close()
+
+	ctx := mcpgrafana.WithGrafanaConfig(mockCtxWithClient(server), mcpgrafana.GrafanaConfig{
+		URL:                    "https://mystack.grafana.net",
+		CloudAccessPolicyToken: "glc_token",
+	})
+	client, err := newTempoClient(ctx, "tempo-uid")
+	require.NoError(t, err)
+	assert.Equal(t, "https://tempo-prod-04-prod-us-east-0.grafana.net/tempo/api/search", client.base.JoinPath("api", "search").String())
+}
+
+func TestTempoDatasourceAccessDenied(t *testing.T) {
+	server := newTempoTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message": "access denied to datasource"}`))
+	})
+	defer server.Close()
+
+	ctx := mockDatasourceCtx(server, map[string]string{"X-Webauth-User": "alice"})
+	_, err := searchTraces(ctx, SearchTracesParams{DatasourceUID: "tempo-uid"})
+	require.Error(t, err)
+	var denied *datasourceAccessDeniedError
+	require.ErrorAs(t, err, &denied)
+	assert.Contains(t, err.Error(), `access denied: the authenticated user (forwarded user "alice") lacks access to datasource "tempo-uid": access denied to datasource`)
+	assert.Equal(t, mcpgrafana.ErrKindAuth, mcpgrafana.ErrorKindOf(err))
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+// timeFormatHint is appended to time parsing errors to list the accepted
+// formats.
+const timeFormatHint = "expected 'now', a time relative to now such as 'now-1h' or 'now-7d', an RFC3339 timestamp or Unix seconds"
+
+// maxUnixSeconds is the largest Unix timestamp taken as seconds. Larger ones,
+// such as those in Grafana URLs, are taken as milliseconds.
+const maxUnixSeconds = 1e11
+
+// parseTime parses a time parameter, resolving relative times against the
+// current time.
+func parseTime(value string) (time.Time, error) {
+	return parseTimeAt(value, time.Now())
+}
+
+// parseTimeAt parses a time given as a Grafana style relative time such as
+// "now", "now-15m" or "now-1d/d", an RFC3339 timestamp or Unix seconds,
+// resolving relative times against now. Errors are of kind
+// mcpgrafana.ErrKindValidation.
+func parseTimeAt(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, mcpgrafana.NewError(mcpgrafana.ErrKindValidation, "empty time: "+timeFormatHint, nil)
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+			return time.Time{}, invalidTimeError(value)
+		}
+		if math.Abs(seconds) >= maxUnixSeconds {
+			return time.UnixMilli(int64(seconds)), nil
+		}
+		whole, frac := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(frac*1e9)), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	t, err := gtime.TimeRange{From: value, Now: now}.ParseFrom()
+	if err != nil {
+		return time.Time{}, invalidTimeError(value)
+	}
+	return t, nil
+}
+
+func invalidTimeError(value string) error {
+	return mcpgrafana.NewError(mcpgrafana.ErrKindValidation, fmt.Sprintf("invalid time %q: %s", value, timeFormatHint), nil)
+}
+
+// timeOrDefault parses an optional time parameter with parseTime, returning
+// def if it is empty.
+func timeOrDefault(s string, def time.Time) (time.Time, error) {
+	if strings.TrimSpace(s) == "" {
+		return def, nil
+	}
+	return parseTime(s)
+}
+
+// defaultQueryRange returns how far back query tools look when called
+// without a start time.
+func defaultQueryRange(ctx context.Context) time.Duration {
+	if d := mcpgrafana.GrafanaConfigFromContext(ctx).DefaultQueryRange; d > 0 {
+		return d
+	}
+	return mcpgrafana.DefaultQueryRange
+}
+
+// getDefaultTimeRange fills in an omitted start and end time: end defaults to
+// now and start to the default query range before end. Times which are given
+// are returned unchanged, so explicit ranges always win.
+func getDefaultTimeRange(ctx context.Context, startRFC3339, endRFC3339 string) (string, string) {
+	now := time.Now()
+	if startRFC3339 == "" {
+		end := now
+		// An invalid end time is reported when the range is parsed.
+		if t, err := parseTimeAt(endRFC3339, now); err == nil {
+			end = t
+		}
+		startRFC3339 = end.Add(-defaultQueryRange(ctx)).Format(time.RFC3339)
+	}
+	if endRFC3339 == "" {
+		endRFC3339 = now.Format(time.RFC3339)
+	}
+	return startRFC3339, endRFC3339
+}
+
+// defaultTimeRange parses an optional start and end time, defaulting them as
+// getDefaultTimeRange does.
+func defaultTimeRange(ctx context.Context, start, end string) (time.Time, time.Time, error) {
+	endTime, err := timeOrDefault(end, time.Now())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parsing end time: %w", err)
+	}
+	startTime, err := timeOrDefault(start, endTime.Add(-defaultQueryRange(ctx)))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parsing start time: %w", err)
+	}
+	return startTime, endTime, nil
+}
@@ -0,0 +1,121 @@
+//go:build unit
+
+package tools
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcpgrafana "github.com/grafana/mcp-grafana"
+)
+
+func TestParseTimeAt(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 30, 45, 0, time.UTC)
+
+	for _, tc := range []struct {
+		input    string
+		expected time.Time
+		err      bool
+	}{
+		{input: "now", expected: now},
+		{input: " now ", expected: now},
+		{input: "now-15m", expected: now.Add(-15 * time.Minute)},
+		{input: "now-1h", expected: now.Add(-time.Hour)},
+		{input: "now-7d", expected: now.AddDate(0, 0, -7)},
+		{input: "now-30d", expected: now.AddDate(0, 0, -30)},
+		{input: "now+1h", expected: now.Add(time.Hour)},
+		{input: "now-1d/d", expected: time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{input: "2024-01-02T03:04:05Z", expected: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{input: "2024-01-02T03:04:05.5+02:00", expected: time.Date(2024, 1, 2, 1, 4, 5, 500_000_000, time.UTC)},
+		{input: "1700000000", expected: time.Unix(1700000000, 0)},
+		{input: "1700000000.25", expected: time.Unix(1700000000, 250_000_000)},
+		// Timestamps too large to be seconds are milliseconds, as in
+		// Grafana URLs.
+		{input: "1700000000000", expected: time.UnixMilli(1700000000000)},
+		{input: "", err: true},
+		{input: "yesterday", err: true},
+		{input: "now-", err: true},
+		{input: "now-1.5h", err: true},
+		{input: "NaN", err: true},
+		{input: "2024-13-01T00:00:00Z", err: true},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			result, err := parseTimeAt(tc.input, now)
+			if tc.err {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "RFC3339 timestamp or Unix seconds")
+				assert.Equal(t, mcpgrafana.ErrKindValidation, mcpgrafana.ErrorKindOf(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tc.expected.Equal(result), "expected %s, got %s", tc.expected, result)
+		})
+	}
+}
+
+func TestAddTimeRangeParamsRelative(t *testing.T) {
+	params := url.Values{}
+	require.NoError(t, addTimeRangeParams(params, "now-1h", "1700000000"))
+	assert.Equal(t, "1700000000000000000", params.Get("end"))
+	start, err := strconv.ParseInt(params.Get("start"), 10, 64)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), time.Unix(0, start), time.Minute)
+
+	err = addTimeRangeParams(url.Values{}, "last hour", "")
+	assert.ErrorContains(t, err, `invalid time "last hour"`)
+}
+
+func TestDefaultTimeRange(t *testing.T) {
+	sixHours := mcpgrafana.WithGrafanaConfig(context.Background(), mcpgrafana.GrafanaConfig{DefaultQueryRange: 6 * time.Hour})
+
+	t.Run("defaults to the last hour", func(t *testing.T) {
+		start, end, err := defaultTimeRange(context.Background(), "", "")
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), end, time.Minute)
+		assert.Equal(t, time.Hour, end.Sub(start))
+	})
+
+	t.Run("defaults to the configured range", func(t *testing.T) {
+		start, end, err := defaultTimeRange(sixHours, "", "")
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), end, time.Minute)
+		assert.Equal(t, 6*time.Hour, end.Sub(start))
+	})
+
+	t.Run("start defaults to the range before an explicit end", func(t *testing.T) {
+		start, end, err := defaultTimeRange(sixHours, "", "1700000000")
+		require.NoError(t, err)
+		assert.Equal(t, time.Unix(1700000000, 0), end)
+		assert.Equal(t, time.Unix(1700000000, 0).Add(-6*time.Hour), start)
+	})
+
+	t.Run("explicit range wins", func(t *testing.T) {
+		start, end, err := defaultTimeRange(sixHours, "1700000000", "1700000060")
+		require.NoError(t, err)
+		assert.Equal(t, time.Unix(1700000000, 0), start)
+		assert.Equal(t, time.Unix(1700000060, 0), end)
+	})
+
+	t.Run("invalid times", func(t *testing.T) {
+		_, _, err := defaultTimeRange(sixHours, "yesterday", "")
+		assert.ErrorContains(t, err, "parsing start time")
+		_, _, err = defaultTimeRange(sixHours, "", "tomorrow")
+		assert.ErrorContains(t, err, "parsing end time")
+	})
+
+	t.Run("as strings", func(t *testing.T) {
+		start, end := getDefaultTimeRange(sixHours, "", "2024-01-02T09:00:00Z")
+		assert.Equal(t, "2024-01-02T03:00:00Z", start)
+		assert.Equal(t, "2024-01-02T09:00:00Z", end)
+
+		start, end = getDefaultTimeRange(sixHours, "now-15m", "now")
+		assert.Equal(t, "now-15m", start)
+		assert.Equal(t, "now", end)
+	})
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// MaxToolTimeoutEnvVar caps the per-call timeout which can be requested with
+// the timeoutSeconds parameter of the query tools. It accepts a duration
+// (e.g. "90s") or a number of seconds.
+const MaxToolTimeoutEnvVar = "GRAFANA_MAX_TOOL_TIMEOUT"
+
+// DefaultMaxToolTimeout is used when GRAFANA_MAX_TOOL_TIMEOUT is not set.
+const DefaultMaxToolTimeout = 120 * time.Second
+
+// maxToolTimeout returns the maximum per-call timeout configured by the
+// GRAFANA_MAX_TOOL_TIMEOUT environment variable.
+func maxToolTimeout() time.Duration {
+	value := os.Getenv(MaxToolTimeoutEnvVar)
+	if value == "" {
+		return DefaultMaxToolTimeout
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	slog.Warn("invalid GRAFANA_MAX_TOOL_TIMEOUT value, using default", "value", value, "default", DefaultMaxToolTimeout)
+	return DefaultMaxToolTimeout
+}
+
+// withToolTimeout applies the timeout requested by a tool call to the context,
+// capped at maxToolTimeout. A zero timeoutSeconds leaves the context unchanged
+// so the client's default timeout applies. The returned timeout is the one
+// applied, for use in error messages.
+func withToolTimeout(ctx context.Context, timeoutSeconds int) (context.Context, context.CancelFunc, time.Duration, error) {
+	if timeoutSeconds < 0 {
+		return nil, nil, 0, fmt.Errorf("timeoutSeconds must be positive")
+	}
+	if timeoutSeconds == 0 {
+		return ctx, func() {}, 0, nil
+	}
+	timeout := min(time.Duration(timeoutSeconds)*time.Second, maxToolTimeout())
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, timeout, nil
+}
+
+// wrapTimeoutError turns an error caused by a tool call timeout into one
+// which names the tool and the timeout, so callers know to retry with a
+// longer timeout or a cheaper query.
+func wrapTimeoutError(ctx context.Context, toolName string, timeout time.Duration, err error) error {
+	if err == nil || timeout == 0 {
+		return err
+	}
+	// Not every client wraps the context error, so check the context too.
+	if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%s timed out after %s; narrow the query or time range, or increase timeoutSeconds (max %s): %w", toolName, timeout, maxToolTimeout(), err)
+}
@@ -0,0 +1,94 @@
+//go:build unit
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowHandler blocks until the request is cancelled, or a few seconds pass.
+func slowHandler(w http.ResponseWriter, r *http.Request) {
+	// The server only notices the client going away once the body is read.
+	_, _ = io.Copy(io.Discard, r.Body)
+	select {
+	case <-r.Context().Done():
+	case <-time.After(5 * time.Second):
+	}
+}
+
+func TestToolTimeout(t *testing.T) {
+	t.Setenv(MaxToolTimeoutEnvVar, "50ms")
+
+	t.Run("query_prometheus", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/datasources/uid/prom-uid" {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(map[string]any{"uid": "prom-uid", "name": "Prometheus", "type": "prometheus"})
+				return
+			}
+			slowHandler(w, r)
+		}))
+		defer server.Close()
+
+		start := time.Now()
+		_, err := queryPrometheus(mockDatasourceCtx(server, nil), QueryPrometheusParams{
+			DatasourceUID:  "prom-uid",
+			Expr:           "up",
+			StartTime:      "now-1h",
+			EndTime:        "now",
+			TimeoutSeconds: 30,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "query_prometheus timed out after 50ms")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("query_loki_logs", func(t *testing.T) {
+		server := newLokiTestServer(t, slowHandler)
+		defer server.Close()
+
+		_, err := queryLokiLogs(mockDatasourceCtx(server, nil), QueryLokiLogsParams{
+			DatasourceUID:  "loki-uid",
+			LogQL:          `{app="nginx"}`,
+			TimeoutSeconds: 30,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "query_loki_logs timed out after 50ms")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("negative timeout is rejected", func(t *testing.T) {
+		_, err := queryPrometheus(context.Background(), QueryPrometheusParams{TimeoutSeconds: -1})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timeoutSeconds must be positive")
+	})
+}
+
+func TestMaxToolTimeout(t *testing.T) {
+	t.Setenv(MaxToolTimeoutEnvVar, "")
+	assert.Equal(t, DefaultMaxToolTimeout, maxToolTimeout())
+
+	t.Setenv(MaxToolTimeoutEnvVar, "300")
+	assert.Equal(t, 300*time.Second, maxToolTimeout())
+
+	t.Setenv(MaxToolTimeoutEnvVar, "2m")
+	assert.Equal(t, 2*time.Minute, maxToolTimeout())
+
+	t.Setenv(MaxToolTimeoutEnvVar, "forever")
+	assert.Equal(t, DefaultMaxToolTimeout, maxToolTimeout())
+
+	t.Setenv(MaxToolTimeoutEnvVar, "10s")
+	_, cancel, timeout, err := withToolTimeout(context.Background(), 60)
+	require.NoError(t, err)
+	defer cancel()
+	assert.Equal(t, 10*time.Second, timeout, "timeout should be capped")
+}
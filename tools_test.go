@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -541,9 +542,13 @@ func TestConvertTool(t *testing.T) {
 			},
 		}
 
-		_, err = handler(context.Background(), mismatchRequest)
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "unmarshal args")
+		// Arguments of the wrong type are the caller's mistake, so they are
+		// reported as a validation error the caller can act on.
+		result, err := handler(context.Background(), mismatchRequest)
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "invalid arguments")
+		assert.Equal(t, string(ErrKindValidation), result.Meta.AdditionalFields[ErrorKindMetaKey])
 	})
 }
 
@@ -606,3 +611,32 @@ func TestEmptyStructJSONSchema(t *testing.T) {
 	assert.True(t, ok, "properties should be a map")
 	assert.Len(t, propertiesMap, 0, "properties should be an empty map")
 }
+
+func TestMustMutatingTool(t *testing.T) {
+	mutating := MustMutatingTool("mutating_tool", "A mutating tool", testToolHandler)
+	readOnly := MustTool("read_only_tool", "A read-only tool", testToolHandler, mcp.WithReadOnlyHintAnnotation(true))
+	assert.True(t, mutating.Mutating)
+	assert.False(t, readOnly.Mutating)
+
+	t.Run("read-only annotation", func(t *testing.T) {
+		assert.Panics(t, func() {
+			MustMutatingTool("mutating_tool", "A mutating tool", testToolHandler, mcp.WithReadOnlyHintAnnotation(true))
+		})
+	})
+
+	t.Run("skipped in read-only mode", func(t *testing.T) {
+		s := server.NewMCPServer("test", "1.0.0")
+		SetReadOnly(s, true)
+		defer SetReadOnly(s, false)
+		mutating.Register(s)
+		readOnly.Register(s)
+		assert.NotContains(t, s.ListTools(), "mutating_tool")
+		assert.Contains(t, s.ListTools(), "read_only_tool")
+	})
+
+	t.Run("registered otherwise", func(t *testing.T) {
+		s := server.NewMCPServer("test", "1.0.0")
+		mutating.Register(s)
+		assert.Contains(t, s.ListTools(), "mutating_tool")
+	})
+}